@@ -359,6 +359,32 @@ type CompletedAspect interface {
 	Completed(ctx RuleContext, msg RuleMsg) RuleMsg
 }
 
+// MsgExpiredAspect defines the interface for aspects executed when a message expired before a node ran.
+// These aspects are called in rule_context.go tellNext() method instead of executing the node.
+//
+// MsgExpiredAspect 定义在节点执行前消息已过期时执行的切面接口。
+// 这些切面在 rule_context.go tellNext() 方法中代替节点执行调用。
+type MsgExpiredAspect interface {
+	NodeAspect
+
+	// MsgExpired is executed when an expired message reaches a node.
+	// The node is not executed; the message is routed through the Expired relation
+	// of the node, or ends the branch if the node has no such relation. The expiry time is
+	// cleared before routing, so the whole expiry handling branch runs normally.
+	//
+	// MsgExpired 在过期消息到达节点时执行。
+	// 节点不会被执行；消息通过节点的 Expired 关系路由，如果节点没有该关系则结束分支。
+	// 路由前清除过期时间，整个过期处理分支正常执行。
+	//
+	// Parameters:
+	// 参数：
+	//   - ctx: Rule execution context of the node that was skipped
+	//     ctx：被跳过节点的规则执行上下文
+	//   - msg: The expired message
+	//     msg：过期的消息
+	MsgExpired(ctx RuleContext, msg RuleMsg)
+}
+
 // OnChainBeforeInitAspect defines the interface for aspects executed before rule chain initialization.
 // These aspects are called in engine.go initChain() method before the rule chain is created.
 //
@@ -0,0 +1,40 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+// DefaultBlobSpillThreshold is the payload size in bytes above which message data is spilled
+// to the BlobStore when Config.BlobSpillThreshold is not set.
+// DefaultBlobSpillThreshold 未设置 Config.BlobSpillThreshold 时，消息负载超过该字节数则溢出到 BlobStore。
+const DefaultBlobSpillThreshold = 1 << 20
+
+// BlobStore stores large message payloads outside of process memory.
+// Only the returned handle travels with the message; the payload is loaded lazily when read.
+// Implementations must be thread safe.
+//
+// BlobStore 用于在进程内存之外存储大消息负载。
+// 消息中只保存返回的句柄，读取时才延迟加载负载。实现必须是线程安全的。
+type BlobStore interface {
+	// Put stores the payload and returns a handle that identifies it.
+	// Put 存储负载并返回标识它的句柄。
+	Put(data []byte) (string, error)
+	// Get returns the payload identified by handle.
+	// Get 返回句柄对应的负载。
+	Get(handle string) ([]byte, error)
+	// Delete removes the payload identified by handle. Deleting a missing handle is not an error.
+	// Delete 删除句柄对应的负载，删除不存在的句柄不返回错误。
+	Delete(handle string) error
+}
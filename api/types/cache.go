@@ -45,3 +45,38 @@ type Cache interface {
 	//   - map[string]interface{}: map of matching key-value pairs
 	GetByPrefix(prefix string) map[string]interface{}
 }
+
+// AtomicCache is an optional extension of Cache that provides atomic operations.
+// It is used by components that must not race each other, for example counting events
+// per device or taking a lock. Check for it with a type assertion:
+//
+//	if ac, ok := cache.(AtomicCache); ok {
+//		count, err := ac.Incr("device:1:count", 1, "1m")
+//	}
+//
+// AtomicCache 是 Cache 的可选扩展接口，提供原子操作，
+// 用于需要避免并发竞争的场景，例如按设备计数或者加锁。
+type AtomicCache interface {
+	Cache
+	// Incr atomically adds delta to the integer value of key and returns the new value
+	// Parameters:
+	//   - key: cache key (string)
+	//   - delta: value to add, can be negative (int64)
+	//   - ttl: time-to-live duration string, only applied when the key is created
+	// Returns:
+	//   - int64: value after the increment
+	//   - error: ErrCacheValueNotInteger if the stored value is not an integer, or if ttl format is invalid
+	// Note: A missing or expired key is treated as 0
+	Incr(key string, delta int64, ttl string) (int64, error)
+	// SetNX stores the key-value pair only if the key does not exist or has expired
+	// Returns:
+	//   - bool: true if the value was stored
+	//   - error: returns error if ttl format is invalid
+	SetNX(key string, value interface{}, ttl string) (bool, error)
+	// CompareAndSwap replaces the value of key with newValue only if the current value equals oldValue
+	// Values are compared by their string form, see str.ToString
+	// Returns:
+	//   - bool: true if the value was swapped, false if the key does not exist or the value does not match
+	//   - error: returns error if ttl format is invalid
+	CompareAndSwap(key string, oldValue, newValue interface{}, ttl string) (bool, error)
+}
@@ -242,13 +242,42 @@ type Config struct {
 	// 自定义示例 - Custom Examples:
 	//
 	//	// 使用Redis缓存 - Using Redis cache
-	//	redisCache := &MyRedisCache{client: redisClient}
+	//	redisCache := cache.NewRedisCache(redisClient)
 	//	config := NewConfig(WithCache(redisCache))
 	//
 	//	// 使用内存缓存（自定义GC间隔）- Using memory cache (custom GC interval)
 	//	memCache := cache.NewMemoryCache(time.Minute * 10)
 	//	config := NewConfig(WithCache(memCache))
+	//
+	//	// 使用有界、持久化的内存缓存 - Using bounded, persistent memory cache
+	//	memCache := cache.NewMemoryCache(time.Minute, cache.WithMaxEntries(100000),
+	//		cache.WithSnapshot("./data/cache.json", time.Minute))
+	//	config := NewConfig(WithCache(memCache))
 	Cache Cache
+	// BlobStore stores message payloads that exceed BlobSpillThreshold. If nil, payloads always stay in memory.
+	// Spilled payloads are loaded lazily when read and deleted once the rule chain run completes.
+	// BlobStore 存储超过 BlobSpillThreshold 的消息负载，为 nil 时负载始终保存在内存中。
+	// 溢出的负载在读取时延迟加载，并在规则链运行结束后删除。
+	//
+	// Example:
+	// 示例：
+	//
+	//	store, _ := blob.NewFileStore("")
+	//	config := NewConfig(WithBlobStore(store, 4<<20))
+	BlobStore BlobStore
+	// BlobSpillThreshold is the payload size in bytes above which data is spilled to BlobStore.
+	// If not greater than 0, DefaultBlobSpillThreshold is used.
+	// BlobSpillThreshold 是负载溢出到 BlobStore 的字节数阈值，不大于0时使用 DefaultBlobSpillThreshold。
+	BlobSpillThreshold int
+}
+
+// GetBlobSpillThreshold returns the effective payload size above which data is spilled to BlobStore.
+// GetBlobSpillThreshold 返回负载溢出到 BlobStore 的有效阈值。
+func (c *Config) GetBlobSpillThreshold() int {
+	if c.BlobSpillThreshold > 0 {
+		return c.BlobSpillThreshold
+	}
+	return DefaultBlobSpillThreshold
 }
 
 // RegisterUdf registers a custom function. Function names can be repeated for different script types.
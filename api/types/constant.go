@@ -54,6 +54,10 @@ var (
 	// ErrConcurrencyLimitReached is the error returned when the concurrency limit has been reached
 	ErrConcurrencyLimitReached = errors.New("concurrency limit reached")
 	ErrCacheNotInitialized     = errors.New("cache not initialized")
+	// ErrCacheAtomicNotSupported is returned when the cache does not implement AtomicCache
+	ErrCacheAtomicNotSupported = errors.New("cache does not support atomic operations")
+	// ErrCacheValueNotInteger is returned by Incr when the stored value is not an integer
+	ErrCacheValueNotInteger = errors.New("cache value is not an integer")
 	// ErrEngineShuttingDown is the error returned when the engine is shutting down and cannot accept new messages
 	ErrEngineShuttingDown = errors.New("engine is shutting down")
 	// ErrEngineNotInitialized is the error returned when the rule engine is not initialized
@@ -69,4 +73,8 @@ var (
 	ErrEngineDisabled = errors.New("the rule chain has been disabled")
 	// ErrEngineDslEmpty is returned when the rule chain dsl is empty.
 	ErrEngineDslEmpty = errors.New("dsl can not empty")
+	// ErrMsgExpired is returned when a message expired before a node could process it.
+	ErrMsgExpired = errors.New("message expired")
+	// ErrStreamConsumed is returned when a message stream has already been consumed by another node.
+	ErrStreamConsumed = errors.New("message stream already consumed")
 )
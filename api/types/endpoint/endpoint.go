@@ -115,6 +115,52 @@ const (
 	EventCompletedServer = "completedServer"
 )
 
+// FromConfigKeyMsgTtl is the From configuration key for the time-to-live of the messages
+// created by the router, e.g. "30s". Expired messages are not processed by the rule engine.
+// FromConfigKeyMsgTtl 是路由创建的消息存活时间的 From 配置键，例如 "30s"。
+// 过期的消息不会被规则引擎处理。
+const FromConfigKeyMsgTtl = "msgTtl"
+
+// FromConfigKeyStreamBody is the From configuration key that passes the request body to the
+// rule chain as a message stream instead of reading it into memory, see types.RuleMsg.SetStream.
+// It is supported by endpoints whose request body stays readable while the rule chain runs,
+// e.g. the rest endpoint with a router that waits for the result.
+// FromConfigKeyStreamBody 是将请求体以消息流形式传给规则链、而不读取到内存的 From 配置键，
+// 参考 types.RuleMsg.SetStream。只有规则链运行期间请求体仍可读取的端点支持该配置，
+// 例如等待结果的 rest 端点路由。
+const FromConfigKeyStreamBody = "streamBody"
+
+// FromConfigKeyRequest is the From configuration key of the request contract: body schema,
+// required headers, typed query parameters and max body size. Requests that break the contract
+// are rejected with 400 before the rule chain runs. It is supported by the rest endpoint.
+// FromConfigKeyRequest 是请求约定的 From 配置键：请求体Schema、必需的请求头、带类型的查询参数和请求体最大长度。
+// 不符合约定的请求在规则链执行前返回400。rest 端点支持该配置。
+const FromConfigKeyRequest = "request"
+
+// FromConfigKeyResponse is the From configuration key of the response mapping, which sets
+// the status code and headers of the response from the output message metadata.
+// It is supported by the rest endpoint.
+// FromConfigKeyResponse 是响应映射的 From 配置键，根据输出消息元数据设置响应状态码和响应头。
+// rest 端点支持该配置。
+const FromConfigKeyResponse = "response"
+
+// FromConfigKeyClientId is the From configuration key of the client ID template, which registers
+// the live connection under that ID so rule chains can push to it later, e.g. "${deviceId}".
+// Variables are read from the message metadata after the router From processors accepted a message,
+// so connections rejected by authentication processors are never registered. Messages without the
+// variables keep the previous ID; the default is the remote address of the connection.
+// It is supported by the websocket and tcp net endpoints.
+// FromConfigKeyClientId 是客户端ID模板的 From 配置键，连接以该ID注册，规则链之后可以向其推送消息，例如 "${deviceId}"。
+// 消息通过路由 From 处理器后从消息元数据读取变量，被认证处理器拒绝的连接不会注册。
+// 没有变量的消息沿用之前的ID，默认为连接的远程地址。websocket 和 tcp net 端点支持该配置。
+const FromConfigKeyClientId = "clientId"
+
+// FromConfigKeyClientTags is the From configuration key of the client tag templates,
+// e.g. ["tenant/${tenant}"]. Connections with the same tag form a group that can be pushed together.
+// FromConfigKeyClientTags 是客户端标签模板的 From 配置键，例如 ["tenant/${tenant}"]。
+// 相同标签的连接组成分组，可以一起推送。
+const FromConfigKeyClientTags = "clientTags"
+
 // OnEvent is a callback function type for handling endpoint events.
 // It provides a flexible way to respond to various endpoint lifecycle and operational events.
 //
@@ -558,6 +604,20 @@ type Message interface {
 	GetError() error
 }
 
+// StreamWriter is an optional interface of response messages that can send the body
+// in several chunks without buffering it, e.g. the rest endpoint writing to the HTTP response.
+// Processors writing a streaming body check for it and fall back to a single SetBody
+// for endpoints that send each SetBody as a separate frame, publish or packet.
+//
+// StreamWriter 是响应消息的可选接口，实现该接口的响应可以分多次写入消息体而不在内存中缓冲，
+// 例如 rest 端点直接写入 HTTP 响应。写入流式消息体的处理器会检查该接口，
+// 对于每次 SetBody 都作为单独帧、发布或数据包发送的端点，退化为一次性调用 SetBody。
+type StreamWriter interface {
+	// WriteBody writes a chunk of the body to the client.
+	// WriteBody 把一块消息体写入客户端。
+	WriteBody(chunk []byte) error
+}
+
 // Exchange represents a complete message exchange containing both request and response.
 // It provides the context for processing a single message interaction through the endpoint system.
 //
@@ -26,6 +26,7 @@ type EngineMetrics struct {
 	Total   int64 // Total number of engine executions
 	Failed  int64 // Number of failed chains executions
 	Success int64 // Number of successful chains executions
+	Expired int64 // Number of messages dropped or rerouted because they expired
 }
 
 // NewEngineMetrics creates a new instance of EngineMetrics.
@@ -59,6 +60,11 @@ func (m *EngineMetrics) IncrementSuccess() {
 	atomic.AddInt64(&m.Success, 1)
 }
 
+// IncrementExpired increases the count of expired messages.
+func (m *EngineMetrics) IncrementExpired() {
+	atomic.AddInt64(&m.Expired, 1)
+}
+
 // Get returns a copy of the current metrics.
 func (m *EngineMetrics) Get() EngineMetrics {
 	return EngineMetrics{
@@ -66,6 +72,7 @@ func (m *EngineMetrics) Get() EngineMetrics {
 		Total:   atomic.LoadInt64(&m.Total),
 		Failed:  atomic.LoadInt64(&m.Failed),
 		Success: atomic.LoadInt64(&m.Success),
+		Expired: atomic.LoadInt64(&m.Expired),
 	}
 }
 
@@ -75,4 +82,5 @@ func (m *EngineMetrics) Reset() {
 	atomic.StoreInt64(&m.Total, 0)
 	atomic.StoreInt64(&m.Failed, 0)
 	atomic.StoreInt64(&m.Success, 0)
+	atomic.StoreInt64(&m.Expired, 0)
 }
@@ -18,6 +18,9 @@ package types
 
 import (
 	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -65,6 +68,12 @@ const (
 	MetadataKey = "metadata" // Key for the message metadata  消息元数据的键
 	MsgTypeKey  = "msgType"  // Key for the message type  消息类型的键
 	DataTypeKey = "dataType" // Key for the data type of the message  消息数据类型的键
+	// ParentIdKey is the key for the id of the message this message was derived from
+	// ParentIdKey 派生出当前消息的父消息ID的键
+	ParentIdKey = "parentId"
+	// CorrelationIdKey is the key for the id of the root message of the lineage
+	// CorrelationIdKey 消息血缘根消息ID的键
+	CorrelationIdKey = "correlationId"
 )
 
 // Properties is a simple map type for storing key-value pairs as metadata.
@@ -168,11 +177,18 @@ func (md Properties) Values() map[string]string {
 //   - Thread-safe operations with mutex protection  使用互斥锁保护的线程安全操作
 //   - Efficient sharing between multiple rule nodes  多个规则节点间的高效共享
 //   - JSON marshaling/unmarshaling support  JSON 序列化/反序列化支持
+//   - Typed scalar values (int64, float64, bool, time.Time) alongside the string API  在字符串API之外支持类型化标量值
 type Metadata struct {
 	// data holds the actual metadata key-value pairs
 	// data 保存实际的元数据键值对
 	data map[string]string
 
+	// typed holds the native values of keys set through the typed API.
+	// data always holds the string form of the same keys, so the string API keeps working.
+	// typed 保存通过类型化API设置的键的原生值。
+	// data 始终保存相同键的字符串形式，因此字符串API保持兼容。
+	typed map[string]interface{}
+
 	// shared indicates if this metadata is shared with other instances
 	// shared 表示此元数据是否与其他实例共享
 	shared bool
@@ -211,7 +227,26 @@ func BuildMetadataFromMetadata(md *Metadata) *Metadata {
 	if md == nil || md.data == nil {
 		return NewMetadata()
 	}
-	return BuildMetadata(md.data)
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+	metadata := BuildMetadata(md.data)
+	if len(md.typed) > 0 {
+		metadata.typed = make(map[string]interface{}, len(md.typed))
+		for k, v := range md.typed {
+			metadata.typed[k] = v
+		}
+	}
+	return metadata
+}
+
+// BuildTypedMetadata creates a new instance of rule engine message metadata from a map of typed values.
+// Supported value types are string, integers, floats, bool and time.Time; other values are stored as strings.
+func BuildTypedMetadata(data map[string]interface{}) *Metadata {
+	metadata := NewMetadata()
+	for k, v := range data {
+		metadata.putTypedValue(k, v)
+	}
+	return metadata
 }
 
 // Copy creates a copy of the metadata using Copy-on-Write optimization.
@@ -226,6 +261,7 @@ func (md *Metadata) Copy() *Metadata {
 	// Note: The new instance gets its own mutex (zero value is ready to use)
 	return &Metadata{
 		data:   md.data,
+		typed:  md.typed,
 		shared: true,
 		// mu is automatically initialized as zero value (ready to use)
 	}
@@ -236,6 +272,11 @@ func (md *Metadata) ensureUnique() {
 	md.mu.Lock()
 	defer md.mu.Unlock()
 
+	md.unshareLocked()
+}
+
+// unshareLocked copies the shared maps. The caller must hold the write lock.
+func (md *Metadata) unshareLocked() {
 	if md.shared {
 		// Create a new copy of the data
 		newData := make(map[string]string, len(md.data))
@@ -243,23 +284,79 @@ func (md *Metadata) ensureUnique() {
 			newData[k] = v
 		}
 		md.data = newData
+		if md.typed != nil {
+			newTyped := make(map[string]interface{}, len(md.typed))
+			for k, v := range md.typed {
+				newTyped[k] = v
+			}
+			md.typed = newTyped
+		}
 		md.shared = false
 	}
 }
 
-// MarshalJSON implements the json.Marshaler interface for Metadata
+// MarshalJSON implements the json.Marshaler interface for Metadata.
+// Typed values are written as JSON numbers and booleans; time values are written as RFC 3339 strings.
 func (md *Metadata) MarshalJSON() ([]byte, error) {
 	md.mu.RLock()
 	defer md.mu.RUnlock()
-	return json.Marshal(md.data)
+	if len(md.typed) == 0 {
+		return json.Marshal(md.data)
+	}
+	values := make(map[string]interface{}, len(md.data))
+	for k, v := range md.data {
+		values[k] = v
+	}
+	for k, v := range md.typed {
+		if t, ok := v.(time.Time); ok {
+			values[k] = t.Format(time.RFC3339Nano)
+		} else {
+			values[k] = v
+		}
+	}
+	return json.Marshal(values)
 }
 
-// UnmarshalJSON implements the json.Unmarshaler interface for Metadata
+// UnmarshalJSON implements the json.Unmarshaler interface for Metadata.
+// JSON numbers are restored as int64 or float64 and booleans as bool.
 func (md *Metadata) UnmarshalJSON(data []byte) error {
-	var m map[string]string
-	if err := json.Unmarshal(data, &m); err != nil {
+	var m map[string]interface{}
+	if err := json.UnmarshalUseNumber(data, &m); err != nil {
 		return err
 	}
+	newData := make(map[string]string, len(m))
+	var newTyped map[string]interface{}
+	for k, v := range m {
+		var typedValue interface{}
+		switch value := v.(type) {
+		case string:
+			newData[k] = value
+			continue
+		case nil:
+			newData[k] = ""
+			continue
+		case json.Number:
+			if i, err := value.Int64(); err == nil {
+				typedValue = i
+			} else if f, err := value.Float64(); err == nil {
+				typedValue = f
+			} else {
+				newData[k] = value.String()
+				continue
+			}
+		case bool:
+			typedValue = value
+		default:
+			// Objects and arrays are kept as their JSON text
+			newData[k] = str.ToString(value)
+			continue
+		}
+		if newTyped == nil {
+			newTyped = make(map[string]interface{})
+		}
+		newTyped[k] = typedValue
+		newData[k] = str.ToString(typedValue)
+	}
 	md.mu.Lock()
 	defer md.mu.Unlock()
 
@@ -269,7 +366,8 @@ func (md *Metadata) UnmarshalJSON(data []byte) error {
 		md.shared = false
 	}
 
-	md.data = m
+	md.data = newData
+	md.typed = newTyped
 	return nil
 }
 
@@ -299,17 +397,245 @@ func (md *Metadata) PutValue(key, value string) {
 	defer md.mu.Unlock()
 
 	// Ensure unique copy within the same lock
-	if md.shared {
-		// Create a new copy of the data
-		newData := make(map[string]string, len(md.data))
-		for k, v := range md.data {
-			newData[k] = v
+	md.unshareLocked()
+
+	md.data[key] = value
+	if md.typed != nil {
+		delete(md.typed, key)
+	}
+}
+
+// PutTypedValue sets a typed value in the metadata.
+// Integers are stored as int64, floats as float64, and bool and time.Time are kept as is.
+// Strings behave like PutValue, and any other value is stored as its string form.
+// The string form stays available through GetValue, Values and ForEach.
+func (md *Metadata) PutTypedValue(key string, value interface{}) {
+	if key == "" {
+		return
+	}
+	md.putTypedValue(key, value)
+}
+
+// PutInt sets an int64 value in the metadata.
+func (md *Metadata) PutInt(key string, value int64) {
+	md.PutTypedValue(key, value)
+}
+
+// PutFloat sets a float64 value in the metadata.
+func (md *Metadata) PutFloat(key string, value float64) {
+	md.PutTypedValue(key, value)
+}
+
+// PutBool sets a bool value in the metadata.
+func (md *Metadata) PutBool(key string, value bool) {
+	md.PutTypedValue(key, value)
+}
+
+// PutTime sets a time value in the metadata. Its string form is RFC 3339.
+func (md *Metadata) PutTime(key string, value time.Time) {
+	md.PutTypedValue(key, value)
+}
+
+func (md *Metadata) putTypedValue(key string, value interface{}) {
+	typedValue, strValue, ok := normalizeMetadataValue(value)
+	if !ok {
+		md.PutValue(key, strValue)
+		return
+	}
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.unshareLocked()
+	md.data[key] = strValue
+	if md.typed == nil {
+		md.typed = make(map[string]interface{})
+	}
+	md.typed[key] = typedValue
+}
+
+// GetTypedValue retrieves the native value of a key.
+// Keys set through the string API are returned as string.
+func (md *Metadata) GetTypedValue(key string) (interface{}, bool) {
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+	if v, ok := md.typed[key]; ok {
+		return v, true
+	}
+	v, ok := md.data[key]
+	return v, ok
+}
+
+// GetInt returns the value of a key as int64, parsing the string form if necessary.
+func (md *Metadata) GetInt(key string) (int64, bool) {
+	v, ok := md.GetTypedValue(key)
+	if !ok {
+		return 0, false
+	}
+	switch value := v.(type) {
+	case int64:
+		return value, true
+	case float64:
+		return int64(value), true
+	case string:
+		i, err := strconv.ParseInt(value, 10, 64)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// GetFloat returns the value of a key as float64, parsing the string form if necessary.
+func (md *Metadata) GetFloat(key string) (float64, bool) {
+	v, ok := md.GetTypedValue(key)
+	if !ok {
+		return 0, false
+	}
+	switch value := v.(type) {
+	case float64:
+		return value, true
+	case int64:
+		return float64(value), true
+	case string:
+		f, err := strconv.ParseFloat(value, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// GetBool returns the value of a key as bool, parsing the string form if necessary.
+func (md *Metadata) GetBool(key string) (bool, bool) {
+	v, ok := md.GetTypedValue(key)
+	if !ok {
+		return false, false
+	}
+	switch value := v.(type) {
+	case bool:
+		return value, true
+	case string:
+		b, err := strconv.ParseBool(value)
+		return b, err == nil
+	default:
+		return false, false
+	}
+}
+
+// GetTime returns the value of a key as time.Time, parsing an RFC 3339 string form if necessary.
+func (md *Metadata) GetTime(key string) (time.Time, bool) {
+	v, ok := md.GetTypedValue(key)
+	if !ok {
+		return time.Time{}, false
+	}
+	switch value := v.(type) {
+	case time.Time:
+		return value, true
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		return t, err == nil
+	default:
+		return time.Time{}, false
+	}
+}
+
+// HasTypedValues reports whether any value was set through the typed API.
+func (md *Metadata) HasTypedValues() bool {
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+	return len(md.typed) > 0
+}
+
+// TypedValues returns all key-value pairs with their native values.
+// Keys set through the string API are returned as string.
+func (md *Metadata) TypedValues() map[string]interface{} {
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+	result := make(map[string]interface{}, len(md.data))
+	for k, v := range md.data {
+		result[k] = v
+	}
+	for k, v := range md.typed {
+		result[k] = v
+	}
+	return result
+}
+
+// ForEachTyped iterates over all key-value pairs with their native values.
+// The iteration will stop early if the callback function returns false.
+func (md *Metadata) ForEachTyped(fn func(key string, value interface{}) bool) {
+	md.mu.RLock()
+	defer md.mu.RUnlock()
+	for k, v := range md.data {
+		var value interface{} = v
+		if typedValue, ok := md.typed[k]; ok {
+			value = typedValue
+		}
+		if !fn(k, value) {
+			break
 		}
-		md.data = newData
-		md.shared = false
 	}
+}
 
-	md.data[key] = value
+// ReplaceAllTyped replaces all metadata with new typed data.
+// Values are normalized the same way as PutTypedValue.
+func (md *Metadata) ReplaceAllTyped(newData map[string]interface{}) {
+	data := make(map[string]string, len(newData))
+	var typed map[string]interface{}
+	for k, v := range newData {
+		typedValue, strValue, ok := normalizeMetadataValue(v)
+		data[k] = strValue
+		if ok {
+			if typed == nil {
+				typed = make(map[string]interface{})
+			}
+			typed[k] = typedValue
+		}
+	}
+	md.mu.Lock()
+	defer md.mu.Unlock()
+	md.shared = false
+	md.data = data
+	md.typed = typed
+}
+
+// normalizeMetadataValue converts a value to its typed metadata form and string form.
+// ok is false if the value is stored as a plain string.
+func normalizeMetadataValue(value interface{}) (typedValue interface{}, strValue string, ok bool) {
+	switch v := value.(type) {
+	case string:
+		return nil, v, false
+	case nil:
+		return nil, "", false
+	case bool:
+		return v, strconv.FormatBool(v), true
+	case int:
+		typedValue = int64(v)
+	case int8:
+		typedValue = int64(v)
+	case int16:
+		typedValue = int64(v)
+	case int32:
+		typedValue = int64(v)
+	case int64:
+		typedValue = v
+	case uint:
+		typedValue = int64(v)
+	case uint8:
+		typedValue = int64(v)
+	case uint16:
+		typedValue = int64(v)
+	case uint32:
+		typedValue = int64(v)
+	case uint64:
+		typedValue = int64(v)
+	case float32:
+		typedValue = float64(v)
+	case float64:
+		typedValue = v
+	case time.Time:
+		return v, v.Format(time.RFC3339Nano), true
+	default:
+		return nil, str.ToString(v), false
+	}
+	return typedValue, str.ToString(typedValue), true
 }
 
 // Values returns all key-value pairs in the metadata.
@@ -381,6 +707,7 @@ func (md *Metadata) ReplaceAll(newData map[string]string) {
 	for k, v := range newData {
 		md.data[k] = v
 	}
+	md.typed = nil
 }
 
 // Clear clears all metadata.
@@ -396,6 +723,7 @@ func (md *Metadata) Clear() {
 
 	// Create new empty data map
 	md.data = make(map[string]string)
+	md.typed = nil
 }
 
 // Len returns the number of key-value pairs in the metadata.
@@ -478,6 +806,31 @@ type RuleMsg struct {
 	// Metadata 包含与消息相关的附加键值对。
 	// 此字段使用写时复制优化，在多节点场景下提供更好的性能。
 	Metadata *Metadata `json:"metadata"`
+
+	// ParentId is the Id of the message this message was derived from by a fan-out
+	// (fork, for, iterator or sub-chain). It is empty for root messages.
+	// ParentId 是派生出当前消息的父消息 Id（通过 fork、for、iterator 或子规则链扇出）。
+	// 根消息的 ParentId 为空。
+	ParentId string `json:"parentId,omitempty"`
+
+	// CorrelationId is the Id of the root message that started the lineage.
+	// All messages derived from the same incoming event share the same CorrelationId,
+	// use it instead of Id to correlate the outputs of fan-out branches.
+	// CorrelationId 是血缘链路中根消息的 Id。
+	// 由同一个输入事件派生出的所有消息共享相同的 CorrelationId，关联扇出分支的输出时应使用它而不是 Id。
+	CorrelationId string `json:"correlationId,omitempty"`
+
+	// ExpireAt is the expiry time of the message in milliseconds since Unix epoch.
+	// The engine does not run nodes for an expired message. 0 means the message never expires.
+	// ExpireAt 是消息的过期时间，自 Unix 纪元以来的毫秒数。
+	// 引擎不会为已过期的消息执行节点。0 表示消息永不过期。
+	ExpireAt int64 `json:"expireAt,omitempty"`
+
+	// stream is an optional streaming body that is consumed at most once, see SetStream.
+	// It is not serialized and is shared by copies of the message.
+	// stream 是可选的流式消息体，最多被消费一次，参考 SetStream。
+	// 它不会被序列化，并且消息副本共享同一个流。
+	stream *MsgStream
 }
 
 // NewMsg creates a new message instance and generates a message ID using UUID.
@@ -486,6 +839,14 @@ func NewMsg(ts int64, msgType string, dataType DataType, metaData *Metadata, dat
 	return newMsg(uuId.String(), ts, msgType, dataType, metaData, str.UnsafeBytesFromString(data))
 }
 
+// NewMsgWithTTL creates a new message instance that expires after the given ttl.
+// A ttl less than or equal to 0 means the message never expires.
+func NewMsgWithTTL(ts int64, msgType string, dataType DataType, metaData *Metadata, data string, ttl time.Duration) RuleMsg {
+	msg := NewMsg(ts, msgType, dataType, metaData, data)
+	msg.SetTTL(ttl)
+	return msg
+}
+
 // NewMsgFromBytes creates a new message instance from []byte data and generates a message ID using UUID.
 func NewMsgFromBytes(ts int64, msgType string, dataType DataType, metaData *Metadata, data []byte) RuleMsg {
 	uuId, _ := uuid.NewV4()
@@ -521,12 +882,13 @@ func newMsg(id string, ts int64, msgType string, dataType DataType, metaData *Me
 
 	// Create the message
 	msg := RuleMsg{
-		Ts:       ts,
-		Id:       id,
-		Type:     msgType,
-		DataType: dataType,
-		Data:     NewSharedDataFromBytesWithType(data, dataType),
-		Metadata: metadata,
+		Ts:            ts,
+		Id:            id,
+		Type:          msgType,
+		DataType:      dataType,
+		Data:          NewSharedDataFromBytesWithType(data, dataType),
+		Metadata:      metadata,
+		CorrelationId: id,
 	}
 
 	// Note: We cannot set the callback here because when the function returns,
@@ -586,6 +948,15 @@ func (m *RuleMsg) GetBytes() []byte {
 	return m.Data.GetBytes()
 }
 
+// GetBytesE is like GetBytes but returns the error if the payload was spilled to a BlobStore
+// and cannot be loaded, for example because it was already released or the store failed.
+func (m *RuleMsg) GetBytesE() ([]byte, error) {
+	if m.Data == nil {
+		return nil, nil
+	}
+	return m.Data.GetBytesE()
+}
+
 // GetSharedData returns the underlying SharedData instance.
 // This provides direct access to the SharedData for advanced operations.
 //
@@ -665,12 +1036,16 @@ func (m *RuleMsg) Copy() RuleMsg {
 	}
 
 	copiedMsg := RuleMsg{
-		Ts:       m.Ts,
-		Id:       m.Id,
-		Type:     m.Type,
-		DataType: m.DataType,
-		Data:     copiedData,
-		Metadata: copiedMetadata,
+		Ts:            m.Ts,
+		Id:            m.Id,
+		Type:          m.Type,
+		DataType:      m.DataType,
+		Data:          copiedData,
+		Metadata:      copiedMetadata,
+		ParentId:      m.ParentId,
+		CorrelationId: m.GetCorrelationId(),
+		ExpireAt:      m.ExpireAt,
+		stream:        m.stream,
 	}
 
 	// Note: Cannot set callback here for the same reason as in newMsg.
@@ -679,6 +1054,22 @@ func (m *RuleMsg) Copy() RuleMsg {
 	return copiedMsg
 }
 
+// CopyAsChild creates a copy of the message that is recorded as a child of this message.
+// The child gets a new Id, its ParentId is set to this message's Id and it inherits
+// the root CorrelationId. It is used wherever one input message fans out into several
+// output messages, so that the fan-out tree can be rebuilt from run logs.
+//
+// CopyAsChild 创建当前消息的子消息副本。
+// 子消息获得新的 Id，ParentId 设置为当前消息的 Id，并继承根 CorrelationId。
+// 用于一个输入消息扇出为多个输出消息的场景，以便从运行日志中重建扇出树。
+func (m *RuleMsg) CopyAsChild() RuleMsg {
+	child := m.Copy()
+	uuId, _ := uuid.NewV4()
+	child.Id = uuId.String()
+	child.ParentId = m.Id
+	return child
+}
+
 // GetTs returns the timestamp of the message.
 func (m *RuleMsg) GetTs() int64 {
 	return m.Ts
@@ -695,10 +1086,117 @@ func (m *RuleMsg) GetId() string {
 }
 
 // SetId sets the unique identifier of the message.
+// A root message keeps its CorrelationId in sync with its Id.
 func (m *RuleMsg) SetId(id string) {
+	if m.ParentId == "" && (m.CorrelationId == "" || m.CorrelationId == m.Id) {
+		m.CorrelationId = id
+	}
 	m.Id = id
 }
 
+// GetParentId returns the Id of the message this message was derived from.
+func (m *RuleMsg) GetParentId() string {
+	return m.ParentId
+}
+
+// SetParentId sets the Id of the message this message was derived from.
+func (m *RuleMsg) SetParentId(parentId string) {
+	m.ParentId = parentId
+}
+
+// GetCorrelationId returns the Id of the root message of the lineage.
+// If it is not set, the message is treated as a root and its own Id is returned.
+func (m *RuleMsg) GetCorrelationId() string {
+	if m.CorrelationId == "" {
+		return m.Id
+	}
+	return m.CorrelationId
+}
+
+// SetCorrelationId sets the Id of the root message of the lineage.
+func (m *RuleMsg) SetCorrelationId(correlationId string) {
+	m.CorrelationId = correlationId
+}
+
+// GetExpireAt returns the expiry time of the message in milliseconds, 0 if it never expires.
+func (m *RuleMsg) GetExpireAt() int64 {
+	return m.ExpireAt
+}
+
+// SetExpireAt sets the expiry time of the message in milliseconds. 0 means the message never expires.
+func (m *RuleMsg) SetExpireAt(expireAt int64) {
+	m.ExpireAt = expireAt
+}
+
+// SetTTL sets the message to expire after ttl from now.
+// A ttl less than or equal to 0 clears the expiry.
+func (m *RuleMsg) SetTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		m.ExpireAt = 0
+	} else {
+		m.ExpireAt = time.Now().Add(ttl).UnixMilli()
+	}
+}
+
+// IsExpired reports whether the message has expired.
+func (m *RuleMsg) IsExpired() bool {
+	return m.ExpireAt > 0 && time.Now().UnixMilli() >= m.ExpireAt
+}
+
+// SetStream attaches a streaming body to the message. size is the length of the body in bytes,
+// or -1 if unknown. A nil reader removes the stream.
+//
+// Streaming mode lets large bodies pass through the chain without being buffered.
+// Data is left untouched, so nodes that are not stream aware only see Data and Metadata.
+// The stream can be consumed only once, see MsgStream. Inside a rule engine run it must be
+// consumed by a node or the end callback of the run: the engine closes streams that are
+// still unconsumed when the run completes.
+func (m *RuleMsg) SetStream(r io.Reader, size int64) {
+	if r == nil {
+		m.stream = nil
+		return
+	}
+	m.stream = NewMsgStream(r, size)
+}
+
+// GetStream returns the streaming body of the message, or nil if there is none.
+func (m *RuleMsg) GetStream() *MsgStream {
+	return m.stream
+}
+
+// HasStream returns true if the message has a streaming body that has not been consumed yet.
+func (m *RuleMsg) HasStream() bool {
+	return m.stream != nil && !m.stream.Consumed()
+}
+
+// TakeStream takes over the streaming body. The caller must close the returned reader.
+// It returns ErrStreamConsumed if the message has no stream or it has already been consumed.
+func (m *RuleMsg) TakeStream() (io.ReadCloser, error) {
+	if m.stream == nil {
+		return nil, ErrStreamConsumed
+	}
+	return m.stream.Take()
+}
+
+// ReadStream consumes the streaming body and stores it in Data, for nodes that need the whole body.
+// It does nothing if the message has no unconsumed stream.
+func (m *RuleMsg) ReadStream() error {
+	if !m.HasStream() {
+		return nil
+	}
+	r, err := m.TakeStream()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.SetBytes(b)
+	return nil
+}
+
 // GetDataType returns the data type of the message.
 func (m *RuleMsg) GetDataType() DataType {
 	return m.DataType
@@ -771,6 +1269,20 @@ type SharedData struct {
 	// dataVersion tracks the version of the data to prevent caching stale parsed results
 	// This version is incremented every time the data is modified
 	dataVersion int64
+	// blob references the payload stored in a BlobStore after Spill.
+	// While data is nil the payload has not been loaded yet and is read lazily from the store.
+	// Modifying the data drops the reference.
+	blob *blobRef
+	// loadErr is the error of the last failed attempt to load the spilled payload
+	loadErr error
+}
+
+// blobRef identifies a payload that was spilled to a BlobStore.
+// It is immutable and shared among copies of the same SharedData.
+type blobRef struct {
+	store  BlobStore
+	handle string
+	size   int
 }
 
 // NewSharedData creates a new SharedData instance from string.
@@ -825,6 +1337,7 @@ func (sd *SharedData) Copy() *SharedData {
 	refCountPtr := sd.refCount
 	parsedData := sd.parsedData
 	dataVersion := sd.dataVersion
+	blob := sd.blob
 
 	// Increment reference count atomically while still holding the read lock
 	// This prevents ensureUnique() from replacing the refCount pointer between
@@ -842,6 +1355,7 @@ func (sd *SharedData) Copy() *SharedData {
 		refCount:    refCountPtr, // Share the same reference count pointer
 		parsedData:  parsedData,  // Share parsed cache for performance (protected by COW)
 		dataVersion: dataVersion, // Copy the data version to maintain consistency
+		blob:        blob,        // Share the spilled payload reference, it is immutable
 	}
 }
 
@@ -885,6 +1399,7 @@ func (sd *SharedData) ensureUnique() {
 
 // Get returns the data value as string using safe conversion.
 func (sd *SharedData) Get() string {
+	sd.loadQuietly()
 	sd.mu.RLock()
 	defer sd.mu.RUnlock()
 
@@ -897,6 +1412,7 @@ func (sd *SharedData) Get() string {
 
 // GetUnsafe returns the data as string using zero-copy conversion.
 func (sd *SharedData) GetUnsafe() string {
+	sd.loadQuietly()
 	sd.mu.RLock()
 	defer sd.mu.RUnlock()
 
@@ -912,7 +1428,10 @@ func (sd *SharedData) GetUnsafe() string {
 // IMPORTANT: The returned []byte slice shares memory with the internal data and
 // MUST NOT be modified. Any modification will corrupt the shared data and may
 // cause data races. If you need to modify the data, use GetMutableBytes() instead.
+//
+// If the payload has been spilled to a BlobStore, it is loaded on first access.
 func (sd *SharedData) GetBytes() []byte {
+	sd.loadQuietly()
 	sd.mu.RLock()
 	defer sd.mu.RUnlock()
 	return sd.data
@@ -928,6 +1447,8 @@ func (sd *SharedData) GetBytes() []byte {
 //	data[0] = 'X' // Safe to modify
 //	sharedData.SetBytes(data) // Optional: set the modified data back
 func (sd *SharedData) GetMutableBytes() []byte {
+	// Load a spilled payload before copying it
+	sd.loadQuietly()
 	// First ensure we have a unique copy
 	sd.ensureUnique()
 
@@ -960,6 +1481,8 @@ func (sd *SharedData) Set(data string) {
 	sd.dataVersion++
 	// Clear parsed data cache since data has changed
 	sd.parsedData = nil
+	// The spilled payload no longer matches the data
+	sd.blob = nil
 
 	// Notify data change if callback is set
 	if sd.onDataChanged != nil {
@@ -982,6 +1505,8 @@ func (sd *SharedData) SetUnsafe(data string) {
 	sd.dataVersion++
 	// Clear parsed data cache since data has changed
 	sd.parsedData = nil
+	// The spilled payload no longer matches the data
+	sd.blob = nil
 
 	// Notify data change if callback is set
 	if sd.onDataChanged != nil {
@@ -1004,6 +1529,8 @@ func (sd *SharedData) SetBytes(data []byte) {
 	sd.dataVersion++
 	// Clear parsed data cache since data has changed
 	sd.parsedData = nil
+	// The spilled payload no longer matches the data
+	sd.blob = nil
 
 	// Notify data change if callback is set
 	if sd.onDataChanged != nil {
@@ -1012,17 +1539,19 @@ func (sd *SharedData) SetBytes(data []byte) {
 }
 
 // Len returns the length of the data.
+// A spilled payload is not loaded to compute its length.
 func (sd *SharedData) Len() int {
 	sd.mu.RLock()
 	defer sd.mu.RUnlock()
+	if sd.data == nil && sd.blob != nil {
+		return sd.blob.size
+	}
 	return len(sd.data)
 }
 
 // IsEmpty checks if the data is empty.
 func (sd *SharedData) IsEmpty() bool {
-	sd.mu.RLock()
-	defer sd.mu.RUnlock()
-	return len(sd.data) == 0
+	return sd.Len() == 0
 }
 
 // GetRefCount returns the current reference count (for debugging/testing).
@@ -1033,6 +1562,9 @@ func (sd *SharedData) GetRefCount() int64 {
 // MarshalJSON implements the json.Marshaler interface.
 // For binary data, it uses hex encoding to ensure data integrity and readability.
 func (sd *SharedData) MarshalJSON() ([]byte, error) {
+	if err := sd.Load(); err != nil {
+		return nil, err
+	}
 	sd.mu.RLock()
 	defer sd.mu.RUnlock()
 
@@ -1096,6 +1628,7 @@ func (sd *SharedData) UnmarshalJSON(data []byte) error {
 	sd.dataVersion++
 	// Clear parsed data cache since data has changed
 	sd.parsedData = nil
+	sd.blob = nil
 	return nil
 }
 
@@ -1234,6 +1767,9 @@ func isLikelyJSON(data []byte) bool {
 // Returns map[string]interface{} for JSON objects or []interface{} for JSON arrays.
 // This method is thread-safe and uses version-based validation to prevent caching stale data.
 func (sd *SharedData) GetJsonData() (interface{}, error) {
+	if err := sd.Load(); err != nil {
+		return nil, err
+	}
 	// First check if we have cached data (with read lock)
 	sd.mu.RLock()
 	if sd.parsedData != nil {
@@ -1277,3 +1813,104 @@ func (sd *SharedData) GetJsonData() (interface{}, error) {
 		return result, nil
 	}
 }
+
+// Spill writes the payload to store and releases the in-memory copy, so that only a handle
+// travels with the message. The payload is loaded again lazily on the next read.
+// If the payload is already held by a BlobStore, it is not written again and the existing handle is returned.
+// Empty payloads are not spilled and an empty handle is returned.
+//
+// The caller owns the returned handle and is responsible for deleting it from store once the
+// payload is no longer needed. The rule engine deletes handles it created when the rule chain run completes.
+func (sd *SharedData) Spill(store BlobStore) (string, error) {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+
+	if sd.blob == nil {
+		if len(sd.data) == 0 {
+			return "", nil
+		}
+		handle, err := store.Put(sd.data)
+		if err != nil {
+			return "", err
+		}
+		sd.blob = &blobRef{store: store, handle: handle, size: len(sd.data)}
+	}
+	// Release the in-memory payload and its parsed cache, other copies keep their own references
+	sd.data = nil
+	sd.parsedData = nil
+	return sd.blob.handle, nil
+}
+
+// Load reads a spilled payload back from its BlobStore. It does nothing if the payload is in memory.
+// Read methods such as GetBytes load lazily and return empty data if loading fails, the error is kept
+// and returned by LoadErr; call Load or GetBytesE first to handle the error explicitly.
+func (sd *SharedData) Load() error {
+	sd.mu.RLock()
+	ref := sd.blob
+	loaded := ref == nil || sd.data != nil
+	sd.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	// Read outside of the lock, the reference is immutable
+	data, err := ref.store.Get(ref.handle)
+
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	// Only keep the result if the data was not replaced while loading
+	if sd.blob == ref && sd.data == nil {
+		if err != nil {
+			sd.loadErr = fmt.Errorf("load spilled payload handle=%s: %w", ref.handle, err)
+			return sd.loadErr
+		}
+		sd.data = data
+		sd.loadErr = nil
+	}
+	return err
+}
+
+// loadQuietly loads a spilled payload for read methods that cannot return an error.
+// A failure is kept and returned by LoadErr.
+func (sd *SharedData) loadQuietly() {
+	_ = sd.Load()
+}
+
+// LoadErr returns the error of the last failed attempt to load the spilled payload,
+// or nil if the payload is in memory or was never read. Read methods such as GetBytes
+// return empty data in that case, the rule engine routes the message to the Failure
+// relation with this error when the node tells the next nodes.
+func (sd *SharedData) LoadErr() error {
+	sd.mu.RLock()
+	defer sd.mu.RUnlock()
+	if sd.blob == nil || sd.data != nil {
+		return nil
+	}
+	return sd.loadErr
+}
+
+// GetBytesE is like GetBytes but returns the error if the spilled payload cannot be loaded.
+func (sd *SharedData) GetBytesE() ([]byte, error) {
+	if err := sd.Load(); err != nil {
+		return nil, err
+	}
+	return sd.GetBytes(), nil
+}
+
+// IsSpilled returns true if the payload is held by a BlobStore and has not been loaded into memory.
+func (sd *SharedData) IsSpilled() bool {
+	sd.mu.RLock()
+	defer sd.mu.RUnlock()
+	return sd.blob != nil && sd.data == nil
+}
+
+// BlobHandle returns the handle of the payload in its BlobStore, or an empty string if it was never spilled
+// or has been modified since.
+func (sd *SharedData) BlobHandle() string {
+	sd.mu.RLock()
+	defer sd.mu.RUnlock()
+	if sd.blob == nil {
+		return ""
+	}
+	return sd.blob.handle
+}
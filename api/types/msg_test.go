@@ -104,6 +104,109 @@ func TestMetadataConcurrentAccess(t *testing.T) {
 	assert.Equal(t, 1, original.Len())
 }
 
+// TestTypedMetadata 测试类型化的元数据值
+func TestTypedMetadata(t *testing.T) {
+	now := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+	md := NewMetadata()
+	md.PutValue("name", "sensor")
+	md.PutInt("count", 12)
+	md.PutFloat("ratio", 0.5)
+	md.PutBool("alarm", true)
+	md.PutTime("at", now)
+	md.PutTypedValue("small", int8(3))
+
+	// 字符串API保持兼容
+	assert.Equal(t, "12", md.GetValue("count"))
+	assert.Equal(t, "0.5", md.GetValue("ratio"))
+	assert.Equal(t, "true", md.GetValue("alarm"))
+	assert.Equal(t, "2024-05-01T08:30:00Z", md.GetValue("at"))
+	assert.Equal(t, 6, len(md.Values()))
+
+	v, ok := md.GetTypedValue("count")
+	assert.True(t, ok)
+	assert.Equal(t, int64(12), v)
+	v, _ = md.GetTypedValue("small")
+	assert.Equal(t, int64(3), v)
+	v, _ = md.GetTypedValue("name")
+	assert.Equal(t, "sensor", v)
+	_, ok = md.GetTypedValue("notFound")
+	assert.False(t, ok)
+
+	i, ok := md.GetInt("count")
+	assert.True(t, ok)
+	assert.Equal(t, int64(12), i)
+	f, ok := md.GetFloat("ratio")
+	assert.True(t, ok)
+	assert.Equal(t, 0.5, f)
+	b, ok := md.GetBool("alarm")
+	assert.True(t, ok)
+	assert.True(t, b)
+	tm, ok := md.GetTime("at")
+	assert.True(t, ok)
+	assert.True(t, now.Equal(tm))
+	_, ok = md.GetInt("name")
+	assert.False(t, ok)
+
+	// 字符串形式的值也可以按类型读取
+	md.PutValue("level", "7")
+	i, ok = md.GetInt("level")
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), i)
+
+	// 使用字符串API覆盖后丢弃原生类型
+	md.PutValue("count", "13")
+	v, _ = md.GetTypedValue("count")
+	assert.Equal(t, "13", v)
+
+	// 写时复制同样作用于类型化的值
+	copied := md.Copy()
+	copied.PutInt("ratio", 2)
+	v, _ = md.GetTypedValue("ratio")
+	assert.Equal(t, 0.5, v)
+	v, _ = copied.GetTypedValue("ratio")
+	assert.Equal(t, int64(2), v)
+
+	// JSON序列化保留类型
+	data, err := json.Marshal(md)
+	assert.Nil(t, err)
+	var raw map[string]interface{}
+	assert.Nil(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, 0.5, raw["ratio"])
+	assert.Equal(t, true, raw["alarm"])
+	assert.Equal(t, "13", raw["count"])
+	assert.Equal(t, "2024-05-01T08:30:00Z", raw["at"])
+
+	decoded := NewMetadata()
+	assert.Nil(t, json.Unmarshal(data, decoded))
+	v, _ = decoded.GetTypedValue("ratio")
+	assert.Equal(t, 0.5, v)
+	v, _ = decoded.GetTypedValue("small")
+	assert.Equal(t, int64(3), v)
+	v, _ = decoded.GetTypedValue("alarm")
+	assert.Equal(t, true, v)
+	assert.Equal(t, "sensor", decoded.GetValue("name"))
+
+	// 旧格式的字符串JSON仍然可以反序列化
+	legacy := NewMetadata()
+	assert.Nil(t, json.Unmarshal([]byte(`{"a":"1","b":"x"}`), legacy))
+	assert.False(t, legacy.HasTypedValues())
+	assert.Equal(t, "1", legacy.GetValue("a"))
+
+	typedValues := md.TypedValues()
+	assert.Equal(t, true, typedValues["alarm"])
+	assert.Equal(t, "sensor", typedValues["name"])
+
+	md.ReplaceAllTyped(map[string]interface{}{"x": 1.5, "y": "z"})
+	assert.Equal(t, 2, md.Len())
+	assert.Equal(t, "1.5", md.GetValue("x"))
+	md.ReplaceAll(map[string]string{"x": "1"})
+	assert.False(t, md.HasTypedValues())
+
+	built := BuildTypedMetadata(map[string]interface{}{"n": 1, "s": "v"})
+	assert.True(t, built.HasTypedValues())
+	assert.True(t, BuildMetadataFromMetadata(built).HasTypedValues())
+}
+
 // TestRuleMsgOperations 测试RuleMsg的基本操作和复制机制
 func TestRuleMsgOperations(t *testing.T) {
 	// 基本操作
@@ -156,6 +259,85 @@ func TestRuleMsgOperations(t *testing.T) {
 	assert.Equal(t, "value", copiedMsg.Metadata.GetValue("test"))
 }
 
+// TestRuleMsgLineage 测试消息血缘：父消息ID和关联ID
+func TestRuleMsgLineage(t *testing.T) {
+	root := NewMsg(0, "TEST", JSON, nil, `{"temperature":41}`)
+	assert.Equal(t, "", root.ParentId)
+	assert.Equal(t, root.Id, root.CorrelationId)
+
+	// Copy 保持身份和血缘不变
+	copied := root.Copy()
+	assert.Equal(t, root.Id, copied.Id)
+	assert.Equal(t, "", copied.ParentId)
+	assert.Equal(t, root.Id, copied.CorrelationId)
+
+	// 子消息获得新ID，并记录父消息ID和根关联ID
+	child := root.CopyAsChild()
+	assert.True(t, child.Id != root.Id)
+	assert.Equal(t, root.Id, child.ParentId)
+	assert.Equal(t, root.Id, child.CorrelationId)
+	assert.Equal(t, root.GetData(), child.GetData())
+
+	grandChild := child.CopyAsChild()
+	assert.True(t, grandChild.Id != child.Id)
+	assert.Equal(t, child.Id, grandChild.ParentId)
+	assert.Equal(t, root.Id, grandChild.GetCorrelationId())
+
+	// 节点为派生消息设置新ID后，其子消息记录新的父消息ID
+	derived := child.CopyAsChild()
+	derived.SetId("derived-1")
+	assert.Equal(t, root.Id, derived.GetCorrelationId())
+	assert.Equal(t, "derived-1", derived.CopyAsChild().ParentId)
+
+	// 根消息修改ID时同步关联ID，子消息则保持不变
+	rootId := root.Id
+	root.SetId("root-1")
+	assert.Equal(t, "root-1", root.GetCorrelationId())
+	child.SetId("child-1")
+	assert.Equal(t, rootId, child.GetCorrelationId())
+
+	// 没有设置关联ID的消息视为根消息
+	legacy := RuleMsg{Id: "legacy"}
+	assert.Equal(t, "legacy", legacy.GetCorrelationId())
+	assert.Equal(t, "legacy", legacy.CopyAsChild().CorrelationId)
+
+	// 血缘字段参与序列化
+	data, err := json.Marshal(grandChild)
+	assert.Nil(t, err)
+	var decoded RuleMsg
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, grandChild.Id, decoded.Id)
+	assert.Equal(t, grandChild.ParentId, decoded.ParentId)
+	assert.Equal(t, grandChild.CorrelationId, decoded.CorrelationId)
+}
+
+// TestRuleMsgExpiry 测试消息过期时间
+func TestRuleMsgExpiry(t *testing.T) {
+	msg := NewMsg(0, "TEST", JSON, nil, "{}")
+	assert.Equal(t, int64(0), msg.GetExpireAt())
+	assert.False(t, msg.IsExpired())
+
+	msg = NewMsgWithTTL(0, "TEST", JSON, nil, "{}", time.Minute)
+	assert.True(t, msg.GetExpireAt() > time.Now().UnixMilli())
+	assert.False(t, msg.IsExpired())
+	assert.Equal(t, msg.GetExpireAt(), msg.Copy().ExpireAt)
+	assert.Equal(t, msg.GetExpireAt(), msg.CopyAsChild().ExpireAt)
+
+	msg.SetExpireAt(time.Now().Add(-time.Millisecond).UnixMilli())
+	assert.True(t, msg.IsExpired())
+
+	msg.SetTTL(0)
+	assert.Equal(t, int64(0), msg.GetExpireAt())
+	assert.False(t, msg.IsExpired())
+
+	msg.SetExpireAt(1234)
+	data, err := json.Marshal(msg)
+	assert.Nil(t, err)
+	var decoded RuleMsg
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, int64(1234), decoded.ExpireAt)
+}
+
 // TestSharedDataOperations 测试SharedData的核心功能
 func TestSharedDataOperations(t *testing.T) {
 	// 基本操作
@@ -1080,3 +1262,193 @@ func TestSharedDataJSONRoundTrip(t *testing.T) {
 		assert.Equal(t, BINARY, sharedData.dataType, "SharedData should infer BINARY type")
 	})
 }
+
+// memBlobStore 测试用的内存BlobStore
+type memBlobStore struct {
+	mu    sync.Mutex
+	seq   int
+	blobs map[string][]byte
+	gets  int
+}
+
+func newMemBlobStore() *memBlobStore {
+	return &memBlobStore{blobs: make(map[string][]byte)}
+}
+
+func (s *memBlobStore) Put(data []byte) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	handle := fmt.Sprintf("blob-%d", s.seq)
+	s.blobs[handle] = append([]byte(nil), data...)
+	return handle, nil
+}
+
+func (s *memBlobStore) Get(handle string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gets++
+	data, ok := s.blobs[handle]
+	if !ok {
+		return nil, fmt.Errorf("blob %s not found", handle)
+	}
+	return data, nil
+}
+
+func (s *memBlobStore) Delete(handle string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blobs, handle)
+	return nil
+}
+
+// TestSharedDataSpill 测试大负载溢出到BlobStore及延迟加载
+func TestSharedDataSpill(t *testing.T) {
+	payload := `{"name":"` + strings.Repeat("x", 1024) + `"}`
+
+	t.Run("SpillAndLoad", func(t *testing.T) {
+		store := newMemBlobStore()
+		sd := NewSharedDataWithType(payload, JSON)
+		handle, err := sd.Spill(store)
+		assert.Nil(t, err)
+		assert.True(t, handle != "")
+		assert.True(t, sd.IsSpilled())
+		assert.Equal(t, handle, sd.BlobHandle())
+		// Len不需要加载负载
+		assert.Equal(t, len(payload), sd.Len())
+		assert.False(t, sd.IsEmpty())
+		assert.Equal(t, 0, store.gets)
+
+		// 读取时延迟加载
+		assert.Equal(t, payload, string(sd.GetBytes()))
+		assert.False(t, sd.IsSpilled())
+		assert.Equal(t, 1, store.gets)
+		jsonData, err := sd.GetJsonData()
+		assert.Nil(t, err)
+		assert.Equal(t, strings.Repeat("x", 1024), jsonData.(map[string]interface{})["name"])
+		assert.Equal(t, 1, store.gets)
+
+		// 再次溢出不重复写入
+		again, err := sd.Spill(store)
+		assert.Nil(t, err)
+		assert.Equal(t, handle, again)
+		assert.Equal(t, 1, len(store.blobs))
+		assert.True(t, sd.IsSpilled())
+	})
+
+	t.Run("CopySharesHandle", func(t *testing.T) {
+		store := newMemBlobStore()
+		sd := NewSharedData(payload)
+		_, err := sd.Spill(store)
+		assert.Nil(t, err)
+
+		copied := sd.Copy()
+		assert.Equal(t, sd.BlobHandle(), copied.BlobHandle())
+		assert.Equal(t, payload, copied.Get())
+
+		// 修改副本不影响原数据，并丢弃句柄
+		copied.Set("changed")
+		assert.Equal(t, "", copied.BlobHandle())
+		assert.False(t, copied.IsSpilled())
+		assert.Equal(t, "changed", copied.Get())
+		assert.Equal(t, payload, sd.Get())
+		assert.Equal(t, 1, len(store.blobs))
+	})
+
+	t.Run("MutableBytes", func(t *testing.T) {
+		store := newMemBlobStore()
+		sd := NewSharedDataFromBytes([]byte(payload))
+		_, err := sd.Spill(store)
+		assert.Nil(t, err)
+		data := sd.GetMutableBytes()
+		assert.Equal(t, payload, string(data))
+	})
+
+	t.Run("MarshalJSON", func(t *testing.T) {
+		store := newMemBlobStore()
+		msg := NewMsg(0, "TEST", JSON, NewMetadata(), payload)
+		_, err := msg.Data.Spill(store)
+		assert.Nil(t, err)
+		b, err := json.Marshal(msg.Data)
+		assert.Nil(t, err)
+		var s string
+		assert.Nil(t, json.Unmarshal(b, &s))
+		assert.Equal(t, payload, s)
+	})
+
+	t.Run("EmptyNotSpilled", func(t *testing.T) {
+		store := newMemBlobStore()
+		handle, err := NewSharedData("").Spill(store)
+		assert.Nil(t, err)
+		assert.Equal(t, "", handle)
+		assert.Equal(t, 0, len(store.blobs))
+	})
+
+	t.Run("LoadError", func(t *testing.T) {
+		store := newMemBlobStore()
+		sd := NewSharedData(payload)
+		handle, err := sd.Spill(store)
+		assert.Nil(t, err)
+		_ = store.Delete(handle)
+		assert.NotNil(t, sd.Load())
+		assert.Equal(t, "", sd.Get())
+		_, err = sd.MarshalJSON()
+		assert.NotNil(t, err)
+		// 读取失败的错误被保留，GetBytesE直接返回错误
+		assert.NotNil(t, sd.LoadErr())
+		_, err = sd.GetBytesE()
+		assert.NotNil(t, err)
+		// 替换数据后不再有加载错误
+		sd.Set("changed")
+		assert.Nil(t, sd.LoadErr())
+		data, err := sd.GetBytesE()
+		assert.Nil(t, err)
+		assert.Equal(t, "changed", string(data))
+	})
+}
+
+// TestRuleMsgStream 测试流式消息体的单次消费语义
+func TestRuleMsgStream(t *testing.T) {
+	msg := NewMsg(0, "TEST", BINARY, NewMetadata(), "")
+	assert.False(t, msg.HasStream())
+	_, err := msg.TakeStream()
+	assert.Equal(t, ErrStreamConsumed, err)
+
+	msg.SetStream(strings.NewReader("streaming body"), 14)
+	assert.True(t, msg.HasStream())
+	assert.Equal(t, int64(14), msg.GetStream().Size())
+
+	// 副本共享同一个消息流，只有一个消费者
+	copied := msg.Copy()
+	child := msg.CopyAsChild()
+	assert.True(t, copied.HasStream())
+	reader, err := child.TakeStream()
+	assert.Nil(t, err)
+	b := make([]byte, 9)
+	_, _ = reader.Read(b)
+	assert.Nil(t, reader.Close())
+	assert.Equal(t, "streaming", string(b))
+	assert.False(t, msg.HasStream())
+	assert.False(t, copied.HasStream())
+	_, err = copied.TakeStream()
+	assert.Equal(t, ErrStreamConsumed, err)
+
+	// 不序列化消息流
+	out, err := json.Marshal(msg)
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(out), "stream"))
+
+	// ReadStream 把消息流读取到Data
+	msg.SetStream(strings.NewReader("whole body"), -1)
+	assert.Nil(t, msg.ReadStream())
+	assert.Equal(t, "whole body", msg.GetData())
+	assert.False(t, msg.HasStream())
+
+	// Close 关闭未消费的消息流
+	msg.SetStream(strings.NewReader("unused"), -1)
+	assert.Nil(t, msg.GetStream().Close())
+	assert.False(t, msg.HasStream())
+
+	msg.SetStream(nil, 0)
+	assert.Nil(t, msg.GetStream())
+}
@@ -310,3 +310,13 @@ func WithCache(cache Cache) Option {
 		return nil
 	}
 }
+
+// WithBlobStore is an option that spills message payloads larger than threshold bytes to store.
+// WithBlobStore 是将超过 threshold 字节的消息负载溢出到 store 的选项。
+func WithBlobStore(store BlobStore, threshold int) Option {
+	return func(c *Config) error {
+		c.BlobStore = store
+		c.BlobSpillThreshold = threshold
+		return nil
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"io"
+	"sync"
+)
+
+// MsgStream is a streaming message body backed by an io.Reader, used to move large
+// payloads such as uploads between endpoints and nodes without buffering them in memory.
+//
+// A MsgStream has single-consumer semantics: the first call to Take hands the reader
+// over to the caller, who becomes responsible for closing it, and all later calls
+// return ErrStreamConsumed. Copies of a message share the same MsgStream, so when a
+// message fans out only one branch can consume the body. The rule engine closes streams
+// that no node or end callback consumed once the rule chain run completes.
+//
+// MsgStream 是基于 io.Reader 的流式消息体，用于在端点和节点之间传递上传文件等大负载而不在内存中缓冲。
+//
+// MsgStream 只能被消费一次：第一次调用 Take 将 reader 交给调用方，由调用方负责关闭，
+// 之后的调用返回 ErrStreamConsumed。消息的副本共享同一个 MsgStream，
+// 因此消息扇出时只有一个分支可以消费该消息体。规则链运行结束时，规则引擎关闭没有被节点或结束回调消费的消息流。
+type MsgStream struct {
+	mu     sync.Mutex
+	reader io.ReadCloser
+	size   int64
+	taken  bool
+}
+
+// NewMsgStream creates a MsgStream. size is the length of the body in bytes, or -1 if unknown.
+// If r is not an io.ReadCloser, closing the stream is a no-op.
+// NewMsgStream 创建 MsgStream。size 是消息体的字节长度，未知时为 -1。
+func NewMsgStream(r io.Reader, size int64) *MsgStream {
+	rc, ok := r.(io.ReadCloser)
+	if !ok {
+		rc = io.NopCloser(r)
+	}
+	return &MsgStream{reader: rc, size: size}
+}
+
+// Take hands the reader over to the caller, who must close it.
+// It returns ErrStreamConsumed if the stream has already been taken or closed.
+// Take 将 reader 交给调用方，调用方必须关闭它。如果流已经被获取或关闭，返回 ErrStreamConsumed。
+func (s *MsgStream) Take() (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.taken {
+		return nil, ErrStreamConsumed
+	}
+	s.taken = true
+	return s.reader, nil
+}
+
+// Size returns the length of the body in bytes, or -1 if unknown.
+// Size 返回消息体的字节长度，未知时返回 -1。
+func (s *MsgStream) Size() int64 {
+	return s.size
+}
+
+// Consumed returns true if the stream has been taken or closed.
+// Consumed 返回流是否已经被获取或关闭。
+func (s *MsgStream) Consumed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.taken
+}
+
+// Close closes the underlying reader if it has not been taken yet.
+// Close 如果流还没有被获取，则关闭底层 reader。
+func (s *MsgStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.taken {
+		return nil
+	}
+	s.taken = true
+	return s.reader.Close()
+}
@@ -144,11 +144,14 @@ import (
 //     Failure：消息处理失败，路由到错误处理
 //   - True/False: Boolean logic routing for filter and condition nodes
 //     True/False：用于过滤器和条件节点的布尔逻辑路由
+//   - Expired: Message expired before the node ran, route to expiry handling
+//     Expired：消息在节点执行前已过期，路由到过期处理
 const (
 	Success = "Success"
 	Failure = "Failure"
 	True    = "True"
 	False   = "False"
+	Expired = "Expired"
 )
 
 // Flow direction types indicate the direction of message flow into and out of nodes.
@@ -159,6 +159,14 @@ func (a *MetricsAspect) Completed(ctx types.RuleContext, msg types.RuleMsg) type
 	return msg
 }
 
+// MsgExpired is called when an expired message reaches a node. It increments
+// the expired counter.
+//
+// MsgExpired 在过期消息到达节点时调用。它增加过期计数器。
+func (a *MetricsAspect) MsgExpired(ctx types.RuleContext, msg types.RuleMsg) {
+	a.metrics.IncrementExpired()
+}
+
 // GetMetrics returns the current metrics instance containing all collected
 // performance data. This allows external systems to monitor rule engine performance.
 //
@@ -0,0 +1,694 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package processor
+
+import (
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Authentication processor names. Each name is also the key of the processor configuration
+// in the router From configuration, so authentication can be configured per router:
+//
+// 认证处理器名称。名称同时也是路由 From 配置中对应处理器配置的键，因此每个路由可以单独配置认证：
+//
+//	{
+//	  "from": {
+//	    "path": "/api/v1/devices/:id",
+//	    "configuration": {
+//	      "jwtAuth": {"secret": "my-secret", "issuer": "rulego"}
+//	    },
+//	    "processors": ["jwtAuth"]
+//	  },
+//	  "to": {"path": "chain:device"}
+//	}
+const (
+	// ApiKeyAuth verifies an API key from a header or query parameter
+	// ApiKeyAuth 校验请求头或查询参数中的API key
+	ApiKeyAuth = "apiKeyAuth"
+	// BasicAuth verifies HTTP Basic credentials
+	// BasicAuth 校验HTTP Basic认证
+	BasicAuth = "basicAuth"
+	// HmacAuth verifies the HMAC signature of webhook requests
+	// HmacAuth 校验webhook请求的HMAC签名
+	HmacAuth = "hmacAuth"
+	// JwtAuth verifies JWT bearer tokens
+	// JwtAuth 校验JWT bearer令牌
+	JwtAuth = "jwtAuth"
+)
+
+// Metadata keys set by the authentication processors after a successful verification.
+// 认证成功后认证处理器设置的元数据键。
+const (
+	// KeyAuthType 认证方式：apiKey、basic、hmac、jwt
+	KeyAuthType = "authType"
+	// KeyAuthSubject 认证主体：API key的客户端名称、Basic用户名或者JWT的sub
+	KeyAuthSubject = "authSubject"
+	// DefaultClaimsPrefix JWT声明写入元数据时默认的键前缀
+	DefaultClaimsPrefix = "jwt_"
+)
+
+const (
+	headerKeyAuthorization   = "Authorization"
+	headerKeyWwwAuthenticate = "WWW-Authenticate"
+)
+
+// ApiKeyAuthConfig apiKeyAuth 处理器配置
+type ApiKeyAuthConfig struct {
+	// Header 读取API key的请求头，默认X-API-Key
+	Header string
+	// Query 读取API key的查询参数，为空则不从查询参数读取
+	Query string
+	// Keys 允许的API key，key为API key，value为客户端名称
+	Keys map[string]string
+}
+
+// BasicAuthConfig basicAuth 处理器配置
+type BasicAuthConfig struct {
+	// Realm 认证域，默认RuleGo
+	Realm string
+	// Credentials 用户凭证，key为用户名，value为密码或者bcrypt哈希值($2a$、$2b$、$2y$开头)
+	Credentials map[string]string
+}
+
+// HmacAuthConfig hmacAuth 处理器配置
+type HmacAuthConfig struct {
+	// Secret 签名密钥
+	Secret string
+	// Algorithm 哈希算法：sha1、sha256、sha512，默认sha256
+	Algorithm string
+	// Header 读取签名的请求头，默认X-Signature
+	Header string
+	// Prefix 签名前缀，例如：sha256=，校验前去掉
+	Prefix string
+	// Encoding 签名编码：hex、base64，默认hex
+	Encoding string
+	// TimestampHeader 读取时间戳(秒)的请求头，不为空时签名内容为 timestamp + "." + body，并校验时间偏差
+	TimestampHeader string
+	// MaxSkew 允许的最大时间偏差，单位秒，默认300
+	MaxSkew int
+}
+
+// JwtAuthConfig jwtAuth 处理器配置，Secret、PublicKeyFile 和 JwksFile 至少配置一个
+type JwtAuthConfig struct {
+	// Secret HS256/HS384/HS512 密钥
+	Secret string
+	// PublicKeyFile RS256/RS384/RS512 PEM格式公钥或者证书文件
+	PublicKeyFile string
+	// JwksFile RS256/RS384/RS512 JWKS文件，按令牌头部的kid选择公钥
+	JwksFile string
+	// ReloadInterval 检查公钥文件和JWKS文件修改时间的间隔，单位秒，文件修改后重新加载，
+	// 用于密钥轮换。默认60，为0则每次请求都检查，小于0不重新加载
+	ReloadInterval int
+	// Issuer 校验iss，为空不校验
+	Issuer string
+	// Audience 校验aud，为空不校验
+	Audience string
+	// Leeway 校验exp、nbf、iat时允许的时钟偏差，单位秒
+	Leeway int
+	// RequiredClaims 必须满足的声明，不满足返回403。声明为数组时包含该值即满足
+	RequiredClaims map[string]interface{}
+	// ClaimsPrefix 声明写入元数据的键前缀，默认jwt_
+	ClaimsPrefix string
+}
+
+// AuthError is returned by an authenticator when a request is rejected.
+// AuthError 认证器拒绝请求时返回的错误。
+type AuthError struct {
+	// StatusCode 响应状态码：401或403
+	StatusCode int
+	// Message 错误信息
+	Message string
+	// Challenge WWW-Authenticate 响应头
+	Challenge string
+}
+
+func (e *AuthError) Error() string {
+	return e.Message
+}
+
+// unauthorized 返回401错误
+func unauthorized(challenge, format string, args ...interface{}) *AuthError {
+	return &AuthError{StatusCode: http.StatusUnauthorized, Message: fmt.Sprintf(format, args...), Challenge: challenge}
+}
+
+// forbidden 返回403错误
+func forbidden(format string, args ...interface{}) *AuthError {
+	return &AuthError{StatusCode: http.StatusForbidden, Message: fmt.Sprintf(format, args...)}
+}
+
+// authenticator 认证器
+type authenticator interface {
+	// authenticate 认证请求，成功后把认证信息写入消息元数据
+	authenticate(exchange *endpoint.Exchange) *AuthError
+}
+
+// authCacheIdle 认证器超过该时间没有使用则从缓存中删除，例如路由已被删除
+const authCacheIdle = 10 * time.Minute
+
+// authKey 认证器缓存的键，每个路由的每种认证处理器单独缓存
+type authKey struct {
+	from endpoint.From
+	name string
+}
+
+// cachedAuthenticator 缓存的认证器
+type cachedAuthenticator struct {
+	// fingerprint 创建认证器的配置摘要，配置变化时重新创建
+	fingerprint [sha256.Size]byte
+	auth        authenticator
+	// lastUsed 最近使用时间，UnixNano
+	lastUsed int64
+}
+
+var (
+	// authenticators 认证器缓存，key为 authKey
+	authenticators sync.Map
+	// authSweepAt 下次清理空闲认证器的时间，UnixNano
+	authSweepAt int64
+)
+
+func init() {
+	InBuiltins.Register(ApiKeyAuth, newAuthProcessor(ApiKeyAuth, newApiKeyAuthenticator))
+	InBuiltins.Register(BasicAuth, newAuthProcessor(BasicAuth, newBasicAuthenticator))
+	InBuiltins.Register(HmacAuth, newAuthProcessor(HmacAuth, newHmacAuthenticator))
+	InBuiltins.Register(JwtAuth, newAuthProcessor(JwtAuth, newJwtAuthenticator))
+}
+
+// newAuthProcessor 创建认证处理器，从路由 From 配置中读取名称为 name 的配置创建认证器，
+// 认证失败通过 exchange.Out.SetStatusCode 返回401或403，并终止处理
+func newAuthProcessor(name string, factory func(configuration interface{}) (authenticator, error)) endpoint.Process {
+	return func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		auth, err := loadAuthenticator(router, name, factory)
+		if err != nil {
+			reject(exchange, &AuthError{StatusCode: http.StatusInternalServerError, Message: err.Error()})
+			return false
+		}
+		if authErr := auth.authenticate(exchange); authErr != nil {
+			reject(exchange, authErr)
+			return false
+		}
+		return true
+	}
+}
+
+// loadAuthenticator 获取路由的认证器。认证器按路由缓存，配置变化时重新创建，
+// 长时间没有使用的认证器从缓存中删除
+func loadAuthenticator(router endpoint.Router, name string, factory func(configuration interface{}) (authenticator, error)) (authenticator, error) {
+	var from endpoint.From
+	var configuration interface{}
+	if router != nil {
+		from = router.GetFrom()
+		if f, ok := from.(interface{ GetConfiguration() types.Configuration }); ok {
+			configuration = f.GetConfiguration()[name]
+		}
+	}
+	if configuration == nil {
+		return nil, fmt.Errorf("%s configuration not found in router from configuration", name)
+	}
+	data, err := json.Marshal(configuration)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UnixNano()
+	sweepAuthenticators(now)
+	key := authKey{from: from, name: name}
+	fingerprint := sha256.Sum256(data)
+	if v, ok := authenticators.Load(key); ok {
+		if cached := v.(*cachedAuthenticator); cached.fingerprint == fingerprint {
+			atomic.StoreInt64(&cached.lastUsed, now)
+			return cached.auth, nil
+		}
+	}
+	auth, err := factory(configuration)
+	if err != nil {
+		return nil, fmt.Errorf("%s configuration error: %w", name, err)
+	}
+	authenticators.Store(key, &cachedAuthenticator{fingerprint: fingerprint, auth: auth, lastUsed: now})
+	return auth, nil
+}
+
+// sweepAuthenticators 每隔 authCacheIdle 删除空闲的认证器
+func sweepAuthenticators(now int64) {
+	sweepAt := atomic.LoadInt64(&authSweepAt)
+	if now < sweepAt || !atomic.CompareAndSwapInt64(&authSweepAt, sweepAt, now+int64(authCacheIdle)) {
+		return
+	}
+	authenticators.Range(func(key, value interface{}) bool {
+		if now-atomic.LoadInt64(&value.(*cachedAuthenticator).lastUsed) > int64(authCacheIdle) {
+			authenticators.Delete(key)
+		}
+		return true
+	})
+}
+
+// reject 拒绝请求
+func reject(exchange *endpoint.Exchange, err *AuthError) {
+	if headers := exchange.Out.Headers(); headers != nil && err.Challenge != "" {
+		headers.Set(headerKeyWwwAuthenticate, err.Challenge)
+	}
+	exchange.Out.SetError(err)
+	exchange.Out.SetStatusCode(err.StatusCode)
+	exchange.Out.SetBody([]byte(err.Message))
+}
+
+// putAuthMetadata 认证成功后写入认证方式和主体
+func putAuthMetadata(exchange *endpoint.Exchange, authType, subject string) *types.Metadata {
+	msg := exchange.In.GetMsg()
+	msg.Metadata.PutValue(KeyAuthType, authType)
+	msg.Metadata.PutValue(KeyAuthSubject, subject)
+	return msg.Metadata
+}
+
+// getHeader 获取请求头
+func getHeader(exchange *endpoint.Exchange, key string) string {
+	if headers := exchange.In.Headers(); headers != nil {
+		return headers.Get(key)
+	}
+	return ""
+}
+
+// apiKeyAuthenticator API key认证
+type apiKeyAuthenticator struct {
+	config ApiKeyAuthConfig
+}
+
+func newApiKeyAuthenticator(configuration interface{}) (authenticator, error) {
+	auth := &apiKeyAuthenticator{config: ApiKeyAuthConfig{Header: "X-API-Key"}}
+	if err := maps.Map2Struct(configuration, &auth.config); err != nil {
+		return nil, err
+	}
+	if len(auth.config.Keys) == 0 {
+		return nil, errors.New("keys can not empty")
+	}
+	return auth, nil
+}
+
+func (a *apiKeyAuthenticator) authenticate(exchange *endpoint.Exchange) *AuthError {
+	var key string
+	if a.config.Header != "" {
+		key = getHeader(exchange, a.config.Header)
+	}
+	if key == "" && a.config.Query != "" {
+		key = exchange.In.GetParam(a.config.Query)
+	}
+	if key == "" {
+		return unauthorized("", "missing api key")
+	}
+	// 遍历所有key进行常量时间比较，避免时序攻击
+	var client string
+	var found bool
+	for item, name := range a.config.Keys {
+		if subtle.ConstantTimeCompare([]byte(item), []byte(key)) == 1 {
+			client, found = name, true
+		}
+	}
+	if !found {
+		return unauthorized("", "invalid api key")
+	}
+	putAuthMetadata(exchange, "apiKey", client)
+	return nil
+}
+
+// basicAuthenticator HTTP Basic认证
+type basicAuthenticator struct {
+	config BasicAuthConfig
+}
+
+func newBasicAuthenticator(configuration interface{}) (authenticator, error) {
+	auth := &basicAuthenticator{config: BasicAuthConfig{Realm: "RuleGo"}}
+	if err := maps.Map2Struct(configuration, &auth.config); err != nil {
+		return nil, err
+	}
+	if len(auth.config.Credentials) == 0 {
+		return nil, errors.New("credentials can not empty")
+	}
+	return auth, nil
+}
+
+func (a *basicAuthenticator) authenticate(exchange *endpoint.Exchange) *AuthError {
+	challenge := fmt.Sprintf(`Basic realm=%q`, a.config.Realm)
+	authorization := getHeader(exchange, headerKeyAuthorization)
+	const prefix = "Basic "
+	if len(authorization) < len(prefix) || !strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return unauthorized(challenge, "missing basic credentials")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(authorization[len(prefix):]))
+	if err != nil {
+		return unauthorized(challenge, "invalid basic credentials")
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return unauthorized(challenge, "invalid basic credentials")
+	}
+	expected, ok := a.config.Credentials[username]
+	if !ok || !checkPassword(expected, password) {
+		return unauthorized(challenge, "invalid username or password")
+	}
+	putAuthMetadata(exchange, "basic", username)
+	return nil
+}
+
+// checkPassword 校验密码，支持bcrypt哈希值
+func checkPassword(expected, password string) bool {
+	if strings.HasPrefix(expected, "$2a$") || strings.HasPrefix(expected, "$2b$") || strings.HasPrefix(expected, "$2y$") {
+		return bcrypt.CompareHashAndPassword([]byte(expected), []byte(password)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(password)) == 1
+}
+
+// hmacAuthenticator HMAC签名认证
+type hmacAuthenticator struct {
+	config  HmacAuthConfig
+	newHash func() hash.Hash
+}
+
+func newHmacAuthenticator(configuration interface{}) (authenticator, error) {
+	auth := &hmacAuthenticator{config: HmacAuthConfig{
+		Algorithm: "sha256",
+		Header:    "X-Signature",
+		Encoding:  "hex",
+		MaxSkew:   300,
+	}}
+	if err := maps.Map2Struct(configuration, &auth.config); err != nil {
+		return nil, err
+	}
+	if auth.config.Secret == "" {
+		return nil, errors.New("secret can not empty")
+	}
+	switch strings.ToLower(auth.config.Algorithm) {
+	case "sha1":
+		auth.newHash = sha1.New
+	case "sha256":
+		auth.newHash = sha256.New
+	case "sha512":
+		auth.newHash = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", auth.config.Algorithm)
+	}
+	switch auth.config.Encoding {
+	case "hex", "base64":
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %s", auth.config.Encoding)
+	}
+	return auth, nil
+}
+
+func (a *hmacAuthenticator) authenticate(exchange *endpoint.Exchange) *AuthError {
+	signature := strings.TrimSpace(getHeader(exchange, a.config.Header))
+	if signature == "" {
+		return unauthorized("", "missing signature")
+	}
+	signature = strings.TrimPrefix(signature, a.config.Prefix)
+	var actual []byte
+	var err error
+	if a.config.Encoding == "base64" {
+		actual, err = base64.StdEncoding.DecodeString(signature)
+	} else {
+		actual, err = hex.DecodeString(signature)
+	}
+	if err != nil {
+		return unauthorized("", "invalid signature")
+	}
+	mac := hmac.New(a.newHash, []byte(a.config.Secret))
+	if a.config.TimestampHeader != "" {
+		timestamp := getHeader(exchange, a.config.TimestampHeader)
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return unauthorized("", "invalid timestamp")
+		}
+		skew := time.Since(time.Unix(seconds, 0))
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > time.Duration(a.config.MaxSkew)*time.Second {
+			return unauthorized("", "timestamp out of range")
+		}
+		mac.Write([]byte(timestamp + "."))
+	}
+	mac.Write(exchange.In.Body())
+	if !hmac.Equal(mac.Sum(nil), actual) {
+		return unauthorized("", "invalid signature")
+	}
+	putAuthMetadata(exchange, "hmac", "")
+	return nil
+}
+
+// jwtAuthenticator JWT bearer令牌认证
+type jwtAuthenticator struct {
+	config JwtAuthConfig
+	parser *jwt.Parser
+	// keys 公钥文件和JWKS文件中的公钥，文件修改后重新加载
+	keys     jwtKeys
+	keysLock sync.RWMutex
+	// checkedAt 最近一次检查文件修改时间的时间
+	checkedAt time.Time
+}
+
+// jwtKeys 从文件加载的公钥
+type jwtKeys struct {
+	// publicKey PublicKeyFile 中的公钥
+	publicKey *rsa.PublicKey
+	// jwks JwksFile 中的公钥，key为kid
+	jwks map[string]*rsa.PublicKey
+	// publicKeyModTime 和 jwksModTime 加载时文件的修改时间
+	publicKeyModTime time.Time
+	jwksModTime      time.Time
+}
+
+func newJwtAuthenticator(configuration interface{}) (authenticator, error) {
+	auth := &jwtAuthenticator{config: JwtAuthConfig{ClaimsPrefix: DefaultClaimsPrefix, ReloadInterval: 60}}
+	if err := maps.Map2Struct(configuration, &auth.config); err != nil {
+		return nil, err
+	}
+	var methods []string
+	if auth.config.Secret != "" {
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	keys, err := loadJwtKeys(auth.config)
+	if err != nil {
+		return nil, err
+	}
+	auth.keys = keys
+	auth.checkedAt = time.Now()
+	if keys.publicKey != nil || len(keys.jwks) > 0 {
+		methods = append(methods, "RS256", "RS384", "RS512")
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("secret, publicKeyFile or jwksFile must be set")
+	}
+	opts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithIssuedAt()}
+	if auth.config.Leeway > 0 {
+		opts = append(opts, jwt.WithLeeway(time.Duration(auth.config.Leeway)*time.Second))
+	}
+	if auth.config.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(auth.config.Issuer))
+	}
+	if auth.config.Audience != "" {
+		opts = append(opts, jwt.WithAudience(auth.config.Audience))
+	}
+	auth.parser = jwt.NewParser(opts...)
+	return auth, nil
+}
+
+func (a *jwtAuthenticator) authenticate(exchange *endpoint.Exchange) *AuthError {
+	authorization := getHeader(exchange, headerKeyAuthorization)
+	const prefix = "Bearer "
+	if len(authorization) < len(prefix) || !strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return unauthorized("Bearer", "missing bearer token")
+	}
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(strings.TrimSpace(authorization[len(prefix):]), claims, a.keyFunc); err != nil {
+		return unauthorized(`Bearer error="invalid_token"`, "invalid token: %v", err)
+	}
+	for key, expected := range a.config.RequiredClaims {
+		if !claimMatches(claims[key], expected) {
+			return forbidden("claim %s does not match", key)
+		}
+	}
+	subject, _ := claims.GetSubject()
+	metadata := putAuthMetadata(exchange, "jwt", subject)
+	for key, value := range claims {
+		metadata.PutValue(a.config.ClaimsPrefix+key, str.ToString(value))
+	}
+	return nil
+}
+
+// keyFunc 根据签名算法和kid选择密钥
+func (a *jwtAuthenticator) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		return []byte(a.config.Secret), nil
+	case *jwt.SigningMethodRSA:
+		keys := a.currentKeys()
+		if kid, ok := token.Header["kid"].(string); ok && kid != "" {
+			if key, ok := keys.jwks[kid]; ok {
+				return key, nil
+			}
+		}
+		if keys.publicKey != nil {
+			return keys.publicKey, nil
+		}
+		// 令牌没有kid并且JWKS只有一个公钥时使用该公钥
+		if len(keys.jwks) == 1 {
+			for _, key := range keys.jwks {
+				return key, nil
+			}
+		}
+		return nil, errors.New("public key not found")
+	default:
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+}
+
+// currentKeys 返回当前的公钥，每隔 ReloadInterval 检查文件修改时间，文件修改后重新加载。
+// 重新加载失败时继续使用原有的公钥
+func (a *jwtAuthenticator) currentKeys() jwtKeys {
+	a.keysLock.RLock()
+	keys, checkedAt := a.keys, a.checkedAt
+	a.keysLock.RUnlock()
+	if a.config.ReloadInterval < 0 || (a.config.PublicKeyFile == "" && a.config.JwksFile == "") ||
+		time.Since(checkedAt) < time.Duration(a.config.ReloadInterval)*time.Second {
+		return keys
+	}
+	a.keysLock.Lock()
+	defer a.keysLock.Unlock()
+	if !a.checkedAt.Equal(checkedAt) {
+		// 其他请求已经检查过
+		return a.keys
+	}
+	a.checkedAt = time.Now()
+	if modTime(a.config.PublicKeyFile).Equal(a.keys.publicKeyModTime) && modTime(a.config.JwksFile).Equal(a.keys.jwksModTime) {
+		return a.keys
+	}
+	if keys, err := loadJwtKeys(a.config); err == nil {
+		a.keys = keys
+	}
+	return a.keys
+}
+
+// loadJwtKeys 加载公钥文件和JWKS文件中的公钥
+func loadJwtKeys(config JwtAuthConfig) (jwtKeys, error) {
+	var keys jwtKeys
+	if config.PublicKeyFile != "" {
+		keys.publicKeyModTime = modTime(config.PublicKeyFile)
+		data, err := os.ReadFile(config.PublicKeyFile)
+		if err != nil {
+			return keys, err
+		}
+		if keys.publicKey, err = jwt.ParseRSAPublicKeyFromPEM(data); err != nil {
+			return keys, err
+		}
+	}
+	if config.JwksFile != "" {
+		keys.jwksModTime = modTime(config.JwksFile)
+		jwks, err := loadJwks(config.JwksFile)
+		if err != nil {
+			return keys, err
+		}
+		keys.jwks = jwks
+	}
+	return keys, nil
+}
+
+// modTime 返回文件的修改时间，文件不存在或者路径为空返回零值
+func modTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// claimMatches 声明是否满足要求，声明为数组时包含该值即满足
+func claimMatches(actual, expected interface{}) bool {
+	if items, ok := actual.([]interface{}); ok {
+		for _, item := range items {
+			if claimMatches(item, expected) {
+				return true
+			}
+		}
+		return false
+	}
+	return actual != nil && str.ToString(actual) == str.ToString(expected)
+}
+
+// loadJwks 加载JWKS文件中的RSA公钥
+func loadJwks(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, item := range jwks.Keys {
+		if item.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(item.N, "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwk %s: %w", item.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(item.E, "="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwk %s: %w", item.Kid, err)
+		}
+		keys[item.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no RSA key found in jwks file")
+	}
+	return keys, nil
+}
@@ -0,0 +1,443 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package processor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/test/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testRequestMessage 请求消息
+type testRequestMessage struct {
+	headers textproto.MIMEHeader
+	params  map[string]string
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *testRequestMessage) Body() []byte {
+	return r.body
+}
+
+func (r *testRequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *testRequestMessage) From() string {
+	return ""
+}
+
+func (r *testRequestMessage) GetParam(key string) string {
+	return r.params[key]
+}
+
+func (r *testRequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *testRequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		ruleMsg := types.NewMsg(0, r.From(), types.JSON, types.NewMetadata(), string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *testRequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *testRequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+func (r *testRequestMessage) SetError(err error) {
+	r.err = err
+}
+
+func (r *testRequestMessage) GetError() error {
+	return r.err
+}
+
+// testResponseMessage 响应消息，记录状态码和 SetBody 调用次数
+type testResponseMessage struct {
+	body       []byte
+	msg        *types.RuleMsg
+	headers    textproto.MIMEHeader
+	statusCode int
+	err        error
+	setBodies  int
+}
+
+func (r *testResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *testResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *testResponseMessage) From() string {
+	return ""
+}
+
+func (r *testResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *testResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *testResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+func (r *testResponseMessage) SetStatusCode(statusCode int) {
+	r.statusCode = statusCode
+}
+
+func (r *testResponseMessage) SetBody(body []byte) {
+	r.body = body
+	r.setBodies++
+}
+
+func (r *testResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+func (r *testResponseMessage) GetError() error {
+	return r.err
+}
+
+// newAuthRouter 创建带有认证配置的路由
+func newAuthRouter(name string, configuration interface{}) endpoint.Router {
+	router := impl.NewRouter()
+	router.From("/api/v1/test", types.Configuration{name: configuration})
+	return router
+}
+
+// doAuth 执行认证处理器，返回处理结果、请求和响应
+func doAuth(router endpoint.Router, name string, request *testRequestMessage) (bool, *testRequestMessage, *testResponseMessage) {
+	response := &testResponseMessage{}
+	exchange := &endpoint.Exchange{In: request, Out: response}
+	process, _ := InBuiltins.Get(name)
+	return process(router, exchange), request, response
+}
+
+func TestApiKeyAuth(t *testing.T) {
+	router := newAuthRouter(ApiKeyAuth, map[string]interface{}{
+		"query": "apiKey",
+		"keys":  map[string]string{"key01": "client01", "key02": "client02"},
+	})
+	t.Run("Header", func(t *testing.T) {
+		request := &testRequestMessage{}
+		request.Headers().Set("X-API-Key", "key02")
+		ok, request, _ := doAuth(router, ApiKeyAuth, request)
+		assert.True(t, ok)
+		assert.Equal(t, "apiKey", request.GetMsg().Metadata.GetValue(KeyAuthType))
+		assert.Equal(t, "client02", request.GetMsg().Metadata.GetValue(KeyAuthSubject))
+	})
+	t.Run("Query", func(t *testing.T) {
+		ok, request, _ := doAuth(router, ApiKeyAuth, &testRequestMessage{params: map[string]string{"apiKey": "key01"}})
+		assert.True(t, ok)
+		assert.Equal(t, "client01", request.GetMsg().Metadata.GetValue(KeyAuthSubject))
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		request := &testRequestMessage{}
+		request.Headers().Set("X-API-Key", "key03")
+		ok, _, response := doAuth(router, ApiKeyAuth, request)
+		assert.False(t, ok)
+		assert.Equal(t, 401, response.statusCode)
+		assert.Equal(t, "invalid api key", string(response.Body()))
+
+		ok, _, response = doAuth(router, ApiKeyAuth, &testRequestMessage{})
+		assert.False(t, ok)
+		assert.Equal(t, 401, response.statusCode)
+		assert.Equal(t, "missing api key", response.GetError().Error())
+	})
+	t.Run("ConfigError", func(t *testing.T) {
+		ok, _, response := doAuth(impl.NewRouter().From("/api/v1/test").End(), ApiKeyAuth, &testRequestMessage{})
+		assert.False(t, ok)
+		assert.Equal(t, 500, response.statusCode)
+
+		ok, _, response = doAuth(newAuthRouter(ApiKeyAuth, map[string]interface{}{}), ApiKeyAuth, &testRequestMessage{})
+		assert.False(t, ok)
+		assert.Equal(t, 500, response.statusCode)
+		assert.Equal(t, "apiKeyAuth configuration error: keys can not empty", string(response.Body()))
+	})
+}
+
+func TestBasicAuth(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("pass02"), bcrypt.MinCost)
+	assert.Nil(t, err)
+	router := newAuthRouter(BasicAuth, map[string]interface{}{
+		"realm":       "test",
+		"credentials": map[string]string{"user01": "pass01", "user02": string(hashed)},
+	})
+	basic := func(username, password string) *testRequestMessage {
+		request := &testRequestMessage{}
+		request.Headers().Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+		return request
+	}
+	t.Run("Plain", func(t *testing.T) {
+		ok, request, _ := doAuth(router, BasicAuth, basic("user01", "pass01"))
+		assert.True(t, ok)
+		assert.Equal(t, "basic", request.GetMsg().Metadata.GetValue(KeyAuthType))
+		assert.Equal(t, "user01", request.GetMsg().Metadata.GetValue(KeyAuthSubject))
+	})
+	t.Run("Bcrypt", func(t *testing.T) {
+		ok, request, _ := doAuth(router, BasicAuth, basic("user02", "pass02"))
+		assert.True(t, ok)
+		assert.Equal(t, "user02", request.GetMsg().Metadata.GetValue(KeyAuthSubject))
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		for _, request := range []*testRequestMessage{basic("user01", "pass02"), basic("user03", "pass01"), {}} {
+			ok, _, response := doAuth(router, BasicAuth, request)
+			assert.False(t, ok)
+			assert.Equal(t, 401, response.statusCode)
+			assert.Equal(t, `Basic realm="test"`, response.Headers().Get("WWW-Authenticate"))
+		}
+	})
+}
+
+func TestHmacAuth(t *testing.T) {
+	body := []byte(`{"temperature":41}`)
+	sign := func(payload []byte) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(payload)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	t.Run("Signature", func(t *testing.T) {
+		router := newAuthRouter(HmacAuth, map[string]interface{}{"secret": "secret", "prefix": "sha256="})
+		request := &testRequestMessage{body: body}
+		request.Headers().Set("X-Signature", "sha256="+sign(body))
+		ok, request, _ := doAuth(router, HmacAuth, request)
+		assert.True(t, ok)
+		assert.Equal(t, "hmac", request.GetMsg().Metadata.GetValue(KeyAuthType))
+
+		request = &testRequestMessage{body: []byte(`{"temperature":42}`)}
+		request.Headers().Set("X-Signature", "sha256="+sign(body))
+		ok, _, response := doAuth(router, HmacAuth, request)
+		assert.False(t, ok)
+		assert.Equal(t, 401, response.statusCode)
+		assert.Equal(t, "invalid signature", string(response.Body()))
+	})
+	t.Run("Timestamp", func(t *testing.T) {
+		router := newAuthRouter(HmacAuth, map[string]interface{}{"secret": "secret", "timestampHeader": "X-Timestamp", "maxSkew": 60})
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		request := &testRequestMessage{body: body}
+		request.Headers().Set("X-Timestamp", timestamp)
+		request.Headers().Set("X-Signature", sign([]byte(timestamp+"."+string(body))))
+		ok, _, _ := doAuth(router, HmacAuth, request)
+		assert.True(t, ok)
+
+		expired := strconv.FormatInt(time.Now().Add(-2*time.Minute).Unix(), 10)
+		request = &testRequestMessage{body: body}
+		request.Headers().Set("X-Timestamp", expired)
+		request.Headers().Set("X-Signature", sign([]byte(expired+"."+string(body))))
+		ok, _, response := doAuth(router, HmacAuth, request)
+		assert.False(t, ok)
+		assert.Equal(t, "timestamp out of range", string(response.Body()))
+	})
+	t.Run("ConfigError", func(t *testing.T) {
+		ok, _, response := doAuth(newAuthRouter(HmacAuth, map[string]interface{}{"secret": "secret", "algorithm": "md5"}), HmacAuth, &testRequestMessage{})
+		assert.False(t, ok)
+		assert.Equal(t, 500, response.statusCode)
+		assert.Equal(t, "hmacAuth configuration error: unsupported algorithm: md5", string(response.Body()))
+	})
+}
+
+func TestJwtAuth(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	dir := t.TempDir()
+	publicKeyFile := filepath.Join(dir, "public.pem")
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(publicKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes}), 0644))
+	jwksFile := filepath.Join(dir, "jwks.json")
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"RSA","kid":"k1","n":"%s","e":"%s"}]}`,
+		base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()))
+	assert.Nil(t, os.WriteFile(jwksFile, []byte(jwks), 0644))
+
+	claims := jwt.MapClaims{
+		"sub":   "device01",
+		"iss":   "rulego",
+		"roles": []string{"admin", "operator"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+	bearer := func(token string) *testRequestMessage {
+		request := &testRequestMessage{}
+		request.Headers().Set("Authorization", "Bearer "+token)
+		return request
+	}
+	hsToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	assert.Nil(t, err)
+	rsToken, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(privateKey)
+	assert.Nil(t, err)
+	kidToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	kidToken.Header["kid"] = "k1"
+	jwksToken, err := kidToken.SignedString(privateKey)
+	assert.Nil(t, err)
+
+	t.Run("HS256", func(t *testing.T) {
+		router := newAuthRouter(JwtAuth, map[string]interface{}{"secret": "secret", "issuer": "rulego"})
+		ok, request, _ := doAuth(router, JwtAuth, bearer(hsToken))
+		assert.True(t, ok)
+		metadata := request.GetMsg().Metadata
+		assert.Equal(t, "jwt", metadata.GetValue(KeyAuthType))
+		assert.Equal(t, "device01", metadata.GetValue(KeyAuthSubject))
+		assert.Equal(t, "device01", metadata.GetValue("jwt_sub"))
+		assert.Equal(t, "rulego", metadata.GetValue("jwt_iss"))
+
+		// 签名密钥不一致
+		ok, _, response := doAuth(newAuthRouter(JwtAuth, map[string]interface{}{"secret": "other"}), JwtAuth, bearer(hsToken))
+		assert.False(t, ok)
+		assert.Equal(t, 401, response.statusCode)
+		assert.Equal(t, `Bearer error="invalid_token"`, response.Headers().Get("WWW-Authenticate"))
+		// 签发者不一致
+		ok, _, response = doAuth(newAuthRouter(JwtAuth, map[string]interface{}{"secret": "secret", "issuer": "other"}), JwtAuth, bearer(hsToken))
+		assert.False(t, ok)
+		assert.Equal(t, 401, response.statusCode)
+		// 缺少令牌
+		ok, _, response = doAuth(router, JwtAuth, &testRequestMessage{})
+		assert.False(t, ok)
+		assert.Equal(t, "Bearer", response.Headers().Get("WWW-Authenticate"))
+	})
+	t.Run("PublicKeyFile", func(t *testing.T) {
+		router := newAuthRouter(JwtAuth, map[string]interface{}{"publicKeyFile": publicKeyFile, "claimsPrefix": "token_"})
+		ok, request, _ := doAuth(router, JwtAuth, bearer(rsToken))
+		assert.True(t, ok)
+		assert.Equal(t, "device01", request.GetMsg().Metadata.GetValue("token_sub"))
+
+		// 只配置RS公钥时不接受HS令牌
+		ok, _, response := doAuth(router, JwtAuth, bearer(hsToken))
+		assert.False(t, ok)
+		assert.Equal(t, 401, response.statusCode)
+	})
+	t.Run("JwksFile", func(t *testing.T) {
+		router := newAuthRouter(JwtAuth, map[string]interface{}{"jwksFile": jwksFile})
+		ok, _, _ := doAuth(router, JwtAuth, bearer(jwksToken))
+		assert.True(t, ok)
+		ok, _, _ = doAuth(router, JwtAuth, bearer(rsToken))
+		assert.True(t, ok)
+	})
+	t.Run("KeyRotation", func(t *testing.T) {
+		rotatedKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.Nil(t, err)
+		rotatedToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		rotatedToken.Header["kid"] = "k2"
+		token, err := rotatedToken.SignedString(rotatedKey)
+		assert.Nil(t, err)
+		file := filepath.Join(dir, "rotated.json")
+		assert.Nil(t, os.WriteFile(file, []byte(jwks), 0644))
+		router := newAuthRouter(JwtAuth, map[string]interface{}{"jwksFile": file, "reloadInterval": 0})
+		ok, _, _ := doAuth(router, JwtAuth, bearer(token))
+		assert.False(t, ok)
+
+		// JWKS文件修改后重新加载
+		rotated := fmt.Sprintf(`{"keys":[{"kty":"RSA","kid":"k2","n":"%s","e":"%s"}]}`,
+			base64.RawURLEncoding.EncodeToString(rotatedKey.N.Bytes()),
+			base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rotatedKey.E)).Bytes()))
+		assert.Nil(t, os.WriteFile(file, []byte(rotated), 0644))
+		modified := time.Now().Add(time.Second)
+		assert.Nil(t, os.Chtimes(file, modified, modified))
+		ok, _, _ = doAuth(router, JwtAuth, bearer(token))
+		assert.True(t, ok)
+		ok, _, _ = doAuth(router, JwtAuth, bearer(jwksToken))
+		assert.False(t, ok)
+
+		// 文件损坏时继续使用原有的公钥
+		assert.Nil(t, os.WriteFile(file, []byte("{"), 0644))
+		modified = modified.Add(time.Second)
+		assert.Nil(t, os.Chtimes(file, modified, modified))
+		ok, _, _ = doAuth(router, JwtAuth, bearer(token))
+		assert.True(t, ok)
+	})
+	t.Run("RequiredClaims", func(t *testing.T) {
+		ok, _, _ := doAuth(newAuthRouter(JwtAuth, map[string]interface{}{"secret": "secret", "requiredClaims": map[string]interface{}{"roles": "admin"}}), JwtAuth, bearer(hsToken))
+		assert.True(t, ok)
+		ok, _, response := doAuth(newAuthRouter(JwtAuth, map[string]interface{}{"secret": "secret", "requiredClaims": map[string]interface{}{"roles": "root"}}), JwtAuth, bearer(hsToken))
+		assert.False(t, ok)
+		assert.Equal(t, 403, response.statusCode)
+		assert.Equal(t, "claim roles does not match", string(response.Body()))
+	})
+	t.Run("ConfigError", func(t *testing.T) {
+		ok, _, response := doAuth(newAuthRouter(JwtAuth, map[string]interface{}{"issuer": "rulego"}), JwtAuth, bearer(hsToken))
+		assert.False(t, ok)
+		assert.Equal(t, 500, response.statusCode)
+		ok, _, response = doAuth(newAuthRouter(JwtAuth, map[string]interface{}{"jwksFile": "notFound.json"}), JwtAuth, bearer(hsToken))
+		assert.False(t, ok)
+		assert.Equal(t, 500, response.statusCode)
+	})
+}
+
+func TestAuthenticatorCache(t *testing.T) {
+	request := func() *testRequestMessage {
+		request := &testRequestMessage{}
+		request.Headers().Set("X-API-Key", "k1")
+		return request
+	}
+	router := newAuthRouter(ApiKeyAuth, map[string]interface{}{"keys": map[string]string{"k0": "c0"}})
+	ok, _, _ := doAuth(router, ApiKeyAuth, request())
+	assert.False(t, ok)
+
+	// 配置变化时重新创建认证器
+	from := router.GetFrom().(interface{ GetConfiguration() types.Configuration })
+	from.GetConfiguration()[ApiKeyAuth] = map[string]interface{}{"keys": map[string]string{"k1": "c1"}}
+	ok, _, _ = doAuth(router, ApiKeyAuth, request())
+	assert.True(t, ok)
+
+	// 每个路由单独缓存，空闲的认证器被删除
+	key := authKey{from: router.GetFrom(), name: ApiKeyAuth}
+	_, ok = authenticators.Load(key)
+	assert.True(t, ok)
+	sweepAuthenticators(time.Now().Add(authCacheIdle * 2).UnixNano())
+	_, ok = authenticators.Load(key)
+	assert.False(t, ok)
+	ok, _, _ = doAuth(router, ApiKeyAuth, request())
+	assert.True(t, ok)
+}
@@ -57,6 +57,9 @@
 //   - toHex: Converts binary data to hexadecimal string representation
 //     toHex：将二进制数据转换为十六进制字符串表示
 //
+//   - apiKeyAuth, basicAuth, hmacAuth, jwtAuth: Authenticate requests, configured per router in From configuration
+//     apiKeyAuth、basicAuth、hmacAuth、jwtAuth：认证请求，在路由 From 配置中单独配置
+//
 // Available Output Processors:
 // 可用的输出处理器：
 //
@@ -117,6 +120,7 @@ package processor
 
 import (
 	"encoding/hex"
+	"io"
 	"strings"
 	"sync"
 
@@ -266,7 +270,15 @@ func init() {
 			if exchange.Out.GetMsg().DataType == types.JSON && exchange.Out.Headers().Get(HeaderKeyContentType) == "" {
 				exchange.Out.Headers().Set(HeaderKeyContentType, HeaderValueApplicationJson)
 			}
-			exchange.Out.SetBody([]byte(exchange.Out.GetMsg().GetData()))
+			if msg := exchange.Out.GetMsg(); msg.HasStream() {
+				// Write the streaming body in chunks without buffering it.
+				// 分块写入流式消息体，不在内存中缓冲。
+				if err := writeStreamBody(exchange.Out, msg); err != nil {
+					exchange.Out.SetError(err)
+				}
+			} else {
+				exchange.Out.SetBody([]byte(msg.GetData()))
+			}
 		}
 		return true
 	})
@@ -297,6 +309,45 @@ func init() {
 	})
 }
 
+// streamChunkSize is the size of the chunks a streaming body is written in.
+// streamChunkSize 是流式消息体分块写入的大小。
+const streamChunkSize = 32 * 1024
+
+// writeStreamBody consumes the message stream and writes it to out. Responses implementing
+// endpoint.StreamWriter receive the body chunk by chunk, other responses receive it in a single SetBody.
+// writeStreamBody 消费消息流并写入 out。实现 endpoint.StreamWriter 的响应分块写入，
+// 其他响应读取完整消息体后一次性调用 SetBody。
+func writeStreamBody(out endpoint.Message, msg *types.RuleMsg) error {
+	r, err := msg.TakeStream()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, ok := out.(endpoint.StreamWriter)
+	if !ok {
+		body, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		out.SetBody(body)
+		return nil
+	}
+	buf := make([]byte, streamChunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if werr := w.WriteBody(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
 // builtins is a thread-safe registry for processor functions that can be
 // registered and retrieved by name. It provides the foundation for both
 // InBuiltins and OutBuiltins collections.
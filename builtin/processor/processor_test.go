@@ -0,0 +1,63 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testStreamResponseMessage 支持分块写入的响应消息
+type testStreamResponseMessage struct {
+	testResponseMessage
+	chunks []string
+}
+
+func (r *testStreamResponseMessage) WriteBody(chunk []byte) error {
+	r.chunks = append(r.chunks, string(chunk))
+	return nil
+}
+
+func TestResponseToBodyStream(t *testing.T) {
+	responseToBody, ok := OutBuiltins.Get("responseToBody")
+	assert.True(t, ok)
+	payload := strings.Repeat("a", streamChunkSize*2+10)
+	newMsg := func() *types.RuleMsg {
+		msg := types.NewMsg(0, "TEST", types.BINARY, types.NewMetadata(), "")
+		msg.SetStream(strings.NewReader(payload), int64(len(payload)))
+		return &msg
+	}
+
+	//不支持分块写入的响应一次性写入完整消息体
+	response := &testResponseMessage{msg: newMsg()}
+	assert.True(t, responseToBody(nil, &endpoint.Exchange{In: &testRequestMessage{}, Out: response}))
+	assert.Nil(t, response.err)
+	assert.Equal(t, 1, response.setBodies)
+	assert.Equal(t, payload, string(response.body))
+
+	//支持分块写入的响应不缓冲消息体
+	streamResponse := &testStreamResponseMessage{testResponseMessage: testResponseMessage{msg: newMsg()}}
+	assert.True(t, responseToBody(nil, &endpoint.Exchange{In: &testRequestMessage{}, Out: streamResponse}))
+	assert.Nil(t, streamResponse.err)
+	assert.Equal(t, 0, streamResponse.setBodies)
+	assert.Equal(t, 3, len(streamResponse.chunks))
+	assert.Equal(t, payload, strings.Join(streamResponse.chunks, ""))
+}
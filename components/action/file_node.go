@@ -0,0 +1,588 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+var (
+	// ErrFileBaseDirNotSet 未配置文件组件根目录的错误
+	// ErrFileBaseDirNotSet is returned when the base directory of the file components is not configured.
+	ErrFileBaseDirNotSet = errors.New("file base dir not set")
+	// ErrFilePathNotAllowed 文件路径不在根目录下的错误
+	// ErrFilePathNotAllowed is returned when a path resolves outside the base directory.
+	ErrFilePathNotAllowed = errors.New("file path not allowed")
+)
+
+const (
+	// KeyFileNodeBaseDir 文件组件根目录的配置键，所有文件路径都限制在该目录下
+	// KeyFileNodeBaseDir is the Config.Properties key of the base directory all file component paths are confined to.
+	KeyFileNodeBaseDir = "fileNodeBaseDir"
+
+	// KeyLineIndex 逐行读取时当前行索引的元数据键
+	// KeyLineIndex is the metadata key of the current line index in line-by-line reads.
+	KeyLineIndex = "lineIndex"
+	// KeyLineCount 逐行读取完成后总行数的元数据键
+	// KeyLineCount is the metadata key of the number of lines after a line-by-line read.
+	KeyLineCount = "lineCount"
+)
+
+// init 注册文件组件
+// init registers the file components with the default registry.
+func init() {
+	Registry.Add(&FileReadNode{})
+	Registry.Add(&FileWriteNode{})
+	Registry.Add(&FileDeleteNode{})
+	Registry.Add(&FileListNode{})
+}
+
+// fileSandbox 把文件路径限制在根目录下
+// fileSandbox confines file paths to a base directory.
+type fileSandbox struct {
+	// baseDir 根目录的绝对路径，已解析符号链接
+	baseDir string
+}
+
+// init 从全局属性读取根目录
+func (s *fileSandbox) init(ruleConfig types.Config) error {
+	baseDir := ruleConfig.Properties.GetValue(KeyFileNodeBaseDir)
+	if baseDir == "" {
+		s.baseDir = ""
+		return nil
+	}
+	baseDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return err
+	}
+	if realDir, err := filepath.EvalSymlinks(baseDir); err == nil {
+		baseDir = realDir
+	}
+	s.baseDir = baseDir
+	return nil
+}
+
+// resolve 把路径解析成根目录下的绝对路径，相对路径相对于根目录。
+// 路径本身或者通过符号链接指向根目录以外时返回 ErrFilePathNotAllowed
+func (s *fileSandbox) resolve(path string) (string, error) {
+	if s.baseDir == "" {
+		return "", ErrFileBaseDirNotSet
+	}
+	if path == "" {
+		return "", errors.New("file path can not empty")
+	}
+	var fullPath string
+	if filepath.IsAbs(path) {
+		fullPath = filepath.Clean(path)
+	} else {
+		fullPath = filepath.Join(s.baseDir, path)
+	}
+	if !s.contains(fullPath) {
+		return "", ErrFilePathNotAllowed
+	}
+	realPath, err := evalExistingSymlinks(fullPath)
+	if err != nil {
+		return "", err
+	}
+	if !s.contains(realPath) {
+		return "", ErrFilePathNotAllowed
+	}
+	return fullPath, nil
+}
+
+// contains 路径是否是根目录或者在根目录下
+func (s *fileSandbox) contains(path string) bool {
+	rel, err := filepath.Rel(s.baseDir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// rel 返回相对于根目录的路径，使用/分隔
+func (s *fileSandbox) rel(path string) string {
+	if rel, err := filepath.Rel(s.baseDir, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// evalExistingSymlinks 解析路径中已存在部分的符号链接，不存在的部分原样拼接
+func evalExistingSymlinks(path string) (string, error) {
+	var rest []string
+	current := path
+	for {
+		realPath, err := filepath.EvalSymlinks(current)
+		if err == nil {
+			for i := len(rest) - 1; i >= 0; i-- {
+				realPath = filepath.Join(realPath, rest[i])
+			}
+			return realPath, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return path, nil
+		}
+		rest = append(rest, filepath.Base(current))
+		current = parent
+	}
+}
+
+// resolvePath 替换路径模板变量并限制在根目录下
+func resolvePath(ctx types.RuleContext, msg types.RuleMsg, sandbox *fileSandbox, path string) (string, error) {
+	if str.CheckHasVar(path) {
+		path = str.ExecuteTemplate(path, base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	}
+	return sandbox.resolve(path)
+}
+
+// FileReadNodeConfiguration FileReadNode配置结构
+// FileReadNodeConfiguration defines the configuration structure for the FileReadNode component.
+type FileReadNodeConfiguration struct {
+	// Path 文件路径，相对路径相对于根目录，支持${metadata.key}和${msg.key}变量替换
+	// Path specifies the file path, relative to the base directory.
+	// Supports variable substitution using ${metadata.key} and ${msg.key} syntax.
+	Path string
+	// DataType 读取结果的数据类型：TEXT、JSON或BINARY，默认TEXT
+	// DataType specifies the data type of the result: TEXT, JSON or BINARY. Defaults to TEXT.
+	DataType string
+	// ReadLines 是否逐行读取，每行一条消息通过True关系发送，全部发送后原消息通过Success关系发送
+	// ReadLines controls whether the file is read line by line. Each line is sent as its own message
+	// via the True relation, then the original message is sent via the Success relation.
+	ReadLines bool
+}
+
+// FileReadNode 读取根目录下文件的动作组件
+// FileReadNode is an action component that reads a file under the base directory.
+//
+// 路径限制 - Path confinement:
+//   - 所有路径限制在全局属性 fileNodeBaseDir 配置的根目录下，未配置时所有操作失败 -
+//     All paths are confined to the directory set by the fileNodeBaseDir property, all operations fail if it is not set
+//
+// 输出 - Output:
+//   - 默认把整个文件内容替换消息数据 - By default the whole file replaces the message data
+//   - ReadLines模式：每行一条消息通过True关系发送，元数据lineIndex为行索引，全部发送后原消息通过Success关系发送，元数据lineCount为行数 -
+//     ReadLines mode: one message per line via True with lineIndex in metadata, then the original message via Success with lineCount in metadata
+type FileReadNode struct {
+	// Config 节点配置
+	// Config holds the node configuration
+	Config  FileReadNodeConfiguration
+	sandbox fileSandbox
+}
+
+// Type 返回组件类型
+// Type returns the component type identifier.
+func (x *FileReadNode) Type() string {
+	return "fileRead"
+}
+
+// New 创建新实例
+// New creates a new instance.
+func (x *FileReadNode) New() types.Node {
+	return &FileReadNode{Config: FileReadNodeConfiguration{
+		Path:     "${metadata.path}",
+		DataType: string(types.TEXT),
+	}}
+}
+
+// Init 初始化组件
+// Init initializes the component.
+func (x *FileReadNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	if x.Config.DataType == "" {
+		x.Config.DataType = string(types.TEXT)
+	}
+	return x.sandbox.init(ruleConfig)
+}
+
+// OnMsg 处理消息，读取文件内容
+// OnMsg processes incoming messages by reading the file.
+func (x *FileReadNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	path, err := resolvePath(ctx, msg, &x.sandbox, x.Config.Path)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	dataType := types.DataType(strings.ToUpper(x.Config.DataType))
+	if x.Config.ReadLines {
+		x.readLines(ctx, msg, path, dataType)
+		return
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.DataType = dataType
+	msg.SetBytes(content)
+	ctx.TellSuccess(msg)
+}
+
+// readLines 逐行读取文件，不把整个文件加载到内存
+func (x *FileReadNode) readLines(ctx types.RuleContext, msg types.RuleMsg, path string, dataType types.DataType) {
+	f, err := os.Open(path)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	defer f.Close()
+	reader := bufio.NewReader(f)
+	index := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			lineMsg := msg.CopyAsChild()
+			lineMsg.DataType = dataType
+			lineMsg.Metadata.PutValue(KeyLineIndex, strconv.Itoa(index))
+			lineMsg.SetData(strings.TrimRight(line, "\r\n"))
+			ctx.TellNext(lineMsg, types.True)
+			index++
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	msg.Metadata.PutValue(KeyLineCount, strconv.Itoa(index))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 清理资源
+// Destroy cleans up resources.
+func (x *FileReadNode) Destroy() {
+}
+
+// FileWriteNodeConfiguration FileWriteNode配置结构
+// FileWriteNodeConfiguration defines the configuration structure for the FileWriteNode component.
+type FileWriteNodeConfiguration struct {
+	// Path 文件路径，相对路径相对于根目录，支持${metadata.key}和${msg.key}变量替换
+	// Path specifies the file path, relative to the base directory.
+	// Supports variable substitution using ${metadata.key} and ${msg.key} syntax.
+	Path string
+	// Content 写入的内容模板，为空时写入消息数据
+	// Content specifies the content template. If empty, the message data is written.
+	Content string
+	// Append 是否追加到文件末尾，false则覆盖文件
+	// Append controls whether to append to the file instead of overwriting it.
+	Append bool
+}
+
+// FileWriteNode 写入根目录下文件的动作组件，父目录不存在时自动创建
+// FileWriteNode is an action component that writes a file under the base directory, creating parent directories as needed.
+//
+// 写入内容 - Written content:
+//   - Content模板不为空时写入模板结果 - The result of the Content template if it is not empty
+//   - 消息携带流式消息体时写入流内容，不加载到内存 - The message stream if the message carries one, without buffering it
+//   - 否则写入消息数据，BINARY类型写入原始字节 - Otherwise the message data, BINARY data is written as raw bytes
+type FileWriteNode struct {
+	// Config 节点配置
+	// Config holds the node configuration
+	Config  FileWriteNodeConfiguration
+	sandbox fileSandbox
+}
+
+// Type 返回组件类型
+// Type returns the component type identifier.
+func (x *FileWriteNode) Type() string {
+	return "fileWrite"
+}
+
+// New 创建新实例
+// New creates a new instance.
+func (x *FileWriteNode) New() types.Node {
+	return &FileWriteNode{Config: FileWriteNodeConfiguration{
+		Path: "${metadata.path}",
+	}}
+}
+
+// Init 初始化组件
+// Init initializes the component.
+func (x *FileWriteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	return x.sandbox.init(ruleConfig)
+}
+
+// OnMsg 处理消息，写入文件
+// OnMsg processes incoming messages by writing the file.
+func (x *FileWriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	path, err := resolvePath(ctx, msg, &x.sandbox, x.Config.Path)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	flag := os.O_CREATE | os.O_WRONLY
+	if x.Config.Append {
+		flag |= os.O_APPEND
+	} else {
+		flag |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	err = x.write(ctx, msg, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	ctx.TellSuccess(msg)
+}
+
+// write 写入内容模板、流式消息体或者消息数据
+func (x *FileWriteNode) write(ctx types.RuleContext, msg types.RuleMsg, w io.Writer) error {
+	if x.Config.Content != "" {
+		content := x.Config.Content
+		if str.CheckHasVar(content) {
+			content = str.ExecuteTemplate(content, base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+		}
+		_, err := io.WriteString(w, content)
+		return err
+	}
+	if msg.HasStream() {
+		reader, err := msg.TakeStream()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		_, err = io.Copy(w, reader)
+		return err
+	}
+	_, err := w.Write(msg.GetBytes())
+	return err
+}
+
+// Destroy 清理资源
+// Destroy cleans up resources.
+func (x *FileWriteNode) Destroy() {
+}
+
+// FileDeleteNodeConfiguration FileDeleteNode配置结构
+// FileDeleteNodeConfiguration defines the configuration structure for the FileDeleteNode component.
+type FileDeleteNodeConfiguration struct {
+	// Path 文件路径，相对路径相对于根目录，支持${metadata.key}和${msg.key}变量替换
+	// Path specifies the file path, relative to the base directory.
+	// Supports variable substitution using ${metadata.key} and ${msg.key} syntax.
+	Path string
+}
+
+// FileDeleteNode 删除根目录下文件或空目录的动作组件，文件不存在时视为成功
+// FileDeleteNode is an action component that deletes a file or an empty directory under the base directory.
+// Missing files are treated as deleted.
+type FileDeleteNode struct {
+	// Config 节点配置
+	// Config holds the node configuration
+	Config  FileDeleteNodeConfiguration
+	sandbox fileSandbox
+}
+
+// Type 返回组件类型
+// Type returns the component type identifier.
+func (x *FileDeleteNode) Type() string {
+	return "fileDelete"
+}
+
+// New 创建新实例
+// New creates a new instance.
+func (x *FileDeleteNode) New() types.Node {
+	return &FileDeleteNode{Config: FileDeleteNodeConfiguration{
+		Path: "${metadata.path}",
+	}}
+}
+
+// Init 初始化组件
+// Init initializes the component.
+func (x *FileDeleteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	return x.sandbox.init(ruleConfig)
+}
+
+// OnMsg 处理消息，删除文件
+// OnMsg processes incoming messages by deleting the file.
+func (x *FileDeleteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	path, err := resolvePath(ctx, msg, &x.sandbox, x.Config.Path)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	// 不允许删除根目录
+	if path == x.sandbox.baseDir {
+		ctx.TellFailure(msg, ErrFilePathNotAllowed)
+		return
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 清理资源
+// Destroy cleans up resources.
+func (x *FileDeleteNode) Destroy() {
+}
+
+// FileListNodeConfiguration FileListNode配置结构
+// FileListNodeConfiguration defines the configuration structure for the FileListNode component.
+type FileListNodeConfiguration struct {
+	// Path 目录路径，相对路径相对于根目录，支持${metadata.key}和${msg.key}变量替换
+	// Path specifies the directory path, relative to the base directory.
+	// Supports variable substitution using ${metadata.key} and ${msg.key} syntax.
+	Path string
+	// Pattern 文件名匹配模式，参考filepath.Match，为空时匹配所有文件
+	// Pattern filters entries by name, see filepath.Match. Empty matches all entries.
+	Pattern string
+	// Recursive 是否递归列出子目录
+	// Recursive controls whether subdirectories are listed recursively.
+	Recursive bool
+}
+
+// FileInfo 文件列表中的一项
+// FileInfo is an entry of the file list.
+type FileInfo struct {
+	// Name 文件名
+	Name string `json:"name"`
+	// Path 相对于根目录的路径，使用/分隔
+	Path string `json:"path"`
+	// Size 文件大小，单位字节
+	Size int64 `json:"size"`
+	// IsDir 是否是目录
+	IsDir bool `json:"isDir"`
+	// ModTime 修改时间，毫秒时间戳
+	ModTime int64 `json:"modTime"`
+}
+
+// FileListNode 列出根目录下目录内容的动作组件，结果以JSON数组替换消息数据
+// FileListNode is an action component that lists a directory under the base directory.
+// The result replaces the message data as a JSON array of FileInfo.
+type FileListNode struct {
+	// Config 节点配置
+	// Config holds the node configuration
+	Config  FileListNodeConfiguration
+	sandbox fileSandbox
+}
+
+// Type 返回组件类型
+// Type returns the component type identifier.
+func (x *FileListNode) Type() string {
+	return "fileList"
+}
+
+// New 创建新实例
+// New creates a new instance.
+func (x *FileListNode) New() types.Node {
+	return &FileListNode{Config: FileListNodeConfiguration{
+		Path: ".",
+	}}
+}
+
+// Init 初始化组件
+// Init initializes the component.
+func (x *FileListNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	if x.Config.Pattern != "" {
+		if _, err := filepath.Match(x.Config.Pattern, ""); err != nil {
+			return err
+		}
+	}
+	return x.sandbox.init(ruleConfig)
+}
+
+// OnMsg 处理消息，列出目录内容
+// OnMsg processes incoming messages by listing the directory.
+func (x *FileListNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	dir, err := resolvePath(ctx, msg, &x.sandbox, x.Config.Path)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	list := make([]FileInfo, 0)
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if x.Config.Pattern == "" || x.match(d.Name()) {
+			info, infoErr := d.Info()
+			if infoErr != nil {
+				return infoErr
+			}
+			list = append(list, FileInfo{
+				Name:    d.Name(),
+				Path:    x.sandbox.rel(path),
+				Size:    info.Size(),
+				IsDir:   d.IsDir(),
+				ModTime: info.ModTime().UnixMilli(),
+			})
+		}
+		if d.IsDir() && !x.Config.Recursive {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.DataType = types.JSON
+	msg.SetData(str.ToString(list))
+	ctx.TellSuccess(msg)
+}
+
+func (x *FileListNode) match(name string) bool {
+	matched, _ := filepath.Match(x.Config.Pattern, name)
+	return matched
+}
+
+// Destroy 清理资源
+// Destroy cleans up resources.
+func (x *FileListNode) Destroy() {
+}
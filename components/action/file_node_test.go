@@ -0,0 +1,265 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/json"
+)
+
+// fileNodeResult 同步执行节点的结果
+type fileNodeResult struct {
+	msg          types.RuleMsg
+	relationType string
+	err          error
+}
+
+// execFileNode 同步执行节点，返回所有回调结果
+func execFileNode(node types.Node, msg types.RuleMsg) []fileNodeResult {
+	var results []fileNodeResult
+	ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+		results = append(results, fileNodeResult{msg: msg, relationType: relationType, err: err})
+	})
+	node.OnMsg(ctx, msg)
+	return results
+}
+
+func newFileNode(t *testing.T, baseDir, nodeType string, configuration types.Configuration) types.Node {
+	config := types.NewConfig()
+	config.Properties.PutValue(KeyFileNodeBaseDir, baseDir)
+	node := test.InitNodeByConfig(config, nodeType, configuration, Registry)
+	assert.NotNil(t, node)
+	return node
+}
+
+func newFileMsg(path, data string) types.RuleMsg {
+	metadata := types.NewMetadata()
+	metadata.PutValue("path", path)
+	return types.NewMsg(0, "TEST", types.TEXT, metadata, data)
+}
+
+func TestFileNodes(t *testing.T) {
+	baseDir := t.TempDir()
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, "fileRead", &FileReadNode{}, types.Configuration{
+			"path":     "${metadata.path}",
+			"dataType": "TEXT",
+		}, Registry)
+		test.NodeNew(t, "fileWrite", &FileWriteNode{}, types.Configuration{
+			"path": "${metadata.path}",
+		}, Registry)
+		test.NodeNew(t, "fileDelete", &FileDeleteNode{}, types.Configuration{
+			"path": "${metadata.path}",
+		}, Registry)
+		test.NodeNew(t, "fileList", &FileListNode{}, types.Configuration{
+			"path": ".",
+		}, Registry)
+	})
+
+	t.Run("BaseDirNotSet", func(t *testing.T) {
+		node := test.InitNodeByConfig(types.NewConfig(), "fileRead", types.Configuration{}, Registry)
+		results := execFileNode(node, newFileMsg("a.txt", ""))
+		assert.Equal(t, types.Failure, results[0].relationType)
+		assert.Equal(t, ErrFileBaseDirNotSet, results[0].err)
+	})
+
+	t.Run("WriteAndRead", func(t *testing.T) {
+		writeNode := newFileNode(t, baseDir, "fileWrite", types.Configuration{
+			"path": "data/${metadata.name}.txt",
+		})
+		appendNode := newFileNode(t, baseDir, "fileWrite", types.Configuration{
+			"path":    "data/${metadata.name}.txt",
+			"content": "${msg.line}\n",
+			"append":  true,
+		})
+		readNode := newFileNode(t, baseDir, "fileRead", types.Configuration{
+			"path": "data/${metadata.name}.txt",
+		})
+
+		msg := newFileMsg("", "first\n")
+		msg.Metadata.PutValue("name", "log")
+		results := execFileNode(writeNode, msg)
+		assert.Equal(t, types.Success, results[0].relationType)
+
+		appendMsg := types.NewMsg(0, "TEST", types.JSON, msg.Metadata, `{"line":"second"}`)
+		results = execFileNode(appendNode, appendMsg)
+		assert.Equal(t, types.Success, results[0].relationType)
+
+		results = execFileNode(readNode, msg)
+		assert.Equal(t, types.Success, results[0].relationType)
+		assert.Equal(t, "first\nsecond\n", results[0].msg.GetData())
+		assert.Equal(t, types.TEXT, results[0].msg.DataType)
+
+		// 覆盖写入
+		execFileNode(writeNode, newFileMsgWithName("log", "replaced"))
+		results = execFileNode(readNode, msg)
+		assert.Equal(t, "replaced", results[0].msg.GetData())
+	})
+
+	t.Run("Binary", func(t *testing.T) {
+		writeNode := newFileNode(t, baseDir, "fileWrite", types.Configuration{
+			"path": "${metadata.path}",
+		})
+		readNode := newFileNode(t, baseDir, "fileRead", types.Configuration{
+			"path":     "${metadata.path}",
+			"dataType": "BINARY",
+		})
+		content := []byte{0x00, 0x01, 0xff, 0xfe}
+		msg := newFileMsg("bin/data.bin", "")
+		msg.DataType = types.BINARY
+		msg.SetBytes(content)
+		results := execFileNode(writeNode, msg)
+		assert.Equal(t, types.Success, results[0].relationType)
+
+		results = execFileNode(readNode, newFileMsg("bin/data.bin", ""))
+		assert.Equal(t, types.Success, results[0].relationType)
+		assert.Equal(t, types.BINARY, results[0].msg.DataType)
+		assert.Equal(t, content, results[0].msg.GetBytes())
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		writeNode := newFileNode(t, baseDir, "fileWrite", types.Configuration{
+			"path": "${metadata.path}",
+		})
+		msg := newFileMsg("stream.txt", "")
+		msg.SetStream(strings.NewReader("streamed content"), -1)
+		results := execFileNode(writeNode, msg)
+		assert.Equal(t, types.Success, results[0].relationType)
+		assert.True(t, msg.GetStream().Consumed())
+		content, err := os.ReadFile(filepath.Join(baseDir, "stream.txt"))
+		assert.Nil(t, err)
+		assert.Equal(t, "streamed content", string(content))
+	})
+
+	t.Run("ReadLines", func(t *testing.T) {
+		assert.Nil(t, os.WriteFile(filepath.Join(baseDir, "lines.txt"), []byte("a\r\nb\n\nc"), 0o644))
+		readNode := newFileNode(t, baseDir, "fileRead", types.Configuration{
+			"path":      "lines.txt",
+			"readLines": true,
+		})
+		results := execFileNode(readNode, newFileMsg("", "{}"))
+		assert.Equal(t, 5, len(results))
+		var lines []string
+		for i, result := range results[:4] {
+			assert.Equal(t, types.True, result.relationType)
+			assert.Equal(t, strconv.Itoa(i), result.msg.Metadata.GetValue(KeyLineIndex))
+			lines = append(lines, result.msg.GetData())
+		}
+		assert.Equal(t, []string{"a", "b", "", "c"}, lines)
+		assert.Equal(t, types.Success, results[4].relationType)
+		assert.Equal(t, "4", results[4].msg.Metadata.GetValue(KeyLineCount))
+		assert.Equal(t, "{}", results[4].msg.GetData())
+	})
+
+	t.Run("List", func(t *testing.T) {
+		listDir := filepath.Join(baseDir, "list")
+		assert.Nil(t, os.MkdirAll(filepath.Join(listDir, "sub"), 0o755))
+		assert.Nil(t, os.WriteFile(filepath.Join(listDir, "a.csv"), []byte("1"), 0o644))
+		assert.Nil(t, os.WriteFile(filepath.Join(listDir, "b.txt"), []byte("22"), 0o644))
+		assert.Nil(t, os.WriteFile(filepath.Join(listDir, "sub", "c.csv"), []byte("333"), 0o644))
+
+		listNode := newFileNode(t, baseDir, "fileList", types.Configuration{
+			"path": "list",
+		})
+		results := execFileNode(listNode, newFileMsg("", "{}"))
+		assert.Equal(t, types.Success, results[0].relationType)
+		var list []FileInfo
+		assert.Nil(t, json.Unmarshal([]byte(results[0].msg.GetData()), &list))
+		assert.Equal(t, 3, len(list))
+		assert.Equal(t, "list/a.csv", list[0].Path)
+		assert.Equal(t, int64(1), list[0].Size)
+		assert.True(t, list[2].IsDir)
+
+		recursiveNode := newFileNode(t, baseDir, "fileList", types.Configuration{
+			"path":      "list",
+			"pattern":   "*.csv",
+			"recursive": true,
+		})
+		results = execFileNode(recursiveNode, newFileMsg("", "{}"))
+		list = nil
+		assert.Nil(t, json.Unmarshal([]byte(results[0].msg.GetData()), &list))
+		assert.Equal(t, 2, len(list))
+		assert.Equal(t, "list/sub/c.csv", list[1].Path)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		path := filepath.Join(baseDir, "delete.txt")
+		assert.Nil(t, os.WriteFile(path, []byte("x"), 0o644))
+		deleteNode := newFileNode(t, baseDir, "fileDelete", types.Configuration{
+			"path": "${metadata.path}",
+		})
+		results := execFileNode(deleteNode, newFileMsg("delete.txt", ""))
+		assert.Equal(t, types.Success, results[0].relationType)
+		_, err := os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+		// 文件不存在视为成功
+		results = execFileNode(deleteNode, newFileMsg("delete.txt", ""))
+		assert.Equal(t, types.Success, results[0].relationType)
+		// 不允许删除根目录
+		results = execFileNode(deleteNode, newFileMsg(".", ""))
+		assert.Equal(t, ErrFilePathNotAllowed, results[0].err)
+	})
+
+	t.Run("Sandbox", func(t *testing.T) {
+		outsideDir := t.TempDir()
+		assert.Nil(t, os.WriteFile(filepath.Join(outsideDir, "secret.txt"), []byte("secret"), 0o644))
+		readNode := newFileNode(t, baseDir, "fileRead", types.Configuration{
+			"path": "${metadata.path}",
+		})
+		writeNode := newFileNode(t, baseDir, "fileWrite", types.Configuration{
+			"path": "${metadata.path}",
+		})
+		for _, path := range []string{
+			"../secret.txt",
+			"data/../../secret.txt",
+			filepath.Join(outsideDir, "secret.txt"),
+		} {
+			results := execFileNode(readNode, newFileMsg(path, ""))
+			assert.Equal(t, ErrFilePathNotAllowed, results[0].err)
+			results = execFileNode(writeNode, newFileMsg(path, "x"))
+			assert.Equal(t, ErrFilePathNotAllowed, results[0].err)
+		}
+
+		// 通过符号链接逃逸
+		if err := os.Symlink(outsideDir, filepath.Join(baseDir, "link")); err == nil {
+			results := execFileNode(readNode, newFileMsg("link/secret.txt", ""))
+			assert.Equal(t, ErrFilePathNotAllowed, results[0].err)
+			results = execFileNode(writeNode, newFileMsg("link/new/file.txt", "x"))
+			assert.Equal(t, ErrFilePathNotAllowed, results[0].err)
+		}
+
+		// 根目录下的绝对路径允许访问
+		assert.Nil(t, os.WriteFile(filepath.Join(baseDir, "inside.txt"), []byte("inside"), 0o644))
+		results := execFileNode(readNode, newFileMsg(filepath.Join(baseDir, "inside.txt"), ""))
+		assert.Equal(t, "inside", results[0].msg.GetData())
+	})
+}
+
+func newFileMsgWithName(name, data string) types.RuleMsg {
+	msg := newFileMsg("", data)
+	msg.Metadata.PutValue("name", name)
+	return msg
+}
@@ -406,8 +406,10 @@ func (x *ForNode) executeItem(ctxWithCancel context.Context, ctx types.RuleConte
 	var lock sync.Mutex
 	var msgData []string
 	var lastMsg types.RuleMsg
+	//每一项作为子消息执行，记录消息血缘
+	itemMsg := fromMsg.CopyAsChild()
 	if x.ruleNodeId.Type == types.CHAIN {
-		ctx.TellFlow(ctx.GetContext(), x.ruleNodeId.Id, fromMsg, func(ctx types.RuleContext, msg types.RuleMsg, err error, relationType string) {
+		ctx.TellFlow(ctx.GetContext(), x.ruleNodeId.Id, itemMsg, func(ctx types.RuleContext, msg types.RuleMsg, err error, relationType string) {
 			if err != nil {
 				returnErr = err
 			} else {
@@ -424,7 +426,7 @@ func (x *ForNode) executeItem(ctxWithCancel context.Context, ctx types.RuleConte
 			wg.Done()
 		})
 	} else {
-		ctx.TellNode(ctx.GetContext(), x.ruleNodeId.Id, fromMsg, false, func(ctx types.RuleContext, msg types.RuleMsg, err error, relationType string) {
+		ctx.TellNode(ctx.GetContext(), x.ruleNodeId.Id, itemMsg, false, func(ctx types.RuleContext, msg types.RuleMsg, err error, relationType string) {
 			if err != nil {
 				returnErr = err
 			} else {
@@ -452,7 +454,7 @@ func (x *ForNode) executeItem(ctxWithCancel context.Context, ctx types.RuleConte
 
 // 异步执行每一项
 func (x *ForNode) asyncExecuteItem(ctxWithCancel context.Context, ctx types.RuleContext, fromMsg types.RuleMsg) error {
-	fromMsg = fromMsg.Copy()
+	fromMsg = fromMsg.CopyAsChild()
 	if x.ruleNodeId.Type == types.CHAIN {
 		ctx.TellFlow(ctx.GetContext(), x.ruleNodeId.Id, fromMsg, nil, nil)
 	} else {
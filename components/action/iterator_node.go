@@ -166,21 +166,23 @@ func (x *IteratorNode) Destroy() {
 // executeItem processes each individual item during iteration.
 func (x *IteratorNode) executeItem(ctx types.RuleContext, msg types.RuleMsg, item interface{}, index interface{}) error {
 	if x.jsEngine != nil {
-		// 使用零拷贝GetReadOnlyValues，JS引擎只读取metadata
-		if out, err := x.jsEngine.Execute(ctx, "ItemFilter", item, index, msg.Metadata.GetReadOnlyValues()); err != nil {
+		if out, err := x.jsEngine.Execute(ctx, "ItemFilter", item, index, base.NodeUtils.PrepareJsMetadata(msg)); err != nil {
 			ctx.TellFailure(msg, err)
 			//出现错误中断遍历
 			return err
 		} else if formatData, ok := out.(bool); ok && formatData {
-			msg.SetData(str.ToString(item))
-			ctx.TellNext(msg, types.True)
+			itemMsg := msg.CopyAsChild()
+			itemMsg.SetData(str.ToString(item))
+			ctx.TellNext(itemMsg, types.True)
 		} else {
-			msg.SetData(str.ToString(item))
-			ctx.TellNext(msg, types.False)
+			itemMsg := msg.CopyAsChild()
+			itemMsg.SetData(str.ToString(item))
+			ctx.TellNext(itemMsg, types.False)
 		}
 	} else {
-		msg.SetData(str.ToString(item))
-		ctx.TellNext(msg, types.True)
+		itemMsg := msg.CopyAsChild()
+		itemMsg.SetData(str.ToString(item))
+		ctx.TellNext(itemMsg, types.True)
 	}
 	return nil
 }
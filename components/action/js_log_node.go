@@ -109,12 +109,7 @@ func (x *LogNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 	// 准备传递给JS脚本的数据
 	data := base.NodeUtils.PrepareJsData(msg)
 
-	var metadataValues map[string]string
-	if msg.Metadata != nil {
-		metadataValues = msg.Metadata.Values()
-	} else {
-		metadataValues = make(map[string]string)
-	}
+	metadataValues := base.NodeUtils.PrepareJsMetadata(msg)
 
 	// 执行JavaScript脚本
 	out, err := x.jsEngine.Execute(ctx, JsLogFuncName, data, metadataValues, msg.Type, msg.DataType)
@@ -131,6 +131,18 @@ func (n *nodeUtils) PrepareJsData(msg types.RuleMsg) interface{} {
 	return data
 }
 
+// PrepareJsMetadata 准备传递给JavaScript脚本的元数据
+// 如果元数据包含类型化的值，则以原生类型传递，否则传递字符串map
+func (n *nodeUtils) PrepareJsMetadata(msg types.RuleMsg) interface{} {
+	if msg.Metadata == nil {
+		return make(map[string]string)
+	}
+	if msg.Metadata.HasTypedValues() {
+		return msg.Metadata.TypedValues()
+	}
+	return msg.Metadata.Values()
+}
+
 // SharedNode 共享资源组件，通过 Get 获取共享实例，多个节点可以在共享池中获取相同的实例
 // 例如：mqtt 客户端、数据库客户端，也可以http server以及是可复用的节点。
 type SharedNode[T any] struct {
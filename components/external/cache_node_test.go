@@ -307,3 +307,154 @@ func TestCacheDeleteNode(t *testing.T) {
 		})
 	})
 }
+
+func TestCacheIncrNode(t *testing.T) {
+	var targetNodeType = "cacheIncr"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &CacheIncrNode{}, types.Configuration{
+			"level":     CacheLevelChain,
+			"operation": CacheOperationIncr,
+			"key":       "key1",
+			"delta":     int64(1),
+			"outputKey": DefaultCacheIncrOutputKey,
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"key": "key1",
+			"ttl": "abc",
+		}, Registry)
+		assert.NotNil(t, err)
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"key":       "key1",
+			"operation": "decr",
+		}, Registry)
+		assert.Equal(t, "unsupported cache operation: decr", err.Error())
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"level":     CacheLevelGlobal,
+			"key":       "count_${metadata.deviceId}",
+			"delta":     2,
+			"ttl":       "1m",
+			"outputKey": "alarmCount",
+		}, Registry)
+		assert.Nil(t, err)
+
+		var results []types.RuleMsg
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+			results = append(results, msg)
+		})
+		for i := 0; i < 3; i++ {
+			metadata := types.NewMetadata()
+			metadata.PutValue("deviceId", "1")
+			node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG", types.JSON, metadata, "{}"))
+		}
+		assert.Equal(t, 3, len(results))
+		assert.Equal(t, "2", results[0].Metadata.GetValue("alarmCount"))
+		assert.Equal(t, "6", results[2].Metadata.GetValue("alarmCount"))
+		//计数以整数类型写入元数据
+		count, ok := results[2].Metadata.GetTypedValue("alarmCount")
+		assert.True(t, ok)
+		assert.Equal(t, int64(6), count)
+		assert.Equal(t, int64(6), ctx.GlobalCache().Get("count_1"))
+	})
+
+	t.Run("ChainLevel", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"key": "lock",
+		}, Registry)
+		assert.Nil(t, err)
+
+		var counts []string
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			counts = append(counts, msg.Metadata.GetValue(DefaultCacheIncrOutputKey))
+		})
+		node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG", types.JSON, types.NewMetadata(), "{}"))
+		node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG", types.JSON, types.NewMetadata(), "{}"))
+		assert.Equal(t, []string{"1", "2"}, counts)
+		assert.True(t, ctx.ChainCache().Has("lock"))
+		assert.False(t, ctx.GlobalCache().Has("lock"))
+	})
+
+	t.Run("NotInteger", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"key": "text",
+		}, Registry)
+		assert.Nil(t, err)
+
+		var resultErr error
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Failure, relationType)
+			resultErr = err
+		})
+		_ = ctx.ChainCache().Set("text", "abc", "")
+		node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG", types.JSON, types.NewMetadata(), "{}"))
+		assert.Equal(t, types.ErrCacheValueNotInteger, resultErr)
+	})
+
+	t.Run("SetNX", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"level":     CacheLevelGlobal,
+			"operation": CacheOperationSetNX,
+			"key":       "lock_${metadata.deviceId}",
+			"value":     "${metadata.owner}",
+			"ttl":       "1m",
+		}, Registry)
+		assert.Nil(t, err)
+
+		var relationTypes []string
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			assert.Nil(t, err)
+			relationTypes = append(relationTypes, relationType)
+		})
+		for _, owner := range []string{"a", "b"} {
+			metadata := types.NewMetadata()
+			metadata.PutValue("deviceId", "1")
+			metadata.PutValue("owner", owner)
+			node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG", types.JSON, metadata, "{}"))
+		}
+		// 第一个调用者获得锁，第二个调用者冲突
+		assert.Equal(t, []string{types.True, types.False}, relationTypes)
+		assert.Equal(t, "a", ctx.GlobalCache().Get("lock_1"))
+	})
+
+	t.Run("CompareAndSwap", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"operation":     CacheOperationCas,
+			"key":           "state",
+			"expectedValue": "${metadata.from}",
+			"value":         "${metadata.to}",
+		}, Registry)
+		assert.Nil(t, err)
+
+		var relationTypes []string
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			assert.Nil(t, err)
+			relationTypes = append(relationTypes, relationType)
+		})
+		_ = ctx.ChainCache().Set("state", "idle", "")
+		for _, transition := range [][2]string{{"idle", "running"}, {"idle", "stopped"}, {"running", "stopped"}} {
+			metadata := types.NewMetadata()
+			metadata.PutValue("from", transition[0])
+			metadata.PutValue("to", transition[1])
+			node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG", types.JSON, metadata, "{}"))
+		}
+		// 当前值不等于期望值时不替换
+		assert.Equal(t, []string{types.True, types.False, types.True}, relationTypes)
+		assert.Equal(t, "stopped", ctx.ChainCache().Get("state"))
+
+		// 键不存在视为冲突
+		_ = ctx.ChainCache().Delete("state")
+		metadata := types.NewMetadata()
+		metadata.PutValue("from", "idle")
+		metadata.PutValue("to", "running")
+		node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG", types.JSON, metadata, "{}"))
+		assert.Equal(t, types.False, relationTypes[3])
+		assert.False(t, ctx.ChainCache().Has("state"))
+	})
+}
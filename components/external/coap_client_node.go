@@ -0,0 +1,190 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/coap"
+	"github.com/rulego/rulego/utils/el"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+func init() {
+	Registry.Add(&CoapClientNode{})
+}
+
+// CoapClientNodeConfiguration CoAP客户端配置
+type CoapClientNodeConfiguration struct {
+	// Server 服务端地址，格式为 host:port
+	Server string `json:"server"`
+	// Method 请求方法：GET、POST、PUT、DELETE，默认POST
+	Method string `json:"method"`
+	// Path 资源路径，可以包含 ?key=value 查询参数，支持 ${metadata.key} 和 ${msg.key} 变量
+	Path string `json:"path"`
+	// Confirmable 是否发送需要确认的请求，需要确认的请求在收到确认前重传。默认true
+	Confirmable bool `json:"confirmable"`
+	// ContentFormat 请求内容格式，MIME类型或者编号，为空根据消息数据类型设置
+	ContentFormat string `json:"contentFormat"`
+	// TimeoutMs 等待响应超时时间，单位毫秒，默认5000
+	TimeoutMs int `json:"timeoutMs"`
+}
+
+// CoapClientNode CoAP客户端组件，通过UDP向受限设备或者CoAP服务发送请求，响应作为消息负荷输出。
+// CoapClientNode sends CoAP requests over UDP to constrained devices or CoAP services
+// and outputs the response payload as the message data.
+//
+// Configuration:
+// 配置说明：
+//
+//	{
+//		"server": "127.0.0.1:5683",        // CoAP server address  服务端地址
+//		"method": "POST",                  // GET, POST, PUT or DELETE  请求方法
+//		"path": "/devices/${deviceId}",    // Resource path with variables  资源路径，支持变量
+//		"confirmable": true,               // Confirmable request with retransmission  需要确认的请求
+//		"contentFormat": "",               // Request content format, empty follows data type  请求内容格式
+//		"timeoutMs": 5000                  // Response timeout in milliseconds  响应超时（毫秒）
+//	}
+//
+// GET and DELETE requests are sent without payload. The request content format follows the
+// message data type when not configured: JSON -> application/json, BINARY -> application/octet-stream,
+// otherwise text/plain. The response data type follows the response content format.
+//
+// GET 和 DELETE 请求不发送负荷。没有配置请求内容格式时按消息数据类型设置：JSON -> application/json，
+// BINARY -> application/octet-stream，其他为 text/plain。响应的数据类型由响应内容格式决定。
+//
+// Payloads larger than 1024 bytes are sent with Block1 and block-wise responses (Block2) are
+// fetched and merged automatically (RFC 7959).
+// 超过1024字节的负荷使用 Block1 分块发送，分块返回的响应（Block2）自动获取并合并（RFC 7959）。
+//
+// Output Metadata:
+// 输出元数据：
+//
+//   - statusCode: Response code, e.g. 2.05  响应码，例如 2.05
+//   - errorBody: Response payload of an error response  错误响应的负荷
+//
+// Output Relations:
+// 输出关系：
+//
+//   - Success: Response code 2.xx  响应码为 2.xx
+//   - Failure: Error response, timeout or reset by server  错误响应、超时或者被服务端复位
+//
+// Clients are shared through the SharedNode pattern; use "ref://" server to reuse a pooled client.
+// 客户端通过 SharedNode 模式共享，使用 "ref://" 引用连接池中的客户端。
+type CoapClientNode struct {
+	base.SharedNode[*coap.Client]
+	//节点配置
+	Config CoapClientNodeConfiguration
+	method coap.Code
+	//pathTemplate 资源路径模板
+	pathTemplate     *el.MixedTemplate
+	contentFormat    coap.MediaType
+	hasContentFormat bool
+}
+
+// Type 组件类型
+func (x *CoapClientNode) Type() string {
+	return "coapClient"
+}
+
+func (x *CoapClientNode) New() types.Node {
+	return &CoapClientNode{Config: CoapClientNodeConfiguration{
+		Server:      "127.0.0.1:5683",
+		Method:      "POST",
+		Path:        "/",
+		Confirmable: true,
+		TimeoutMs:   5000,
+	}}
+}
+
+// Init 初始化
+func (x *CoapClientNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.method, err = coap.ParseMethod(strings.TrimSpace(x.Config.Method)); err != nil {
+		return err
+	}
+	if x.pathTemplate, err = el.NewMixedTemplate(x.Config.Path); err != nil {
+		return err
+	}
+	if v := strings.TrimSpace(x.Config.ContentFormat); v != "" {
+		if x.contentFormat, x.hasContentFormat = coap.ParseMediaType(v); !x.hasContentFormat {
+			return fmt.Errorf("unsupported content format: %s", v)
+		}
+	}
+	if x.Config.TimeoutMs <= 0 {
+		x.Config.TimeoutMs = 5000
+	}
+	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, x.initClient, func(client *coap.Client) error {
+		return client.Close()
+	})
+}
+
+// OnMsg 处理消息
+func (x *CoapClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	req := &coap.Message{Type: coap.NonConfirmable, Code: x.method}
+	if x.Config.Confirmable {
+		req.Type = coap.Confirmable
+	}
+	path := x.Config.Path
+	if x.pathTemplate.HasVar() {
+		path = x.pathTemplate.ExecuteAsString(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	}
+	req.SetPath(path)
+	if x.method == coap.POST || x.method == coap.PUT {
+		req.Payload = msg.GetBytes()
+		if x.hasContentFormat {
+			req.SetContentFormat(x.contentFormat)
+		} else {
+			req.SetContentFormat(coap.MediaTypeOf(msg.GetDataType()))
+		}
+	}
+	resp, err := client.Do(req, time.Duration(x.Config.TimeoutMs)*time.Millisecond)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(StatusCodeMetadataKey, resp.Code.String())
+	if !resp.Code.IsSuccess() {
+		msg.Metadata.PutValue(ErrorBodyMetadataKey, string(resp.Payload))
+		ctx.TellFailure(msg, fmt.Errorf("coap response code: %s", resp.Code))
+		return
+	}
+	msg.SetDataType(resp.DataType())
+	msg.SetBytes(resp.Payload)
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *CoapClientNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+func (x *CoapClientNode) initClient() (*coap.Client, error) {
+	return coap.Dial("udp", x.Config.Server, time.Duration(x.Config.TimeoutMs)*time.Millisecond)
+}
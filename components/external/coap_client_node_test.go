@@ -0,0 +1,164 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/coap"
+)
+
+func TestCoapClientNode(t *testing.T) {
+	var targetNodeType = "coapClient"
+	server := "127.0.0.1:5698"
+	stop := createCoapServer(t, server)
+	defer stop()
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &CoapClientNode{}, types.Configuration{
+			"server":      "127.0.0.1:5683",
+			"method":      "POST",
+			"path":        "/",
+			"confirmable": true,
+			"timeoutMs":   5000,
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		test.NodeInit(t, targetNodeType, types.Configuration{
+			"server":    server,
+			"method":    "get",
+			"timeoutMs": -1,
+		}, types.Configuration{
+			"server":    server,
+			"timeoutMs": 5000,
+		}, Registry)
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server": server,
+			"method": "PATCH",
+		}, Registry)
+		assert.Equal(t, "unsupported coap method: PATCH", err.Error())
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server":        server,
+			"contentFormat": "image/png",
+		}, Registry)
+		assert.Equal(t, "unsupported content format: image/png", err.Error())
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		postNode, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server": server,
+			"method": "POST",
+			"path":   "/devices/${metadata.deviceId}?qos=1",
+		}, Registry)
+		assert.Nil(t, err)
+		defer postNode.Destroy()
+		getNode, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server":      server,
+			"method":      "GET",
+			"path":        "/missing",
+			"confirmable": false,
+		}, Registry)
+		assert.Nil(t, err)
+		defer getNode.Destroy()
+		timeoutNode, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server":    server,
+			"method":    "PUT",
+			"path":      "/drop",
+			"timeoutMs": 300,
+		}, Registry)
+		assert.Nil(t, err)
+		defer timeoutNode.Destroy()
+
+		metaData := types.BuildMetadata(map[string]string{"deviceId": "d1"})
+		msgList := []test.Msg{{MetaData: metaData, DataType: types.JSON, MsgType: "TELEMETRY", Data: `{"temperature":20}`, AfterSleep: time.Millisecond * 500}}
+		var nodeList = []test.NodeAndCallback{
+			{
+				Node:    postNode,
+				MsgList: msgList,
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Success, relationType)
+					assert.Equal(t, "2.04", msg.Metadata.GetValue(StatusCodeMetadataKey))
+					assert.Equal(t, `{"path":"/devices/d1","qos":"1","cf":"application/json","payload":{"temperature":20}}`, msg.GetData())
+					assert.Equal(t, types.JSON, msg.DataType)
+				},
+			},
+			{
+				Node:    getNode,
+				MsgList: msgList,
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Failure, relationType)
+					assert.Equal(t, "4.04", msg.Metadata.GetValue(StatusCodeMetadataKey))
+					assert.Equal(t, "not found", msg.Metadata.GetValue(ErrorBodyMetadataKey))
+				},
+			},
+			{
+				Node:    timeoutNode,
+				MsgList: msgList,
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Failure, relationType)
+					assert.Equal(t, coap.ErrTimeout, err)
+				},
+			},
+		}
+		for _, item := range nodeList {
+			test.NodeOnMsgWithChildren(t, item.Node, item.MsgList, item.ChildrenNodes, item.Callback)
+		}
+	})
+}
+
+// createCoapServer 创建CoAP测试服务：POST /devices/:id 返回请求信息，/drop 不响应，其他路径返回 4.04
+func createCoapServer(t *testing.T, server string) func() {
+	addr, err := net.ResolveUDPAddr("udp", server)
+	assert.Nil(t, err)
+	conn, err := net.ListenUDP("udp", addr)
+	assert.Nil(t, err)
+	go func() {
+		buf := make([]byte, coap.MaxMessageSize)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			req, err := coap.Unmarshal(buf[:n])
+			if err != nil || req.Path() == "/drop" {
+				continue
+			}
+			resp := &coap.Message{Type: coap.NonConfirmable, MessageID: req.MessageID + 1, Token: req.Token, Code: coap.NotFound, Payload: []byte("not found")}
+			if req.Type == coap.Confirmable {
+				resp.Type = coap.Acknowledgement
+				resp.MessageID = req.MessageID
+			}
+			if req.Code == coap.POST && req.Path() == "/devices/d1" {
+				cf, _ := req.ContentFormat()
+				resp.Code = coap.Changed
+				resp.Payload = []byte(`{"path":"` + req.Path() + `","qos":"` + req.Queries()["qos"] + `","cf":"` + cf.String() + `","payload":` + string(req.Payload) + `}`)
+				resp.SetContentFormat(coap.AppJSON)
+			}
+			data, _ := resp.Marshal()
+			_, _ = conn.WriteToUDP(data, addr)
+		}
+	}()
+	return func() {
+		_ = conn.Close()
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/coap"
+	"github.com/rulego/rulego/utils/el"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// 注册节点
+func init() {
+	Registry.Add(&CoapNotifyNode{})
+}
+
+// KeyCoapObservers 接收通知的观察者数量写入的元数据键
+const KeyCoapObservers = "coapObservers"
+
+// CoapNotifyNodeConfiguration coapNotify 节点配置
+type CoapNotifyNodeConfiguration struct {
+	// Server 目标 coap 端点的服务地址，与端点的 server 配置相同，例如 :5683
+	Server string `json:"server"`
+	// Path 观察的资源路径，与客户端 Observe 请求的路径对应。
+	// 可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	Path string `json:"path"`
+}
+
+// CoapNotifyNode pushes the message data as a 2.05 Content notification to the clients observing
+// a resource path of the coap endpoint (RFC 7641). The content format follows the message data type.
+// The coap endpoint is selected by the server configuration and must be started;
+// otherwise the message goes to the Failure chain.
+//
+// CoapNotifyNode 把消息负荷作为 2.05 Content 通知推送给观察 coap 端点资源路径的客户端（RFC 7641）。
+// 内容格式由消息数据类型决定。通过 server 配置选择 coap 端点，端点未启动时消息发送到 Failure 链。
+//
+// Configuration:
+// 配置说明：
+//
+//	{
+//		"server": ":5683",                          // Server address of the coap endpoint  coap 端点服务地址
+//		"path": "/sensors/${metadata.deviceId}"     // Observed resource path  观察的资源路径
+//	}
+//
+// Output metadata:
+// 输出元数据：
+//
+//   - coapObservers: Number of observers that were notified  接收通知的观察者数量
+type CoapNotifyNode struct {
+	//节点配置
+	Config       CoapNotifyNodeConfiguration
+	pathTemplate *el.MixedTemplate
+}
+
+// Type 组件类型
+func (x *CoapNotifyNode) Type() string {
+	return "coapNotify"
+}
+
+func (x *CoapNotifyNode) New() types.Node {
+	return &CoapNotifyNode{Config: CoapNotifyNodeConfiguration{
+		Server: ":5683",
+		Path:   "/",
+	}}
+}
+
+// Init 初始化
+func (x *CoapNotifyNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.Config.Server = strings.TrimSpace(x.Config.Server)
+	if x.Config.Server == "" {
+		return errors.New("server can not empty")
+	}
+	x.Config.Path = strings.TrimSpace(x.Config.Path)
+	if x.Config.Path == "" {
+		return errors.New("path can not empty")
+	}
+	x.pathTemplate, err = el.NewMixedTemplate(x.Config.Path)
+	return err
+}
+
+// OnMsg 处理消息
+func (x *CoapNotifyNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	notifier, ok := coap.GetNotifier(x.Config.Server)
+	if !ok {
+		ctx.TellFailure(msg, fmt.Errorf("coap endpoint not found: %s", x.Config.Server))
+		return
+	}
+	path := x.pathTemplate.ExecuteAsString(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	count := notifier.Notify(path, msg.GetDataType(), msg.GetBytes())
+	msg.Metadata.PutValue(KeyCoapObservers, strconv.Itoa(count))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *CoapNotifyNode) Destroy() {
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/coap"
+)
+
+// testNotifier 记录通知的 coap 端点
+type testNotifier struct {
+	lock     sync.Mutex
+	path     string
+	dataType types.DataType
+	data     string
+}
+
+func (n *testNotifier) Notify(path string, dataType types.DataType, data []byte) int {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.path, n.dataType, n.data = path, dataType, string(data)
+	return 2
+}
+
+func TestCoapNotifyNode(t *testing.T) {
+	var targetNodeType = "coapNotify"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &CoapNotifyNode{}, types.Configuration{
+			"server": ":5683",
+			"path":   "/",
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		test.NodeInit(t, targetNodeType, types.Configuration{
+			"server": ":5684",
+			"path":   "/sensors/${metadata.deviceId}",
+		}, types.Configuration{
+			"server": ":5684",
+			"path":   "/sensors/${metadata.deviceId}",
+		}, Registry)
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{"server": " "}, Registry)
+		assert.Equal(t, "server can not empty", err.Error())
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{"path": " "}, Registry)
+		assert.Equal(t, "path can not empty", err.Error())
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		server := ":15683"
+		notifier := &testNotifier{}
+		coap.RegisterNotifier(server, notifier)
+		defer coap.UnregisterNotifier(server, notifier)
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server": server,
+			"path":   "/sensors/${metadata.deviceId}",
+		}, Registry)
+		assert.Nil(t, err)
+		msgList := []test.Msg{{
+			MetaData:   types.BuildMetadata(map[string]string{"deviceId": "d1"}),
+			MsgType:    "TEST_MSG",
+			Data:       "{\"temperature\":41}",
+			DataType:   types.JSON,
+			AfterSleep: time.Millisecond * 100,
+		}}
+		test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, "2", msg.Metadata.GetValue(KeyCoapObservers))
+		})
+		notifier.lock.Lock()
+		defer notifier.lock.Unlock()
+		assert.Equal(t, "/sensors/d1", notifier.path)
+		assert.Equal(t, types.JSON, notifier.dataType)
+		assert.Equal(t, "{\"temperature\":41}", notifier.data)
+	})
+
+	t.Run("EndpointNotFound", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server": ":15684",
+			"path":   "/state",
+		}, Registry)
+		assert.Nil(t, err)
+		msgList := []test.Msg{{
+			MetaData:   types.NewMetadata(),
+			MsgType:    "TEST_MSG",
+			Data:       "on",
+			AfterSleep: time.Millisecond * 100,
+		}}
+		test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, "coap endpoint not found: :15684", err.Error())
+		})
+	})
+}
@@ -20,6 +20,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -41,26 +42,78 @@ const (
 	INSERT = "INSERT"
 	DELETE = "DELETE"
 	UPDATE = "UPDATE"
+	// UPSERT 插入或更新，包括 UPSERT、REPLACE、MERGE 语句以及 INSERT ... ON CONFLICT/ON DUPLICATE KEY UPDATE
+	UPSERT = "UPSERT"
+	// DDL 数据定义语句，包括 CREATE、ALTER、DROP、TRUNCATE
+	DDL = "DDL"
+)
+const (
+	// sqliteDriverName 内置的纯Go SQLite驱动名称，使用 with_sqlite 编译标签时注册
+	sqliteDriverName = "sqlite"
+	// sqlite3DriverName 兼容 mattn/go-sqlite3 的驱动名称，未注册该驱动时使用内置的SQLite驱动
+	sqlite3DriverName = "sqlite3"
 )
 const (
 	rowsAffectedKey = "rowsAffected"
 	lastInsertIdKey = "lastInsertId"
+	// rowIndexKey 流式模式下当前行的索引
+	rowIndexKey = "rowIndex"
+	// rowCountKey 流式模式下查询结果的行数
+	rowCountKey = "rowCount"
 )
 
+// 查询结果模式
+const (
+	// ResultModeList 默认模式，返回行数组，GetOne=true时返回第一行
+	ResultModeList = "list"
+	// ResultModeScalar 返回第一行第一列的值
+	ResultModeScalar = "scalar"
+	// ResultModeKeyed 返回以 KeyColumn 列的值为键、行为值的对象
+	ResultModeKeyed = "keyed"
+	// ResultModeStream 逐行通过True关系发送，每行一条消息，全部发送后原消息通过Success关系发送
+	ResultModeStream = "stream"
+)
+
+// DbStatement 多语句模式下的一条SQL语句
+type DbStatement struct {
+	// Sql SQL语句
+	Sql string
+	// Params SQL语句参数列表，可以使用 ${metadata.key} 或者 ${msg.key} 进行替换
+	Params []interface{}
+	// NamedParams 命名参数，参考 DbClientNodeConfiguration.NamedParams
+	NamedParams map[string]interface{}
+}
+
 // DbClientNodeConfiguration 节点配置
 type DbClientNodeConfiguration struct {
-	// DriverName 数据库驱动名称，mysql或postgres
+	// DriverName 数据库驱动名称，mysql、postgres或sqlite。内置的sqlite驱动需要使用 with_sqlite 编译标签，
+	// 或者导入其他已注册的sqlite驱动
 	DriverName string
-	// Dsn 数据库连接配置，参考sql.Open参数
+	// Dsn 数据库连接配置，参考sql.Open参数。sqlite为数据库文件路径，例如：/data/rulego.db?_pragma=busy_timeout(5000)
 	Dsn string
-	// PoolSize 连接池大小
+	// PoolSize 连接池大小，sqlite未配置时为1
 	PoolSize int
 	// Sql SQL语句，v0.23.0之后不再支持运行时变量进行替换
 	Sql string
 	// Params SQL语句参数列表，可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
 	Params []interface{}
+	// NamedParams 命名参数，SQL语句中使用 :name 引用，值可以使用 ${metadata.key}、${msg.key} 或者表达式，
+	// 例如：sql: "select * from users where id = :id"，namedParams: {"id": "${msg.id}"}。不能与 Params 同时使用
+	NamedParams map[string]interface{}
 	// GetOne 是否只返回一条记录，true:返回结构不是数组结构，false：返回数据是数组结构
 	GetOne bool
+	// ResultMode 查询结果模式：list(默认)、scalar、keyed、stream
+	ResultMode string
+	// KeyColumn keyed模式下作为键的列名
+	KeyColumn string
+	// ColumnMapping 查询结果列名重命名，key为列名，value为字段名
+	ColumnMapping map[string]string
+	// Statements 多语句模式，按顺序在同一个事务中执行的SQL语句列表，任意一条语句失败则回滚。
+	// 配置后忽略 Sql 和 Params
+	Statements []DbStatement
+	// Batch 批量模式，msg.Data 必须是JSON数组，在同一个事务中对数组的每个元素执行一次预编译的 Sql，
+	// Sql 只能是 INSERT 或 UPSERT 语句，Params 中的 ${msg.key} 读取当前元素的字段
+	Batch bool
 }
 
 // DbClientNode 为RuleGo规则引擎提供通用数据库连接和SQL执行能力的外部组件
@@ -75,13 +128,14 @@ type DbClientNodeConfiguration struct {
 // 5. 使用连接池进行高效资源管理 - Use connection pooling for efficient resource management
 //
 // 数据库驱动支持 - Database driver support:
-//   - 内置支持：MySQL、PostgreSQL - Built-in: MySQL, PostgreSQL
-//   - 第三方驱动：TDengine、SQL Server、Oracle、ClickHouse、SQLite、Snowflake等 - Third-party: TDengine, SQL Server, Oracle, ClickHouse, SQLite, Snowflake, etc.
+//   - 内置支持：MySQL、PostgreSQL、SQLite（纯Go实现，无需CGO和数据库服务，需要 with_sqlite 编译标签） - Built-in: MySQL, PostgreSQL, SQLite (pure Go, no CGO or database server needed, requires the with_sqlite build tag)
+//   - 第三方驱动：TDengine、SQL Server、Oracle、ClickHouse、Snowflake等 - Third-party: TDengine, SQL Server, Oracle, ClickHouse, Snowflake, etc.
 //   - 支持任何实现database/sql接口的驱动 - Supports any driver implementing database/sql interface
 //
 // 变量替换 - Variable substitution:
 //   - ${metadata.key}: 从消息元数据获取值 - Access message metadata
 //   - ${msg.key}: 从消息负荷获取值 - Access message payload variables
+//   - NamedParams: SQL中使用 :name 引用命名参数，值为上述模板或表达式 - Reference named params with :name in SQL, values are the templates or expressions above
 //
 // SQL占位符转换 - SQL placeholder conversion:
 //   - MySQL/SQLite: 使用?占位符 - Uses ? placeholders
@@ -92,6 +146,25 @@ type DbClientNodeConfiguration struct {
 //   - SELECT: 返回查询结果到消息数据 - Returns query results in message data
 //   - INSERT: 设置rowsAffected和lastInsertId到元数据 - Sets rowsAffected and lastInsertId in metadata
 //   - UPDATE/DELETE: 设置rowsAffected到元数据 - Sets rowsAffected in metadata
+//   - DDL: 设置rowsAffected为0到元数据 - Sets rowsAffected to 0 in metadata
+//   - UPSERT: 同INSERT - Same as INSERT
+//
+// 查询结果模式 - Query result modes:
+//   - list: 默认，返回行数组，GetOne=true时返回第一行 - Default, returns an array of rows, or the first row if GetOne=true
+//   - scalar: 返回第一行第一列的值 - Returns the value of the first column of the first row
+//   - keyed: 返回以KeyColumn列的值为键的对象 - Returns an object keyed by the value of KeyColumn
+//   - stream: 每行一条消息通过True关系发送，元数据rowIndex为行索引，全部发送后原消息通过Success关系发送，元数据rowCount为行数 -
+//     Sends one message per row via the True relation with rowIndex in metadata, then the original message via Success with rowCount in metadata.
+//     行在结果集读取完成并释放连接后发送，后续节点可以使用同一个连接池 -
+//     Rows are sent after the result set is read and its connection released, so later nodes can use the same pool
+//   - ColumnMapping: 所有模式下对列名重命名 - Renames columns in all modes
+//
+// 事务和批量执行 - Transactional and batch execution:
+//   - Statements: 在同一个事务中按顺序执行多条语句，任意一条失败则回滚，rowsAffected为所有语句影响行数之和，
+//     最后一条SELECT语句的结果作为消息数据 - Runs several statements in order in one transaction and rolls back on error.
+//     rowsAffected is the sum over all statements, the result of the last SELECT becomes the message data
+//   - Batch: msg.Data为JSON数组时，在同一个事务中对每个元素执行一次预编译的INSERT/UPSERT语句 -
+//     Runs one prepared INSERT/UPSERT once per element of the JSON array in msg.Data in one transaction
 //
 // 连接管理 - Connection management:
 //   - 使用连接池和SharedNode模式共享连接 - Uses connection pooling and SharedNode pattern for sharing connections
@@ -100,10 +173,17 @@ type DbClientNode struct {
 	base.SharedNode[*sql.DB]
 	//节点配置
 	Config DbClientNodeConfiguration
-	//操作类型 SELECT\UPDATE\INSERT\DELETE
+	//单语句或批量模式执行的语句
+	statement *dbStatement
+	//多语句模式执行的语句列表
+	statements []*dbStatement
+}
+
+// dbStatement 初始化后的SQL语句
+type dbStatement struct {
+	sql string
+	//操作类型 SELECT\UPDATE\INSERT\DELETE\UPSERT\DDL
 	opType string
-	//sqlTemplate    str.Template
-	//paramsTemplate []str.Template
 	//sql是否有变量
 	sqlHasVar bool
 	//参数是否有变量
@@ -111,6 +191,22 @@ type DbClientNode struct {
 	paramsTemplate []el.Template
 }
 
+// dbResult SQL语句执行结果
+type dbResult struct {
+	data            interface{}
+	hasData         bool
+	rowsAffected    int64
+	hasRowsAffected bool
+	lastInsertId    int64
+	hasInsertId     bool
+}
+
+// sqlExecutor 由 *sql.DB 和 *sql.Tx 实现
+type sqlExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
 // Type 返回组件类型
 func (x *DbClientNode) Type() string {
 	return "dbClient"
@@ -135,33 +231,30 @@ func (x *DbClientNode) Init(ruleConfig types.Config, configuration types.Configu
 	}
 
 	if !base.NodeUtils.IsInitNetResource(ruleConfig, configuration) {
-		if x.Config.Sql == "" {
-			return errors.New("sql can not empty")
+		if err = x.checkResultMode(); err != nil {
+			return err
 		}
-		//检查是否需要转换成$1风格占位符
-		x.Config.Sql = str.ConvertDollarPlaceholder(x.Config.Sql, x.Config.DriverName)
-		if str.CheckHasVar(x.Config.Sql) {
-			x.sqlHasVar = true
-		}
-		if !x.sqlHasVar {
-			x.opType = x.getOpType(x.Config.Sql)
-			if err = x.checkOpType(x.opType, x.Config.Sql); err != nil {
-				return err
+		if len(x.Config.Statements) > 0 {
+			if x.Config.Batch {
+				return errors.New("batch mode can not be used with statements")
 			}
-		}
-		//检查是参数否有变量
-		for _, item := range x.Config.Params {
-
-			if temp, err := el.NewTemplate(item); err != nil {
+			for _, item := range x.Config.Statements {
+				statement, err := x.newStatement(item.Sql, item.Params, item.NamedParams)
+				if err != nil {
+					return err
+				}
+				x.statements = append(x.statements, statement)
+			}
+		} else {
+			if x.statement, err = x.newStatement(x.Config.Sql, x.Config.Params, x.Config.NamedParams); err != nil {
 				return err
-			} else {
-				x.paramsTemplate = append(x.paramsTemplate, temp)
-				if !temp.IsNotVar() {
-					x.paramsHasVar = true
+			}
+			if x.Config.Batch && !x.statement.sqlHasVar {
+				if err = x.checkBatchOpType(x.statement.opType, x.statement.sql); err != nil {
+					return err
 				}
 			}
 		}
-
 	}
 	//初始化客户端
 	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), x.Config.Dsn, ruleConfig.NodeClientInitNow, func() (*sql.DB, error) {
@@ -172,148 +265,426 @@ func (x *DbClientNode) Init(ruleConfig types.Config, configuration types.Configu
 	})
 }
 
-// OnMsg 处理消息，执行SQL操作并处理结果
-// OnMsg processes messages by executing SQL operations and handling results.
-func (x *DbClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
-	var data interface{}
-	var err error
-	var rowsAffected int64
-	var lastInsertId int64
-	var evn map[string]interface{}
-	if x.sqlHasVar || x.paramsHasVar {
-		evn = base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+// checkResultMode 检查查询结果模式配置
+func (x *DbClientNode) checkResultMode() error {
+	switch x.Config.ResultMode {
+	case "", ResultModeList, ResultModeScalar:
+	case ResultModeKeyed:
+		if x.Config.KeyColumn == "" {
+			return errors.New("keyColumn can not empty in keyed result mode")
+		}
+	case ResultModeStream:
+		if len(x.Config.Statements) > 0 || x.Config.Batch {
+			return errors.New("stream result mode can not be used with statements or batch mode")
+		}
+	default:
+		return fmt.Errorf("unsupported result mode: %s", x.Config.ResultMode)
+	}
+	return nil
+}
+
+// newStatement 解析SQL语句和参数模板
+func (x *DbClientNode) newStatement(sqlStr string, params []interface{}, namedParams map[string]interface{}) (*dbStatement, error) {
+	if sqlStr == "" {
+		return nil, errors.New("sql can not empty")
+	}
+	if len(namedParams) > 0 {
+		if len(params) > 0 {
+			return nil, errors.New("params and namedParams can not be used together")
+		}
+		//把 :name 转换成 ? 占位符，并按出现顺序生成参数列表
+		var names []string
+		sqlStr, names = parseNamedParams(sqlStr)
+		for _, name := range names {
+			value, ok := namedParams[name]
+			if !ok {
+				return nil, fmt.Errorf("named param %s not found", name)
+			}
+			params = append(params, value)
+		}
 	}
-	var sqlStr = x.Config.Sql
-	if x.sqlHasVar {
+	//检查是否需要转换成$1风格占位符
+	statement := &dbStatement{sql: str.ConvertDollarPlaceholder(sqlStr, x.Config.DriverName)}
+	if str.CheckHasVar(statement.sql) {
+		statement.sqlHasVar = true
+	}
+	if !statement.sqlHasVar {
+		statement.opType = x.getOpType(statement.sql)
+		if err := x.checkOpType(statement.opType, statement.sql); err != nil {
+			return nil, err
+		}
+	}
+	//检查是参数否有变量
+	for _, item := range params {
+		if temp, err := el.NewTemplate(item); err != nil {
+			return nil, err
+		} else {
+			statement.paramsTemplate = append(statement.paramsTemplate, temp)
+			if !temp.IsNotVar() {
+				statement.paramsHasVar = true
+			}
+		}
+	}
+	return statement, nil
+}
+
+// hasVar 语句或参数是否有变量
+func (s *dbStatement) hasVar() bool {
+	return s.sqlHasVar || s.paramsHasVar
+}
+
+// resolveSql 替换SQL语句变量并返回操作类型
+func (x *DbClientNode) resolveSql(statement *dbStatement, evn map[string]interface{}) (string, string, error) {
+	var sqlStr = statement.sql
+	if statement.sqlHasVar {
 		//转换sql变量
-		sqlStr = str.ExecuteTemplate(x.Config.Sql, evn)
+		sqlStr = str.ExecuteTemplate(statement.sql, evn)
 		sqlStr = str.ConvertDollarPlaceholder(sqlStr, x.Config.DriverName)
 	}
-	opType := x.opType
+	opType := statement.opType
 	if opType == "" {
 		opType = x.getOpType(sqlStr)
 		if err := x.checkOpType(opType, sqlStr); err != nil {
-			ctx.TellFailure(msg, err)
-			return
+			return "", "", err
 		}
 	}
+	return sqlStr, opType, nil
+}
+
+// resolveParams 替换参数变量
+func (x *DbClientNode) resolveParams(statement *dbStatement, evn map[string]interface{}) ([]interface{}, error) {
 	var params []interface{}
-	//转换参数变量
-	for _, item := range x.paramsTemplate {
+	for _, item := range statement.paramsTemplate {
 		param, err := item.Execute(evn)
 		if err != nil {
-			ctx.TellFailure(msg, err)
-			return
+			return nil, err
 		}
 		params = append(params, param)
 	}
+	return params, nil
+}
+
+// OnMsg 处理消息，执行SQL操作并处理结果
+// OnMsg processes messages by executing SQL operations and handling results.
+func (x *DbClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 	client, err := x.SharedNode.GetSafely()
 	if err != nil {
 		ctx.TellFailure(msg, err)
 		return
 	}
+	var result dbResult
+	if len(x.statements) > 0 {
+		result, err = x.execStatements(ctx, client, msg)
+	} else if x.Config.Batch {
+		result, err = x.execBatch(ctx, client, msg)
+	} else {
+		var evn map[string]interface{}
+		if x.statement.hasVar() {
+			evn = base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+		}
+		if x.Config.ResultMode == ResultModeStream {
+			x.queryStream(ctx, client, msg, evn)
+			return
+		}
+		result, err = x.execStatement(client, x.statement, evn)
+	}
 
+	if err != nil {
+		ctx.TellFailure(msg, err)
+	} else {
+		if result.hasData {
+			msg.SetData(str.ToString(result.data))
+		}
+		if result.hasRowsAffected {
+			msg.Metadata.PutValue(rowsAffectedKey, str.ToString(result.rowsAffected))
+		}
+		if result.hasInsertId {
+			msg.Metadata.PutValue(lastInsertIdKey, str.ToString(result.lastInsertId))
+		}
+		ctx.TellSuccess(msg)
+	}
+}
+
+// queryStream 逐行发送查询结果，每行一条消息通过True关系发送，全部发送后原消息携带行数通过Success关系发送。
+// 结果集读取完成并释放连接后再发送，后续节点使用同一个连接池（例如sqlite默认 PoolSize=1）时不会死锁
+func (x *DbClientNode) queryStream(ctx types.RuleContext, client *sql.DB, msg types.RuleMsg, evn map[string]interface{}) {
+	sqlStr, opType, err := x.resolveSql(x.statement, evn)
+	if err == nil && opType != SELECT {
+		err = fmt.Errorf("stream result mode only supports select statement: %s", sqlStr)
+	}
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	params, err := x.resolveParams(x.statement, evn)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var rows []string
+	err = x.queryRows(client, sqlStr, params, func(columns []string, row map[string]interface{}) (bool, error) {
+		rows = append(rows, str.ToString(row))
+		return true, nil
+	})
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	for index, row := range rows {
+		rowMsg := msg.CopyAsChild()
+		rowMsg.DataType = types.JSON
+		rowMsg.Metadata.PutValue(rowIndexKey, strconv.Itoa(index))
+		rowMsg.SetData(row)
+		ctx.TellNext(rowMsg, types.True)
+	}
+	msg.Metadata.PutValue(rowCountKey, strconv.Itoa(len(rows)))
+	ctx.TellSuccess(msg)
+}
+
+// execStatement 执行一条语句
+func (x *DbClientNode) execStatement(client sqlExecutor, statement *dbStatement, evn map[string]interface{}) (dbResult, error) {
+	var result dbResult
+	sqlStr, opType, err := x.resolveSql(statement, evn)
+	if err != nil {
+		return result, err
+	}
+	params, err := x.resolveParams(statement, evn)
+	if err != nil {
+		return result, err
+	}
 	switch opType {
 	case SELECT:
-		data, err = x.query(client, sqlStr, params, x.Config.GetOne)
+		result.data, err = x.query(client, sqlStr, params, x.Config.GetOne)
+		result.hasData = true
 	case UPDATE:
-		rowsAffected, err = x.update(client, sqlStr, params)
-	case INSERT:
-		rowsAffected, lastInsertId, err = x.insert(client, sqlStr, params)
+		result.rowsAffected, err = x.update(client, sqlStr, params)
+	case DDL:
+		// DDL语句不影响数据行，部分驱动（如sqlite）会返回上一条语句的影响行数，因此忽略
+		_, err = client.Exec(sqlStr, params...)
+	case INSERT, UPSERT:
+		result.rowsAffected, result.lastInsertId, err = x.insert(client, sqlStr, params)
+		result.hasInsertId = true
 	case DELETE:
-		rowsAffected, err = x.delete(client, sqlStr, params)
+		result.rowsAffected, err = x.delete(client, sqlStr, params)
 	default:
 		err = fmt.Errorf("unsupported sql statement: %s", sqlStr)
 	}
+	result.hasRowsAffected = !result.hasData
+	return result, err
+}
 
+// execStatements 在同一个事务中按顺序执行多条语句，任意一条失败则回滚
+func (x *DbClientNode) execStatements(ctx types.RuleContext, client *sql.DB, msg types.RuleMsg) (dbResult, error) {
+	var result dbResult
+	var evn map[string]interface{}
+	for _, statement := range x.statements {
+		if statement.hasVar() {
+			evn = base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+			break
+		}
+	}
+	err := x.withTx(client, func(tx *sql.Tx) error {
+		for _, statement := range x.statements {
+			itemResult, err := x.execStatement(tx, statement, evn)
+			if err != nil {
+				return err
+			}
+			if itemResult.hasData {
+				result.data = itemResult.data
+				result.hasData = true
+			}
+			if itemResult.hasInsertId {
+				result.lastInsertId = itemResult.lastInsertId
+				result.hasInsertId = true
+			}
+			result.rowsAffected += itemResult.rowsAffected
+		}
+		result.hasRowsAffected = true
+		return nil
+	})
+	return result, err
+}
+
+// execBatch 在同一个事务中对JSON数组的每个元素执行一次预编译的 INSERT/UPSERT 语句
+func (x *DbClientNode) execBatch(ctx types.RuleContext, client *sql.DB, msg types.RuleMsg) (dbResult, error) {
+	var result dbResult
+	jsonData, err := msg.GetJsonData()
 	if err != nil {
-		ctx.TellFailure(msg, err)
-	} else {
-		switch opType {
-		case SELECT:
-			msg.SetData(str.ToString(data))
-		case UPDATE, DELETE:
-			msg.Metadata.PutValue(rowsAffectedKey, str.ToString(rowsAffected))
-		case INSERT:
-			msg.Metadata.PutValue(rowsAffectedKey, str.ToString(rowsAffected))
-			msg.Metadata.PutValue(lastInsertIdKey, str.ToString(lastInsertId))
+		return result, err
+	}
+	items, ok := jsonData.([]interface{})
+	if !ok {
+		return result, errors.New("batch mode requires msg data to be a json array")
+	}
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	sqlStr, opType, err := x.resolveSql(x.statement, evn)
+	if err != nil {
+		return result, err
+	}
+	if err = x.checkBatchOpType(opType, sqlStr); err != nil {
+		return result, err
+	}
+	err = x.withTx(client, func(tx *sql.Tx) error {
+		stmt, err := tx.Prepare(sqlStr)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		// 每个元素使用独立的环境变量，${msg.key} 读取当前元素的字段
+		itemEvn := make(map[string]interface{}, len(evn))
+		for k, v := range evn {
+			itemEvn[k] = v
+		}
+		for _, item := range items {
+			itemEvn[types.MsgKey] = item
+			params, err := x.resolveParams(x.statement, itemEvn)
+			if err != nil {
+				return err
+			}
+			execResult, err := stmt.Exec(params...)
+			if err != nil {
+				return err
+			}
+			rowsAffected, err := execResult.RowsAffected()
+			if err != nil {
+				return err
+			}
+			result.rowsAffected += rowsAffected
+			if lastInsertId, err := execResult.LastInsertId(); err == nil {
+				result.lastInsertId = lastInsertId
+			}
+		}
+		result.hasRowsAffected = true
+		result.hasInsertId = true
+		return nil
+	})
+	return result, err
+}
+
+// withTx 在事务中执行fn，fn返回错误则回滚，否则提交
+func (x *DbClientNode) withTx(client *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := client.Begin()
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w, rollback error: %v", err, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// query 查询数据并根据结果模式返回map、slice或者单个值
+func (x *DbClientNode) query(client sqlExecutor, sqlStr string, params []interface{}, getOne bool) (interface{}, error) {
+	switch x.Config.ResultMode {
+	case ResultModeScalar:
+		var value interface{}
+		err := x.queryRows(client, sqlStr, params, func(columns []string, row map[string]interface{}) (bool, error) {
+			value = row[columns[0]]
+			return false, nil
+		})
+		return value, err
+	case ResultModeKeyed:
+		keyField := x.mapColumn(x.Config.KeyColumn)
+		result := make(map[string]interface{})
+		err := x.queryRows(client, sqlStr, params, func(columns []string, row map[string]interface{}) (bool, error) {
+			key, ok := row[keyField]
+			if !ok {
+				return false, fmt.Errorf("key column %s not found", x.Config.KeyColumn)
+			}
+			result[str.ToString(key)] = row
+			return true, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	default:
+		// 创建一个空的 map 切片，用于存储最终结果
+		result := make([]map[string]interface{}, 0)
+		err := x.queryRows(client, sqlStr, params, func(columns []string, row map[string]interface{}) (bool, error) {
+			result = append(result, row)
+			return !getOne, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if getOne {
+			if len(result) > 0 {
+				return result[0], nil // 如果只有一条记录，返回map类型
+			} else {
+				return nil, nil
+			}
+		} else {
+			return result, nil // 否则返回slice类型
 		}
-		ctx.TellSuccess(msg)
 	}
 }
 
-// query 查询数据并返回map或slice类型
-func (x *DbClientNode) query(client *sql.DB, sqlStr string, params []interface{}, getOne bool) (interface{}, error) {
+// queryRows 查询数据并逐行回调，列名已按 ColumnMapping 重命名，回调返回false则停止遍历
+func (x *DbClientNode) queryRows(client sqlExecutor, sqlStr string, params []interface{}, fn func(columns []string, row map[string]interface{}) (bool, error)) error {
 	rows, err := client.Query(sqlStr, params...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
-	// 获取列名和列类型
+	// 获取列名
 	columns, err := rows.Columns()
 	if err != nil {
-		return nil, err
+		return err
+	}
+	fields := make([]string, len(columns))
+	for i, column := range columns {
+		fields[i] = x.mapColumn(column)
 	}
 
-	// 创建一个固定大小的 map 和切片，用于存储每一行的数据
-	row := make(map[string]interface{}, len(columns))
+	// 创建一个固定大小的切片，用于存储每一行的数据
 	values := make([]interface{}, len(columns))
-
 	// 遍历每一列，初始化 interface{} 切片中的值
 	for i := range columns {
 		var v interface{}
 		values[i] = &v
-		row[columns[i]] = &v
 	}
 
-	// 创建一个空的 map 切片，用于存储最终结果
-	result := make([]map[string]interface{}, 0)
-
 	// 遍历结果集中的每一行数据
 	for rows.Next() {
 		// 调用 rows.Scan 方法，将结果存储在指针切片中
-		err = rows.Scan(values...)
-		if err != nil {
-			return nil, err
+		if err = rows.Scan(values...); err != nil {
+			return err
 		}
-
-		// 将当前行的 map 深拷贝到一个新的 map 中，避免后续循环覆盖数据
-		m := make(map[string]interface{}, len(row))
-		for k, v := range row {
-			var temp = v
+		// 每一行使用新的 map，避免后续循环覆盖数据
+		m := make(map[string]interface{}, len(fields))
+		for i, field := range fields {
+			v := *(values[i].(*interface{}))
 			// 如果值是 []byte 类型，转换成 string 类型
-			if b1, ok := v.(*interface{}); ok {
-				if b, ok := (*b1).([]byte); ok {
-					temp = string(b)
-				} else {
-					temp = *b1
-				}
+			if b, ok := v.([]byte); ok {
+				m[field] = string(b)
+			} else {
+				m[field] = v
 			}
-			m[k] = temp
 		}
-		// 将新的 map 追加到结果切片中
-		result = append(result, m)
+		if next, err := fn(fields, m); err != nil {
+			return err
+		} else if !next {
+			break
+		}
 	}
-
 	// 检查是否有错误发生
-	if err = rows.Err(); err != nil {
-		return nil, err
-	}
+	return rows.Err()
+}
 
-	if getOne {
-		if len(result) > 0 {
-			return result[0], nil // 如果只有一条记录，返回map类型
-		} else {
-			return nil, nil
-		}
-	} else {
-		return result, nil // 否则返回slice类型
+// mapColumn 返回列名对应的字段名
+func (x *DbClientNode) mapColumn(column string) string {
+	if field, ok := x.Config.ColumnMapping[column]; ok && field != "" {
+		return field
 	}
-
+	return column
 }
 
 // update 修改数据并返回影响行数
-func (x *DbClientNode) update(client *sql.DB, sqlStr string, params []interface{}) (int64, error) {
+func (x *DbClientNode) update(client sqlExecutor, sqlStr string, params []interface{}) (int64, error) {
 	result, err := client.Exec(sqlStr, params...)
 	if err != nil {
 		return 0, err
@@ -326,7 +697,7 @@ func (x *DbClientNode) update(client *sql.DB, sqlStr string, params []interface{
 }
 
 // insert 插入数据并返回自增ID
-func (x *DbClientNode) insert(client *sql.DB, sqlStr string, params []interface{}) (int64, int64, error) {
+func (x *DbClientNode) insert(client sqlExecutor, sqlStr string, params []interface{}) (int64, int64, error) {
 	result, err := client.Exec(sqlStr, params...)
 	if err != nil {
 		return 0, 0, err
@@ -342,7 +713,7 @@ func (x *DbClientNode) insert(client *sql.DB, sqlStr string, params []interface{
 }
 
 // delete 删除数据并返回影响行数
-func (x *DbClientNode) delete(client *sql.DB, sqlStr string, params []interface{}) (int64, error) {
+func (x *DbClientNode) delete(client sqlExecutor, sqlStr string, params []interface{}) (int64, error) {
 	result, err := client.Exec(sqlStr, params...)
 	if err != nil {
 		return 0, err
@@ -361,29 +732,131 @@ func (x *DbClientNode) Destroy() {
 
 // initClient 初始化客户端
 func (x *DbClientNode) initClient() (*sql.DB, error) {
-	client, err := sql.Open(x.Config.DriverName, x.Config.Dsn)
+	driverName := x.driverName()
+	client, err := sql.Open(driverName, x.Config.Dsn)
 	if err == nil {
-		client.SetMaxOpenConns(x.Config.PoolSize)
-		client.SetMaxIdleConns(x.Config.PoolSize / 2)
+		poolSize := x.Config.PoolSize
+		maxIdleConns := poolSize / 2
+		if driverName == sqliteDriverName {
+			// sqlite同一时间只允许一个写连接，内存数据库的每个连接也是独立的数据库，默认使用单连接，
+			// 并至少保留一个空闲连接，避免内存数据库随连接关闭而丢失
+			if poolSize <= 0 {
+				poolSize = 1
+			}
+			if maxIdleConns < 1 {
+				maxIdleConns = 1
+			}
+		}
+		client.SetMaxOpenConns(poolSize)
+		client.SetMaxIdleConns(maxIdleConns)
 		err = client.Ping()
 	}
 	return client, err
 }
 
+// driverName 返回sql.Open使用的驱动名称，未注册sqlite3驱动时使用内置的sqlite驱动
+func (x *DbClientNode) driverName() string {
+	if x.Config.DriverName == sqlite3DriverName {
+		for _, item := range sql.Drivers() {
+			if item == sqlite3DriverName {
+				return sqlite3DriverName
+			}
+		}
+		return sqliteDriverName
+	}
+	return x.Config.DriverName
+}
+
 func (x *DbClientNode) getOpType(sql string) string {
 	if sql == "" {
 		return ""
 	}
 	words := strings.Fields(sql)
-	return strings.ToUpper(words[0])
+	if len(words) == 0 {
+		return ""
+	}
+	opType := strings.ToUpper(words[0])
+	switch opType {
+	case "UPSERT", "REPLACE", "MERGE":
+		return UPSERT
+	case "CREATE", "ALTER", "DROP", "TRUNCATE":
+		return DDL
+	case INSERT:
+		upperSql := strings.ToUpper(strings.Join(words, " "))
+		if strings.Contains(upperSql, " ON CONFLICT") || strings.Contains(upperSql, " ON DUPLICATE KEY UPDATE") {
+			return UPSERT
+		}
+	}
+	return opType
 }
 
 func (x *DbClientNode) checkOpType(opType string, sql string) error {
 	//检查操作类型是否支持
 	switch opType {
-	case SELECT, UPDATE, INSERT, DELETE:
+	case SELECT, UPDATE, INSERT, DELETE, UPSERT, DDL:
 		return nil
 	default:
 		return fmt.Errorf("unsupported sql statement: %s", sql)
 	}
 }
+
+// checkBatchOpType 批量模式只支持 INSERT 和 UPSERT 语句
+func (x *DbClientNode) checkBatchOpType(opType string, sql string) error {
+	switch opType {
+	case INSERT, UPSERT:
+		return nil
+	default:
+		return fmt.Errorf("batch mode only supports insert or upsert statement: %s", sql)
+	}
+}
+
+// parseNamedParams 把SQL语句中的 :name 命名参数替换成 ? 占位符，返回替换后的SQL语句和按出现顺序排列的参数名。
+// 忽略字符串、引号标识符、${} 变量中的冒号以及 postgres 的 :: 类型转换
+func parseNamedParams(sql string) (string, []string) {
+	var builder strings.Builder
+	var names []string
+	n := len(sql)
+	for i := 0; i < n; i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(sql[i+1:], c)
+			if end < 0 {
+				builder.WriteString(sql[i:])
+				return builder.String(), names
+			}
+			builder.WriteString(sql[i : i+end+2])
+			i += end + 1
+		case c == '$' && i+1 < n && sql[i+1] == '{':
+			end := strings.IndexByte(sql[i:], '}')
+			if end < 0 {
+				builder.WriteString(sql[i:])
+				return builder.String(), names
+			}
+			builder.WriteString(sql[i : i+end+1])
+			i += end
+		case c == ':' && i+1 < n && sql[i+1] == ':':
+			builder.WriteString("::")
+			i++
+		case c == ':' && i+1 < n && isNamedParamStart(sql[i+1]):
+			end := i + 2
+			for end < n && isNamedParamPart(sql[end]) {
+				end++
+			}
+			names = append(names, sql[i+1:end])
+			builder.WriteByte('?')
+			i = end - 1
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String(), names
+}
+
+func isNamedParamStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNamedParamPart(c byte) bool {
+	return isNamedParamStart(c) || (c >= '0' && c <= '9')
+}
@@ -28,11 +28,13 @@
 // Database Components:
 // 数据库组件：
 //   - DbClientNode: Connect to database via Go standard database/sql interface
+//   - RedisClientNode: Execute arbitrary Redis commands, requires the with_redis build tag
+//     执行任意 Redis 命令，需要 with_redis 编译标签
 //
 // Message Broker Components:
 // 消息代理组件：
-//   - MqttClientNode: MQTT broker connectivity for IoT and messaging
-//     MQTT 代理连接，用于物联网和消息传递
+//   - MqttClientNode: MQTT 3.1.1 and MQTT 5 broker connectivity for IoT and messaging
+//     MQTT 3.1.1 和 MQTT 5 代理连接，用于物联网和消息传递
 //
 // Network Components:
 // 网络组件：
@@ -40,6 +42,18 @@
 //     TCP/UDP/Unix 套接字通信，支持各种协议
 //   - RestApiCallNode: HTTP/REST API client for web service integration
 //     HTTP/REST API 客户端，用于 Web 服务集成
+//   - GrpcClientNode: Unary gRPC client driven by .proto files or descriptor sets, requires the with_grpc build tag
+//     基于 .proto 文件或描述集的一元 gRPC 客户端，需要 with_grpc 编译标签
+//   - SsePublishNode: Publish messages to Server-Sent Events clients of the sse endpoint
+//     发布消息到 sse 端点的 Server-Sent Events 客户端
+//   - EndpointSendNode: Push messages to live websocket or tcp net endpoint connections
+//     推送消息到 websocket 或 tcp net 端点的在线连接
+//   - CoapClientNode: CoAP client over UDP for constrained devices
+//     基于 UDP 的 CoAP 客户端，用于受限设备
+//   - CoapNotifyNode: Push notifications to the observers of a coap endpoint resource
+//     推送通知到 coap 端点资源的观察者
+//   - ModbusClientNode: Modbus TCP client reading and writing coils and registers
+//     读写线圈和寄存器的 Modbus TCP 客户端
 //
 // Remote Execution Components:
 // 远程执行组件：
@@ -54,6 +68,8 @@
 //     在缓存中存储数据，支持 TTL
 //   - CacheDeleteNode: Remove data from cache with pattern matching
 //     从缓存中删除数据，支持模式匹配
+//   - CacheIncrNode: Atomically increment a counter, set if absent or compare-and-swap in cache
+//     原子地递增计数器、键不存在时设置值或者比较并替换缓存值
 //
 // Component Categories:
 // 组件分类：
@@ -71,6 +87,8 @@
 //     物联网场景的 MQTT 消息传递
 //   - HTTP/REST API calls for web integration
 //     Web 集成的 HTTP/REST API 调用
+//   - gRPC calls without generated code
+//     无需生成代码的 gRPC 调用
 //   - Raw network protocols for custom communication
 //     自定义通信的原始网络协议
 //
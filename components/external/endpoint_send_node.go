@@ -0,0 +1,162 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/connection"
+	"github.com/rulego/rulego/utils/el"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// 注册节点
+func init() {
+	Registry.Add(&EndpointSendNode{})
+}
+
+const (
+	// SendModeClient 发送给指定客户端
+	SendModeClient = "client"
+	// SendModeTag 发送给标签分组的所有客户端
+	SendModeTag = "tag"
+	// SendModeBroadcast 广播给所有客户端
+	SendModeBroadcast = "broadcast"
+	// KeySentCount 发送成功的客户端数量写入的元数据键
+	KeySentCount = "sentCount"
+)
+
+// EndpointSendNodeConfiguration endpointSend 节点配置
+type EndpointSendNodeConfiguration struct {
+	// Server 目标端点的服务地址，与 websocket 或者 net 端点的 server 配置相同，例如 :9090。
+	// 每个端点使用自己的连接注册表，只推送到该端点的连接
+	Server string `json:"server"`
+	// Mode 发送方式：client 发送给指定客户端，tag 发送给标签分组，broadcast 广播给所有客户端
+	Mode string `json:"mode"`
+	// Target 客户端ID或者标签，broadcast 方式忽略。
+	// 可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	Target string `json:"target"`
+}
+
+// EndpointSendNode pushes the message data to live connections of the websocket and tcp net endpoints,
+// outside of the request exchange. The endpoints register their connections by client ID and tags,
+// which are configured with the clientId and clientTags router From configuration.
+// Each endpoint has its own registry, selected by the server configuration.
+// The message goes to the Failure chain when the target client or tag group is not connected.
+//
+// EndpointSendNode 在请求交换之外把消息负荷推送到 websocket 和 tcp net 端点的在线连接。
+// 端点按客户端ID和标签注册连接，通过路由 From 配置的 clientId 和 clientTags 指定。
+// 每个端点使用自己的注册表，通过 server 配置选择。目标客户端或者标签分组不在线时，消息发送到 Failure 链。
+//
+// Configuration:
+// 配置说明：
+//
+//	{
+//		"server": ":9090",                  // Server address of the endpoint  端点服务地址
+//		"mode": "client",                   // client, tag or broadcast  发送方式
+//		"target": "${metadata.clientId}"    // Client ID or tag  客户端ID或者标签
+//	}
+//
+// Output metadata:
+// 输出元数据：
+//
+//   - sentCount: Number of clients that received the message  接收消息的客户端数量
+type EndpointSendNode struct {
+	//节点配置
+	Config EndpointSendNodeConfiguration
+	//Connections 在线连接注册表，默认使用 connection.EndpointRegistry(Config.Server)
+	Connections    *connection.Registry
+	targetTemplate *el.MixedTemplate
+}
+
+// Type 组件类型
+func (x *EndpointSendNode) Type() string {
+	return "endpointSend"
+}
+
+func (x *EndpointSendNode) New() types.Node {
+	return &EndpointSendNode{Config: EndpointSendNodeConfiguration{
+		Server: ":6334",
+		Mode:   SendModeClient,
+		Target: "${metadata.clientId}",
+	}}
+}
+
+// Init 初始化
+func (x *EndpointSendNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.Config.Server = strings.TrimSpace(x.Config.Server)
+	x.Config.Mode = strings.TrimSpace(x.Config.Mode)
+	if x.Config.Mode == "" {
+		x.Config.Mode = SendModeClient
+	}
+	x.Config.Target = strings.TrimSpace(x.Config.Target)
+	switch x.Config.Mode {
+	case SendModeClient, SendModeTag:
+		if x.Config.Target == "" {
+			return errors.New("target can not empty")
+		}
+	case SendModeBroadcast:
+	default:
+		return fmt.Errorf("unsupported mode: %s", x.Config.Mode)
+	}
+	if x.Connections == nil {
+		if x.Config.Server == "" {
+			return errors.New("server can not empty")
+		}
+		x.Connections = connection.EndpointRegistry(x.Config.Server)
+	}
+	x.targetTemplate, err = el.NewMixedTemplate(x.Config.Target)
+	return err
+}
+
+// OnMsg 处理消息
+func (x *EndpointSendNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var count int
+	var err error
+	data := msg.GetBytes()
+	switch x.Config.Mode {
+	case SendModeBroadcast:
+		count, err = x.Connections.Broadcast(msg.GetDataType(), data)
+	case SendModeTag:
+		target := x.targetTemplate.ExecuteAsString(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+		count, err = x.Connections.SendToTag(target, msg.GetDataType(), data)
+	default:
+		target := x.targetTemplate.ExecuteAsString(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+		if err = x.Connections.Send(target, msg.GetDataType(), data); err == nil {
+			count = 1
+		}
+	}
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(KeySentCount, strconv.Itoa(count))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *EndpointSendNode) Destroy() {
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/connection"
+)
+
+// testClientConn 记录推送数据的测试连接
+type testClientConn struct {
+	data []string
+	lock sync.Mutex
+}
+
+func (c *testClientConn) Send(dataType types.DataType, data []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.data = append(c.data, string(data))
+	return nil
+}
+
+func (c *testClientConn) received() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.data
+}
+
+func TestEndpointSendNode(t *testing.T) {
+	var targetNodeType = "endpointSend"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &EndpointSendNode{}, types.Configuration{
+			"server": ":6334",
+			"mode":   SendModeClient,
+			"target": "${metadata.clientId}",
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		test.NodeInit(t, targetNodeType, types.Configuration{
+			"server": ":9090",
+			"mode":   SendModeTag,
+			"target": "tenant/${metadata.tenant}",
+		}, types.Configuration{
+			"server": ":9090",
+			"mode":   SendModeTag,
+			"target": "tenant/${metadata.tenant}",
+		}, Registry)
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{"server": ":9090", "mode": SendModeTag, "target": ""}, Registry)
+		assert.Equal(t, "target can not empty", err.Error())
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{"server": ":9090", "mode": "multicast"}, Registry)
+		assert.Equal(t, "unsupported mode: multicast", err.Error())
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{"mode": SendModeBroadcast, "server": ""}, Registry)
+		assert.Equal(t, "server can not empty", err.Error())
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{"server": ":9090", "mode": SendModeBroadcast, "target": ""}, Registry)
+		assert.Nil(t, err)
+		assert.True(t, connection.EndpointRegistry(":9090") == node.(*EndpointSendNode).Connections)
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		registry := connection.EndpointRegistry(":19090")
+		c1, c2 := &testClientConn{}, &testClientConn{}
+		registry.Register(c1, "d1", "tenant/t1")
+		registry.Register(c2, "d2", "tenant/t1")
+		//其他端点的连接
+		other := &testClientConn{}
+		connection.EndpointRegistry(":19091").Register(other, "d1", "tenant/t1")
+
+		newNode := func(mode, target string) types.Node {
+			node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{"server": ":19090", "mode": mode, "target": target}, Registry)
+			assert.Nil(t, err)
+			return node
+		}
+		metaData := types.BuildMetadata(map[string]string{"clientId": "d1", "tenant": "t1"})
+		msgList := []test.Msg{{
+			MetaData:   metaData,
+			MsgType:    "TEST_MSG",
+			Data:       "{\"cmd\":\"reboot\"}",
+			DataType:   types.JSON,
+			AfterSleep: time.Millisecond * 100,
+		}}
+
+		test.NodeOnMsg(t, newNode(SendModeClient, "${metadata.clientId}"), msgList, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, "1", msg.Metadata.GetValue(KeySentCount))
+		})
+		test.NodeOnMsg(t, newNode(SendModeTag, "tenant/${metadata.tenant}"), msgList, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, "2", msg.Metadata.GetValue(KeySentCount))
+		})
+		test.NodeOnMsg(t, newNode(SendModeBroadcast, ""), msgList, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, "2", msg.Metadata.GetValue(KeySentCount))
+		})
+		assert.Equal(t, 3, len(c1.received()))
+		assert.Equal(t, 2, len(c2.received()))
+		assert.Equal(t, "{\"cmd\":\"reboot\"}", c2.received()[0])
+		assert.Equal(t, 0, len(other.received()))
+
+		//目标不在线
+		test.NodeOnMsg(t, newNode(SendModeClient, "d3"), msgList, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Failure, relationType)
+			assert.True(t, errors.Is(err, connection.ErrClientNotFound))
+		})
+		test.NodeOnMsg(t, newNode(SendModeTag, "tenant/t2"), msgList, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Failure, relationType)
+			assert.True(t, errors.Is(err, connection.ErrClientNotFound))
+		})
+	})
+}
@@ -0,0 +1,306 @@
+//go:build with_grpc
+
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/el"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/protofile"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// 注册节点
+func init() {
+	Registry.Add(&GrpcClientNode{})
+}
+
+// GrpcClientNodeConfiguration 节点配置
+type GrpcClientNodeConfiguration struct {
+	// Server 服务器地址，例如：127.0.0.1:50051
+	Server string
+	// Service 服务全名，包含包名，例如：helloworld.Greeter
+	Service string
+	// Method 方法名，例如：SayHello
+	Method string
+	// ProtoFile .proto文件路径，和 DescriptorSet 二选一
+	ProtoFile string
+	// ImportPaths 解析 ProtoFile 及其import的搜索目录，为空则使用当前目录
+	ImportPaths []string
+	// DescriptorSet protoc --descriptor_set_out 生成的描述集文件路径，需要使用 --include_imports 包含依赖
+	DescriptorSet string
+	// Headers 请求gRPC元数据，可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	Headers map[string]string
+	// ForwardMetadata 是否把所有消息元数据作为请求gRPC元数据
+	ForwardMetadata bool
+	// TimeoutMs 请求超时时间，单位毫秒，0表示不超时
+	TimeoutMs int
+	// UseTLS 是否使用TLS连接
+	UseTLS bool
+	// InsecureSkipVerify 使用TLS时是否跳过证书验证
+	InsecureSkipVerify bool
+}
+
+// GrpcClientNode calls a unary gRPC method without generated code.
+// The method is resolved from a .proto file or a descriptor set at Init,
+// the JSON message data is converted to the request message and the response is converted back to JSON.
+// Connections are shared by nodes with the same server through the SharedNode pattern.
+//
+// GrpcClientNode 无需生成代码即可调用一元gRPC方法。
+// 方法在初始化时从.proto文件或描述集解析，JSON消息负荷转换为请求消息，响应转换回JSON。
+// 相同服务器地址的节点通过 SharedNode 模式共享连接。
+//
+// The component is only registered with the with_grpc build tag, which adds about 7M to the binary:
+// go build -tags with_grpc .
+// 组件只在使用 with_grpc 编译标签时注册，编译后文件大约增加7M：go build -tags with_grpc .
+//
+// Configuration:
+// 配置说明：
+//
+//	{
+//		"server": "127.0.0.1:50051",           // gRPC server address  gRPC服务器地址
+//		"service": "helloworld.Greeter",       // Fully qualified service name  服务全名
+//		"method": "SayHello",                  // Method name  方法名
+//		"protoFile": "./proto/greeter.proto",  // .proto file, or use descriptorSet  .proto文件，或者使用描述集
+//		"importPaths": ["./proto"],            // Import search paths  import搜索目录
+//		"headers": {"x-device-id": "${metadata.deviceId}"},  // gRPC metadata  gRPC元数据
+//		"forwardMetadata": false,              // Forward all message metadata  转发所有消息元数据
+//		"timeoutMs": 3000                      // Request timeout  请求超时
+//	}
+//
+// Message Conversion:
+// 消息转换：
+//
+//   - Request: message data is parsed with protojson, unknown fields are ignored  请求：使用protojson解析消息负荷，忽略未知字段
+//   - Response: converted with protojson, data type is JSON  响应：使用protojson转换，数据类型为JSON
+//   - Metadata keys are lowercased, invalid gRPC metadata keys are skipped  元数据键转换为小写，跳过无效的gRPC元数据键
+//
+// Output Relations:
+// 输出关系：
+//
+//   - Success: Call succeeded, metadata status=OK, statusCode=0  调用成功
+//   - Failure: Conversion error, connection error or non-OK status. Metadata status, statusCode and errorBody are set for status errors
+//     转换错误、连接错误或者非OK状态。状态错误时设置元数据status、statusCode和errorBody
+//
+// Only unary methods are supported.
+// 只支持一元方法。
+type GrpcClientNode struct {
+	base.SharedNode[*grpc.ClientConn]
+	// 节点配置
+	Config GrpcClientNodeConfiguration
+	// method 方法描述
+	method protoreflect.MethodDescriptor
+	// fullMethod 方法全路径，例如：/helloworld.Greeter/SayHello
+	fullMethod string
+	// headersTemplate 元数据模板
+	headersTemplate map[string]el.Template
+	// hasVar 元数据是否有变量
+	hasVar bool
+}
+
+// Type 组件类型
+func (x *GrpcClientNode) Type() string {
+	return "grpcClient"
+}
+
+func (x *GrpcClientNode) New() types.Node {
+	return &GrpcClientNode{Config: GrpcClientNodeConfiguration{
+		Server:    "127.0.0.1:50051",
+		Service:   "helloworld.Greeter",
+		Method:    "SayHello",
+		TimeoutMs: 3000,
+	}}
+}
+
+// Init 初始化
+func (x *GrpcClientNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if !base.NodeUtils.IsInitNetResource(ruleConfig, configuration) {
+		if x.method, err = x.loadMethod(); err != nil {
+			return err
+		}
+		x.fullMethod = protofile.FullMethodName(x.method)
+		x.headersTemplate = make(map[string]el.Template)
+		for key, value := range x.Config.Headers {
+			tmpl, err := el.NewTemplate(value)
+			if err != nil {
+				return err
+			}
+			if tmpl.HasVar() {
+				x.hasVar = true
+			}
+			x.headersTemplate[strings.ToLower(key)] = tmpl
+		}
+	}
+	//初始化客户端
+	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, func() (*grpc.ClientConn, error) {
+		return x.initClient()
+	}, func(client *grpc.ClientConn) error {
+		// 清理回调函数
+		return client.Close()
+	})
+}
+
+// OnMsg 处理消息，调用gRPC方法并把响应作为消息负荷
+func (x *GrpcClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	request := dynamicpb.NewMessage(x.method.Input())
+	if data := msg.GetBytes(); len(data) > 0 {
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, request); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	callCtx := ctx.GetContext()
+	if callCtx == nil {
+		callCtx = context.Background()
+	}
+	if x.Config.TimeoutMs > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(callCtx, time.Duration(x.Config.TimeoutMs)*time.Millisecond)
+		defer cancel()
+	}
+	md, err := x.buildMetadata(ctx, msg)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if len(md) > 0 {
+		callCtx = metadata.NewOutgoingContext(callCtx, md)
+	}
+	response := dynamicpb.NewMessage(x.method.Output())
+	if err := client.Invoke(callCtx, x.fullMethod, request, response); err != nil {
+		if s, ok := status.FromError(err); ok {
+			msg.Metadata.PutValue(StatusMetadataKey, s.Code().String())
+			msg.Metadata.PutValue(StatusCodeMetadataKey, strconv.Itoa(int(s.Code())))
+			msg.Metadata.PutValue(ErrorBodyMetadataKey, s.Message())
+		}
+		ctx.TellFailure(msg, err)
+		return
+	}
+	data, err := protojson.Marshal(response)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(StatusMetadataKey, "OK")
+	msg.Metadata.PutValue(StatusCodeMetadataKey, "0")
+	msg.DataType = types.JSON
+	msg.SetBytes(data)
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *GrpcClientNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// buildMetadata 构建请求gRPC元数据
+func (x *GrpcClientNode) buildMetadata(ctx types.RuleContext, msg types.RuleMsg) (metadata.MD, error) {
+	md := metadata.MD{}
+	if x.Config.ForwardMetadata {
+		for key, value := range msg.Metadata.Values() {
+			if key = strings.ToLower(key); isValidGrpcMetadataKey(key) {
+				md.Set(key, value)
+			}
+		}
+	}
+	if len(x.headersTemplate) > 0 {
+		var evn map[string]interface{}
+		if x.hasVar {
+			evn = base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+		}
+		for key, tmpl := range x.headersTemplate {
+			value, err := tmpl.Execute(evn)
+			if err != nil {
+				return nil, err
+			}
+			if isValidGrpcMetadataKey(key) {
+				md.Set(key, fmt.Sprint(value))
+			}
+		}
+	}
+	return md, nil
+}
+
+// loadMethod 从.proto文件或描述集中查找方法
+func (x *GrpcClientNode) loadMethod() (protoreflect.MethodDescriptor, error) {
+	if x.Config.Service == "" || x.Config.Method == "" {
+		return nil, errors.New("service and method can not empty")
+	}
+	files, err := protofile.Load(x.Config.ProtoFile, x.Config.ImportPaths, x.Config.DescriptorSet)
+	if err != nil {
+		return nil, err
+	}
+	method, err := protofile.FindMethod(files, x.Config.Service, x.Config.Method)
+	if err != nil {
+		return nil, err
+	}
+	if protofile.IsStreaming(method) {
+		return nil, fmt.Errorf("streaming method is not supported: %s", x.Config.Method)
+	}
+	return method, nil
+}
+
+// initClient 初始化客户端
+func (x *GrpcClientNode) initClient() (*grpc.ClientConn, error) {
+	var creds credentials.TransportCredentials
+	if x.Config.UseTLS {
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: x.Config.InsecureSkipVerify})
+	} else {
+		creds = insecure.NewCredentials()
+	}
+	return grpc.Dial(x.Config.Server, grpc.WithTransportCredentials(creds))
+}
+
+// isValidGrpcMetadataKey 检查是否为有效的gRPC元数据键，二进制键(-bin后缀)和保留键(grpc-前缀)无效
+func isValidGrpcMetadataKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "grpc-") || strings.HasSuffix(key, "-bin") {
+		return false
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '-' && r != '_' && r != '.' {
+			return false
+		}
+	}
+	return true
+}
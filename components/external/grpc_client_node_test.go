@@ -0,0 +1,237 @@
+//go:build with_grpc
+
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/protofile"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const testProtoDir = "../../testdata/proto"
+
+// startGreeterServer 启动测试gRPC服务，使用动态消息实现 helloworld.Greeter/SayHello
+func startGreeterServer(t *testing.T) string {
+	files, err := protofile.Compile("greeter.proto", []string{testProtoDir})
+	assert.Nil(t, err)
+	descriptor, err := files.FindDescriptorByName("helloworld.Greeter")
+	assert.Nil(t, err)
+	method := descriptor.(protoreflect.ServiceDescriptor).Methods().ByName("SayHello")
+
+	handler := func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := dynamicpb.NewMessage(method.Input())
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		name := req.Get(method.Input().Fields().ByName("name")).String()
+		if name == "error" {
+			return nil, status.Error(codes.InvalidArgument, "invalid name")
+		}
+		reply := dynamicpb.NewMessage(method.Output())
+		reply.Set(method.Output().Fields().ByName("message"), protoreflect.ValueOfString("Hello "+name))
+		// 把收到的元数据写入响应
+		md, _ := metadata.FromIncomingContext(ctx)
+		mdField := method.Output().Fields().ByName("metadata")
+		mdMap := reply.Mutable(mdField).Map()
+		for key, values := range md {
+			if strings.HasPrefix(key, "x-") && len(values) > 0 {
+				mdMap.Set(protoreflect.ValueOfString(key).MapKey(), protoreflect.ValueOfString(values[0]))
+			}
+		}
+		return reply, nil
+	}
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "helloworld.Greeter",
+		HandlerType: (*interface{})(nil),
+		Methods:     []grpc.MethodDesc{{MethodName: "SayHello", Handler: handler}},
+	}, struct{}{})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+// execGrpcClientNode 同步执行节点
+func execGrpcClientNode(node types.Node, metadata *types.Metadata, data string) (types.RuleMsg, string, error) {
+	var resultMsg types.RuleMsg
+	var resultRelationType string
+	var resultErr error
+	ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+		resultMsg, resultRelationType, resultErr = msg, relationType, err
+	})
+	if metadata == nil {
+		metadata = types.NewMetadata()
+	}
+	node.OnMsg(ctx, types.NewMsg(0, "TEST", types.JSON, metadata, data))
+	return resultMsg, resultRelationType, resultErr
+}
+
+func TestGrpcClientNode(t *testing.T) {
+	var targetNodeType = "grpcClient"
+	addr := startGreeterServer(t)
+
+	newNode := func(config types.Configuration) types.Node {
+		configuration := types.Configuration{
+			"server":      addr,
+			"service":     "helloworld.Greeter",
+			"method":      "SayHello",
+			"protoFile":   "greeter.proto",
+			"importPaths": []string{testProtoDir},
+		}
+		for k, v := range config {
+			configuration[k] = v
+		}
+		node := test.InitNodeByConfig(types.NewConfig(), targetNodeType, configuration, Registry)
+		assert.NotNil(t, node)
+		return node
+	}
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &GrpcClientNode{}, types.Configuration{
+			"server":    "127.0.0.1:50051",
+			"service":   "helloworld.Greeter",
+			"method":    "SayHello",
+			"timeoutMs": 3000,
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		config := types.Configuration{
+			"server":      addr,
+			"service":     "helloworld.Greeter",
+			"method":      "SayHello",
+			"importPaths": []string{testProtoDir},
+		}
+		node := &GrpcClientNode{}
+		err := node.Init(types.NewConfig(), config)
+		assert.Equal(t, "protoFile or descriptorSet must be set", err.Error())
+
+		config["protoFile"] = "greeter.proto"
+		config["method"] = "NotFound"
+		err = (&GrpcClientNode{}).Init(types.NewConfig(), config)
+		assert.Equal(t, "method NotFound not found in service helloworld.Greeter", err.Error())
+
+		config["method"] = "SayHelloStream"
+		err = (&GrpcClientNode{}).Init(types.NewConfig(), config)
+		assert.Equal(t, "streaming method is not supported: SayHelloStream", err.Error())
+
+		config["service"] = "helloworld.HelloRequest"
+		err = (&GrpcClientNode{}).Init(types.NewConfig(), config)
+		assert.Equal(t, "helloworld.HelloRequest is not a service", err.Error())
+
+		config["protoFile"] = "notFound.proto"
+		err = (&GrpcClientNode{}).Init(types.NewConfig(), config)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Call", func(t *testing.T) {
+		metadata := types.NewMetadata()
+		metadata.PutValue("deviceId", "d1")
+		node := newNode(types.Configuration{
+			"headers": map[string]string{"X-Device-Id": "${metadata.deviceId}", "x-name": "${msg.name}"},
+		})
+		msg, relationType, err := execGrpcClientNode(node, metadata, `{"name":"rulego","times":2,"unknown":true}`)
+		assert.Nil(t, err)
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, types.JSON, msg.DataType)
+		assert.Equal(t, "OK", msg.Metadata.GetValue(StatusMetadataKey))
+		assert.Equal(t, "0", msg.Metadata.GetValue(StatusCodeMetadataKey))
+		var result map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+		assert.Equal(t, "Hello rulego", result["message"])
+		assert.Equal(t, map[string]interface{}{"x-device-id": "d1", "x-name": "rulego"}, result["metadata"])
+	})
+
+	t.Run("ForwardMetadata", func(t *testing.T) {
+		metadata := types.NewMetadata()
+		metadata.PutValue("X-Trace-Id", "t1")
+		metadata.PutValue("x-invalid key", "skip")
+		metadata.PutValue("x-data-bin", "skip")
+		node := newNode(types.Configuration{"forwardMetadata": true})
+		msg, relationType, _ := execGrpcClientNode(node, metadata, `{"name":"a"}`)
+		assert.Equal(t, types.Success, relationType)
+		var result map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+		assert.Equal(t, map[string]interface{}{"x-trace-id": "t1"}, result["metadata"])
+	})
+
+	t.Run("DescriptorSet", func(t *testing.T) {
+		files, err := protofile.Compile("greeter.proto", []string{testProtoDir})
+		assert.Nil(t, err)
+		set := &descriptorpb.FileDescriptorSet{}
+		files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+			set.File = append(set.File, protodesc.ToFileDescriptorProto(file))
+			return true
+		})
+		data, err := proto.Marshal(set)
+		assert.Nil(t, err)
+		path := filepath.Join(t.TempDir(), "greeter.pb")
+		assert.Nil(t, os.WriteFile(path, data, 0644))
+
+		node := newNode(types.Configuration{"protoFile": "", "descriptorSet": path})
+		msg, relationType, _ := execGrpcClientNode(node, nil, `{"name":"set"}`)
+		assert.Equal(t, types.Success, relationType)
+		assert.True(t, strings.Contains(msg.GetData(), "Hello set"))
+	})
+
+	t.Run("StatusError", func(t *testing.T) {
+		msg, relationType, err := execGrpcClientNode(newNode(nil), nil, `{"name":"error"}`)
+		assert.Equal(t, types.Failure, relationType)
+		assert.NotNil(t, err)
+		assert.Equal(t, "InvalidArgument", msg.Metadata.GetValue(StatusMetadataKey))
+		assert.Equal(t, "3", msg.Metadata.GetValue(StatusCodeMetadataKey))
+		assert.Equal(t, "invalid name", msg.Metadata.GetValue(ErrorBodyMetadataKey))
+	})
+
+	t.Run("InvalidData", func(t *testing.T) {
+		_, relationType, err := execGrpcClientNode(newNode(nil), nil, `{"times":"abc"}`)
+		assert.Equal(t, types.Failure, relationType)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ConnectError", func(t *testing.T) {
+		node := newNode(types.Configuration{"server": "127.0.0.1:1", "timeoutMs": 500})
+		msg, relationType, err := execGrpcClientNode(node, nil, `{"name":"a"}`)
+		assert.Equal(t, types.Failure, relationType)
+		assert.NotNil(t, err)
+		assert.Equal(t, "Unavailable", msg.Metadata.GetValue(StatusMetadataKey))
+	})
+}
@@ -0,0 +1,298 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/cast"
+	"github.com/rulego/rulego/utils/el"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/modbus"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func init() {
+	Registry.Add(&ModbusClientNode{})
+}
+
+// ModbusClientNodeConfiguration Modbus客户端配置
+type ModbusClientNodeConfiguration struct {
+	// Server Modbus TCP 服务端地址，格式为 host:port
+	Server string `json:"server"`
+	// UnitId 单元ID（从站地址），默认1
+	UnitId int `json:"unitId"`
+	// Function 功能：readCoils、readDiscreteInputs、readHoldingRegisters、readInputRegisters、
+	// writeSingleCoil、writeSingleRegister、writeMultipleCoils、writeMultipleRegisters
+	Function string `json:"function"`
+	// Address 起始地址，从0开始
+	Address int `json:"address"`
+	// Quantity 读取的数量，线圈和离散输入为位数，寄存器为按 DataType 解码的值的个数，默认1
+	Quantity int `json:"quantity"`
+	// DataType 寄存器数据类型：int16、uint16、int32、uint32、float32、int64、uint64、float64，默认uint16
+	DataType string `json:"dataType"`
+	// ByteOrder 寄存器内的字节序：big 或 little，默认big
+	ByteOrder string `json:"byteOrder"`
+	// WordOrder 多寄存器数据类型的字序：big 或 little，默认big
+	WordOrder string `json:"wordOrder"`
+	// Value 写入的值，可以是单个值或者数组，支持 ${msg.key} 变量，为空使用消息负荷
+	Value interface{} `json:"value"`
+	// TimeoutMs 连接和请求超时时间，单位毫秒，默认3000
+	TimeoutMs int `json:"timeoutMs"`
+}
+
+// ModbusClientNode Modbus TCP 客户端组件，读写设备的线圈、离散输入、保持寄存器和输入寄存器。
+// ModbusClientNode reads and writes coils, discrete inputs, holding registers and input registers
+// of Modbus TCP devices.
+//
+// Configuration:
+// 配置说明：
+//
+//	{
+//		"server": "127.0.0.1:502",              // Modbus TCP server address  服务端地址
+//		"unitId": 1,                            // Unit identifier  单元ID
+//		"function": "readHoldingRegisters",     // Function to execute  功能
+//		"address": 0,                           // Start address  起始地址
+//		"quantity": 1,                          // Number of bits or values to read  读取数量
+//		"dataType": "uint16",                   // Register data type  寄存器数据类型
+//		"byteOrder": "big",                     // Byte order in a register  字节序
+//		"wordOrder": "big",                     // Word order of multi-register types  字序
+//		"value": "${msg.setpoint}",             // Value to write, empty uses message data  写入的值
+//		"timeoutMs": 3000                       // Timeout in milliseconds  超时（毫秒）
+//	}
+//
+// Read functions output a JSON array of decoded values as the message data: booleans for coils
+// and discrete inputs, numbers for registers. A float32 stored in CDAB order is read with
+// byteOrder=big and wordOrder=little.
+//
+// 读功能把解码后的值作为JSON数组输出到消息负荷：线圈和离散输入为布尔值，寄存器为数字。
+// 例如 CDAB 顺序的 float32 使用 byteOrder=big、wordOrder=little 读取。
+//
+// Write functions encode the value with the data type, a value can be a single value or an array,
+// e.g. [1, 2.5]. writeSingleCoil and writeSingleRegister accept a single value and
+// writeSingleRegister only supports 16-bit data types. The incoming message is forwarded unchanged.
+//
+// 写功能按数据类型编码值，值可以是单个值或者数组，例如 [1, 2.5]。writeSingleCoil 和 writeSingleRegister
+// 只接受单个值，writeSingleRegister 只支持16位数据类型。写成功后原消息转发到下一个节点。
+//
+// Output Relations:
+// 输出关系：
+//
+//   - Success: Read or write succeeded  读写成功
+//   - Failure: Connection error, timeout, modbus exception or invalid value  连接错误、超时、Modbus异常或者值无效
+//
+// Clients are shared through the SharedNode pattern; use "ref://" server to reuse a pooled client.
+// 客户端通过 SharedNode 模式共享，使用 "ref://" 引用连接池中的客户端。
+type ModbusClientNode struct {
+	base.SharedNode[*modbus.Client]
+	//节点配置
+	Config   ModbusClientNodeConfiguration
+	function modbus.Function
+	codec    modbus.Codec
+	//valueTemplate 写入值模板
+	valueTemplate el.Template
+}
+
+// Type 组件类型
+func (x *ModbusClientNode) Type() string {
+	return "modbusClient"
+}
+
+func (x *ModbusClientNode) New() types.Node {
+	return &ModbusClientNode{Config: ModbusClientNodeConfiguration{
+		Server:    "127.0.0.1:502",
+		UnitId:    1,
+		Function:  "readHoldingRegisters",
+		Quantity:  1,
+		DataType:  modbus.Uint16,
+		ByteOrder: modbus.BigEndian,
+		WordOrder: modbus.BigEndian,
+		TimeoutMs: 3000,
+	}}
+}
+
+// Init 初始化
+func (x *ModbusClientNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.function, err = modbus.ParseFunction(strings.TrimSpace(x.Config.Function)); err != nil {
+		return err
+	}
+	if x.codec, err = modbus.NewCodec(x.Config.DataType, x.Config.ByteOrder, x.Config.WordOrder); err != nil {
+		return err
+	}
+	if x.function == modbus.WriteSingleRegister && x.codec.Registers() != 1 {
+		return fmt.Errorf("%s does not support data type: %s", x.function, x.codec.DataType)
+	}
+	if x.Config.UnitId < 0 || x.Config.UnitId > 255 {
+		return fmt.Errorf("invalid unit id: %d", x.Config.UnitId)
+	}
+	if x.Config.Address < 0 || x.Config.Address > 65535 {
+		return fmt.Errorf("invalid address: %d", x.Config.Address)
+	}
+	if x.Config.Quantity <= 0 {
+		x.Config.Quantity = 1
+	}
+	if x.Config.TimeoutMs <= 0 {
+		x.Config.TimeoutMs = 3000
+	}
+	if x.Config.Value != nil && x.Config.Value != "" {
+		if x.valueTemplate, err = el.NewTemplate(x.Config.Value); err != nil {
+			return err
+		}
+	}
+	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, x.initClient, func(client *modbus.Client) error {
+		return client.Close()
+	})
+}
+
+// OnMsg 处理消息
+func (x *ModbusClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if x.function.IsRead() {
+		values, err := x.read(client)
+		if err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		data, err := json.Marshal(values)
+		if err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		msg.SetDataType(types.JSON)
+		msg.SetData(string(data))
+		ctx.TellSuccess(msg)
+		return
+	}
+	values, err := x.values(ctx, msg)
+	if err == nil {
+		err = x.write(client, values)
+	}
+	if err != nil {
+		ctx.TellFailure(msg, err)
+	} else {
+		ctx.TellSuccess(msg)
+	}
+}
+
+// Destroy 销毁
+func (x *ModbusClientNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+func (x *ModbusClientNode) initClient() (*modbus.Client, error) {
+	return modbus.Dial(x.Config.Server, time.Duration(x.Config.TimeoutMs)*time.Millisecond)
+}
+
+// read 读取并解码
+func (x *ModbusClientNode) read(client *modbus.Client) (interface{}, error) {
+	unitId, address := byte(x.Config.UnitId), uint16(x.Config.Address)
+	switch x.function {
+	case modbus.ReadCoils:
+		return client.ReadCoils(unitId, address, uint16(x.Config.Quantity))
+	case modbus.ReadDiscreteInputs:
+		return client.ReadDiscreteInputs(unitId, address, uint16(x.Config.Quantity))
+	}
+	quantity := uint16(x.Config.Quantity * x.codec.Registers())
+	var regs []uint16
+	var err error
+	if x.function == modbus.ReadHoldingRegisters {
+		regs, err = client.ReadHoldingRegisters(unitId, address, quantity)
+	} else {
+		regs, err = client.ReadInputRegisters(unitId, address, quantity)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return x.codec.Decode(regs)
+}
+
+// values 获取写入的值，没有配置值时解析消息负荷
+func (x *ModbusClientNode) values(ctx types.RuleContext, msg types.RuleMsg) ([]interface{}, error) {
+	var value interface{}
+	if x.valueTemplate != nil {
+		var err error
+		if value, err = x.valueTemplate.Execute(base.NodeUtils.GetEvnAndMetadata(ctx, msg)); err != nil {
+			return nil, err
+		}
+	} else {
+		data := strings.TrimSpace(msg.GetData())
+		if err := json.Unmarshal([]byte(data), &value); err != nil {
+			value = data
+		}
+	}
+	if v, ok := value.(string); ok && strings.HasPrefix(strings.TrimSpace(v), "[") {
+		//模板中的JSON数组
+		var items []interface{}
+		if err := json.Unmarshal([]byte(v), &items); err == nil {
+			value = items
+		}
+	}
+	var values []interface{}
+	if items, ok := value.([]interface{}); ok {
+		values = items
+	} else {
+		values = []interface{}{value}
+	}
+	if len(values) == 0 {
+		return nil, errors.New("no value to write")
+	}
+	if (x.function == modbus.WriteSingleCoil || x.function == modbus.WriteSingleRegister) && len(values) != 1 {
+		return nil, fmt.Errorf("%s accepts a single value, got: %s", x.function, str.ToString(value))
+	}
+	return values, nil
+}
+
+// write 编码并写入
+func (x *ModbusClientNode) write(client *modbus.Client, values []interface{}) error {
+	unitId, address := byte(x.Config.UnitId), uint16(x.Config.Address)
+	if x.function.IsBit() {
+		bits := make([]bool, len(values))
+		for i, v := range values {
+			b, err := cast.ToBoolE(v)
+			if err != nil {
+				return err
+			}
+			bits[i] = b
+		}
+		if x.function == modbus.WriteSingleCoil {
+			return client.WriteSingleCoil(unitId, address, bits[0])
+		}
+		return client.WriteMultipleCoils(unitId, address, bits)
+	}
+	regs, err := x.codec.Encode(values...)
+	if err != nil {
+		return err
+	}
+	if x.function == modbus.WriteSingleRegister {
+		return client.WriteSingleRegister(unitId, address, regs[0])
+	}
+	return client.WriteMultipleRegisters(unitId, address, regs)
+}
@@ -0,0 +1,176 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/test/modbustest"
+	"github.com/rulego/rulego/utils/modbus"
+)
+
+func TestModbusClientNode(t *testing.T) {
+	var targetNodeType = "modbusClient"
+	server := modbustest.NewServer()
+	assert.Nil(t, server.Start("127.0.0.1:0"))
+	defer server.Close()
+	addr := server.Addr().String()
+	//float32 12.5 CDAB 顺序
+	server.SetHoldingRegisters(0, 0x0000, 0x4148, 0x0000, 0xC148)
+	server.SetInputRegisters(10, 0xFFFE)
+	server.SetCoils(0, true, false, true)
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &ModbusClientNode{}, types.Configuration{
+			"server":    "127.0.0.1:502",
+			"unitId":    1,
+			"function":  "readHoldingRegisters",
+			"quantity":  1,
+			"dataType":  "uint16",
+			"byteOrder": "big",
+			"wordOrder": "big",
+			"timeoutMs": 3000,
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		test.NodeInit(t, targetNodeType, types.Configuration{
+			"server":    addr,
+			"quantity":  0,
+			"timeoutMs": -1,
+		}, types.Configuration{
+			"server":    addr,
+			"quantity":  1,
+			"timeoutMs": 3000,
+		}, Registry)
+		for expected, config := range map[string]types.Configuration{
+			"unsupported modbus function: readFifo":                   {"function": "readFifo"},
+			"unsupported data type: int8":                             {"dataType": "int8"},
+			"unsupported byte order: mixed":                           {"byteOrder": "mixed"},
+			"writeSingleRegister does not support data type: float32": {"function": "writeSingleRegister", "dataType": "float32"},
+			"invalid unit id: 256":                                    {"unitId": 256},
+			"invalid address: 70000":                                  {"address": 70000},
+		} {
+			config["server"] = addr
+			_, err := test.CreateAndInitNode(targetNodeType, config, Registry)
+			assert.Equal(t, expected, err.Error())
+		}
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		newNode := func(config types.Configuration) types.Node {
+			config["server"] = addr
+			node, err := test.CreateAndInitNode(targetNodeType, config, Registry)
+			assert.Nil(t, err)
+			return node
+		}
+		readFloat := newNode(types.Configuration{"function": "readHoldingRegisters", "quantity": 2, "dataType": "float32", "wordOrder": "little"})
+		defer readFloat.Destroy()
+		readInput := newNode(types.Configuration{"function": "readInputRegisters", "address": 10, "dataType": "int16"})
+		defer readInput.Destroy()
+		readCoils := newNode(types.Configuration{"function": "readCoils", "quantity": 3})
+		defer readCoils.Destroy()
+		writeRegisters := newNode(types.Configuration{"function": "writeMultipleRegisters", "address": 100, "dataType": "uint32", "value": "${msg.setpoints}"})
+		defer writeRegisters.Destroy()
+		writeRegister := newNode(types.Configuration{"function": "writeSingleRegister", "address": 200, "dataType": "int16"})
+		defer writeRegister.Destroy()
+		writeCoil := newNode(types.Configuration{"function": "writeSingleCoil", "address": 300, "value": "${metadata.on}"})
+		defer writeCoil.Destroy()
+		writeError := newNode(types.Configuration{"function": "writeSingleRegister", "address": 200, "value": "[1,2]"})
+		defer writeError.Destroy()
+		readError := newNode(types.Configuration{"function": "readHoldingRegisters", "address": 65535, "quantity": 2})
+		defer readError.Destroy()
+
+		metaData := types.BuildMetadata(map[string]string{"on": "true"})
+		msgList := []test.Msg{{MetaData: metaData, DataType: types.JSON, MsgType: "TELEMETRY", Data: `{"setpoints":[70000,1]}`, AfterSleep: time.Millisecond * 200}}
+		var nodeList = []test.NodeAndCallback{
+			{
+				Node:    readFloat,
+				MsgList: msgList,
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Success, relationType)
+					assert.Equal(t, "[12.5,-12.5]", msg.GetData())
+					assert.Equal(t, types.JSON, msg.DataType)
+				},
+			},
+			{
+				Node:    readInput,
+				MsgList: msgList,
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Success, relationType)
+					assert.Equal(t, "[-2]", msg.GetData())
+				},
+			},
+			{
+				Node:    readCoils,
+				MsgList: msgList,
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Success, relationType)
+					assert.Equal(t, "[true,false,true]", msg.GetData())
+				},
+			},
+			{
+				Node:    writeRegisters,
+				MsgList: msgList,
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Success, relationType)
+					assert.Equal(t, `{"setpoints":[70000,1]}`, msg.GetData())
+					assert.Equal(t, []uint16{0x0001, 0x1170, 0, 1}, server.HoldingRegisters(100, 4))
+				},
+			},
+			{
+				Node:    writeRegister,
+				MsgList: []test.Msg{{DataType: types.TEXT, MsgType: "TELEMETRY", Data: "-3", AfterSleep: time.Millisecond * 200}},
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Success, relationType)
+					assert.Equal(t, []uint16{0xFFFD}, server.HoldingRegisters(200, 1))
+				},
+			},
+			{
+				Node:    writeCoil,
+				MsgList: msgList,
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Success, relationType)
+					assert.Equal(t, []bool{true}, server.Coils(300, 1))
+				},
+			},
+			{
+				Node:    writeError,
+				MsgList: msgList,
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Failure, relationType)
+					assert.Equal(t, "writeSingleRegister accepts a single value, got: [1,2]", err.Error())
+				},
+			},
+			{
+				Node:    readError,
+				MsgList: msgList,
+				Callback: func(msg types.RuleMsg, relationType string, err error) {
+					assert.Equal(t, types.Failure, relationType)
+					assert.Equal(t, &modbus.Exception{Function: modbus.ReadHoldingRegisters, Code: modbus.IllegalDataAddress}, err)
+				},
+			},
+		}
+		for _, item := range nodeList {
+			test.NodeOnMsgWithChildren(t, item.Node, item.MsgList, item.ChildrenNodes, item.Callback)
+		}
+	})
+}
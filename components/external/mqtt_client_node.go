@@ -18,12 +18,14 @@ package external
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/rulego/rulego/utils/mqtt"
 
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/el"
 	"github.com/rulego/rulego/utils/maps"
 	"github.com/rulego/rulego/utils/str"
 )
@@ -51,7 +53,13 @@ import (
 //		"clientID": "rulegoClient",          // MQTT client identifier  MQTT 客户端标识符
 //		"caFile": "/path/to/ca.crt",         // CA certificate file for SSL/TLS  SSL/TLS 的 CA 证书文件
 //		"certFile": "/path/to/client.crt",   // Client certificate file  客户端证书文件
-//		"certKeyFile": "/path/to/client.key" // Client private key file  客户端私钥文件
+//		"certKeyFile": "/path/to/client.key", // Client private key file  客户端私钥文件
+//		"protocolVersion": 5,                // Protocol version 3, 4 or 5  协议版本
+//		"userProperties": {"deviceId": "${metadata.deviceId}"}, // MQTT 5 user properties  MQTT 5 用户属性
+//		"responseTopic": "/device/${metadata.deviceId}/resp",   // MQTT 5 response topic  MQTT 5 响应主题
+//		"correlationData": "${metadata.requestId}",             // MQTT 5 correlation data  MQTT 5 关联数据
+//		"contentType": "application/json",   // MQTT 5 content type  MQTT 5 内容类型
+//		"messageExpiry": 60                  // MQTT 5 message expiry in seconds  MQTT 5 消息过期时间（秒）
 //	}
 //
 // 主题变量替换 - Topic variable substitution:
@@ -63,6 +71,14 @@ import (
 //   - 1: 至少一次投递（确认投递）- At least once delivery (acknowledged delivery)
 //   - 2: 恰好一次投递（保证投递）- Exactly once delivery (assured delivery)
 //
+// MQTT 5 - MQTT 5:
+//   - protocolVersion 为5时使用 MQTT 5 客户端，服务端返回的失败原因码（例如没有发布权限）转发到 Failure 链
+//     With protocolVersion 5 the MQTT 5 client is used, failure reason codes returned by the broker
+//     (e.g. not authorized) are routed to the Failure relation
+//   - userProperties、responseTopic、correlationData 支持变量替换，MQTT 3.1/3.1.1 忽略这些属性
+//     userProperties, responseTopic and correlationData support variable substitution, they are
+//     ignored by MQTT 3.1/3.1.1
+//
 // 连接管理 - Connection management:
 //   - 指数退避自动重连直到最大间隔 - Automatic reconnection with exponential backoff up to maximum interval
 //   - SharedNode模式高效资源利用 - SharedNode pattern for efficient resource utilization
@@ -105,6 +121,18 @@ type MqttClientNodeConfiguration struct {
 	CAFile               string
 	CertFile             string
 	CertKeyFile          string
+	//ProtocolVersion 协议版本，3：MQTT 3.1，4：MQTT 3.1.1，5：MQTT 5，默认4
+	ProtocolVersion uint
+	//UserProperties MQTT 5 用户属性，键和值可以使用 ${metadata.key} 或者 ${msg.key} 变量
+	UserProperties map[string]string
+	//ResponseTopic MQTT 5 响应主题，可以使用 ${metadata.key} 或者 ${msg.key} 变量
+	ResponseTopic string
+	//CorrelationData MQTT 5 关联数据，可以使用 ${metadata.key} 或者 ${msg.key} 变量
+	CorrelationData string
+	//ContentType MQTT 5 内容类型
+	ContentType string
+	//MessageExpiry MQTT 5 消息过期时间 单位秒，0表示不过期
+	MessageExpiry uint32
 }
 
 func (x *MqttClientNodeConfiguration) ToMqttConfig() mqtt.Config {
@@ -122,6 +150,7 @@ func (x *MqttClientNodeConfiguration) ToMqttConfig() mqtt.Config {
 		CAFile:               x.CAFile,
 		CertFile:             x.CertFile,
 		CertKeyFile:          x.CertKeyFile,
+		ProtocolVersion:      x.ProtocolVersion,
 	}
 }
 
@@ -131,6 +160,12 @@ type MqttClientNode struct {
 	Config MqttClientNodeConfiguration
 	//topic 模板
 	topicTemplate str.Template
+	//MQTT 5 用户属性模板
+	userPropertiesTemplate map[*el.MixedTemplate]*el.MixedTemplate
+	//MQTT 5 响应主题模板
+	responseTopicTemplate str.Template
+	//MQTT 5 关联数据模板
+	correlationDataTemplate str.Template
 }
 
 // Type 组件类型
@@ -158,6 +193,20 @@ func (x *MqttClientNode) Init(ruleConfig types.Config, configuration types.Confi
 			return client.Close()
 		})
 		x.topicTemplate = str.NewTemplate(x.Config.Topic)
+		x.responseTopicTemplate = str.NewTemplate(x.Config.ResponseTopic)
+		x.correlationDataTemplate = str.NewTemplate(x.Config.CorrelationData)
+		x.userPropertiesTemplate = make(map[*el.MixedTemplate]*el.MixedTemplate)
+		for key, value := range x.Config.UserProperties {
+			keyTmpl, err := el.NewMixedTemplate(key)
+			if err != nil {
+				return err
+			}
+			valueTmpl, err := el.NewMixedTemplate(value)
+			if err != nil {
+				return err
+			}
+			x.userPropertiesTemplate[keyTmpl] = valueTmpl
+		}
 	}
 	return err
 }
@@ -165,14 +214,19 @@ func (x *MqttClientNode) Init(ruleConfig types.Config, configuration types.Confi
 // OnMsg 处理消息，使用变量替换解析主题并发布MQTT消息
 // OnMsg processes messages by parsing topic with variable substitution and publishing MQTT messages.
 func (x *MqttClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
-	topic := x.topicTemplate.ExecuteFn(func() map[string]any {
-		return base.NodeUtils.GetEvnAndMetadata(ctx, msg)
-	})
+	var evn map[string]any
+	loadEvn := func() map[string]any {
+		if evn == nil {
+			evn = base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+		}
+		return evn
+	}
+	topic := x.topicTemplate.ExecuteFn(loadEvn)
 
 	if client, err := x.SharedNode.GetSafely(); err != nil {
 		ctx.TellFailure(msg, err)
 	} else {
-		if err := client.Publish(topic, x.Config.QOS, []byte(msg.GetData())); err != nil {
+		if err := client.PublishWithProperties(topic, x.Config.QOS, false, []byte(msg.GetData()), x.properties(loadEvn)); err != nil {
 			ctx.TellFailure(msg, err)
 		} else {
 			ctx.TellSuccess(msg)
@@ -180,6 +234,28 @@ func (x *MqttClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 	}
 }
 
+// properties 构建 MQTT 5 消息属性，非 MQTT 5 模式返回 nil
+func (x *MqttClientNode) properties(loadEvn func() map[string]any) *mqtt.Properties {
+	if x.Config.ProtocolVersion != mqtt.ProtocolVersion5 {
+		return nil
+	}
+	props := &mqtt.Properties{
+		ContentType:   x.Config.ContentType,
+		MessageExpiry: x.Config.MessageExpiry,
+		ResponseTopic: x.responseTopicTemplate.ExecuteFn(loadEvn),
+	}
+	if correlationData := x.correlationDataTemplate.ExecuteFn(loadEvn); correlationData != "" {
+		props.CorrelationData = []byte(correlationData)
+	}
+	for key, value := range x.userPropertiesTemplate {
+		props.User = append(props.User, mqtt.UserProperty{Key: key.ExecuteAsString(loadEvn()), Value: value.ExecuteAsString(loadEvn())})
+	}
+	sort.Slice(props.User, func(i, j int) bool {
+		return props.User[i].Key < props.User[j].Key
+	})
+	return props
+}
+
 // Destroy 销毁
 func (x *MqttClientNode) Destroy() {
 	_ = x.SharedNode.Close()
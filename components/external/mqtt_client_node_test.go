@@ -17,11 +17,16 @@
 package external
 
 import (
+	"context"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/test"
 	"github.com/rulego/rulego/test/assert"
-	"testing"
-	"time"
+	"github.com/rulego/rulego/test/mqtttest"
+	"github.com/rulego/rulego/utils/mqtt"
 )
 
 func TestMqttClientNode(t *testing.T) {
@@ -130,4 +135,69 @@ func TestMqttClientNode(t *testing.T) {
 		}
 		time.Sleep(time.Second * 2)
 	})
+	t.Run("OnMsgV5", func(t *testing.T) {
+		broker := mqtttest.NewBroker()
+		broker.Deny("/device/denied")
+		assert.Nil(t, broker.Start("127.0.0.1:0"))
+		defer broker.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+		defer cancel()
+		subscriber, err := mqtt.NewClient(ctx, mqtt.Config{Server: broker.Addr().String(), ProtocolVersion: mqtt.ProtocolVersion5})
+		assert.Nil(t, err)
+		defer subscriber.Close()
+		msgs := make(chan *mqtt.Message, 10)
+		subscriber.RegisterHandler(mqtt.Handler{Topic: "/device/#", Qos: 1, Handle: func(c paho.Client, data paho.Message) {
+			msgs <- data.(*mqtt.Message)
+		}})
+
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server":          broker.Addr().String(),
+			"topic":           "/device/${metadata.deviceId}",
+			"qos":             1,
+			"protocolVersion": 5,
+			"userProperties":  map[string]string{"deviceId": "${metadata.deviceId}", "source": "rulego"},
+			"responseTopic":   "/device/${metadata.deviceId}/resp",
+			"correlationData": "${metadata.requestId}",
+			"contentType":     "application/json",
+			"messageExpiry":   60,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		deniedNode, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server":          broker.Addr().String(),
+			"topic":           "/device/denied",
+			"qos":             1,
+			"protocolVersion": 5,
+		}, Registry)
+		assert.Nil(t, err)
+		defer deniedNode.Destroy()
+
+		metaData := types.BuildMetadata(map[string]string{"deviceId": "d1", "requestId": "r1"})
+		msgList := []test.Msg{{MetaData: metaData, MsgType: "TELEMETRY", Data: `{"temperature":60}`, AfterSleep: time.Millisecond * 200}}
+		test.NodeOnMsgWithChildren(t, node, msgList, nil, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+		})
+		select {
+		case msg := <-msgs:
+			assert.Equal(t, "/device/d1", msg.Topic())
+			assert.Equal(t, `{"temperature":60}`, string(msg.Payload()))
+			assert.Equal(t, &mqtt.Properties{
+				MessageExpiry:   60,
+				ContentType:     "application/json",
+				ResponseTopic:   "/device/d1/resp",
+				CorrelationData: []byte("r1"),
+				User:            []mqtt.UserProperty{{Key: "deviceId", Value: "d1"}, {Key: "source", Value: "rulego"}},
+			}, msg.Properties())
+		case <-time.After(time.Second * 3):
+			t.Fatal("receive message timeout")
+		}
+
+		//服务端返回的失败原因码
+		test.NodeOnMsgWithChildren(t, deniedNode, msgList, nil, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, &mqtt.ReasonCodeError{Packet: "puback", Code: mqtt.NotAuthorized}, err)
+		})
+	})
 }
@@ -17,7 +17,10 @@
 package external
 
 import (
+	"errors"
 	"net"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -47,6 +50,14 @@ type NetNodeConfiguration struct {
 	ConnectTimeout int
 	// 心跳间隔，用于定期发送心跳消息，单位为秒，如果=0，则不发心跳包。默认60
 	HeartbeatInterval int
+	// RequestResponse 请求/响应模式，发送后在同一连接上等待匹配的响应，响应作为节点输出。默认只发送
+	RequestResponse bool `json:"requestResponse"`
+	// Timeout 请求/响应模式等待响应的超时时间，单位为毫秒，如果<=0 则默认3000
+	Timeout int `json:"timeout"`
+	// PacketMode 请求/响应模式的响应分割方式：line 按行分割（默认），raw 每次读取到的数据作为一个响应
+	PacketMode string `json:"packetMode"`
+	// Correlation 请求/响应模式的关联ID提取方式，为空则同一时间只有一个等待的请求
+	Correlation NetCorrelation `json:"correlation"`
 }
 
 // NetNode provides network protocol communication capabilities for sending messages over various protocols.
@@ -64,7 +75,14 @@ type NetNodeConfiguration struct {
 //		"protocol": "tcp",              // Network protocol  网络协议
 //		"server": "192.168.1.100:8080", // Server address  服务器地址
 //		"connectTimeout": 30,           // Connection timeout in seconds  连接超时（秒）
-//		"heartbeatInterval": 60         // Heartbeat interval in seconds (0=disabled)  心跳间隔（秒，0=禁用）
+//		"heartbeatInterval": 60,        // Heartbeat interval in seconds (0=disabled)  心跳间隔（秒，0=禁用）
+//		"requestResponse": false,       // Wait for the matching reply  等待匹配的响应
+//		"timeout": 3000,                // Reply timeout in milliseconds  响应超时（毫秒）
+//		"packetMode": "line",           // Reply framing: line or raw  响应分割方式
+//		"correlation": {                // Correlation ID extractor  关联ID提取方式
+//			"type": "regex",            // regex, offset or js  提取方式
+//			"expression": "\"id\":(\\d+)" // Regex or JS function body  正则表达式或JS函数体
+//		}
 //	}
 //
 // Supported Protocols:
@@ -139,11 +157,35 @@ type NetNodeConfiguration struct {
 //
 // 组件使用原子操作和互斥锁确保多个规则链执行间的安全并发访问。
 //
+// Request/Response Mode:
+// 请求/响应模式：
+//
+// When requestResponse is true, the component sends the message and waits on the same connection
+// for the reply, which replaces the message data on the Success relation. Half-duplex devices
+// without correlation configuration handle one pending request at a time. With a correlation
+// extractor, the same regex, byte offset or JS function is applied to the request and the replies,
+// and concurrent requests are matched by correlation ID. Replies without a pending request are dropped.
+// Without correlation a late reply cannot be told apart from the reply of the next request, so the
+// connection is closed after a timeout and re-established by the next request. Heartbeats are sent
+// like a request and their reply is discarded, so devices that do not answer heartbeats should set
+// heartbeatInterval to 0. Nodes sharing a connection through ref:// share one reply reader.
+//
+// 当 requestResponse 为 true 时，组件发送消息后在同一连接上等待响应，响应替换消息负荷从 Success 关系输出。
+// 没有配置关联ID时按半双工设备处理，同一时间只有一个等待的请求。配置关联ID提取方式后，
+// 请求和响应使用同一个正则表达式、字节位置或者JS函数提取关联ID，并发请求按关联ID匹配。没有等待请求的响应被丢弃。
+// 没有关联ID时无法区分迟到的响应和下一个请求的响应，超时后关闭连接，由下一个请求重新连接；
+// 心跳和请求一样等待响应并丢弃，不响应心跳的设备需要把 heartbeatInterval 设置为0。
+// 通过 ref:// 共享连接的节点共用一个响应读取协程。
+//
+//   - regex: The first capture group, or the whole match  第一个捕获组或者整个匹配
+//   - offset: Hex string of length bytes from offset  从 offset 开始 length 个字节的十六进制字符串
+//   - js: Function body of Correlate(data, dataType) returning the ID  Correlate(data, dataType) 函数体，返回关联ID
+//
 // Output Relations:
 // 输出关系：
 //
-//   - Success: Message sent successfully  消息发送成功
-//   - Failure: Network error or connection failure  网络错误或连接失败
+//   - Success: Message sent successfully, or the reply in request/response mode  消息发送成功，请求/响应模式输出响应
+//   - Failure: Network error, connection failure or reply timeout  网络错误、连接失败或响应超时
 //
 // Usage Examples:
 // 使用示例：
@@ -187,6 +229,11 @@ type NetNode struct {
 	disconnected int32
 	//断开连接次数
 	disconnectedCount int32
+	//请求/响应模式的关联ID提取器
+	correlator *netCorrelator
+	//请求/响应模式的连接会话，跟踪该连接上等待响应的请求
+	session     *netSession
+	sessionLock sync.Mutex
 }
 
 // Type 组件类型
@@ -211,6 +258,12 @@ func (x *NetNode) Init(ruleConfig types.Config, configuration types.Configuratio
 	// 设置默认值
 	x.setDefaultConfig()
 	x.heartbeatDuration = time.Duration(x.Config.HeartbeatInterval) * time.Second
+	if x.Config.RequestResponse {
+		var err error
+		if x.correlator, err = newNetCorrelator(ruleConfig, x.Config.Correlation, configuration); err != nil {
+			return err
+		}
+	}
 	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, x.initConnect, func(conn net.Conn) error {
 		// 清理回调函数：关闭连接并清理相关状态
 		x.onDisconnect()
@@ -233,12 +286,17 @@ func (x *NetNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 		data = append(data, EndSign)
 	}
 
-	x.onWrite(ctx, msg, data)
+	if x.Config.RequestResponse {
+		x.onRequest(ctx, msg, data)
+	} else {
+		x.onWrite(ctx, msg, data)
+	}
 }
 
 // Destroy 销毁
 func (x *NetNode) Destroy() {
 	_ = x.SharedNode.Close()
+	x.correlator.stop()
 }
 
 func (x *NetNode) Printf(format string, v ...interface{}) {
@@ -288,7 +346,7 @@ func (x *NetNode) onPing() {
 	}
 	// 发送心跳
 	if conn, err := x.SharedNode.GetSafely(); err == nil {
-		if _, err := conn.Write(PingData); err != nil {
+		if err := x.ping(conn); err != nil {
 			x.Printf("Ping failed: %v", err)
 			x.setDisconnected(true)
 			x.tryReconnect()
@@ -298,6 +356,22 @@ func (x *NetNode) onPing() {
 	}
 }
 
+// ping 发送心跳，请求/响应模式的连接通过会话发送，不会和等待响应的请求交错
+func (x *NetNode) ping(conn net.Conn) error {
+	var session *netSession
+	if x.Config.RequestResponse {
+		session = x.getSession(conn)
+	} else {
+		//通过 ref:// 共享连接的其他节点可能使用请求/响应模式
+		session = loadNetSession(conn, nil)
+	}
+	if session != nil {
+		return session.ping(PingData, time.Duration(x.Config.Timeout)*time.Millisecond)
+	}
+	_, err := conn.Write(PingData)
+	return err
+}
+
 func (x *NetNode) onWrite(ctx types.RuleContext, msg types.RuleMsg, data []byte) {
 	// 向服务器发送数据
 	if conn, err := x.SharedNode.GetSafely(); err != nil {
@@ -321,6 +395,66 @@ func (x *NetNode) onWrite(ctx types.RuleContext, msg types.RuleMsg, data []byte)
 	}
 }
 
+// onRequest 发送请求并等待响应，响应作为消息负荷输出
+func (x *NetNode) onRequest(ctx types.RuleContext, msg types.RuleMsg, data []byte) {
+	conn, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var id string
+	if x.correlator != nil {
+		if id, err = x.correlator.extract(msg.GetBytes(), msg.GetDataType()); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		} else if id == "" {
+			ctx.TellFailure(msg, errors.New("correlation id not found in request"))
+			return
+		}
+	}
+	reply, err := x.getSession(conn).request(id, data, time.Duration(x.Config.Timeout)*time.Millisecond)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	//重置心跳发送间隔
+	if x.heartbeatTimer != nil {
+		x.heartbeatTimer.Reset(x.heartbeatDuration)
+	}
+	if id != "" {
+		msg.Metadata.PutValue(KeyNetCorrelationId, id)
+	}
+	msg.SetBytes(reply)
+	ctx.TellSuccess(msg)
+}
+
+// getSession 获取连接的会话，连接变化时切换到新连接的会话。每个连接只有一个会话读取响应，
+// 通过 ref:// 共享连接的节点使用第一个发送请求的节点的关联ID提取方式和响应分割方式
+func (x *NetNode) getSession(conn net.Conn) *netSession {
+	x.sessionLock.Lock()
+	defer x.sessionLock.Unlock()
+	if x.session == nil || x.session.conn != conn || x.session.closed() {
+		x.session = loadNetSession(conn, func() *netSession {
+			return x.newSession(conn)
+		})
+	}
+	return x.session
+}
+
+// newSession 创建连接的会话
+func (x *NetNode) newSession(conn net.Conn) *netSession {
+	return newNetSession(conn, x.correlator, x.Config.PacketMode, func(err error) {
+		//连接已断开，关闭连接以便下一个请求重连
+		x.sessionLock.Lock()
+		current := x.session != nil && x.session.conn == conn
+		x.sessionLock.Unlock()
+		if current {
+			x.setDisconnected(true)
+			_ = x.SharedNode.Close()
+		}
+	})
+}
+
 func (x *NetNode) onDisconnect() {
 	// 停止心跳定时器
 	if x.heartbeatTimer != nil {
@@ -354,4 +488,11 @@ func (x *NetNode) setDefaultConfig() {
 	if x.Config.HeartbeatInterval < 0 {
 		x.Config.HeartbeatInterval = 60
 	}
+	if x.Config.Timeout <= 0 {
+		x.Config.Timeout = DefaultNetRequestTimeout
+	}
+	x.Config.PacketMode = strings.TrimSpace(x.Config.PacketMode)
+	if x.Config.PacketMode == "" {
+		x.Config.PacketMode = NetPacketModeLine
+	}
 }
@@ -0,0 +1,343 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/js"
+	"github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// CorrelationTypeRegex 使用正则表达式提取关联ID，有捕获组时使用第一个捕获组
+	CorrelationTypeRegex = "regex"
+	// CorrelationTypeOffset 使用指定位置的字节作为关联ID，以十六进制字符串表示
+	CorrelationTypeOffset = "offset"
+	// CorrelationTypeJs 使用JS脚本提取关联ID
+	CorrelationTypeJs = "js"
+
+	// NetPacketModeLine 响应按行分割
+	NetPacketModeLine = "line"
+	// NetPacketModeRaw 每次读取到的数据作为一个响应，适用于二进制设备协议
+	NetPacketModeRaw = "raw"
+
+	// NetCorrelateFuncName JS关联ID提取函数名
+	NetCorrelateFuncName = "Correlate"
+	// NetCorrelateFuncTemplate JS关联ID提取函数模板
+	NetCorrelateFuncTemplate = "function Correlate(data, dataType) { %s }"
+
+	// KeyNetCorrelationId 关联ID写入的元数据键
+	KeyNetCorrelationId = "correlationId"
+	// DefaultNetRequestTimeout 默认等待响应超时，单位毫秒
+	DefaultNetRequestTimeout = 3000
+	// netReadBufferSize raw模式读取缓冲区大小
+	netReadBufferSize = 4096
+)
+
+// ErrNetRequestTimeout 等待响应超时
+var ErrNetRequestTimeout = errors.New("net request timeout")
+
+// ErrNetSessionClosed 等待响应时连接断开
+var ErrNetSessionClosed = errors.New("net connection closed")
+
+// NetCorrelation 请求和响应的关联ID提取配置，请求和响应使用同一个提取方式
+type NetCorrelation struct {
+	// Type 提取方式：regex、offset、js，为空表示不关联，同一时间只有一个等待的请求，收到的响应属于该请求
+	Type string `json:"type"`
+	// Expression regex方式为正则表达式；js方式为函数体，参数为 data 和 dataType，返回关联ID
+	Expression string `json:"expression"`
+	// Offset offset方式关联ID的起始字节
+	Offset int `json:"offset"`
+	// Length offset方式关联ID的字节数
+	Length int `json:"length"`
+}
+
+// netCorrelator 关联ID提取器
+type netCorrelator struct {
+	config   NetCorrelation
+	regexp   *regexp.Regexp
+	jsEngine types.JsEngine
+}
+
+// newNetCorrelator 创建关联ID提取器，没有配置返回nil
+func newNetCorrelator(ruleConfig types.Config, config NetCorrelation, configuration types.Configuration) (*netCorrelator, error) {
+	c := &netCorrelator{config: config}
+	var err error
+	switch config.Type {
+	case "":
+		return nil, nil
+	case CorrelationTypeRegex:
+		c.regexp, err = regexp.Compile(config.Expression)
+	case CorrelationTypeOffset:
+		if config.Offset < 0 || config.Length <= 0 {
+			err = errors.New("correlation offset must be >= 0 and length must be > 0")
+		}
+	case CorrelationTypeJs:
+		if strings.TrimSpace(config.Expression) == "" {
+			return nil, errors.New("correlation expression can not empty")
+		}
+		c.jsEngine, err = js.NewGojaJsEngine(ruleConfig, fmt.Sprintf(NetCorrelateFuncTemplate, config.Expression), base.NodeUtils.GetVars(configuration))
+	default:
+		err = fmt.Errorf("unsupported correlation type: %s", config.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// extract 从数据包提取关联ID，提取不到返回空
+func (c *netCorrelator) extract(data []byte, dataType types.DataType) (string, error) {
+	switch c.config.Type {
+	case CorrelationTypeRegex:
+		match := c.regexp.FindSubmatch(data)
+		if len(match) == 0 {
+			return "", nil
+		}
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+		return string(match[0]), nil
+	case CorrelationTypeOffset:
+		end := c.config.Offset + c.config.Length
+		if len(data) < end {
+			return "", nil
+		}
+		return hex.EncodeToString(data[c.config.Offset:end]), nil
+	default:
+		var jsData interface{} = string(data)
+		if dataType == types.BINARY {
+			jsData = data
+		}
+		out, err := c.jsEngine.Execute(nil, NetCorrelateFuncName, jsData, string(dataType))
+		if err != nil || out == nil {
+			return "", err
+		}
+		return str.ToString(out), nil
+	}
+}
+
+// stop 释放JS引擎
+func (c *netCorrelator) stop() {
+	if c != nil && c.jsEngine != nil {
+		c.jsEngine.Stop()
+	}
+}
+
+// netSessions 连接的会话，key为连接。通过 ref:// 共享同一个连接的节点共用一个会话，每个连接只有一个协程读取响应
+var (
+	netSessions     = make(map[net.Conn]*netSession)
+	netSessionsLock sync.Mutex
+)
+
+// loadNetSession 获取连接的会话，没有则使用 create 创建
+func loadNetSession(conn net.Conn, create func() *netSession) *netSession {
+	netSessionsLock.Lock()
+	defer netSessionsLock.Unlock()
+	if s, ok := netSessions[conn]; ok {
+		return s
+	}
+	if create == nil {
+		return nil
+	}
+	s := create()
+	netSessions[conn] = s
+	return s
+}
+
+// netSession 请求/响应模式的连接会话，读取连接上的响应并按关联ID分发给等待的请求
+type netSession struct {
+	conn       net.Conn
+	correlator *netCorrelator
+	packetMode string
+	// dataType 响应的数据类型，raw模式为二进制，line模式为文本
+	dataType types.DataType
+	// pending 等待响应的请求，key为关联ID
+	pending map[string]chan []byte
+	err     error
+	lock    sync.Mutex
+	// single 不使用关联ID时，同一时间只发送一个请求
+	single sync.Mutex
+	// onClose 读取出错时回调
+	onClose func(err error)
+}
+
+// newNetSession 创建会话并开始读取响应
+func newNetSession(conn net.Conn, correlator *netCorrelator, packetMode string, onClose func(err error)) *netSession {
+	dataType := types.TEXT
+	if packetMode == NetPacketModeRaw {
+		dataType = types.BINARY
+	}
+	s := &netSession{
+		conn:       conn,
+		correlator: correlator,
+		packetMode: packetMode,
+		dataType:   dataType,
+		pending:    make(map[string]chan []byte),
+		onClose:    onClose,
+	}
+	go s.read()
+	return s
+}
+
+// request 发送请求并等待关联ID相同的响应。没有关联ID时无法区分超时请求迟到的响应和下一个请求的响应，
+// 超时后关闭连接，丢弃迟到的响应，下一个请求重新连接
+func (s *netSession) request(id string, data []byte, timeout time.Duration) ([]byte, error) {
+	if s.correlator == nil {
+		s.single.Lock()
+		defer s.single.Unlock()
+	}
+	ch := make(chan []byte, 1)
+	s.lock.Lock()
+	if s.err != nil {
+		s.lock.Unlock()
+		return nil, s.err
+	}
+	if _, ok := s.pending[id]; ok {
+		s.lock.Unlock()
+		return nil, fmt.Errorf("duplicate pending request: %s", id)
+	}
+	s.pending[id] = ch
+	s.lock.Unlock()
+	defer func() {
+		s.lock.Lock()
+		if s.pending[id] == ch {
+			delete(s.pending, id)
+		}
+		s.lock.Unlock()
+	}()
+
+	if _, err := s.conn.Write(data); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case reply, ok := <-ch:
+		if !ok {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			return nil, s.err
+		}
+		return reply, nil
+	case <-timer.C:
+		err := fmt.Errorf("%w after %v", ErrNetRequestTimeout, timeout)
+		if s.correlator == nil {
+			s.close(err)
+			_ = s.conn.Close()
+		}
+		return nil, err
+	}
+}
+
+// ping 发送心跳。没有关联ID时心跳和请求一样独占连接并等待响应，响应被丢弃，
+// 避免心跳响应被当作请求的响应；有关联ID时心跳响应提取不到等待的关联ID，直接发送
+func (s *netSession) ping(data []byte, timeout time.Duration) error {
+	if s.correlator != nil {
+		_, err := s.conn.Write(data)
+		return err
+	}
+	_, err := s.request("", data, timeout)
+	return err
+}
+
+// closed 会话是否已经关闭
+func (s *netSession) closed() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.err != nil
+}
+
+// pendingCount 等待响应的请求数量
+func (s *netSession) pendingCount() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.pending)
+}
+
+// read 循环读取响应，连接断开时结束所有等待的请求
+func (s *netSession) read() {
+	reader := bufio.NewReader(s.conn)
+	buf := make([]byte, netReadBufferSize)
+	for {
+		var packet []byte
+		var err error
+		if s.packetMode == NetPacketModeRaw {
+			var n int
+			if n, err = reader.Read(buf); n > 0 {
+				packet = append([]byte(nil), buf[:n]...)
+			}
+		} else if packet, err = reader.ReadBytes(EndSign); err == nil {
+			packet = bytes.TrimRight(packet, "\r\n")
+		}
+		if err != nil {
+			s.close(err)
+			return
+		}
+		var id string
+		if s.correlator != nil {
+			if id, _ = s.correlator.extract(packet, s.dataType); id == "" {
+				//不是响应，例如设备主动上报的数据或者心跳响应
+				continue
+			}
+		}
+		s.lock.Lock()
+		ch, ok := s.pending[id]
+		if ok {
+			delete(s.pending, id)
+		}
+		s.lock.Unlock()
+		if ok {
+			ch <- packet
+		}
+	}
+}
+
+// close 关闭会话，结束所有等待的请求
+func (s *netSession) close(err error) {
+	s.lock.Lock()
+	if s.err != nil {
+		s.lock.Unlock()
+		return
+	}
+	s.err = fmt.Errorf("%w: %v", ErrNetSessionClosed, err)
+	for id, ch := range s.pending {
+		close(ch)
+		delete(s.pending, id)
+	}
+	s.lock.Unlock()
+	netSessionsLock.Lock()
+	if netSessions[s.conn] == s {
+		delete(netSessions, s.conn)
+	}
+	netSessionsLock.Unlock()
+	if s.onClose != nil {
+		s.onClose(err)
+	}
+}
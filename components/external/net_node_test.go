@@ -17,7 +17,11 @@
 package external
 
 import (
+	"bufio"
+	"errors"
 	"net"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -144,6 +148,285 @@ func TestNetNode(t *testing.T) {
 	})
 }
 
+func TestNetNodeRequest(t *testing.T) {
+	var targetNodeType = "net"
+	lineServer := "127.0.0.1:9998"
+	rawServer := "127.0.0.1:9997"
+	stop := make(chan struct{})
+	defer close(stop)
+	go createNetReplyServer(lineServer, stop, false)
+	go createNetReplyServer(rawServer, stop, true)
+	time.Sleep(time.Millisecond * 200)
+
+	//发送消息并收集结果
+	onMsg := func(t *testing.T, node types.Node, msgList []test.Msg) map[string]*types.RuleMsg {
+		results := make(chan types.RuleMsg, len(msgList))
+		test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err error) {
+			if relationType == types.Failure {
+				msg.Metadata.PutValue("error", err.Error())
+			}
+			msg.Metadata.PutValue("relationType", relationType)
+			results <- msg
+		})
+		out := make(map[string]*types.RuleMsg)
+		for range msgList {
+			select {
+			case msg := <-results:
+				out[msg.Type] = &msg
+			case <-time.After(time.Second * 3):
+				t.Fatal("wait reply timeout")
+			}
+		}
+		return out
+	}
+
+	t.Run("InitError", func(t *testing.T) {
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server":          lineServer,
+			"requestResponse": true,
+			"correlation":     map[string]interface{}{"type": "xml"},
+		}, Registry)
+		assert.Equal(t, "unsupported correlation type: xml", err.Error())
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server":          lineServer,
+			"requestResponse": true,
+			"correlation":     map[string]interface{}{"type": "offset"},
+		}, Registry)
+		assert.NotNil(t, err)
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server":          lineServer,
+			"requestResponse": true,
+			"correlation":     map[string]interface{}{"type": "regex", "expression": "("},
+		}, Registry)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Single", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server":            lineServer,
+			"heartbeatInterval": 0,
+			"requestResponse":   true,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+		assert.Equal(t, DefaultNetRequestTimeout, node.(*NetNode).Config.Timeout)
+		assert.Equal(t, NetPacketModeLine, node.(*NetNode).Config.PacketMode)
+
+		results := onMsg(t, node, []test.Msg{
+			{MsgType: "A", Data: "{\"id\":1}", DataType: types.TEXT},
+			{MsgType: "B", Data: "{\"id\":2}", DataType: types.TEXT},
+		})
+		assert.Equal(t, types.Success, results["A"].Metadata.GetValue("relationType"))
+		assert.Equal(t, "{\"reply\":{\"id\":1}}", results["A"].GetData())
+		assert.Equal(t, types.TEXT, results["A"].DataType)
+		assert.Equal(t, "{\"reply\":{\"id\":2}}", results["B"].GetData())
+	})
+
+	t.Run("StaleReply", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server":            lineServer,
+			"heartbeatInterval": 0,
+			"requestResponse":   true,
+			"timeout":           250,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		//超时请求迟到的响应在下一个请求的响应之前到达，不会被当作下一个请求的响应
+		results := onMsg(t, node, []test.Msg{
+			{MsgType: "LATE", Data: "{\"id\":1,\"late\":true}", AfterSleep: time.Millisecond * 270},
+			{MsgType: "NEXT", Data: "{\"id\":2,\"slow\":true}"},
+		})
+		assert.True(t, strings.HasPrefix(results["LATE"].Metadata.GetValue("error"), ErrNetRequestTimeout.Error()))
+		assert.Equal(t, types.Success, results["NEXT"].Metadata.GetValue("relationType"))
+		assert.Equal(t, "{\"reply\":{\"id\":2,\"slow\":true}}", results["NEXT"].GetData())
+	})
+
+	t.Run("Heartbeat", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server":            lineServer,
+			"heartbeatInterval": 1,
+			"requestResponse":   true,
+			"timeout":           500,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		results := onMsg(t, node, []test.Msg{{MsgType: "A", Data: "{\"id\":1}"}})
+		assert.Equal(t, "{\"reply\":{\"id\":1}}", results["A"].GetData())
+		//心跳发送后、响应前发送请求，心跳响应不会被当作请求的响应
+		time.Sleep(time.Millisecond * 1050)
+		results = onMsg(t, node, []test.Msg{{MsgType: "B", Data: "{\"id\":2,\"slow\":true}"}})
+		assert.Equal(t, "{\"reply\":{\"id\":2,\"slow\":true}}", results["B"].GetData())
+	})
+
+	t.Run("SharedSession", func(t *testing.T) {
+		client, server := net.Pipe()
+		defer server.Close()
+		node1 := &NetNode{Config: NetNodeConfiguration{PacketMode: NetPacketModeLine}}
+		node2 := &NetNode{Config: NetNodeConfiguration{PacketMode: NetPacketModeLine}}
+		//共享同一个连接的节点使用同一个会话
+		session := node1.getSession(client)
+		assert.True(t, session == node2.getSession(client))
+		assert.True(t, session == loadNetSession(client, nil))
+		//连接断开后移除会话
+		_ = client.Close()
+		time.Sleep(time.Millisecond * 50)
+		assert.Nil(t, loadNetSession(client, nil))
+	})
+
+	t.Run("Regex", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server":            lineServer,
+			"heartbeatInterval": 0,
+			"requestResponse":   true,
+			"timeout":           500,
+			"correlation": map[string]interface{}{
+				"type":       "regex",
+				"expression": `"id":(\d+)`,
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		//慢请求先发送，响应后到，按关联ID匹配
+		results := onMsg(t, node, []test.Msg{
+			{MsgType: "SLOW", Data: "{\"id\":1,\"slow\":true}", AfterSleep: time.Millisecond * 50},
+			{MsgType: "FAST", Data: "{\"id\":2,\"notify\":true}"},
+			{MsgType: "DROP", Data: "{\"id\":3,\"drop\":true}"},
+			{MsgType: "NOID", Data: "{}"},
+		})
+		assert.Equal(t, "{\"reply\":{\"id\":1,\"slow\":true}}", results["SLOW"].GetData())
+		assert.Equal(t, "1", results["SLOW"].Metadata.GetValue(KeyNetCorrelationId))
+		assert.Equal(t, "{\"reply\":{\"id\":2,\"notify\":true}}", results["FAST"].GetData())
+		assert.Equal(t, "2", results["FAST"].Metadata.GetValue(KeyNetCorrelationId))
+		assert.Equal(t, types.Failure, results["DROP"].Metadata.GetValue("relationType"))
+		assert.True(t, strings.HasPrefix(results["DROP"].Metadata.GetValue("error"), ErrNetRequestTimeout.Error()))
+		assert.Equal(t, "correlation id not found in request", results["NOID"].Metadata.GetValue("error"))
+		assert.Equal(t, 0, node.(*NetNode).session.pendingCount())
+	})
+
+	t.Run("Js", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server":            lineServer,
+			"heartbeatInterval": 0,
+			"requestResponse":   true,
+			"correlation": map[string]interface{}{
+				"type":       "js",
+				"expression": "var m = /\"id\":(\\d+)/.exec(data); return m ? m[1] : '';",
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		results := onMsg(t, node, []test.Msg{
+			{MsgType: "A", Data: "{\"id\":7,\"slow\":true}"},
+			{MsgType: "B", Data: "{\"id\":8,\"notify\":true}"},
+		})
+		assert.Equal(t, "{\"reply\":{\"id\":7,\"slow\":true}}", results["A"].GetData())
+		assert.Equal(t, "8", results["B"].Metadata.GetValue(KeyNetCorrelationId))
+	})
+
+	t.Run("Offset", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server":            rawServer,
+			"heartbeatInterval": 0,
+			"requestResponse":   true,
+			"packetMode":        NetPacketModeRaw,
+			"correlation": map[string]interface{}{
+				"type":   "offset",
+				"offset": 0,
+				"length": 2,
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		results := onMsg(t, node, []test.Msg{
+			{MsgType: "A", Data: string([]byte{0x00, 0x01, 0x10}), DataType: types.BINARY},
+		})
+		assert.Equal(t, types.Success, results["A"].Metadata.GetValue("relationType"))
+		assert.Equal(t, []byte{0x00, 0x01, 0x10, 0xFF}, results["A"].GetBytes())
+		assert.Equal(t, types.BINARY, results["A"].DataType)
+		assert.Equal(t, "0001", results["A"].Metadata.GetValue(KeyNetCorrelationId))
+	})
+
+	t.Run("ConnectError", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server":            "127.0.0.1:6666",
+			"heartbeatInterval": 0,
+			"requestResponse":   true,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+		results := onMsg(t, node, []test.Msg{{MsgType: "A", Data: "{}"}})
+		assert.Equal(t, types.Failure, results["A"].Metadata.GetValue("relationType"))
+	})
+}
+
+// createNetReplyServer 创建响应服务。line 模式每行回复 {"reply":请求}，包含 late、slow 的请求和心跳延迟回复，
+// 包含 drop 的请求不回复，包含 notify 的请求在回复前推送一行没有关联ID的数据；raw 模式回复请求并追加 0xFF
+func createNetReplyServer(server string, stop chan struct{}, raw bool) {
+	listener, err := net.Listen("tcp", server)
+	if err != nil {
+		return
+	}
+	go func() {
+		<-stop
+		_ = listener.Close()
+	}()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			if raw {
+				buf := make([]byte, 1024)
+				for {
+					n, err := conn.Read(buf)
+					if err != nil {
+						return
+					}
+					_, _ = conn.Write(append(buf[:n:n], 0xFF))
+				}
+			}
+			var lock sync.Mutex
+			reader := bufio.NewReader(conn)
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				line = strings.TrimSpace(line)
+				if strings.Contains(line, "drop") {
+					continue
+				}
+				go func(line string) {
+					if strings.Contains(line, "late") {
+						time.Sleep(time.Millisecond * 300)
+					} else if strings.Contains(line, "slow") {
+						time.Sleep(time.Millisecond * 200)
+					} else if line == strings.TrimSpace(string(PingData)) {
+						time.Sleep(time.Millisecond * 100)
+					}
+					reply := "{\"reply\":" + line + "}\n"
+					if strings.Contains(line, "notify") {
+						reply = "{\"notify\":true}\n" + reply
+					}
+					lock.Lock()
+					defer lock.Unlock()
+					_, _ = conn.Write([]byte(reply))
+				}(line)
+			}
+		}(conn)
+	}
+}
+
 // 创建net服务
 func createNetServer(config NetNodeConfiguration, stop chan struct{}) {
 	//var err error
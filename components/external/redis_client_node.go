@@ -0,0 +1,286 @@
+//go:build with_redis
+
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/el"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 注册节点
+func init() {
+	Registry.Add(&RedisClientNode{})
+}
+
+// RedisClientNodeConfiguration 节点配置
+type RedisClientNodeConfiguration struct {
+	// Server redis服务器地址，例如：127.0.0.1:6379
+	Server string
+	// Password 密码
+	Password string
+	// PoolSize 连接池大小，0使用默认值
+	PoolSize int
+	// Db 数据库索引
+	Db int
+	// Cmd 执行的命令，例如：GET、SET、HSET、LPUSH、PUBLISH、INCR，可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	Cmd string
+	// Params 命令参数，每个元素对应一个参数，可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	// 参数值为map或数组时转换为JSON字符串
+	Params []interface{}
+}
+
+// RedisClientNode executes arbitrary Redis commands and uses the reply as the message data.
+// The Redis client is shared by nodes with the same server through the SharedNode pattern.
+//
+// RedisClientNode 执行任意Redis命令，并把命令结果作为消息负荷。
+// 相同服务器地址的节点通过 SharedNode 模式共享Redis客户端。
+//
+// The component is only registered with the with_redis build tag: go build -tags with_redis .
+// 组件只在使用 with_redis 编译标签时注册：go build -tags with_redis .
+//
+// Configuration:
+// 配置说明：
+//
+//	{
+//		"server": "127.0.0.1:6379",  // Redis server address  Redis服务器地址
+//		"password": "",              // Password  密码
+//		"poolSize": 0,               // Connection pool size, 0 uses default  连接池大小，0使用默认值
+//		"db": 0,                     // Database index  数据库索引
+//		"cmd": "SET",                // Command, supports variables  命令，支持变量
+//		"params": ["${metadata.key}", "${msg.value}"]  // Parameters, support variables  参数，支持变量
+//	}
+//
+// Result Processing:
+// 结果处理：
+//
+//   - String and number replies replace the message data, data type is TEXT  字符串和数字结果替换消息负荷，数据类型为TEXT
+//   - Array and map replies are converted to JSON, data type is JSON  数组和map结果转换为JSON，数据类型为JSON
+//   - Missing keys (nil reply) produce empty data  key不存在（nil结果）时消息负荷为空
+//
+// Output Relations:
+// 输出关系：
+//
+//   - Success: Command executed successfully  命令执行成功
+//   - Failure: Connection error or command error  连接错误或命令执行错误
+//
+// Usage Examples:
+// 使用示例：
+//
+//	// Store device state as a hash
+//	// 把设备状态存储为hash
+//	{
+//		"id": "saveState",
+//		"type": "redisClient",
+//		"configuration": {
+//			"server": "127.0.0.1:6379",
+//			"cmd": "HSET",
+//			"params": ["device:${metadata.deviceId}", "temperature", "${msg.temperature}", "online", "${msg.online}"]
+//		}
+//	}
+type RedisClientNode struct {
+	base.SharedNode[*redis.Client]
+	// 节点配置
+	Config RedisClientNodeConfiguration
+	// cmdTemplate 命令模板
+	cmdTemplate str.Template
+	// paramsTemplate 参数模板
+	paramsTemplate []el.Template
+	// hasVar 参数是否有变量
+	hasVar bool
+}
+
+// Type 组件类型
+func (x *RedisClientNode) Type() string {
+	return "redisClient"
+}
+
+func (x *RedisClientNode) New() types.Node {
+	return &RedisClientNode{Config: RedisClientNodeConfiguration{
+		Server: "127.0.0.1:6379",
+		Cmd:    "GET",
+		Params: []interface{}{"${metadata.key}"},
+	}}
+}
+
+// Init 初始化
+func (x *RedisClientNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if !base.NodeUtils.IsInitNetResource(ruleConfig, configuration) {
+		x.Config.Cmd = strings.TrimSpace(x.Config.Cmd)
+		if x.Config.Cmd == "" {
+			return errors.New("cmd can not empty")
+		}
+		x.cmdTemplate = str.NewTemplate(x.Config.Cmd)
+		if !x.cmdTemplate.IsNotVar() {
+			x.hasVar = true
+		}
+		x.paramsTemplate = nil
+		for _, item := range x.Config.Params {
+			tmpl, err := el.NewTemplate(item)
+			if err != nil {
+				return err
+			}
+			if tmpl.HasVar() {
+				x.hasVar = true
+			}
+			x.paramsTemplate = append(x.paramsTemplate, tmpl)
+		}
+	}
+	//初始化客户端
+	return x.SharedNode.InitWithClose(ruleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, func() (*redis.Client, error) {
+		return x.initClient()
+	}, func(client *redis.Client) error {
+		// 清理回调函数
+		return client.Close()
+	})
+}
+
+// OnMsg 处理消息，执行命令并把结果作为消息负荷
+func (x *RedisClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	client, err := x.SharedNode.GetSafely()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	args, err := x.buildArgs(ctx, msg)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	result, err := client.Do(ctx.GetContext(), args...).Result()
+	if err != nil && err != redis.Nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	switch v := normalizeRedisReply(result).(type) {
+	case nil:
+		msg.DataType = types.TEXT
+		msg.SetData("")
+	case []interface{}, map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		msg.DataType = types.JSON
+		msg.SetBytes(data)
+	default:
+		msg.DataType = types.TEXT
+		msg.SetData(str.ToString(v))
+	}
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *RedisClientNode) Destroy() {
+	_ = x.SharedNode.Close()
+}
+
+// buildArgs 解析命令和参数
+func (x *RedisClientNode) buildArgs(ctx types.RuleContext, msg types.RuleMsg) ([]interface{}, error) {
+	var evn map[string]interface{}
+	if x.hasVar {
+		evn = base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	}
+	cmd := x.Config.Cmd
+	if !x.cmdTemplate.IsNotVar() {
+		cmd = strings.TrimSpace(x.cmdTemplate.Execute(evn))
+		if cmd == "" {
+			return nil, errors.New("cmd can not empty")
+		}
+	}
+	args := []interface{}{cmd}
+	for _, tmpl := range x.paramsTemplate {
+		value, err := tmpl.Execute(evn)
+		if err != nil {
+			return nil, err
+		}
+		arg, err := redisArg(value)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// initClient 初始化客户端
+func (x *RedisClientNode) initClient() (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     x.Config.Server,
+		Password: x.Config.Password,
+		DB:       x.Config.Db,
+		PoolSize: x.Config.PoolSize,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// redisArg 转换单个参数，复合类型转换为JSON
+func redisArg(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case nil:
+		return "", nil
+	case string, []byte, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return value, nil
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	}
+}
+
+// normalizeRedisReply 把RESP3的map结果转换为可以JSON序列化的map[string]interface{}
+func normalizeRedisReply(reply interface{}) interface{} {
+	switch v := reply.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[str.ToString(key)] = normalizeRedisReply(item)
+		}
+		return result
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeRedisReply(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,153 @@
+//go:build with_redis
+
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/json"
+)
+
+// execRedisClientNode 同步执行节点
+func execRedisClientNode(node types.Node, metadata *types.Metadata, data string) (types.RuleMsg, string, error) {
+	var resultMsg types.RuleMsg
+	var resultRelationType string
+	var resultErr error
+	ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+		resultMsg, resultRelationType, resultErr = msg, relationType, err
+	})
+	if metadata == nil {
+		metadata = types.NewMetadata()
+	}
+	node.OnMsg(ctx, types.NewMsg(0, "TEST", types.JSON, metadata, data))
+	return resultMsg, resultRelationType, resultErr
+}
+
+func TestRedisClientNode(t *testing.T) {
+	var targetNodeType = "redisClient"
+	server := miniredis.RunT(t)
+
+	newNode := func(cmd string, params ...interface{}) types.Node {
+		node := test.InitNodeByConfig(types.NewConfig(), targetNodeType, types.Configuration{
+			"server": server.Addr(),
+			"cmd":    cmd,
+			"params": params,
+		}, Registry)
+		assert.NotNil(t, node)
+		return node
+	}
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &RedisClientNode{}, types.Configuration{
+			"server": "127.0.0.1:6379",
+			"cmd":    "GET",
+			"params": []interface{}{"${metadata.key}"},
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		node := &RedisClientNode{}
+		err := node.Init(types.NewConfig(), types.Configuration{"server": server.Addr()})
+		assert.Equal(t, "cmd can not empty", err.Error())
+	})
+
+	t.Run("SetAndGet", func(t *testing.T) {
+		metadata := types.NewMetadata()
+		metadata.PutValue("key", "device:1")
+		msg, relationType, err := execRedisClientNode(newNode("SET", "${metadata.key}", "${msg.temperature}"), metadata, `{"temperature":41}`)
+		assert.Nil(t, err)
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "OK", msg.GetData())
+		assert.Equal(t, types.TEXT, msg.DataType)
+		value, _ := server.Get("device:1")
+		assert.Equal(t, "41", value)
+
+		msg, relationType, _ = execRedisClientNode(newNode("GET", "${metadata.key}"), metadata, `{}`)
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "41", msg.GetData())
+
+		// key不存在
+		metadata.PutValue("key", "notFound")
+		msg, relationType, _ = execRedisClientNode(newNode("GET", "${metadata.key}"), metadata, `{}`)
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "", msg.GetData())
+	})
+
+	t.Run("Hash", func(t *testing.T) {
+		metadata := types.NewMetadata()
+		metadata.PutValue("deviceId", "2")
+		msg, relationType, err := execRedisClientNode(newNode("HSET", "device:${metadata.deviceId}", "temperature", "${msg.temperature}", "info", "${msg.info}"), metadata, `{"temperature":41,"info":{"online":true}}`)
+		assert.Nil(t, err)
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "2", msg.GetData())
+		assert.Equal(t, "41", server.HGet("device:2", "temperature"))
+		assert.Equal(t, `{"online":true}`, server.HGet("device:2", "info"))
+
+		msg, relationType, _ = execRedisClientNode(newNode("HGETALL", "device:${metadata.deviceId}"), metadata, `{}`)
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, types.JSON, msg.DataType)
+		var result map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &result))
+		assert.Equal(t, map[string]interface{}{"temperature": "41", "info": `{"online":true}`}, result)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		_, relationType, _ := execRedisClientNode(newNode("LPUSH", "queue", "${msg.a}", "${msg.b}"), nil, `{"a":"x","b":{"c":1}}`)
+		assert.Equal(t, types.Success, relationType)
+		msg, _, _ := execRedisClientNode(newNode("LRANGE", "queue", 0, -1), nil, `{}`)
+		assert.Equal(t, types.JSON, msg.DataType)
+		assert.Equal(t, `["{\"c\":1}","x"]`, msg.GetData())
+	})
+
+	t.Run("IncrAndPublish", func(t *testing.T) {
+		node := newNode("INCR", "counter")
+		execRedisClientNode(node, nil, `{}`)
+		msg, _, _ := execRedisClientNode(node, nil, `{}`)
+		assert.Equal(t, "2", msg.GetData())
+
+		msg, relationType, _ := execRedisClientNode(newNode("${metadata.cmd}", "events", "${msg}"), types.BuildMetadata(map[string]string{"cmd": "PUBLISH"}), `{"a":1}`)
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "0", msg.GetData())
+	})
+
+	t.Run("CommandError", func(t *testing.T) {
+		_, relationType, err := execRedisClientNode(newNode("INCR", "device:1", "extra"), nil, `{}`)
+		assert.Equal(t, types.Failure, relationType)
+		assert.NotNil(t, err)
+
+		_, relationType, err = execRedisClientNode(newNode("${metadata.cmd}"), types.BuildMetadata(map[string]string{"cmd": " "}), `{}`)
+		assert.Equal(t, types.Failure, relationType)
+		assert.Equal(t, "cmd can not empty", err.Error())
+	})
+
+	t.Run("ConnectError", func(t *testing.T) {
+		node := test.InitNodeByConfig(types.NewConfig(), targetNodeType, types.Configuration{
+			"server": "127.0.0.1:1",
+			"cmd":    "GET",
+			"params": []interface{}{"a"},
+		}, Registry)
+		_, relationType, err := execRedisClientNode(node, nil, `{}`)
+		assert.Equal(t, types.Failure, relationType)
+		assert.NotNil(t, err)
+	})
+}
@@ -100,6 +100,9 @@ type RestApiCallNodeConfiguration struct {
 	ProxyUser string
 	//ProxyPassword 代理密码
 	ProxyPassword string
+	//StreamResponse 响应体以消息流的形式传递给下一个节点，不读取到内存，由下一个节点负责消费和关闭
+	//没有被消费的响应体在规则链运行结束时关闭，参考 types.RuleMsg.TakeStream。注意：ReadTimeoutMs 同样限制读取响应体的时间
+	StreamResponse bool
 }
 
 // RestApiCallNode 用于进行外部API调用的HTTP/REST API客户端组件
@@ -129,6 +132,10 @@ type RestApiCallNodeConfiguration struct {
 //   - 非200: Failure relation, error details stored in metadata - Failure relation with error details in metadata
 //   - SSE stream: process event data line by line - SSE streams: process event data line by line
 //
+// 流式消息体 - Streaming bodies:
+//   - 如果消息带有未消费的消息流且没有配置body，则消息流作为请求体发送，不缓冲 - If the message carries an unconsumed stream and no body is configured, the stream is sent as the request body without buffering
+//   - streamResponse=true: 响应体作为消息流传递给下一个节点，由下一个节点消费和关闭，未消费的响应体在规则链运行结束时关闭 - The response body is passed on as a message stream, the next node consumes and closes it, an unconsumed body is closed when the rule chain run completes
+//
 // 配置示例 - Configuration examples:
 //
 //	// 基础POST请求 - Basic POST request
@@ -287,10 +294,27 @@ func (x *RestApiCallNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 			} else {
 				body = []byte(str.ToString(v))
 			}
+		} else if msg.HasStream() {
+			//消息流作为请求体，不缓冲
+			stream := msg.GetStream()
+			reader, err := msg.TakeStream()
+			if err != nil {
+				ctx.TellFailure(msg, err)
+				return
+			}
+			req, err = http.NewRequest(x.Config.RequestMethod, endpointUrl, reader)
+			if err != nil {
+				_ = reader.Close()
+				ctx.TellFailure(msg, err)
+				return
+			}
+			req.ContentLength = stream.Size()
 		} else {
 			body = []byte(msg.GetData())
 		}
-		req, err = http.NewRequest(x.Config.RequestMethod, endpointUrl, bytes.NewReader(body))
+		if req == nil {
+			req, err = http.NewRequest(x.Config.RequestMethod, endpointUrl, bytes.NewReader(body))
+		}
 	}
 	if err != nil {
 		ctx.TellFailure(msg, err)
@@ -302,8 +326,10 @@ func (x *RestApiCallNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 	}
 
 	response, err := x.httpClient.Do(req)
+	//响应体交给下一个节点时，由下一个节点关闭
+	streamed := false
 	defer func() {
-		if response != nil && response.Body != nil {
+		if !streamed && response != nil && response.Body != nil {
 			_ = response.Body.Close()
 		}
 	}()
@@ -322,6 +348,13 @@ func (x *RestApiCallNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 			ctx.TellNext(msg, types.Failure)
 		}
 
+	} else if x.Config.StreamResponse && response.StatusCode == 200 {
+		msg.Metadata.PutValue(StatusMetadataKey, response.Status)
+		msg.Metadata.PutValue(StatusCodeMetadataKey, strconv.Itoa(response.StatusCode))
+		streamed = true
+		msg.SetData("")
+		msg.SetStream(response.Body, response.ContentLength)
+		ctx.TellSuccess(msg)
 	} else if b, err := io.ReadAll(response.Body); err != nil {
 		msg.Metadata.PutValue(ErrorBodyMetadataKey, err.Error())
 		ctx.TellFailure(msg, err)
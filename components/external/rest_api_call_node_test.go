@@ -17,9 +17,12 @@
 package external
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -222,6 +225,57 @@ func TestRestApiCallNode(t *testing.T) {
 	})
 
 	// 代理测试
+	t.Run("Stream", func(t *testing.T) {
+		payload := strings.Repeat("0123456789", 10000)
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			w.Header().Set("X-Content-Length", strconv.FormatInt(r.ContentLength, 10))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(b)
+		}))
+		defer testServer.Close()
+
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"restEndpointUrlPattern": testServer.URL,
+			"requestMethod":          "POST",
+			"streamResponse":         true,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		msg := types.NewMsg(0, "TEST", types.BINARY, types.NewMetadata(), "")
+		msg.SetStream(strings.NewReader(payload), int64(len(payload)))
+		var outMsg types.RuleMsg
+		var outRelation string
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			outMsg = msg
+			outRelation = relationType
+		})
+		node.OnMsg(ctx, msg)
+		assert.Equal(t, types.Success, outRelation)
+		// 请求消息流已被消费
+		assert.False(t, msg.HasStream())
+		// 响应以消息流传递，读取后关闭
+		assert.True(t, outMsg.HasStream())
+		assert.Equal(t, "", outMsg.GetData())
+		reader, err := outMsg.TakeStream()
+		assert.Nil(t, err)
+		b, err := io.ReadAll(reader)
+		assert.Nil(t, err)
+		assert.Nil(t, reader.Close())
+		assert.Equal(t, payload, string(b))
+
+		// 消息流只能被消费一次
+		_, err = outMsg.TakeStream()
+		assert.Equal(t, types.ErrStreamConsumed, err)
+		node.OnMsg(ctx, msg)
+		assert.Equal(t, types.Success, outRelation)
+		reader, _ = outMsg.TakeStream()
+		b, _ = io.ReadAll(reader)
+		_ = reader.Close()
+		assert.Equal(t, "", string(b))
+	})
+
 	t.Run("ProxyTest", func(t *testing.T) {
 		// 检查是否启用代理测试
 		if !ENABLE_PROXY_TEST {
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/el"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/sse"
+)
+
+// 注册节点
+func init() {
+	Registry.Add(&SsePublishNode{})
+}
+
+const (
+	// KeySseEventId 发布的事件ID写入的元数据键
+	KeySseEventId = "sseEventId"
+	// KeySseClients 接收事件的客户端数量写入的元数据键
+	KeySseClients = "sseClients"
+)
+
+// SsePublishNodeConfiguration ssePublish 节点配置
+type SsePublishNodeConfiguration struct {
+	// Channel 通道ID，与 sse 端点客户端订阅的通道对应。
+	// 可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	Channel string `json:"channel"`
+	// Event 事件类型，为空则客户端触发 message 事件，支持变量替换
+	Event string `json:"event"`
+	// Id 事件ID，为空则使用通道内自增序号，支持变量替换
+	Id string `json:"id"`
+}
+
+// SsePublishNode publishes the message data as a Server-Sent Event to the clients subscribed
+// to a channel of the sse endpoint. The message metadata is attached to the event,
+// so clients that subscribe with a metadata filter only receive matching events.
+// The event is kept in the bounded channel buffer, so disconnected clients can resume with Last-Event-ID.
+//
+// SsePublishNode 把消息负荷作为 Server-Sent Event 发布给订阅 sse 端点通道的客户端。
+// 消息元数据附加到事件上，使用元数据过滤订阅的客户端只接收匹配的事件。
+// 事件保存在有限长度的通道缓存中，断开的客户端可以通过 Last-Event-ID 续传。
+//
+// Configuration:
+// 配置说明：
+//
+//	{
+//		"channel": "/events/${metadata.tenant}",  // Channel ID  通道ID
+//		"event": "telemetry",                     // Event type  事件类型
+//		"id": ""                                  // Event ID, empty for auto increment  事件ID，为空自增
+//	}
+//
+// Output metadata:
+// 输出元数据：
+//
+//   - sseEventId: ID of the published event  发布的事件ID
+//   - sseClients: Number of clients that received the event  接收事件的客户端数量
+type SsePublishNode struct {
+	//节点配置
+	Config SsePublishNodeConfiguration
+	//Broker 事件代理，默认 sse.DefaultBroker
+	Broker          *sse.Broker
+	channelTemplate *el.MixedTemplate
+	eventTemplate   *el.MixedTemplate
+	idTemplate      *el.MixedTemplate
+}
+
+// Type 组件类型
+func (x *SsePublishNode) Type() string {
+	return "ssePublish"
+}
+
+func (x *SsePublishNode) New() types.Node {
+	return &SsePublishNode{Config: SsePublishNodeConfiguration{
+		Channel: "/events",
+	}}
+}
+
+// Init 初始化
+func (x *SsePublishNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.Config.Channel = strings.TrimSpace(x.Config.Channel)
+	if x.Config.Channel == "" {
+		return errors.New("channel can not empty")
+	}
+	if x.Broker == nil {
+		x.Broker = sse.DefaultBroker
+	}
+	if x.channelTemplate, err = el.NewMixedTemplate(x.Config.Channel); err != nil {
+		return err
+	}
+	if x.eventTemplate, err = el.NewMixedTemplate(x.Config.Event); err != nil {
+		return err
+	}
+	x.idTemplate, err = el.NewMixedTemplate(x.Config.Id)
+	return err
+}
+
+// OnMsg 处理消息
+func (x *SsePublishNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	env := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	channel := x.channelTemplate.ExecuteAsString(env)
+	event, count := x.Broker.Publish(channel, sse.Event{
+		ID:       x.idTemplate.ExecuteAsString(env),
+		Event:    x.eventTemplate.ExecuteAsString(env),
+		Data:     msg.GetData(),
+		Metadata: msg.Metadata.Values(),
+	})
+	msg.Metadata.PutValue(KeySseEventId, event.ID)
+	msg.Metadata.PutValue(KeySseClients, strconv.Itoa(count))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *SsePublishNode) Destroy() {
+}
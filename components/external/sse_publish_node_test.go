@@ -0,0 +1,80 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/sse"
+)
+
+func TestSsePublishNode(t *testing.T) {
+	var targetNodeType = "ssePublish"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &SsePublishNode{}, types.Configuration{
+			"channel": "/events",
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		test.NodeInit(t, targetNodeType, types.Configuration{
+			"channel": "/events/${metadata.tenant}",
+			"event":   "telemetry",
+		}, types.Configuration{
+			"channel": "/events/${metadata.tenant}",
+			"event":   "telemetry",
+		}, Registry)
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{"channel": " "}, Registry)
+		assert.Equal(t, "channel can not empty", err.Error())
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"channel": "/events/${metadata.tenant}",
+			"event":   "${metadata.type}",
+		}, Registry)
+		assert.Nil(t, err)
+		broker := sse.NewBroker(10)
+		node.(*SsePublishNode).Broker = broker
+		all := broker.Subscribe("/events/t1", "", nil)
+		filtered := broker.Subscribe("/events/t1", "", map[string]string{"deviceId": "d2"})
+
+		msgList := []test.Msg{{
+			MetaData:   types.BuildMetadata(map[string]string{"tenant": "t1", "type": "alarm", "deviceId": "d1"}),
+			MsgType:    "TEST_MSG",
+			Data:       "{\"temperature\":41}",
+			DataType:   types.JSON,
+			AfterSleep: time.Millisecond * 100,
+		}}
+		test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, "1", msg.Metadata.GetValue(KeySseEventId))
+			assert.Equal(t, "1", msg.Metadata.GetValue(KeySseClients))
+		})
+
+		event := <-all.Events()
+		assert.Equal(t, "1", event.ID)
+		assert.Equal(t, "alarm", event.Event)
+		assert.Equal(t, "{\"temperature\":41}", event.Data)
+		assert.Equal(t, 0, len(filtered.Events()))
+	})
+}
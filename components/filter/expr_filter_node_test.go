@@ -160,4 +160,25 @@ func TestExprFilterNode(t *testing.T) {
 		}
 		time.Sleep(time.Millisecond * 20)
 	})
+
+	t.Run("TypedMetadata", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"expr": "metadata.count > 10 && metadata.ratio > 0.5 && metadata.alarm == true",
+		}, Registry)
+		assert.Nil(t, err)
+		metaData := types.NewMetadata()
+		metaData.PutInt("count", 12)
+		metaData.PutFloat("ratio", 0.75)
+		metaData.PutBool("alarm", true)
+		msg := test.Msg{
+			MetaData:   metaData,
+			MsgType:    "ACTIVITY_EVENT",
+			Data:       "{}",
+			AfterSleep: time.Millisecond * 200,
+		}
+		test.NodeOnMsgWithChildren(t, node, []test.Msg{msg}, nil, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Nil(t, err)
+			assert.Equal(t, types.True, relationType)
+		})
+	})
 }
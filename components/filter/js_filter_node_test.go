@@ -668,4 +668,22 @@ func TestJsFilterNodeJSONArraySupport(t *testing.T) {
 		assert.Nil(t, err2)
 		assert.Equal(t, types.False, result2)
 	})
+	t.Run("TypedMetadata", func(t *testing.T) {
+		config := types.NewConfig()
+		node, err := test.CreateAndInitNode("jsFilter", types.Configuration{
+			"jsScript": "return metadata.count + 1 === 13 && metadata.alarm === true && metadata.name === 'a';",
+		}, Registry)
+		assert.Nil(t, err)
+		metadata := types.NewMetadata()
+		metadata.PutInt("count", 12)
+		metadata.PutBool("alarm", true)
+		metadata.PutValue("name", "a")
+		var result string
+		ctx := test.NewRuleContext(config, func(msg types.RuleMsg, relationType string, err error) {
+			assert.Nil(t, err)
+			result = relationType
+		})
+		node.OnMsg(ctx, types.NewMsg(0, "TEST", types.JSON, metadata, "{}"))
+		assert.Equal(t, types.True, result)
+	})
 }
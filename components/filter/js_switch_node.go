@@ -106,7 +106,7 @@ func (x *JsSwitchNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 	// 准备传递给JS脚本的数据
 	data := base.NodeUtils.PrepareJsData(msg)
 
-	out, err := x.jsEngine.Execute(ctx, "Switch", data, msg.Metadata.Values(), msg.Type, msg.DataType)
+	out, err := x.jsEngine.Execute(ctx, "Switch", data, base.NodeUtils.PrepareJsMetadata(msg), msg.Type, msg.DataType)
 
 	if err != nil {
 		ctx.TellFailure(msg, err)
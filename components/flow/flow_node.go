@@ -124,7 +124,8 @@ func (x *ChainNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 // TellFlowAndNoMerge 执行子规则链而不合并结果，每个输出单独转发
 // TellFlowAndNoMerge executes the sub-rule chain without merging results.
 func (x *ChainNode) TellFlowAndNoMerge(ctx types.RuleContext, msg types.RuleMsg) {
-	ctx.TellFlow(ctx.GetContext(), x.Config.TargetId, msg, func(nodeCtx types.RuleContext, onEndMsg types.RuleMsg, err error, relationType string) {
+	//子规则链以子消息执行，记录消息血缘
+	ctx.TellFlow(ctx.GetContext(), x.Config.TargetId, msg.CopyAsChild(), func(nodeCtx types.RuleContext, onEndMsg types.RuleMsg, err error, relationType string) {
 		if err != nil {
 			ctx.TellFailure(onEndMsg, err)
 		} else {
@@ -143,7 +144,7 @@ func (x *ChainNode) TellFlowAndMerge(ctx types.RuleContext, msg types.RuleMsg) {
 	var targetErr error
 	//使用一个互斥锁来保护对msgs切片的并发写入和metadata合并
 	var mu sync.Mutex
-	ctx.TellFlow(ctx.GetContext(), x.Config.TargetId, msg, func(nodeCtx types.RuleContext, onEndMsg types.RuleMsg, err error, relationType string) {
+	ctx.TellFlow(ctx.GetContext(), x.Config.TargetId, msg.CopyAsChild(), func(nodeCtx types.RuleContext, onEndMsg types.RuleMsg, err error, relationType string) {
 		mu.Lock()
 		defer mu.Unlock()
 		errStr := ""
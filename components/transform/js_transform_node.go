@@ -69,6 +69,10 @@ type JsTransformNodeConfiguration struct {
 	// 支持修改消息的任意字段，dataType字段可选
 	// Supports modifying any message fields, dataType field is optional
 	JsScript string
+	// TypedMetadata 是否保留脚本返回元数据的原生类型（数值、布尔），默认脚本修改的值转换为字符串，未修改的值保留原类型
+	// TypedMetadata keeps native types (numbers, booleans) of the returned metadata. By default values changed
+	// by the script are converted to strings and untouched values keep their type
+	TypedMetadata bool
 }
 
 // JsTransformNode JavaScript消息转换节点，使用JavaScript脚本对消息进行转换处理
@@ -107,6 +111,9 @@ type JsTransformNodeConfiguration struct {
 //	  "jsScript": "msg.temperature = msg.temperature * 9/5 + 32; metadata.unit = 'Fahrenheit'; return {'msg':msg,'metadata':metadata,'msgType':msgType};"
 //	}
 //
+// 返回的元数据默认转换为字符串，设置 typedMetadata=true 保留数值、布尔等原生类型。
+// Returned metadata values are converted to strings by default, set typedMetadata=true to keep native types.
+//
 // 使用场景 - Use cases:
 //   - 数据格式转换：JSON字段重组、单位换算 - Data format conversion: JSON field reorganization, unit conversion
 //   - 消息富化：添加计算字段、时间戳、标识符 - Message enrichment: add calculated fields, timestamps, identifiers
@@ -171,11 +178,10 @@ func (x *JsTransformNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 	// 准备传递给JS脚本的数据
 	data := base.NodeUtils.PrepareJsData(msg)
 
-	var metadataValues map[string]string
-	if msg.Metadata != nil {
-		metadataValues = msg.Metadata.Values()
-	} else {
-		metadataValues = make(map[string]string)
+	metadataValues := base.NodeUtils.PrepareJsMetadata(msg)
+	if x.Config.TypedMetadata && msg.Metadata != nil {
+		// 使用可以保存任意类型的map，脚本赋值的数值、布尔不会被转换为字符串
+		metadataValues = msg.Metadata.TypedValues()
 	}
 
 	// 执行JavaScript脚本
@@ -213,7 +219,25 @@ func (x *JsTransformNode) processJsResult(ctx types.RuleContext, msg types.RuleM
 
 	// 更新元数据
 	if formatMetaData, ok := formatData[types.MetadataKey]; ok {
-		msg.Metadata.ReplaceAll(str.ToStringMapString(formatMetaData))
+		typedMetaData, isMap := formatMetaData.(map[string]interface{})
+		if isMap && x.Config.TypedMetadata {
+			// 保留数值、布尔等原生类型
+			msg.Metadata.ReplaceAllTyped(typedMetaData)
+		} else if isMap && msg.Metadata.HasTypedValues() {
+			// 脚本修改的值转换为字符串，未修改的值保留上游节点设置的原生类型
+			values := make(map[string]interface{}, len(typedMetaData))
+			for k, v := range typedMetaData {
+				strValue := str.ToString(v)
+				if typedValue, ok := msg.Metadata.GetTypedValue(k); ok && msg.Metadata.GetValue(k) == strValue {
+					values[k] = typedValue
+				} else {
+					values[k] = strValue
+				}
+			}
+			msg.Metadata.ReplaceAllTyped(values)
+		} else {
+			msg.Metadata.ReplaceAll(str.ToStringMapString(formatMetaData))
+		}
 	}
 
 	// 更新消息数据
@@ -104,6 +104,78 @@ func TestJsTransformNode(t *testing.T) {
 			})
 		}
 	})
+	t.Run("LegacyStringMetadata", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"jsScript": "metadata['count']=5;metadata['alarm']=true;return {'msg':msg,'metadata':metadata,'msgType':msgType};",
+		}, Registry)
+		assert.Nil(t, err)
+		msgList := []test.Msg{{
+			MetaData:   types.NewMetadata(),
+			MsgType:    "ACTIVITY_EVENT",
+			Data:       "{}",
+			AfterSleep: time.Millisecond * 200,
+		}}
+		test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err2 error) {
+			assert.Nil(t, err2)
+			assert.False(t, msg.Metadata.HasTypedValues())
+			value, _ := msg.Metadata.GetTypedValue("count")
+			assert.Equal(t, "5", value)
+			assert.Equal(t, "true", msg.Metadata.GetValue("alarm"))
+		})
+	})
+
+	t.Run("KeepUntouchedTypedMetadata", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"jsScript": "metadata['count']=metadata['count']+1;metadata['name']='a';return {'msg':msg,'metadata':metadata,'msgType':msgType};",
+		}, Registry)
+		assert.Nil(t, err)
+		metaData := types.NewMetadata()
+		metaData.PutInt("count", 5)
+		metaData.PutBool("alarm", true)
+		metaData.PutFloat("temperature", 21.5)
+		msgList := []test.Msg{{
+			MetaData:   metaData,
+			MsgType:    "ACTIVITY_EVENT",
+			Data:       "{}",
+			AfterSleep: time.Millisecond * 200,
+		}}
+		test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err2 error) {
+			assert.Nil(t, err2)
+			//脚本修改的值转换为字符串
+			value, _ := msg.Metadata.GetTypedValue("count")
+			assert.Equal(t, "6", value)
+			assert.Equal(t, "a", msg.Metadata.GetValue("name"))
+			//未修改的值保留原类型
+			value, _ = msg.Metadata.GetTypedValue("alarm")
+			assert.Equal(t, true, value)
+			value, _ = msg.Metadata.GetTypedValue("temperature")
+			assert.Equal(t, 21.5, value)
+		})
+	})
+
+	t.Run("TypedMetadata", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"jsScript":      "metadata['count']=5;metadata['alarm']=true;return {'msg':msg,'metadata':metadata,'msgType':msgType};",
+			"typedMetadata": true,
+		}, Registry)
+		assert.Nil(t, err)
+		msgList := []test.Msg{{
+			MetaData:   types.NewMetadata(),
+			MsgType:    "ACTIVITY_EVENT",
+			Data:       "{}",
+			AfterSleep: time.Millisecond * 200,
+		}}
+		test.NodeOnMsg(t, node, msgList, func(msg types.RuleMsg, relationType string, err2 error) {
+			assert.Nil(t, err2)
+			count, ok := msg.Metadata.GetInt("count")
+			assert.True(t, ok)
+			assert.Equal(t, int64(5), count)
+			value, _ := msg.Metadata.GetTypedValue("alarm")
+			assert.Equal(t, true, value)
+			assert.Equal(t, "5", msg.Metadata.GetValue("count"))
+		})
+	})
+
 	t.Run("OnMsgError", func(t *testing.T) {
 		node1, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
 			"jsScript": "msg['add']=5+msg['test'];return {'msg':msg,'metadata':metadata,'msgType':msgType};",
@@ -0,0 +1,1073 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package coap provides a CoAP (RFC 7252) server endpoint implementation for the RuleGo framework.
+// Constrained devices send GET/POST/PUT/DELETE requests over UDP to resource paths, which are
+// routed to rule chains or components the same way as the rest endpoint.
+//
+// Key components in this package include:
+// - Endpoint (alias Coap): Implements the CoAP server, request routing and observers
+// - RequestMessage: Represents an incoming CoAP request
+// - ResponseMessage: Represents the CoAP response piggybacked on the acknowledgement
+//
+// Routers are added with the method as parameter and a path that supports :name and *name
+// parameters. Confirmable requests are answered with a piggybacked acknowledgement after the
+// router finishes, so routers use Wait to return the rule chain result. Retransmitted requests
+// are answered from a cache without running the router again. GET requests with the Observe
+// option register the client as an observer of the path when the response is successful,
+// and Notify (or the coapNotify component) pushes notifications to the observers of a path.
+// Notifications are sent confirmable at least every Config.ConfirmInterval, and observers that do
+// not acknowledge them after the retransmissions are removed (RFC 7641 4.5). Request payloads sent with the
+// Block1 option are reassembled before routing, and responses larger than Config.BlockSize are
+// returned block by block with the Block2 option (RFC 7959).
+//
+// Package coap 提供 RuleGo 框架的 CoAP（RFC 7252）服务端点实现。
+// 受限设备通过UDP向资源路径发送 GET/POST/PUT/DELETE 请求，与 rest 端点一样路由到规则链或者组件。
+// 添加路由时以请求方法作为参数，路径支持 :name 和 *name 参数。Confirmable 请求在路由处理完成后
+// 通过捎带确认响应，所以路由使用 Wait 返回规则链的结果。重传的请求使用缓存的响应回复，不会再次执行路由。
+// 带 Observe 选项的 GET 请求响应成功时，客户端注册为该路径的观察者，通过 Notify 或者 coapNotify 组件向路径的观察者推送通知。
+// 至少每隔 Config.ConfirmInterval 使用 Confirmable 消息发送通知，重传后仍未确认的观察者被取消（RFC 7641 4.5）。
+// 使用 Block1 选项分块发送的请求负荷在合并后再路由，超过 Config.BlockSize 的响应使用 Block2 选项分块返回（RFC 7959）。
+package coap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/coap"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/runtime"
+)
+
+// Type 组件类型
+const Type = types.EndpointTypePrefix + "coap"
+
+const (
+	// RemoteAddrKey 客户端地址写入的元数据键
+	RemoteAddrKey = "remoteAddr"
+	// ContentTypeKey 内容格式对应的请求头和响应头，值为MIME类型
+	ContentTypeKey = "Content-Type"
+	// AcceptKey Accept 选项对应的请求头，值为MIME类型
+	AcceptKey = "Accept"
+	// ObserveKey Observe 选项对应的请求头和元数据键，0表示注册观察，1表示取消观察
+	ObserveKey = "Observe"
+	// MaxAgeKey Max-Age 选项对应的响应头，单位秒
+	MaxAgeKey = "Max-Age"
+	// exchangeLifetime 重传请求的去重时间
+	exchangeLifetime = time.Minute
+	// defaultConfirmInterval 默认至少每24小时发送一次 Confirmable 通知（RFC 7641 4.5）
+	defaultConfirmInterval = 86400
+	// observeSeqMask Observe 序号为24位
+	observeSeqMask = 0xffffff
+	// maxBlockTransferSize 分块传输的最大负荷
+	maxBlockTransferSize = 1 << 20
+)
+
+// Endpoint 别名
+type Endpoint = Coap
+
+// RequestMessage CoAP请求消息
+type RequestMessage struct {
+	message *coap.Message
+	addr    net.Addr
+	//路径参数
+	Params  map[string]string
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *RequestMessage) Body() []byte {
+	if r.body == nil && r.message != nil {
+		return r.message.Payload
+	}
+	return r.body
+}
+
+// Headers 返回 Content-Type、Accept 和 Observe 选项
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+		if r.message != nil {
+			if cf, ok := r.message.ContentFormat(); ok {
+				r.headers.Set(ContentTypeKey, cf.String())
+			}
+			if accept, ok := r.message.OptionUint(coap.Accept); ok {
+				r.headers.Set(AcceptKey, coap.MediaType(accept).String())
+			}
+			if observe, ok := r.message.Observe(); ok {
+				r.headers.Set(ObserveKey, strconv.FormatUint(uint64(observe), 10))
+			}
+		}
+	}
+	return r.headers
+}
+
+// From 返回请求路径
+func (r *RequestMessage) From() string {
+	if r.message == nil {
+		return ""
+	}
+	return r.message.Path()
+}
+
+// GetParam 返回路径参数或者查询参数
+func (r *RequestMessage) GetParam(key string) string {
+	if v, ok := r.Params[key]; ok {
+		return v
+	}
+	if r.message == nil {
+		return ""
+	}
+	return r.message.Queries()[key]
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+// GetMsg 把请求转换成 RuleMsg，数据类型由内容格式决定
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		dataType := types.TEXT
+		if r.message != nil {
+			dataType = r.message.DataType()
+		}
+		var ruleMsg types.RuleMsg
+		if dataType == types.BINARY {
+			ruleMsg = types.NewMsgFromBytes(0, r.From(), dataType, types.NewMetadata(), r.Body())
+		} else {
+			ruleMsg = types.NewMsg(0, r.From(), dataType, types.NewMetadata(), string(r.Body()))
+		}
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// Message 返回原始CoAP请求
+func (r *RequestMessage) Message() *coap.Message {
+	return r.message
+}
+
+// RemoteAddr 返回客户端地址
+func (r *RequestMessage) RemoteAddr() net.Addr {
+	return r.addr
+}
+
+// ResponseMessage CoAP响应消息，路由处理完成后作为响应发送
+type ResponseMessage struct {
+	message *coap.Message
+	addr    net.Addr
+	headers textproto.MIMEHeader
+	code    coap.Code
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+	mu      sync.RWMutex
+}
+
+func (r *ResponseMessage) Body() []byte {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	if r.message == nil {
+		return ""
+	}
+	return r.message.Path()
+}
+
+func (r *ResponseMessage) GetParam(key string) string {
+	if r.message == nil {
+		return ""
+	}
+	return r.message.Queries()[key]
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.msg
+}
+
+// SetStatusCode 使用HTTP状态码设置响应码，例如 404 -> 4.04
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+	r.SetCode(coap.CodeFromHTTP(statusCode))
+}
+
+// SetCode 设置响应码
+func (r *ResponseMessage) SetCode(code coap.Code) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.code = code
+}
+
+// Code 返回设置的响应码，没有设置返回 coap.Empty
+func (r *ResponseMessage) Code() coap.Code {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.code
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.body = body
+}
+
+func (r *ResponseMessage) SetError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+func (r *ResponseMessage) GetError() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.err
+}
+
+// build 生成响应，没有设置响应体时使用路由输出的消息，没有设置响应码时出错返回 5.00，
+// 否则按请求方法返回 2.05 Content、2.04 Changed 或 2.02 Deleted
+func (r *ResponseMessage) build(method coap.Code) *coap.Message {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	resp := &coap.Message{Code: r.code, Payload: r.body}
+	var dataType types.DataType
+	if r.msg != nil {
+		dataType = r.msg.DataType
+		if resp.Payload == nil && r.err == nil {
+			resp.Payload = r.msg.GetBytes()
+		}
+	}
+	if resp.Code == coap.Empty {
+		switch {
+		case r.err != nil:
+			resp.Code = coap.InternalServerError
+			if resp.Payload == nil {
+				resp.Payload = []byte(r.err.Error())
+				dataType = types.TEXT
+			}
+		case method == coap.GET:
+			resp.Code = coap.Content
+		case method == coap.DELETE:
+			resp.Code = coap.Deleted
+		default:
+			resp.Code = coap.Changed
+		}
+	}
+	if cf, ok := coap.ParseMediaType(r.headers.Get(ContentTypeKey)); ok {
+		resp.SetContentFormat(cf)
+	} else if len(resp.Payload) > 0 && dataType != "" {
+		resp.SetContentFormat(coap.MediaTypeOf(dataType))
+	}
+	if maxAge, err := strconv.ParseUint(r.headers.Get(MaxAgeKey), 10, 32); err == nil {
+		resp.SetOptionUint(coap.MaxAge, uint32(maxAge))
+	}
+	return resp
+}
+
+// Config CoAP 服务配置
+type Config struct {
+	// Server 服务监听地址，默认 :5683
+	Server string `json:"server"`
+	// BlockSize 响应负荷超过该大小时使用 Block2 分块返回，取值16~1024，默认1024
+	BlockSize int `json:"blockSize"`
+	// ConfirmInterval 观察者距离上次确认超过该时间（秒）后，通知使用 Confirmable 消息发送，
+	// 客户端重传后仍未确认则取消观察（RFC 7641 4.5）。默认86400，小于0则每次通知都需要确认
+	ConfirmInterval int `json:"confirmInterval"`
+}
+
+// route 路由
+type route struct {
+	router endpoint.Router
+	method coap.Code
+	//路径分段，:name 匹配一个分段，*name 匹配剩余路径
+	segments []string
+}
+
+// match 匹配请求路径，返回路径参数
+func (r *route) match(segments []string) (map[string]string, bool) {
+	params := make(map[string]string)
+	for i, segment := range r.segments {
+		if strings.HasPrefix(segment, "*") {
+			params[segment[1:]] = "/" + strings.Join(segments[i:], "/")
+			return params, true
+		}
+		if i >= len(segments) {
+			return nil, false
+		}
+		if strings.HasPrefix(segment, ":") {
+			params[segment[1:]] = segments[i]
+		} else if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, len(segments) == len(r.segments)
+}
+
+// static 路径是否不包含参数
+func (r *route) static() bool {
+	for _, segment := range r.segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			return false
+		}
+	}
+	return true
+}
+
+func splitPath(path string) []string {
+	var segments []string
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// observer 观察者
+type observer struct {
+	addr  *net.UDPAddr
+	token []byte
+	seq   uint32
+	//最近一次通知的消息ID，用于匹配客户端的确认和复位消息
+	messageID uint16
+	//confirmed 客户端最近一次确认通知的时间，注册时为注册时间
+	confirmed time.Time
+	//confirm 等待确认的 Confirmable 通知，没有则为nil
+	confirm *confirm
+}
+
+// confirm 等待确认的 Confirmable 通知的重传状态
+type confirm struct {
+	data     []byte
+	retries  int
+	interval time.Duration
+	timer    *time.Timer
+}
+
+// cachedResponse 去重缓存的响应，处理中为nil
+type cachedResponse struct {
+	data    []byte
+	expires time.Time
+}
+
+// blockTransfer 分块传输中的负荷，上传时为已收到的请求负荷，下载时为完整的响应
+type blockTransfer struct {
+	payload  []byte
+	response *coap.Message
+	expires  time.Time
+}
+
+// Coap CoAP 接收端端点
+type Coap struct {
+	impl.BaseEndpoint
+	// Config 配置
+	Config Config
+	// RuleConfig rulego配置
+	RuleConfig types.Config
+	// AckTimeout Confirmable 通知首次重传等待确认的时间，每次重传加倍，默认 coap.DefaultAckTimeout
+	AckTimeout time.Duration
+	// MaxRetransmit Confirmable 通知的最大重传次数，默认 coap.DefaultMaxRetransmit
+	MaxRetransmit int
+	conn          *net.UDPConn
+	routes        []*route
+	//observers 路径 -> 客户端地址和token -> 观察者
+	observers    map[string]map[string]*observer
+	observerLock sync.Mutex
+	//responses 客户端地址和消息ID -> 响应，用于重传去重
+	responses    map[string]*cachedResponse
+	responseLock sync.Mutex
+	//uploads 和 downloads 客户端地址、方法和资源 -> 分块传输中的负荷
+	uploads   map[string]*blockTransfer
+	downloads map[string]*blockTransfer
+	blockLock sync.Mutex
+	messageID uint32
+	closed    int32
+}
+
+// Type 组件类型
+func (ep *Coap) Type() string {
+	return Type
+}
+
+func (ep *Coap) New() types.Node {
+	return &Coap{
+		Config: Config{
+			Server:          ":5683",
+			BlockSize:       coap.DefaultBlockSize,
+			ConfirmInterval: defaultConfirmInterval,
+		},
+		AckTimeout:    coap.DefaultAckTimeout,
+		MaxRetransmit: coap.DefaultMaxRetransmit,
+	}
+}
+
+// Init 初始化
+func (ep *Coap) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &ep.Config)
+	if ep.Config.BlockSize <= 0 {
+		ep.Config.BlockSize = coap.DefaultBlockSize
+	}
+	if ep.Config.ConfirmInterval == 0 {
+		ep.Config.ConfirmInterval = defaultConfirmInterval
+	}
+	if ep.AckTimeout <= 0 {
+		ep.AckTimeout = coap.DefaultAckTimeout
+	}
+	ep.RuleConfig = ruleConfig
+	return err
+}
+
+// Destroy 销毁
+func (ep *Coap) Destroy() {
+	_ = ep.Close()
+}
+
+// Close 停止服务并清除观察者
+func (ep *Coap) Close() error {
+	atomic.StoreInt32(&ep.closed, 1)
+	coap.UnregisterNotifier(ep.Config.Server, ep)
+	ep.observerLock.Lock()
+	for _, group := range ep.observers {
+		for _, o := range group {
+			o.stopConfirm()
+		}
+	}
+	ep.observers = nil
+	ep.observerLock.Unlock()
+	ep.Lock()
+	defer ep.Unlock()
+	if ep.conn != nil {
+		err := ep.conn.Close()
+		ep.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (ep *Coap) Id() string {
+	return ep.Config.Server
+}
+
+// AddRouter 添加路由，params[0] 为请求方法：GET、POST、PUT、DELETE
+func (ep *Coap) AddRouter(router endpoint.Router, params ...interface{}) (string, error) {
+	if len(params) <= 0 {
+		return "", errors.New("need to specify CoAP method")
+	} else if router == nil {
+		return "", errors.New("router can not nil")
+	}
+	method, err := coap.ParseMethod(fmt.Sprint(params[0]))
+	if err != nil {
+		return "", err
+	}
+	router.SetParams(method.String())
+	ep.CheckAndSetRouterId(router)
+	ep.Lock()
+	defer ep.Unlock()
+	if ep.RouterStorage == nil {
+		ep.RouterStorage = make(map[string]endpoint.Router)
+	}
+	if _, ok := ep.RouterStorage[router.GetId()]; ok {
+		return router.GetId(), fmt.Errorf("duplicate router %s", router.GetId())
+	}
+	ep.RouterStorage[router.GetId()] = router
+	ep.routes = append(ep.routes, &route{
+		router:   router,
+		method:   method,
+		segments: splitPath(router.FromToString()),
+	})
+	return router.GetId(), nil
+}
+
+func (ep *Coap) RemoveRouter(routerId string, params ...interface{}) error {
+	routerId = strings.TrimSpace(routerId)
+	ep.Lock()
+	defer ep.Unlock()
+	if _, ok := ep.RouterStorage[routerId]; !ok {
+		return fmt.Errorf("router: %s not found", routerId)
+	}
+	delete(ep.RouterStorage, routerId)
+	routes := ep.routes[:0]
+	for _, item := range ep.routes {
+		if item.router.GetId() != routerId {
+			routes = append(routes, item)
+		}
+	}
+	ep.routes = routes
+	return nil
+}
+
+// match 匹配路由，静态路径优先。路径存在但方法不匹配时 methodMismatch 为true
+func (ep *Coap) match(method coap.Code, path string) (router endpoint.Router, params map[string]string, methodMismatch bool) {
+	segments := splitPath(path)
+	ep.RLock()
+	defer ep.RUnlock()
+	for _, static := range []bool{true, false} {
+		for _, item := range ep.routes {
+			if item.static() != static || item.router.IsDisable() {
+				continue
+			}
+			if p, ok := item.match(segments); ok {
+				if item.method == method {
+					return item.router, p, false
+				}
+				methodMismatch = true
+			}
+		}
+	}
+	return nil, nil, methodMismatch
+}
+
+func (ep *Coap) Start() error {
+	addr, err := net.ResolveUDPAddr("udp", ep.Config.Server)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+	ep.Lock()
+	ep.conn = conn
+	ep.Unlock()
+	atomic.StoreInt32(&ep.closed, 0)
+	coap.RegisterNotifier(ep.Config.Server, ep)
+	if ep.OnEvent != nil {
+		ep.OnEvent(endpoint.EventInitServer, ep)
+	}
+	ep.Printf("started CoAP server on %s", ep.Config.Server)
+	go ep.serve(conn)
+	return nil
+}
+
+// LocalAddr 返回服务监听地址，未启动返回nil
+func (ep *Coap) LocalAddr() net.Addr {
+	ep.RLock()
+	defer ep.RUnlock()
+	if ep.conn == nil {
+		return nil
+	}
+	return ep.conn.LocalAddr()
+}
+
+func (ep *Coap) Printf(format string, v ...interface{}) {
+	if ep.RuleConfig.Logger != nil {
+		ep.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// serve 循环读取数据报
+func (ep *Coap) serve(conn *net.UDPConn) {
+	buf := make([]byte, coap.MaxMessageSize)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if atomic.LoadInt32(&ep.closed) == 1 || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		m, err := coap.Unmarshal(buf[:n])
+		if err != nil {
+			continue
+		}
+		switch {
+		case m.Type == coap.Reset:
+			//客户端不再需要通知
+			ep.removeObserverByMessageID(addr, m.MessageID)
+		case m.Type == coap.Acknowledgement:
+			//客户端确认了 Confirmable 通知
+			ep.confirmObserver(addr, m.MessageID)
+		case m.Code.IsRequest():
+			if !ep.isDuplicate(conn, addr, m) {
+				go ep.handle(conn, addr, m)
+			}
+		case m.Type == coap.Confirmable:
+			//空的 Confirmable 消息为 CoAP ping，回复复位消息
+			ep.write(conn, addr, &coap.Message{Type: coap.Reset, MessageID: m.MessageID})
+		}
+	}
+}
+
+// handle 处理请求并发送响应
+func (ep *Coap) handle(conn *net.UDPConn, addr *net.UDPAddr, m *coap.Message) {
+	defer func() {
+		//捕捉异常
+		if e := recover(); e != nil {
+			ep.Printf("coap endpoint handler err :\n%v", runtime.Stack())
+		}
+	}()
+	resp := ep.respond(addr, m)
+	resp.Token = m.Token
+	if m.Type == coap.Confirmable {
+		resp.Type = coap.Acknowledgement
+		resp.MessageID = m.MessageID
+	} else {
+		resp.Type = coap.NonConfirmable
+		resp.MessageID = ep.nextMessageID()
+	}
+	if data := ep.write(conn, addr, resp); data != nil {
+		ep.cacheResponse(addr, m.MessageID, data)
+	}
+}
+
+// respond 生成请求的响应，处理分块传输。Block1 的请求块收齐后再执行路由，
+// 响应超过块大小或者请求后续的 Block2 块时按块返回，后续的块优先从缓存的响应中获取
+func (ep *Coap) respond(addr *net.UDPAddr, m *coap.Message) *coap.Message {
+	key := blockKey(addr, m)
+	block1, hasBlock1 := m.Block(coap.Block1)
+	if hasBlock1 {
+		payload, code := ep.receiveBlock(key, block1, m.Payload)
+		if code != coap.Empty {
+			resp := &coap.Message{Code: code}
+			if code == coap.Continue {
+				resp.SetBlock(coap.Block1, block1)
+			}
+			return resp
+		}
+		m.Payload = payload
+		m.RemoveOption(coap.Block1)
+	}
+	block2, hasBlock2 := m.Block(coap.Block2)
+	if hasBlock2 && block2.Num > 0 {
+		if full, ok := ep.cachedBlocks(key); ok {
+			return sliceResponse(full, block2)
+		}
+		//只有 GET 可以重新执行路由获取后续的块
+		if m.Code != coap.GET {
+			return &coap.Message{Code: coap.RequestIncomplete}
+		}
+	}
+	var resp *coap.Message
+	router, params, methodMismatch := ep.match(m.Code, m.Path())
+	if router == nil {
+		resp = &coap.Message{Code: coap.NotFound}
+		if methodMismatch {
+			resp.Code = coap.MethodNotAllowed
+		}
+	} else {
+		resp = ep.process(router, params, addr, m)
+	}
+	if hasBlock1 {
+		resp.SetBlock(coap.Block1, block1)
+	}
+	//客户端可以要求更小的块
+	block := coap.Block{Num: block2.Num, SZX: coap.BlockSZX(ep.Config.BlockSize)}
+	if hasBlock2 && block2.SZX < block.SZX {
+		block.SZX = block2.SZX
+	}
+	if len(resp.Payload) > block.Size() || block.Num > 0 {
+		ep.cacheBlocks(key, resp)
+		return sliceResponse(resp, block)
+	}
+	return resp
+}
+
+// blockKey 分块传输的键，由客户端地址、方法、路径和查询参数组成
+func blockKey(addr *net.UDPAddr, m *coap.Message) string {
+	return addr.String() + " " + m.Code.String() + " " + m.Path() + "?" + strings.Join(m.OptionStrings(coap.URIQuery), "&")
+}
+
+// receiveBlock 保存收到的请求块，还有后续块时返回 2.31 Continue，块不连续时返回 4.08，
+// 超过最大负荷时返回 4.13，收齐后返回合并的负荷和 Empty
+func (ep *Coap) receiveBlock(key string, block coap.Block, data []byte) ([]byte, coap.Code) {
+	now := time.Now()
+	ep.blockLock.Lock()
+	defer ep.blockLock.Unlock()
+	if ep.uploads == nil {
+		ep.uploads = make(map[string]*blockTransfer)
+	}
+	expireBlocks(ep.uploads, now)
+	upload, ok := ep.uploads[key]
+	if block.Num == 0 {
+		upload = &blockTransfer{}
+		ep.uploads[key] = upload
+	} else if !ok || len(upload.payload) != block.Offset() {
+		delete(ep.uploads, key)
+		return nil, coap.RequestIncomplete
+	}
+	if len(upload.payload)+len(data) > maxBlockTransferSize {
+		delete(ep.uploads, key)
+		return nil, coap.RequestTooLarge
+	}
+	upload.payload = append(upload.payload, data...)
+	upload.expires = now.Add(exchangeLifetime)
+	if block.More {
+		return nil, coap.Continue
+	}
+	delete(ep.uploads, key)
+	return upload.payload, coap.Empty
+}
+
+// cacheBlocks 缓存分块返回的完整响应
+func (ep *Coap) cacheBlocks(key string, resp *coap.Message) {
+	now := time.Now()
+	ep.blockLock.Lock()
+	defer ep.blockLock.Unlock()
+	if ep.downloads == nil {
+		ep.downloads = make(map[string]*blockTransfer)
+	}
+	expireBlocks(ep.downloads, now)
+	ep.downloads[key] = &blockTransfer{response: resp, expires: now.Add(exchangeLifetime)}
+}
+
+// cachedBlocks 返回缓存的完整响应
+func (ep *Coap) cachedBlocks(key string) (*coap.Message, bool) {
+	ep.blockLock.Lock()
+	defer ep.blockLock.Unlock()
+	download, ok := ep.downloads[key]
+	if !ok || time.Now().After(download.expires) {
+		return nil, false
+	}
+	return download.response, true
+}
+
+// expireBlocks 删除过期的分块传输，调用方需持有锁
+func expireBlocks(transfers map[string]*blockTransfer, now time.Time) {
+	for k, transfer := range transfers {
+		if now.After(transfer.expires) {
+			delete(transfers, k)
+		}
+	}
+}
+
+// sliceResponse 返回完整响应的一个块，首个块带 Size2 选项，后续的块不带 Observe 选项。
+// 块超出负荷范围时返回 4.02 Bad Option
+func sliceResponse(full *coap.Message, block coap.Block) *coap.Message {
+	part, block, ok := coap.SliceBlock(full.Payload, block)
+	if !ok {
+		return &coap.Message{Code: coap.BadOption}
+	}
+	resp := &coap.Message{Code: full.Code, Options: append([]coap.Option(nil), full.Options...), Payload: part}
+	resp.SetBlock(coap.Block2, block)
+	if block.Num == 0 {
+		resp.SetOptionUint(coap.Size2, uint32(len(full.Payload)))
+	} else {
+		resp.RemoveOption(coap.Observe)
+	}
+	return resp
+}
+
+// process 执行路由并生成响应，处理观察注册和取消
+func (ep *Coap) process(router endpoint.Router, params map[string]string, addr *net.UDPAddr, m *coap.Message) *coap.Message {
+	exchange := &endpoint.Exchange{
+		In: &RequestMessage{
+			message: m,
+			addr:    addr,
+			Params:  params,
+		},
+		Out: &ResponseMessage{
+			message: m,
+			addr:    addr,
+		},
+	}
+	msg := exchange.In.GetMsg()
+	//把路径参数和查询参数放到msg元数据中
+	for k, v := range params {
+		msg.Metadata.PutValue(k, v)
+	}
+	for k, v := range m.Queries() {
+		msg.Metadata.PutValue(k, v)
+	}
+	msg.Metadata.PutValue(RemoteAddrKey, addr.String())
+	observe, observing := m.Observe()
+	if observing && m.Code == coap.GET {
+		msg.Metadata.PutValue(ObserveKey, strconv.FormatUint(uint64(observe), 10))
+	}
+	ep.DoProcess(context.Background(), router, exchange)
+
+	resp := exchange.Out.(*ResponseMessage).build(m.Code)
+	if observing && m.Code == coap.GET {
+		if observe == 0 && resp.Code.IsSuccess() {
+			resp.SetOptionUint(coap.Observe, ep.addObserver(m.Path(), addr, m.Token))
+		} else {
+			ep.removeObserver(m.Path(), addr, m.Token)
+		}
+	}
+	return resp
+}
+
+// write 编码并发送消息，返回发送的数据
+func (ep *Coap) write(conn *net.UDPConn, addr *net.UDPAddr, m *coap.Message) []byte {
+	data, err := m.Marshal()
+	if err != nil {
+		ep.Printf("coap endpoint marshal err :%v", err)
+		return nil
+	}
+	if _, err = conn.WriteToUDP(data, addr); err != nil {
+		ep.Printf("coap endpoint write to %s err :%v", addr, err)
+	}
+	return data
+}
+
+func (ep *Coap) nextMessageID() uint16 {
+	return uint16(atomic.AddUint32(&ep.messageID, 1))
+}
+
+// isDuplicate 检查重传的请求，已处理的请求重发缓存的响应，处理中的请求忽略
+func (ep *Coap) isDuplicate(conn *net.UDPConn, addr *net.UDPAddr, m *coap.Message) bool {
+	key := addr.String() + "/" + strconv.Itoa(int(m.MessageID))
+	now := time.Now()
+	ep.responseLock.Lock()
+	defer ep.responseLock.Unlock()
+	if ep.responses == nil {
+		ep.responses = make(map[string]*cachedResponse)
+	}
+	if cached, ok := ep.responses[key]; ok && now.Before(cached.expires) {
+		if cached.data != nil {
+			_, _ = conn.WriteToUDP(cached.data, addr)
+		}
+		return true
+	}
+	for k, cached := range ep.responses {
+		if now.After(cached.expires) {
+			delete(ep.responses, k)
+		}
+	}
+	ep.responses[key] = &cachedResponse{expires: now.Add(exchangeLifetime)}
+	return false
+}
+
+// cacheResponse 缓存请求的响应
+func (ep *Coap) cacheResponse(addr *net.UDPAddr, messageID uint16, data []byte) {
+	key := addr.String() + "/" + strconv.Itoa(int(messageID))
+	ep.responseLock.Lock()
+	defer ep.responseLock.Unlock()
+	if cached, ok := ep.responses[key]; ok {
+		cached.data = data
+	}
+}
+
+func observerKey(addr *net.UDPAddr, token []byte) string {
+	return addr.String() + "/" + string(token)
+}
+
+// addObserver 注册观察者，返回初始 Observe 序号
+func (ep *Coap) addObserver(path string, addr *net.UDPAddr, token []byte) uint32 {
+	ep.observerLock.Lock()
+	defer ep.observerLock.Unlock()
+	if ep.observers == nil {
+		ep.observers = make(map[string]map[string]*observer)
+	}
+	group, ok := ep.observers[path]
+	if !ok {
+		group = make(map[string]*observer)
+		ep.observers[path] = group
+	}
+	key := observerKey(addr, token)
+	if o, ok := group[key]; ok {
+		return o.seq
+	}
+	group[key] = &observer{addr: addr, token: token, confirmed: time.Now()}
+	if ep.OnEvent != nil {
+		ep.OnEvent(endpoint.EventConnect, path, addr.String())
+	}
+	return 0
+}
+
+// removeObserver 取消观察者
+func (ep *Coap) removeObserver(path string, addr *net.UDPAddr, token []byte) {
+	ep.observerLock.Lock()
+	defer ep.observerLock.Unlock()
+	if group, ok := ep.observers[path]; ok {
+		ep.deleteObserver(path, group, observerKey(addr, token))
+	}
+}
+
+// removeObserverByMessageID 客户端复位通知时取消观察者
+func (ep *Coap) removeObserverByMessageID(addr *net.UDPAddr, messageID uint16) {
+	ep.observerLock.Lock()
+	defer ep.observerLock.Unlock()
+	for path, group := range ep.observers {
+		for key, o := range group {
+			if o.messageID == messageID && o.addr.String() == addr.String() {
+				ep.deleteObserver(path, group, key)
+				return
+			}
+		}
+	}
+}
+
+// deleteObserver 删除观察者，调用方需持有锁
+func (ep *Coap) deleteObserver(path string, group map[string]*observer, key string) {
+	o, ok := group[key]
+	if !ok {
+		return
+	}
+	o.stopConfirm()
+	delete(group, key)
+	if len(group) == 0 {
+		delete(ep.observers, path)
+	}
+	if ep.OnEvent != nil {
+		ep.OnEvent(endpoint.EventDisconnect, path, o.addr.String())
+	}
+}
+
+// Observers 返回路径的观察者数量
+func (ep *Coap) Observers(path string) int {
+	ep.observerLock.Lock()
+	defer ep.observerLock.Unlock()
+	return len(ep.observers[path])
+}
+
+// Notify 向路径的所有观察者发送 2.05 Content 通知，返回通知的观察者数量。
+// 通知默认为 Non-confirmable 消息，观察者距离上次确认超过 Config.ConfirmInterval 后使用 Confirmable 消息，
+// 按 AckTimeout 和 MaxRetransmit 重传，仍未确认则取消观察；等待确认期间的新通知替代原通知，沿用重传计数（RFC 7641 4.5）。
+// 客户端回复复位消息时取消观察。Observe 序号为24位，超出后回绕到0。通知不分块，负荷需要小于数据报大小
+func (ep *Coap) Notify(path string, dataType types.DataType, data []byte) int {
+	ep.RLock()
+	conn := ep.conn
+	ep.RUnlock()
+	if conn == nil {
+		return 0
+	}
+	ep.observerLock.Lock()
+	defer ep.observerLock.Unlock()
+	group := ep.observers[path]
+	for key, o := range group {
+		o.seq = (o.seq + 1) & observeSeqMask
+		o.messageID = ep.nextMessageID()
+		m := &coap.Message{
+			Type:      coap.NonConfirmable,
+			Code:      coap.Content,
+			MessageID: o.messageID,
+			Token:     o.token,
+			Payload:   data,
+		}
+		m.SetOptionUint(coap.Observe, o.seq)
+		m.SetContentFormat(coap.MediaTypeOf(dataType))
+		confirmable := ep.needConfirm(o)
+		if confirmable {
+			m.Type = coap.Confirmable
+		}
+		if sent := ep.write(conn, o.addr, m); sent != nil && confirmable {
+			ep.awaitConfirm(conn, path, key, o, sent)
+		}
+	}
+	return len(group)
+}
+
+// needConfirm 判断通知是否需要使用 Confirmable 消息
+func (ep *Coap) needConfirm(o *observer) bool {
+	if o.confirm != nil || ep.Config.ConfirmInterval < 0 {
+		return true
+	}
+	return time.Since(o.confirmed) >= time.Duration(ep.Config.ConfirmInterval)*time.Second
+}
+
+// awaitConfirm 等待客户端确认通知，超时重传，重传次数用完后取消观察。调用方需持有锁
+func (ep *Coap) awaitConfirm(conn *net.UDPConn, path, key string, o *observer, data []byte) {
+	if o.confirm != nil {
+		//新通知替代等待确认的通知，沿用重传计数
+		o.confirm.data = data
+		return
+	}
+	c := &confirm{data: data, interval: ep.AckTimeout}
+	o.confirm = c
+	c.timer = time.AfterFunc(c.interval, func() {
+		ep.retransmit(conn, path, key, o, c)
+	})
+}
+
+// retransmit 重传未确认的通知
+func (ep *Coap) retransmit(conn *net.UDPConn, path, key string, o *observer, c *confirm) {
+	ep.observerLock.Lock()
+	defer ep.observerLock.Unlock()
+	if o.confirm != c {
+		return
+	}
+	if c.retries++; c.retries > ep.MaxRetransmit {
+		if group, ok := ep.observers[path]; ok && group[key] == o {
+			ep.deleteObserver(path, group, key)
+		}
+		return
+	}
+	if _, err := conn.WriteToUDP(c.data, o.addr); err != nil {
+		ep.Printf("coap endpoint write to %s err :%v", o.addr, err)
+	}
+	c.interval *= 2
+	c.timer.Reset(c.interval)
+}
+
+// confirmObserver 客户端确认通知后停止重传
+func (ep *Coap) confirmObserver(addr *net.UDPAddr, messageID uint16) {
+	ep.observerLock.Lock()
+	defer ep.observerLock.Unlock()
+	for _, group := range ep.observers {
+		for _, o := range group {
+			if o.confirm != nil && o.messageID == messageID && o.addr.String() == addr.String() {
+				o.stopConfirm()
+				o.confirmed = time.Now()
+				return
+			}
+		}
+	}
+}
+
+// stopConfirm 停止等待确认
+func (o *observer) stopConfirm() {
+	if o.confirm != nil {
+		o.confirm.timer.Stop()
+		o.confirm = nil
+	}
+}
@@ -0,0 +1,404 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coap
+
+import (
+	"bytes"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/coap"
+)
+
+var testServer = "127.0.0.1:5699"
+
+var testChain = `{
+  "ruleChain": {"id": "coapChain", "name": "coap"},
+  "metadata": {
+    "nodes": [
+      {
+        "id": "s1",
+        "type": "jsTransform",
+        "configuration": {
+          "jsScript": "msg.temperature = msg.temperature + 1; metadata.deviceId = metadata.id; return {'msg':msg,'metadata':metadata,'msgType':msgType};"
+        }
+      }
+    ]
+  }
+}`
+
+// 测试请求/响应消息
+func TestCoapMessage(t *testing.T) {
+	t.Run("Request", func(t *testing.T) {
+		var request = &RequestMessage{}
+		test.EndpointMessage(t, request)
+	})
+	t.Run("Response", func(t *testing.T) {
+		var response = &ResponseMessage{}
+		test.EndpointMessage(t, response)
+	})
+}
+
+func TestRouterId(t *testing.T) {
+	var ep = &Endpoint{}
+	err := ep.Init(types.NewConfig(), types.Configuration{"server": testServer})
+	assert.Nil(t, err)
+	assert.Equal(t, testServer, ep.Id())
+	assert.Equal(t, ":5683", ep.New().(*Endpoint).Config.Server)
+	assert.Equal(t, coap.DefaultBlockSize, ep.Config.BlockSize)
+
+	_, err = ep.AddRouter(impl.NewRouter().From("/a").End())
+	assert.Equal(t, "need to specify CoAP method", err.Error())
+	_, err = ep.AddRouter(nil, "GET")
+	assert.Equal(t, "router can not nil", err.Error())
+	_, err = ep.AddRouter(impl.NewRouter().From("/a").End(), "PATCH")
+	assert.Equal(t, "unsupported coap method: PATCH", err.Error())
+
+	routerId, err := ep.AddRouter(impl.NewRouter().SetId("r1").From("/sensors/:id").End(), "get")
+	assert.Nil(t, err)
+	assert.Equal(t, "r1", routerId)
+	_, err = ep.AddRouter(impl.NewRouter().SetId("r1").From("/sensors/:id").End(), "PUT")
+	assert.NotNil(t, err)
+	routerId, _ = ep.AddRouter(impl.NewRouter().From("/sensors").End(), "POST")
+	assert.Equal(t, "/sensors", routerId)
+
+	assert.Nil(t, ep.RemoveRouter("r1"))
+	assert.Nil(t, ep.RemoveRouter("/sensors"))
+	err = ep.RemoveRouter("/sensors")
+	assert.Equal(t, "router: /sensors not found", err.Error())
+}
+
+func TestCoapEndpoint(t *testing.T) {
+	config := engine.NewConfig(types.WithDefaultPool())
+	_, err := engine.New("coapChain", []byte(testChain), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("coapChain")
+
+	ep := &Endpoint{}
+	err = ep.Init(config, types.Configuration{"server": testServer, "blockSize": 256})
+	assert.Nil(t, err)
+	defer ep.Destroy()
+	var connected, disconnected int32
+	ep.OnEvent = func(eventName string, params ...interface{}) {
+		switch eventName {
+		case endpoint.EventConnect:
+			atomic.AddInt32(&connected, 1)
+		case endpoint.EventDisconnect:
+			atomic.AddInt32(&disconnected, 1)
+		}
+	}
+
+	var processed int32
+	_, err = ep.AddRouter(impl.NewRouter().From("/sensors/:id").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		atomic.AddInt32(&processed, 1)
+		msg := exchange.In.GetMsg()
+		exchange.Out.Headers().Set(MaxAgeKey, "30")
+		exchange.Out.SetBody([]byte(msg.Metadata.GetValue("id") + ":" + msg.Metadata.GetValue("unit")))
+		return false
+	}).End(), "GET")
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("/telemetry/:id").To("chain:coapChain").Wait().End(), "POST")
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("/secure").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		exchange.Out.SetStatusCode(401)
+		exchange.Out.SetBody([]byte("unauthorized"))
+		return false
+	}).End(), "PUT")
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("/files/*path").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		exchange.Out.SetBody([]byte(exchange.In.GetParam("path")))
+		return false
+	}).End(), "DELETE")
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("/state").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		exchange.Out.SetBody([]byte("on"))
+		return false
+	}).End(), "GET")
+	assert.Nil(t, err)
+	var echoed int32
+	_, err = ep.AddRouter(impl.NewRouter().From("/echo").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		atomic.AddInt32(&echoed, 1)
+		exchange.Out.SetBody(exchange.In.Body())
+		return false
+	}).End(), "POST")
+	assert.Nil(t, err)
+	largePayload := bytes.Repeat([]byte("0123456789"), 100)
+	_, err = ep.AddRouter(impl.NewRouter().From("/large").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		exchange.Out.SetBody(largePayload)
+		return false
+	}).End(), "GET")
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+
+	client, err := coap.Dial("udp", testServer, time.Second)
+	assert.Nil(t, err)
+	defer client.Close()
+
+	request := func(method coap.Code, path string, payload []byte) *coap.Message {
+		req := &coap.Message{Type: coap.Confirmable, Code: method, Payload: payload}
+		req.SetPath(path)
+		resp, err := client.Do(req, time.Second*3)
+		assert.Nil(t, err)
+		return resp
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		resp := request(coap.GET, "/sensors/s1?unit=C", nil)
+		assert.Equal(t, coap.Content, resp.Code)
+		assert.Equal(t, "s1:C", string(resp.Payload))
+		maxAge, _ := resp.OptionUint(coap.MaxAge)
+		assert.Equal(t, uint32(30), maxAge)
+	})
+
+	t.Run("Chain", func(t *testing.T) {
+		req := &coap.Message{Type: coap.Confirmable, Code: coap.POST, Payload: []byte(`{"temperature":20}`)}
+		req.SetPath("/telemetry/d1")
+		req.SetContentFormat(coap.AppJSON)
+		resp, err := client.Do(req, time.Second*3)
+		assert.Nil(t, err)
+		assert.Equal(t, coap.Changed, resp.Code)
+		assert.Equal(t, `{"temperature":21}`, string(resp.Payload))
+		cf, _ := resp.ContentFormat()
+		assert.Equal(t, coap.AppJSON, cf)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		resp := request(coap.PUT, "/secure", []byte("x"))
+		assert.Equal(t, coap.Unauthorized, resp.Code)
+		assert.Equal(t, "unauthorized", string(resp.Payload))
+		assert.Equal(t, coap.NotFound, request(coap.GET, "/unknown", nil).Code)
+		assert.Equal(t, coap.MethodNotAllowed, request(coap.PUT, "/sensors/s1", nil).Code)
+	})
+
+	t.Run("CatchAll", func(t *testing.T) {
+		resp := request(coap.DELETE, "/files/logs/a.txt", nil)
+		assert.Equal(t, coap.Deleted, resp.Code)
+		assert.Equal(t, "/logs/a.txt", string(resp.Payload))
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		conn, err := net.Dial("udp", testServer)
+		assert.Nil(t, err)
+		defer conn.Close()
+		req := &coap.Message{Type: coap.Confirmable, Code: coap.GET, MessageID: 42, Token: []byte{1}}
+		req.SetPath("/sensors/s2")
+		data, _ := req.Marshal()
+		atomic.StoreInt32(&processed, 0)
+		buf := make([]byte, 1024)
+		var responses [][]byte
+		for i := 0; i < 2; i++ {
+			_, _ = conn.Write(data)
+			_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+			n, err := conn.Read(buf)
+			assert.Nil(t, err)
+			responses = append(responses, append([]byte(nil), buf[:n]...))
+		}
+		assert.Equal(t, responses[0], responses[1])
+		assert.Equal(t, int32(1), atomic.LoadInt32(&processed))
+
+		//CoAP ping
+		ping, _ := (&coap.Message{Type: coap.Confirmable, MessageID: 43}).Marshal()
+		_, _ = conn.Write(ping)
+		n, err := conn.Read(buf)
+		assert.Nil(t, err)
+		pong, _ := coap.Unmarshal(buf[:n])
+		assert.Equal(t, coap.Reset, pong.Type)
+		assert.Equal(t, uint16(43), pong.MessageID)
+	})
+
+	t.Run("BlockWise", func(t *testing.T) {
+		//响应按256字节分块返回，客户端合并
+		resp := request(coap.GET, "/large", nil)
+		assert.Equal(t, coap.Content, resp.Code)
+		assert.Equal(t, largePayload, resp.Payload)
+
+		//请求按64字节分块发送，服务端合并后只执行一次路由，响应再分块返回
+		client.BlockSize = 64
+		defer func() {
+			client.BlockSize = coap.DefaultBlockSize
+		}()
+		atomic.StoreInt32(&echoed, 0)
+		resp = request(coap.POST, "/echo", largePayload)
+		assert.Equal(t, coap.Changed, resp.Code)
+		assert.Equal(t, largePayload, resp.Payload)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&echoed))
+
+		//客户端要求更小的块
+		req := &coap.Message{Type: coap.Confirmable, Code: coap.GET}
+		req.SetPath("/large")
+		req.SetBlock(coap.Block2, coap.Block{SZX: 2})
+		resp, err := client.Do(req, time.Second*3)
+		assert.Nil(t, err)
+		assert.Equal(t, largePayload, resp.Payload)
+
+		//不连续的请求块
+		req = &coap.Message{Type: coap.Confirmable, Code: coap.POST, Payload: largePayload[:64]}
+		req.SetPath("/echo")
+		req.SetBlock(coap.Block1, coap.Block{Num: 3, More: true, SZX: 2})
+		resp, err = client.Do(req, time.Second*3)
+		assert.Nil(t, err)
+		assert.Equal(t, coap.RequestIncomplete, resp.Code)
+
+		//超出负荷范围的块
+		req = &coap.Message{Type: coap.Confirmable, Code: coap.GET}
+		req.SetPath("/large")
+		req.SetBlock(coap.Block2, coap.Block{Num: 100, SZX: 4})
+		data, _ := req.Marshal()
+		conn, err := net.Dial("udp", testServer)
+		assert.Nil(t, err)
+		defer conn.Close()
+		_, _ = conn.Write(data)
+		buf := make([]byte, 2048)
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.Read(buf)
+		assert.Nil(t, err)
+		resp, _ = coap.Unmarshal(buf[:n])
+		assert.Equal(t, coap.BadOption, resp.Code)
+	})
+
+	t.Run("Observe", func(t *testing.T) {
+		notifications := make(chan *coap.Message, 10)
+		req := &coap.Message{Type: coap.Confirmable, Code: coap.GET}
+		req.SetPath("/state")
+		resp, cancel, err := client.Observe(req, time.Second, func(m *coap.Message) {
+			notifications <- m
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "on", string(resp.Payload))
+		seq, ok := resp.Observe()
+		assert.True(t, ok)
+		assert.Equal(t, uint32(0), seq)
+		assert.Equal(t, 1, ep.Observers("/state"))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&connected))
+
+		assert.Equal(t, 1, ep.Notify("/state", types.TEXT, []byte("off")))
+		select {
+		case m := <-notifications:
+			assert.Equal(t, "off", string(m.Payload))
+			seq, _ = m.Observe()
+			assert.Equal(t, uint32(1), seq)
+		case <-time.After(time.Second):
+			t.Fatal("notification timeout")
+		}
+		assert.Equal(t, 0, ep.Notify("/sensors/s1", types.TEXT, []byte("x")))
+
+		//客户端取消后复位通知，服务端删除观察者
+		cancel()
+		ep.Notify("/state", types.TEXT, []byte("on"))
+		time.Sleep(time.Millisecond * 200)
+		assert.Equal(t, 0, ep.Observers("/state"))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&disconnected))
+	})
+
+	t.Run("ObserveWraparound", func(t *testing.T) {
+		conn, err := net.Dial("udp", testServer)
+		assert.Nil(t, err)
+		defer conn.Close()
+		req := &coap.Message{Type: coap.Confirmable, Code: coap.GET, MessageID: 500, Token: []byte{5}}
+		req.SetPath("/state")
+		req.SetOptionUint(coap.Observe, 0)
+		data, _ := req.Marshal()
+		_, _ = conn.Write(data)
+		buf := make([]byte, 1024)
+		read := func() *coap.Message {
+			_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+			n, err := conn.Read(buf)
+			assert.Nil(t, err)
+			m, err := coap.Unmarshal(buf[:n])
+			assert.Nil(t, err)
+			return m
+		}
+		read()
+		ep.observerLock.Lock()
+		for _, o := range ep.observers["/state"] {
+			o.seq = observeSeqMask - 1
+		}
+		ep.observerLock.Unlock()
+
+		//24位序号用完后回绕到0
+		for _, expected := range []uint32{observeSeqMask, 0, 1} {
+			ep.Notify("/state", types.TEXT, []byte("on"))
+			seq, ok := read().Observe()
+			assert.True(t, ok)
+			assert.Equal(t, expected, seq)
+		}
+		ep.removeObserver("/state", conn.LocalAddr().(*net.UDPAddr), []byte{5})
+	})
+
+	t.Run("ObserveConfirm", func(t *testing.T) {
+		n, ok := coap.GetNotifier(testServer)
+		assert.True(t, ok)
+		assert.True(t, n == coap.Notifier(ep))
+		ep.Config.ConfirmInterval = -1
+		ep.AckTimeout = time.Millisecond * 50
+		ep.MaxRetransmit = 2
+		defer func() {
+			ep.Config.ConfirmInterval = defaultConfirmInterval
+			ep.AckTimeout = coap.DefaultAckTimeout
+			ep.MaxRetransmit = coap.DefaultMaxRetransmit
+		}()
+		conn, err := net.Dial("udp", testServer)
+		assert.Nil(t, err)
+		defer conn.Close()
+		req := &coap.Message{Type: coap.Confirmable, Code: coap.GET, MessageID: 600, Token: []byte{6}}
+		req.SetPath("/state")
+		req.SetOptionUint(coap.Observe, 0)
+		data, _ := req.Marshal()
+		_, _ = conn.Write(data)
+		buf := make([]byte, 1024)
+		read := func() *coap.Message {
+			_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+			n, err := conn.Read(buf)
+			assert.Nil(t, err)
+			m, err := coap.Unmarshal(buf[:n])
+			assert.Nil(t, err)
+			return m
+		}
+		read()
+		disconnectedBefore := atomic.LoadInt32(&disconnected)
+
+		//确认通知后观察者保留
+		ep.Notify("/state", types.TEXT, []byte("off"))
+		m := read()
+		assert.Equal(t, coap.Confirmable, m.Type)
+		ack, _ := (&coap.Message{Type: coap.Acknowledgement, Code: coap.Empty, MessageID: m.MessageID}).Marshal()
+		_, _ = conn.Write(ack)
+		time.Sleep(time.Millisecond * 300)
+		assert.Equal(t, 1, ep.Observers("/state"))
+
+		//未确认的通知重传后取消观察
+		ep.Notify("/state", types.TEXT, []byte("on"))
+		first := read()
+		assert.Equal(t, coap.Confirmable, first.Type)
+		for i := 0; i < 2; i++ {
+			retransmitted := read()
+			assert.Equal(t, first.MessageID, retransmitted.MessageID)
+			assert.Equal(t, "on", string(retransmitted.Payload))
+		}
+		time.Sleep(time.Millisecond * 400)
+		assert.Equal(t, 0, ep.Observers("/state"))
+		assert.Equal(t, disconnectedBefore+1, atomic.LoadInt32(&disconnected))
+	})
+}
@@ -0,0 +1,724 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package filewatch provides a file watch endpoint implementation for the RuleGo framework.
+// It polls a directory and triggers rule chains when files are created, modified or deleted,
+// for example CSV drops, firmware uploads or exported reports.
+//
+// Package filewatch 为 RuleGo 框架提供文件监听端点实现。
+// 它轮询目录，在文件创建、修改或删除时触发规则链，例如 CSV 文件投递、固件上传或导出报表。
+//
+// Polling is used instead of OS file notifications, so the endpoint has no
+// platform-specific dependency and also works on network file systems.
+// 使用轮询而不是操作系统文件通知，因此端点没有平台相关的依赖，也适用于网络文件系统。
+//
+// Routers are keyed by a glob pattern, see filepath.Match. Patterns without a '/' match
+// the file name, other patterns match the path relative to the watched directory.
+// An empty pattern or "*" matches all files.
+// 路由使用 glob 模式作为键，参考 filepath.Match。不包含 '/' 的模式匹配文件名，
+// 其他模式匹配相对于监听目录的路径。空模式或 "*" 匹配所有文件。
+//
+// Example / 示例：
+//
+//	ep, err := endpoint.Registry.New(filewatch.Type, ruleConfig, filewatch.Config{
+//		Dir:          "/data/inbox",
+//		PollInterval: 1000,
+//		ReadContent:  true,
+//		AfterProcess: filewatch.AfterProcessMove,
+//		MoveDir:      "/data/done",
+//	})
+//	router := impl.NewRouter().From("*.csv").To("chain:importCsv").End()
+//	_, err = ep.AddRouter(router)
+//	err = ep.Start()
+package filewatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/runtime"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// Type 组件类型
+const Type = types.EndpointTypePrefix + "fileWatch"
+
+// Endpoint 别名
+type Endpoint = FileWatch
+
+// 文件事件类型，作为消息类型
+const (
+	EventCreate = "CREATE"
+	EventModify = "MODIFY"
+	EventDelete = "DELETE"
+)
+
+// 处理完成后对文件的操作
+const (
+	// AfterProcessNone 不处理
+	AfterProcessNone = ""
+	// AfterProcessDelete 删除文件
+	AfterProcessDelete = "delete"
+	// AfterProcessMove 移动文件到 MoveDir
+	AfterProcessMove = "move"
+)
+
+// 消息元数据键
+const (
+	// KeyEvent 事件类型
+	KeyEvent = "event"
+	// KeyPath 文件绝对路径
+	KeyPath = "path"
+	// KeyRelPath 相对于监听目录的路径，使用/分隔
+	KeyRelPath = "relPath"
+	// KeyName 文件名
+	KeyName = "name"
+	// KeySize 文件大小，单位字节
+	KeySize = "size"
+	// KeyModTime 修改时间，毫秒时间戳
+	KeyModTime = "modTime"
+)
+
+// DefaultPollInterval 默认轮询间隔，单位毫秒
+const DefaultPollInterval = 1000
+
+// Config 端点配置
+type Config struct {
+	// Dir 监听的目录
+	// Dir is the directory to watch
+	Dir string
+	// PollInterval 轮询间隔，单位毫秒，默认1000
+	// PollInterval is the poll interval in milliseconds. Defaults to 1000
+	PollInterval int
+	// Recursive 是否监听子目录
+	// Recursive controls whether subdirectories are watched
+	Recursive bool
+	// Events 触发的事件类型，多个使用逗号分隔：CREATE,MODIFY,DELETE，为空则全部触发
+	// Events is a comma separated list of the events to trigger: CREATE,MODIFY,DELETE. Empty triggers all events
+	Events string
+	// EmitExisting 启动时是否为目录中已存在的文件触发CREATE事件
+	// EmitExisting controls whether files that exist on start trigger a CREATE event
+	EmitExisting bool
+	// WaitStable 是否等待文件大小和修改时间在一次轮询间隔内不再变化后才触发CREATE和MODIFY事件，避免处理写入中的文件
+	// WaitStable delays CREATE and MODIFY events until the size and modification time are unchanged
+	// for one poll interval, so files that are still being written are not processed
+	WaitStable bool
+	// ReadContent 是否读取文件内容作为消息数据，否则消息数据为文件信息的JSON
+	// ReadContent controls whether the file content becomes the message data. Otherwise, the data is the file information as JSON
+	ReadContent bool
+	// DataType 读取文件内容时的数据类型：TEXT、JSON或BINARY，默认TEXT
+	// DataType is the data type of the content when ReadContent is set: TEXT, JSON or BINARY. Defaults to TEXT
+	DataType string
+	// AfterProcess 路由处理完CREATE和MODIFY事件后对文件的操作：空不处理，delete删除，move移动到 MoveDir
+	// AfterProcess is applied to the file after the routers processed a CREATE or MODIFY event:
+	// empty does nothing, delete deletes it, move moves it to MoveDir
+	AfterProcess string
+	// MoveDir 移动的目标目录，位于监听目录下时不会被监听
+	// MoveDir is the target directory of the move. It is not watched if it is inside Dir
+	MoveDir string
+}
+
+// RequestMessage 请求消息
+type RequestMessage struct {
+	event   string
+	path    string
+	relPath string
+	info    fs.FileInfo
+	headers textproto.MIMEHeader
+	body    []byte
+	// dataType 消息数据类型
+	dataType types.DataType
+	msg      *types.RuleMsg
+	err      error
+}
+
+func (r *RequestMessage) Body() []byte {
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+// From 返回相对于监听目录的路径
+func (r *RequestMessage) From() string {
+	return r.relPath
+}
+
+// GetParam 获取文件信息：event、path、relPath、name、size、modTime
+func (r *RequestMessage) GetParam(key string) string {
+	return r.metadata()[key]
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		dataType := r.dataType
+		if dataType == "" {
+			dataType = types.JSON
+		}
+		ruleMsg := types.NewMsg(0, r.event, dataType, types.BuildMetadata(r.metadata()), "")
+		ruleMsg.SetBytes(r.body)
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+// SetStatusCode 不提供设置状态码
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// metadata 文件信息
+func (r *RequestMessage) metadata() map[string]string {
+	metadata := map[string]string{
+		KeyEvent:   r.event,
+		KeyPath:    r.path,
+		KeyRelPath: r.relPath,
+		KeyName:    filepath.Base(r.path),
+	}
+	if r.info != nil {
+		metadata[KeySize] = strconv.FormatInt(r.info.Size(), 10)
+		metadata[KeyModTime] = strconv.FormatInt(r.info.ModTime().UnixMilli(), 10)
+	}
+	return metadata
+}
+
+// ResponseMessage 响应消息
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+	mu      sync.RWMutex
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+// From 不提供获取来源
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.msg
+}
+
+// SetStatusCode 不提供设置状态码
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.body = body
+}
+
+func (r *ResponseMessage) SetError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+func (r *ResponseMessage) GetError() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.err
+}
+
+// globRouter glob模式路由
+type globRouter struct {
+	router  endpoint.Router
+	pattern string
+}
+
+// match 是否匹配文件路径
+func (r *globRouter) match(relPath string) bool {
+	if r.pattern == "" || r.pattern == "*" {
+		return true
+	}
+	name := relPath
+	if !strings.Contains(r.pattern, "/") {
+		name = filepath.Base(filepath.FromSlash(relPath))
+	}
+	matched, _ := filepath.Match(r.pattern, name)
+	return matched
+}
+
+// fileState 轮询时记录的文件状态
+type fileState struct {
+	info fs.FileInfo
+	// pending 等待稳定后触发的事件
+	pending string
+}
+
+// FileWatch 文件监听端点，轮询目录并把文件的创建、修改和删除事件转换成消息交给路由处理
+// FileWatch is a file watch endpoint that polls a directory and turns file create, modify and delete
+// events into messages handled by the routers.
+//
+// 消息格式 - Message format:
+//   - 消息类型为事件类型：CREATE、MODIFY、DELETE - The message type is the event: CREATE, MODIFY, DELETE
+//   - 元数据：event、path、relPath、name、size、modTime - Metadata: event, path, relPath, name, size, modTime
+//   - ReadContent=true时消息数据为文件内容，否则为文件信息的JSON - The data is the file content if ReadContent=true, otherwise the file information as JSON
+//
+// 处理后操作 - Post-processing:
+//   - AfterProcess在所有匹配的路由处理完后执行，需要在规则链处理完后执行时使用路由的Wait() -
+//     AfterProcess runs after all matching routers processed the event. Use Wait() on the router to run it after the rule chain completed
+type FileWatch struct {
+	impl.BaseEndpoint
+	// Config 配置
+	Config Config
+	// RuleConfig rulego配置
+	RuleConfig types.Config
+	// dir 监听目录的绝对路径
+	dir string
+	// moveDir 移动目标目录的绝对路径
+	moveDir string
+	// events 触发的事件类型
+	events map[string]bool
+	// routers 路由映射表
+	routers map[string]*globRouter
+	// files 上一次轮询的文件状态，只在轮询协程中访问
+	files map[string]*fileState
+	// stop 停止轮询
+	stop context.CancelFunc
+	// done 轮询协程退出
+	done chan struct{}
+}
+
+// Type 组件类型
+func (ep *FileWatch) Type() string {
+	return Type
+}
+
+func (ep *FileWatch) New() types.Node {
+	return &FileWatch{
+		Config: Config{
+			Dir:          "./data",
+			PollInterval: DefaultPollInterval,
+		},
+	}
+}
+
+// Init 初始化
+func (ep *FileWatch) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &ep.Config); err != nil {
+		return err
+	}
+	ep.RuleConfig = ruleConfig
+	if ep.Config.Dir == "" {
+		return errors.New("dir can not empty")
+	}
+	if ep.Config.PollInterval <= 0 {
+		ep.Config.PollInterval = DefaultPollInterval
+	}
+	dir, err := filepath.Abs(ep.Config.Dir)
+	if err != nil {
+		return err
+	}
+	ep.dir = dir
+	switch ep.Config.AfterProcess {
+	case AfterProcessNone, AfterProcessDelete:
+	case AfterProcessMove:
+		if ep.Config.MoveDir == "" {
+			return errors.New("moveDir can not empty")
+		}
+		if ep.moveDir, err = filepath.Abs(ep.Config.MoveDir); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported afterProcess: %s", ep.Config.AfterProcess)
+	}
+	ep.events = make(map[string]bool)
+	for _, item := range strings.Split(ep.Config.Events, ",") {
+		event := strings.ToUpper(strings.TrimSpace(item))
+		switch event {
+		case "":
+		case EventCreate, EventModify, EventDelete:
+			ep.events[event] = true
+		default:
+			return fmt.Errorf("unsupported event: %s", item)
+		}
+	}
+	if len(ep.events) == 0 {
+		ep.events[EventCreate] = true
+		ep.events[EventModify] = true
+		ep.events[EventDelete] = true
+	}
+	return nil
+}
+
+// Destroy 销毁
+func (ep *FileWatch) Destroy() {
+	_ = ep.Close()
+	ep.BaseEndpoint.Destroy()
+}
+
+// Close 停止轮询
+func (ep *FileWatch) Close() error {
+	ep.Lock()
+	stop, done := ep.stop, ep.done
+	ep.stop, ep.done = nil, nil
+	ep.Unlock()
+	if stop != nil {
+		stop()
+		<-done
+	}
+	return nil
+}
+
+func (ep *FileWatch) Id() string {
+	return ep.dir
+}
+
+// AddRouter 添加路由，from为glob模式
+func (ep *FileWatch) AddRouter(router endpoint.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router can not nil")
+	}
+	var pattern string
+	if from := router.GetFrom(); from != nil {
+		pattern = filepath.ToSlash(from.ToString())
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return "", err
+	}
+	ep.CheckAndSetRouterId(router)
+	ep.Lock()
+	defer ep.Unlock()
+	if ep.routers == nil {
+		ep.routers = make(map[string]*globRouter)
+	}
+	if _, ok := ep.routers[router.GetId()]; ok {
+		return router.GetId(), fmt.Errorf("duplicate router %s", pattern)
+	}
+	ep.routers[router.GetId()] = &globRouter{router: router, pattern: pattern}
+	return router.GetId(), nil
+}
+
+func (ep *FileWatch) RemoveRouter(routerId string, params ...interface{}) error {
+	ep.Lock()
+	defer ep.Unlock()
+	if ep.routers != nil {
+		if _, ok := ep.routers[routerId]; ok {
+			delete(ep.routers, routerId)
+		} else {
+			return fmt.Errorf("router: %s not found", routerId)
+		}
+	}
+	return nil
+}
+
+// Start 开始轮询监听目录
+func (ep *FileWatch) Start() error {
+	if ep.dir == "" {
+		return errors.New("file watch endpoint has not been initialized yet")
+	}
+	if info, err := os.Stat(ep.dir); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", ep.dir)
+	}
+	ep.Lock()
+	if ep.stop != nil {
+		ep.Unlock()
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	ep.stop, ep.done = cancel, done
+	ep.Unlock()
+
+	// 首次扫描只记录已存在的文件
+	ep.files = make(map[string]*fileState)
+	if !ep.Config.EmitExisting {
+		files, err := ep.scan()
+		if err != nil {
+			ep.Printf("file watch endpoint scan dir=%s err: %v", ep.dir, err)
+		}
+		for path, info := range files {
+			ep.files[path] = &fileState{info: info}
+		}
+	}
+	go ep.run(ctx, done)
+	ep.Printf("started file watch endpoint on %s", ep.dir)
+	return nil
+}
+
+// Printf 打印日志
+func (ep *FileWatch) Printf(format string, v ...interface{}) {
+	if ep.RuleConfig.Logger != nil {
+		ep.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// run 轮询协程
+func (ep *FileWatch) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(time.Duration(ep.Config.PollInterval) * time.Millisecond)
+	defer ticker.Stop()
+	ep.poll(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ep.poll(ctx)
+		}
+	}
+}
+
+// poll 扫描目录并与上一次的状态比较，触发文件事件
+func (ep *FileWatch) poll(ctx context.Context) {
+	files, err := ep.scan()
+	if err != nil {
+		ep.Printf("file watch endpoint scan dir=%s err: %v", ep.dir, err)
+		return
+	}
+	// 按路径排序，保证事件顺序稳定
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			return
+		}
+		info := files[path]
+		state, ok := ep.files[path]
+		if !ok {
+			state = &fileState{info: info, pending: EventCreate}
+			ep.files[path] = state
+			if ep.Config.WaitStable {
+				continue
+			}
+		} else if changed(state.info, info) {
+			if state.pending == "" {
+				state.pending = EventModify
+			}
+			state.info = info
+			if ep.Config.WaitStable {
+				continue
+			}
+		}
+		if state.pending != "" {
+			event := state.pending
+			state.pending = ""
+			if ep.handle(ctx, event, path, info) {
+				delete(ep.files, path)
+			}
+		}
+	}
+	for path, state := range ep.files {
+		if _, ok := files[path]; !ok {
+			delete(ep.files, path)
+			// 还未触发CREATE事件的文件不触发DELETE事件
+			if state.pending != EventCreate {
+				ep.handle(ctx, EventDelete, path, state.info)
+			}
+		}
+	}
+}
+
+// changed 文件大小或修改时间是否变化
+func changed(old, new fs.FileInfo) bool {
+	return old.Size() != new.Size() || !old.ModTime().Equal(new.ModTime())
+}
+
+// scan 扫描目录下的文件
+func (ep *FileWatch) scan() (map[string]fs.FileInfo, error) {
+	files := make(map[string]fs.FileInfo)
+	err := filepath.WalkDir(ep.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 扫描过程中被删除的文件忽略
+			if errors.Is(err, fs.ErrNotExist) && path != ep.dir {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if path == ep.dir {
+				return nil
+			}
+			if !ep.Config.Recursive || path == ep.moveDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		files[path] = info
+		return nil
+	})
+	return files, err
+}
+
+// handle 把文件事件交给匹配的路由处理，返回文件是否已被移动或删除
+func (ep *FileWatch) handle(ctx context.Context, event, path string, info fs.FileInfo) (removed bool) {
+	if !ep.events[event] {
+		return false
+	}
+	relPath := path
+	if rel, err := filepath.Rel(ep.dir, path); err == nil {
+		relPath = filepath.ToSlash(rel)
+	}
+	ep.RLock()
+	var routers []*globRouter
+	for _, item := range ep.routers {
+		if item.match(relPath) {
+			routers = append(routers, item)
+		}
+	}
+	ep.RUnlock()
+	if len(routers) == 0 {
+		return false
+	}
+
+	var body []byte
+	var dataType types.DataType
+	if ep.Config.ReadContent && event != EventDelete {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			ep.Printf("file watch endpoint read file=%s err: %v", path, err)
+			return false
+		}
+		body = content
+		dataType = types.DataType(strings.ToUpper(ep.Config.DataType))
+		if dataType == "" {
+			dataType = types.TEXT
+		}
+	}
+	for _, item := range routers {
+		request := &RequestMessage{
+			event:    event,
+			path:     path,
+			relPath:  relPath,
+			info:     info,
+			body:     body,
+			dataType: dataType,
+		}
+		if !ep.Config.ReadContent || event == EventDelete {
+			request.body = []byte(str.ToString(request.metadata()))
+		}
+		ep.process(ctx, item.router, request)
+	}
+	if event == EventDelete {
+		return false
+	}
+	return ep.afterProcess(path, relPath)
+}
+
+// process 执行路由，捕获异常
+func (ep *FileWatch) process(ctx context.Context, router endpoint.Router, request *RequestMessage) {
+	defer func() {
+		//捕捉异常
+		if e := recover(); e != nil {
+			ep.Printf("file watch endpoint handler err :\n%v", runtime.Stack())
+		}
+	}()
+	exchange := &endpoint.Exchange{
+		In:  request,
+		Out: &ResponseMessage{},
+	}
+	ep.DoProcess(ctx, router, exchange)
+}
+
+// afterProcess 处理完后删除或移动文件，返回文件是否已被移动或删除
+func (ep *FileWatch) afterProcess(path, relPath string) bool {
+	switch ep.Config.AfterProcess {
+	case AfterProcessDelete:
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			ep.Printf("file watch endpoint delete file=%s err: %v", path, err)
+			return false
+		}
+		return true
+	case AfterProcessMove:
+		target := filepath.Join(ep.moveDir, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			ep.Printf("file watch endpoint move file=%s err: %v", path, err)
+			return false
+		}
+		if err := os.Rename(path, target); err != nil {
+			ep.Printf("file watch endpoint move file=%s err: %v", path, err)
+			return false
+		}
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,252 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filewatch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/json"
+)
+
+// 测试请求/响应消息
+func TestMessage(t *testing.T) {
+	t.Run("Request", func(t *testing.T) {
+		var request = &RequestMessage{}
+		test.EndpointMessage(t, request)
+	})
+	t.Run("Response", func(t *testing.T) {
+		var response = &ResponseMessage{}
+		test.EndpointMessage(t, response)
+	})
+}
+
+func TestInit(t *testing.T) {
+	config := types.NewConfig()
+	assert.NotNil(t, (&Endpoint{}).Init(config, types.Configuration{"dir": ""}))
+	assert.Equal(t, "unsupported event: rename", (&Endpoint{}).Init(config, types.Configuration{"dir": ".", "events": "create,rename"}).Error())
+	assert.Equal(t, "moveDir can not empty", (&Endpoint{}).Init(config, types.Configuration{"dir": ".", "afterProcess": "move"}).Error())
+	assert.Equal(t, "unsupported afterProcess: copy", (&Endpoint{}).Init(config, types.Configuration{"dir": ".", "afterProcess": "copy"}).Error())
+
+	var ep = &Endpoint{}
+	assert.Nil(t, ep.Init(config, types.Configuration{"dir": "."}))
+	assert.Equal(t, DefaultPollInterval, ep.Config.PollInterval)
+	assert.Equal(t, 3, len(ep.events))
+
+	router := impl.NewRouter().From("*.csv").End()
+	routerId, err := ep.AddRouter(router)
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("[").End())
+	assert.NotNil(t, err)
+	assert.Nil(t, ep.RemoveRouter(routerId))
+	assert.Equal(t, "router: "+routerId+" not found", ep.RemoveRouter(routerId).Error())
+
+	ep = &Endpoint{}
+	assert.Equal(t, "file watch endpoint has not been initialized yet", ep.Start().Error())
+}
+
+func TestGlobRouter(t *testing.T) {
+	assert.True(t, (&globRouter{}).match("a/b.csv"))
+	assert.True(t, (&globRouter{pattern: "*"}).match("a/b.csv"))
+	assert.True(t, (&globRouter{pattern: "*.csv"}).match("b.csv"))
+	assert.True(t, (&globRouter{pattern: "*.csv"}).match("a/b.csv"))
+	assert.False(t, (&globRouter{pattern: "*.csv"}).match("a/b.txt"))
+	assert.True(t, (&globRouter{pattern: "a/*.csv"}).match("a/b.csv"))
+	assert.False(t, (&globRouter{pattern: "a/*.csv"}).match("b.csv"))
+	assert.False(t, (&globRouter{pattern: "a/*.csv"}).match("c/a/b.csv"))
+}
+
+// newTestEndpoint 创建并启动端点，把路由收到的消息发送到通道
+func newTestEndpoint(t *testing.T, configuration types.Configuration, patterns ...string) (*Endpoint, chan *types.RuleMsg) {
+	var ep = &Endpoint{}
+	configuration["pollInterval"] = 20
+	assert.Nil(t, ep.Init(types.NewConfig(), configuration))
+	msgCh := make(chan *types.RuleMsg, 100)
+	for _, pattern := range patterns {
+		router := impl.NewRouter().From(pattern).Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			msgCh <- exchange.In.GetMsg()
+			return true
+		}).End()
+		_, err := ep.AddRouter(router)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, ep.Start())
+	t.Cleanup(ep.Destroy)
+	return ep, msgCh
+}
+
+// writeFile 先写入临时文件再重命名，避免轮询读到写入中的文件
+func writeFile(t *testing.T, path, content string) {
+	tmp := filepath.Join(t.TempDir(), filepath.Base(path))
+	assert.Nil(t, os.WriteFile(tmp, []byte(content), 0o644))
+	assert.Nil(t, os.Rename(tmp, path))
+}
+
+// waitMsg 等待下一条消息
+func waitMsg(t *testing.T, msgCh chan *types.RuleMsg) *types.RuleMsg {
+	select {
+	case msg := <-msgCh:
+		return msg
+	case <-time.After(time.Second * 3):
+		t.Fatal("wait file event timeout")
+		return nil
+	}
+}
+
+// assertNoMsg 断言一段时间内没有消息
+func assertNoMsg(t *testing.T, msgCh chan *types.RuleMsg) {
+	select {
+	case msg := <-msgCh:
+		t.Fatalf("unexpected file event %s %s", msg.Type, msg.Metadata.GetValue(KeyRelPath))
+	case <-time.After(time.Millisecond * 150):
+	}
+}
+
+func TestFileWatchEndpoint(t *testing.T) {
+	t.Run("Events", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "existing.csv"), "0")
+		_, msgCh := newTestEndpoint(t, types.Configuration{"dir": dir}, "*.csv")
+
+		path := filepath.Join(dir, "a.csv")
+		writeFile(t, path, "1,2")
+		msg := waitMsg(t, msgCh)
+		assert.Equal(t, EventCreate, msg.Type)
+		assert.Equal(t, path, msg.Metadata.GetValue(KeyPath))
+		assert.Equal(t, "a.csv", msg.Metadata.GetValue(KeyRelPath))
+		assert.Equal(t, "a.csv", msg.Metadata.GetValue(KeyName))
+		assert.Equal(t, "3", msg.Metadata.GetValue(KeySize))
+		assert.Equal(t, types.JSON, msg.DataType)
+		var info map[string]string
+		assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &info))
+		assert.Equal(t, "a.csv", info[KeyRelPath])
+
+		// 不匹配的文件
+		writeFile(t, filepath.Join(dir, "b.txt"), "x")
+		assertNoMsg(t, msgCh)
+
+		writeFile(t, path, "1,2,3")
+		msg = waitMsg(t, msgCh)
+		assert.Equal(t, EventModify, msg.Type)
+		assert.Equal(t, "5", msg.Metadata.GetValue(KeySize))
+
+		assert.Nil(t, os.Remove(path))
+		msg = waitMsg(t, msgCh)
+		assert.Equal(t, EventDelete, msg.Type)
+		assert.Equal(t, "a.csv", msg.Metadata.GetValue(KeyRelPath))
+		assertNoMsg(t, msgCh)
+	})
+
+	t.Run("EventsFilterAndExisting", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "existing.csv"), "0")
+		_, msgCh := newTestEndpoint(t, types.Configuration{
+			"dir":          dir,
+			"events":       "CREATE",
+			"emitExisting": true,
+		}, "")
+		msg := waitMsg(t, msgCh)
+		assert.Equal(t, EventCreate, msg.Type)
+		assert.Equal(t, "existing.csv", msg.Metadata.GetValue(KeyRelPath))
+
+		assert.Nil(t, os.Remove(filepath.Join(dir, "existing.csv")))
+		assertNoMsg(t, msgCh)
+	})
+
+	t.Run("Recursive", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.Nil(t, os.MkdirAll(filepath.Join(dir, "sub"), 0o755))
+		_, msgCh := newTestEndpoint(t, types.Configuration{
+			"dir":       dir,
+			"recursive": true,
+		}, "sub/*.csv")
+		writeFile(t, filepath.Join(dir, "a.csv"), "1")
+		writeFile(t, filepath.Join(dir, "sub", "b.csv"), "1")
+		msg := waitMsg(t, msgCh)
+		assert.Equal(t, "sub/b.csv", msg.Metadata.GetValue(KeyRelPath))
+		assertNoMsg(t, msgCh)
+	})
+
+	t.Run("ReadContentAndDelete", func(t *testing.T) {
+		dir := t.TempDir()
+		_, msgCh := newTestEndpoint(t, types.Configuration{
+			"dir":          dir,
+			"readContent":  true,
+			"afterProcess": AfterProcessDelete,
+		}, "*.json")
+		path := filepath.Join(dir, "a.json")
+		writeFile(t, path, `{"temperature":41}`)
+		msg := waitMsg(t, msgCh)
+		assert.Equal(t, types.TEXT, msg.DataType)
+		assert.Equal(t, `{"temperature":41}`, msg.GetData())
+		// 处理完后删除文件，不触发DELETE事件
+		assertNoMsg(t, msgCh)
+		_, err := os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		dir := t.TempDir()
+		moveDir := filepath.Join(dir, "done")
+		_, msgCh := newTestEndpoint(t, types.Configuration{
+			"dir":          dir,
+			"recursive":    true,
+			"readContent":  true,
+			"dataType":     "json",
+			"afterProcess": AfterProcessMove,
+			"moveDir":      moveDir,
+		}, "*.json")
+		writeFile(t, filepath.Join(dir, "a.json"), `{"a":1}`)
+		msg := waitMsg(t, msgCh)
+		assert.Equal(t, types.JSON, msg.DataType)
+		// 移动目录不被监听
+		assertNoMsg(t, msgCh)
+		content, err := os.ReadFile(filepath.Join(moveDir, "a.json"))
+		assert.Nil(t, err)
+		assert.Equal(t, `{"a":1}`, string(content))
+	})
+
+	t.Run("WaitStable", func(t *testing.T) {
+		dir := t.TempDir()
+		_, msgCh := newTestEndpoint(t, types.Configuration{
+			"dir":        dir,
+			"waitStable": true,
+		}, "*")
+		path := filepath.Join(dir, "a.bin")
+		writeFile(t, path, "1")
+		msg := waitMsg(t, msgCh)
+		assert.Equal(t, EventCreate, msg.Type)
+		writeFile(t, path, "12")
+		msg = waitMsg(t, msgCh)
+		assert.Equal(t, EventModify, msg.Type)
+		assert.Equal(t, "2", msg.Metadata.GetValue(KeySize))
+	})
+
+	t.Run("Registry", func(t *testing.T) {
+		var ep = &Endpoint{}
+		assert.Equal(t, Type, ep.Type())
+		node := ep.New().(*Endpoint)
+		assert.Equal(t, DefaultPollInterval, node.Config.PollInterval)
+	})
+}
@@ -0,0 +1,112 @@
+//go:build with_grpc
+
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpc
+
+import (
+	"fmt"
+
+	"github.com/rulego/rulego/utils/json"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// CodecName JSON编解码器名称，客户端使用 grpc.ForceCodec(JSONCodec{}) 发送JSON请求
+const CodecName = "json"
+
+// JSONCodec is a gRPC codec for the content subtype "json" (application/grpc+json).
+// Raw bytes are sent as is, so any JSON payload can be exchanged without generated code.
+// Protobuf messages are converted with protojson and other values with encoding/json.
+// It is not registered globally, clients pass it per call or per connection with grpc.ForceCodec(JSONCodec{}).
+//
+// JSONCodec 内容子类型为 "json"（application/grpc+json）的gRPC编解码器。
+// 原始字节直接发送，因此无需生成代码即可交换任意JSON负荷。protobuf消息使用protojson转换，其他值使用encoding/json转换。
+// 该编解码器不会全局注册，客户端通过 grpc.ForceCodec(JSONCodec{}) 按调用或按连接使用。
+type JSONCodec struct {
+}
+
+// Marshal 编码
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	switch value := v.(type) {
+	case []byte:
+		return value, nil
+	case *[]byte:
+		return *value, nil
+	case proto.Message:
+		return protojson.Marshal(value)
+	default:
+		return json.Marshal(v)
+	}
+}
+
+// Unmarshal 解码
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	switch value := v.(type) {
+	case *[]byte:
+		*value = append((*value)[:0], data...)
+		return nil
+	case proto.Message:
+		return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, value)
+	case nil:
+		return fmt.Errorf("can not unmarshal into nil")
+	default:
+		return json.Unmarshal(data, v)
+	}
+}
+
+// Name 编解码器名称
+func (JSONCodec) Name() string {
+	return CodecName
+}
+
+// serverCodec 端点服务使用的编解码器，只在 grpc.NewServer 时通过 grpc.ForceServerCodec 设置，
+// 不影响进程内其他gRPC服务和客户端。JSON负荷以原始字节收发，protobuf消息使用proto编解码。
+type serverCodec struct {
+}
+
+// Marshal 编码
+func (serverCodec) Marshal(v interface{}) ([]byte, error) {
+	switch value := v.(type) {
+	case []byte:
+		return value, nil
+	case *[]byte:
+		return *value, nil
+	case proto.Message:
+		return proto.Marshal(value)
+	default:
+		return nil, fmt.Errorf("unsupported message type: %T", v)
+	}
+}
+
+// Unmarshal 解码
+func (serverCodec) Unmarshal(data []byte, v interface{}) error {
+	switch value := v.(type) {
+	case *[]byte:
+		*value = append((*value)[:0], data...)
+		return nil
+	case proto.Message:
+		return proto.Unmarshal(data, value)
+	default:
+		return fmt.Errorf("unsupported message type: %T", v)
+	}
+}
+
+// Name 编解码器名称
+func (serverCodec) Name() string {
+	return "proto"
+}
@@ -0,0 +1,647 @@
+//go:build with_grpc
+
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package grpc provides a gRPC server endpoint implementation for the RuleGo framework.
+// It exposes rule chains as unary RPC methods, the router "from" is the full method name,
+// for example /helloworld.Greeter/SayHello. A trailing "/*" matches all methods of a service.
+//
+// Package grpc 为 RuleGo 框架提供 gRPC 服务端点实现。
+// 它把规则链暴露为一元RPC方法，路由的 from 为方法全路径，例如 /helloworld.Greeter/SayHello。
+// 以 "/*" 结尾则匹配服务的所有方法。
+//
+// Payloads / 负荷：
+//
+//   - JSON over gRPC: clients use the content subtype "json" (application/grpc+json), e.g. grpc.ForceCodec(JSONCodec{}).
+//     Any method name can be called with a JSON body and no descriptor or generated code is needed.
+//     JSON over gRPC：客户端使用内容子类型 "json"（application/grpc+json），例如 grpc.ForceCodec(JSONCodec{})。
+//     任意方法名都可以使用JSON负荷调用，不需要描述或生成代码。
+//   - Protobuf: standard clients are supported for methods defined in the configured .proto file or descriptor set.
+//     The request is converted to JSON and the chain output is converted back to the response message.
+//     Protobuf：支持调用配置的.proto文件或描述集中定义的方法的标准客户端。请求转换为JSON，规则链输出转换回响应消息。
+//
+// Request gRPC metadata is put into the message metadata. With To(...).Wait() the chain output
+// is returned as the RPC response, otherwise an empty response is returned immediately.
+// 请求的gRPC元数据放入消息元数据。使用 To(...).Wait() 时规则链输出作为RPC响应返回，否则立即返回空响应。
+//
+// Example / 示例：
+//
+//	ep, err := endpoint.Registry.New(grpc.Type, ruleConfig, grpc.Config{
+//		Server:      ":9090",
+//		ProtoFile:   "greeter.proto",
+//		ImportPaths: []string{"./proto"},
+//	})
+//	router := impl.NewRouter().From("/helloworld.Greeter/SayHello").To("chain:greeter").Wait().End()
+//	_, err = ep.AddRouter(router)
+//	err = ep.Start()
+//
+// The endpoint is only compiled with the with_grpc build tag, which adds about 7M to the binary:
+// 该端点只在使用 with_grpc 编译标签时编译，编译后文件大约增加7M：
+//
+//	go build -tags with_grpc .
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/protofile"
+	"github.com/rulego/rulego/utils/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Type 组件类型
+const Type = types.EndpointTypePrefix + "grpc"
+
+// Endpoint 别名
+type Endpoint = Grpc
+
+// shutdownTimeout 关闭服务时等待正在处理的请求结束的时间
+const shutdownTimeout = 5 * time.Second
+
+// Config 端点配置
+type Config struct {
+	// Server 监听地址，例如：:9090
+	// Server is the listen address, for example :9090
+	Server string
+	// ProtoFile .proto文件路径，用于支持标准protobuf客户端，为空则只支持JSON over gRPC
+	// ProtoFile is the .proto file used to support standard protobuf clients. Only JSON over gRPC is supported if it is empty
+	ProtoFile string
+	// ImportPaths 解析 ProtoFile 及其import的搜索目录
+	// ImportPaths are the search paths of ProtoFile and its imports
+	ImportPaths []string
+	// DescriptorSet 描述集文件路径，和 ProtoFile 二选一
+	// DescriptorSet is the descriptor set file, it can be used instead of ProtoFile
+	DescriptorSet string
+	// CertFile TLS证书文件，和 CertKeyFile 同时设置时使用TLS
+	// CertFile is the TLS certificate file. TLS is enabled when both CertFile and CertKeyFile are set
+	CertFile string
+	// CertKeyFile TLS私钥文件
+	// CertKeyFile is the TLS private key file
+	CertKeyFile string
+}
+
+// RequestMessage 请求消息
+type RequestMessage struct {
+	// method 方法全路径
+	method   string
+	metadata metadata.MD
+	headers  textproto.MIMEHeader
+	body     []byte
+	msg      *types.RuleMsg
+	err      error
+}
+
+func (r *RequestMessage) Body() []byte {
+	return r.body
+}
+
+// Headers 返回请求gRPC元数据
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(textproto.MIMEHeader)
+		for key, values := range r.metadata {
+			for _, value := range values {
+				r.headers.Add(key, value)
+			}
+		}
+	}
+	return r.headers
+}
+
+// From 返回方法全路径
+func (r *RequestMessage) From() string {
+	return r.method
+}
+
+// GetParam 获取请求gRPC元数据
+func (r *RequestMessage) GetParam(key string) string {
+	if values := r.metadata.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		md := types.NewMetadata()
+		for key, values := range r.metadata {
+			// 跳过 :authority 等伪头
+			if strings.HasPrefix(key, ":") || len(values) == 0 {
+				continue
+			}
+			md.PutValue(key, strings.Join(values, ","))
+		}
+		ruleMsg := types.NewMsg(0, r.method, types.JSON, md, "")
+		ruleMsg.SetBytes(r.body)
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+// SetStatusCode 不提供设置状态码
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage 响应消息
+type ResponseMessage struct {
+	method  string
+	headers textproto.MIMEHeader
+	body    []byte
+	// statusCode 状态码，可以是gRPC状态码或者HTTP状态码，参考 toGrpcCode
+	statusCode int
+	msg        *types.RuleMsg
+	err        error
+	mu         sync.RWMutex
+}
+
+func (r *ResponseMessage) Body() []byte {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.body
+}
+
+// Headers 响应gRPC元数据
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+// From 返回方法全路径
+func (r *ResponseMessage) From() string {
+	return r.method
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.msg
+}
+
+// SetStatusCode 设置状态码，1~16 作为gRPC状态码（参考 google.golang.org/grpc/codes），
+// 其他值作为HTTP状态码转换成对应的gRPC状态码，参考 toGrpcCode
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statusCode = statusCode
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.body = body
+}
+
+func (r *ResponseMessage) SetError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+func (r *ResponseMessage) GetError() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.err
+}
+
+// Grpc gRPC服务端点，把一元RPC调用交给路由处理
+// Grpc is a gRPC server endpoint that hands unary RPC calls to the routers.
+//
+// 响应 - Response:
+//   - 规则链出错时返回 Internal 状态，错误为gRPC状态时原样返回 - A chain error returns the Internal status, gRPC status errors are returned as is
+//   - 调用 exchange.Out.SetStatusCode 设置错误状态码时返回对应的gRPC状态，响应内容作为错误信息，HTTP状态码按 toGrpcCode 转换 -
+//     An error code set by exchange.Out.SetStatusCode is returned as the gRPC status, the body becomes the error message,
+//     HTTP status codes are mapped with toGrpcCode
+//   - 否则响应内容为 exchange.Out.SetBody 设置的内容或者规则链输出的消息负荷 -
+//     Otherwise, the response is the body set by exchange.Out.SetBody or the data of the chain output
+//   - exchange.Out.Headers() 作为响应gRPC元数据发送 - exchange.Out.Headers() are sent as the response gRPC metadata
+type Grpc struct {
+	impl.BaseEndpoint
+	// Config 配置
+	Config Config
+	// RuleConfig rulego配置
+	RuleConfig types.Config
+	// methods 描述中定义的方法，key为方法全路径
+	methods map[string]protoreflect.MethodDescriptor
+	// routers 路由映射表，key为路由from
+	routers  map[string]endpoint.Router
+	server   *grpc.Server
+	listener net.Listener
+}
+
+// Type 组件类型
+func (ep *Grpc) Type() string {
+	return Type
+}
+
+func (ep *Grpc) New() types.Node {
+	return &Grpc{
+		Config: Config{
+			Server: ":9090",
+		},
+	}
+}
+
+// Init 初始化
+func (ep *Grpc) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &ep.Config); err != nil {
+		return err
+	}
+	ep.RuleConfig = ruleConfig
+	if ep.Config.Server == "" {
+		return errors.New("server can not empty")
+	}
+	ep.methods = make(map[string]protoreflect.MethodDescriptor)
+	if ep.Config.ProtoFile != "" || ep.Config.DescriptorSet != "" {
+		files, err := protofile.Load(ep.Config.ProtoFile, ep.Config.ImportPaths, ep.Config.DescriptorSet)
+		if err != nil {
+			return err
+		}
+		files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+			services := file.Services()
+			for i := 0; i < services.Len(); i++ {
+				methods := services.Get(i).Methods()
+				for j := 0; j < methods.Len(); j++ {
+					method := methods.Get(j)
+					ep.methods[protofile.FullMethodName(method)] = method
+				}
+			}
+			return true
+		})
+	}
+	return nil
+}
+
+// Destroy 销毁
+func (ep *Grpc) Destroy() {
+	_ = ep.Close()
+	ep.BaseEndpoint.Destroy()
+}
+
+// Close 停止服务，等待正在处理的请求结束，超时则强制关闭
+func (ep *Grpc) Close() error {
+	ep.Lock()
+	server := ep.server
+	ep.server, ep.listener = nil, nil
+	ep.Unlock()
+	if server == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		server.Stop()
+	}
+	return nil
+}
+
+func (ep *Grpc) Id() string {
+	return ep.Config.Server
+}
+
+// AddRouter 添加路由，from为方法全路径，例如：/helloworld.Greeter/SayHello 或者 /helloworld.Greeter/*
+func (ep *Grpc) AddRouter(router endpoint.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router can not nil")
+	}
+	var from string
+	if router.GetFrom() != nil {
+		from = normalizeMethod(router.GetFrom().ToString())
+	}
+	if strings.Count(from, "/") != 2 || strings.HasSuffix(from, "/") || strings.HasPrefix(from, "//") {
+		return "", fmt.Errorf("invalid method: %s, it should be /package.Service/Method", from)
+	}
+	ep.CheckAndSetRouterId(router)
+	ep.Lock()
+	defer ep.Unlock()
+	if ep.routers == nil {
+		ep.routers = make(map[string]endpoint.Router)
+	}
+	if _, ok := ep.routers[from]; ok {
+		return router.GetId(), fmt.Errorf("duplicate router %s", from)
+	}
+	ep.routers[from] = router
+	return router.GetId(), nil
+}
+
+func (ep *Grpc) RemoveRouter(routerId string, params ...interface{}) error {
+	ep.Lock()
+	defer ep.Unlock()
+	for from, router := range ep.routers {
+		if router.GetId() == routerId {
+			delete(ep.routers, from)
+			return nil
+		}
+	}
+	return fmt.Errorf("router: %s not found", routerId)
+}
+
+// Start 启动服务
+func (ep *Grpc) Start() error {
+	if ep.methods == nil {
+		return errors.New("grpc endpoint has not been initialized yet")
+	}
+	ep.Lock()
+	defer ep.Unlock()
+	if ep.server != nil {
+		return nil
+	}
+	opts := []grpc.ServerOption{grpc.UnknownServiceHandler(ep.handler), grpc.ForceServerCodec(serverCodec{})}
+	if ep.Config.CertFile != "" && ep.Config.CertKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(ep.Config.CertFile, ep.Config.CertKeyFile)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}})))
+	}
+	listener, err := net.Listen("tcp", ep.Config.Server)
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer(opts...)
+	// 允许在启动前注册其他服务，例如健康检查
+	if ep.OnEvent != nil {
+		ep.OnEvent(endpoint.EventInitServer, server)
+	}
+	ep.server, ep.listener = server, listener
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			ep.Printf("grpc endpoint serve err: %v", err)
+		}
+	}()
+	ep.Printf("started grpc server on %s", ep.Config.Server)
+	return nil
+}
+
+// Addr 返回监听地址，服务未启动时返回nil
+func (ep *Grpc) Addr() net.Addr {
+	ep.RLock()
+	defer ep.RUnlock()
+	if ep.listener == nil {
+		return nil
+	}
+	return ep.listener.Addr()
+}
+
+// Printf 打印日志
+func (ep *Grpc) Printf(format string, v ...interface{}) {
+	if ep.RuleConfig.Logger != nil {
+		ep.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// matchRouter 查找方法对应的路由，优先精确匹配
+func (ep *Grpc) matchRouter(method string) endpoint.Router {
+	ep.RLock()
+	defer ep.RUnlock()
+	if router, ok := ep.routers[method]; ok {
+		return router
+	}
+	if index := strings.LastIndex(method, "/"); index > 0 {
+		return ep.routers[method[:index]+"/*"]
+	}
+	return nil
+}
+
+// handler 处理所有方法的调用
+func (ep *Grpc) handler(srv interface{}, stream grpc.ServerStream) (err error) {
+	defer func() {
+		//捕捉异常
+		if e := recover(); e != nil {
+			ep.Printf("grpc endpoint handler err :\n%v", runtime.Stack())
+			err = status.Errorf(codes.Internal, "%v", e)
+		}
+	}()
+	method, ok := grpc.MethodFromServerStream(stream)
+	if !ok {
+		return status.Error(codes.Internal, "method not found in stream")
+	}
+	router := ep.matchRouter(method)
+	if router == nil || router.IsDisable() {
+		return status.Errorf(codes.Unimplemented, "method %s not implemented", method)
+	}
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	isJSON := contentSubtype(md) == CodecName
+	descriptor := ep.methods[method]
+
+	var body []byte
+	if isJSON {
+		if err := stream.RecvMsg(&body); err != nil {
+			return err
+		}
+	} else {
+		if descriptor == nil {
+			return status.Errorf(codes.Unimplemented, "method %s is not defined in the proto descriptors, use the json content subtype", method)
+		}
+		if protofile.IsStreaming(descriptor) {
+			return status.Errorf(codes.Unimplemented, "streaming method %s is not supported", method)
+		}
+		request := dynamicpb.NewMessage(descriptor.Input())
+		if err := stream.RecvMsg(request); err != nil {
+			return err
+		}
+		if body, err = protojson.Marshal(request); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	exchange := &endpoint.Exchange{
+		In: &RequestMessage{
+			method:   method,
+			metadata: md,
+			body:     body,
+		},
+		Out: &ResponseMessage{
+			method: method,
+		},
+	}
+	ctx := stream.Context()
+	if to := router.GetFrom().GetTo(); to == nil || !to.IsWait() {
+		//异步不能使用请求的context，否则后续执行会取消
+		ctx = context.Background()
+	}
+	ep.DoProcess(ctx, router, exchange)
+
+	out := exchange.Out.(*ResponseMessage)
+	if err := ep.sendHeader(stream, out); err != nil {
+		return err
+	}
+	if err := out.GetError(); err != nil {
+		if s, ok := status.FromError(err); ok {
+			return s.Err()
+		}
+		return status.Error(codes.Internal, err.Error())
+	}
+	responseBody := out.Body()
+	if responseBody == nil {
+		if msg := out.GetMsg(); msg != nil {
+			responseBody = msg.GetBytes()
+		}
+	}
+	out.mu.RLock()
+	statusCode := out.statusCode
+	out.mu.RUnlock()
+	if code := toGrpcCode(statusCode); code != codes.OK {
+		return status.Error(code, string(responseBody))
+	}
+	if isJSON {
+		if len(responseBody) == 0 {
+			responseBody = []byte("{}")
+		}
+		return stream.SendMsg(responseBody)
+	}
+	response := dynamicpb.NewMessage(descriptor.Output())
+	if len(responseBody) > 0 {
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(responseBody, response); err != nil {
+			return status.Errorf(codes.Internal, "convert response err: %v", err)
+		}
+	}
+	return stream.SendMsg(response)
+}
+
+// sendHeader 发送响应gRPC元数据
+func (ep *Grpc) sendHeader(stream grpc.ServerStream, out *ResponseMessage) error {
+	out.mu.RLock()
+	headers := out.headers
+	out.mu.RUnlock()
+	if len(headers) == 0 {
+		return nil
+	}
+	md := metadata.MD{}
+	for key, values := range headers {
+		md.Append(strings.ToLower(key), values...)
+	}
+	return stream.SetHeader(md)
+}
+
+// normalizeMethod 方法全路径统一以/开头
+func normalizeMethod(method string) string {
+	method = strings.TrimSpace(method)
+	if !strings.HasPrefix(method, "/") {
+		method = "/" + method
+	}
+	return method
+}
+
+// toGrpcCode 把 SetStatusCode 设置的状态码转换成gRPC状态码
+// 0、2xx和3xx 返回OK；1~16 本身就是gRPC状态码，原样返回；其他值作为HTTP状态码转换
+func toGrpcCode(statusCode int) codes.Code {
+	switch {
+	case statusCode == 0:
+		return codes.OK
+	case statusCode > 0 && statusCode <= int(codes.Unauthenticated):
+		return codes.Code(statusCode)
+	case statusCode >= http.StatusOK && statusCode < http.StatusBadRequest:
+		return codes.OK
+	}
+	switch statusCode {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+	if statusCode >= http.StatusInternalServerError && statusCode < 600 {
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+// contentSubtype 返回请求的内容子类型，例如 application/grpc+json 返回json
+func contentSubtype(md metadata.MD) string {
+	values := md.Get("content-type")
+	if len(values) == 0 {
+		return ""
+	}
+	contentType := strings.ToLower(values[0])
+	if index := strings.IndexAny(contentType, "+;"); index >= 0 && contentType[index] == '+' {
+		subtype := contentType[index+1:]
+		if end := strings.Index(subtype, ";"); end >= 0 {
+			subtype = subtype[:end]
+		}
+		return subtype
+	}
+	return ""
+}
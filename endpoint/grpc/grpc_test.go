@@ -0,0 +1,266 @@
+//go:build with_grpc
+
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/protofile"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const testProtoDir = "../../testdata/proto"
+
+var failureChain = `{
+  "ruleChain": {"id": "failure", "name": "failure"},
+  "metadata": {
+    "nodes": [
+      {
+        "id": "s1",
+        "type": "jsTransform",
+        "configuration": {
+          "jsScript": "throw 'invalid device';"
+        }
+      }
+    ]
+  }
+}`
+
+var greeterChain = `{
+  "ruleChain": {"id": "greeter", "name": "greeter"},
+  "metadata": {
+    "nodes": [
+      {
+        "id": "s1",
+        "type": "jsTransform",
+        "configuration": {
+          "jsScript": "msg.message='Hello '+msg.name; msg.deviceId=metadata['x-device-id']; msg.method=msgType; return {'msg':msg,'metadata':metadata,'msgType':msgType};"
+        }
+      }
+    ]
+  }
+}`
+
+// 测试请求/响应消息
+func TestMessage(t *testing.T) {
+	t.Run("Request", func(t *testing.T) {
+		var request = &RequestMessage{}
+		test.EndpointMessage(t, request)
+	})
+	t.Run("Response", func(t *testing.T) {
+		var response = &ResponseMessage{}
+		test.EndpointMessage(t, response)
+	})
+}
+
+func TestInit(t *testing.T) {
+	config := types.NewConfig()
+	assert.Equal(t, "server can not empty", (&Endpoint{}).Init(config, types.Configuration{"server": ""}).Error())
+	assert.NotNil(t, (&Endpoint{}).Init(config, types.Configuration{"server": ":0", "protoFile": "notFound.proto"}))
+	assert.Equal(t, "grpc endpoint has not been initialized yet", (&Endpoint{}).Start().Error())
+
+	var ep = &Endpoint{}
+	assert.Nil(t, ep.Init(config, types.Configuration{"server": ":0", "protoFile": "greeter.proto", "importPaths": []string{testProtoDir}}))
+	assert.NotNil(t, ep.methods["/helloworld.Greeter/SayHello"])
+
+	routerId, err := ep.AddRouter(impl.NewRouter().From("helloworld.Greeter/SayHello").End())
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("/helloworld.Greeter/SayHello").End())
+	assert.Equal(t, "duplicate router /helloworld.Greeter/SayHello", err.Error())
+	_, err = ep.AddRouter(impl.NewRouter().From("/helloworld.Greeter").End())
+	assert.NotNil(t, err)
+	assert.Nil(t, ep.RemoveRouter(routerId))
+	assert.Equal(t, "router: "+routerId+" not found", ep.RemoveRouter(routerId).Error())
+}
+
+func TestContentSubtype(t *testing.T) {
+	assert.Equal(t, "", contentSubtype(metadata.Pairs()))
+	assert.Equal(t, "", contentSubtype(metadata.Pairs("content-type", "application/grpc")))
+	assert.Equal(t, "json", contentSubtype(metadata.Pairs("content-type", "application/grpc+json")))
+	assert.Equal(t, "json", contentSubtype(metadata.Pairs("content-type", "application/grpc+JSON; charset=utf-8")))
+	assert.Equal(t, "", contentSubtype(metadata.Pairs("content-type", "application/grpc; a=b+c")))
+}
+
+func TestToGrpcCode(t *testing.T) {
+	testCases := []struct {
+		statusCode int
+		code       codes.Code
+	}{
+		{0, codes.OK},
+		{http.StatusOK, codes.OK},
+		{http.StatusNoContent, codes.OK},
+		{http.StatusFound, codes.OK},
+		{int(codes.PermissionDenied), codes.PermissionDenied},
+		{int(codes.Unauthenticated), codes.Unauthenticated},
+		{http.StatusBadRequest, codes.InvalidArgument},
+		{http.StatusUnauthorized, codes.Unauthenticated},
+		{http.StatusForbidden, codes.PermissionDenied},
+		{http.StatusNotFound, codes.NotFound},
+		{http.StatusRequestTimeout, codes.DeadlineExceeded},
+		{http.StatusConflict, codes.AlreadyExists},
+		{http.StatusTooManyRequests, codes.ResourceExhausted},
+		{http.StatusInternalServerError, codes.Internal},
+		{http.StatusNotImplemented, codes.Unimplemented},
+		{http.StatusBadGateway, codes.Unavailable},
+		{http.StatusServiceUnavailable, codes.Unavailable},
+		{http.StatusGatewayTimeout, codes.DeadlineExceeded},
+		{599, codes.Internal},
+		{http.StatusTeapot, codes.Unknown},
+		{-1, codes.Unknown},
+	}
+	for _, item := range testCases {
+		assert.Equal(t, item.code, toGrpcCode(item.statusCode))
+	}
+}
+
+func TestCodecNotRegistered(t *testing.T) {
+	// 端点不能替换进程内全局的json编解码器
+	assert.Nil(t, encoding.GetCodec(CodecName))
+}
+
+func TestGrpcEndpoint(t *testing.T) {
+	config := engine.NewConfig(types.WithDefaultPool())
+	_, err := engine.New("greeter", []byte(greeterChain), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("greeter")
+	_, err = engine.New("failure", []byte(failureChain), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("failure")
+
+	ep := &Endpoint{}
+	assert.Nil(t, ep.Init(config, types.Configuration{"server": "127.0.0.1:0", "protoFile": "greeter.proto", "importPaths": []string{testProtoDir}}))
+	_, err = ep.AddRouter(impl.NewRouter().From("/helloworld.Greeter/SayHello").To("chain:greeter").Wait().End())
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("/rulego.Test/*").To("chain:greeter").Wait().End())
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("/rulego.Test/Async").To("chain:greeter").End())
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("/rulego.Test/Failure").To("chain:failure").Wait().End())
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("/rulego.Test/Status").To("chain:greeter").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		exchange.Out.Headers().Set("x-result", "denied")
+		exchange.Out.SetStatusCode(int(codes.PermissionDenied))
+		exchange.Out.SetBody([]byte("permission denied"))
+		return true
+	}).Wait().End())
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("/rulego.Test/NotFound").To("chain:greeter").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		exchange.Out.SetStatusCode(http.StatusNotFound)
+		exchange.Out.SetBody([]byte("device not found"))
+		return true
+	}).Wait().End())
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	defer ep.Destroy()
+	// 重复启动
+	assert.Nil(t, ep.Start())
+
+	conn, err := grpc.Dial(ep.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	invokeJSON := func(method string, request string, opts ...grpc.CallOption) (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-device-id", "d1")
+		var response []byte
+		opts = append(opts, grpc.ForceCodec(JSONCodec{}))
+		err := conn.Invoke(ctx, method, []byte(request), &response, opts...)
+		return string(response), err
+	}
+
+	t.Run("Json", func(t *testing.T) {
+		response, err := invokeJSON("/rulego.Test/Echo", `{"name":"json"}`)
+		assert.Nil(t, err)
+		var result map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(response), &result))
+		assert.Equal(t, "Hello json", result["message"])
+		assert.Equal(t, "d1", result["deviceId"])
+		assert.Equal(t, "/rulego.Test/Echo", result["method"])
+	})
+
+	t.Run("Proto", func(t *testing.T) {
+		files, err := protofile.Compile("greeter.proto", []string{testProtoDir})
+		assert.Nil(t, err)
+		method, err := protofile.FindMethod(files, "helloworld.Greeter", "SayHello")
+		assert.Nil(t, err)
+		request := dynamicpb.NewMessage(method.Input())
+		request.Set(method.Input().Fields().ByName("name"), protoreflect.ValueOfString("proto"))
+		response := dynamicpb.NewMessage(method.Output())
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-device-id", "d2")
+		assert.Nil(t, conn.Invoke(ctx, "/helloworld.Greeter/SayHello", request, response))
+		assert.Equal(t, "Hello proto", response.Get(method.Output().Fields().ByName("message")).String())
+
+		// 描述中未定义的方法需要使用JSON
+		err = conn.Invoke(ctx, "/rulego.Test/Echo", request, response)
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+
+	t.Run("Async", func(t *testing.T) {
+		response, err := invokeJSON("/rulego.Test/Async", `{"name":"async"}`)
+		assert.Nil(t, err)
+		assert.Equal(t, "{}", response)
+	})
+
+	t.Run("Unimplemented", func(t *testing.T) {
+		_, err := invokeJSON("/rulego.Other/Echo", `{}`)
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+
+	t.Run("ChainError", func(t *testing.T) {
+		_, err := invokeJSON("/rulego.Test/Failure", `{}`)
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.True(t, strings.Contains(status.Convert(err).Message(), "invalid device"))
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		var header metadata.MD
+		_, err := invokeJSON("/rulego.Test/Status", `{}`, grpc.Header(&header))
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.Equal(t, "permission denied", status.Convert(err).Message())
+		assert.Equal(t, []string{"denied"}, header.Get("x-result"))
+	})
+
+	t.Run("HttpStatus", func(t *testing.T) {
+		_, err := invokeJSON("/rulego.Test/NotFound", `{}`)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Equal(t, "device not found", status.Convert(err).Message())
+	})
+
+	assert.Nil(t, ep.Close())
+	assert.Nil(t, ep.Addr())
+}
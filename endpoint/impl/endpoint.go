@@ -55,10 +55,12 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/api/types/endpoint"
 	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/utils/cast"
 	"github.com/rulego/rulego/utils/str"
 )
 
@@ -98,6 +100,8 @@ type From struct {
 	processList []endpoint.Process
 	//流转目标路径，例如"chain:{chainId}"，则是交给规则引擎处理数据  Target flow path, e.g., "chain:{chainId}" for rule engine processing  流转目标路径
 	to *To
+	//消息存活时间，通过 msgTtl 配置  Time-to-live of created messages, configured by msgTtl  消息存活时间
+	msgTtl time.Duration
 }
 
 // ToString returns the string representation of the From path.
@@ -109,6 +113,13 @@ func (f *From) ToString() string {
 	return f.From
 }
 
+// GetConfiguration returns the configuration of the From, processors can read their router specific settings from it.
+//
+// GetConfiguration 返回 From 的配置，处理器可以从中读取路由级别的配置。
+func (f *From) GetConfiguration() types.Configuration {
+	return f.Config
+}
+
 // Transform adds a transformation processor to the From processing pipeline.
 // Transformations are applied to incoming data before routing to the destination.
 //
@@ -170,6 +181,12 @@ func (f *From) ExecuteProcess(router endpoint.Router, exchange *endpoint.Exchang
 			break
 		}
 	}
+	//设置消息存活时间，处理器已经设置的过期时间优先
+	if result && f.msgTtl > 0 && exchange.In != nil {
+		if msg := exchange.In.GetMsg(); msg != nil && msg.GetExpireAt() == 0 {
+			msg.SetTTL(f.msgTtl)
+		}
+	}
 	return result
 }
 
@@ -588,6 +605,13 @@ func (r *Router) From(from string, configs ...types.Configuration) endpoint.From
 		}
 	}
 	r.from = &From{Router: r, From: from, Config: fromConfig}
+	if v, ok := fromConfig[endpoint.FromConfigKeyMsgTtl]; ok {
+		if ttl, err := cast.ToDurationE(v); err == nil {
+			r.from.msgTtl = ttl
+		} else {
+			r.err = fmt.Errorf("invalid %s: %w", endpoint.FromConfigKeyMsgTtl, err)
+		}
+	}
 
 	return r.from
 }
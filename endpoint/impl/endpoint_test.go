@@ -432,6 +432,23 @@ func TestEndpoint(t *testing.T) {
 		testEp.DoProcess(nil, router, exchange)
 	})
 
+	t.Run("MsgTtl", func(t *testing.T) {
+		exchange := &endpoint.Exchange{
+			In:  &testRequestMessage{body: []byte("{\"productName\":\"lala\"}")},
+			Out: &testResponseMessage{}}
+		router := NewRouter(endpoint.RouterOptions.WithRuleConfig(config)).
+			From(from, types.Configuration{endpoint.FromConfigKeyMsgTtl: "1m"}).End()
+		assert.Nil(t, router.Err())
+		assert.True(t, router.GetFrom().ExecuteProcess(router, exchange))
+		expireAt := exchange.In.GetMsg().GetExpireAt()
+		assert.True(t, expireAt > time.Now().UnixMilli())
+		assert.True(t, expireAt <= time.Now().Add(time.Minute).UnixMilli())
+
+		router = NewRouter(endpoint.RouterOptions.WithRuleConfig(config)).
+			From(from, types.Configuration{endpoint.FromConfigKeyMsgTtl: "abc"}).End()
+		assert.NotNil(t, router.Err())
+	})
+
 }
 
 func executeRouterTest(router endpoint.Router, exchange *endpoint.Exchange) {
@@ -0,0 +1,503 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package modbus provides a Modbus TCP polling endpoint implementation for the RuleGo framework.
+// It reads a register map from a Modbus TCP device on a cron schedule and emits one JSON message
+// per poll to rule chains or components.
+//
+// Key components in this package include:
+// - Endpoint (alias Modbus): Implements the polling and message routing
+// - Point: A named coil, discrete input or register range of the register map
+// - RequestMessage: Represents the values of one poll
+// - ResponseMessage: Polling is one-way, the response is not sent to the device
+//
+// The router From is a cron expression with seconds, the same syntax as the schedule endpoint,
+// e.g. "*/5 * * * * *" polls every 5 seconds. The message data is a JSON object of point name to
+// value, e.g. {"temperature":21.5,"running":true}. Points that fail to read are left out and the
+// first error is written to the error metadata.
+//
+// Package modbus 提供 RuleGo 框架的 Modbus TCP 轮询端点实现。按 cron 定时从 Modbus TCP 设备读取
+// 寄存器表，每次轮询向规则链或者组件发送一条JSON消息。路由的 From 为带秒的 cron 表达式，语法与
+// schedule 端点相同，例如 "*/5 * * * * *" 每5秒轮询一次。消息负荷为点位名称到值的JSON对象，
+// 例如 {"temperature":21.5,"running":true}。读取失败的点位不输出，第一个错误写入 error 元数据。
+package modbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/cast"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/modbus"
+	"github.com/rulego/rulego/utils/runtime"
+)
+
+// Type 组件类型
+const Type = types.EndpointTypePrefix + "modbus"
+
+const (
+	// KeyServer 服务端地址写入的元数据键
+	KeyServer = "server"
+	// KeyUnitId 单元ID写入的元数据键
+	KeyUnitId = "unitId"
+	// KeyError 读取错误写入的元数据键
+	KeyError = "error"
+)
+
+// Endpoint 别名
+type Endpoint = Modbus
+
+// RequestMessage 一次轮询的结果
+type RequestMessage struct {
+	from    string
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+}
+
+// Body 返回点位值的JSON
+func (r *RequestMessage) Body() []byte {
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+// From 返回服务端地址
+func (r *RequestMessage) From() string {
+	return r.from
+}
+
+func (r *RequestMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		ruleMsg := types.NewMsg(0, r.From(), types.JSON, types.NewMetadata(), string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage 轮询是单向的，响应不会发送给设备
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+	mu      sync.RWMutex
+}
+
+func (r *ResponseMessage) Body() []byte {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.body = body
+}
+
+func (r *ResponseMessage) SetError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+func (r *ResponseMessage) GetError() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.err
+}
+
+// Point 寄存器表中的一个点位
+type Point struct {
+	// Name 点位名称，作为消息负荷JSON的字段名
+	Name string `json:"name"`
+	// Function 读功能：readCoils、readDiscreteInputs、readHoldingRegisters、readInputRegisters，默认readHoldingRegisters
+	Function string `json:"function"`
+	// Address 起始地址，从0开始
+	Address int `json:"address"`
+	// Quantity 值的个数，大于1时输出数组，默认1
+	Quantity int `json:"quantity"`
+	// DataType 寄存器数据类型：int16、uint16、int32、uint32、float32、int64、uint64、float64，默认uint16
+	DataType string `json:"dataType"`
+	// ByteOrder 寄存器内的字节序：big 或 little，默认big
+	ByteOrder string `json:"byteOrder"`
+	// WordOrder 多寄存器数据类型的字序：big 或 little，默认big
+	WordOrder string `json:"wordOrder"`
+	// Scale 寄存器值的倍率，例如0.1，0表示不缩放
+	Scale float64 `json:"scale"`
+}
+
+// Config Modbus 轮询端点配置
+type Config struct {
+	// Server Modbus TCP 服务端地址，格式为 host:port
+	Server string `json:"server"`
+	// UnitId 单元ID（从站地址），默认1
+	UnitId int `json:"unitId"`
+	// TimeoutMs 连接和请求超时时间，单位毫秒，默认3000
+	TimeoutMs int `json:"timeoutMs"`
+	// Points 寄存器表
+	Points []Point `json:"points"`
+}
+
+// point 解析后的点位
+type point struct {
+	Point
+	function modbus.Function
+	codec    modbus.Codec
+}
+
+// Modbus Modbus TCP 轮询端点
+type Modbus struct {
+	impl.BaseEndpoint
+	// Config 配置
+	Config Config
+	// RuleConfig rulego配置
+	RuleConfig types.Config
+	points     []point
+	cron       *cron.Cron
+	client     *modbus.Client
+	clientLock sync.Mutex
+}
+
+// Type 组件类型
+func (ep *Modbus) Type() string {
+	return Type
+}
+
+func (ep *Modbus) New() types.Node {
+	return &Modbus{
+		Config: Config{
+			Server:    "127.0.0.1:502",
+			UnitId:    1,
+			TimeoutMs: 3000,
+		},
+		cron: cron.New(cron.WithSeconds()),
+	}
+}
+
+// Init 初始化
+func (ep *Modbus) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	ep.RuleConfig = ruleConfig
+	if err := maps.Map2Struct(configuration, &ep.Config); err != nil {
+		return err
+	}
+	if ep.Config.UnitId < 0 || ep.Config.UnitId > 255 {
+		return fmt.Errorf("invalid unit id: %d", ep.Config.UnitId)
+	}
+	if ep.Config.TimeoutMs <= 0 {
+		ep.Config.TimeoutMs = 3000
+	}
+	points := make([]point, 0, len(ep.Config.Points))
+	names := make(map[string]bool)
+	for _, item := range ep.Config.Points {
+		p, err := newPoint(item)
+		if err != nil {
+			return err
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate point: %s", p.Name)
+		}
+		names[p.Name] = true
+		points = append(points, p)
+	}
+	ep.points = points
+	if ep.cron == nil {
+		ep.cron = cron.New(cron.WithSeconds())
+	}
+	return nil
+}
+
+// newPoint 校验并解析点位
+func newPoint(item Point) (point, error) {
+	p := point{Point: item}
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return p, errors.New("point name can not be empty")
+	}
+	if strings.TrimSpace(p.Function) == "" {
+		p.Function = modbus.ReadHoldingRegisters.String()
+	}
+	var err error
+	if p.function, err = modbus.ParseFunction(strings.TrimSpace(p.Function)); err != nil {
+		return p, err
+	}
+	if !p.function.IsRead() {
+		return p, fmt.Errorf("point %s: %s is not a read function", p.Name, p.function)
+	}
+	if p.codec, err = modbus.NewCodec(p.DataType, p.ByteOrder, p.WordOrder); err != nil {
+		return p, fmt.Errorf("point %s: %w", p.Name, err)
+	}
+	if p.Address < 0 || p.Address > 65535 {
+		return p, fmt.Errorf("point %s: invalid address: %d", p.Name, p.Address)
+	}
+	if p.Quantity <= 0 {
+		p.Quantity = 1
+	}
+	return p, nil
+}
+
+// Destroy 销毁
+func (ep *Modbus) Destroy() {
+	_ = ep.Close()
+}
+
+// Close 停止轮询并关闭客户端
+func (ep *Modbus) Close() error {
+	if ep.cron != nil {
+		ep.cron.Stop()
+		ep.cron = nil
+	}
+	ep.clientLock.Lock()
+	defer ep.clientLock.Unlock()
+	if ep.client != nil {
+		_ = ep.client.Close()
+		ep.client = nil
+	}
+	ep.BaseEndpoint.Destroy()
+	return nil
+}
+
+func (ep *Modbus) Id() string {
+	return ep.Config.Server
+}
+
+// AddRouter 添加路由，From 为带秒的 cron 表达式，返回定时任务ID作为路由ID
+func (ep *Modbus) AddRouter(router endpoint.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router can not nil")
+	}
+	if router.GetFrom() == nil {
+		return "", errors.New("from can not nil")
+	}
+	if ep.cron == nil {
+		ep.cron = cron.New(cron.WithSeconds())
+	}
+	id, err := ep.cron.AddFunc(router.GetFrom().ToString(), func() {
+		ep.handler(router)
+	})
+	if err != nil {
+		return "", err
+	}
+	idStr := strconv.Itoa(int(id))
+	router.SetId(idStr)
+	return idStr, nil
+}
+
+func (ep *Modbus) RemoveRouter(routerId string, params ...interface{}) error {
+	entryID, err := strconv.Atoi(routerId)
+	if err != nil {
+		return fmt.Errorf("%s it is an illegal routing id", routerId)
+	}
+	if ep.cron != nil {
+		ep.cron.Remove(cron.EntryID(entryID))
+	}
+	return nil
+}
+
+func (ep *Modbus) Start() error {
+	if ep.cron == nil {
+		return errors.New("cron has not been initialized yet")
+	}
+	ep.cron.Start()
+	return nil
+}
+
+func (ep *Modbus) Printf(format string, v ...interface{}) {
+	if ep.RuleConfig.Logger != nil {
+		ep.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// Poll 读取寄存器表，返回点位名称到值的映射和第一个读取错误
+func (ep *Modbus) Poll() (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	client, err := ep.getClient()
+	if err != nil {
+		return values, err
+	}
+	var firstErr error
+	for _, p := range ep.points {
+		value, err := ep.read(client, p)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("point %s: %w", p.Name, err)
+			}
+			continue
+		}
+		values[p.Name] = value
+	}
+	return values, firstErr
+}
+
+// getClient 获取客户端，第一次连接失败时在下次轮询重新连接
+func (ep *Modbus) getClient() (*modbus.Client, error) {
+	ep.clientLock.Lock()
+	defer ep.clientLock.Unlock()
+	if ep.client == nil {
+		client, err := modbus.Dial(ep.Config.Server, time.Duration(ep.Config.TimeoutMs)*time.Millisecond)
+		if err != nil {
+			return nil, err
+		}
+		ep.client = client
+	}
+	return ep.client, nil
+}
+
+// read 读取并解码一个点位，Quantity 为1时返回单个值，否则返回数组
+func (ep *Modbus) read(client *modbus.Client, p point) (interface{}, error) {
+	unitId, address := byte(ep.Config.UnitId), uint16(p.Address)
+	var values []interface{}
+	switch p.function {
+	case modbus.ReadCoils, modbus.ReadDiscreteInputs:
+		var bits []bool
+		var err error
+		if p.function == modbus.ReadCoils {
+			bits, err = client.ReadCoils(unitId, address, uint16(p.Quantity))
+		} else {
+			bits, err = client.ReadDiscreteInputs(unitId, address, uint16(p.Quantity))
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range bits {
+			values = append(values, b)
+		}
+	default:
+		quantity := uint16(p.Quantity * p.codec.Registers())
+		var regs []uint16
+		var err error
+		if p.function == modbus.ReadHoldingRegisters {
+			regs, err = client.ReadHoldingRegisters(unitId, address, quantity)
+		} else {
+			regs, err = client.ReadInputRegisters(unitId, address, quantity)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if values, err = p.codec.Decode(regs); err != nil {
+			return nil, err
+		}
+		if p.Scale != 0 {
+			for i, v := range values {
+				values[i] = cast.ToFloat64(v) * p.Scale
+			}
+		}
+	}
+	if p.Quantity == 1 {
+		return values[0], nil
+	}
+	return values, nil
+}
+
+// handler 处理定时任务，每次轮询发送一条消息
+func (ep *Modbus) handler(router endpoint.Router) {
+	defer func() {
+		//捕捉异常
+		if e := recover(); e != nil {
+			ep.Printf("modbus endpoint handler err :\n%v", runtime.Stack())
+		}
+	}()
+	values, pollErr := ep.Poll()
+	body, err := json.Marshal(values)
+	if err != nil {
+		ep.Printf("modbus endpoint marshal values err :%v", err)
+		return
+	}
+	exchange := &endpoint.Exchange{
+		In:  &RequestMessage{from: ep.Config.Server, body: body},
+		Out: &ResponseMessage{},
+	}
+	msg := exchange.In.GetMsg()
+	msg.Metadata.PutValue(KeyServer, ep.Config.Server)
+	msg.Metadata.PutValue(KeyUnitId, strconv.Itoa(ep.Config.UnitId))
+	if pollErr != nil {
+		msg.Metadata.PutValue(KeyError, pollErr.Error())
+	}
+	ep.DoProcess(context.Background(), router, exchange)
+}
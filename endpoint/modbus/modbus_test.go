@@ -0,0 +1,143 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/test/modbustest"
+)
+
+var testChain = `{
+  "ruleChain": {"id": "modbusChain", "name": "modbus"},
+  "metadata": {
+    "nodes": [
+      {
+        "id": "s1",
+        "type": "jsFilter",
+        "configuration": {
+          "jsScript": "return msg.temperature > 20;"
+        }
+      }
+    ]
+  }
+}`
+
+// 测试请求/响应消息
+func TestModbusMessage(t *testing.T) {
+	t.Run("Request", func(t *testing.T) {
+		var request = &RequestMessage{}
+		test.EndpointMessage(t, request)
+	})
+	t.Run("Response", func(t *testing.T) {
+		var response = &ResponseMessage{}
+		test.EndpointMessage(t, response)
+	})
+}
+
+func TestModbusConfig(t *testing.T) {
+	ep := &Endpoint{}
+	assert.Equal(t, "127.0.0.1:502", ep.New().(*Endpoint).Config.Server)
+	for expected, points := range map[string][]Point{
+		"point name can not be empty":                     {{Address: 1}},
+		"duplicate point: a":                              {{Name: "a"}, {Name: "a"}},
+		"unsupported modbus function: readFifo":           {{Name: "a", Function: "readFifo"}},
+		"point a: writeSingleCoil is not a read function": {{Name: "a", Function: "writeSingleCoil"}},
+		"point a: unsupported data type: int8":            {{Name: "a", DataType: "int8"}},
+		"point a: invalid address: -1":                    {{Name: "a", Address: -1}},
+	} {
+		err := ep.Init(types.NewConfig(), types.Configuration{"points": points})
+		assert.Equal(t, expected, err.Error())
+	}
+	err := ep.Init(types.NewConfig(), types.Configuration{"unitId": 300})
+	assert.Equal(t, "invalid unit id: 300", err.Error())
+
+	ep = &Endpoint{}
+	err = ep.Init(types.NewConfig(), types.Configuration{"points": []Point{{Name: "a"}}})
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(nil)
+	assert.Equal(t, "router can not nil", err.Error())
+	_, err = ep.AddRouter(impl.NewRouter().From("bad cron").End())
+	assert.NotNil(t, err)
+	routerId, err := ep.AddRouter(impl.NewRouter().From("*/1 * * * * *").End())
+	assert.Nil(t, err)
+	assert.Nil(t, ep.RemoveRouter(routerId))
+	assert.Equal(t, "a it is an illegal routing id", ep.RemoveRouter("a").Error())
+	ep.Destroy()
+}
+
+func TestModbusEndpoint(t *testing.T) {
+	server := modbustest.NewServer()
+	assert.Nil(t, server.Start("127.0.0.1:0"))
+	defer server.Close()
+	//float32 21.5 ABCD 顺序
+	server.SetHoldingRegisters(0, 0x41AC, 0x0000)
+	server.SetInputRegisters(10, 235, 0xFFFF)
+	server.SetCoils(5, true, false)
+
+	config := engine.NewConfig(types.WithDefaultPool())
+	_, err := engine.New("modbusChain", []byte(testChain), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("modbusChain")
+
+	ep := &Endpoint{}
+	err = ep.Init(config, types.Configuration{
+		"server": server.Addr().String(),
+		"unitId": 2,
+		"points": []map[string]interface{}{
+			{"name": "temperature", "address": 0, "dataType": "float32"},
+			{"name": "humidity", "function": "readInputRegisters", "address": 10, "scale": 0.1},
+			{"name": "raw", "function": "readInputRegisters", "address": 10, "quantity": 2, "dataType": "int16"},
+			{"name": "running", "function": "readCoils", "address": 5},
+			{"name": "alarm", "function": "readCoils", "address": 65535, "quantity": 2},
+		},
+	})
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	msgs := make(chan *types.RuleMsg, 10)
+	_, err = ep.AddRouter(impl.NewRouter().From("*/1 * * * * *").To("chain:modbusChain").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msgs <- exchange.Out.GetMsg()
+		return true
+	}).Wait().End())
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+
+	select {
+	case msg := <-msgs:
+		assert.Equal(t, `{"humidity":23.5,"raw":[235,-1],"running":true,"temperature":21.5}`, msg.GetData())
+		assert.Equal(t, server.Addr().String(), msg.Type)
+		assert.Equal(t, server.Addr().String(), msg.Metadata.GetValue(KeyServer))
+		assert.Equal(t, "2", msg.Metadata.GetValue(KeyUnitId))
+		assert.Equal(t, "point alarm: modbus exception 2 (illegal data address) for readCoils", msg.Metadata.GetValue(KeyError))
+	case <-time.After(time.Second * 3):
+		t.Fatal("poll timeout")
+	}
+
+	//设备离线时发送空数据和错误
+	_ = server.Close()
+	values, err := ep.Poll()
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(values))
+}
@@ -143,6 +143,20 @@
 //
 // • responseTopic: Target topic for response  响应的目标主题
 // • responseQos: QoS level for response  响应的 QoS 级别
+//
+// MQTT 5 / MQTT 5 协议：
+//
+// Set "protocolVersion": 5 to use the MQTT 5 client mode:
+// 设置 "protocolVersion": 5 使用 MQTT 5 客户端模式：
+//
+// • User properties are mapped to message metadata  用户属性映射到消息元数据
+// • The request's response topic and correlation data are stored in the responseTopic and
+// correlationData metadata, so the response is published back to the requester  请求的响应主题和关联数据
+// 保存到 responseTopic 和 correlationData 元数据，响应发布回请求方
+// • Response headers are published as user properties, Content-Type as the content type  响应头作为用户属性发布，
+// Content-Type 作为内容类型
+// • Shared subscriptions "$share/{group}/{topic}" distribute messages between endpoint instances
+// for horizontal scaling  共享订阅在多个端点实例之间分配消息，用于水平扩展
 package mqtt
 
 import (
@@ -150,6 +164,7 @@ import (
 	"errors"
 	"fmt"
 	"net/textproto"
+	"sort"
 	"strconv"
 	"time"
 
@@ -188,6 +203,18 @@ const (
 	// KeyResponseQos specifies the QoS level for publishing response messages
 	// KeyResponseQos 指定发布响应消息的 QoS 级别
 	KeyResponseQos = "responseQos"
+
+	// KeyCorrelationData stores the MQTT 5 correlation data, which is sent back with the response
+	// KeyCorrelationData 存储 MQTT 5 关联数据，发布响应时携带
+	KeyCorrelationData = "correlationData"
+
+	// KeyContentType stores the MQTT 5 content type of the request
+	// KeyContentType 存储 MQTT 5 请求的内容类型
+	KeyContentType = "contentType"
+
+	// headerContentType response header published as the MQTT 5 content type
+	// headerContentType 作为 MQTT 5 内容类型发布的响应头
+	headerContentType = "Content-Type"
 )
 
 // Endpoint is an alias for Mqtt to provide consistent naming with other endpoints.
@@ -269,6 +296,14 @@ func (r *RequestMessage) Headers() textproto.MIMEHeader {
 	return r.headers
 }
 
+// properties 返回 MQTT 5 消息属性，MQTT 3.1/3.1.1 消息返回 nil
+func (r *RequestMessage) properties() *mqtt.Properties {
+	if msg, ok := r.request.(*mqtt.Message); ok && msg != nil {
+		return msg.Properties()
+	}
+	return nil
+}
+
 // From returns the MQTT topic name for this message.
 // This is used for routing and logging purposes in the RuleGo framework.
 //
@@ -327,9 +362,29 @@ func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
 // • Source: Set to MQTT topic name  来源：设置为 MQTT 主题名称
 // • Payload: MQTT message payload as string  载荷：MQTT 消息载荷作为字符串
 // • Metadata: Includes original topic information  元数据：包含原始主题信息
+// • MQTT 5: User properties, response topic, correlation data and content type  MQTT 5：用户属性、响应主题、关联数据和内容类型
+// • MQTT 5 message expiry: Mapped to the message TTL  MQTT 5 消息过期时间：映射为消息TTL
 func (r *RequestMessage) GetMsg() *types.RuleMsg {
 	if r.msg == nil {
 		ruleMsg := types.NewMsg(0, r.From(), types.JSON, types.NewMetadata(), string(r.Body()))
+		if props := r.properties(); props != nil {
+			for _, item := range props.User {
+				ruleMsg.Metadata.PutValue(item.Key, item.Value)
+			}
+			if props.ResponseTopic != "" {
+				ruleMsg.Metadata.PutValue(KeyResponseTopic, props.ResponseTopic)
+			}
+			if props.CorrelationData != nil {
+				ruleMsg.Metadata.PutValue(KeyCorrelationData, string(props.CorrelationData))
+			}
+			if props.ContentType != "" {
+				ruleMsg.Metadata.PutValue(KeyContentType, props.ContentType)
+			}
+			//消息过期时间映射为规则消息的TTL，过期的消息不再处理
+			if props.MessageExpiry > 0 {
+				ruleMsg.SetTTL(time.Duration(props.MessageExpiry) * time.Second)
+			}
+		}
 		ruleMsg.Metadata.PutValue(KeyRequestTopic, r.From())
 		r.msg = &ruleMsg
 	}
@@ -418,6 +473,7 @@ func (r *RequestMessage) Request() paho.Message {
 // Configuration via Metadata / 通过元数据配置：
 // • responseTopic: Target topic for publishing  发布的目标主题
 // • responseQos: QoS level for publishing (0, 1, or 2)  发布的 QoS 级别
+// • correlationData: MQTT 5 correlation data of the response  MQTT 5 响应的关联数据
 type ResponseMessage struct {
 	//HTTP 风格的头部映射，存储 MQTT 响应配置  HTTP-style headers map storing MQTT response configuration  头部映射
 	headers textproto.MIMEHeader
@@ -425,6 +481,8 @@ type ResponseMessage struct {
 	request paho.Message
 	//MQTT 客户端，用于发布响应消息  MQTT client for publishing response messages  MQTT 客户端
 	response paho.Client
+	//MQTT 5 模式下用于发布响应消息的客户端  Client for publishing response messages in MQTT 5 mode  MQTT 5 客户端
+	client *mqtt.Client
 	//响应消息体数据  Response message body data  响应消息体
 	body []byte
 	//处理结果的规则消息  Rule message with processing results  处理结果的规则消息
@@ -490,8 +548,38 @@ func (r *ResponseMessage) SetBody(body []byte) {
 			qosInt, _ := strconv.Atoi(qosStr)
 			qos = byte(qosInt)
 		}
-		r.response.Publish(topic, qos, false, r.body)
+		if r.response != nil {
+			r.response.Publish(topic, qos, false, r.body)
+		} else if r.client != nil {
+			if err := r.client.PublishWithProperties(topic, qos, false, r.body, r.properties()); err != nil {
+				r.SetError(err)
+			}
+		}
+	}
+}
+
+// properties 把关联数据和响应头转换成 MQTT 5 消息属性，Content-Type 作为内容类型，其他响应头作为用户属性
+func (r *ResponseMessage) properties() *mqtt.Properties {
+	props := &mqtt.Properties{}
+	if correlationData := r.getMetadataValue(KeyCorrelationData, KeyCorrelationData); correlationData != "" {
+		props.CorrelationData = []byte(correlationData)
+	}
+	for key, values := range r.Headers() {
+		switch textproto.CanonicalMIMEHeaderKey(key) {
+		case headerContentType:
+			props.ContentType = r.Headers().Get(key)
+		case textproto.CanonicalMIMEHeaderKey(KeyResponseTopic), textproto.CanonicalMIMEHeaderKey(KeyResponseQos),
+			textproto.CanonicalMIMEHeaderKey(KeyCorrelationData):
+		default:
+			for _, value := range values {
+				props.User = append(props.User, mqtt.UserProperty{Key: key, Value: value})
+			}
+		}
 	}
+	sort.SliceStable(props.User, func(i, j int) bool {
+		return props.User[i].Key < props.User[j].Key
+	})
+	return props
 }
 
 func (r *ResponseMessage) SetError(err error) {
@@ -673,7 +761,7 @@ func (x *Mqtt) AddRouter(router endpoint.Router, params ...interface{}) (string,
 			client.RegisterHandler(mqtt.Handler{
 				Topic:  form.ToString(),
 				Qos:    x.Config.QOS,
-				Handle: x.handler(router),
+				Handle: x.handler(router, client),
 			})
 		}
 	}
@@ -707,7 +795,7 @@ func (x *Mqtt) Start() error {
 			client.RegisterHandler(mqtt.Handler{
 				Topic:  form.ToString(),
 				Qos:    x.Config.QOS,
-				Handle: x.handler(router),
+				Handle: x.handler(router, client),
 			})
 		}
 	}
@@ -740,7 +828,8 @@ func (x *Mqtt) deleteRouter(id string) endpoint.Router {
 	return nil
 }
 
-func (x *Mqtt) handler(router endpoint.Router) func(c paho.Client, data paho.Message) {
+// handler 订阅数据处理器，MQTT 5 模式下 c 为 nil，使用 client 发布响应
+func (x *Mqtt) handler(router endpoint.Router, client *mqtt.Client) func(c paho.Client, data paho.Message) {
 	return func(c paho.Client, data paho.Message) {
 		defer func() {
 			//捕捉异常
@@ -766,6 +855,7 @@ func (x *Mqtt) handler(router endpoint.Router) func(c paho.Client, data paho.Mes
 			Out: &ResponseMessage{
 				request:  data,
 				response: c,
+				client:   client,
 			}}
 
 		// 使用停机上下文处理消息
@@ -1,6 +1,7 @@
 package mqtt
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"reflect"
@@ -9,12 +10,14 @@ import (
 	"testing"
 	"time"
 
+	paho "github.com/eclipse/paho.mqtt.golang"
 	"github.com/rulego/rulego/api/types"
 	endpoint "github.com/rulego/rulego/api/types/endpoint"
 	"github.com/rulego/rulego/endpoint/impl"
 	"github.com/rulego/rulego/engine"
 	"github.com/rulego/rulego/test"
 	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/test/mqtttest"
 	"github.com/rulego/rulego/utils/maps"
 	"github.com/rulego/rulego/utils/mqtt"
 )
@@ -90,6 +93,93 @@ func TestMqttEndpoint(t *testing.T) {
 	close(stop)
 }
 
+// TestMqtt5Endpoint 测试 MQTT 5 用户属性、共享订阅和请求/响应
+func TestMqtt5Endpoint(t *testing.T) {
+	broker := mqtttest.NewBroker()
+	assert.Nil(t, broker.Start("127.0.0.1:0"))
+	defer broker.Close()
+
+	config := engine.NewConfig(types.WithDefaultPool())
+	_, err := engine.New("mqtt5Chain", []byte(`{
+		"ruleChain": {"id": "mqtt5Chain", "name": "mqtt5"},
+		"metadata": {
+			"nodes": [
+				{
+					"id": "s1",
+					"type": "jsTransform",
+					"configuration": {
+						"jsScript": "msg.deviceId = metadata.deviceId; return {'msg':msg,'metadata':metadata,'msgType':msgType};"
+					}
+				}
+			]
+		}
+	}`), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("mqtt5Chain")
+
+	var ep = &Endpoint{}
+	err = ep.Init(config, types.Configuration{
+		"server":          broker.Addr().String(),
+		"qos":             1,
+		"protocolVersion": 5,
+	})
+	assert.Nil(t, err)
+	defer ep.Destroy()
+	//共享订阅，多个实例订阅同一个组时消息只由其中一个处理
+	_, err = ep.AddRouter(impl.NewRouter().From("$share/rulego/device/+/req").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		requestMessage := exchange.In.(*RequestMessage)
+		ruleMsg := requestMessage.GetMsg()
+		metadata := ruleMsg.Metadata
+		//消息过期时间映射为TTL
+		now := time.Now()
+		assert.True(t, ruleMsg.ExpireAt > now.UnixMilli() && ruleMsg.ExpireAt <= now.Add(time.Second*30).UnixMilli())
+		assert.Equal(t, "device/1/req", metadata.GetValue(KeyRequestTopic))
+		assert.Equal(t, "1", metadata.GetValue("deviceId"))
+		assert.Equal(t, "device/1/resp", metadata.GetValue(KeyResponseTopic))
+		assert.Equal(t, "c1", metadata.GetValue(KeyCorrelationData))
+		assert.Equal(t, "application/json", metadata.GetValue(KeyContentType))
+		assert.Nil(t, exchange.Out.(*ResponseMessage).Response())
+		return true
+	}).To("chain:mqtt5Chain").Wait().Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		exchange.Out.Headers().Set("Content-Type", "application/json")
+		exchange.Out.Headers()["result"] = []string{"ok"}
+		exchange.Out.SetBody([]byte(exchange.Out.GetMsg().GetData()))
+		return true
+	}).End())
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+	defer cancel()
+	requester, err := mqtt.NewClient(ctx, mqtt.Config{Server: broker.Addr().String(), ProtocolVersion: mqtt.ProtocolVersion5})
+	assert.Nil(t, err)
+	defer requester.Close()
+	responses := make(chan *mqtt.Message, 1)
+	requester.RegisterHandler(mqtt.Handler{Topic: "device/1/resp", Qos: 1, Handle: func(c paho.Client, data paho.Message) {
+		responses <- data.(*mqtt.Message)
+	}})
+
+	err = requester.PublishWithProperties("device/1/req", 1, false, []byte(`{"temperature":20}`), &mqtt.Properties{
+		MessageExpiry:   30,
+		ContentType:     "application/json",
+		ResponseTopic:   "device/1/resp",
+		CorrelationData: []byte("c1"),
+		User:            []mqtt.UserProperty{{Key: "deviceId", Value: "1"}},
+	})
+	assert.Nil(t, err)
+	select {
+	case response := <-responses:
+		assert.Equal(t, `{"deviceId":"1","temperature":20}`, string(response.Payload()))
+		assert.Equal(t, &mqtt.Properties{
+			ContentType:     "application/json",
+			CorrelationData: []byte("c1"),
+			User:            []mqtt.UserProperty{{Key: "result", Value: "ok"}},
+		}, response.Properties())
+	case <-time.After(time.Second * 3):
+		t.Fatal("receive response timeout")
+	}
+}
+
 // TestMqttEndpointGracefulShutdown tests graceful shutdown functionality of MQTT endpoint
 // TestMqttEndpointGracefulShutdown 测试 MQTT 端点的优雅停机功能
 func TestMqttEndpointGracefulShutdown(t *testing.T) {
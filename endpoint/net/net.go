@@ -50,6 +50,7 @@ import (
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/api/types/endpoint"
 	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/connection"
 	"github.com/rulego/rulego/utils/maps"
 	"github.com/rulego/rulego/utils/runtime"
 )
@@ -232,6 +233,7 @@ func (r *RequestMessage) Conn() net.Conn {
 type ResponseMessage struct {
 	headers textproto.MIMEHeader
 	conn    net.Conn
+	client  *tcpClient
 	log     func(format string, v ...interface{})
 	body    []byte
 	msg     *types.RuleMsg
@@ -307,6 +309,10 @@ func (r *ResponseMessage) SetBody(body []byte) {
 		} else {
 			r.err = errors.New("write err: conn is not udp")
 		}
+	} else if r.client != nil {
+		if err := r.client.write(body); err != nil {
+			r.err = err
+		}
 	} else {
 		if _, err := r.conn.Write(body); err != nil {
 			r.err = err
@@ -326,6 +332,27 @@ func (r *ResponseMessage) GetError() error {
 	return r.err
 }
 
+// tcpClient 在线的TCP连接，注册到连接注册表，写入加锁避免并发推送的数据交错
+type tcpClient struct {
+	conn net.Conn
+	lock sync.Mutex
+}
+
+// Send 发送数据，JSON数据以换行符结尾
+func (c *tcpClient) Send(dataType types.DataType, data []byte) error {
+	if dataType == types.JSON && len(data) > 0 && !strings.HasSuffix(string(data), LineBreak) {
+		data = append(data[:len(data):len(data)], LineBreak...)
+	}
+	return c.write(data)
+}
+
+func (c *tcpClient) write(data []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, err := c.conn.Write(data)
+	return err
+}
+
 // Config endpoint组件的配置
 // Configuration for the NET endpoint component that creates TCP/UDP servers
 // for receiving and processing network messages through the RuleGo framework.
@@ -437,6 +464,8 @@ type RegexpRouter struct {
 	regexp *regexp.Regexp
 	//路由匹配选项
 	matchOptions *RouterMatchOptions
+	//客户端ID和标签
+	identity *connection.Identity
 }
 
 // RouterMatchOptions 路由匹配选项
@@ -483,6 +512,9 @@ type Net struct {
 	// 路由映射表
 	routers map[string]*RegexpRouter
 	closed  int32 // 使用int32类型支持原子操作，0表示未关闭，1表示已关闭
+	//Connections 在线TCP连接注册表，默认使用 connection.EndpointRegistry(Config.Server)，
+	//endpointSend 组件通过 server 配置查找
+	Connections *connection.Registry
 }
 
 // Type 组件类型
@@ -581,6 +613,14 @@ func (ep *Net) AddRouter(router endpoint.Router, params ...interface{}) (string,
 				matchOptions = opts
 			}
 		}
+		// 解析客户端ID和标签
+		var identity *connection.Identity
+		if from, ok := router.GetFrom().(*impl.From); ok {
+			var err error
+			if identity, err = connection.NewIdentity(from.Config); err != nil {
+				return "", err
+			}
+		}
 
 		ep.CheckAndSetRouterId(router)
 		ep.Lock()
@@ -595,6 +635,7 @@ func (ep *Net) AddRouter(router endpoint.Router, params ...interface{}) (string,
 				router:       router,
 				regexp:       regexpV,
 				matchOptions: matchOptions,
+				identity:     identity,
 			}
 			return router.GetId(), nil
 		}
@@ -715,6 +756,14 @@ func (ep *Net) submitTask(fn func()) {
 	}
 }
 
+// connections 返回在线连接注册表，没有指定时使用端点服务地址对应的注册表
+func (ep *Net) connections() *connection.Registry {
+	if ep.Connections == nil {
+		return connection.EndpointRegistry(ep.Config.Server)
+	}
+	return ep.Connections
+}
+
 func (ep *Net) Printf(format string, v ...interface{}) {
 	if ep.RuleConfig.Logger != nil {
 		ep.RuleConfig.Logger.Printf(format, v...)
@@ -763,6 +812,11 @@ type TcpHandler struct {
 	config Config
 	// 数据包分割器
 	splitter PacketSplitter
+	// 注册到连接注册表的客户端
+	client *tcpClient
+	// 当前注册的客户端ID和标签
+	clientId   string
+	clientTags []string
 }
 
 func (x *TcpHandler) handler() {
@@ -782,6 +836,10 @@ func (x *TcpHandler) handler() {
 	}
 	x.splitter = splitter
 
+	// 数据包通过路由 From 处理器后注册在线连接
+	x.client = &tcpClient{conn: x.conn}
+	defer x.endpoint.connections().Unregister(x.client)
+
 	readTimeoutDuration := time.Duration(x.endpoint.Config.ReadTimeout+5) * time.Second
 	//读超时，断开连接
 	x.readTimeoutTimer = time.AfterFunc(readTimeoutDuration, func() {
@@ -844,18 +902,28 @@ func (x *TcpHandler) handler() {
 				log: func(format string, v ...interface{}) {
 					x.endpoint.Printf(format, v...)
 				},
-				conn: x.conn,
-				from: from,
+				conn:   x.conn,
+				client: x.client,
+				from:   from,
 			}}
 
 		msg := exchange.In.GetMsg()
 		// 把客户端连接的地址放到msg元数据中
 		msg.Metadata.PutValue(RemoteAddrKey, from)
+		if x.clientId != "" {
+			msg.Metadata.PutValue(connection.KeyClientId, x.clientId)
+		}
 
 		// 匹配符合的路由，处理消息
 		for _, v := range x.endpoint.routers {
 			if x.matchesRouter(v, data, encodedMessage, exchange) {
-				x.endpoint.DoProcess(context.Background(), v.router, exchange)
+				identity := v.identity
+				//From 处理器通过后注册在线连接，处理器可以在元数据中设置客户端ID和标签的变量
+				router := connection.OnAccepted(v.router, func(exchange *endpoint.Exchange) {
+					x.register(identity, exchange.In.GetMsg().Metadata.Values())
+					exchange.In.GetMsg().Metadata.PutValue(connection.KeyClientId, x.clientId)
+				})
+				x.endpoint.DoProcess(context.Background(), router, exchange)
 			}
 		}
 	}
@@ -904,6 +972,20 @@ func (x *TcpHandler) matchesRouter(router *RegexpRouter, rawData, encodedData []
 	return router.regexp == nil || router.regexp.Match(dataToMatch)
 }
 
+// register 注册在线连接。元数据中没有客户端ID的变量时沿用之前注册的ID和标签，
+// 没有配置客户端ID时使用远程地址
+func (x *TcpHandler) register(identity *connection.Identity, metadata map[string]string) {
+	id, tags := identity.Resolve(metadata)
+	if id == "" && x.clientId != "" {
+		id, tags = x.clientId, x.clientTags
+	}
+	if id == "" && x.conn.RemoteAddr() != nil {
+		id = x.conn.RemoteAddr().String()
+	}
+	x.clientId, x.clientTags = id, tags
+	x.endpoint.connections().Register(x.client, id, tags...)
+}
+
 func (x *TcpHandler) onDisconnect() {
 	if x.conn != nil {
 		_ = x.conn.Close()
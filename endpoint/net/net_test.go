@@ -2,6 +2,7 @@ package net
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -21,6 +22,7 @@ import (
 	"github.com/rulego/rulego/engine"
 	"github.com/rulego/rulego/test"
 	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/connection"
 	"github.com/rulego/rulego/utils/maps"
 )
 
@@ -2004,3 +2006,68 @@ func startProcessorTestServer(t *testing.T, stop chan struct{}, wg *sync.WaitGro
 	ep.Destroy()
 	wg.Done()
 }
+
+// 测试在线连接注册表，规则链在请求交换之外按客户端ID和标签推送
+func TestConnectionRegistry(t *testing.T) {
+	registry := connection.NewRegistry()
+	ep := &Net{Connections: registry}
+	err := ep.Init(types.NewConfig(), types.Configuration{
+		"protocol": "tcp",
+		"server":   "127.0.0.1:8901",
+	})
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	//登录消息携带设备ID，拒绝的消息不注册连接
+	router := impl.NewRouter().From("", types.Configuration{
+		endpoint.FromConfigKeyClientId:   "${deviceId}",
+		endpoint.FromConfigKeyClientTags: "devices",
+	}).Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		msg := exchange.In.GetMsg()
+		if strings.HasPrefix(msg.GetData(), "deny:") {
+			return false
+		}
+		if deviceId, ok := strings.CutPrefix(msg.GetData(), "login:"); ok {
+			msg.Metadata.PutValue("deviceId", deviceId)
+		}
+		return true
+	}).End()
+	_, err = ep.AddRouter(router)
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	time.Sleep(time.Millisecond * 100)
+
+	conn, err := net.Dial("tcp", "127.0.0.1:8901")
+	assert.Nil(t, err)
+	defer conn.Close()
+	time.Sleep(time.Millisecond * 100)
+	//处理器通过前不注册
+	assert.Equal(t, 0, len(registry.Clients("")))
+	_, err = conn.Write([]byte("deny:d1\n"))
+	assert.Nil(t, err)
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, 0, len(registry.Clients("")))
+
+	_, err = conn.Write([]byte("login:d1\n"))
+	assert.Nil(t, err)
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, []string{"d1"}, registry.Clients("devices"))
+
+	//后续消息没有设备ID时沿用之前的客户端ID和标签
+	_, err = conn.Write([]byte("data\n"))
+	assert.Nil(t, err)
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, []string{"d1"}, registry.Clients("devices"))
+
+	assert.Nil(t, registry.Send("d1", types.JSON, []byte(`{"cmd":"reboot"}`)))
+	reader := bufio.NewReader(conn)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	line, err := reader.ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"cmd\":\"reboot\"}\n", line)
+
+	//断开后取消注册
+	_ = conn.Close()
+	time.Sleep(time.Millisecond * 200)
+	assert.True(t, errors.Is(registry.Send("d1", types.TEXT, []byte("a")), connection.ErrClientNotFound))
+}
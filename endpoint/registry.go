@@ -22,10 +22,15 @@ import (
 
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/coap"
+	"github.com/rulego/rulego/endpoint/filewatch"
+	"github.com/rulego/rulego/endpoint/modbus"
 	"github.com/rulego/rulego/endpoint/mqtt"
 	"github.com/rulego/rulego/endpoint/net"
 	"github.com/rulego/rulego/endpoint/rest"
 	"github.com/rulego/rulego/endpoint/schedule"
+	"github.com/rulego/rulego/endpoint/sse"
+	"github.com/rulego/rulego/endpoint/syslog"
 	"github.com/rulego/rulego/endpoint/websocket"
 	"github.com/rulego/rulego/engine"
 	"github.com/rulego/rulego/utils/maps"
@@ -33,25 +38,42 @@ import (
 
 // init registers all built-in endpoint components with the default Registry.
 // This initialization automatically registers the following endpoint types:
-// • endpoint/mqtt: MQTT client endpoint for IoT messaging
+// • endpoint/mqtt: MQTT 3.1.1 and MQTT 5 client endpoint for IoT messaging
 // • endpoint/rest: HTTP/REST API server endpoint
 // • endpoint/net: TCP/UDP network server endpoint
 // • endpoint/websocket: WebSocket server endpoint
 // • endpoint/schedule: Timer-based message generation endpoint
+// • endpoint/fileWatch: Polling file/directory watch endpoint
+// • endpoint/grpc: gRPC server endpoint, registered with the with_grpc build tag
+// • endpoint/sse: Server-Sent Events server endpoint
+// • endpoint/coap: CoAP server endpoint for constrained devices
+// • endpoint/syslog: Syslog receiver endpoint over UDP, TCP and TLS
+// • endpoint/modbus: Modbus TCP register polling endpoint
 //
 // init 向默认 Registry 注册所有内置端点组件。
 // 此初始化自动注册以下端点类型：
-// • endpoint/mqtt：用于物联网消息传递的 MQTT 客户端端点
+// • endpoint/mqtt：用于物联网消息传递的 MQTT 3.1.1 和 MQTT 5 客户端端点
 // • endpoint/rest：HTTP/REST API 服务器端点
 // • endpoint/net：TCP/UDP 网络服务器端点
 // • endpoint/websocket：WebSocket 服务器端点
 // • endpoint/schedule：基于定时器的消息生成端点
+// • endpoint/fileWatch：基于轮询的文件/目录监听端点
+// • endpoint/grpc：gRPC 服务器端点，使用 with_grpc 编译标签时注册
+// • endpoint/sse：Server-Sent Events 服务器端点
+// • endpoint/coap：面向受限设备的 CoAP 服务器端点
+// • endpoint/syslog：基于 UDP、TCP 和 TLS 的 syslog 接收端点
+// • endpoint/modbus：Modbus TCP 寄存器轮询端点
 func init() {
 	_ = Registry.Register(&mqtt.Endpoint{})
 	_ = Registry.Register(&rest.Endpoint{})
 	_ = Registry.Register(&net.Endpoint{})
 	_ = Registry.Register(&websocket.Endpoint{})
 	_ = Registry.Register(&schedule.Endpoint{})
+	_ = Registry.Register(&filewatch.Endpoint{})
+	_ = Registry.Register(&sse.Endpoint{})
+	_ = Registry.Register(&coap.Endpoint{})
+	_ = Registry.Register(&syslog.Endpoint{})
+	_ = Registry.Register(&modbus.Endpoint{})
 }
 
 // Registry is the default global registry for endpoint components.
@@ -0,0 +1,342 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/schema"
+)
+
+// Locations of a request contract violation.
+// 请求约定校验错误的位置。
+const (
+	ErrorInBody   = "body"
+	ErrorInHeader = "header"
+	ErrorInQuery  = "query"
+)
+
+// RequestContract is the request contract of a router, declared in the From configuration "request":
+//
+// RequestContract 路由的请求约定，在 From 配置 request 中声明：
+//
+//	{
+//	  "from": {
+//	    "path": "/api/v1/devices/:id/telemetry",
+//	    "configuration": {
+//	      "request": {
+//	        "maxBodySize": 65536,
+//	        "headers": ["X-Device-Token"],
+//	        "query": {"qos": {"type": "integer", "default": "0"}},
+//	        "bodySchema": {"type": "object", "properties": {"temperature": {"type": "number"}}, "required": ["temperature"]}
+//	      },
+//	      "response": {
+//	        "statusCode": "httpStatus",
+//	        "headers": {"X-Total-Count": "total"}
+//	      }
+//	    }
+//	  },
+//	  "to": {"path": "chain:telemetry", "wait": true, "processors": ["responseToBody"]}
+//	}
+//
+// The contract is validated after the From processors, such as authentication, have accepted the request.
+// Requests that break the contract are rejected with 400 and a JSON body of ValidationErrors before
+// the rule chain runs.
+// 请求约定在 From 处理器（例如认证）通过后校验，不符合约定的请求在规则链执行前返回400，
+// 响应体为 ValidationErrors JSON。
+type RequestContract struct {
+	// BodySchema 请求体JSON Schema，流式请求体(streamBody)不校验
+	BodySchema *schema.FieldSchema `json:"bodySchema"`
+	// Headers 必需的请求头
+	Headers []string `json:"headers"`
+	// Query 查询参数，key为参数名
+	Query map[string]QueryParam `json:"query"`
+	// MaxBodySize 请求体最大字节数，0不限制
+	MaxBodySize int64 `json:"maxBodySize"`
+}
+
+// QueryParam 查询参数约定
+type QueryParam struct {
+	// Type 参数类型：string、integer、number、boolean，默认string。
+	// 校验通过后转换成规范格式写入消息元数据，例如：qos=01 转换成 qos=1
+	Type string `json:"type"`
+	// Required 是否必需
+	Required bool `json:"required"`
+	// Default 参数缺失时的默认值
+	Default string `json:"default"`
+}
+
+// ResponseMapping is the response mapping of a router, declared in the From configuration "response".
+// It is applied to the output message metadata before the response is written.
+//
+// ResponseMapping 路由的响应映射，在 From 配置 response 中声明，在写入响应前根据输出消息元数据设置响应。
+type ResponseMapping struct {
+	// StatusCode 响应状态码的元数据键，值不是合法状态码则忽略
+	StatusCode string `json:"statusCode"`
+	// Headers 响应头映射，key为响应头，value为元数据键
+	Headers map[string]string `json:"headers"`
+}
+
+// ValidationError 请求约定校验错误
+type ValidationError struct {
+	// In 错误位置：body、header、query
+	In string `json:"in"`
+	// Field 字段，body的字段路径、请求头或者查询参数名称
+	Field string `json:"field"`
+	// Message 错误信息
+	Message string `json:"message"`
+}
+
+// ValidationErrors 请求约定校验失败的响应体
+type ValidationErrors struct {
+	Error  string            `json:"error"`
+	Errors []ValidationError `json:"errors"`
+}
+
+// routerContract 解析路由 From 配置中的请求约定和响应映射
+func routerContract(router endpoint.Router) (*RequestContract, *ResponseMapping, error) {
+	from, ok := router.GetFrom().(*impl.From)
+	if !ok {
+		return nil, nil, nil
+	}
+	var contract *RequestContract
+	var mapping *ResponseMapping
+	if v, ok := from.Config[endpoint.FromConfigKeyRequest]; ok && v != nil {
+		contract = &RequestContract{}
+		if err := maps.Map2Struct(v, contract); err != nil {
+			return nil, nil, fmt.Errorf("invalid %s configuration: %w", endpoint.FromConfigKeyRequest, err)
+		}
+		for name, param := range contract.Query {
+			switch param.Type {
+			case "", "string", "integer", "number", "boolean":
+			default:
+				return nil, nil, fmt.Errorf("unsupported type of query parameter %s: %s", name, param.Type)
+			}
+		}
+	}
+	if v, ok := from.Config[endpoint.FromConfigKeyResponse]; ok && v != nil {
+		mapping = &ResponseMapping{}
+		if err := maps.Map2Struct(v, mapping); err != nil {
+			return nil, nil, fmt.Errorf("invalid %s configuration: %w", endpoint.FromConfigKeyResponse, err)
+		}
+	}
+	return contract, mapping, nil
+}
+
+// limitedBody 记录请求体读取错误的 http.MaxBytesReader，用于判断请求体是否超过最大长度
+type limitedBody struct {
+	io.ReadCloser
+	err error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && b.err == nil {
+		b.err = err
+	}
+	return n, err
+}
+
+// limitBody 在处理器读取请求体前限制请求体长度，超过长度的请求在校验时拒绝
+func (c *RequestContract) limitBody(request *RequestMessage, w http.ResponseWriter) *limitedBody {
+	r := request.request
+	if c.MaxBodySize <= 0 || r.Body == nil {
+		return nil
+	}
+	body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, c.MaxBodySize)}
+	r.Body = body
+	return body
+}
+
+// router 返回在 From 处理器（例如认证处理器）通过后才校验请求约定的路由，
+// 未通过处理器的请求由处理器响应，不会暴露约定的校验错误
+func (c *RequestContract) router(router endpoint.Router, request *RequestMessage, w http.ResponseWriter) endpoint.Router {
+	body := c.limitBody(request, w)
+	return &contractRouter{Router: router, validate: func() bool {
+		if errs := c.validate(request, body); len(errs) > 0 {
+			writeValidationErrors(w, errs)
+			return false
+		}
+		return true
+	}}
+}
+
+// contractRouter 在 From 处理器之后校验请求约定的路由
+type contractRouter struct {
+	endpoint.Router
+	validate func() bool
+}
+
+func (r *contractRouter) GetFrom() endpoint.From {
+	from := r.Router.GetFrom()
+	if from == nil {
+		return nil
+	}
+	return &contractFrom{From: from, router: r.Router, validate: r.validate}
+}
+
+// contractFrom 在处理器通过后校验请求约定的 From
+type contractFrom struct {
+	endpoint.From
+	// router 原始路由，处理器通过它读取 From 配置
+	router   endpoint.Router
+	validate func() bool
+}
+
+func (f *contractFrom) ExecuteProcess(_ endpoint.Router, exchange *endpoint.Exchange) bool {
+	if !f.From.ExecuteProcess(f.router, exchange) {
+		return false
+	}
+	return f.validate()
+}
+
+// validate 校验请求，返回所有校验错误。查询参数的默认值和规范格式写入请求URL和消息元数据。
+// body 是 limitBody 限制长度后的请求体，没有配置最大长度时为nil
+func (c *RequestContract) validate(request *RequestMessage, body *limitedBody) []ValidationError {
+	var errs []ValidationError
+	r := request.request
+	for _, header := range c.Headers {
+		if r.Header.Get(header) == "" {
+			errs = append(errs, ValidationError{In: ErrorInHeader, Field: header, Message: "is required"})
+		}
+	}
+
+	if len(c.Query) > 0 {
+		query := r.URL.Query()
+		//按参数名排序，保证错误顺序稳定
+		names := make([]string, 0, len(c.Query))
+		for name := range c.Query {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			param := c.Query[name]
+			value := query.Get(name)
+			if value == "" {
+				if param.Default == "" {
+					if param.Required {
+						errs = append(errs, ValidationError{In: ErrorInQuery, Field: name, Message: "is required"})
+					}
+					continue
+				}
+				value = param.Default
+			}
+			if v, err := coerceQueryParam(value, param.Type); err != nil {
+				errs = append(errs, ValidationError{In: ErrorInQuery, Field: name, Message: err.Error()})
+			} else {
+				query.Set(name, v)
+				request.Metadata.PutValue(name, v)
+			}
+		}
+		r.URL.RawQuery = query.Encode()
+	}
+
+	if body != nil {
+		if r.ContentLength > c.MaxBodySize {
+			return append(errs, bodyTooLarge(c.MaxBodySize))
+		}
+		if !request.streamBody {
+			//请求体可能已经被处理器读取，读取错误由 limitedBody 记录
+			request.Body()
+			if body.err != nil {
+				return append(errs, bodyTooLarge(c.MaxBodySize))
+			}
+		}
+	}
+
+	if c.BodySchema != nil && !request.streamBody && r.Method != http.MethodGet {
+		var data interface{}
+		if body := request.Body(); len(body) > 0 {
+			if err := json.Unmarshal(body, &data); err != nil {
+				return append(errs, ValidationError{In: ErrorInBody, Message: "invalid JSON: " + err.Error()})
+			}
+		}
+		for _, err := range c.BodySchema.Validate(data) {
+			errs = append(errs, ValidationError{In: ErrorInBody, Field: err.Field, Message: err.Message})
+		}
+	}
+	return errs
+}
+
+// bodyTooLarge 请求体超过最大长度错误
+func bodyTooLarge(maxBodySize int64) ValidationError {
+	return ValidationError{In: ErrorInBody, Message: fmt.Sprintf("exceeds max body size %d bytes", maxBodySize)}
+}
+
+// coerceQueryParam 校验查询参数类型，并转换成规范格式
+func coerceQueryParam(value, paramType string) (string, error) {
+	switch paramType {
+	case "integer":
+		v, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("expected integer, got %q", value)
+		}
+		return strconv.FormatInt(v, 10), nil
+	case "number":
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return "", fmt.Errorf("expected number, got %q", value)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case "boolean":
+		v, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return "", fmt.Errorf("expected boolean, got %q", value)
+		}
+		return strconv.FormatBool(v), nil
+	default:
+		return value, nil
+	}
+}
+
+// writeValidationErrors 返回400和校验错误
+func writeValidationErrors(w http.ResponseWriter, errs []ValidationError) {
+	body, _ := json.Marshal(ValidationErrors{Error: "invalid request", Errors: errs})
+	w.Header().Set(ContentTypeKey, JsonContextType)
+	w.WriteHeader(http.StatusBadRequest)
+	_, _ = w.Write(body)
+}
+
+// apply 根据输出消息元数据设置响应头，返回元数据中的状态码，没有配置或者不合法返回0
+func (m *ResponseMapping) apply(msg *types.RuleMsg, header http.Header) int {
+	if m == nil || msg == nil || msg.Metadata == nil {
+		return 0
+	}
+	for key, metadataKey := range m.Headers {
+		if v := msg.Metadata.GetValue(metadataKey); v != "" {
+			header.Set(key, v)
+		}
+	}
+	if m.StatusCode == "" {
+		return 0
+	}
+	statusCode, err := strconv.Atoi(msg.Metadata.GetValue(m.StatusCode))
+	if err != nil || statusCode < 100 || statusCode > 599 {
+		return 0
+	}
+	return statusCode
+}
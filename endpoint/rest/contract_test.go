@@ -0,0 +1,222 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/builtin/processor"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/json"
+)
+
+var contractChain = `{
+  "ruleChain": {"id": "contractChain", "name": "contractChain"},
+  "metadata": {
+    "nodes": [
+      {
+        "id": "s1",
+        "type": "jsTransform",
+        "configuration": {
+          "jsScript": "metadata['httpStatus']='201'; metadata['total']='2'; return {'msg':{'qos':metadata['qos'],'data':msg},'metadata':metadata,'msgType':msgType};"
+        }
+      }
+    ]
+  }
+}`
+
+func TestRequestContract(t *testing.T) {
+	config := engine.NewConfig(types.WithDefaultPool())
+	_, err := engine.New("contractChain", []byte(contractChain), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("contractChain")
+
+	restEndpoint := &Endpoint{}
+	assert.Nil(t, restEndpoint.Init(config, types.Configuration{"server": ":9095"}))
+	defer restEndpoint.Destroy()
+
+	responseToBody, _ := processor.OutBuiltins.Get("responseToBody")
+	fromConfig := types.Configuration{
+		endpoint.FromConfigKeyRequest: map[string]interface{}{
+			"maxBodySize": 64,
+			"headers":     []string{"X-Device-Token"},
+			"query": map[string]interface{}{
+				"qos": map[string]interface{}{"type": "integer", "default": "0"},
+			},
+			"bodySchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"temperature": map[string]interface{}{"type": "number"},
+				},
+				"required": []string{"temperature"},
+			},
+		},
+		endpoint.FromConfigKeyResponse: map[string]interface{}{
+			"statusCode": "httpStatus",
+			"headers":    map[string]string{"X-Total-Count": "total"},
+		},
+	}
+	router := impl.NewRouter().From("/api/v1/devices/:id/telemetry", fromConfig).
+		To("chain:contractChain").Wait().Process(responseToBody).End()
+	_, err = restEndpoint.AddRouter(router, "POST")
+	assert.Nil(t, err)
+
+	//认证处理器在请求约定校验之前执行
+	secureRouter := impl.NewRouter().From("/api/v1/secure", fromConfig).Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		//读取请求体，仍然受最大长度限制
+		_ = exchange.In.GetMsg()
+		if exchange.In.Headers().Get("Authorization") != "secret" {
+			exchange.Out.SetStatusCode(http.StatusUnauthorized)
+			exchange.Out.SetBody([]byte("unauthorized"))
+			return false
+		}
+		return true
+	}).To("chain:contractChain").Wait().Process(responseToBody).End()
+	_, err = restEndpoint.AddRouter(secureRouter, "POST")
+	assert.Nil(t, err)
+
+	//不支持的查询参数类型
+	_, err = restEndpoint.AddRouter(impl.NewRouter().From("/api/v1/invalid", types.Configuration{
+		endpoint.FromConfigKeyRequest: map[string]interface{}{
+			"query": map[string]interface{}{"qos": map[string]interface{}{"type": "date"}},
+		},
+	}).To("chain:contractChain").End(), "GET")
+	assert.Equal(t, "unsupported type of query parameter qos: date", err.Error())
+
+	assert.Nil(t, restEndpoint.Start())
+	time.Sleep(time.Millisecond * 200)
+
+	var authorization string
+	post := func(url, token, body string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodPost, "http://127.0.0.1:9095"+url, strings.NewReader(body))
+		assert.Nil(t, err)
+		req.Header.Set(ContentTypeKey, JsonContextType)
+		if token != "" {
+			req.Header.Set("X-Device-Token", token)
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+		data, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		return resp, string(data)
+	}
+	validationErrors := func(body string) []ValidationError {
+		var result ValidationErrors
+		assert.Nil(t, json.Unmarshal([]byte(body), &result))
+		assert.Equal(t, "invalid request", result.Error)
+		return result.Errors
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		resp, body := post("/api/v1/devices/d1/telemetry?qos=01", "token", `{"temperature":21.5}`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "2", resp.Header.Get("X-Total-Count"))
+		var result map[string]interface{}
+		assert.Nil(t, json.Unmarshal([]byte(body), &result))
+		assert.Equal(t, "1", result["qos"])
+
+		//使用查询参数默认值
+		resp, body = post("/api/v1/devices/d1/telemetry", "token", `{"temperature":21.5}`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Nil(t, json.Unmarshal([]byte(body), &result))
+		assert.Equal(t, "0", result["qos"])
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		resp, body := post("/api/v1/devices/d1/telemetry?qos=high", "", `{"temperature":"hot"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, JsonContextType, resp.Header.Get(ContentTypeKey))
+		assert.Equal(t, []ValidationError{
+			{In: ErrorInHeader, Field: "X-Device-Token", Message: "is required"},
+			{In: ErrorInQuery, Field: "qos", Message: `expected integer, got "high"`},
+			{In: ErrorInBody, Field: "temperature", Message: "expected number, got string"},
+		}, validationErrors(body))
+
+		resp, body = post("/api/v1/devices/d1/telemetry", "token", `{"temperature":`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		errs := validationErrors(body)
+		assert.Equal(t, 1, len(errs))
+		assert.True(t, strings.HasPrefix(errs[0].Message, "invalid JSON"))
+
+		resp, body = post("/api/v1/devices/d1/telemetry", "token", `{}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, []ValidationError{{In: ErrorInBody, Field: "temperature", Message: "is required"}}, validationErrors(body))
+	})
+
+	t.Run("MaxBodySize", func(t *testing.T) {
+		resp, body := post("/api/v1/devices/d1/telemetry", "token", `{"temperature":21.5,"comment":"`+strings.Repeat("a", 64)+`"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, []ValidationError{{In: ErrorInBody, Message: "exceeds max body size 64 bytes"}}, validationErrors(body))
+	})
+
+	t.Run("AfterProcessors", func(t *testing.T) {
+		//未通过认证的请求由认证处理器响应，不返回约定校验错误
+		resp, body := post("/api/v1/secure?qos=high", "", `{"temperature":"hot"}`)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, "unauthorized", body)
+
+		authorization = "secret"
+		defer func() { authorization = "" }()
+		resp, body = post("/api/v1/secure?qos=high", "token", `{"temperature":21.5}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, []ValidationError{{In: ErrorInQuery, Field: "qos", Message: `expected integer, got "high"`}}, validationErrors(body))
+
+		resp, body = post("/api/v1/secure", "token", `{"temperature":21.5,"comment":"`+strings.Repeat("a", 64)+`"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, []ValidationError{{In: ErrorInBody, Message: "exceeds max body size 64 bytes"}}, validationErrors(body))
+
+		//没有Content-Length的分块请求体，由处理器读取时截断，校验时拒绝
+		req, err := http.NewRequest(http.MethodPost, "http://127.0.0.1:9095/api/v1/secure",
+			io.NopCloser(strings.NewReader(`{"temperature":21.5,"comment":"`+strings.Repeat("a", 64)+`"}`)))
+		assert.Nil(t, err)
+		req.Header.Set("X-Device-Token", "token")
+		req.Header.Set("Authorization", "secret")
+		resp, err = http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+		data, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, []ValidationError{{In: ErrorInBody, Message: "exceeds max body size 64 bytes"}}, validationErrors(string(data)))
+
+		resp, _ = post("/api/v1/secure", "token", `{"temperature":21.5}`)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+}
+
+func TestResponseMapping(t *testing.T) {
+	mapping := &ResponseMapping{StatusCode: "status", Headers: map[string]string{"X-Result": "result"}}
+	msg := types.NewMsg(0, "", types.JSON, types.BuildMetadata(map[string]string{"status": "404", "result": "notFound"}), "")
+	header := http.Header{}
+	assert.Equal(t, 404, mapping.apply(&msg, header))
+	assert.Equal(t, "notFound", header.Get("X-Result"))
+
+	msg.Metadata.PutValue("status", "ok")
+	assert.Equal(t, 0, mapping.apply(&msg, header))
+	assert.Equal(t, 0, (*ResponseMapping)(nil).apply(&msg, header))
+	assert.Equal(t, 0, mapping.apply(nil, header))
+}
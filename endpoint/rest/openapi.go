@@ -0,0 +1,330 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// OpenAPI related keys of RouterDsl.AdditionalInfo, used to describe a route in the generated document:
+//
+// RouterDsl.AdditionalInfo 中与 OpenAPI 相关的键，用于在生成的文档中描述路由：
+//
+//	{
+//	  "id": "getDevice",
+//	  "params": ["GET"],
+//	  "from": {"path": "/api/v1/devices/:id"},
+//	  "to": {"path": "chain:device", "wait": true},
+//	  "additionalInfo": {
+//	    "summary": "Get device",
+//	    "tags": ["device"],
+//	    "responseSchema": {"type": "object", "properties": {"name": {"type": "string"}}}
+//	  }
+//	}
+const (
+	// AdditionalInfoKeySummary 路由摘要
+	AdditionalInfoKeySummary = "summary"
+	// AdditionalInfoKeyDescription 路由描述
+	AdditionalInfoKeyDescription = "description"
+	// AdditionalInfoKeyTags 路由标签
+	AdditionalInfoKeyTags = "tags"
+	// AdditionalInfoKeyRequestSchema 请求JSON Schema，没有配置则使用目标规则链 ruleChain.additionalInfo.inputSchema
+	AdditionalInfoKeyRequestSchema = "requestSchema"
+	// AdditionalInfoKeyResponseSchema 响应JSON Schema
+	AdditionalInfoKeyResponseSchema = "responseSchema"
+	// ChainAdditionalInfoKeyInputSchema 规则链输入参数JSON Schema
+	ChainAdditionalInfoKeyInputSchema = "inputSchema"
+)
+
+const (
+	// OpenApiVersion 生成的OpenAPI文档版本
+	OpenApiVersion    = "3.0.3"
+	defaultApiTitle   = "RuleGo API"
+	defaultApiVersion = "1.0.0"
+)
+
+// OpenApi generates the OpenAPI 3 document of all enabled routers.
+// Request schemas are read from RouterDsl.AdditionalInfo["requestSchema"] or inferred
+// from ruleChain.additionalInfo.inputSchema of the target chain.
+//
+// OpenApi 生成所有可用路由的 OpenAPI 3 文档。
+// 请求Schema从 RouterDsl.AdditionalInfo["requestSchema"] 读取，没有配置则从目标规则链的 ruleChain.additionalInfo.inputSchema 推断。
+func (rest *Rest) OpenApi() map[string]interface{} {
+	rest.RLock()
+	var routers []endpoint.Router
+	for _, router := range rest.RouterStorage {
+		if !router.IsDisable() {
+			routers = append(routers, router)
+		}
+	}
+	rest.RUnlock()
+	//保证operationId等输出顺序稳定
+	sort.Slice(routers, func(i, j int) bool {
+		return routers[i].GetId() < routers[j].GetId()
+	})
+
+	paths := make(map[string]interface{})
+	for _, router := range routers {
+		method := http.MethodGet
+		if params := router.GetParams(); len(params) > 0 {
+			method = strings.ToUpper(str.ToString(params[0]))
+		}
+		path, pathParams := rest.openApiPath(strings.TrimSpace(router.FromToString()))
+		item, ok := paths[path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[path] = item
+		}
+		item[strings.ToLower(method)] = rest.openApiOperation(router, method, pathParams)
+	}
+	title := rest.Config.OpenApiTitle
+	if title == "" {
+		title = defaultApiTitle
+	}
+	version := rest.Config.OpenApiVersion
+	if version == "" {
+		version = defaultApiVersion
+	}
+	return map[string]interface{}{
+		"openapi": OpenApiVersion,
+		"info": map[string]interface{}{
+			"title":   title,
+			"version": version,
+		},
+		"paths": paths,
+	}
+}
+
+// openApiHandler 提供OpenAPI文档的HTTP处理器
+func (rest *Rest) openApiHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	doc, err := json.Marshal(rest.OpenApi())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(ContentTypeKey, JsonContextType)
+	if rest.Config.AllowCors {
+		w.Header().Set(HeaderKeyAccessControlAllowOrigin, HeaderValueAll)
+	}
+	_, _ = w.Write(doc)
+}
+
+// openApiPath 把路由路径转换成OpenAPI路径，例如：/api/:id/*file 转换成 /api/{id}/{file}，并返回路径参数
+func (rest *Rest) openApiPath(path string) (string, []string) {
+	segments := strings.Split(rest.convertPathParams(path), "/")
+	var params []string
+	for i, segment := range segments {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// openApiOperation 生成路由的OpenAPI operation
+func (rest *Rest) openApiOperation(router endpoint.Router, method string, pathParams []string) map[string]interface{} {
+	var additionalInfo map[string]interface{}
+	if def := router.Definition(); def != nil {
+		additionalInfo = def.AdditionalInfo
+	}
+	operation := map[string]interface{}{
+		"operationId": router.GetId(),
+	}
+	for _, key := range []string{AdditionalInfoKeySummary, AdditionalInfoKeyDescription, AdditionalInfoKeyTags} {
+		if v, ok := additionalInfo[key]; ok {
+			operation[key] = v
+		}
+	}
+
+	var parameters []interface{}
+	for _, name := range pathParams {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	//请求约定中的请求头和查询参数
+	contract, _, _ := routerContract(router)
+	if contract != nil {
+		for _, header := range contract.Headers {
+			parameters = append(parameters, map[string]interface{}{
+				"name":     header,
+				"in":       "header",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+		names := make([]string, 0, len(contract.Query))
+		for name := range contract.Query {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			param := contract.Query[name]
+			paramSchema := map[string]interface{}{"type": "string"}
+			if param.Type != "" {
+				paramSchema["type"] = param.Type
+			}
+			if param.Default != "" {
+				paramSchema["default"] = param.Default
+			}
+			parameters = append(parameters, map[string]interface{}{
+				"name":     name,
+				"in":       "query",
+				"required": param.Required,
+				"schema":   paramSchema,
+			})
+		}
+	}
+	//请求Schema优先级：路由定义 requestSchema、请求约定 bodySchema、目标规则链 inputSchema
+	requestSchema, ok := additionalInfo[AdditionalInfoKeyRequestSchema]
+	if !ok {
+		if requestSchema = contractBodySchema(router); requestSchema == nil {
+			requestSchema = chainInputSchema(router)
+		}
+	}
+	if requestSchema != nil {
+		if hasRequestBody(method) {
+			operation["requestBody"] = map[string]interface{}{
+				"content": map[string]interface{}{
+					JsonContextType: map[string]interface{}{"schema": requestSchema},
+				},
+			}
+		} else {
+			//没有请求体的方法，参数通过url?参数传递
+			parameters = append(parameters, queryParameters(requestSchema, pathParams)...)
+		}
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	response := map[string]interface{}{"description": "OK"}
+	if responseSchema, ok := additionalInfo[AdditionalInfoKeyResponseSchema]; ok {
+		response["content"] = map[string]interface{}{
+			JsonContextType: map[string]interface{}{"schema": responseSchema},
+		}
+	}
+	operation["responses"] = map[string]interface{}{"200": response}
+	return operation
+}
+
+// chainInputSchema 获取路由目标规则链的 inputSchema，目标不是规则链或者规则链不存在返回nil
+func chainInputSchema(router endpoint.Router) interface{} {
+	r, ok := router.(*impl.Router)
+	if !ok || r.RuleGo == nil || router.GetFrom() == nil {
+		return nil
+	}
+	to, ok := router.GetFrom().GetTo().(*impl.To)
+	if !ok || to.HasVars {
+		return nil
+	}
+	var chainId string
+	if strings.HasPrefix(to.To, "chain:") {
+		chainId = to.ToPath
+	} else if _, ok := impl.DefaultExecutorFactory.New(strings.Split(to.To, ":")[0]); !ok {
+		//没有指定执行器，默认是规则链
+		chainId = to.ToPath
+	}
+	//去掉节点ID，例如：chainId:nodeId
+	chainId = strings.Split(chainId, ":")[0]
+	if chainId == "" {
+		return nil
+	}
+	ruleEngine, ok := r.RuleGo.Get(chainId)
+	if !ok {
+		return nil
+	}
+	schema, _ := ruleEngine.Definition().RuleChain.GetAdditionalInfo(ChainAdditionalInfoKeyInputSchema)
+	return schema
+}
+
+// contractBodySchema 获取路由请求约定中原始的 bodySchema 配置
+func contractBodySchema(router endpoint.Router) interface{} {
+	from, ok := router.GetFrom().(*impl.From)
+	if !ok {
+		return nil
+	}
+	switch request := from.Config[endpoint.FromConfigKeyRequest].(type) {
+	case map[string]interface{}:
+		return request["bodySchema"]
+	case types.Configuration:
+		return request["bodySchema"]
+	default:
+		return nil
+	}
+}
+
+// hasRequestBody 请求方法是否有请求体
+func hasRequestBody(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+// queryParameters 把对象Schema的属性转换成url?参数，路径参数除外
+func queryParameters(schema interface{}, pathParams []string) []interface{} {
+	schemaMap, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	properties, ok := schemaMap["properties"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	required := make(map[string]bool)
+	if items, ok := schemaMap["required"].([]interface{}); ok {
+		for _, item := range items {
+			required[str.ToString(item)] = true
+		}
+	}
+	var names []string
+	for name := range properties {
+		if !str.Contains(pathParams, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var parameters []interface{}
+	for _, name := range names {
+		parameter := map[string]interface{}{
+			"name":   name,
+			"in":     "query",
+			"schema": properties[name],
+		}
+		if required[name] {
+			parameter["required"] = true
+		}
+		parameters = append(parameters, parameter)
+	}
+	return parameters
+}
@@ -0,0 +1,182 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rest
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/json"
+)
+
+var openApiChain = `{
+  "ruleChain": {
+    "id": "openApiChain",
+    "name": "openApiChain",
+    "additionalInfo": {
+      "inputSchema": {
+        "type": "object",
+        "properties": {"temperature": {"type": "number"}},
+        "required": ["temperature"]
+      }
+    }
+  },
+  "metadata": {
+    "nodes": [
+      {"id": "s1", "type": "log", "configuration": {}}
+    ]
+  }
+}`
+
+func TestOpenApiPath(t *testing.T) {
+	rest := &Rest{}
+	path, params := rest.openApiPath("/api/v1/devices/:id/files/*filepath")
+	assert.Equal(t, "/api/v1/devices/{id}/files/{filepath}", path)
+	assert.Equal(t, []string{"id", "filepath"}, params)
+
+	path, params = rest.openApiPath("/api/v1/{chainId}/{msgType}")
+	assert.Equal(t, "/api/v1/{chainId}/{msgType}", path)
+	assert.Equal(t, []string{"chainId", "msgType"}, params)
+
+	path, params = rest.openApiPath("/api/v1/devices")
+	assert.Equal(t, "/api/v1/devices", path)
+	assert.Equal(t, 0, len(params))
+}
+
+func TestOpenApi(t *testing.T) {
+	config := engine.NewConfig(types.WithDefaultPool())
+	_, err := engine.New("openApiChain", []byte(openApiChain), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer engine.Del("openApiChain")
+
+	restEndpoint := &Endpoint{}
+	err = restEndpoint.Init(config, types.Configuration{
+		"server":         ":9096",
+		"openApiPath":    "/api/openapi.json",
+		"openApiTitle":   "Device API",
+		"openApiVersion": "2.0.0",
+	})
+	assert.Nil(t, err)
+	defer restEndpoint.Destroy()
+
+	//请求Schema从目标规则链推断
+	router1 := impl.NewRouter(endpoint.RouterOptions.WithDefinition(&types.RouterDsl{
+		AdditionalInfo: map[string]interface{}{
+			AdditionalInfoKeySummary: "Report telemetry",
+			AdditionalInfoKeyTags:    []string{"device"},
+			AdditionalInfoKeyResponseSchema: map[string]interface{}{
+				"type": "object",
+			},
+		},
+	})).SetId("reportTelemetry").From("/api/v1/devices/{id}/telemetry").To("chain:openApiChain").Wait().End()
+	_, err = restEndpoint.AddRouter(router1, "POST")
+	assert.Nil(t, err)
+
+	//请求Schema从路由定义读取，GET请求转换成url?参数
+	router2 := impl.NewRouter(endpoint.RouterOptions.WithDefinition(&types.RouterDsl{
+		AdditionalInfo: map[string]interface{}{
+			AdditionalInfoKeyRequestSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id":   map[string]interface{}{"type": "string"},
+					"page": map[string]interface{}{"type": "integer"},
+				},
+				"required": []interface{}{"page"},
+			},
+		},
+	})).SetId("listTelemetry").From("/api/v1/devices/:id/telemetry").To("chain:openApiChain").End()
+	_, err = restEndpoint.AddRouter(router2, "GET")
+	assert.Nil(t, err)
+
+	//请求Schema和参数从请求约定读取
+	router3 := impl.NewRouter().SetId("updateDevice").From("/api/v1/devices/:id", types.Configuration{
+		endpoint.FromConfigKeyRequest: map[string]interface{}{
+			"headers":    []string{"X-Device-Token"},
+			"query":      map[string]interface{}{"qos": map[string]interface{}{"type": "integer", "default": "0"}},
+			"bodySchema": map[string]interface{}{"type": "object"},
+		},
+	}).To("chain:openApiChain").End()
+	_, err = restEndpoint.AddRouter(router3, "PUT")
+	assert.Nil(t, err)
+
+	//已删除的路由不出现在文档中
+	router4 := impl.NewRouter().From("/api/v1/removed").To("chain:openApiChain").End()
+	router4Id, err := restEndpoint.AddRouter(router4, "DELETE")
+	assert.Nil(t, err)
+	assert.Nil(t, restEndpoint.RemoveRouter(router4Id))
+
+	assert.Nil(t, restEndpoint.Start())
+	time.Sleep(time.Millisecond * 200)
+
+	resp, err := http.Get("http://127.0.0.1:9096/api/openapi.json")
+	assert.Nil(t, err)
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, JsonContextType, resp.Header.Get(ContentTypeKey))
+
+	var doc map[string]interface{}
+	assert.Nil(t, json.Unmarshal(body, &doc))
+	assert.Equal(t, OpenApiVersion, doc["openapi"])
+	info := doc["info"].(map[string]interface{})
+	assert.Equal(t, "Device API", info["title"])
+	assert.Equal(t, "2.0.0", info["version"])
+
+	paths := doc["paths"].(map[string]interface{})
+	assert.Equal(t, 2, len(paths))
+	item := paths["/api/v1/devices/{id}/telemetry"].(map[string]interface{})
+
+	post := item["post"].(map[string]interface{})
+	assert.Equal(t, "reportTelemetry", post["operationId"])
+	assert.Equal(t, "Report telemetry", post["summary"])
+	assert.Equal(t, []interface{}{"device"}, post["tags"])
+	pathParam := post["parameters"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "id", pathParam["name"])
+	assert.Equal(t, "path", pathParam["in"])
+	assert.Equal(t, true, pathParam["required"])
+	requestSchema := post["requestBody"].(map[string]interface{})["content"].(map[string]interface{})[JsonContextType].(map[string]interface{})["schema"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"temperature"}, requestSchema["required"])
+	response := post["responses"].(map[string]interface{})["200"].(map[string]interface{})
+	assert.NotNil(t, response["content"])
+
+	get := item["get"].(map[string]interface{})
+	assert.Nil(t, get["requestBody"])
+	parameters := get["parameters"].([]interface{})
+	assert.Equal(t, 2, len(parameters))
+	queryParam := parameters[1].(map[string]interface{})
+	assert.Equal(t, "page", queryParam["name"])
+	assert.Equal(t, "query", queryParam["in"])
+	assert.Equal(t, true, queryParam["required"])
+
+	put := paths["/api/v1/devices/{id}"].(map[string]interface{})["put"].(map[string]interface{})
+	parameters = put["parameters"].([]interface{})
+	assert.Equal(t, 3, len(parameters))
+	assert.Equal(t, "header", parameters[1].(map[string]interface{})["in"])
+	assert.Equal(t, "X-Device-Token", parameters[1].(map[string]interface{})["name"])
+	assert.Equal(t, "qos", parameters[2].(map[string]interface{})["name"])
+	assert.Equal(t, map[string]interface{}{"type": "integer", "default": "0"}, parameters[2].(map[string]interface{})["schema"])
+	requestSchema = put["requestBody"].(map[string]interface{})["content"].(map[string]interface{})[JsonContextType].(map[string]interface{})["schema"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "object"}, requestSchema)
+}
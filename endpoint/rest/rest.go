@@ -162,6 +162,7 @@ import (
 	"github.com/rulego/rulego/api/types/endpoint"
 	nodeBase "github.com/rulego/rulego/components/base"
 	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/cast"
 	"github.com/rulego/rulego/utils/maps"
 	"github.com/rulego/rulego/utils/runtime"
 	"github.com/rulego/rulego/utils/str"
@@ -231,6 +232,8 @@ type RequestMessage struct {
 	err error
 	//消息元数据，用于存储额外的键值对信息  Message metadata for storing additional key-value information  消息元数据
 	Metadata *types.Metadata
+	//请求体以消息流形式传递，不读取到内存  Pass the request body as a message stream instead of reading it  请求体以消息流形式传递
+	streamBody bool
 }
 
 // Body returns the HTTP request body as a byte slice.
@@ -340,10 +343,12 @@ func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
 // • Other methods: Request body as data  其他方法：请求体作为数据
 // • Content-Type detection: JSON vs TEXT based on Content-Type header  内容类型检测：基于 Content-Type 头的 JSON vs TEXT
 // • Metadata: Additional request information  元数据：额外的请求信息
+// • Stream body: With the streamBody router option, the unread body is attached as a message stream  流式请求体：配置 streamBody 时，未读取的请求体作为消息流传递
 func (r *RequestMessage) GetMsg() *types.RuleMsg {
 	if r.msg == nil {
 		dataType := types.TEXT
 		var data string
+		var stream bool
 		if r.request != nil && r.request.Method == http.MethodGet {
 			dataType = types.JSON
 			data = str.ToString(r.request.URL.Query())
@@ -351,12 +356,19 @@ func (r *RequestMessage) GetMsg() *types.RuleMsg {
 			if contentType := r.Headers().Get(ContentTypeKey); strings.HasPrefix(contentType, JsonContextType) {
 				dataType = types.JSON
 			}
-			data = string(r.Body())
+			if r.streamBody && r.body == nil && r.request != nil && r.request.Body != nil {
+				stream = true
+			} else {
+				data = string(r.Body())
+			}
 		}
 		if r.Metadata == nil {
 			r.Metadata = types.NewMetadata()
 		}
 		ruleMsg := types.NewMsg(0, r.From(), dataType, r.Metadata, data)
+		if stream {
+			ruleMsg.SetStream(r.request.Body, r.request.ContentLength)
+		}
 		r.msg = &ruleMsg
 	}
 	return r.msg
@@ -445,6 +457,10 @@ type ResponseMessage struct {
 	msg *types.RuleMsg
 	//响应处理过程中的错误  Error during response processing  响应处理错误
 	err error
+	//响应映射，写入响应前根据输出消息元数据设置状态码和响应头  Response mapping applied before the response is written  响应映射
+	mapping *ResponseMapping
+	//是否已经写入响应头  Whether the response header has been written  是否已经写入响应头
+	wroteHeader bool
 	//保护并发访问的互斥锁  Mutex protecting concurrent access  并发访问保护锁
 	mu sync.RWMutex
 }
@@ -560,6 +576,10 @@ func (r *ResponseMessage) SetStatusCode(statusCode int) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if r.response != nil {
+		if !r.wroteHeader {
+			r.wroteHeader = true
+			r.mapping.apply(r.msg, r.response.Header())
+		}
 		r.response.WriteHeader(statusCode)
 	}
 }
@@ -586,10 +606,44 @@ func (r *ResponseMessage) SetBody(body []byte) {
 	defer r.mu.Unlock()
 	r.body = body
 	if r.response != nil {
+		r.writeHeaderOnce()
 		_, _ = r.response.Write(body)
 	}
 }
 
+// WriteBody writes a chunk of a streaming body to the HTTP response and flushes it,
+// implementing endpoint.StreamWriter. The chunk is not kept in memory.
+//
+// WriteBody 把流式消息体的一块写入 HTTP 响应并刷新，实现 endpoint.StreamWriter。
+// 写入的数据不保存在内存中。
+func (r *ResponseMessage) WriteBody(chunk []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.response == nil {
+		r.body = append(r.body, chunk...)
+		return nil
+	}
+	r.writeHeaderOnce()
+	if _, err := r.response.Write(chunk); err != nil {
+		return err
+	}
+	if flusher, ok := r.response.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// writeHeaderOnce 首次写入时应用响应映射，调用方需要持有锁
+func (r *ResponseMessage) writeHeaderOnce() {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	if statusCode := r.mapping.apply(r.msg, r.response.Header()); statusCode > 0 {
+		r.response.WriteHeader(statusCode)
+	}
+}
+
 // SetError sets an error associated with this response message.
 // This is used for error tracking and debugging purposes.
 //
@@ -707,6 +761,20 @@ type Config struct {
 	// 当为 true 时，每个请求使用新连接，这可能影响性能，
 	// 但对于某些部署场景或调试可能有用。
 	DisableKeepalive bool `json:"disableKeepalive"`
+
+	// OpenApiPath specifies the path serving the OpenAPI 3 JSON document of all registered routers.
+	// Empty disables the document. Example: "/api/openapi.json"
+	// OpenApiPath 指定提供所有已注册路由 OpenAPI 3 JSON 文档的路径。
+	// 为空则不提供文档。示例："/api/openapi.json"
+	OpenApiPath string `json:"openApiPath"`
+
+	// OpenApiTitle specifies info.title of the OpenAPI document, default "RuleGo API".
+	// OpenApiTitle 指定 OpenAPI 文档的 info.title，默认 "RuleGo API"。
+	OpenApiTitle string `json:"openApiTitle"`
+
+	// OpenApiVersion specifies info.version of the OpenAPI document, default "1.0.0".
+	// OpenApiVersion 指定 OpenAPI 文档的 info.version，默认 "1.0.0"。
+	OpenApiVersion string `json:"openApiVersion"`
 }
 
 // Rest represents an HTTP/REST endpoint implementation for the RuleGo framework.
@@ -1034,6 +1102,11 @@ func (rest *Rest) addRouter(method string, routers ...endpoint.Router) error {
 	}
 	for _, item := range routers {
 		path := strings.TrimSpace(item.FromToString())
+		//请求约定和响应映射
+		contract, mapping, err := routerContract(item)
+		if err != nil {
+			return err
+		}
 		if id := item.GetId(); id == "" {
 			item.SetId(rest.RouterKey(method, path))
 		}
@@ -1051,14 +1124,19 @@ func (rest *Rest) addRouter(method string, routers ...endpoint.Router) error {
 				rest.newRouter()
 			}
 			isWait := false
+			streamBody := false
 			if from := item.GetFrom(); from != nil {
 				if to := from.GetTo(); to != nil {
 					isWait = to.IsWait()
 				}
+				//请求体只在处理函数返回前可读，所以只有同步等待的路由支持流式请求体
+				if f, ok := from.(*impl.From); ok && isWait {
+					streamBody = cast.ToBool(f.Config[endpoint.FromConfigKeyStreamBody])
+				}
 			}
 			// 转换路径参数格式：将 {id} 格式转换为 :id 格式
 			path = rest.convertPathParams(path)
-			rest.router.Handle(method, path, rest.handler(item, isWait))
+			rest.router.Handle(method, path, rest.handler(item, isWait, streamBody, contract, mapping))
 		}
 
 	}
@@ -1168,7 +1246,7 @@ func (rest *Rest) RouterKey(method string, from string) string {
 	return method + ":" + from
 }
 
-func (rest *Rest) handler(router endpoint.Router, isWait bool) httprouter.Handle {
+func (rest *Rest) handler(router endpoint.Router, isWait bool, streamBody bool, contract *RequestContract, mapping *ResponseMapping) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 		defer func() {
 			//捕捉异常
@@ -1181,16 +1259,19 @@ func (rest *Rest) handler(router endpoint.Router, isWait bool) httprouter.Handle
 			return
 		}
 		metadata := types.NewMetadata()
+		requestMessage := &RequestMessage{
+			request:    r,
+			response:   w,
+			Params:     params,
+			Metadata:   metadata,
+			streamBody: streamBody,
+		}
 		exchange := &endpoint.Exchange{
-			In: &RequestMessage{
-				request:  r,
-				response: w,
-				Params:   params,
-				Metadata: metadata,
-			},
+			In: requestMessage,
 			Out: &ResponseMessage{
 				request:  r,
 				response: w,
+				mapping:  mapping,
 			},
 		}
 
@@ -1208,12 +1289,17 @@ func (rest *Rest) handler(router endpoint.Router, isWait bool) httprouter.Handle
 			}
 
 		}
+		//在From处理器之后校验请求约定，不符合约定的请求不交给规则链处理
+		processRouter := router
+		if contract != nil {
+			processRouter = contract.router(router, requestMessage, w)
+		}
 		var ctx = r.Context()
 		if !isWait {
 			//异步不能使用request context，否则后续执行会取消
 			ctx = context.Background()
 		}
-		rest.DoProcess(ctx, router, exchange)
+		rest.DoProcess(ctx, processRouter, exchange)
 	}
 }
 
@@ -1269,6 +1355,10 @@ func (rest *Rest) newRouter() *httprouter.Router {
 		}
 		rest.Interceptors = append(rest.Interceptors, corsInterceptor)
 	}
+	//提供OpenAPI文档
+	if rest.Config.OpenApiPath != "" {
+		rest.router.GET(rest.Config.OpenApiPath, rest.openApiHandler)
+	}
 	return rest.router
 }
 
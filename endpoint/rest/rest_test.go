@@ -4,15 +4,19 @@ import (
 	"fmt"
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/builtin/processor"
 	"github.com/rulego/rulego/components/action"
 	"github.com/rulego/rulego/endpoint/impl"
 	"github.com/rulego/rulego/engine"
 	"github.com/rulego/rulego/test"
 	"github.com/rulego/rulego/test/assert"
 	"github.com/rulego/rulego/utils/maps"
+	"io"
 	"net/http"
 	"os"
 	"reflect"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -336,3 +340,83 @@ func startServer(t *testing.T, stop chan struct{}, wg *sync.WaitGroup) {
 	restEndpoint.Destroy()
 	wg.Done()
 }
+
+// streamCountNode 测试用组件，读取消息流并返回读取的字节数
+type streamCountNode struct{}
+
+func (n *streamCountNode) New() types.Node { return &streamCountNode{} }
+
+func (n *streamCountNode) Type() string { return "test/streamCount" }
+
+func (n *streamCountNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	return nil
+}
+
+func (n *streamCountNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	if !msg.HasStream() {
+		msg.SetStream(strings.NewReader("buffered:"+strconv.Itoa(len(msg.GetData()))), -1)
+		ctx.TellSuccess(msg)
+		return
+	}
+	reader, err := msg.TakeStream()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	defer reader.Close()
+	count, err := io.Copy(io.Discard, reader)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetStream(strings.NewReader("streamed:"+strconv.FormatInt(count, 10)), -1)
+	ctx.TellSuccess(msg)
+}
+
+func (n *streamCountNode) Destroy() {}
+
+func TestRestStreamBody(t *testing.T) {
+	config := engine.NewConfig(types.WithDefaultPool())
+	var nodeConfig = make(types.Configuration)
+	_ = maps.Map2Struct(&Config{
+		Server: ":9097",
+	}, &nodeConfig)
+	restEndpoint := &Endpoint{}
+	err := restEndpoint.Init(config, nodeConfig)
+	assert.Nil(t, err)
+	defer restEndpoint.Destroy()
+
+	responseToBody, ok := processor.OutBuiltins.Get("responseToBody")
+	assert.True(t, ok)
+	streamConfig := types.Configuration{endpoint.FromConfigKeyStreamBody: true}
+	//同步等待的路由，请求体以消息流传递
+	router1 := impl.NewRouter().From("/api/v1/upload", streamConfig).
+		ToComponent(&streamCountNode{}).Wait().Process(responseToBody).End()
+	_, err = restEndpoint.AddRouter(router1, "POST")
+	assert.Nil(t, err)
+	assert.Nil(t, restEndpoint.Start())
+	time.Sleep(time.Millisecond * 200)
+
+	payload := strings.Repeat("a", 1<<20)
+	resp, err := http.Post("http://127.0.0.1:9097/api/v1/upload", "application/octet-stream", strings.NewReader(payload))
+	assert.Nil(t, err)
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, "streamed:"+strconv.Itoa(len(payload)), string(body))
+
+	//未开启流式请求体时读取整个请求体
+	var requestMsg = &RequestMessage{streamBody: false, request: httptestRequest(payload)}
+	assert.False(t, requestMsg.GetMsg().HasStream())
+	assert.Equal(t, payload, requestMsg.GetMsg().GetData())
+
+	requestMsg = &RequestMessage{streamBody: true, request: httptestRequest(payload)}
+	assert.True(t, requestMsg.GetMsg().HasStream())
+	assert.Equal(t, "", requestMsg.GetMsg().GetData())
+	assert.Equal(t, int64(len(payload)), requestMsg.GetMsg().GetStream().Size())
+}
+
+func httptestRequest(body string) *http.Request {
+	req, _ := http.NewRequest(http.MethodPost, "http://127.0.0.1/upload", strings.NewReader(body))
+	return req
+}
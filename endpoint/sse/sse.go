@@ -0,0 +1,606 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package sse provides a Server-Sent Events endpoint implementation for the RuleGo framework.
+// Browsers and other HTTP clients subscribe to a channel with a plain GET request and
+// receive the events that rule chains publish to that channel with the ssePublish component.
+//
+// Key components in this package include:
+// - Endpoint (alias Sse): Implements the SSE server and subscription handling
+// - RequestMessage: Represents the subscription request
+// - ResponseMessage: Writes events directly to the subscribed client
+//
+// Each router subscribes its clients to a channel, by default the request path.
+// The endpoint sends heartbeat comments to keep idle connections open, replays buffered
+// events after the Last-Event-ID sent by reconnecting clients, and only delivers events
+// whose metadata matches the filter parameters of the client.
+//
+// Package sse 提供 RuleGo 框架的 Server-Sent Events 端点实现。
+// 浏览器和其他HTTP客户端通过普通GET请求订阅通道，接收规则链通过 ssePublish 组件发布到该通道的事件。
+// 每个路由把客户端订阅到一个通道，默认为请求路径。端点定时发送心跳注释保持空闲连接，
+// 对重连客户端补发 Last-Event-ID 之后的缓存事件，并且只发送元数据匹配客户端过滤参数的事件。
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/endpoint/rest"
+	"github.com/rulego/rulego/utils/el"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/runtime"
+	"github.com/rulego/rulego/utils/sse"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// Type 组件类型
+const Type = types.EndpointTypePrefix + "sse"
+
+const (
+	// ContentType SSE响应内容类型
+	ContentType = "text/event-stream"
+	// LastEventIdHeader 客户端重连时携带的最后接收事件ID请求头
+	LastEventIdHeader = "Last-Event-ID"
+	// LastEventIdParam 无法设置请求头的客户端可以通过该url参数指定最后接收的事件ID
+	LastEventIdParam = "lastEventId"
+	// FromConfigKeyChannel 订阅通道的 From 配置键，支持 ${key} 读取路径参数、url参数和元数据，默认为请求路径
+	FromConfigKeyChannel = "channel"
+	// FromConfigKeyFilter 过滤参数的 From 配置键，客户端只接收元数据与这些参数值相等的事件，
+	// 缺少任意过滤参数的订阅被拒绝
+	FromConfigKeyFilter = "filter"
+	// KeyChannel 订阅的通道写入的元数据键
+	KeyChannel = "channel"
+)
+
+// Endpoint 别名
+type Endpoint = Sse
+
+// RequestMessage SSE订阅请求消息
+type RequestMessage struct {
+	request *http.Request
+	body    []byte
+	//路径参数
+	Params httprouter.Params
+	msg    *types.RuleMsg
+	err    error
+}
+
+func (r *RequestMessage) Body() []byte {
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.request == nil {
+		return nil
+	}
+	return textproto.MIMEHeader(r.request.Header)
+}
+
+func (r *RequestMessage) From() string {
+	if r.request == nil {
+		return ""
+	}
+	return r.request.URL.String()
+}
+
+func (r *RequestMessage) GetParam(key string) string {
+	if r.request == nil {
+		return ""
+	}
+	if v := r.Params.ByName(key); v == "" {
+		return r.request.FormValue(key)
+	} else {
+		return v
+	}
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		//订阅请求没有请求体，默认使用url参数作为消息负荷
+		data := string(r.body)
+		if r.body == nil && r.request != nil {
+			data = str.ToString(r.request.URL.Query())
+		}
+		ruleMsg := types.NewMsg(0, r.From(), types.JSON, types.NewMetadata(), data)
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+func (r *RequestMessage) Request() *http.Request {
+	return r.request
+}
+
+// 响应状态
+const (
+	// stateIdle 尚未写入响应
+	stateIdle = iota
+	// stateStreaming 已写入事件流响应头
+	stateStreaming
+	// stateRejected 订阅被拒绝，已写入错误状态码
+	stateRejected
+	// stateClosed 连接已关闭
+	stateClosed
+)
+
+// ResponseMessage SSE响应消息，SetBody 把数据作为事件直接发送给订阅的客户端
+type ResponseMessage struct {
+	headers    textproto.MIMEHeader
+	request    *http.Request
+	response   http.ResponseWriter
+	controller *http.ResponseController
+	//重连间隔（毫秒），大于0时在连接建立后发送给客户端
+	retry  int
+	state  int
+	body   []byte
+	msg    *types.RuleMsg
+	err    error
+	locker sync.RWMutex
+}
+
+func (r *ResponseMessage) Body() []byte {
+	r.locker.RLock()
+	defer r.locker.RUnlock()
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	if r.request == nil {
+		return ""
+	}
+	return r.request.URL.String()
+}
+
+func (r *ResponseMessage) GetParam(key string) string {
+	if r.request == nil {
+		return ""
+	}
+	return r.request.FormValue(key)
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	r.locker.RLock()
+	defer r.locker.RUnlock()
+	return r.msg
+}
+
+// SetStatusCode 事件流开始前设置错误状态码表示拒绝订阅，事件流开始后忽略
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+	if r.state == stateIdle && statusCode >= http.StatusBadRequest && r.response != nil {
+		r.response.WriteHeader(statusCode)
+		r.state = stateRejected
+	}
+}
+
+// SetBody 把数据作为事件发送给客户端，订阅被拒绝时作为错误响应内容
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+	r.body = body
+	if r.response == nil {
+		return
+	}
+	switch r.state {
+	case stateRejected:
+		_, _ = r.response.Write(body)
+	case stateIdle, stateStreaming:
+		if err := r.write(sse.Event{Data: string(body)}.Encode()); err != nil {
+			r.err = err
+		}
+	}
+}
+
+func (r *ResponseMessage) SetError(err error) {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+	r.err = err
+}
+
+func (r *ResponseMessage) GetError() error {
+	r.locker.RLock()
+	defer r.locker.RUnlock()
+	return r.err
+}
+
+// start 开始事件流，订阅被拒绝或者处理出错返回false
+func (r *ResponseMessage) start() bool {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+	if r.state == stateIdle && r.err != nil {
+		http.Error(r.response, r.err.Error(), http.StatusInternalServerError)
+		r.state = stateRejected
+	}
+	if r.state != stateIdle {
+		return r.state == stateStreaming
+	}
+	return r.writeHeader() == nil
+}
+
+// reject 事件流开始前拒绝订阅，已经被拒绝或者处理出错时忽略
+func (r *ResponseMessage) reject(statusCode int, message string) {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+	if r.state == stateIdle && r.err == nil {
+		http.Error(r.response, message, statusCode)
+		r.state = stateRejected
+	}
+}
+
+// writeHeader 写入事件流响应头，调用方需持有锁
+func (r *ResponseMessage) writeHeader() error {
+	//事件流是长连接，取消服务的写超时
+	_ = r.controller.SetWriteDeadline(time.Time{})
+	header := r.response.Header()
+	header.Set(rest.ContentTypeKey, ContentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	//禁止nginx等反向代理缓冲事件流
+	header.Set("X-Accel-Buffering", "no")
+	r.response.WriteHeader(http.StatusOK)
+	r.state = stateStreaming
+	if r.retry > 0 {
+		if _, err := fmt.Fprintf(r.response, "retry: %d\n\n", r.retry); err != nil {
+			return err
+		}
+	}
+	return r.controller.Flush()
+}
+
+// write 写入数据并立即发送，调用方需持有锁
+func (r *ResponseMessage) write(data []byte) error {
+	if r.state == stateIdle {
+		if err := r.writeHeader(); err != nil {
+			return err
+		}
+	}
+	if r.state != stateStreaming {
+		return nil
+	}
+	if _, err := r.response.Write(data); err != nil {
+		return err
+	}
+	return r.controller.Flush()
+}
+
+// send 发送数据给客户端
+func (r *ResponseMessage) send(data []byte) error {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+	return r.write(data)
+}
+
+// close 关闭后不再写入，处理函数返回后 http.ResponseWriter 不能再使用
+func (r *ResponseMessage) close() {
+	r.locker.Lock()
+	defer r.locker.Unlock()
+	r.state = stateClosed
+}
+
+// Config SSE 服务配置
+type Config struct {
+	rest.Config `mapstructure:",squash"`
+	// HeartbeatInterval 心跳间隔（秒），定时发送注释行保持空闲连接，小于等于0不发送心跳
+	HeartbeatInterval int `json:"heartbeatInterval"`
+	// Retry 建议客户端的重连间隔（毫秒），0表示使用客户端默认值
+	Retry int `json:"retry"`
+}
+
+// Sse Server-Sent Events 接收端端点
+type Sse struct {
+	*rest.Rest
+	//配置
+	Config Config
+	//Broker 事件代理，默认 sse.DefaultBroker，与 ssePublish 组件共享
+	Broker *sse.Broker
+}
+
+// Type 组件类型
+func (s *Sse) Type() string {
+	return Type
+}
+
+func (s *Sse) New() types.Node {
+	return &Sse{
+		Config: Config{
+			Config: rest.Config{
+				Server:    ":6335",
+				AllowCors: true,
+			},
+			HeartbeatInterval: 15,
+		},
+	}
+}
+
+// Init 初始化
+func (s *Sse) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &s.Config)
+	if err != nil {
+		return err
+	}
+	if s.Broker == nil {
+		s.Broker = sse.DefaultBroker
+	}
+	s.Rest = &rest.Rest{}
+	return s.Rest.Init(ruleConfig, configuration)
+}
+
+func (s *Sse) Id() string {
+	return s.Config.Server
+}
+
+func (s *Sse) AddRouter(router endpoint.Router, params ...interface{}) (id string, err error) {
+	if router == nil {
+		return "", errors.New("router can not nil")
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("addRouter err :%v", e)
+		}
+	}()
+	if err = s.addRouter(router); err != nil {
+		return "", err
+	}
+	return router.GetId(), nil
+}
+
+func (s *Sse) RemoveRouter(routerId string, params ...interface{}) error {
+	routerId = strings.TrimSpace(routerId)
+	s.Lock()
+	defer s.Unlock()
+	if s.RouterStorage != nil {
+		if router, ok := s.RouterStorage[routerId]; ok && !router.IsDisable() {
+			router.Disable(true)
+			return nil
+		} else {
+			return fmt.Errorf("router: %s not found", routerId)
+		}
+	}
+	return nil
+}
+
+func (s *Sse) Printf(format string, v ...interface{}) {
+	if s.RuleConfig.Logger != nil {
+		s.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+func (s *Sse) Start() error {
+	if s.OnEvent != nil {
+		s.OnEvent(endpoint.EventInitServer, s.Rest.Server)
+	}
+	if s.Rest.Started() {
+		return nil
+	}
+	return s.Rest.Start()
+}
+
+// subscription 路由的订阅配置
+type subscription struct {
+	//通道模板，为空使用请求路径
+	channel *el.MixedTemplate
+	//过滤参数
+	filter []string
+}
+
+// routerSubscription 从 From 配置解析订阅配置
+func routerSubscription(router endpoint.Router) (*subscription, error) {
+	sub := &subscription{}
+	from, ok := router.GetFrom().(*impl.From)
+	if !ok {
+		return sub, nil
+	}
+	if v := strings.TrimSpace(str.ToString(from.Config[FromConfigKeyChannel])); v != "" {
+		tmpl, err := el.NewMixedTemplate(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s configuration: %w", FromConfigKeyChannel, err)
+		}
+		sub.channel = tmpl
+	}
+	switch v := from.Config[FromConfigKeyFilter].(type) {
+	case nil:
+	case string:
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				sub.filter = append(sub.filter, item)
+			}
+		}
+	default:
+		if err := maps.Map2Struct(v, &sub.filter); err != nil {
+			return nil, fmt.Errorf("invalid %s configuration: %w", FromConfigKeyFilter, err)
+		}
+	}
+	return sub, nil
+}
+
+// addRouter 注册路由
+func (s *Sse) addRouter(router endpoint.Router) error {
+	sub, err := routerSubscription(router)
+	if err != nil {
+		return err
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	if s.RouterStorage == nil {
+		s.RouterStorage = make(map[string]endpoint.Router)
+	}
+	router.SetParams("GET")
+	s.CheckAndSetRouterId(router)
+	//存储路由
+	s.RouterStorage[router.GetId()] = router
+	//添加到http路由器
+	s.Router().Handle("GET", router.FromToString(), s.handler(router, sub))
+	return nil
+}
+
+func (s *Sse) handler(router endpoint.Router, sub *subscription) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		defer func() {
+			//捕捉异常
+			if e := recover(); e != nil {
+				s.Printf("sse endpoint handler err :\n%v", runtime.Stack())
+			}
+		}()
+		if router.IsDisable() {
+			http.NotFound(w, r)
+			return
+		}
+		response := &ResponseMessage{
+			request:    r,
+			response:   w,
+			controller: http.NewResponseController(w),
+			retry:      s.Config.Retry,
+		}
+		defer response.close()
+		exchange := &endpoint.Exchange{
+			In: &RequestMessage{
+				request: r,
+				Params:  params,
+			},
+			Out: response,
+		}
+		msg := exchange.In.GetMsg()
+		//把路径参数放到msg元数据中
+		for _, param := range params {
+			msg.Metadata.PutValue(param.Key, param.Value)
+		}
+		//把url?参数放到msg元数据中
+		for key, value := range r.URL.Query() {
+			if len(value) > 1 {
+				msg.Metadata.PutValue(key, str.ToString(value))
+			} else {
+				msg.Metadata.PutValue(key, value[0])
+			}
+		}
+		channel := r.URL.Path
+		if sub.channel != nil {
+			env := make(map[string]any)
+			for k, v := range msg.Metadata.Values() {
+				env[k] = v
+			}
+			channel = sub.channel.ExecuteAsString(env)
+		}
+		msg.Metadata.PutValue(KeyChannel, channel)
+
+		//执行拦截器和 From 处理器（例如认证），并触发路由的目标规则链
+		s.DoProcess(r.Context(), router, exchange)
+
+		//处理器可以修改元数据，所以在处理后读取过滤参数。缺少过滤参数时拒绝订阅，避免接收所有事件
+		msg = exchange.In.GetMsg()
+		var filter map[string]string
+		for _, key := range sub.filter {
+			v := msg.Metadata.GetValue(key)
+			if v == "" {
+				response.reject(http.StatusBadRequest, "missing filter parameter: "+key)
+				break
+			}
+			if filter == nil {
+				filter = make(map[string]string)
+			}
+			filter[key] = v
+		}
+		if !response.start() {
+			return
+		}
+		lastEventId := r.Header.Get(LastEventIdHeader)
+		if lastEventId == "" {
+			lastEventId = r.URL.Query().Get(LastEventIdParam)
+		}
+		subscriber := s.Broker.Subscribe(channel, lastEventId, filter)
+		defer s.Broker.Unsubscribe(subscriber)
+
+		if s.OnEvent != nil {
+			s.OnEvent(endpoint.EventConnect, exchange)
+			defer s.OnEvent(endpoint.EventDisconnect, exchange)
+		}
+
+		var heartbeat <-chan time.Time
+		if s.Config.HeartbeatInterval > 0 {
+			ticker := time.NewTicker(time.Duration(s.Config.HeartbeatInterval) * time.Second)
+			defer ticker.Stop()
+			heartbeat = ticker.C
+		}
+		for {
+			var err error
+			select {
+			case <-r.Context().Done():
+				return
+			case event, ok := <-subscriber.Events():
+				if !ok {
+					//通道被删除或者客户端太慢被断开，由客户端重连续传
+					return
+				}
+				err = response.send(event.Encode())
+			case <-heartbeat:
+				err = response.send([]byte(": ping\n\n"))
+			}
+			if err != nil {
+				return
+			}
+			if router.IsDisable() {
+				return
+			}
+		}
+	}
+}
+
+// Subscribers 返回通道的订阅客户端数量
+func (s *Sse) Subscribers(channel string) int {
+	return s.Broker.Subscribers(channel)
+}
@@ -0,0 +1,197 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/sse"
+)
+
+var testServer = ":9098"
+
+// 测试请求/响应消息
+func TestSseMessage(t *testing.T) {
+	t.Run("Request", func(t *testing.T) {
+		var request = &RequestMessage{}
+		test.EndpointMessage(t, request)
+	})
+	t.Run("Response", func(t *testing.T) {
+		var response = &ResponseMessage{}
+		test.EndpointMessage(t, response)
+	})
+}
+
+func TestRouterId(t *testing.T) {
+	var ep = &Endpoint{}
+	err := ep.Init(types.NewConfig(), types.Configuration{"server": testServer})
+	assert.Nil(t, err)
+	assert.Equal(t, testServer, ep.Id())
+	assert.Equal(t, 15, ep.New().(*Endpoint).Config.HeartbeatInterval)
+
+	router := impl.NewRouter().SetId("r1").From("/events").End()
+	routerId, _ := ep.AddRouter(router)
+	assert.Equal(t, "r1", routerId)
+
+	router = impl.NewRouter().From("/events/:tenant").End()
+	routerId, _ = ep.AddRouter(router)
+	assert.Equal(t, "/events/:tenant", routerId)
+
+	assert.Nil(t, ep.RemoveRouter("r1"))
+	assert.Nil(t, ep.RemoveRouter("/events/:tenant"))
+	err = ep.RemoveRouter("/events/:tenant")
+	assert.Equal(t, fmt.Sprintf("router: %s not found", "/events/:tenant"), err.Error())
+
+	_, err = ep.AddRouter(nil)
+	assert.Equal(t, "router can not nil", err.Error())
+}
+
+// readEvent 读取一个事件或者心跳
+func readEvent(reader *bufio.Reader) (string, error) {
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		if line == "\n" {
+			return strings.Join(lines, ""), nil
+		}
+		lines = append(lines, line)
+	}
+}
+
+func subscribe(t *testing.T, url string, header map[string]string) (*http.Response, *bufio.Reader) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	assert.Nil(t, err)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	return resp, bufio.NewReader(resp.Body)
+}
+
+func TestSseEndpoint(t *testing.T) {
+	broker := sse.NewBroker(10)
+	ep := &Endpoint{Broker: broker}
+	err := ep.Init(types.NewConfig(), types.Configuration{
+		"server":            testServer,
+		"heartbeatInterval": 1,
+		"retry":             3000,
+	})
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	var connected, disconnected int32
+	ep.OnEvent = func(eventName string, params ...interface{}) {
+		switch eventName {
+		case endpoint.EventConnect:
+			atomic.AddInt32(&connected, 1)
+		case endpoint.EventDisconnect:
+			atomic.AddInt32(&disconnected, 1)
+		}
+	}
+
+	router := impl.NewRouter().From("/events/:tenant", types.Configuration{
+		FromConfigKeyChannel: "tenant/${tenant}",
+		FromConfigKeyFilter:  "deviceId",
+	}).Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		if exchange.In.Headers().Get("Authorization") != "token" {
+			exchange.Out.SetStatusCode(http.StatusUnauthorized)
+			exchange.Out.SetBody([]byte("unauthorized"))
+			return false
+		}
+		return true
+	}).End()
+	_, err = ep.AddRouter(router)
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	time.Sleep(time.Millisecond * 200)
+
+	url := "http://127.0.0.1" + testServer + "/events/t1"
+	auth := map[string]string{"Authorization": "token"}
+
+	t.Run("Reject", func(t *testing.T) {
+		resp, reader := subscribe(t, url, nil)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		body, _ := io.ReadAll(reader)
+		assert.Equal(t, "unauthorized", string(body))
+	})
+
+	t.Run("MissingFilter", func(t *testing.T) {
+		//缺少过滤参数时拒绝订阅，不能接收所有设备的事件
+		resp, reader := subscribe(t, url, auth)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		body, _ := io.ReadAll(reader)
+		assert.Equal(t, "missing filter parameter: deviceId\n", string(body))
+	})
+
+	t.Run("Subscribe", func(t *testing.T) {
+		resp, reader := subscribe(t, url+"?deviceId=d1", auth)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, ContentType, resp.Header.Get("Content-Type"))
+		event, err := readEvent(reader)
+		assert.Nil(t, err)
+		assert.Equal(t, "retry: 3000\n", event)
+		assert.Equal(t, 1, ep.Subscribers("tenant/t1"))
+
+		//其他设备的事件被过滤
+		broker.Publish("tenant/t1", sse.Event{Data: "d2", Metadata: map[string]string{"deviceId": "d2"}})
+		broker.Publish("tenant/t1", sse.Event{Event: "alarm", Data: "d1", Metadata: map[string]string{"deviceId": "d1"}})
+		event, err = readEvent(reader)
+		assert.Nil(t, err)
+		assert.Equal(t, "id: 2\nevent: alarm\ndata: d1\n", event)
+
+		//心跳
+		event, err = readEvent(reader)
+		assert.Nil(t, err)
+		assert.Equal(t, ": ping\n", event)
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		d1 := map[string]string{"deviceId": "d1"}
+		broker.Publish("tenant/t1", sse.Event{Data: "a", Metadata: d1})
+		broker.Publish("tenant/t1", sse.Event{Data: "b", Metadata: d1})
+		resp, reader := subscribe(t, url+"?deviceId=d1", map[string]string{"Authorization": "token", LastEventIdHeader: "3"})
+		defer resp.Body.Close()
+		_, _ = readEvent(reader)
+		event, err := readEvent(reader)
+		assert.Nil(t, err)
+		assert.Equal(t, "id: 4\ndata: b\n", event)
+	})
+
+	time.Sleep(time.Millisecond * 200)
+	assert.Equal(t, 0, ep.Subscribers("tenant/t1"))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&connected))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&disconnected))
+}
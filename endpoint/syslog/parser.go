@@ -0,0 +1,337 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// FormatRFC5424 RFC 5424 格式
+	FormatRFC5424 = "rfc5424"
+	// FormatRFC3164 RFC 3164（BSD）格式
+	FormatRFC3164 = "rfc3164"
+	// defaultPriority 没有优先级的消息按 RFC 3164 作为 user.notice 处理
+	defaultPriority = 13
+	// maxPriority 最大优先级，facility 23 * 8 + severity 7
+	maxPriority = 191
+	// rfc3164TimestampLayout RFC 3164 时间戳格式，例如 "Oct 11 22:14:15"
+	rfc3164TimestampLayout = "Jan _2 15:04:05"
+	// nilValue RFC 5424 空值
+	nilValue = "-"
+	// maxFrameLengthDigits octet-counting 帧长度字段的最大位数
+	maxFrameLengthDigits = 10
+)
+
+// Facilities 设施名称，下标为设施编号
+var Facilities = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// Severities 严重级别名称，下标为级别编号，数值越小越严重
+var Severities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// ErrEmptyMessage 空消息
+var ErrEmptyMessage = errors.New("empty syslog message")
+
+// Message 解析后的 syslog 消息
+type Message struct {
+	// Format 消息格式：rfc5424 或 rfc3164
+	Format   string `json:"format"`
+	Priority int    `json:"priority"`
+	Facility int    `json:"facility"`
+	Severity int    `json:"severity"`
+	// FacilityName 设施名称，例如 auth
+	FacilityName string `json:"facilityName"`
+	// SeverityName 严重级别名称，例如 err
+	SeverityName string `json:"severityName"`
+	// Version RFC 5424 版本，RFC 3164 为0
+	Version int `json:"version,omitempty"`
+	// Timestamp RFC 3339 格式的时间，消息没有时间为空
+	Timestamp string `json:"timestamp,omitempty"`
+	Hostname  string `json:"hostname,omitempty"`
+	AppName   string `json:"appName,omitempty"`
+	ProcId    string `json:"procId,omitempty"`
+	MsgId     string `json:"msgId,omitempty"`
+	// StructuredData 结构化数据，SD-ID -> 参数名 -> 参数值
+	StructuredData map[string]map[string]string `json:"structuredData,omitempty"`
+	Message        string                       `json:"message"`
+}
+
+// setPriority 设置优先级以及设施和严重级别
+func (m *Message) setPriority(priority int) {
+	m.Priority = priority
+	m.Facility = priority / 8
+	m.Severity = priority % 8
+	m.FacilityName = Facilities[m.Facility]
+	m.SeverityName = Severities[m.Severity]
+}
+
+// Parse 解析一条 syslog 消息，自动识别 RFC 5424 和 RFC 3164 格式。
+// RFC 3164 时间戳没有年份和时区，使用 now 的年份和时区补全。
+// 不符合格式的内容按 RFC 3164 宽松解析，作为消息内容保留
+func Parse(data []byte, now time.Time) (*Message, error) {
+	line := strings.TrimRight(string(data), "\r\n\x00")
+	if strings.TrimSpace(line) == "" {
+		return nil, ErrEmptyMessage
+	}
+	m := &Message{}
+	priority, rest, ok := parsePriority(line)
+	if !ok {
+		//没有优先级，整行作为消息内容
+		m.Format = FormatRFC3164
+		m.setPriority(defaultPriority)
+		m.Message = line
+		return m, nil
+	}
+	m.setPriority(priority)
+	if version, after, ok := parseVersion(rest); ok {
+		m.Format = FormatRFC5424
+		m.Version = version
+		if err := parseRFC5424(m, after); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+	m.Format = FormatRFC3164
+	parseRFC3164(m, rest, now)
+	return m, nil
+}
+
+// parsePriority 解析 <PRI>
+func parsePriority(line string) (int, string, bool) {
+	if !strings.HasPrefix(line, "<") {
+		return 0, line, false
+	}
+	end := strings.IndexByte(line, '>')
+	if end < 2 || end > 4 {
+		return 0, line, false
+	}
+	priority, err := strconv.Atoi(line[1:end])
+	if err != nil || priority < 0 || priority > maxPriority {
+		return 0, line, false
+	}
+	return priority, line[end+1:], true
+}
+
+// parseVersion 解析 RFC 5424 版本号，版本号为非0开头的数字，后接空格
+func parseVersion(rest string) (int, string, bool) {
+	sp := strings.IndexByte(rest, ' ')
+	if sp < 1 || sp > 3 || rest[0] < '1' || rest[0] > '9' {
+		return 0, rest, false
+	}
+	version, err := strconv.Atoi(rest[:sp])
+	if err != nil {
+		return 0, rest, false
+	}
+	return version, rest[sp+1:], true
+}
+
+// parseRFC5424 解析 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+func parseRFC5424(m *Message, rest string) error {
+	fields := make([]string, 5)
+	for i := range fields {
+		var field string
+		field, rest, _ = strings.Cut(rest, " ")
+		if field == "" {
+			return errors.New("invalid rfc5424 header")
+		}
+		if field != nilValue {
+			fields[i] = field
+		}
+	}
+	if fields[0] != "" {
+		ts, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return fmt.Errorf("invalid rfc5424 timestamp: %s", fields[0])
+		}
+		m.Timestamp = ts.Format(time.RFC3339Nano)
+	}
+	m.Hostname, m.AppName, m.ProcId, m.MsgId = fields[1], fields[2], fields[3], fields[4]
+	if strings.HasPrefix(rest, nilValue) {
+		rest = rest[len(nilValue):]
+	} else {
+		sd, after, err := parseStructuredData(rest)
+		if err != nil {
+			return err
+		}
+		m.StructuredData = sd
+		rest = after
+	}
+	if strings.HasPrefix(rest, " ") {
+		//去掉 UTF-8 BOM
+		m.Message = strings.TrimPrefix(rest[1:], "\ufeff")
+	} else if rest != "" {
+		return errors.New("invalid rfc5424 structured data")
+	}
+	return nil
+}
+
+// parseStructuredData 解析一个或多个 [SD-ID PARAM="VALUE" ...]，参数值中的 \" \\ \] 为转义字符
+func parseStructuredData(rest string) (map[string]map[string]string, string, error) {
+	sd := make(map[string]map[string]string)
+	for strings.HasPrefix(rest, "[") {
+		rest = rest[1:]
+		end := strings.IndexAny(rest, " ]")
+		if end < 1 {
+			return nil, rest, errors.New("invalid rfc5424 structured data")
+		}
+		id := rest[:end]
+		params := make(map[string]string)
+		sd[id] = params
+		rest = rest[end:]
+		for strings.HasPrefix(rest, " ") {
+			rest = rest[1:]
+			eq := strings.Index(rest, "=\"")
+			if eq < 1 {
+				return nil, rest, errors.New("invalid rfc5424 structured data param")
+			}
+			name := rest[:eq]
+			rest = rest[eq+2:]
+			var value strings.Builder
+			closed := false
+			for i := 0; i < len(rest); i++ {
+				c := rest[i]
+				if c == '\\' && i+1 < len(rest) && (rest[i+1] == '"' || rest[i+1] == '\\' || rest[i+1] == ']') {
+					value.WriteByte(rest[i+1])
+					i++
+				} else if c == '"' {
+					rest = rest[i+1:]
+					closed = true
+					break
+				} else {
+					value.WriteByte(c)
+				}
+			}
+			if !closed {
+				return nil, rest, errors.New("unterminated rfc5424 structured data param")
+			}
+			params[name] = value.String()
+		}
+		if !strings.HasPrefix(rest, "]") {
+			return nil, rest, errors.New("invalid rfc5424 structured data")
+		}
+		rest = rest[1:]
+	}
+	return sd, rest, nil
+}
+
+// parseRFC3164 解析 TIMESTAMP HOSTNAME TAG[PID]: MSG，没有时间戳时全部作为消息内容
+func parseRFC3164(m *Message, rest string, now time.Time) {
+	if len(rest) < len(rfc3164TimestampLayout) {
+		m.Message = rest
+		return
+	}
+	ts, err := time.ParseInLocation(rfc3164TimestampLayout, rest[:len(rfc3164TimestampLayout)], now.Location())
+	if err != nil {
+		m.Message = rest
+		return
+	}
+	ts = ts.AddDate(now.Year(), 0, 0)
+	//跨年时收到去年12月的日志
+	if ts.After(now.AddDate(0, 1, 0)) {
+		ts = ts.AddDate(-1, 0, 0)
+	}
+	m.Timestamp = ts.Format(time.RFC3339)
+	rest = strings.TrimPrefix(rest[len(rfc3164TimestampLayout):], " ")
+	m.Hostname, rest, _ = strings.Cut(rest, " ")
+
+	//TAG 以非字母数字字符结束，常见格式为 app[pid]: msg 或 app: msg
+	end := strings.IndexAny(rest, "[: ")
+	if end <= 0 {
+		m.Message = rest
+		return
+	}
+	m.AppName = rest[:end]
+	rest = rest[end:]
+	if strings.HasPrefix(rest, "[") {
+		if closeIdx := strings.IndexByte(rest, ']'); closeIdx > 0 {
+			m.ProcId = rest[1:closeIdx]
+			rest = rest[closeIdx+1:]
+		}
+	}
+	rest = strings.TrimPrefix(rest, ":")
+	m.Message = strings.TrimPrefix(rest, " ")
+}
+
+// readFrame 读取TCP流中的一条消息，以数字开头为 RFC 6587 octet-counting 帧 "长度 消息"，
+// 否则为换行分隔的帧
+func readFrame(reader *bufio.Reader, maxSize int) ([]byte, error) {
+	first, err := reader.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if first[0] >= '1' && first[0] <= '9' {
+		length, err := readFrameLength(reader)
+		if err != nil {
+			return nil, err
+		}
+		if length > int64(maxSize) {
+			return nil, fmt.Errorf("syslog message too large: %d", length)
+		}
+		frame := make([]byte, length)
+		if _, err = io.ReadFull(reader, frame); err != nil {
+			return nil, err
+		}
+		return frame, nil
+	}
+	var frame []byte
+	for {
+		line, err := reader.ReadSlice('\n')
+		frame = append(frame, line...)
+		if len(frame) > maxSize {
+			return nil, fmt.Errorf("syslog message too large: %d", len(frame))
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && (err != io.EOF || len(frame) == 0) {
+			return nil, err
+		}
+		return bytes.TrimRight(frame, "\r\n\x00"), nil
+	}
+}
+
+// readFrameLength 读取 octet-counting 帧的长度字段和后面的空格，长度字段最多 maxFrameLengthDigits 位数字
+func readFrameLength(reader *bufio.Reader) (int64, error) {
+	var length int64
+	for digits := 0; ; digits++ {
+		c, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if c == ' ' && digits > 0 {
+			return length, nil
+		}
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid octet counting frame length: %q", c)
+		}
+		if digits >= maxFrameLengthDigits {
+			return 0, fmt.Errorf("octet counting frame length exceeds %d digits", maxFrameLengthDigits)
+		}
+		length = length*10 + int64(c-'0')
+	}
+}
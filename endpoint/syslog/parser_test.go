@@ -0,0 +1,138 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package syslog
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2025, 1, 10, 8, 0, 0, 0, time.UTC)
+
+	t.Run("RFC5424", func(t *testing.T) {
+		m, err := Parse([]byte(`<165>1 2025-01-10T07:59:58.123Z host1 app1 1234 ID47 [exampleSDID@32473 iut="3" eventSource="App\"lication"][meta x="1"] `+"\ufeff"+`An application event`), now)
+		assert.Nil(t, err)
+		assert.Equal(t, FormatRFC5424, m.Format)
+		assert.Equal(t, 165, m.Priority)
+		assert.Equal(t, 20, m.Facility)
+		assert.Equal(t, 5, m.Severity)
+		assert.Equal(t, "local4", m.FacilityName)
+		assert.Equal(t, "notice", m.SeverityName)
+		assert.Equal(t, 1, m.Version)
+		assert.Equal(t, "2025-01-10T07:59:58.123Z", m.Timestamp)
+		assert.Equal(t, "host1", m.Hostname)
+		assert.Equal(t, "app1", m.AppName)
+		assert.Equal(t, "1234", m.ProcId)
+		assert.Equal(t, "ID47", m.MsgId)
+		assert.Equal(t, "3", m.StructuredData["exampleSDID@32473"]["iut"])
+		assert.Equal(t, `App"lication`, m.StructuredData["exampleSDID@32473"]["eventSource"])
+		assert.Equal(t, "1", m.StructuredData["meta"]["x"])
+		assert.Equal(t, "An application event", m.Message)
+
+		m, err = Parse([]byte("<34>1 - - - - - -"), now)
+		assert.Nil(t, err)
+		assert.Equal(t, "auth", m.FacilityName)
+		assert.Equal(t, "crit", m.SeverityName)
+		assert.Equal(t, "", m.Timestamp)
+		assert.Equal(t, "", m.Hostname)
+		assert.Equal(t, "", m.Message)
+
+		_, err = Parse([]byte("<34>1 2025-01-10 host"), now)
+		assert.NotNil(t, err)
+		_, err = Parse([]byte(`<34>1 - - - - - [id a="1" msg`), now)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("RFC3164", func(t *testing.T) {
+		m, err := Parse([]byte("<38>Jan  9 22:14:15 mymachine sshd[4321]: Accepted password for root\n"), now)
+		assert.Nil(t, err)
+		assert.Equal(t, FormatRFC3164, m.Format)
+		assert.Equal(t, "auth", m.FacilityName)
+		assert.Equal(t, "info", m.SeverityName)
+		assert.Equal(t, "2025-01-09T22:14:15Z", m.Timestamp)
+		assert.Equal(t, "mymachine", m.Hostname)
+		assert.Equal(t, "sshd", m.AppName)
+		assert.Equal(t, "4321", m.ProcId)
+		assert.Equal(t, "Accepted password for root", m.Message)
+
+		//跨年时收到去年12月的日志
+		m, err = Parse([]byte("<13>Dec 31 23:59:59 host cron: job done"), now)
+		assert.Nil(t, err)
+		assert.Equal(t, "2024-12-31T23:59:59Z", m.Timestamp)
+		assert.Equal(t, "cron", m.AppName)
+		assert.Equal(t, "", m.ProcId)
+		assert.Equal(t, "job done", m.Message)
+
+		m, err = Parse([]byte("<11>no timestamp here"), now)
+		assert.Nil(t, err)
+		assert.Equal(t, "err", m.SeverityName)
+		assert.Equal(t, "no timestamp here", m.Message)
+	})
+
+	t.Run("NoPriority", func(t *testing.T) {
+		m, err := Parse([]byte("plain text line"), now)
+		assert.Nil(t, err)
+		assert.Equal(t, defaultPriority, m.Priority)
+		assert.Equal(t, "user", m.FacilityName)
+		assert.Equal(t, "notice", m.SeverityName)
+		assert.Equal(t, "plain text line", m.Message)
+
+		m, err = Parse([]byte("<999>x"), now)
+		assert.Nil(t, err)
+		assert.Equal(t, "<999>x", m.Message)
+
+		_, err = Parse([]byte(" \r\n"), now)
+		assert.Equal(t, ErrEmptyMessage, err)
+	})
+}
+
+func TestReadFrame(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("9 <13>hello5 <13>a<14>line one\r\n<14>line two"))
+	frame, err := readFrame(reader, 1024)
+	assert.Nil(t, err)
+	assert.Equal(t, "<13>hello", string(frame))
+	frame, err = readFrame(reader, 1024)
+	assert.Nil(t, err)
+	assert.Equal(t, "<13>a", string(frame))
+	frame, err = readFrame(reader, 1024)
+	assert.Nil(t, err)
+	assert.Equal(t, "<14>line one", string(frame))
+	frame, err = readFrame(reader, 1024)
+	assert.Nil(t, err)
+	assert.Equal(t, "<14>line two", string(frame))
+	_, err = readFrame(reader, 1024)
+	assert.Equal(t, io.EOF, err)
+
+	_, err = readFrame(bufio.NewReader(strings.NewReader("100 <13>hello")), 10)
+	assert.Equal(t, "syslog message too large: 100", err.Error())
+	_, err = readFrame(bufio.NewReader(strings.NewReader("<13>"+strings.Repeat("a", 20)+"\n")), 10)
+	assert.NotNil(t, err)
+
+	//长度字段最多10位数字，不会一直读取到空格
+	_, err = readFrame(bufio.NewReader(strings.NewReader(strings.Repeat("1", 100)+" <13>a")), 1024)
+	assert.Equal(t, "octet counting frame length exceeds 10 digits", err.Error())
+	_, err = readFrame(bufio.NewReader(strings.NewReader("9999999999 <13>a")), 1024)
+	assert.Equal(t, "syslog message too large: 9999999999", err.Error())
+	_, err = readFrame(bufio.NewReader(strings.NewReader("12a <13>a")), 1024)
+	assert.Equal(t, `invalid octet counting frame length: 'a'`, err.Error())
+}
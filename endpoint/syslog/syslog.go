@@ -0,0 +1,667 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package syslog provides a syslog receiver endpoint implementation for the RuleGo framework.
+// It receives RFC 5424 and RFC 3164 (BSD) messages over UDP, TCP or TLS and routes the parsed
+// messages to rule chains or components.
+//
+// Key components in this package include:
+// - Endpoint (alias Syslog): Implements the syslog server and message routing
+// - RequestMessage: Represents a received syslog message
+// - ResponseMessage: Syslog is one-way, the response is not sent back
+//
+// TCP and TLS streams support RFC 6587 octet-counting framing ("LEN MSG") and newline
+// delimited framing, detected per message. The parsed message, including structured data,
+// is the JSON message data. The priority, facility, severity, hostname, app name, proc id,
+// msg id and timestamp are also written to the message metadata. Routers match on facility,
+// severity or app name with the facility, severity and appName From configuration.
+//
+// Package syslog 提供 RuleGo 框架的 syslog 接收端点实现。通过 UDP、TCP 或 TLS 接收 RFC 5424 和
+// RFC 3164（BSD）格式的消息，把解析后的消息路由到规则链或者组件。TCP 和 TLS 流支持 RFC 6587
+// octet-counting 帧（"长度 消息"）和换行分隔的帧，按每条消息自动识别。解析后的消息作为JSON消息负荷，
+// 优先级、设施、严重级别、主机名、应用名、进程ID、消息ID和时间同时写入元数据，结构化数据保留在消息负荷中。
+// 路由通过 From 配置的 facility、severity 和 appName 按设施、严重级别或者应用名匹配。
+package syslog
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/runtime"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// Type 组件类型
+const Type = types.EndpointTypePrefix + "syslog"
+
+const (
+	// ProtocolUDP UDP协议，每个数据报为一条消息
+	ProtocolUDP = "udp"
+	// ProtocolTCP TCP协议
+	ProtocolTCP = "tcp"
+	// ProtocolTLS TLS协议（RFC 5425）
+	ProtocolTLS = "tls"
+
+	// RemoteAddrKey 客户端地址写入的元数据键
+	RemoteAddrKey = "remoteAddr"
+	// KeyFormat 消息格式写入的元数据键
+	KeyFormat = "format"
+	// KeyPriority 优先级写入的元数据键
+	KeyPriority = "priority"
+	// KeyFacility 设施名称写入的元数据键
+	KeyFacility = "facility"
+	// KeySeverity 严重级别名称写入的元数据键
+	KeySeverity = "severity"
+	// KeyHostname 主机名写入的元数据键
+	KeyHostname = "hostname"
+	// KeyAppName 应用名写入的元数据键
+	KeyAppName = "appName"
+	// KeyProcId 进程ID写入的元数据键
+	KeyProcId = "procId"
+	// KeyMsgId 消息ID写入的元数据键
+	KeyMsgId = "msgId"
+	// KeyTimestamp 消息时间写入的元数据键
+	KeyTimestamp = "timestamp"
+
+	// FromConfigKeyFacility 匹配设施的 From 配置键，多个设施名称或编号用逗号分隔
+	FromConfigKeyFacility = "facility"
+	// FromConfigKeySeverity 匹配严重级别的 From 配置键，多个级别名称或编号用逗号分隔，
+	// "<=warning" 匹配 warning 及更严重的级别
+	FromConfigKeySeverity = "severity"
+	// FromConfigKeyAppName 匹配应用名的 From 配置键，正则表达式
+	FromConfigKeyAppName = "appName"
+
+	// DefaultMaxMessageSize 默认最大消息大小(64KB)
+	DefaultMaxMessageSize = 65536
+	// atMostPrefix 严重级别阈值前缀
+	atMostPrefix = "<="
+)
+
+// Endpoint 别名
+type Endpoint = Syslog
+
+// RequestMessage syslog请求消息
+type RequestMessage struct {
+	message *Message
+	raw     []byte
+	from    string
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+}
+
+// Body 返回解析后消息的JSON
+func (r *RequestMessage) Body() []byte {
+	if r.body == nil && r.message != nil {
+		r.body, _ = json.Marshal(r.message)
+	}
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+// From 返回客户端地址
+func (r *RequestMessage) From() string {
+	return r.from
+}
+
+// GetParam 返回解析后消息的字段
+func (r *RequestMessage) GetParam(key string) string {
+	if r.message == nil {
+		return ""
+	}
+	return r.metadata()[key]
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+// GetMsg 把解析后的消息转换成JSON RuleMsg，字段同时写入元数据
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		if r.message != nil {
+			for k, v := range r.metadata() {
+				if v != "" {
+					metadata.PutValue(k, v)
+				}
+			}
+		}
+		ruleMsg := types.NewMsg(0, r.From(), types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+// metadata 写入元数据的字段
+func (r *RequestMessage) metadata() map[string]string {
+	return map[string]string{
+		KeyFormat:    r.message.Format,
+		KeyPriority:  strconv.Itoa(r.message.Priority),
+		KeyFacility:  r.message.FacilityName,
+		KeySeverity:  r.message.SeverityName,
+		KeyHostname:  r.message.Hostname,
+		KeyAppName:   r.message.AppName,
+		KeyProcId:    r.message.ProcId,
+		KeyMsgId:     r.message.MsgId,
+		KeyTimestamp: r.message.Timestamp,
+	}
+}
+
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// Message 返回解析后的 syslog 消息
+func (r *RequestMessage) Message() *Message {
+	return r.message
+}
+
+// Raw 返回原始消息
+func (r *RequestMessage) Raw() []byte {
+	return r.raw
+}
+
+// ResponseMessage syslog是单向协议，响应不会发送给客户端
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	from    string
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+	mu      sync.RWMutex
+}
+
+func (r *ResponseMessage) Body() []byte {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+func (r *ResponseMessage) From() string {
+	return r.from
+}
+
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.msg
+}
+
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.body = body
+}
+
+func (r *ResponseMessage) SetError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+func (r *ResponseMessage) GetError() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.err
+}
+
+// Config syslog 服务配置
+type Config struct {
+	// Protocol 协议：udp、tcp、tls，默认udp
+	Protocol string `json:"protocol"`
+	// Server 服务监听地址，默认 :514
+	Server string `json:"server"`
+	// CertFile tls协议的证书文件
+	CertFile string `json:"certFile"`
+	// CertKeyFile tls协议的私钥文件
+	CertKeyFile string `json:"certKeyFile"`
+	// ReadTimeout tcp和tls连接的空闲超时时间，单位秒，0表示不超时
+	ReadTimeout int `json:"readTimeout"`
+	// MaxMessageSize 最大消息大小，默认64KB
+	MaxMessageSize int `json:"maxMessageSize"`
+}
+
+// syslogRouter 路由和匹配条件
+type syslogRouter struct {
+	router endpoint.Router
+	//facilities 匹配的设施，为空匹配所有
+	facilities map[int]bool
+	//severities 匹配的严重级别，为空匹配所有
+	severities map[int]bool
+	//appName 匹配应用名的正则表达式
+	appName *regexp.Regexp
+}
+
+// match 消息是否匹配路由
+func (r *syslogRouter) match(m *Message) bool {
+	if r.router.IsDisable() {
+		return false
+	}
+	if r.facilities != nil && !r.facilities[m.Facility] {
+		return false
+	}
+	if r.severities != nil && !r.severities[m.Severity] {
+		return false
+	}
+	return r.appName == nil || r.appName.MatchString(m.AppName)
+}
+
+// newSyslogRouter 从 From 配置解析匹配条件
+func newSyslogRouter(router endpoint.Router) (*syslogRouter, error) {
+	r := &syslogRouter{router: router}
+	from, ok := router.GetFrom().(*impl.From)
+	if !ok || from == nil {
+		return r, nil
+	}
+	config := from.GetConfiguration()
+	var err error
+	if v := strings.TrimSpace(str.ToString(config[FromConfigKeyFacility])); v != "" {
+		if r.facilities, err = parseLevels(v, Facilities); err != nil {
+			return nil, fmt.Errorf("invalid %s configuration: %w", FromConfigKeyFacility, err)
+		}
+	}
+	if v := strings.TrimSpace(str.ToString(config[FromConfigKeySeverity])); v != "" {
+		if strings.HasPrefix(v, atMostPrefix) {
+			level, err := parseLevel(strings.TrimSpace(v[len(atMostPrefix):]), Severities)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s configuration: %w", FromConfigKeySeverity, err)
+			}
+			r.severities = make(map[int]bool)
+			for i := 0; i <= level; i++ {
+				r.severities[i] = true
+			}
+		} else if r.severities, err = parseLevels(v, Severities); err != nil {
+			return nil, fmt.Errorf("invalid %s configuration: %w", FromConfigKeySeverity, err)
+		}
+	}
+	if v := strings.TrimSpace(str.ToString(config[FromConfigKeyAppName])); v != "" {
+		if r.appName, err = regexp.Compile(v); err != nil {
+			return nil, fmt.Errorf("invalid %s configuration: %w", FromConfigKeyAppName, err)
+		}
+	}
+	return r, nil
+}
+
+// parseLevels 解析逗号分隔的名称或编号
+func parseLevels(v string, names []string) (map[int]bool, error) {
+	levels := make(map[int]bool)
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		level, err := parseLevel(item, names)
+		if err != nil {
+			return nil, err
+		}
+		levels[level] = true
+	}
+	return levels, nil
+}
+
+// parseLevel 解析名称或编号
+func parseLevel(v string, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(name, v) {
+			return i, nil
+		}
+	}
+	if level, err := strconv.Atoi(v); err == nil && level >= 0 && level < len(names) {
+		return level, nil
+	}
+	return 0, fmt.Errorf("unknown value: %s", v)
+}
+
+// Syslog syslog 接收端端点
+type Syslog struct {
+	impl.BaseEndpoint
+	// Config 配置
+	Config Config
+	// RuleConfig rulego配置
+	RuleConfig types.Config
+	listener   net.Listener
+	udpConn    net.PacketConn
+	routers    []*syslogRouter
+	conns      map[net.Conn]struct{}
+	connLock   sync.Mutex
+	closed     int32
+}
+
+// Type 组件类型
+func (ep *Syslog) Type() string {
+	return Type
+}
+
+func (ep *Syslog) New() types.Node {
+	return &Syslog{
+		Config: Config{
+			Protocol:       ProtocolUDP,
+			Server:         ":514",
+			MaxMessageSize: DefaultMaxMessageSize,
+		},
+	}
+}
+
+// Init 初始化
+func (ep *Syslog) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &ep.Config)
+	ep.Config.Protocol = strings.ToLower(strings.TrimSpace(ep.Config.Protocol))
+	if ep.Config.Protocol == "" {
+		ep.Config.Protocol = ProtocolUDP
+	}
+	if ep.Config.MaxMessageSize <= 0 {
+		ep.Config.MaxMessageSize = DefaultMaxMessageSize
+	}
+	ep.RuleConfig = ruleConfig
+	return err
+}
+
+// Destroy 销毁
+func (ep *Syslog) Destroy() {
+	_ = ep.Close()
+}
+
+// Close 停止服务并关闭所有连接
+func (ep *Syslog) Close() error {
+	atomic.StoreInt32(&ep.closed, 1)
+	ep.connLock.Lock()
+	for conn := range ep.conns {
+		_ = conn.Close()
+	}
+	ep.conns = nil
+	ep.connLock.Unlock()
+	ep.Lock()
+	defer ep.Unlock()
+	var err error
+	if ep.listener != nil {
+		err = ep.listener.Close()
+		ep.listener = nil
+	}
+	if ep.udpConn != nil {
+		err = ep.udpConn.Close()
+		ep.udpConn = nil
+	}
+	return err
+}
+
+func (ep *Syslog) Id() string {
+	return ep.Config.Server
+}
+
+// AddRouter 添加路由，From 为路由名称，匹配条件通过 From 配置的 facility、severity 和 appName 设置
+func (ep *Syslog) AddRouter(router endpoint.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router can not nil")
+	}
+	r, err := newSyslogRouter(router)
+	if err != nil {
+		return "", err
+	}
+	ep.CheckAndSetRouterId(router)
+	ep.Lock()
+	defer ep.Unlock()
+	if ep.RouterStorage == nil {
+		ep.RouterStorage = make(map[string]endpoint.Router)
+	}
+	if _, ok := ep.RouterStorage[router.GetId()]; ok {
+		return router.GetId(), fmt.Errorf("duplicate router %s", router.GetId())
+	}
+	ep.RouterStorage[router.GetId()] = router
+	ep.routers = append(ep.routers, r)
+	return router.GetId(), nil
+}
+
+func (ep *Syslog) RemoveRouter(routerId string, params ...interface{}) error {
+	routerId = strings.TrimSpace(routerId)
+	ep.Lock()
+	defer ep.Unlock()
+	if _, ok := ep.RouterStorage[routerId]; !ok {
+		return fmt.Errorf("router: %s not found", routerId)
+	}
+	delete(ep.RouterStorage, routerId)
+	routers := ep.routers[:0]
+	for _, item := range ep.routers {
+		if item.router.GetId() != routerId {
+			routers = append(routers, item)
+		}
+	}
+	ep.routers = routers
+	return nil
+}
+
+func (ep *Syslog) Start() error {
+	atomic.StoreInt32(&ep.closed, 0)
+	switch ep.Config.Protocol {
+	case ProtocolUDP:
+		conn, err := net.ListenPacket("udp", ep.Config.Server)
+		if err != nil {
+			return err
+		}
+		ep.Lock()
+		ep.udpConn = conn
+		ep.Unlock()
+		go ep.serveUDP(conn)
+	case ProtocolTCP, ProtocolTLS:
+		listener, err := net.Listen("tcp", ep.Config.Server)
+		if err != nil {
+			return err
+		}
+		if ep.Config.Protocol == ProtocolTLS {
+			cert, err := tls.LoadX509KeyPair(ep.Config.CertFile, ep.Config.CertKeyFile)
+			if err != nil {
+				_ = listener.Close()
+				return err
+			}
+			listener = tls.NewListener(listener, &tls.Config{Certificates: []tls.Certificate{cert}})
+		}
+		ep.Lock()
+		ep.listener = listener
+		ep.Unlock()
+		go ep.accept(listener)
+	default:
+		return fmt.Errorf("unsupported protocol: %s", ep.Config.Protocol)
+	}
+	if ep.OnEvent != nil {
+		ep.OnEvent(endpoint.EventInitServer, ep)
+	}
+	ep.Printf("started syslog %s server on %s", ep.Config.Protocol, ep.Config.Server)
+	return nil
+}
+
+// LocalAddr 返回服务监听地址，未启动返回nil
+func (ep *Syslog) LocalAddr() net.Addr {
+	ep.RLock()
+	defer ep.RUnlock()
+	if ep.listener != nil {
+		return ep.listener.Addr()
+	}
+	if ep.udpConn != nil {
+		return ep.udpConn.LocalAddr()
+	}
+	return nil
+}
+
+func (ep *Syslog) Printf(format string, v ...interface{}) {
+	if ep.RuleConfig.Logger != nil {
+		ep.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+func (ep *Syslog) isClosed() bool {
+	return atomic.LoadInt32(&ep.closed) == 1
+}
+
+// serveUDP 每个数据报为一条消息
+func (ep *Syslog) serveUDP(conn net.PacketConn) {
+	buf := make([]byte, ep.Config.MaxMessageSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ep.isClosed() || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		ep.handle(addr.String(), append([]byte(nil), buf[:n]...))
+	}
+}
+
+// accept 接受tcp和tls连接
+func (ep *Syslog) accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ep.isClosed() || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		go ep.serveConn(conn)
+	}
+}
+
+// serveConn 读取连接上的消息，直到连接关闭或者空闲超时
+func (ep *Syslog) serveConn(conn net.Conn) {
+	ep.connLock.Lock()
+	if ep.conns == nil {
+		ep.conns = make(map[net.Conn]struct{})
+	}
+	ep.conns[conn] = struct{}{}
+	ep.connLock.Unlock()
+	from := conn.RemoteAddr().String()
+	if ep.OnEvent != nil {
+		ep.OnEvent(endpoint.EventConnect, from)
+	}
+	defer func() {
+		ep.connLock.Lock()
+		delete(ep.conns, conn)
+		ep.connLock.Unlock()
+		_ = conn.Close()
+		if ep.OnEvent != nil {
+			ep.OnEvent(endpoint.EventDisconnect, from)
+		}
+	}()
+	reader := bufio.NewReader(conn)
+	for {
+		if ep.Config.ReadTimeout > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(time.Duration(ep.Config.ReadTimeout) * time.Second))
+		}
+		frame, err := readFrame(reader, ep.Config.MaxMessageSize)
+		if err != nil {
+			if !ep.isClosed() && !errors.Is(err, net.ErrClosed) && err != io.EOF {
+				ep.Printf("syslog endpoint read from %s err :%v", from, err)
+			}
+			return
+		}
+		ep.handle(from, frame)
+	}
+}
+
+// handle 解析消息并交给匹配的路由处理
+func (ep *Syslog) handle(from string, data []byte) {
+	defer func() {
+		//捕捉异常
+		if e := recover(); e != nil {
+			ep.Printf("syslog endpoint handler err :\n%v", runtime.Stack())
+		}
+	}()
+	m, err := Parse(data, time.Now())
+	if err != nil {
+		if err != ErrEmptyMessage {
+			ep.Printf("syslog endpoint parse message from %s err :%v", from, err)
+		}
+		return
+	}
+	exchange := &endpoint.Exchange{
+		In: &RequestMessage{
+			message: m,
+			raw:     data,
+			from:    from,
+		},
+		Out: &ResponseMessage{
+			from: from,
+		},
+	}
+	msg := exchange.In.GetMsg()
+	msg.Metadata.PutValue(RemoteAddrKey, from)
+
+	ep.RLock()
+	routers := make([]*syslogRouter, len(ep.routers))
+	copy(routers, ep.routers)
+	ep.RUnlock()
+	for _, r := range routers {
+		if r.match(m) {
+			ep.DoProcess(context.Background(), r.router, exchange)
+		}
+	}
+}
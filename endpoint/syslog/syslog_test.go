@@ -0,0 +1,243 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package syslog
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// 测试请求/响应消息
+func TestSyslogMessage(t *testing.T) {
+	t.Run("Request", func(t *testing.T) {
+		var request = &RequestMessage{}
+		test.EndpointMessage(t, request)
+	})
+	t.Run("Response", func(t *testing.T) {
+		var response = &ResponseMessage{}
+		test.EndpointMessage(t, response)
+	})
+}
+
+func TestRouterId(t *testing.T) {
+	var ep = &Endpoint{}
+	err := ep.Init(types.NewConfig(), types.Configuration{"server": "127.0.0.1:5514"})
+	assert.Nil(t, err)
+	assert.Equal(t, "127.0.0.1:5514", ep.Id())
+	assert.Equal(t, ProtocolUDP, ep.Config.Protocol)
+	assert.Equal(t, DefaultMaxMessageSize, ep.Config.MaxMessageSize)
+	assert.Equal(t, ":514", ep.New().(*Endpoint).Config.Server)
+
+	_, err = ep.AddRouter(nil)
+	assert.Equal(t, "router can not nil", err.Error())
+	_, err = ep.AddRouter(impl.NewRouter().From("bad", types.Configuration{FromConfigKeyFacility: "unknown"}).End())
+	assert.Equal(t, "invalid facility configuration: unknown value: unknown", err.Error())
+	_, err = ep.AddRouter(impl.NewRouter().From("bad", types.Configuration{FromConfigKeySeverity: "<=9"}).End())
+	assert.NotNil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("bad", types.Configuration{FromConfigKeyAppName: "("}).End())
+	assert.NotNil(t, err)
+
+	routerId, err := ep.AddRouter(impl.NewRouter().SetId("r1").From("auth").End())
+	assert.Nil(t, err)
+	assert.Equal(t, "r1", routerId)
+	_, err = ep.AddRouter(impl.NewRouter().SetId("r1").From("auth").End())
+	assert.Equal(t, "duplicate router r1", err.Error())
+	routerId, _ = ep.AddRouter(impl.NewRouter().From("all").End())
+	assert.Equal(t, "all", routerId)
+
+	assert.Nil(t, ep.RemoveRouter("r1"))
+	assert.Nil(t, ep.RemoveRouter("all"))
+	err = ep.RemoveRouter("all")
+	assert.Equal(t, "router: all not found", err.Error())
+	assert.Equal(t, 0, len(ep.routers))
+
+	err = ep.Init(types.NewConfig(), types.Configuration{"server": "127.0.0.1:5514", "protocol": "sctp"})
+	assert.Nil(t, err)
+	assert.Equal(t, "unsupported protocol: sctp", ep.Start().Error())
+}
+
+func TestRouterMatch(t *testing.T) {
+	newRouter := func(config types.Configuration) *syslogRouter {
+		r, err := newSyslogRouter(impl.NewRouter().From("test", config).End())
+		assert.Nil(t, err)
+		return r
+	}
+	auth := &Message{Facility: 4, Severity: 3, AppName: "sshd"}
+	kern := &Message{Facility: 0, Severity: 6, AppName: "kernel"}
+
+	r := newRouter(types.Configuration{FromConfigKeyFacility: "auth, authpriv"})
+	assert.True(t, r.match(auth))
+	assert.False(t, r.match(kern))
+
+	r = newRouter(types.Configuration{FromConfigKeyFacility: "0"})
+	assert.False(t, r.match(auth))
+	assert.True(t, r.match(kern))
+
+	r = newRouter(types.Configuration{FromConfigKeySeverity: "<=warning"})
+	assert.True(t, r.match(auth))
+	assert.False(t, r.match(kern))
+
+	r = newRouter(types.Configuration{FromConfigKeySeverity: "info,debug"})
+	assert.False(t, r.match(auth))
+	assert.True(t, r.match(kern))
+
+	r = newRouter(types.Configuration{FromConfigKeyAppName: "^ssh", FromConfigKeyFacility: "auth"})
+	assert.True(t, r.match(auth))
+	assert.False(t, r.match(kern))
+
+	r = newRouter(nil)
+	assert.True(t, r.match(auth))
+	assert.True(t, r.match(kern))
+}
+
+func TestSyslogEndpoint(t *testing.T) {
+	certFile, keyFile := createCert(t)
+	for _, item := range []struct {
+		protocol string
+		server   string
+	}{
+		{ProtocolUDP, "127.0.0.1:5514"},
+		{ProtocolTCP, "127.0.0.1:5515"},
+		{ProtocolTLS, "127.0.0.1:5516"},
+	} {
+		t.Run(item.protocol, func(t *testing.T) {
+			testEndpoint(t, item.protocol, item.server, certFile, keyFile)
+		})
+	}
+}
+
+func testEndpoint(t *testing.T, protocol, server, certFile, keyFile string) {
+	ep := &Endpoint{}
+	err := ep.Init(types.NewConfig(), types.Configuration{
+		"protocol":    protocol,
+		"server":      server,
+		"certFile":    certFile,
+		"certKeyFile": keyFile,
+	})
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	authMsgs := make(chan *types.RuleMsg, 10)
+	allMsgs := make(chan *types.RuleMsg, 10)
+	_, err = ep.AddRouter(impl.NewRouter().From("auth", types.Configuration{
+		FromConfigKeyFacility: "auth,authpriv",
+		FromConfigKeySeverity: "<=warning",
+	}).Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		authMsgs <- exchange.In.GetMsg()
+		return false
+	}).End())
+	assert.Nil(t, err)
+	_, err = ep.AddRouter(impl.NewRouter().From("all").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		allMsgs <- exchange.In.GetMsg()
+		return false
+	}).End())
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+
+	var conn net.Conn
+	if protocol == ProtocolTLS {
+		conn, err = tls.Dial("tcp", server, &tls.Config{InsecureSkipVerify: true})
+	} else {
+		conn, err = net.Dial(protocol, server)
+	}
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	authMsg := `<34>1 2025-01-10T07:59:58Z host1 sshd 99 LOGIN [auth@1 user="root"] failed login`
+	infoMsg := `<14>Jan 10 07:59:59 host2 app[1]: hello`
+	if protocol == ProtocolUDP {
+		_, _ = conn.Write([]byte(authMsg))
+		_, _ = conn.Write([]byte(infoMsg))
+	} else {
+		//octet-counting 帧和换行分隔的帧混合发送
+		_, _ = conn.Write([]byte(strconv.Itoa(len(authMsg)) + " " + authMsg + infoMsg + "\n"))
+	}
+
+	receive := func(ch chan *types.RuleMsg) *types.RuleMsg {
+		select {
+		case msg := <-ch:
+			return msg
+		case <-time.After(time.Second * 2):
+			t.Fatal("receive timeout")
+		}
+		return nil
+	}
+	msg := receive(authMsgs)
+	assert.Equal(t, types.JSON, msg.DataType)
+	assert.Equal(t, conn.LocalAddr().String(), msg.Type)
+	assert.Equal(t, conn.LocalAddr().String(), msg.Metadata.GetValue(RemoteAddrKey))
+	assert.Equal(t, "auth", msg.Metadata.GetValue(KeyFacility))
+	assert.Equal(t, "crit", msg.Metadata.GetValue(KeySeverity))
+	assert.Equal(t, "34", msg.Metadata.GetValue(KeyPriority))
+	assert.Equal(t, "host1", msg.Metadata.GetValue(KeyHostname))
+	assert.Equal(t, "sshd", msg.Metadata.GetValue(KeyAppName))
+	assert.Equal(t, "99", msg.Metadata.GetValue(KeyProcId))
+	assert.Equal(t, "LOGIN", msg.Metadata.GetValue(KeyMsgId))
+	assert.Equal(t, FormatRFC5424, msg.Metadata.GetValue(KeyFormat))
+	assert.Equal(t, `{"format":"rfc5424","priority":34,"facility":4,"severity":2,"facilityName":"auth","severityName":"crit","version":1,"timestamp":"2025-01-10T07:59:58Z","hostname":"host1","appName":"sshd","procId":"99","msgId":"LOGIN","structuredData":{"auth@1":{"user":"root"}},"message":"failed login"}`, msg.GetData())
+
+	assert.Equal(t, "sshd", receive(allMsgs).Metadata.GetValue(KeyAppName))
+	msg = receive(allMsgs)
+	assert.Equal(t, "user", msg.Metadata.GetValue(KeyFacility))
+	assert.Equal(t, "info", msg.Metadata.GetValue(KeySeverity))
+	assert.Equal(t, "app", msg.Metadata.GetValue(KeyAppName))
+	assert.Equal(t, FormatRFC3164, msg.Metadata.GetValue(KeyFormat))
+	assert.Equal(t, 0, len(authMsgs))
+}
+
+// createCert 创建自签名测试证书，返回证书文件和私钥文件路径
+func createCert(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certDer, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer}), 0600))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certFile, keyFile
+}
@@ -44,7 +44,9 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
 	"github.com/rulego/rulego/endpoint/rest"
+	"github.com/rulego/rulego/utils/connection"
 	"github.com/rulego/rulego/utils/maps"
 	"github.com/rulego/rulego/utils/runtime"
 	"github.com/rulego/rulego/utils/str"
@@ -141,6 +143,7 @@ type ResponseMessage struct {
 	log         func(format string, v ...interface{})
 	request     *http.Request
 	conn        *websocket.Conn
+	client      *client
 	body        []byte
 	to          string
 	msg         *types.RuleMsg
@@ -202,8 +205,14 @@ func (r *ResponseMessage) SetBody(body []byte) {
 			r.messageType = websocket.TextMessage
 		}
 
-		if err := r.conn.WriteMessage(r.messageType, body); err != nil {
-			r.SetError(err)
+		var err error
+		if r.client != nil {
+			err = r.client.write(r.messageType, body)
+		} else {
+			err = r.conn.WriteMessage(r.messageType, body)
+		}
+		if err != nil {
+			r.err = err
 		}
 	}
 }
@@ -220,6 +229,30 @@ func (r *ResponseMessage) GetError() error {
 	return r.err
 }
 
+// client 在线的websocket连接，注册到连接注册表，写入加锁保证并发安全
+type client struct {
+	conn *websocket.Conn
+	lock sync.Mutex
+	//id 和 tags 当前注册的客户端ID和标签，只在读取协程中访问
+	id   string
+	tags []string
+}
+
+// Send 发送数据，二进制数据使用二进制帧，其他使用文本帧
+func (c *client) Send(dataType types.DataType, data []byte) error {
+	messageType := websocket.TextMessage
+	if dataType == types.BINARY {
+		messageType = websocket.BinaryMessage
+	}
+	return c.write(messageType, data)
+}
+
+func (c *client) write(messageType int, data []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.conn.WriteMessage(messageType, data)
+}
+
 // Config Websocket 服务配置
 type Config = rest.Config
 
@@ -229,6 +262,9 @@ type Websocket struct {
 	//配置
 	Config   Config
 	Upgrader websocket.Upgrader
+	//Connections 在线连接注册表，默认使用 connection.EndpointRegistry(Config.Server)，
+	//endpointSend 组件通过 server 配置查找
+	Connections *connection.Registry
 }
 
 // Type 组件类型
@@ -274,7 +310,9 @@ func (ws *Websocket) AddRouter(router endpoint.Router, params ...interface{}) (i
 				err = fmt.Errorf("addRouter err :%v", e)
 			}
 		}()
-		ws.addRouter(router)
+		if err = ws.addRouter(router); err != nil {
+			return "", err
+		}
 		return router.GetId(), err
 	}
 }
@@ -316,7 +354,7 @@ func (ws *Websocket) Start() error {
 }
 
 // addRouter 注册1个或者多个路由
-func (ws *Websocket) addRouter(routers ...endpoint.Router) *Websocket {
+func (ws *Websocket) addRouter(routers ...endpoint.Router) error {
 	ws.Lock()
 	defer ws.Unlock()
 
@@ -324,18 +362,26 @@ func (ws *Websocket) addRouter(routers ...endpoint.Router) *Websocket {
 		ws.RouterStorage = make(map[string]endpoint.Router)
 	}
 	for _, item := range routers {
+		//客户端ID和标签
+		var identity *connection.Identity
+		if from, ok := item.GetFrom().(*impl.From); ok {
+			var err error
+			if identity, err = connection.NewIdentity(from.Config); err != nil {
+				return err
+			}
+		}
 		item.SetParams("GET")
 		ws.CheckAndSetRouterId(item)
 		//存储路由
 		ws.RouterStorage[item.GetId()] = item
 		//添加到http路由器
-		ws.Router().Handle("GET", item.FromToString(), ws.handler(item))
+		ws.Router().Handle("GET", item.FromToString(), ws.handler(item, identity))
 	}
 
-	return ws
+	return nil
 }
 
-func (ws *Websocket) handler(router endpoint.Router) httprouter.Handle {
+func (ws *Websocket) handler(router endpoint.Router, identity *connection.Identity) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 		if router.IsDisable() {
 			http.NotFound(w, r)
@@ -346,6 +392,14 @@ func (ws *Websocket) handler(router endpoint.Router) httprouter.Handle {
 			ws.Printf("Websocket handler upgrade:", err)
 			return
 		}
+		wsClient := &client{conn: c}
+		//From 处理器通过后注册在线连接，处理器可以在元数据中设置客户端ID和标签的变量
+		var clientId string
+		acceptedRouter := connection.OnAccepted(router, func(exchange *endpoint.Exchange) {
+			clientId = ws.register(wsClient, identity, exchange.In.GetMsg().Metadata.Values())
+			exchange.In.GetMsg().Metadata.PutValue(connection.KeyClientId, clientId)
+		})
+		defer ws.connections().Unregister(wsClient)
 		connectExchange := &endpoint.Exchange{
 			In: &RequestMessage{
 				request: r,
@@ -358,6 +412,7 @@ func (ws *Websocket) handler(router endpoint.Router) httprouter.Handle {
 				},
 				request: r,
 				conn:    c,
+				client:  wsClient,
 			}}
 		if ws.OnEvent != nil {
 			ws.OnEvent(endpoint.EventConnect, connectExchange)
@@ -406,6 +461,7 @@ func (ws *Websocket) handler(router endpoint.Router) httprouter.Handle {
 					},
 					request:     r,
 					conn:        c,
+					client:      wsClient,
 					messageType: mt,
 				}}
 
@@ -426,7 +482,33 @@ func (ws *Websocket) handler(router endpoint.Router) httprouter.Handle {
 				}
 
 			}
-			ws.DoProcess(r.Context(), router, exchange)
+			if clientId != "" {
+				msg.Metadata.PutValue(connection.KeyClientId, clientId)
+			}
+			ws.DoProcess(r.Context(), acceptedRouter, exchange)
 		}
 	}
 }
+
+// register 注册在线连接，返回客户端ID。元数据中没有客户端ID的变量时沿用之前注册的ID和标签，
+// 没有配置客户端ID时使用远程地址
+func (ws *Websocket) register(c *client, identity *connection.Identity, metadata map[string]string) string {
+	id, tags := identity.Resolve(metadata)
+	if id == "" && c.id != "" {
+		id, tags = c.id, c.tags
+	}
+	if id == "" {
+		id = c.conn.RemoteAddr().String()
+	}
+	c.id, c.tags = id, tags
+	ws.connections().Register(c, id, tags...)
+	return id
+}
+
+// connections 返回在线连接注册表，没有指定时使用端点服务地址对应的注册表
+func (ws *Websocket) connections() *connection.Registry {
+	if ws.Connections == nil {
+		return connection.EndpointRegistry(ws.Config.Server)
+	}
+	return ws.Connections
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/rulego/rulego/engine"
 	"github.com/rulego/rulego/test"
 	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/connection"
 	"github.com/rulego/rulego/utils/maps"
 )
 
@@ -303,3 +304,68 @@ func newWebsocketServe(t *testing.T, restEndpoint *rest.Rest) endpoint.Endpoint
 	assert.NotNil(t, wsEndpoint.Router())
 	return wsEndpoint
 }
+
+// 测试在线连接注册表，规则链在请求交换之外按客户端ID和标签推送
+func TestConnectionRegistry(t *testing.T) {
+	registry := connection.NewRegistry()
+	ep := &Endpoint{Connections: registry}
+	err := ep.Init(types.NewConfig(), types.Configuration{"server": ":9092", "allowCors": true})
+	assert.Nil(t, err)
+	defer ep.Destroy()
+
+	router := impl.NewRouter().From("/ws/:tenant", types.Configuration{
+		endpoint.FromConfigKeyClientId:   "${deviceId}",
+		endpoint.FromConfigKeyClientTags: []string{"tenant/${tenant}"},
+	}).Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		//认证失败的连接不注册
+		return exchange.In.GetMsg().Metadata.GetValue("token") == "secret"
+	}).Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		exchange.Out.SetBody([]byte(exchange.In.GetMsg().Metadata.GetValue(connection.KeyClientId)))
+		return true
+	}).End()
+	_, err = ep.AddRouter(router)
+	assert.Nil(t, err)
+	assert.Nil(t, ep.Start())
+	time.Sleep(time.Millisecond * 200)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://127.0.0.1:9092/ws/t1?deviceId=d1&token=secret", nil)
+	assert.Nil(t, err)
+	defer conn.Close()
+	time.Sleep(time.Millisecond * 100)
+	//处理器通过前不注册
+	assert.Equal(t, 0, len(registry.Clients("")))
+
+	//处理器通过后注册，之后的请求交换携带客户端ID
+	assert.Nil(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	_, data, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "", string(data))
+	assert.Equal(t, []string{"d1"}, registry.Clients("tenant/t1"))
+	assert.Nil(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	_, data, err = conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "d1", string(data))
+
+	//未认证的连接不能替代其他连接的客户端ID
+	attacker, _, err := websocket.DefaultDialer.Dial("ws://127.0.0.1:9092/ws/t1?deviceId=d1", nil)
+	assert.Nil(t, err)
+	defer attacker.Close()
+	assert.Nil(t, attacker.WriteMessage(websocket.TextMessage, []byte("hello")))
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, []string{"d1"}, registry.Clients(""))
+
+	//推送
+	count, err := registry.SendToTag("tenant/t1", types.BINARY, []byte{0x01, 0x02})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	mt, data, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, websocket.BinaryMessage, mt)
+	assert.Equal(t, []byte{0x01, 0x02}, data)
+	_ = attacker.Close()
+
+	//断开后取消注册
+	_ = conn.Close()
+	time.Sleep(time.Millisecond * 200)
+	assert.Equal(t, 0, len(registry.Clients("")))
+}
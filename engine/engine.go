@@ -1177,6 +1177,15 @@ func (e *RuleEngine) onMsgAndWait(msg types.RuleMsg, wait bool, opts ...types.Ru
 	rootCtx := e.rootRuleChainCtx.rootRuleContext.(*DefaultRuleContext)
 	e.applyShutdownContext(rootCtxCopy, rootCtx)
 
+	// Track payloads spilled to the BlobStore and message streams during this run, they are released when the run completes
+	// 跟踪本次运行中溢出到BlobStore的负载和消息流，运行结束后释放
+	tracker := attachRunTracker(rootCtxCopy)
+	if rootCtxCopy.config.BlobStore != nil && msg.Data != nil {
+		// Spill the engine's own copy so the caller's message is left intact
+		// 溢出引擎自己的副本，避免影响调用方的消息
+		msg.Data = msg.Data.Copy()
+	}
+
 	// Validate rule chain and context state
 	// 验证规则链和上下文状态
 	if err := e.validateRuleChainState(rootCtxCopy); err != nil {
@@ -1198,7 +1207,7 @@ func (e *RuleEngine) onMsgAndWait(msg types.RuleMsg, wait bool, opts ...types.Ru
 
 	// Process message with or without waiting
 	// 处理消息，可选择是否等待
-	e.processMessage(rootCtxCopy, processedMsg, wait)
+	e.processMessage(rootCtxCopy, processedMsg, wait, tracker)
 }
 
 // onStart executes the list of start aspects before the rule chain begins processing a message.
@@ -1291,7 +1300,10 @@ func (e *RuleEngine) setupEndCallback(rootCtxCopy *DefaultRuleContext) {
 
 // processMessage processes the message through the rule chain with optional waiting.
 // processMessage 通过规则链处理消息，可选择等待。
-func (e *RuleEngine) processMessage(rootCtxCopy *DefaultRuleContext, msg types.RuleMsg, wait bool) {
+// tracker is the tracker owned by this run, its spilled payloads are deleted and its unconsumed
+// message streams are closed once all nodes have completed.
+// tracker 是本次运行拥有的 tracker，所有节点完成后删除其溢出的负载并关闭未消费的消息流。
+func (e *RuleEngine) processMessage(rootCtxCopy *DefaultRuleContext, msg types.RuleMsg, wait bool, tracker *runTracker) {
 	// Set up a custom function to be called upon completion of all nodes
 	// 设置在所有节点完成时要调用的自定义函数
 	customFunc := rootCtxCopy.onAllNodeCompleted
@@ -1305,6 +1317,9 @@ func (e *RuleEngine) processMessage(rootCtxCopy *DefaultRuleContext, msg types.R
 			// Execute the completion handling function
 			// 执行完成处理函数
 			e.doOnAllNodeCompleted(rootCtxCopy, msg, customFunc)
+			if tracker != nil {
+				tracker.release(rootCtxCopy.config.Logger)
+			}
 		}
 		// Process the message through the rule chain
 		// 通过规则链处理消息
@@ -1317,6 +1332,9 @@ func (e *RuleEngine) processMessage(rootCtxCopy *DefaultRuleContext, msg types.R
 		// 如果不等待，只需设置完成处理函数
 		rootCtxCopy.onAllNodeCompleted = func() {
 			e.doOnAllNodeCompleted(rootCtxCopy, msg, customFunc)
+			if tracker != nil {
+				tracker.release(rootCtxCopy.config.Logger)
+			}
 		}
 		// Process the message through the rule chain
 		// 通过规则链处理消息
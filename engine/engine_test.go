@@ -1771,6 +1771,12 @@ func TestMetadataIsolationInMultipleNodes(t *testing.T) {
 	assert.Equal(t, "shared_value", branch1Msg.Metadata.GetValue("shared_key"))
 	assert.Equal(t, "shared_value", branch2Msg.Metadata.GetValue("shared_key"))
 
+	// 验证分叉后的消息血缘
+	assert.True(t, branch1Msg.Id != branch2Msg.Id)
+	assert.Equal(t, msg.Id, branch1Msg.ParentId)
+	assert.Equal(t, msg.Id, branch2Msg.ParentId)
+	assert.Equal(t, msg.Id, branch1Msg.CorrelationId)
+	assert.Equal(t, msg.Id, branch2Msg.CorrelationId)
 }
 
 // TestGroupActionNodeIntegration 测试 GroupActionNode 在完整规则链中的集成功能
@@ -33,7 +33,7 @@ var _ types.RuleContext = (*DefaultRuleContext)(nil)
 // GetEnv 获取环境变量和元数据
 func (ctx *DefaultRuleContext) GetEnv(msg types.RuleMsg, useMetadata bool) map[string]interface{} {
 	// 预分配合适大小的map，减少扩容开销
-	capacity := 7 // 基础字段数量：id, ts, data, msgType, dataType, msg, metadata
+	capacity := 9 // 基础字段数量：id, parentId, correlationId, ts, data, msgType, dataType, msg, metadata
 	if msg.Metadata != nil && useMetadata {
 		// 估算metadata的键值对数量
 		capacity += 8 // 常见metadata数量的估计值
@@ -43,6 +43,8 @@ func (ctx *DefaultRuleContext) GetEnv(msg types.RuleMsg, useMetadata bool) map[s
 
 	// 设置基础字段
 	evn[types.IdKey] = msg.Id
+	evn[types.ParentIdKey] = msg.ParentId
+	evn[types.CorrelationIdKey] = msg.GetCorrelationId()
 	evn[types.TsKey] = msg.Ts
 	evn[types.DataKey] = msg.GetData()
 	evn[types.MsgTypeKey] = msg.Type
@@ -60,7 +62,16 @@ func (ctx *DefaultRuleContext) GetEnv(msg types.RuleMsg, useMetadata bool) map[s
 	}
 
 	// 处理metadata - 使用零拷贝ForEach优化
-	if msg.Metadata != nil {
+	if msg.Metadata != nil && msg.Metadata.HasTypedValues() {
+		// 类型化的metadata以原生类型暴露给表达式
+		if useMetadata {
+			msg.Metadata.ForEachTyped(func(k string, v interface{}) bool {
+				evn[k] = v
+				return true // continue iteration
+			})
+		}
+		evn[types.MetadataKey] = msg.Metadata.TypedValues()
+	} else if msg.Metadata != nil {
 		if useMetadata {
 			// 使用零拷贝ForEach将metadata键值对添加到环境变量中
 			msg.Metadata.ForEach(func(k, v string) bool {
@@ -404,6 +415,7 @@ func (ctx *DefaultRuleContext) NewNextNodeRuleContext(nextNode types.NodeCtx) *D
 		skipTellNext:  ctx.skipTellNext,
 
 		// 共享切面列表，它们在运行时不会改变
+		aspects:       ctx.aspects,
 		aroundAspects: ctx.aroundAspects,
 		beforeAspects: ctx.beforeAspects,
 		afterAspects:  ctx.afterAspects,
@@ -646,6 +658,11 @@ func (ctx *DefaultRuleContext) DoOnEnd(msg types.RuleMsg, err error, relationTyp
 		if msgToUse.Metadata == nil {
 			msgToUse.SetMetadata(types.NewMetadata())
 		}
+		// 回调可能在溢出的负载被删除后执行，先加载到内存
+		if loadErr := loadSpilledData(msgToUse); err == nil && loadErr != nil {
+			err = loadErr
+			relationType = types.Failure
+		}
 	} else {
 		msgToUse = msg
 	}
@@ -717,6 +734,7 @@ func (ctx *DefaultRuleContext) OnDebug(ruleChainId string, flowType string, node
 	var msgCopy types.RuleMsg
 	if needsAsyncDebug || needsSnapshotDebug || needsSnapshot {
 		msgCopy = msg.Copy()
+		loadSpilledData(msgCopy)
 	}
 
 	if ctx.IsDebugMode() {
@@ -843,8 +861,16 @@ func (ctx *DefaultRuleContext) tell(msg types.RuleMsg, err error, relationTypes
 // tellNext 通知执行子节点，如果是当前第一个节点则执行当前节点
 // 如果找不到relationTypes对应的节点，而且defaultRelationType非默认值，则通过defaultRelationType查找节点
 func (ctx *DefaultRuleContext) tellOrElse(msg types.RuleMsg, err error, defaultRelationType string, relationTypes ...string) {
+	//节点读取溢出的负载失败时处理的是空数据，改为通过Failure关系路由
+	if loadErr := spilledLoadErr(msg); err == nil && loadErr != nil {
+		err = loadErr
+		relationTypes = []string{types.Failure}
+		defaultRelationType = ""
+	}
 	ctx.out = msg
 	ctx.err = err
+	//登记节点产生的消息流，没有被消费则在运行结束后关闭
+	ctx.trackStream(msg)
 	if ctx.isFirst {
 		ctx.tellSelf(msg, err, relationTypes...)
 	} else {
@@ -866,18 +892,22 @@ func (ctx *DefaultRuleContext) tellOrElse(msg types.RuleMsg, err error, defaultR
 				if ok && !ctx.skipTellNext {
 					// 内存优化：对于只读节点，避免不必要的消息拷贝
 					needsCopy := len(nodes) > 1 // 只有多个子节点时才需要拷贝
+					if needsCopy {
+						//扇出前溢出大负载，所有分支共享同一个句柄
+						ctx.spillData(msg, true)
+					}
 
-					for i, item := range nodes {
+					for _, item := range nodes {
 						tmp := item
 						//增加一个待执行的子节点
 						ctx.childReady()
 
 						var msgToPass types.RuleMsg
-						if needsCopy && i < len(nodes)-1 {
-							//为除最后一个节点外的其他节点创建拷贝
-							msgToPass = msg.Copy()
+						if needsCopy {
+							//扇出到多个节点，为每个分支创建子消息，记录消息血缘
+							msgToPass = msg.CopyAsChild()
 						} else {
-							//最后一个节点或唯一节点可以直接使用原消息
+							//唯一节点可以直接使用原消息
 							msgToPass = msg
 						}
 
@@ -895,6 +925,18 @@ func (ctx *DefaultRuleContext) tellOrElse(msg types.RuleMsg, err error, defaultR
 	}
 }
 
+// onMsgExpired 通知过期切面，并通过当前节点的Expired关系路由消息，如果没有该关系则结束分支
+func (ctx *DefaultRuleContext) onMsgExpired(msg types.RuleMsg) {
+	for _, aop := range ctx.aspects {
+		if a, ok := aop.(types.MsgExpiredAspect); ok && a.PointCut(ctx, msg, types.Expired) {
+			a.MsgExpired(ctx, msg)
+		}
+	}
+	//标记过期已处理，Expired关系后续的处理链路不再检查过期
+	msg.SetExpireAt(0)
+	ctx.tell(msg, types.ErrMsgExpired, types.Expired)
+}
+
 // 执行环绕aop
 // 返回值true: 继续执行下一个节点，否则不执行
 func (ctx *DefaultRuleContext) executeAroundAop(msg types.RuleMsg, relationType string) bool {
@@ -962,6 +1004,15 @@ func (ctx *DefaultRuleContext) tellNext(msg types.RuleMsg, nextNode types.NodeCt
 
 	nextCtx := ctx.NewNextNodeRuleContext(nextNode)
 
+	// 过期消息不执行节点，通过Expired关系路由
+	if msg.IsExpired() {
+		nextCtx.onMsgExpired(msg)
+		return
+	}
+
+	//首次超过阈值的大负载溢出到BlobStore，只有句柄随消息传递
+	ctx.spillData(msg, false)
+
 	//环绕aop
 	if !nextCtx.executeAroundAop(msg, relationType) {
 		// 如果AroundAspect阻止了执行，需要调用childDone来平衡之前的childReady
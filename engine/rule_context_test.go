@@ -18,14 +18,22 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"reflect"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/action"
 	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/blob"
 	"github.com/rulego/rulego/utils/str"
 )
 
@@ -237,3 +245,432 @@ func TestRuleContext(t *testing.T) {
 		assert.Equal(t, int32(0), atomic.LoadInt32(&count))
 	})
 }
+
+// TestMsgExpired 测试过期消息不执行节点，通过Expired关系路由或结束分支
+func TestMsgExpired(t *testing.T) {
+	var expiredChain = `{
+		"ruleChain": {
+			"id": "test_msg_expired"
+		},
+		"metadata": {
+			"nodes": [
+				{
+					"id": "s1",
+					"type": "functions",
+					"configuration": {
+						"functionName": "slowForExpired"
+					}
+				},
+				{
+					"id": "s2",
+					"type": "jsTransform",
+					"configuration": {
+						"jsScript": "metadata.s2='true';return {'msg':msg,'metadata':metadata,'msgType':msgType};"
+					}
+				},
+				{
+					"id": "s3",
+					"type": "jsTransform",
+					"configuration": {
+						"jsScript": "metadata.expiredHandled='true';return {'msg':msg,'metadata':metadata,'msgType':msgType};"
+					}
+				},
+				{
+					"id": "s4",
+					"type": "jsTransform",
+					"configuration": {
+						"jsScript": "metadata.expiredNotified='true';return {'msg':msg,'metadata':metadata,'msgType':msgType};"
+					}
+				}
+			],
+			"connections": [
+				{
+					"fromId": "s1",
+					"toId": "s2",
+					"type": "Success"
+				},
+				{
+					"fromId": "s2",
+					"toId": "s3",
+					"type": "Expired"
+				},
+				{
+					"fromId": "s3",
+					"toId": "s4",
+					"type": "Success"
+				}
+			]
+		}
+	}`
+	var s1Count int32
+	action.Functions.Register("slowForExpired", func(ctx types.RuleContext, msg types.RuleMsg) {
+		atomic.AddInt32(&s1Count, 1)
+		time.Sleep(time.Millisecond * 100)
+		ctx.TellSuccess(msg)
+	})
+	ruleEngine, err := New(str.RandomStr(10), []byte(expiredChain), WithConfig(NewConfig()))
+	assert.Nil(t, err)
+	defer Del(ruleEngine.Id())
+
+	t.Run("ExpiredRelation", func(t *testing.T) {
+		msg := types.NewMsgWithTTL(0, "TEST", types.JSON, types.NewMetadata(), "{}", time.Millisecond*50)
+		var endMsg types.RuleMsg
+		var endErr error
+		var endRelationType string
+		ruleEngine.OnMsgAndWait(msg, types.WithOnEnd(func(ctx types.RuleContext, msg types.RuleMsg, err error, relationType string) {
+			endMsg = msg
+			endErr = err
+			endRelationType = relationType
+		}))
+		assert.Nil(t, endErr)
+		assert.Equal(t, types.Success, endRelationType)
+		assert.Equal(t, "", endMsg.Metadata.GetValue("s2"))
+		assert.Equal(t, "true", endMsg.Metadata.GetValue("expiredHandled"))
+		//Expired分支的后续节点不再按过期处理
+		assert.Equal(t, "true", endMsg.Metadata.GetValue("expiredNotified"))
+		assert.Equal(t, int64(0), endMsg.GetExpireAt())
+	})
+
+	t.Run("DeadEnd", func(t *testing.T) {
+		msg := types.NewMsg(0, "TEST", types.JSON, types.NewMetadata(), "{}")
+		msg.SetExpireAt(time.Now().Add(-time.Second).UnixMilli())
+		atomic.StoreInt32(&s1Count, 0)
+		var endErr error
+		var endRelationType string
+		ruleEngine.OnMsgAndWait(msg, types.WithOnEnd(func(ctx types.RuleContext, msg types.RuleMsg, err error, relationType string) {
+			endErr = err
+			endRelationType = relationType
+		}))
+		assert.Equal(t, types.ErrMsgExpired, endErr)
+		assert.Equal(t, types.Expired, endRelationType)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&s1Count))
+	})
+
+	t.Run("NotExpired", func(t *testing.T) {
+		msg := types.NewMsgWithTTL(0, "TEST", types.JSON, types.NewMetadata(), "{}", time.Minute)
+		var endMsg types.RuleMsg
+		ruleEngine.OnMsgAndWait(msg, types.WithOnEnd(func(ctx types.RuleContext, msg types.RuleMsg, err error, relationType string) {
+			endMsg = msg
+		}))
+		assert.Equal(t, "true", endMsg.Metadata.GetValue("s2"))
+		assert.Equal(t, "", endMsg.Metadata.GetValue("expiredHandled"))
+		assert.Equal(t, "", endMsg.Metadata.GetValue("expiredNotified"))
+	})
+
+	assert.Equal(t, int64(2), ruleEngine.GetMetrics().Get().Expired)
+}
+
+// TestSpillLargePayload 测试大负载溢出到BlobStore，扇出时共享句柄，运行结束后删除
+func TestSpillLargePayload(t *testing.T) {
+	var spillChain = `{
+		"ruleChain": {
+			"id": "test_spill_payload"
+		},
+		"metadata": {
+			"nodes": [
+				{
+					"id": "s1",
+					"type": "functions",
+					"configuration": {
+						"functionName": "checkSpilled"
+					}
+				},
+				{
+					"id": "s2",
+					"type": "functions",
+					"configuration": {
+						"functionName": "checkSpilled"
+					}
+				},
+				{
+					"id": "s3",
+					"type": "functions",
+					"configuration": {
+						"functionName": "checkSpilled"
+					}
+				}
+			],
+			"connections": [
+				{
+					"fromId": "s1",
+					"toId": "s2",
+					"type": "Success"
+				},
+				{
+					"fromId": "s1",
+					"toId": "s3",
+					"type": "Success"
+				}
+			]
+		}
+	}`
+	payload := strings.Repeat("a", 1024)
+	var spilledCount, matchedCount int32
+	var handles sync.Map
+	action.Functions.Register("checkSpilled", func(ctx types.RuleContext, msg types.RuleMsg) {
+		if msg.Data.IsSpilled() {
+			atomic.AddInt32(&spilledCount, 1)
+			handles.Store(msg.Data.BlobHandle(), true)
+		}
+		if msg.GetData() == payload {
+			atomic.AddInt32(&matchedCount, 1)
+		}
+		ctx.TellSuccess(msg)
+	})
+
+	dir := t.TempDir()
+	store, err := blob.NewFileStore(dir)
+	assert.Nil(t, err)
+	config := NewConfig(types.WithBlobStore(store, 512))
+	ruleEngine, err := New(str.RandomStr(10), []byte(spillChain), WithConfig(config))
+	assert.Nil(t, err)
+	defer Del(ruleEngine.Id())
+
+	t.Run("Spill", func(t *testing.T) {
+		msg := types.NewMsg(0, "TEST", types.TEXT, types.NewMetadata(), payload)
+		var endCount int32
+		var endMatched int32
+		ruleEngine.OnMsgAndWait(msg, types.WithOnEnd(func(ctx types.RuleContext, msg types.RuleMsg, err error, relationType string) {
+			atomic.AddInt32(&endCount, 1)
+			if !msg.Data.IsSpilled() && msg.GetData() == payload {
+				atomic.AddInt32(&endMatched, 1)
+			}
+		}))
+		// 每个节点收到的都是句柄，读取时加载
+		assert.Equal(t, int32(3), atomic.LoadInt32(&spilledCount))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&matchedCount))
+		// 扇出的分支共享同一个句柄
+		var handleCount int
+		handles.Range(func(key, value interface{}) bool {
+			handleCount++
+			return true
+		})
+		assert.Equal(t, 1, handleCount)
+		// 结束回调收到的是已加载的负载
+		assert.Equal(t, int32(2), atomic.LoadInt32(&endCount))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&endMatched))
+		// 调用方的消息不受影响
+		assert.False(t, msg.Data.IsSpilled())
+		assert.Equal(t, "", msg.Data.BlobHandle())
+		// 运行结束后删除溢出的负载
+		entries, err := os.ReadDir(dir)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(entries))
+	})
+
+	t.Run("BelowThreshold", func(t *testing.T) {
+		atomic.StoreInt32(&spilledCount, 0)
+		msg := types.NewMsg(0, "TEST", types.TEXT, types.NewMetadata(), "small")
+		ruleEngine.OnMsgAndWait(msg)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&spilledCount))
+	})
+}
+
+// countingBlobStore 统计读取次数的BlobStore
+type countingBlobStore struct {
+	types.BlobStore
+	gets int32
+}
+
+func (s *countingBlobStore) Get(handle string) ([]byte, error) {
+	atomic.AddInt32(&s.gets, 1)
+	return s.BlobStore.Get(handle)
+}
+
+// TestSpillLargePayloadChain 测试单链路上已加载的负载不会在每个节点前重新溢出
+func TestSpillLargePayloadChain(t *testing.T) {
+	var chain = `{
+		"ruleChain": {
+			"id": "test_spill_payload_chain"
+		},
+		"metadata": {
+			"nodes": [
+				{
+					"id": "s1",
+					"type": "functions",
+					"configuration": {
+						"functionName": "readSpilled"
+					}
+				},
+				{
+					"id": "s2",
+					"type": "functions",
+					"configuration": {
+						"functionName": "readSpilled"
+					}
+				},
+				{
+					"id": "s3",
+					"type": "functions",
+					"configuration": {
+						"functionName": "readSpilled"
+					}
+				}
+			],
+			"connections": [
+				{
+					"fromId": "s1",
+					"toId": "s2",
+					"type": "Success"
+				},
+				{
+					"fromId": "s2",
+					"toId": "s3",
+					"type": "Success"
+				}
+			]
+		}
+	}`
+	payload := strings.Repeat("a", 1024)
+	var spilledCount, matchedCount int32
+	action.Functions.Register("readSpilled", func(ctx types.RuleContext, msg types.RuleMsg) {
+		if msg.Data.IsSpilled() {
+			atomic.AddInt32(&spilledCount, 1)
+		}
+		if msg.GetData() == payload {
+			atomic.AddInt32(&matchedCount, 1)
+		}
+		ctx.TellSuccess(msg)
+	})
+
+	dir := t.TempDir()
+	fileStore, err := blob.NewFileStore(dir)
+	assert.Nil(t, err)
+	store := &countingBlobStore{BlobStore: fileStore}
+	ruleEngine, err := New(str.RandomStr(10), []byte(chain), WithConfig(NewConfig(types.WithBlobStore(store, 512))))
+	assert.Nil(t, err)
+	defer Del(ruleEngine.Id())
+
+	msg := types.NewMsg(0, "TEST", types.TEXT, types.NewMetadata(), payload)
+	ruleEngine.OnMsgAndWait(msg)
+	// 只有第一个节点收到句柄，之后的节点沿用已加载的负载
+	assert.Equal(t, int32(1), atomic.LoadInt32(&spilledCount))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&matchedCount))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&store.gets))
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(entries))
+}
+
+// failingBlobStore 读取总是失败的BlobStore
+type failingBlobStore struct {
+	types.BlobStore
+}
+
+func (s *failingBlobStore) Get(handle string) ([]byte, error) {
+	return nil, errors.New("blob store unavailable")
+}
+
+// TestSpillLoadFailure 测试节点读取溢出负载失败时通过Failure关系路由，而不是处理空数据
+func TestSpillLoadFailure(t *testing.T) {
+	var chain = `{
+		"ruleChain": {
+			"id": "test_spill_load_failure"
+		},
+		"metadata": {
+			"nodes": [
+				{
+					"id": "s1",
+					"type": "functions",
+					"configuration": {
+						"functionName": "readSpilledFailed"
+					}
+				}
+			],
+			"connections": []
+		}
+	}`
+	payload := strings.Repeat("a", 1024)
+	var readData atomic.Value
+	action.Functions.Register("readSpilledFailed", func(ctx types.RuleContext, msg types.RuleMsg) {
+		readData.Store(msg.GetData())
+		ctx.TellSuccess(msg)
+	})
+
+	fileStore, err := blob.NewFileStore(t.TempDir())
+	assert.Nil(t, err)
+	store := &failingBlobStore{BlobStore: fileStore}
+	ruleEngine, err := New(str.RandomStr(10), []byte(chain), WithConfig(NewConfig(types.WithBlobStore(store, 512))))
+	assert.Nil(t, err)
+	defer Del(ruleEngine.Id())
+
+	var endErr error
+	var endRelation string
+	msg := types.NewMsg(0, "TEST", types.TEXT, types.NewMetadata(), payload)
+	ruleEngine.OnMsgAndWait(msg, types.WithOnEnd(func(ctx types.RuleContext, msg types.RuleMsg, err error, relationType string) {
+		endErr = err
+		endRelation = relationType
+	}))
+	assert.Equal(t, "", readData.Load())
+	assert.NotNil(t, endErr)
+	assert.True(t, strings.Contains(endErr.Error(), "blob store unavailable"))
+	assert.Equal(t, types.Failure, endRelation)
+}
+
+// TestUnconsumedStreamClosed 测试没有被消费的消息流在运行结束后关闭
+func TestUnconsumedStreamClosed(t *testing.T) {
+	closed := make(chan struct{})
+	done := make(chan struct{})
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("first chunk"))
+		w.(http.Flusher).Flush()
+		// 持续响应，直到客户端关闭连接
+		select {
+		case <-r.Context().Done():
+			close(closed)
+		case <-done:
+		}
+	}))
+	defer testServer.Close()
+	defer close(done)
+
+	var chain = `{
+		"ruleChain": {
+			"id": "test_unconsumed_stream"
+		},
+		"metadata": {
+			"nodes": [
+				{
+					"id": "s1",
+					"type": "restApiCall",
+					"configuration": {
+						"restEndpointUrlPattern": "` + testServer.URL + `",
+						"requestMethod": "GET",
+						"readTimeoutMs": 0,
+						"streamResponse": true
+					}
+				},
+				{
+					"id": "s2",
+					"type": "jsFilter",
+					"configuration": {
+						"jsScript": "return false;"
+					}
+				}
+			],
+			"connections": [
+				{
+					"fromId": "s1",
+					"toId": "s2",
+					"type": "Success"
+				}
+			]
+		}
+	}`
+	ruleEngine, err := New(str.RandomStr(10), []byte(chain), WithConfig(NewConfig()))
+	assert.Nil(t, err)
+	defer Del(ruleEngine.Id())
+
+	var streamed bool
+	ruleEngine.OnMsgAndWait(types.NewMsg(0, "TEST", types.TEXT, types.NewMetadata(), ""), types.WithOnEnd(func(ctx types.RuleContext, msg types.RuleMsg, err error, relationType string) {
+		streamed = msg.GetStream() != nil
+	}))
+	// 过滤节点丢弃了消息，响应流没有被消费
+	assert.True(t, streamed)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("unconsumed response stream was not closed")
+	}
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package engine
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rulego/rulego/api/types"
+)
+
+// runTrackerKey is the context key of the runTracker of the current rule chain run.
+// runTrackerKey 是当前规则链运行的 runTracker 在 context 中的键。
+type runTrackerKey struct{}
+
+// blobRecord identifies a spilled payload and the store holding it.
+type blobRecord struct {
+	store  types.BlobStore
+	handle string
+}
+
+// runTracker records the resources created during one rule chain run that must be released
+// once the run completes: payloads spilled to a BlobStore are deleted and message streams
+// that no node or callback consumed are closed. Sub rule chains started from the run
+// share the tracker through the context, the outermost run owns and releases it.
+// runTracker 记录一次规则链运行中创建、运行结束后需要释放的资源：删除溢出到 BlobStore 的负载，
+// 关闭没有被节点或回调消费的消息流。由该运行触发的子规则链通过 context 共享同一个 tracker，
+// 由最外层运行负责释放。
+type runTracker struct {
+	mu      sync.Mutex
+	records []blobRecord
+	streams map[*types.MsgStream]struct{}
+	// released indicates the run has completed and no more resources are tracked
+	released bool
+}
+
+func newRunTracker() *runTracker {
+	return &runTracker{}
+}
+
+// runTrackerFromContext returns the runTracker of the current run, or nil if there is none.
+func runTrackerFromContext(ctx context.Context) *runTracker {
+	if ctx == nil {
+		return nil
+	}
+	tracker, _ := ctx.Value(runTrackerKey{}).(*runTracker)
+	return tracker
+}
+
+// spill moves data to store and records its handle. Nothing is spilled once the run has completed,
+// since the payload could no longer be deleted.
+func (t *runTracker) spill(store types.BlobStore, data *types.SharedData) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.released {
+		return nil
+	}
+	// Payloads that already have a handle were recorded when they were first spilled
+	fresh := data.BlobHandle() == ""
+	handle, err := data.Spill(store)
+	if err != nil {
+		return err
+	}
+	if fresh && handle != "" {
+		t.records = append(t.records, blobRecord{store: store, handle: handle})
+	}
+	return nil
+}
+
+// trackStream records a message stream so it is closed when the run completes, unless it is consumed before.
+// A stream reaching the tracker after the run has completed is left to its consumer.
+func (t *runTracker) trackStream(stream *types.MsgStream) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.released {
+		return
+	}
+	if t.streams == nil {
+		t.streams = make(map[*types.MsgStream]struct{})
+	}
+	t.streams[stream] = struct{}{}
+}
+
+// release deletes all recorded payloads and closes the streams that were not consumed.
+func (t *runTracker) release(logger types.Logger) {
+	t.mu.Lock()
+	records := t.records
+	streams := t.streams
+	t.records = nil
+	t.streams = nil
+	t.released = true
+	t.mu.Unlock()
+
+	for _, record := range records {
+		if err := record.store.Delete(record.handle); err != nil && logger != nil {
+			logger.Printf("delete spilled payload handle=%s error: %v", record.handle, err)
+		}
+	}
+	for stream := range streams {
+		// Close is a no-op for streams a node or callback has already taken
+		if err := stream.Close(); err != nil && logger != nil {
+			logger.Printf("close unconsumed message stream error: %v", err)
+		}
+	}
+}
+
+// spillData moves the message payload to the configured BlobStore if it exceeds the threshold,
+// so only a handle travels with the message. Payloads are only spilled inside a tracked run.
+// On a single-successor hop only payloads that first cross the threshold are spilled, a payload
+// already loaded from its handle stays in memory. On a fan-out the in-memory copy of a spilled
+// payload is released as well, so the branches share the handle instead of the bytes.
+// spillData 当消息负载超过阈值时将其溢出到配置的 BlobStore，消息中只保留句柄。
+// 只有在被跟踪的规则链运行中才会溢出。单个后继节点时只溢出首次超过阈值的负载，
+// 已从句柄加载的负载保留在内存中；扇出时同时释放已溢出负载的内存副本，各分支共享句柄。
+func (ctx *DefaultRuleContext) spillData(msg types.RuleMsg, fanOut bool) {
+	store := ctx.config.BlobStore
+	if store == nil || msg.Data == nil {
+		return
+	}
+	tracker := runTrackerFromContext(ctx.GetContext())
+	if tracker == nil {
+		return
+	}
+	if msg.Data.BlobHandle() != "" {
+		// A payload that is already spilled only needs its in-memory copy released at a fan-out
+		if !fanOut {
+			return
+		}
+	} else if msg.Data.Len() <= ctx.config.GetBlobSpillThreshold() {
+		return
+	}
+	if err := tracker.spill(store, msg.Data); err != nil && ctx.config.Logger != nil {
+		ctx.config.Logger.Printf("spill payload msgId=%s error: %v", msg.Id, err)
+	}
+}
+
+// trackStream records the message stream, if any, with the tracker of the current run,
+// so it is closed when the run completes if no node or callback consumed it.
+// trackStream 将消息流登记到当前运行的 tracker，如果没有节点或回调消费，运行结束后关闭。
+func (ctx *DefaultRuleContext) trackStream(msg types.RuleMsg) {
+	stream := msg.GetStream()
+	if stream == nil || stream.Consumed() {
+		return
+	}
+	if tracker := runTrackerFromContext(ctx.GetContext()); tracker != nil {
+		tracker.trackStream(stream)
+	}
+}
+
+// attachRunTracker attaches a runTracker to the run context if the run is not already tracked
+// by an outer run. It returns the tracker owned by this run, or nil.
+// attachRunTracker 如果当前运行未被外层运行跟踪，则为运行上下文添加 runTracker。
+// 返回当前运行拥有的 tracker，否则返回 nil。
+func attachRunTracker(rootCtxCopy *DefaultRuleContext) *runTracker {
+	parent := rootCtxCopy.GetContext()
+	if runTrackerFromContext(parent) != nil {
+		return nil
+	}
+	tracker := newRunTracker()
+	rootCtxCopy.SetContext(context.WithValue(parent, runTrackerKey{}, tracker))
+	return tracker
+}
+
+// loadSpilledData loads a spilled payload back into memory before the message is handed to
+// callbacks that may run after the run completes and its payloads are deleted.
+// loadSpilledData 在消息交给回调前加载溢出的负载，回调可能在运行结束、负载被删除后才执行。
+func loadSpilledData(msg types.RuleMsg) error {
+	if msg.Data != nil {
+		return msg.Data.Load()
+	}
+	return nil
+}
+
+// spilledLoadErr returns the error of a node reading a spilled payload that could not be loaded,
+// for example because the store failed or the payload was released before an async branch read it.
+// spilledLoadErr 返回节点读取溢出负载失败的错误，例如存储失败或异步分支读取前负载已被释放。
+func spilledLoadErr(msg types.RuleMsg) error {
+	if msg.Data == nil {
+		return nil
+	}
+	return msg.Data.LoadErr()
+}
@@ -0,0 +1,324 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package modbustest provides an in-process Modbus TCP server for tests.
+//
+// Package modbustest 提供测试使用的进程内 Modbus TCP 服务端。
+package modbustest
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+)
+
+const (
+	// tableSize 每种数据表的地址数量
+	tableSize = 65536
+	// headerSize MBAP 报文头长度：事务ID、协议ID、长度、单元ID
+	headerSize = 7
+	// maxPduSize 最大PDU长度
+	maxPduSize = 253
+	// exceptionFlag 异常响应的功能码最高位为1
+	exceptionFlag = 0x80
+	// maxReadBits 一次最多读取的线圈或离散输入数量
+	maxReadBits = 2000
+	// maxReadRegisters 一次最多读取的寄存器数量
+	maxReadRegisters = 125
+	// maxWriteBits 一次最多写入的线圈数量
+	maxWriteBits = 1968
+	// maxWriteRegisters 一次最多写入的寄存器数量
+	maxWriteRegisters = 123
+)
+
+// 功能码
+const (
+	readCoils              byte = 1
+	readDiscreteInputs     byte = 2
+	readHoldingRegisters   byte = 3
+	readInputRegisters     byte = 4
+	writeSingleCoil        byte = 5
+	writeSingleRegister    byte = 6
+	writeMultipleCoils     byte = 15
+	writeMultipleRegisters byte = 16
+)
+
+// 异常码
+const (
+	illegalFunction    byte = 1
+	illegalDataAddress byte = 2
+	illegalDataValue   byte = 3
+)
+
+// Server 进程内 Modbus TCP 服务端，只用于测试。保存线圈、离散输入、保持寄存器和输入寄存器数据表，
+// 响应所有单元ID的请求
+type Server struct {
+	coils            []bool
+	discreteInputs   []bool
+	holdingRegisters []uint16
+	inputRegisters   []uint16
+	listener         net.Listener
+	conns            map[net.Conn]struct{}
+	mu               sync.RWMutex
+}
+
+// NewServer 创建服务端，数据表初始值为0
+func NewServer() *Server {
+	return &Server{
+		coils:            make([]bool, tableSize),
+		discreteInputs:   make([]bool, tableSize),
+		holdingRegisters: make([]uint16, tableSize),
+		inputRegisters:   make([]uint16, tableSize),
+		conns:            make(map[net.Conn]struct{}),
+	}
+}
+
+// Start 监听地址并在后台处理连接
+func (s *Server) Start(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns[conn] = struct{}{}
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return nil
+}
+
+// Addr 返回监听地址，未启动返回nil
+func (s *Server) Addr() net.Addr {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Close 停止监听并关闭所有连接
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.conns = make(map[net.Conn]struct{})
+	if s.listener != nil {
+		err := s.listener.Close()
+		s.listener = nil
+		return err
+	}
+	return nil
+}
+
+// CloseConns 关闭所有连接，继续监听，用于测试客户端重连
+func (s *Server) CloseConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+}
+
+// SetCoils 从 address 开始设置线圈
+func (s *Server) SetCoils(address uint16, values ...bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copy(s.coils[address:], values)
+}
+
+// Coils 从 address 开始读取线圈
+func (s *Server) Coils(address, quantity uint16) []bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]bool(nil), s.coils[address:int(address)+int(quantity)]...)
+}
+
+// SetDiscreteInputs 从 address 开始设置离散输入
+func (s *Server) SetDiscreteInputs(address uint16, values ...bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copy(s.discreteInputs[address:], values)
+}
+
+// SetHoldingRegisters 从 address 开始设置保持寄存器
+func (s *Server) SetHoldingRegisters(address uint16, values ...uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copy(s.holdingRegisters[address:], values)
+}
+
+// HoldingRegisters 从 address 开始读取保持寄存器
+func (s *Server) HoldingRegisters(address, quantity uint16) []uint16 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]uint16(nil), s.holdingRegisters[address:int(address)+int(quantity)]...)
+}
+
+// SetInputRegisters 从 address 开始设置输入寄存器
+func (s *Server) SetInputRegisters(address uint16, values ...uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copy(s.inputRegisters[address:], values)
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		_ = conn.Close()
+	}()
+	header := make([]byte, headerSize)
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		length := int(binary.BigEndian.Uint16(header[4:]))
+		if length < 2 || length > maxPduSize+1 {
+			return
+		}
+		pdu := make([]byte, length-1)
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			return
+		}
+		resp := s.handle(pdu[0], pdu[1:])
+		binary.BigEndian.PutUint16(header[4:], uint16(len(resp)+1))
+		if _, err := conn.Write(append(header, resp...)); err != nil {
+			return
+		}
+	}
+}
+
+// handle 处理请求PDU并返回响应PDU
+func (s *Server) handle(function byte, data []byte) []byte {
+	exception := func(code byte) []byte {
+		return []byte{function | exceptionFlag, code}
+	}
+	if len(data) < 4 {
+		return exception(illegalDataValue)
+	}
+	address := binary.BigEndian.Uint16(data)
+	value := binary.BigEndian.Uint16(data[2:])
+	end := int(address) + int(value)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch function {
+	case readCoils, readDiscreteInputs:
+		if value == 0 || value > maxReadBits {
+			return exception(illegalDataValue)
+		}
+		if end > tableSize {
+			return exception(illegalDataAddress)
+		}
+		table := s.coils
+		if function == readDiscreteInputs {
+			table = s.discreteInputs
+		}
+		bits := packBits(table[address:end])
+		return append([]byte{function, byte(len(bits))}, bits...)
+	case readHoldingRegisters, readInputRegisters:
+		if value == 0 || value > maxReadRegisters {
+			return exception(illegalDataValue)
+		}
+		if end > tableSize {
+			return exception(illegalDataAddress)
+		}
+		table := s.holdingRegisters
+		if function == readInputRegisters {
+			table = s.inputRegisters
+		}
+		return append([]byte{function, byte(value * 2)}, uint16s(table[address:end]...)...)
+	case writeSingleCoil:
+		if value != 0 && value != 0xFF00 {
+			return exception(illegalDataValue)
+		}
+		s.coils[address] = value == 0xFF00
+		return append([]byte{function}, data[:4]...)
+	case writeSingleRegister:
+		s.holdingRegisters[address] = value
+		return append([]byte{function}, data[:4]...)
+	case writeMultipleCoils, writeMultipleRegisters:
+		if len(data) < 5 || value == 0 {
+			return exception(illegalDataValue)
+		}
+		values := data[5:]
+		if function == writeMultipleCoils {
+			if value > maxWriteBits || len(values) != (int(value)+7)/8 {
+				return exception(illegalDataValue)
+			}
+			if end > tableSize {
+				return exception(illegalDataAddress)
+			}
+			copy(s.coils[address:], unpackBits(values, int(value)))
+		} else {
+			if value > maxWriteRegisters || len(values) != int(value)*2 {
+				return exception(illegalDataValue)
+			}
+			if end > tableSize {
+				return exception(illegalDataAddress)
+			}
+			for i := 0; i < int(value); i++ {
+				s.holdingRegisters[int(address)+i] = binary.BigEndian.Uint16(values[i*2:])
+			}
+		}
+		return append([]byte{function}, data[:4]...)
+	default:
+		return exception(illegalFunction)
+	}
+}
+
+// uint16s 按大端序编码寄存器值
+func uint16s(values ...uint16) []byte {
+	data := make([]byte, len(values)*2)
+	for i, v := range values {
+		binary.BigEndian.PutUint16(data[i*2:], v)
+	}
+	return data
+}
+
+// packBits 把位打包成字节，低位在前
+func packBits(values []bool) []byte {
+	data := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			data[i/8] |= 1 << (i % 8)
+		}
+	}
+	return data
+}
+
+// unpackBits 从字节解包指定数量的位
+func unpackBits(data []byte, quantity int) []bool {
+	values := make([]bool, quantity)
+	for i := range values {
+		values[i] = data[i/8]&(1<<(i%8)) != 0
+	}
+	return values
+}
@@ -0,0 +1,363 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package mqtttest provides an in-process MQTT 5 broker for tests, built on the
+// github.com/eclipse/paho.golang/packets codec.
+//
+// Package mqtttest 提供测试使用的进程内 MQTT 5 代理，基于 github.com/eclipse/paho.golang/packets 编解码。
+package mqtttest
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/packets"
+	string2 "github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// connectTimeout 等待 CONNECT 报文的超时时间
+	connectTimeout = 10 * time.Second
+	// writeTimeout 发送报文的超时时间
+	writeTimeout = 5 * time.Second
+	// sharePrefix 共享订阅前缀
+	sharePrefix = "$share/"
+)
+
+// MQTT 5 原因码
+const (
+	success               byte = 0x00
+	noMatchingSubscribers byte = 0x10
+	noSubscriptionExisted byte = 0x11
+	badUserNameOrPassword byte = 0x86
+	notAuthorized         byte = 0x87
+)
+
+// Broker 进程内的 MQTT 5 代理，只用于测试，不支持保留消息、遗嘱和会话持久化。
+// 支持 QoS0/1/2、通配符订阅、共享订阅 $share/{group}/{topic}、用户名密码认证和主题权限控制，
+// 转发消息时保留所有消息属性。
+type Broker struct {
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[*brokerConn]bool
+	username string
+	password string
+	// denied 禁止订阅和发布的主题
+	denied map[string]bool
+	// shareIndex 共享订阅轮询位置
+	shareIndex map[string]int
+}
+
+// brokerConn 代理的客户端连接
+type brokerConn struct {
+	conn net.Conn
+	mu   sync.Mutex
+	// subs 订阅的主题过滤器和QoS
+	subs   map[string]byte
+	nextID uint16
+}
+
+// NewBroker 创建 MQTT 5 代理
+func NewBroker() *Broker {
+	return &Broker{
+		conns:      make(map[*brokerConn]bool),
+		denied:     make(map[string]bool),
+		shareIndex: make(map[string]int),
+	}
+}
+
+// SetCredentials 设置连接需要的用户名和密码
+func (b *Broker) SetCredentials(username, password string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.username, b.password = username, password
+}
+
+// Deny 禁止订阅和发布指定主题，返回 NotAuthorized 原因码
+func (b *Broker) Deny(topics ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, topic := range topics {
+		b.denied[topic] = true
+	}
+}
+
+// Start 在指定地址启动代理，例如 127.0.0.1:0
+func (b *Broker) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.listener = listener
+	b.mu.Unlock()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return nil
+}
+
+// Addr 监听地址
+func (b *Broker) Addr() net.Addr {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.listener == nil {
+		return nil
+	}
+	return b.listener.Addr()
+}
+
+// Close 停止代理并关闭所有客户端连接
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	listener := b.listener
+	b.listener = nil
+	b.mu.Unlock()
+	b.CloseConns()
+	if listener == nil {
+		return nil
+	}
+	return listener.Close()
+}
+
+// CloseConns 关闭所有客户端连接，用于测试客户端断线重连
+func (b *Broker) CloseConns() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for c := range b.conns {
+		_ = c.conn.Close()
+	}
+}
+
+func (b *Broker) serve(conn net.Conn) {
+	defer conn.Close()
+	c := &brokerConn{conn: conn, subs: make(map[string]byte)}
+	_ = conn.SetReadDeadline(time.Now().Add(connectTimeout))
+	cp, err := packets.ReadPacket(conn)
+	if err != nil || cp.Type != packets.CONNECT {
+		return
+	}
+	connect := cp.Content.(*packets.Connect)
+	ack := &packets.Connack{Properties: &packets.Properties{}}
+	b.mu.Lock()
+	if b.username != "" && (connect.Username != b.username || string(connect.Password) != b.password) {
+		ack.ReasonCode = badUserNameOrPassword
+	} else {
+		b.conns[c] = true
+	}
+	b.mu.Unlock()
+	if connect.ClientID == "" {
+		ack.Properties.AssignedClientID = "broker/" + string2.RandomStr(8)
+	}
+	if err := c.write(ack); err != nil || ack.ReasonCode >= 0x80 {
+		return
+	}
+	defer func() {
+		b.mu.Lock()
+		delete(b.conns, c)
+		b.mu.Unlock()
+	}()
+
+	keepAlive := time.Duration(connect.KeepAlive) * time.Second
+	for {
+		if keepAlive > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(keepAlive * 3 / 2))
+		} else {
+			_ = conn.SetReadDeadline(time.Time{})
+		}
+		cp, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		switch p := cp.Content.(type) {
+		case *packets.Publish:
+			err = b.handlePublish(c, p)
+		case *packets.Pubrel:
+			err = c.write(&packets.Pubcomp{PacketID: p.PacketID, Properties: &packets.Properties{}})
+		case *packets.Pubrec:
+			err = c.write(&packets.Pubrel{PacketID: p.PacketID, Properties: &packets.Properties{}})
+		case *packets.Subscribe:
+			err = b.handleSubscribe(c, p)
+		case *packets.Unsubscribe:
+			ack := &packets.Unsuback{PacketID: p.PacketID, Properties: &packets.Properties{}}
+			b.mu.Lock()
+			for _, topic := range p.Topics {
+				if _, ok := c.subs[topic]; ok {
+					delete(c.subs, topic)
+					ack.Reasons = append(ack.Reasons, success)
+				} else {
+					ack.Reasons = append(ack.Reasons, noSubscriptionExisted)
+				}
+			}
+			b.mu.Unlock()
+			err = c.write(ack)
+		case *packets.Pingreq:
+			err = c.write(&packets.Pingresp{})
+		case *packets.Disconnect:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (b *Broker) handleSubscribe(c *brokerConn, p *packets.Subscribe) error {
+	ack := &packets.Suback{PacketID: p.PacketID, Properties: &packets.Properties{}}
+	b.mu.Lock()
+	for _, s := range p.Subscriptions {
+		if b.denied[s.Topic] {
+			ack.Reasons = append(ack.Reasons, notAuthorized)
+			continue
+		}
+		c.subs[s.Topic] = s.QoS
+		ack.Reasons = append(ack.Reasons, s.QoS)
+	}
+	b.mu.Unlock()
+	return c.write(ack)
+}
+
+func (b *Broker) handlePublish(c *brokerConn, p *packets.Publish) error {
+	b.mu.Lock()
+	denied := b.denied[p.Topic]
+	b.mu.Unlock()
+	code := success
+	if denied {
+		code = notAuthorized
+	} else if b.route(p) == 0 {
+		code = noMatchingSubscribers
+	}
+	switch p.QoS {
+	case 1:
+		return c.write(&packets.Puback{PacketID: p.PacketID, ReasonCode: code, Properties: &packets.Properties{}})
+	case 2:
+		return c.write(&packets.Pubrec{PacketID: p.PacketID, ReasonCode: code, Properties: &packets.Properties{}})
+	}
+	return nil
+}
+
+// route 把消息转发给匹配的订阅者，共享订阅的每个组只转发给其中一个订阅者，返回转发的数量
+func (b *Broker) route(p *packets.Publish) int {
+	type target struct {
+		conn *brokerConn
+		qos  byte
+	}
+	b.mu.Lock()
+	var targets []target
+	groups := make(map[string][]target)
+	for c := range b.conns {
+		delivered := false
+		for filter, qos := range c.subs {
+			if !matchTopic(filter, p.Topic) {
+				continue
+			}
+			if group, _ := splitShare(filter); group != "" {
+				groups[filter] = append(groups[filter], target{conn: c, qos: qos})
+			} else if !delivered {
+				delivered = true
+				targets = append(targets, target{conn: c, qos: qos})
+			}
+		}
+	}
+	for filter, members := range groups {
+		//按连接地址排序，保证轮询顺序稳定
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].conn.conn.RemoteAddr().String() < members[j].conn.conn.RemoteAddr().String()
+		})
+		index := b.shareIndex[filter] % len(members)
+		b.shareIndex[filter] = index + 1
+		targets = append(targets, members[index])
+	}
+	b.mu.Unlock()
+
+	for _, t := range targets {
+		qos := p.QoS
+		if t.qos < qos {
+			qos = t.qos
+		}
+		out := &packets.Publish{Topic: p.Topic, QoS: qos, Payload: p.Payload, Properties: p.Properties}
+		if out.Properties == nil {
+			out.Properties = &packets.Properties{}
+		}
+		if qos > 0 {
+			out.PacketID = t.conn.allocID()
+		}
+		_ = t.conn.write(out)
+	}
+	return len(targets)
+}
+
+func (c *brokerConn) allocID() uint16 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	if c.nextID == 0 {
+		c.nextID = 1
+	}
+	return c.nextID
+}
+
+func (c *brokerConn) write(p packets.Packet) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, err := p.WriteTo(c.conn)
+	return err
+}
+
+// splitShare 拆分共享订阅 $share/{group}/{filter}，非共享订阅 group 为空
+func splitShare(filter string) (group string, topicFilter string) {
+	if !strings.HasPrefix(filter, sharePrefix) {
+		return "", filter
+	}
+	rest := filter[len(sharePrefix):]
+	if i := strings.IndexByte(rest, '/'); i > 0 {
+		return rest[:i], rest[i+1:]
+	}
+	return "", filter
+}
+
+// matchTopic 判断主题是否匹配订阅的主题过滤器，支持 + 和 # 通配符以及共享订阅
+func matchTopic(filter, topic string) bool {
+	_, filter = splitShare(filter)
+	//通配符不匹配以$开头的主题
+	if strings.HasPrefix(topic, "$") && (strings.HasPrefix(filter, "+") || strings.HasPrefix(filter, "#")) {
+		return false
+	}
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return i == len(filterLevels)-1
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
@@ -297,6 +297,8 @@ func (ctx *NodeTestRuleContext) GetEnv(msg types.RuleMsg, useMetadata bool) map[
 
 	// 设置基础环境变量
 	envVars["id"] = msg.GetId()
+	envVars[types.ParentIdKey] = msg.GetParentId()
+	envVars[types.CorrelationIdKey] = msg.GetCorrelationId()
 	envVars["ts"] = msg.GetTs()
 	envVars["data"] = msg.GetData()
 	envVars["msgType"] = msg.GetType()
@@ -315,7 +317,16 @@ func (ctx *NodeTestRuleContext) GetEnv(msg types.RuleMsg, useMetadata bool) map[
 		envVars[types.MsgKey] = msg.GetData()
 	}
 	// 优化 metadata 处理
-	if msg.Metadata != nil {
+	if msg.Metadata != nil && msg.Metadata.HasTypedValues() {
+		// 类型化的metadata以原生类型暴露给表达式
+		if useMetadata {
+			msg.Metadata.ForEachTyped(func(k string, v interface{}) bool {
+				envVars[k] = v
+				return true // continue iteration
+			})
+		}
+		envVars[types.MetadataKey] = msg.Metadata.TypedValues()
+	} else if msg.Metadata != nil {
 		if useMetadata {
 			// 遍历metadata，将键值对添加到环境变量中 - use zero-copy ForEach
 			msg.Metadata.ForEach(func(k, v string) bool {
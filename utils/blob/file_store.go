@@ -0,0 +1,103 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package blob provides types.BlobStore implementations used to spill large
+// message payloads out of memory.
+package blob
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rulego/rulego/api/types"
+)
+
+// DefaultDirName is the directory created under os.TempDir() when no directory is specified.
+const DefaultDirName = "rulego-blob"
+
+var _ types.BlobStore = (*FileStore)(nil)
+
+// FileStore stores each payload in its own file under a directory.
+// The handle is the file name, so it can only refer to files inside the directory.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a FileStore that keeps payloads in dir, creating it if necessary.
+// If dir is empty, a rulego-blob directory under os.TempDir() is used.
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), DefaultDirName)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Dir returns the directory the payloads are stored in.
+func (s *FileStore) Dir() string {
+	return s.dir
+}
+
+// Put writes data to a new file and returns its name as the handle.
+func (s *FileStore) Put(data []byte) (string, error) {
+	f, err := os.CreateTemp(s.dir, "blob-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	if err = f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return filepath.Base(f.Name()), nil
+}
+
+// Get reads the payload identified by handle.
+func (s *FileStore) Get(handle string) ([]byte, error) {
+	path, err := s.path(handle)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// Delete removes the payload identified by handle. Missing files are ignored.
+func (s *FileStore) Delete(handle string) error {
+	path, err := s.path(handle)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path resolves handle to a file in the store directory, rejecting handles that could escape it.
+func (s *FileStore) path(handle string) (string, error) {
+	if handle == "" || handle == "." || handle == ".." || filepath.Base(handle) != handle {
+		return "", fmt.Errorf("invalid blob handle: %q", handle)
+	}
+	return filepath.Join(s.dir, handle), nil
+}
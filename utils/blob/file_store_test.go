@@ -0,0 +1,63 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package blob
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(filepath.Join(dir, "blobs"))
+	assert.Nil(t, err)
+
+	t.Run("PutGetDelete", func(t *testing.T) {
+		handle, err := store.Put([]byte("large payload"))
+		assert.Nil(t, err)
+		assert.True(t, handle != "")
+
+		data, err := store.Get(handle)
+		assert.Nil(t, err)
+		assert.Equal(t, "large payload", string(data))
+
+		assert.Nil(t, store.Delete(handle))
+		_, err = os.Stat(filepath.Join(store.Dir(), handle))
+		assert.True(t, os.IsNotExist(err))
+		_, err = store.Get(handle)
+		assert.NotNil(t, err)
+		// 重复删除不报错
+		assert.Nil(t, store.Delete(handle))
+	})
+
+	t.Run("InvalidHandle", func(t *testing.T) {
+		for _, handle := range []string{"", ".", "..", "../secret", "a/b"} {
+			_, err := store.Get(handle)
+			assert.NotNil(t, err)
+			assert.NotNil(t, store.Delete(handle))
+		}
+	})
+
+	t.Run("DefaultDir", func(t *testing.T) {
+		defaultStore, err := NewFileStore("")
+		assert.Nil(t, err)
+		assert.Equal(t, filepath.Join(os.TempDir(), DefaultDirName), defaultStore.Dir())
+	})
+}
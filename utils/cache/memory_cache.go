@@ -17,22 +17,54 @@
 package cache
 
 import (
+	"container/list"
+	"math"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/str"
 )
 
 var DefaultCache = NewMemoryCache(time.Minute * 5)
 
 // MemoryCache is an in-memory cache implementation.
 // It stores key-value pairs with optional expiration.
+// It can optionally be bounded by entry count or estimated size with LRU eviction,
+// and be persisted to a snapshot file, see WithMaxEntries, WithMaxBytes and WithSnapshot.
+//
+// Example of a bounded, persistent global cache:
+//
+//	memCache := cache.NewMemoryCache(time.Minute,
+//		cache.WithMaxEntries(100000),
+//		cache.WithSnapshot("./data/cache.json", time.Minute))
+//	config := types.NewConfig(types.WithCache(memCache))
+//	defer memCache.Close()
 type MemoryCache struct {
+	// Counters are accessed atomically and kept first for 64-bit alignment on 32-bit platforms
+	hits      uint64 // Number of Get calls that found a value
+	misses    uint64 // Number of Get calls that found no value
+	evictions uint64 // Number of items evicted by the size bound
+
 	items      map[string]item
 	mu         sync.RWMutex
 	stopGc     chan struct{} // Channel to signal GC to stop
 	ticker     *time.Ticker  // Ticker for GC
 	gcInterval time.Duration // GC interval duration
+
+	maxEntries int        // Maximum number of entries, 0 means unbounded
+	maxBytes   int64      // Maximum estimated size in bytes, 0 means unbounded
+	bytes      int64      // Current estimated size, only tracked if maxBytes > 0
+	lru        *list.List // Keys ordered by recent use, front is the most recent. nil if unbounded
+
+	snapshotPath     string        // Snapshot file path, empty disables snapshotting
+	snapshotInterval time.Duration // Interval of periodic snapshots, 0 disables them
+	stopSnapshot     chan struct{} // Channel to signal the snapshot goroutine to stop
+	snapshotDone     chan struct{} // Closed when the snapshot goroutine exits
+	closeOnce        sync.Once
 }
 
 // item represents a cached item with its value and expiration time.
@@ -41,14 +73,50 @@ type MemoryCache struct {
 type item struct {
 	value      interface{}
 	expiration int64
+	size       int64         // Estimated size, only set if maxBytes > 0
+	elem       *list.Element // LRU list element, only set if the cache is bounded
+}
+
+// MemoryCacheOption configures a MemoryCache.
+type MemoryCacheOption func(c *MemoryCache)
+
+// WithMaxEntries bounds the cache to n entries. When the bound is exceeded,
+// the least recently used entries are evicted. n <= 0 means unbounded.
+func WithMaxEntries(n int) MemoryCacheOption {
+	return func(c *MemoryCache) {
+		c.maxEntries = n
+	}
+}
+
+// WithMaxBytes bounds the estimated size of keys and values to n bytes. When the bound is exceeded,
+// the least recently used entries are evicted. Strings and byte slices are measured by length,
+// other values by their JSON encoding. n <= 0 means unbounded.
+func WithMaxBytes(n int64) MemoryCacheOption {
+	return func(c *MemoryCache) {
+		c.maxBytes = n
+	}
+}
+
+// MemoryCacheStats holds cache statistics.
+type MemoryCacheStats struct {
+	// Hits is the number of Get calls that found a value
+	Hits uint64 `json:"hits"`
+	// Misses is the number of Get calls that found no value
+	Misses uint64 `json:"misses"`
+	// Evictions is the number of entries evicted by the size bound
+	Evictions uint64 `json:"evictions"`
+	// Entries is the current number of entries, including expired entries not yet collected
+	Entries int `json:"entries"`
+	// Bytes is the current estimated size, only tracked if WithMaxBytes is set
+	Bytes int64 `json:"bytes"`
 }
 
 // NewMemoryCache creates a new MemoryCache instance.
 // The returned cache is initialized with:
-// - An empty items map
+// - An empty items map, or the entries of the snapshot file if WithSnapshot is set
 // - A stopGc channel for controlling garbage collection
 // Note: Garbage collection is not started automatically, call StartGC() to enable it.
-func NewMemoryCache(gcInterval time.Duration) *MemoryCache {
+func NewMemoryCache(gcInterval time.Duration, opts ...MemoryCacheOption) *MemoryCache {
 	c := &MemoryCache{
 		items:      make(map[string]item),
 		stopGc:     make(chan struct{}),
@@ -57,10 +125,133 @@ func NewMemoryCache(gcInterval time.Duration) *MemoryCache {
 	if gcInterval > 0 {
 		c.gcInterval = gcInterval
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.maxEntries > 0 || c.maxBytes > 0 {
+		c.lru = list.New()
+	}
+	if c.snapshotPath != "" {
+		// 快照文件不存在或者损坏时使用空缓存启动
+		_ = c.LoadSnapshot()
+		if c.snapshotInterval > 0 {
+			c.startSnapshot()
+		}
+	}
 	// GC is no longer started automatically
 	return c
 }
 
+// Stats returns the cache statistics.
+func (c *MemoryCache) Stats() MemoryCacheStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return MemoryCacheStats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+		Entries:   len(c.items),
+		Bytes:     c.bytes,
+	}
+}
+
+// Close stops garbage collection and periodic snapshots.
+// If WithSnapshot is set, a final snapshot is written.
+// The cache can still be used after Close, but it is no longer persisted periodically.
+func (c *MemoryCache) Close() error {
+	c.StopGC()
+	var err error
+	c.closeOnce.Do(func() {
+		if c.stopSnapshot != nil {
+			close(c.stopSnapshot)
+			<-c.snapshotDone
+		}
+		if c.snapshotPath != "" {
+			err = c.Snapshot()
+		}
+	})
+	return err
+}
+
+// put stores an item and evicts the least recently used items if the cache is over its bound.
+// The caller must hold the write lock.
+func (c *MemoryCache) put(key string, value interface{}, expiration int64) {
+	it := item{
+		value:      value,
+		expiration: expiration,
+	}
+	old, found := c.items[key]
+	if c.maxBytes > 0 {
+		it.size = estimateSize(key, value)
+		c.bytes += it.size
+		if found {
+			c.bytes -= old.size
+		}
+	}
+	if c.lru != nil {
+		if found && old.elem != nil {
+			it.elem = old.elem
+			c.lru.MoveToFront(it.elem)
+		} else {
+			it.elem = c.lru.PushFront(key)
+		}
+	}
+	c.items[key] = it
+	c.evict()
+}
+
+// remove deletes an item. The caller must hold the write lock.
+func (c *MemoryCache) remove(key string) {
+	it, found := c.items[key]
+	if !found {
+		return
+	}
+	if it.elem != nil {
+		c.lru.Remove(it.elem)
+	}
+	c.bytes -= it.size
+	delete(c.items, key)
+}
+
+// evict removes the least recently used items while the cache is over its bound.
+// The most recently used item is always kept. The caller must hold the write lock.
+func (c *MemoryCache) evict() {
+	if c.lru == nil {
+		return
+	}
+	for c.lru.Len() > 1 && ((c.maxEntries > 0 && len(c.items) > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes)) {
+		c.remove(c.lru.Back().Value.(string))
+		atomic.AddUint64(&c.evictions, 1)
+	}
+}
+
+// estimateSize estimates the memory used by an item
+func estimateSize(key string, value interface{}) int64 {
+	size := int64(len(key))
+	switch v := value.(type) {
+	case nil:
+	case string:
+		size += int64(len(v))
+	case []byte:
+		size += int64(len(v))
+	case bool, int8, uint8:
+		size += 1
+	case int16, uint16:
+		size += 2
+	case int32, uint32, float32:
+		size += 4
+	case int, int64, uint, uint64, float64:
+		size += 8
+	default:
+		if data, err := json.Marshal(v); err == nil {
+			size += int64(len(data))
+		} else {
+			size += int64(len(str.ToString(v)))
+		}
+	}
+	return size
+}
+
 // Set stores a value in the cache with the given key and an optional expiration duration.
 // Parameters:
 //   - key: The cache key (string)
@@ -73,36 +264,108 @@ func NewMemoryCache(gcInterval time.Duration) *MemoryCache {
 // If ttl is 0, the item will not expire.
 // ttl should be a string (e.g. "10m").
 func (c *MemoryCache) Set(key string, value interface{}, ttl string) error {
-	var expiration int64
-	var dur time.Duration
-	var err error
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.put(key, value, expiration)
+	// If an expirable item was added and GC is not running (ticker is nil),
+	// set flag to start GC after releasing the lock.
+	shouldStartGC := expiration > 0 && c.ticker == nil
+	c.mu.Unlock()
+
+	if shouldStartGC {
+		c.StartGC() // StartGC handles its own locking
+	}
+
+	return nil
+}
 
-	if ttl != "" {
-		dur, err = time.ParseDuration(ttl)
-		if err != nil {
-			return err
+// Incr atomically adds delta to the integer value of key and returns the new value.
+// A missing or expired key is treated as 0, ttl is only applied when the key is created.
+// Returns types.ErrCacheValueNotInteger if the stored value is not an integer.
+func (c *MemoryCache) Incr(key string, delta int64, ttl string) (int64, error) {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	var value int64
+	if it, found := c.getItem(key); found {
+		current, ok := toInt64(it.value)
+		if !ok {
+			c.mu.Unlock()
+			return 0, types.ErrCacheValueNotInteger
 		}
+		value = current + delta
+		expiration = it.expiration
+	} else {
+		value = delta
 	}
+	c.put(key, value, expiration)
+	shouldStartGC := expiration > 0 && c.ticker == nil
+	c.mu.Unlock()
 
-	if dur > 0 {
-		expiration = time.Now().Add(dur).UnixNano()
+	if shouldStartGC {
+		c.StartGC()
 	}
+	return value, nil
+}
 
+// SetNX stores the value only if the key does not exist or has expired.
+// It returns true if the value was stored.
+func (c *MemoryCache) SetNX(key string, value interface{}, ttl string) (bool, error) {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return false, err
+	}
 	c.mu.Lock()
-	c.items[key] = item{
-		value:      value,
-		expiration: expiration,
+	if _, found := c.getItem(key); found {
+		c.mu.Unlock()
+		return false, nil
 	}
-	// If an expirable item was added and GC is not running (ticker is nil),
-	// set flag to start GC after releasing the lock.
+	c.put(key, value, expiration)
 	shouldStartGC := expiration > 0 && c.ticker == nil
 	c.mu.Unlock()
 
 	if shouldStartGC {
-		c.StartGC() // StartGC handles its own locking
+		c.StartGC()
+	}
+	return true, nil
+}
+
+// CompareAndSwap replaces the value of key with newValue only if the current value equals oldValue.
+// Values are compared by their string form. It returns false if the key does not exist or has expired.
+func (c *MemoryCache) CompareAndSwap(key string, oldValue, newValue interface{}, ttl string) (bool, error) {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	it, found := c.getItem(key)
+	if !found || !valueEqual(it.value, oldValue) {
+		c.mu.Unlock()
+		return false, nil
 	}
+	c.put(key, newValue, expiration)
+	shouldStartGC := expiration > 0 && c.ticker == nil
+	c.mu.Unlock()
 
-	return nil
+	if shouldStartGC {
+		c.StartGC()
+	}
+	return true, nil
+}
+
+// getItem returns the item if it exists and has not expired. The caller must hold the lock.
+func (c *MemoryCache) getItem(key string) (item, bool) {
+	it, found := c.items[key]
+	if !found || (it.expiration > 0 && time.Now().UnixNano() > it.expiration) {
+		return item{}, false
+	}
+	return it, true
 }
 
 // Get retrieves a value from the cache by its key.
@@ -115,20 +378,25 @@ func (c *MemoryCache) Set(key string, value interface{}, ttl string) error {
 //
 // It returns the value and true if the key exists and has not expired, otherwise it returns nil and false.
 func (c *MemoryCache) Get(key string) interface{} {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	// 有界缓存需要更新LRU顺序
+	if c.lru != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+	} else {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+	}
 
-	it, found := c.items[key]
+	// Expired items are left for the GC
+	it, found := c.getItem(key)
 	if !found {
+		atomic.AddUint64(&c.misses, 1)
 		return nil
 	}
-
-	if it.expiration > 0 && time.Now().UnixNano() > it.expiration {
-		// Item has expired
-		// We can also delete it here, but the GC will take care of it
-		return nil
+	atomic.AddUint64(&c.hits, 1)
+	if it.elem != nil {
+		c.lru.MoveToFront(it.elem)
 	}
-
 	return it.value
 }
 
@@ -157,7 +425,7 @@ func (c *MemoryCache) Has(key string) bool {
 func (c *MemoryCache) Delete(key string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	delete(c.items, key)
+	c.remove(key)
 	return nil
 }
 
@@ -172,7 +440,7 @@ func (c *MemoryCache) DeleteByPrefix(prefix string) error {
 	defer c.mu.Unlock()
 	for k := range c.items {
 		if len(k) >= len(prefix) && k[:len(prefix)] == prefix {
-			delete(c.items, k)
+			c.remove(k)
 		}
 	}
 	return nil
@@ -309,7 +577,7 @@ func (c *MemoryCache) deleteExpired() {
 			// This is important because the item might have been updated or deleted
 			// by another goroutine between the RUnlock (after collecting keys) and this Lock.
 			if item, found := c.items[k]; found && item.expiration > 0 && now > item.expiration {
-				delete(c.items, k)
+				c.remove(k)
 			}
 		}
 		c.mu.Unlock()
@@ -448,8 +716,110 @@ func (c *NamespaceCache) GetByPrefix(prefix string) map[string]interface{} {
 	return newResult
 }
 
-// Ensure NamespaceCache implements the Cache interface.
-var _ types.Cache = (*NamespaceCache)(nil)
+// Incr atomically adds delta to the value of the prefixed key
+// Returns types.ErrCacheAtomicNotSupported if the underlying cache does not implement types.AtomicCache
+func (c *NamespaceCache) Incr(key string, delta int64, ttl string) (int64, error) {
+	ac, err := c.atomicCache()
+	if err != nil {
+		return 0, err
+	}
+	return ac.Incr(c.Namespace+key, delta, ttl)
+}
+
+// SetNX stores the value of the prefixed key only if it does not exist
+// Returns types.ErrCacheAtomicNotSupported if the underlying cache does not implement types.AtomicCache
+func (c *NamespaceCache) SetNX(key string, value interface{}, ttl string) (bool, error) {
+	ac, err := c.atomicCache()
+	if err != nil {
+		return false, err
+	}
+	return ac.SetNX(c.Namespace+key, value, ttl)
+}
+
+// CompareAndSwap replaces the value of the prefixed key only if the current value equals oldValue
+// Returns types.ErrCacheAtomicNotSupported if the underlying cache does not implement types.AtomicCache
+func (c *NamespaceCache) CompareAndSwap(key string, oldValue, newValue interface{}, ttl string) (bool, error) {
+	ac, err := c.atomicCache()
+	if err != nil {
+		return false, err
+	}
+	return ac.CompareAndSwap(c.Namespace+key, oldValue, newValue, ttl)
+}
+
+// atomicCache 获取底层缓存的原子操作接口
+func (c *NamespaceCache) atomicCache() (types.AtomicCache, error) {
+	if c == nil || c.Cache == nil {
+		return nil, types.ErrCacheNotInitialized
+	}
+	if ac, ok := c.Cache.(types.AtomicCache); ok {
+		return ac, nil
+	}
+	return nil, types.ErrCacheAtomicNotSupported
+}
+
+// parseExpiration parses the ttl string and returns the expiration as Unix nano timestamp, 0 means never expire
+func parseExpiration(ttl string) (int64, error) {
+	if ttl == "" {
+		return 0, nil
+	}
+	dur, err := time.ParseDuration(ttl)
+	if err != nil {
+		return 0, err
+	}
+	if dur > 0 {
+		return time.Now().Add(dur).UnixNano(), nil
+	}
+	return 0, nil
+}
+
+// toInt64 converts an integer value, integral float or numeric string to int64
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	case float32:
+		return int64(v), float32(int64(v)) == v
+	case float64:
+		return int64(v), float64(int64(v)) == v
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// valueEqual compares two values by their string form
+func valueEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return str.ToString(a) == str.ToString(b)
+}
+
+// Ensure NamespaceCache implements the AtomicCache interface.
+var _ types.AtomicCache = (*NamespaceCache)(nil)
 
-// Ensure MemoryCache implements the Cache interface.
-var _ types.Cache = (*MemoryCache)(nil)
+// Ensure MemoryCache implements the AtomicCache interface.
+var _ types.AtomicCache = (*MemoryCache)(nil)
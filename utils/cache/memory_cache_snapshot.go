@@ -0,0 +1,165 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rulego/rulego/utils/json"
+)
+
+// snapshotVersion is the version of the snapshot file format
+const snapshotVersion = 1
+
+// snapshotFile is the content of a snapshot file
+type snapshotFile struct {
+	Version int            `json:"version"`
+	Items   []snapshotItem `json:"items"`
+}
+
+// snapshotItem is a cache entry in a snapshot file.
+// Items are ordered from the least to the most recently used, so reloading them restores the LRU order.
+type snapshotItem struct {
+	Key string `json:"key"`
+	// Value is the JSON encoded value. After reload, numbers are float64 and objects are map[string]interface{}
+	Value json.RawMessage `json:"value"`
+	// Expiration is the Unix nano timestamp of the expiration, 0 means never expire
+	Expiration int64 `json:"expiration,omitempty"`
+}
+
+// WithSnapshot persists the cache to a JSON file at path.
+// The file is loaded when the cache is created and written every interval and on Close.
+// interval <= 0 disables periodic snapshots, call Snapshot to write the file manually.
+// Values that can not be encoded as JSON are not persisted.
+func WithSnapshot(path string, interval time.Duration) MemoryCacheOption {
+	return func(c *MemoryCache) {
+		c.snapshotPath = path
+		c.snapshotInterval = interval
+	}
+}
+
+// Snapshot writes all unexpired entries to the snapshot file.
+// The file is replaced atomically, so a crash during the write keeps the previous snapshot.
+func (c *MemoryCache) Snapshot() error {
+	if c.snapshotPath == "" {
+		return errors.New("snapshot path is not set")
+	}
+	snapshot := snapshotFile{Version: snapshotVersion, Items: c.snapshotItems()}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.snapshotPath), 0o755); err != nil {
+		return err
+	}
+	tmp := c.snapshotPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.snapshotPath)
+}
+
+// LoadSnapshot loads the entries of the snapshot file into the cache.
+// Expired entries are skipped. A missing file is not an error.
+func (c *MemoryCache) LoadSnapshot() error {
+	if c.snapshotPath == "" {
+		return errors.New("snapshot path is not set")
+	}
+	data, err := os.ReadFile(c.snapshotPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var snapshot snapshotFile
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+	now := time.Now().UnixNano()
+	hasExpirable := false
+	c.mu.Lock()
+	for _, it := range snapshot.Items {
+		if it.Expiration > 0 && now > it.Expiration {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(it.Value, &value); err != nil {
+			continue
+		}
+		c.put(it.Key, value, it.Expiration)
+		if it.Expiration > 0 {
+			hasExpirable = true
+		}
+	}
+	c.mu.Unlock()
+	if hasExpirable {
+		c.StartGC()
+	}
+	return nil
+}
+
+// snapshotItems returns the unexpired entries, ordered from the least to the most recently used if the cache is bounded
+func (c *MemoryCache) snapshotItems() []snapshotItem {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	now := time.Now().UnixNano()
+	items := make([]snapshotItem, 0, len(c.items))
+	add := func(key string, it item) {
+		if it.expiration > 0 && now > it.expiration {
+			return
+		}
+		value, err := json.Marshal(it.value)
+		if err != nil {
+			return
+		}
+		items = append(items, snapshotItem{Key: key, Value: value, Expiration: it.expiration})
+	}
+	if c.lru != nil {
+		for e := c.lru.Back(); e != nil; e = e.Prev() {
+			key := e.Value.(string)
+			add(key, c.items[key])
+		}
+	} else {
+		for key, it := range c.items {
+			add(key, it)
+		}
+	}
+	return items
+}
+
+// startSnapshot starts the goroutine that writes snapshots periodically
+func (c *MemoryCache) startSnapshot() {
+	c.stopSnapshot = make(chan struct{})
+	c.snapshotDone = make(chan struct{})
+	go func() {
+		defer close(c.snapshotDone)
+		ticker := time.NewTicker(c.snapshotInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_ = c.Snapshot()
+			case <-c.stopSnapshot:
+				return
+			}
+		}
+	}()
+}
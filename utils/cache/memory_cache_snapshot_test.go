@@ -0,0 +1,92 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestMemoryCacheSnapshot(t *testing.T) {
+	t.Run("SnapshotAndReload", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "data", "cache.json")
+		c := NewMemoryCache(time.Minute, WithSnapshot(path, 0))
+		assert.Nil(t, c.Set("text", "value", ""))
+		assert.Nil(t, c.Set("number", 10, "1h"))
+		assert.Nil(t, c.Set("map", map[string]interface{}{"a": "b"}, ""))
+		assert.Nil(t, c.Set("expired", "value", "10ms"))
+		assert.Nil(t, c.Set("invalid", make(chan int), ""))
+		time.Sleep(time.Millisecond * 20)
+		assert.Nil(t, c.Close())
+
+		reloaded := NewMemoryCache(time.Minute, WithSnapshot(path, 0))
+		defer reloaded.Close()
+		assert.Equal(t, "value", reloaded.Get("text"))
+		assert.Equal(t, float64(10), reloaded.Get("number"))
+		assert.Equal(t, map[string]interface{}{"a": "b"}, reloaded.Get("map"))
+		assert.False(t, reloaded.Has("expired"))
+		assert.False(t, reloaded.Has("invalid"))
+		// 过期时间保留
+		count, err := reloaded.Incr("number", 1, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(11), count)
+		assert.True(t, reloaded.items["number"].expiration > time.Now().Add(time.Minute*59).UnixNano())
+	})
+
+	t.Run("LRUOrder", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cache.json")
+		c := NewMemoryCache(time.Minute, WithMaxEntries(3), WithSnapshot(path, 0))
+		assert.Nil(t, c.Set("a", 1, ""))
+		assert.Nil(t, c.Set("b", 2, ""))
+		assert.Nil(t, c.Set("c", 3, ""))
+		c.Get("a")
+		assert.Nil(t, c.Snapshot())
+
+		// 重新加载后保留LRU顺序，容量更小时保留最近使用的项
+		reloaded := NewMemoryCache(time.Minute, WithMaxEntries(2), WithSnapshot(path, 0))
+		assert.False(t, reloaded.Has("b"))
+		assert.True(t, reloaded.Has("c"))
+		assert.True(t, reloaded.Has("a"))
+		assert.Nil(t, reloaded.Set("d", 4, ""))
+		assert.False(t, reloaded.Has("c"))
+	})
+
+	t.Run("Periodic", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cache.json")
+		c := NewMemoryCache(time.Minute, WithSnapshot(path, time.Millisecond*20))
+		assert.Nil(t, c.Set("a", "1", ""))
+		time.Sleep(time.Millisecond * 100)
+		_, err := os.Stat(path)
+		assert.Nil(t, err)
+		assert.Nil(t, c.Close())
+		assert.Nil(t, c.Close())
+	})
+
+	t.Run("InvalidFile", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cache.json")
+		assert.Nil(t, os.WriteFile(path, []byte("invalid"), 0o644))
+		c := NewMemoryCache(time.Minute, WithSnapshot(path, 0))
+		assert.NotNil(t, c.LoadSnapshot())
+		assert.Equal(t, 0, c.Stats().Entries)
+
+		assert.NotNil(t, NewMemoryCache(time.Minute).Snapshot())
+	})
+}
@@ -18,9 +18,11 @@ package cache
 
 import (
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/test/assert"
 )
 
@@ -305,3 +307,224 @@ func TestNamespaceCache(t *testing.T) {
 	})
 
 }
+
+func TestMemoryCacheAtomic(t *testing.T) {
+	testAtomicCache(t, NewMemoryCache(time.Minute), func() {
+		time.Sleep(time.Millisecond * 150)
+	})
+
+	t.Run("Namespace", func(t *testing.T) {
+		baseCache := NewMemoryCache(time.Minute)
+		c := NewNamespaceCache(baseCache, "chain1:")
+		count, err := c.Incr("count", 2, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), count)
+		assert.Equal(t, int64(2), baseCache.Get("chain1:count"))
+
+		ok, err := c.SetNX("lock", "a", "")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.True(t, baseCache.Has("chain1:lock"))
+		ok, _ = c.CompareAndSwap("lock", "a", "b", "")
+		assert.True(t, ok)
+		assert.Equal(t, "b", baseCache.Get("chain1:lock"))
+
+		// 底层缓存不支持原子操作
+		c = NewNamespaceCache(&plainCache{Cache: baseCache}, "chain1:")
+		_, err = c.Incr("count", 1, "")
+		assert.Equal(t, types.ErrCacheAtomicNotSupported, err)
+		var nilCache *NamespaceCache
+		_, err = nilCache.SetNX("lock", "a", "")
+		assert.Equal(t, types.ErrCacheNotInitialized, err)
+	})
+}
+
+// plainCache 只实现 types.Cache 接口的缓存
+type plainCache struct {
+	types.Cache
+}
+
+// testAtomicCache 测试 types.AtomicCache 实现，expire 用于等待100ms的ttl过期
+func testAtomicCache(t *testing.T, c types.AtomicCache, expire func()) {
+	t.Run("Incr", func(t *testing.T) {
+		count, err := c.Incr("incr", 1, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), count)
+		count, err = c.Incr("incr", 5, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(6), count)
+		count, err = c.Incr("incr", -7, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(-1), count)
+
+		assert.Nil(t, c.Set("incrNumber", 10, ""))
+		count, err = c.Incr("incrNumber", 1, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(11), count)
+
+		// 整数字符串按整数递增
+		assert.Nil(t, c.Set("incrNumericText", "5", ""))
+		count, err = c.Incr("incrNumericText", 1, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(6), count)
+		count, err = c.Incr("incrNumericText", 1, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(7), count)
+
+		assert.Nil(t, c.Set("incrText", "abc", ""))
+		_, err = c.Incr("incrText", 1, "")
+		assert.Equal(t, types.ErrCacheValueNotInteger, err)
+		assert.Nil(t, c.Set("incrFloat", 1.5, ""))
+		_, err = c.Incr("incrFloat", 1, "")
+		assert.Equal(t, types.ErrCacheValueNotInteger, err)
+
+		_, err = c.Incr("incr", 1, "abc")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("IncrTtl", func(t *testing.T) {
+		count, _ := c.Incr("incrTtl", 1, "100ms")
+		assert.Equal(t, int64(1), count)
+		// ttl只在创建时设置
+		count, _ = c.Incr("incrTtl", 1, "1h")
+		assert.Equal(t, int64(2), count)
+		expire()
+		assert.False(t, c.Has("incrTtl"))
+		count, _ = c.Incr("incrTtl", 1, "")
+		assert.Equal(t, int64(1), count)
+
+		// 递增整数字符串保留原有的过期时间
+		assert.Nil(t, c.Set("incrTextTtl", "5", "100ms"))
+		count, _ = c.Incr("incrTextTtl", 1, "1h")
+		assert.Equal(t, int64(6), count)
+		expire()
+		assert.False(t, c.Has("incrTextTtl"))
+	})
+
+	t.Run("IncrConcurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = c.Incr("incrConcurrent", 1, "")
+			}()
+		}
+		wg.Wait()
+		count, _ := c.Incr("incrConcurrent", 0, "")
+		assert.Equal(t, int64(50), count)
+	})
+
+	t.Run("SetNX", func(t *testing.T) {
+		ok, err := c.SetNX("lock", "owner1", "100ms")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		ok, err = c.SetNX("lock", "owner2", "100ms")
+		assert.Nil(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, "owner1", c.Get("lock"))
+		expire()
+		ok, _ = c.SetNX("lock", "owner2", "")
+		assert.True(t, ok)
+		assert.Equal(t, "owner2", c.Get("lock"))
+	})
+
+	t.Run("CompareAndSwap", func(t *testing.T) {
+		assert.Nil(t, c.Set("cas", "v1", ""))
+		ok, err := c.CompareAndSwap("cas", "v0", "v2", "")
+		assert.Nil(t, err)
+		assert.False(t, ok)
+		ok, err = c.CompareAndSwap("cas", "v1", "v2", "")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "v2", c.Get("cas"))
+
+		// 按字符串形式比较
+		assert.Nil(t, c.Set("casNumber", 1, ""))
+		ok, _ = c.CompareAndSwap("casNumber", "1", 2, "")
+		assert.True(t, ok)
+
+		ok, _ = c.CompareAndSwap("casNotFound", "v1", "v2", "")
+		assert.False(t, ok)
+		assert.False(t, c.Has("casNotFound"))
+	})
+}
+
+func TestMemoryCacheLRU(t *testing.T) {
+	t.Run("MaxEntries", func(t *testing.T) {
+		c := NewMemoryCache(time.Minute, WithMaxEntries(3))
+		assert.Nil(t, c.Set("a", 1, ""))
+		assert.Nil(t, c.Set("b", 2, ""))
+		assert.Nil(t, c.Set("c", 3, ""))
+		// 访问a，使b成为最久未使用
+		assert.Equal(t, 1, c.Get("a"))
+		assert.Nil(t, c.Set("d", 4, ""))
+		assert.False(t, c.Has("b"))
+		assert.True(t, c.Has("a"))
+		assert.True(t, c.Has("c"))
+		assert.True(t, c.Has("d"))
+
+		// 更新已存在的key不会淘汰
+		assert.Nil(t, c.Set("c", 30, ""))
+		assert.Equal(t, 3, c.Stats().Entries)
+		_, _ = c.Incr("e", 1, "")
+		assert.False(t, c.Has("a"))
+
+		stats := c.Stats()
+		assert.Equal(t, uint64(2), stats.Evictions)
+		assert.Equal(t, 3, stats.Entries)
+
+		assert.Nil(t, c.Delete("c"))
+		assert.Nil(t, c.DeleteByPrefix("d"))
+		assert.Equal(t, 1, c.Stats().Entries)
+		assert.Nil(t, c.Set("f", 1, ""))
+		assert.Nil(t, c.Set("g", 1, ""))
+		assert.True(t, c.Has("e"))
+		assert.Equal(t, uint64(2), c.Stats().Evictions)
+	})
+
+	t.Run("MaxBytes", func(t *testing.T) {
+		c := NewMemoryCache(time.Minute, WithMaxBytes(20))
+		assert.Nil(t, c.Set("k1", "12345678", ""))
+		assert.Nil(t, c.Set("k2", "12345678", ""))
+		assert.Equal(t, int64(20), c.Stats().Bytes)
+		assert.Nil(t, c.Set("k3", "1", ""))
+		assert.False(t, c.Has("k1"))
+		assert.Equal(t, int64(13), c.Stats().Bytes)
+
+		// 替换值时更新大小
+		assert.Nil(t, c.Set("k2", "1", ""))
+		assert.Equal(t, int64(6), c.Stats().Bytes)
+
+		// 单个超过限制的值保留为最新的一项
+		assert.Nil(t, c.Set("big", strings.Repeat("x", 30), ""))
+		assert.Equal(t, 1, c.Stats().Entries)
+		assert.True(t, c.Has("big"))
+
+		assert.Equal(t, int64(len(`{"a":1}`)+1), estimateSize("m", map[string]interface{}{"a": 1}))
+	})
+
+	t.Run("ExpiredRemoval", func(t *testing.T) {
+		c := NewMemoryCache(time.Millisecond*50, WithMaxEntries(10), WithMaxBytes(100))
+		assert.Nil(t, c.Set("a", "1", "60ms"))
+		assert.Nil(t, c.Set("b", "1", ""))
+		time.Sleep(time.Millisecond * 200)
+		stats := c.Stats()
+		assert.Equal(t, 1, stats.Entries)
+		assert.Equal(t, int64(2), stats.Bytes)
+		assert.Equal(t, uint64(0), stats.Evictions)
+		c.StopGC()
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		c := NewMemoryCache(time.Minute)
+		assert.Nil(t, c.Set("a", 1, ""))
+		c.Get("a")
+		c.Get("a")
+		c.Get("b")
+		stats := c.Stats()
+		assert.Equal(t, uint64(2), stats.Hits)
+		assert.Equal(t, uint64(1), stats.Misses)
+		assert.Equal(t, int64(0), stats.Bytes)
+	})
+}
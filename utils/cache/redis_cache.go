@@ -0,0 +1,395 @@
+//go:build with_redis
+
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/json"
+)
+
+// DefaultRedisScanCount is the default COUNT hint of the SCAN command used by prefix operations.
+const DefaultRedisScanCount = 500
+
+// RedisCache is a Redis-backed cache implementation.
+// Cached data survives restarts and can be shared by multiple RuleGo instances,
+// for example by setting it as types.Config.Cache.
+//
+// Values are stored JSON encoded and decoded on Get, so maps, slices, numbers and booleans
+// keep their structure. Values written by other clients that are not valid JSON are returned as strings.
+// GetByPrefix and DeleteByPrefix use SCAN instead of KEYS, so they do not block the server.
+//
+// RedisCache 基于Redis的缓存实现，缓存数据在重启后不会丢失，并且可以在多个RuleGo实例间共享，
+// 例如设置为 types.Config.Cache。
+// 值使用JSON编码存储，Get时解码，因此map、数组、数字和布尔值会保留原有结构。其他客户端写入的非JSON值作为字符串返回。
+// GetByPrefix 和 DeleteByPrefix 使用 SCAN 而不是 KEYS，不会阻塞服务器。
+//
+// RedisCache is only compiled with the with_redis build tag: go build -tags with_redis .
+// RedisCache 只在使用 with_redis 编译标签时编译：go build -tags with_redis .
+type RedisCache struct {
+	client redis.UniversalClient
+	// ScanCount SCAN命令的COUNT参数
+	ScanCount int64
+}
+
+// NewRedisCache creates a new RedisCache instance using the given client.
+// The client is owned by the caller and is not closed by the cache.
+func NewRedisCache(client redis.UniversalClient) *RedisCache {
+	return &RedisCache{
+		client:    client,
+		ScanCount: DefaultRedisScanCount,
+	}
+}
+
+// Client returns the underlying Redis client.
+func (c *RedisCache) Client() redis.UniversalClient {
+	return c.client
+}
+
+// Set stores a value in the cache with the given key and an optional expiration duration.
+// Parameters:
+//   - key: The cache key (string)
+//   - value: The value to store (interface{}), it is stored JSON encoded
+//   - ttl: Time-to-live duration as string (e.g. "10m", "1h")
+//
+// Returns:
+//   - error if ttl parsing, value encoding or the Redis command fails
+//
+// If ttl is 0 or empty, the item will not expire.
+func (c *RedisCache) Set(key string, value interface{}, ttl string) error {
+	if c == nil || c.client == nil {
+		return types.ErrCacheNotInitialized
+	}
+	dur, err := parseRedisTtl(ttl)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(context.Background(), key, data, dur).Err()
+}
+
+// Get retrieves a value from the cache by its key.
+// It returns nil if the key does not exist, has expired or the Redis command fails.
+func (c *RedisCache) Get(key string) interface{} {
+	if c == nil || c.client == nil {
+		return nil
+	}
+	data, err := c.client.Get(context.Background(), key).Result()
+	if err != nil {
+		return nil
+	}
+	return decodeRedisValue(data)
+}
+
+// Has checks if a key exists in the cache
+func (c *RedisCache) Has(key string) bool {
+	if c == nil || c.client == nil {
+		return false
+	}
+	count, err := c.client.Exists(context.Background(), key).Result()
+	return err == nil && count > 0
+}
+
+// Delete removes a cache item by key
+func (c *RedisCache) Delete(key string) error {
+	if c == nil || c.client == nil {
+		return types.ErrCacheNotInitialized
+	}
+	return c.client.Del(context.Background(), key).Err()
+}
+
+// DeleteByPrefix removes all cache items with the given prefix.
+// Matching keys are scanned first and then deleted in pipelined batches of about ScanCount keys.
+func (c *RedisCache) DeleteByPrefix(prefix string) error {
+	if c == nil || c.client == nil {
+		return types.ErrCacheNotInitialized
+	}
+	ctx := context.Background()
+	// 先收集所有key再删除，避免扫描过程中删除导致游标遗漏
+	type batch struct {
+		client redis.Cmdable
+		keys   []string
+	}
+	var batches []batch
+	if err := c.scan(ctx, prefix, func(client redis.Cmdable, keys []string) error {
+		batches = append(batches, batch{client: client, keys: keys})
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, item := range batches {
+		if _, err := item.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range item.keys {
+				pipe.Del(ctx, key)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetByPrefix retrieves all values with keys matching the specified prefix
+// Parameters:
+//   - prefix: key prefix to match (string)
+//
+// Returns:
+//   - map[string]interface{}: map of matching key-value pairs, keys expired during the scan are skipped
+func (c *RedisCache) GetByPrefix(prefix string) map[string]interface{} {
+	result := make(map[string]interface{})
+	if c == nil || c.client == nil {
+		return result
+	}
+	ctx := context.Background()
+	_ = c.scan(ctx, prefix, func(client redis.Cmdable, keys []string) error {
+		cmds := make([]*redis.StringCmd, len(keys))
+		_, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				cmds[i] = pipe.Get(ctx, key)
+			}
+			return nil
+		})
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		for i, cmd := range cmds {
+			if data, err := cmd.Result(); err == nil {
+				result[keys[i]] = decodeRedisValue(data)
+			}
+		}
+		return nil
+	})
+	return result
+}
+
+// Incr atomically adds delta to the integer value of key and returns the new value.
+// A missing or expired key is treated as 0, ttl is only applied when the key is created.
+// Values that INCRBY rejects, such as the JSON encoded string "5", are decoded and incremented
+// the same way as MemoryCache.
+// Returns types.ErrCacheValueNotInteger if the stored value is not an integer.
+func (c *RedisCache) Incr(key string, delta int64, ttl string) (int64, error) {
+	if c == nil || c.client == nil {
+		return 0, types.ErrCacheNotInitialized
+	}
+	dur, err := parseRedisTtl(ttl)
+	if err != nil {
+		return 0, err
+	}
+	ctx := context.Background()
+	var incrCmd *redis.IntCmd
+	// 在事务中创建不存在的key并设置过期时间，再执行INCRBY
+	_, err = c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SetNX(ctx, key, 0, dur)
+		incrCmd = pipe.IncrBy(ctx, key, delta)
+		return nil
+	})
+	if incrCmd != nil && incrCmd.Err() != nil {
+		if strings.Contains(incrCmd.Err().Error(), "not an integer") {
+			return c.incrDecoded(ctx, key, delta, dur)
+		}
+		return 0, incrCmd.Err()
+	}
+	if err != nil {
+		return 0, err
+	}
+	return incrCmd.Val(), nil
+}
+
+// incrDecoded 递增INCRBY不支持的值，例如JSON编码的字符串 "5"。值按 MemoryCache 的规则解码为整数，
+// 使用 WATCH 保证原子性，并保留原有的过期时间
+func (c *RedisCache) incrDecoded(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	for {
+		var value int64
+		err := c.client.Watch(ctx, func(tx *redis.Tx) error {
+			current, err := tx.Get(ctx, key).Result()
+			keepTTL := true
+			if err == redis.Nil {
+				// 期间被删除或者过期，与不存在的key相同
+				keepTTL = false
+				value = delta
+			} else if err != nil {
+				return err
+			} else if number, ok := toInt64(decodeRedisValue(current)); ok {
+				value = number + delta
+			} else {
+				return types.ErrCacheValueNotInteger
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				if keepTTL {
+					pipe.SetArgs(ctx, key, value, redis.SetArgs{KeepTTL: true})
+				} else {
+					pipe.Set(ctx, key, value, ttl)
+				}
+				return nil
+			})
+			return err
+		}, key)
+		if err == redis.TxFailedErr {
+			// 并发修改，重试
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		return value, nil
+	}
+}
+
+// SetNX stores the value only if the key does not exist or has expired.
+// It returns true if the value was stored.
+func (c *RedisCache) SetNX(key string, value interface{}, ttl string) (bool, error) {
+	if c == nil || c.client == nil {
+		return false, types.ErrCacheNotInitialized
+	}
+	dur, err := parseRedisTtl(ttl)
+	if err != nil {
+		return false, err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	return c.client.SetNX(context.Background(), key, data, dur).Result()
+}
+
+// CompareAndSwap replaces the value of key with newValue only if the current value equals oldValue.
+// Values are compared by their string form after decoding, the same as MemoryCache.
+// It uses WATCH, so a concurrent modification of the key makes it return false.
+func (c *RedisCache) CompareAndSwap(key string, oldValue, newValue interface{}, ttl string) (bool, error) {
+	if c == nil || c.client == nil {
+		return false, types.ErrCacheNotInitialized
+	}
+	dur, err := parseRedisTtl(ttl)
+	if err != nil {
+		return false, err
+	}
+	data, err := json.Marshal(newValue)
+	if err != nil {
+		return false, err
+	}
+	ctx := context.Background()
+	swapped := false
+	err = c.client.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, key).Result()
+		if err == redis.Nil {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !valueEqual(decodeRedisValue(current), oldValue) {
+			return nil
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, dur)
+			return nil
+		})
+		if err == nil {
+			swapped = true
+		}
+		return err
+	}, key)
+	if err == redis.TxFailedErr {
+		return false, nil
+	}
+	return swapped, err
+}
+
+// scan 扫描匹配前缀的key，分批回调。集群模式下key可能属于不同的槽，因此回调中需要逐个key执行命令
+func (c *RedisCache) scan(ctx context.Context, prefix string, fn func(client redis.Cmdable, keys []string) error) error {
+	count := c.ScanCount
+	if count <= 0 {
+		count = DefaultRedisScanCount
+	}
+	match := escapeRedisPattern(prefix) + "*"
+	// 集群模式需要扫描每个主节点
+	if cluster, ok := c.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return scanKeys(ctx, client, match, count, fn)
+		})
+	}
+	return scanKeys(ctx, c.client, match, count, fn)
+}
+
+// scanKeys 使用SCAN命令遍历匹配的key
+func scanKeys(ctx context.Context, client redis.Cmdable, match string, count int64, fn func(client redis.Cmdable, keys []string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, match, count).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := fn(client, keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// escapeRedisPattern 转义Redis glob模式的特殊字符
+func escapeRedisPattern(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// parseRedisTtl 解析过期时间，0表示永不过期
+func parseRedisTtl(ttl string) (time.Duration, error) {
+	if ttl == "" {
+		return 0, nil
+	}
+	dur, err := time.ParseDuration(ttl)
+	if err != nil {
+		return 0, err
+	}
+	if dur < 0 {
+		dur = 0
+	}
+	return dur, nil
+}
+
+// decodeRedisValue 解码JSON值，非JSON值作为字符串返回
+func decodeRedisValue(data string) interface{} {
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return data
+	}
+	return value
+}
+
+// Ensure RedisCache implements the AtomicCache interface.
+var _ types.AtomicCache = (*RedisCache)(nil)
@@ -0,0 +1,127 @@
+//go:build with_redis
+
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func newTestRedisCache(t *testing.T) (*RedisCache, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+	return NewRedisCache(client), server
+}
+
+func TestRedisCache(t *testing.T) {
+	c, server := newTestRedisCache(t)
+
+	t.Run("SetAndGet", func(t *testing.T) {
+		assert.Nil(t, c.Set("key1", "value1", ""))
+		assert.Equal(t, "value1", c.Get("key1"))
+		assert.Nil(t, c.Set("key2", map[string]interface{}{"name": "test", "age": 18}, "1m"))
+		assert.Equal(t, map[string]interface{}{"name": "test", "age": float64(18)}, c.Get("key2"))
+		assert.Nil(t, c.Set("key3", 1.5, ""))
+		assert.Equal(t, 1.5, c.Get("key3"))
+		assert.Nil(t, c.Get("notFound"))
+
+		// 其他客户端写入的非JSON值
+		assert.Nil(t, server.Set("raw", "not json"))
+		assert.Equal(t, "not json", c.Get("raw"))
+
+		assert.NotNil(t, c.Set("key4", "value4", "abc"))
+	})
+
+	t.Run("Ttl", func(t *testing.T) {
+		assert.Nil(t, c.Set("ttlKey", "value", "10s"))
+		assert.True(t, c.Has("ttlKey"))
+		assert.Equal(t, 10*time.Second, server.TTL("ttlKey"))
+		server.FastForward(11 * time.Second)
+		assert.False(t, c.Has("ttlKey"))
+		assert.Nil(t, c.Get("ttlKey"))
+
+		// 不过期
+		assert.Nil(t, c.Set("noTtlKey", "value", "0"))
+		assert.Equal(t, time.Duration(0), server.TTL("noTtlKey"))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert.Nil(t, c.Set("delKey", "value", ""))
+		assert.Nil(t, c.Delete("delKey"))
+		assert.False(t, c.Has("delKey"))
+		assert.Nil(t, c.Delete("delKey"))
+	})
+
+	t.Run("Prefix", func(t *testing.T) {
+		c.ScanCount = 2
+		for i := 0; i < 5; i++ {
+			assert.Nil(t, c.Set(fmt.Sprintf("user:%d", i), i, ""))
+		}
+		assert.Nil(t, c.Set("user*:x", "special", ""))
+		assert.Nil(t, c.Set("other:1", "other", ""))
+
+		result := c.GetByPrefix("user:")
+		assert.Equal(t, 5, len(result))
+		assert.Equal(t, float64(3), result["user:3"])
+
+		// 特殊字符按字面匹配
+		result = c.GetByPrefix("user*")
+		assert.Equal(t, 1, len(result))
+		assert.Equal(t, "special", result["user*:x"])
+
+		assert.Nil(t, c.DeleteByPrefix("user:"))
+		assert.Equal(t, 0, len(c.GetByPrefix("user:")))
+		assert.True(t, c.Has("user*:x"))
+		assert.True(t, c.Has("other:1"))
+	})
+
+	t.Run("Namespace", func(t *testing.T) {
+		nc := NewNamespaceCache(c, "chain1:")
+		assert.Nil(t, nc.Set("a", "1", ""))
+		assert.Nil(t, nc.Set("b", "2", ""))
+		assert.Equal(t, "1", c.Get("chain1:a"))
+		assert.Equal(t, map[string]interface{}{"a": "1", "b": "2"}, nc.GetByPrefix(""))
+		assert.Nil(t, nc.DeleteByPrefix(""))
+		assert.False(t, c.Has("chain1:a"))
+	})
+
+	t.Run("NotInitialized", func(t *testing.T) {
+		var nilCache *RedisCache
+		assert.Equal(t, types.ErrCacheNotInitialized, nilCache.Set("a", "1", ""))
+		assert.Nil(t, nilCache.Get("a"))
+		assert.False(t, nilCache.Has("a"))
+		assert.Equal(t, 0, len(nilCache.GetByPrefix("")))
+	})
+}
+
+func TestRedisCacheAtomic(t *testing.T) {
+	c, server := newTestRedisCache(t)
+	testAtomicCache(t, c, func() {
+		server.FastForward(time.Millisecond * 150)
+	})
+}
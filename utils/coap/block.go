@@ -0,0 +1,99 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coap
+
+const (
+	// DefaultBlockSize 默认块大小，负荷超过该大小时分块传输
+	DefaultBlockSize = 1024
+	// maxBlockSZX 最大块大小指数，对应1024字节，7为保留值
+	maxBlockSZX = 6
+	// blockSizeShift 块大小为 2^(SZX+4)
+	blockSizeShift = 4
+)
+
+// Block Block1 和 Block2 选项值（RFC 7959），Block1 用于分块发送请求负荷，Block2 用于分块接收响应负荷
+type Block struct {
+	// Num 块序号
+	Num uint32
+	// More 是否还有后续块
+	More bool
+	// SZX 块大小指数，块大小为 2^(SZX+4)，取值0~6
+	SZX uint8
+}
+
+// Size 块大小
+func (b Block) Size() int {
+	return 1 << (b.SZX + blockSizeShift)
+}
+
+// Offset 块在负荷中的偏移
+func (b Block) Offset() int {
+	return int(b.Num) * b.Size()
+}
+
+// BlockSZX 返回不超过 size 的最大块大小指数，size 小于16时返回0，大于1024时返回6
+func BlockSZX(size int) uint8 {
+	var szx uint8
+	for szx < maxBlockSZX && 1<<(szx+1+blockSizeShift) <= size {
+		szx++
+	}
+	return szx
+}
+
+// SliceBlock 返回负荷中 block 对应的部分，返回的块根据是否还有剩余负荷设置 More。
+// 块的偏移超出负荷时返回false
+func SliceBlock(payload []byte, block Block) ([]byte, Block, bool) {
+	offset := block.Offset()
+	if offset > len(payload) || (offset == len(payload) && offset > 0) {
+		return nil, block, false
+	}
+	end := offset + block.Size()
+	block.More = end < len(payload)
+	if !block.More {
+		end = len(payload)
+	}
+	return payload[offset:end], block, true
+}
+
+// Block 返回 Block1 或 Block2 选项值
+func (m *Message) Block(id OptionID) (Block, bool) {
+	v, ok := m.OptionUint(id)
+	if !ok {
+		return Block{}, false
+	}
+	block := Block{Num: v >> 4, More: v&0x08 != 0, SZX: uint8(v & 0x07)}
+	if block.SZX > maxBlockSZX {
+		return Block{}, false
+	}
+	return block, true
+}
+
+// SetBlock 设置 Block1 或 Block2 选项
+func (m *Message) SetBlock(id OptionID, block Block) {
+	v := block.Num<<4 | uint32(block.SZX&0x07)
+	if block.More {
+		v |= 0x08
+	}
+	m.SetOptionUint(id, v)
+}
+
+// clone 复制消息，选项切片单独复制
+func (m *Message) clone() *Message {
+	c := *m
+	c.Options = append([]Option(nil), m.Options...)
+	return &c
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coap
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestBlock(t *testing.T) {
+	t.Run("Option", func(t *testing.T) {
+		m := &Message{}
+		m.SetBlock(Block2, Block{Num: 5, More: true, SZX: 6})
+		//RFC 7959：NUM<<4 | M<<3 | SZX
+		v, ok := m.OptionUint(Block2)
+		assert.True(t, ok)
+		assert.Equal(t, uint32(5<<4|0x08|6), v)
+
+		data, err := m.Marshal()
+		assert.Nil(t, err)
+		decoded, err := Unmarshal(data)
+		assert.Nil(t, err)
+		block, ok := decoded.Block(Block2)
+		assert.True(t, ok)
+		assert.Equal(t, Block{Num: 5, More: true, SZX: 6}, block)
+		assert.Equal(t, 1024, block.Size())
+		assert.Equal(t, 5120, block.Offset())
+
+		//第一个块编码为空值
+		m.SetBlock(Block1, Block{})
+		block, ok = m.Block(Block1)
+		assert.True(t, ok)
+		assert.Equal(t, Block{}, block)
+		assert.Equal(t, 16, block.Size())
+
+		//SZX 7 为保留值
+		m.SetOptionUint(Block1, 7)
+		_, ok = m.Block(Block1)
+		assert.False(t, ok)
+		_, ok = (&Message{}).Block(Block2)
+		assert.False(t, ok)
+	})
+
+	t.Run("SZX", func(t *testing.T) {
+		assert.Equal(t, uint8(0), BlockSZX(0))
+		assert.Equal(t, uint8(0), BlockSZX(16))
+		assert.Equal(t, uint8(0), BlockSZX(31))
+		assert.Equal(t, uint8(1), BlockSZX(32))
+		assert.Equal(t, uint8(5), BlockSZX(1000))
+		assert.Equal(t, uint8(6), BlockSZX(1024))
+		assert.Equal(t, uint8(6), BlockSZX(64*1024))
+	})
+
+	t.Run("Slice", func(t *testing.T) {
+		payload := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+		part, block, ok := SliceBlock(payload, Block{Num: 0})
+		assert.True(t, ok)
+		assert.Equal(t, "0123456789abcdef", string(part))
+		assert.True(t, block.More)
+		part, block, ok = SliceBlock(payload, Block{Num: 2})
+		assert.True(t, ok)
+		assert.Equal(t, "wxyz", string(part))
+		assert.False(t, block.More)
+		_, _, ok = SliceBlock(payload, Block{Num: 3})
+		assert.False(t, ok)
+
+		//负荷正好是块大小的整数倍
+		part, block, ok = SliceBlock(payload[:32], Block{Num: 1, More: true})
+		assert.True(t, ok)
+		assert.Equal(t, "ghijklmnopqrstuv", string(part))
+		assert.False(t, block.More)
+		_, _, ok = SliceBlock(payload[:32], Block{Num: 2})
+		assert.False(t, ok)
+
+		part, block, ok = SliceBlock(nil, Block{})
+		assert.True(t, ok)
+		assert.Equal(t, 0, len(part))
+		assert.False(t, block.More)
+	})
+}
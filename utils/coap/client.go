@@ -0,0 +1,456 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coap
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAckTimeout 首次重传等待确认的时间，每次重传加倍
+	DefaultAckTimeout = 2 * time.Second
+	// DefaultMaxRetransmit 最大重传次数
+	DefaultMaxRetransmit = 4
+	// MaxMessageSize 读取的最大数据报大小
+	MaxMessageSize = 64 * 1024
+	// tokenLen 生成的token长度
+	tokenLen = 4
+	// observeFreshness 相隔超过该时间的通知总是视为较新的通知（RFC 7641 3.4）
+	observeFreshness = 128 * time.Second
+	// observeSeqHalf 24位 Observe 序号空间的一半
+	observeSeqHalf = 1 << 23
+)
+
+var (
+	// ErrTimeout 等待响应超时
+	ErrTimeout = errors.New("coap request timeout")
+	// ErrReset 服务端复位了请求
+	ErrReset = errors.New("coap request reset by server")
+	// ErrClientClosed 客户端已关闭
+	ErrClientClosed = errors.New("coap client closed")
+	// ErrBlockMismatch 分块传输的响应块与请求不一致，或者传输期间资源发生了变化
+	ErrBlockMismatch = errors.New("coap block-wise transfer mismatch")
+)
+
+// NewToken 生成随机token
+func NewToken() []byte {
+	token := make([]byte, tokenLen)
+	_, _ = rand.Read(token)
+	return token
+}
+
+// exchange 等待响应的请求
+type exchange struct {
+	messageID uint16
+	acked     chan struct{}
+	ackOnce   sync.Once
+	response  chan *Message
+}
+
+func (e *exchange) ack() {
+	e.ackOnce.Do(func() {
+		close(e.acked)
+	})
+}
+
+// deliver 投递响应，重复的响应被丢弃
+func (e *exchange) deliver(m *Message) {
+	select {
+	case e.response <- m:
+	default:
+	}
+}
+
+// observation 客户端的观察，记录最近一次通知的序号和接收时间，用于丢弃乱序到达的旧通知
+type observation struct {
+	handler func(*Message)
+	seq     uint32
+	time    time.Time
+}
+
+// fresh 通知是否比最近一次通知新，是则更新序号和时间。调用方需持有锁
+func (o *observation) fresh(m *Message, now time.Time) bool {
+	seq, ok := m.Observe()
+	if !ok {
+		//不带 Observe 选项的通知表示观察结束，总是交给 handler
+		return true
+	}
+	if !o.time.IsZero() && !ObserveFresh(o.seq, o.time, seq, now) {
+		return false
+	}
+	o.seq, o.time = seq, now
+	return true
+}
+
+// ObserveFresh 按 RFC 7641 3.4 判断在 t2 收到的序号为 v2 的通知是否比在 t1 收到的序号为 v1 的通知新。
+// 序号为24位，按序号空间的一半比较，因此序号回绕后仍然有序；相隔超过128秒的通知总是较新的通知
+func ObserveFresh(v1 uint32, t1 time.Time, v2 uint32, t2 time.Time) bool {
+	return (v1 < v2 && v2-v1 < observeSeqHalf) ||
+		(v1 > v2 && v1-v2 > observeSeqHalf) ||
+		t2.After(t1.Add(observeFreshness))
+}
+
+// Client CoAP UDP客户端，连接一个服务端，可以并发发送请求。请求和响应按token匹配，
+// Confirmable 请求在收到确认前按指数退避重传。超过 BlockSize 的请求负荷使用 Block1 分块发送，
+// 带 Block2 选项的响应自动获取剩余的块并合并负荷
+type Client struct {
+	conn net.Conn
+	// AckTimeout 首次重传等待确认的时间，默认 DefaultAckTimeout
+	AckTimeout time.Duration
+	// MaxRetransmit 最大重传次数，默认 DefaultMaxRetransmit
+	MaxRetransmit int
+	// BlockSize 请求负荷超过该大小时分块发送，取值16~1024，默认 DefaultBlockSize，0表示不分块
+	BlockSize int
+
+	messageID uint16
+	exchanges map[string]*exchange
+	observers map[string]*observation
+	lock      sync.Mutex
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Dial 连接服务端，network 为 udp、udp4 或 udp6
+func Dial(network, address string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout(network, address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient 使用已建立的连接创建客户端并开始读取响应
+func NewClient(conn net.Conn) *Client {
+	var id [2]byte
+	_, _ = rand.Read(id[:])
+	c := &Client{
+		conn:          conn,
+		AckTimeout:    DefaultAckTimeout,
+		MaxRetransmit: DefaultMaxRetransmit,
+		BlockSize:     DefaultBlockSize,
+		messageID:     binary.BigEndian.Uint16(id[:]),
+		exchanges:     make(map[string]*exchange),
+		observers:     make(map[string]*observation),
+		closed:        make(chan struct{}),
+	}
+	go c.read()
+	return c
+}
+
+// RemoteAddr 服务端地址
+func (c *Client) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// Do 发送请求并等待响应。没有token时生成随机token，消息ID由客户端分配。
+// 服务端复位请求返回 ErrReset，超时返回 ErrTimeout。
+// 分块传输时每个块的请求单独计算超时，返回的响应包含合并后的完整负荷
+func (c *Client) Do(req *Message, timeout time.Duration) (*Message, error) {
+	if c.BlockSize > 0 && len(req.Payload) > c.BlockSize {
+		return c.upload(req, timeout)
+	}
+	resp, err := c.do(req, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return c.download(req, resp, timeout)
+}
+
+// upload 使用 Block1 分块发送请求负荷，服务端对非最后的块回复 2.31 Continue，
+// 服务端要求更小的块时按服务端的块大小继续发送
+func (c *Client) upload(req *Message, timeout time.Duration) (*Message, error) {
+	if len(req.Token) == 0 {
+		req.Token = NewToken()
+	}
+	block := Block{SZX: BlockSZX(c.BlockSize)}
+	for {
+		part, current, ok := SliceBlock(req.Payload, block)
+		if !ok {
+			return nil, ErrBlockMismatch
+		}
+		blockReq := req.clone()
+		blockReq.Payload = part
+		blockReq.SetBlock(Block1, current)
+		if current.Num == 0 {
+			blockReq.SetOptionUint(Size1, uint32(len(req.Payload)))
+		}
+		resp, err := c.do(blockReq, timeout)
+		if err != nil {
+			return nil, err
+		}
+		if !current.More {
+			return c.download(blockReq, resp, timeout)
+		}
+		if resp.Code != Continue {
+			//服务端拒绝或者不支持分块传输
+			return resp, nil
+		}
+		next := current.Offset() + current.Size()
+		if ack, ok := resp.Block(Block1); ok && ack.SZX < block.SZX {
+			block.SZX = ack.SZX
+		}
+		block.Num = uint32(next / block.Size())
+	}
+}
+
+// download 响应带 Block2 选项且还有后续块时，使用 Block2 依次获取剩余的块并合并负荷。
+// 后续请求不带负荷、Block1 和 Observe 选项，响应的 ETag 变化时返回 ErrBlockMismatch
+func (c *Client) download(req *Message, resp *Message, timeout time.Duration) (*Message, error) {
+	block, ok := resp.Block(Block2)
+	if !ok || !block.More {
+		return resp, nil
+	}
+	etag, _ := resp.Option(ETag)
+	payload := append([]byte(nil), resp.Payload...)
+	for block.More {
+		next := req.clone()
+		next.Token = nil
+		next.Payload = nil
+		next.RemoveOption(Block1)
+		next.RemoveOption(Size1)
+		next.RemoveOption(Observe)
+		next.SetBlock(Block2, Block{Num: block.Num + 1, SZX: block.SZX})
+		blockResp, err := c.do(next, timeout)
+		if err != nil {
+			return nil, err
+		}
+		if !blockResp.Code.IsSuccess() {
+			return blockResp, nil
+		}
+		current, ok := blockResp.Block(Block2)
+		if tag, _ := blockResp.Option(ETag); !ok || current.Num != block.Num+1 || string(tag) != string(etag) {
+			return nil, ErrBlockMismatch
+		}
+		payload = append(payload, blockResp.Payload...)
+		block = current
+	}
+	resp.Payload = payload
+	resp.RemoveOption(Block2)
+	resp.RemoveOption(Size2)
+	return resp, nil
+}
+
+// do 完成一次请求和响应的交换
+func (c *Client) do(req *Message, timeout time.Duration) (*Message, error) {
+	if len(req.Token) == 0 {
+		req.Token = NewToken()
+	}
+	key := string(req.Token)
+	c.lock.Lock()
+	if _, ok := c.exchanges[key]; ok {
+		c.lock.Unlock()
+		return nil, fmt.Errorf("duplicate coap token: %x", req.Token)
+	}
+	c.messageID++
+	req.MessageID = c.messageID
+	ex := &exchange{messageID: req.MessageID, acked: make(chan struct{}), response: make(chan *Message, 1)}
+	c.exchanges[key] = ex
+	c.lock.Unlock()
+	defer func() {
+		c.lock.Lock()
+		delete(c.exchanges, key)
+		c.lock.Unlock()
+	}()
+
+	data, err := req.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = c.conn.Write(data); err != nil {
+		return nil, err
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	interval := c.AckTimeout
+	if req.Type == Confirmable && c.MaxRetransmit > 0 && interval > 0 {
+		retransmit := time.NewTimer(interval)
+		defer retransmit.Stop()
+		retries := 0
+		for {
+			select {
+			case resp := <-ex.response:
+				return response(resp)
+			case <-ex.acked:
+				//已确认，等待单独的响应
+				return c.wait(ex, deadline.C)
+			case <-retransmit.C:
+				if retries++; retries > c.MaxRetransmit {
+					return c.wait(ex, deadline.C)
+				}
+				if _, err = c.conn.Write(data); err != nil {
+					return nil, err
+				}
+				interval *= 2
+				retransmit.Reset(interval)
+			case <-deadline.C:
+				return nil, ErrTimeout
+			case <-c.closed:
+				return nil, ErrClientClosed
+			}
+		}
+	}
+	return c.wait(ex, deadline.C)
+}
+
+// wait 等待响应
+func (c *Client) wait(ex *exchange, deadline <-chan time.Time) (*Message, error) {
+	select {
+	case resp := <-ex.response:
+		return response(resp)
+	case <-deadline:
+		return nil, ErrTimeout
+	case <-c.closed:
+		return nil, ErrClientClosed
+	}
+}
+
+func response(m *Message) (*Message, error) {
+	if m.Type == Reset {
+		return nil, ErrReset
+	}
+	return m, nil
+}
+
+// Observe 发送 Observe 注册请求，返回首个响应和取消函数，后续通知交给 handler 处理。
+// handler 在读取协程中调用，不能阻塞。服务端不支持观察时响应不包含 Observe 选项，不会有后续通知。
+// 按 RFC 7641 3.4 比较通知的序号，乱序到达的旧通知被丢弃，24位序号回绕后的通知仍然视为新的通知。
+// 取消后客户端对后续通知回复复位消息，服务端收到后删除观察者
+func (c *Client) Observe(req *Message, timeout time.Duration, handler func(*Message)) (*Message, func(), error) {
+	if len(req.Token) == 0 {
+		req.Token = NewToken()
+	}
+	req.SetOptionUint(Observe, 0)
+	key := string(req.Token)
+	cancel := func() {
+		c.lock.Lock()
+		delete(c.observers, key)
+		c.lock.Unlock()
+	}
+	//先注册观察者，避免首个通知在响应返回前到达
+	o := &observation{handler: handler}
+	c.lock.Lock()
+	c.observers[key] = o
+	c.lock.Unlock()
+	resp, err := c.Do(req, timeout)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	seq, ok := resp.Observe()
+	if !ok {
+		cancel()
+		return resp, cancel, nil
+	}
+	c.lock.Lock()
+	if o.time.IsZero() {
+		o.seq, o.time = seq, time.Now()
+	}
+	c.lock.Unlock()
+	return resp, cancel, nil
+}
+
+// read 循环读取服务端消息
+func (c *Client) read() {
+	buf := make([]byte, MaxMessageSize)
+	for {
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			//连接被关闭，ICMP端口不可达等临时错误继续读取
+			select {
+			case <-c.closed:
+				return
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				_ = c.Close()
+				return
+			}
+			continue
+		}
+		if m, err := Unmarshal(buf[:n]); err == nil {
+			c.dispatch(m)
+		}
+	}
+}
+
+// dispatch 分发收到的消息
+func (c *Client) dispatch(m *Message) {
+	switch m.Type {
+	case Acknowledgement, Reset:
+		c.lock.Lock()
+		var target *exchange
+		for _, ex := range c.exchanges {
+			if ex.messageID == m.MessageID {
+				target = ex
+				break
+			}
+		}
+		c.lock.Unlock()
+		if target == nil {
+			return
+		}
+		if m.Type == Reset || m.Code != Empty {
+			//复位或者捎带响应
+			target.deliver(m)
+		}
+		target.ack()
+	default:
+		key := string(m.Token)
+		c.lock.Lock()
+		ex := c.exchanges[key]
+		o := c.observers[key]
+		fresh := ex == nil && o != nil && o.fresh(m, time.Now())
+		c.lock.Unlock()
+		if m.Type == Confirmable && (ex != nil || o != nil) {
+			c.send(&Message{Type: Acknowledgement, Code: Empty, MessageID: m.MessageID})
+		}
+		if ex != nil {
+			//单独的响应
+			ex.deliver(m)
+		} else if o != nil {
+			//乱序到达的旧通知只确认，不交给 handler
+			if fresh {
+				o.handler(m)
+			}
+		} else {
+			//未知的响应或者已取消的观察
+			c.send(&Message{Type: Reset, Code: Empty, MessageID: m.MessageID})
+		}
+	}
+}
+
+func (c *Client) send(m *Message) {
+	if data, err := m.Marshal(); err == nil {
+		_, _ = c.conn.Write(data)
+	}
+}
+
+// Close 关闭客户端，结束所有等待的请求
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		err = c.conn.Close()
+	})
+	return err
+}
@@ -0,0 +1,348 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coap
+
+import (
+	"bytes"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+// startServer 启动测试服务，handler 处理收到的每个消息
+func startServer(t *testing.T, handler func(conn *net.UDPConn, addr *net.UDPAddr, m *Message)) *net.UDPConn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	assert.Nil(t, err)
+	go func() {
+		buf := make([]byte, MaxMessageSize)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if m, err := Unmarshal(buf[:n]); err == nil {
+				handler(conn, addr, m)
+			}
+		}
+	}()
+	return conn
+}
+
+func reply(conn *net.UDPConn, addr *net.UDPAddr, m *Message) {
+	data, _ := m.Marshal()
+	_, _ = conn.WriteToUDP(data, addr)
+}
+
+func TestObserveFresh(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		v1, v2 uint32
+		t2     time.Time
+		fresh  bool
+	}{
+		{1, 2, now, true},
+		{2, 1, now, false},
+		{2, 2, now, false},
+		{0, 1<<23 - 1, now, true},
+		{0, 1 << 23, now, false},
+		//24位序号回绕
+		{0xffffff, 0, now, true},
+		{0xfffffe, 1, now, true},
+		{0, 0xffffff, now, false},
+		{1, 0xfffffe, now, false},
+		//相隔超过128秒的通知总是较新
+		{2, 1, now.Add(observeFreshness + time.Second), true},
+		{0, 0xffffff, now.Add(observeFreshness + time.Second), true},
+	}
+	for _, item := range testCases {
+		assert.Equal(t, item.fresh, ObserveFresh(item.v1, now, item.v2, item.t2))
+	}
+}
+
+func TestClient(t *testing.T) {
+	var received int32
+	var observer atomic.Value
+	var wrapObserver atomic.Value
+	var notifyReset int32
+	//dropped 记录被丢弃的请求的接收时间和消息ID
+	var droppedLock sync.Mutex
+	var dropped []time.Time
+	var droppedIDs []uint16
+	var largeRequests int32
+	largePayload := bytes.Repeat([]byte("0123456789"), 10)
+	var uploaded []byte
+	server := startServer(t, func(conn *net.UDPConn, addr *net.UDPAddr, m *Message) {
+		atomic.AddInt32(&received, 1)
+		if m.Type == Reset {
+			atomic.AddInt32(&notifyReset, 1)
+			return
+		}
+		if m.Type == Acknowledgement {
+			return
+		}
+		switch m.Path() {
+		case "/piggyback":
+			reply(conn, addr, &Message{Type: Acknowledgement, Code: Content, MessageID: m.MessageID, Token: m.Token, Payload: m.Payload})
+		case "/separate":
+			//先发送空确认，再单独发送响应
+			reply(conn, addr, &Message{Type: Acknowledgement, MessageID: m.MessageID})
+			reply(conn, addr, &Message{Type: Confirmable, Code: Changed, MessageID: 100, Token: m.Token})
+		case "/lossy":
+			//丢弃第一次请求，测试重传
+			if atomic.LoadInt32(&received) > 1 {
+				reply(conn, addr, &Message{Type: Acknowledgement, Code: Content, MessageID: m.MessageID, Token: m.Token})
+			}
+		case "/reset":
+			reply(conn, addr, &Message{Type: Reset, MessageID: m.MessageID})
+		case "/non":
+			reply(conn, addr, &Message{Type: NonConfirmable, Code: Content, MessageID: 200, Token: m.Token, Payload: []byte("non")})
+		case "/observe":
+			resp := &Message{Type: Acknowledgement, Code: Content, MessageID: m.MessageID, Token: m.Token, Payload: []byte("0")}
+			resp.SetOptionUint(Observe, 1)
+			reply(conn, addr, resp)
+			observer.Store([]interface{}{addr, m.Token})
+		case "/drop":
+			//不回复，测试重传退避
+			droppedLock.Lock()
+			dropped = append(dropped, time.Now())
+			droppedIDs = append(droppedIDs, m.MessageID)
+			droppedLock.Unlock()
+		case "/wrap":
+			resp := &Message{Type: Acknowledgement, Code: Content, MessageID: m.MessageID, Token: m.Token}
+			resp.SetOptionUint(Observe, 0xfffffe)
+			reply(conn, addr, resp)
+			wrapObserver.Store([]interface{}{addr, m.Token})
+		case "/large":
+			//固定使用16字节的块返回
+			atomic.AddInt32(&largeRequests, 1)
+			block, _ := m.Block(Block2)
+			part, block, _ := SliceBlock(largePayload, Block{Num: block.Num})
+			resp := &Message{Type: Acknowledgement, Code: Content, MessageID: m.MessageID, Token: m.Token, Payload: part}
+			resp.SetBlock(Block2, block)
+			reply(conn, addr, resp)
+		case "/upload":
+			block, _ := m.Block(Block1)
+			resp := &Message{Type: Acknowledgement, MessageID: m.MessageID, Token: m.Token}
+			if block.Num == 0 {
+				uploaded = nil
+			}
+			if block.Offset() != len(uploaded) {
+				resp.Code = RequestIncomplete
+				reply(conn, addr, resp)
+				return
+			}
+			uploaded = append(uploaded, m.Payload...)
+			if block.More {
+				//要求客户端使用16字节的块
+				resp.Code = Continue
+				block.SZX = 0
+			} else {
+				resp.Code = Changed
+				resp.Payload = uploaded
+			}
+			resp.SetBlock(Block1, block)
+			reply(conn, addr, resp)
+		}
+	})
+	defer server.Close()
+
+	client, err := Dial("udp", server.LocalAddr().String(), time.Second)
+	assert.Nil(t, err)
+	defer client.Close()
+	client.AckTimeout = time.Millisecond * 100
+
+	newRequest := func(typ Type, path string) *Message {
+		m := &Message{Type: typ, Code: POST}
+		m.SetPath(path)
+		return m
+	}
+
+	t.Run("Piggyback", func(t *testing.T) {
+		req := newRequest(Confirmable, "/piggyback")
+		req.Payload = []byte("hello")
+		resp, err := client.Do(req, time.Second)
+		assert.Nil(t, err)
+		assert.Equal(t, Content, resp.Code)
+		assert.Equal(t, "hello", string(resp.Payload))
+	})
+
+	t.Run("Separate", func(t *testing.T) {
+		resp, err := client.Do(newRequest(Confirmable, "/separate"), time.Second)
+		assert.Nil(t, err)
+		assert.Equal(t, Changed, resp.Code)
+	})
+
+	t.Run("Retransmit", func(t *testing.T) {
+		atomic.StoreInt32(&received, 0)
+		resp, err := client.Do(newRequest(Confirmable, "/lossy"), time.Second)
+		assert.Nil(t, err)
+		assert.Equal(t, Content, resp.Code)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&received))
+	})
+
+	t.Run("RetransmitBackoff", func(t *testing.T) {
+		client.AckTimeout = time.Millisecond * 40
+		client.MaxRetransmit = 3
+		defer func() {
+			client.AckTimeout = time.Millisecond * 100
+			client.MaxRetransmit = DefaultMaxRetransmit
+		}()
+		_, err := client.Do(newRequest(Confirmable, "/drop"), time.Millisecond*600)
+		assert.Equal(t, ErrTimeout, err)
+
+		droppedLock.Lock()
+		defer droppedLock.Unlock()
+		//首次发送加 MaxRetransmit 次重传，重传使用同一个消息ID
+		assert.Equal(t, 4, len(dropped))
+		for _, id := range droppedIDs {
+			assert.Equal(t, droppedIDs[0], id)
+		}
+		//重传间隔依次加倍：40ms、80ms、160ms
+		interval := client.AckTimeout
+		for i := 1; i < len(dropped); i++ {
+			assert.True(t, dropped[i].Sub(dropped[i-1]) >= interval-time.Millisecond*10)
+			interval *= 2
+		}
+		//等待响应期间不再重传
+		assert.True(t, dropped[len(dropped)-1].Sub(dropped[0]) < time.Millisecond*600)
+	})
+
+	t.Run("NonConfirmable", func(t *testing.T) {
+		resp, err := client.Do(newRequest(NonConfirmable, "/non"), time.Second)
+		assert.Nil(t, err)
+		assert.Equal(t, "non", string(resp.Payload))
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		_, err := client.Do(newRequest(Confirmable, "/reset"), time.Second)
+		assert.Equal(t, ErrReset, err)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		_, err := client.Do(newRequest(NonConfirmable, "/none"), time.Millisecond*200)
+		assert.Equal(t, ErrTimeout, err)
+	})
+
+	t.Run("Observe", func(t *testing.T) {
+		notifications := make(chan string, 10)
+		req := &Message{Type: Confirmable, Code: GET}
+		req.SetPath("/observe")
+		resp, cancel, err := client.Observe(req, time.Second, func(m *Message) {
+			notifications <- string(m.Payload)
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "0", string(resp.Payload))
+
+		target := observer.Load().([]interface{})
+		notify := func(payload string) {
+			m := &Message{Type: NonConfirmable, Code: Content, MessageID: 300, Token: target[1].([]byte), Payload: []byte(payload)}
+			m.SetOptionUint(Observe, 2)
+			reply(server, target[0].(*net.UDPAddr), m)
+		}
+		notify("1")
+		select {
+		case v := <-notifications:
+			assert.Equal(t, "1", v)
+		case <-time.After(time.Second):
+			t.Fatal("notification timeout")
+		}
+
+		//取消后客户端复位通知
+		cancel()
+		notify("2")
+		time.Sleep(time.Millisecond * 100)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&notifyReset))
+		assert.Equal(t, 0, len(notifications))
+	})
+
+	t.Run("ObserveWraparound", func(t *testing.T) {
+		notifications := make(chan string, 10)
+		req := &Message{Type: Confirmable, Code: GET}
+		req.SetPath("/wrap")
+		resp, cancel, err := client.Observe(req, time.Second, func(m *Message) {
+			notifications <- string(m.Payload)
+		})
+		assert.Nil(t, err)
+		defer cancel()
+		seq, _ := resp.Observe()
+		assert.Equal(t, uint32(0xfffffe), seq)
+
+		target := wrapObserver.Load().([]interface{})
+		notify := func(seq uint32, payload string) {
+			m := &Message{Type: NonConfirmable, Code: Content, MessageID: uint16(400 + seq&0xff), Token: target[1].([]byte), Payload: []byte(payload)}
+			m.SetOptionUint(Observe, seq)
+			reply(server, target[0].(*net.UDPAddr), m)
+			time.Sleep(time.Millisecond * 20)
+		}
+		notify(0xffffff, "a")
+		//序号回绕到0后仍然是新的通知
+		notify(0, "b")
+		//乱序到达的旧通知被丢弃
+		notify(0xfffffd, "stale")
+		notify(0, "duplicate")
+		notify(1, "c")
+		for _, expected := range []string{"a", "b", "c"} {
+			select {
+			case v := <-notifications:
+				assert.Equal(t, expected, v)
+			case <-time.After(time.Second):
+				t.Fatal("notification timeout")
+			}
+		}
+		time.Sleep(time.Millisecond * 50)
+		assert.Equal(t, 0, len(notifications))
+	})
+
+	t.Run("Block2", func(t *testing.T) {
+		atomic.StoreInt32(&largeRequests, 0)
+		resp, err := client.Do(newRequest(Confirmable, "/large"), time.Second)
+		assert.Nil(t, err)
+		assert.Equal(t, Content, resp.Code)
+		assert.Equal(t, largePayload, resp.Payload)
+		_, ok := resp.Block(Block2)
+		assert.False(t, ok)
+		//100字节分成7个16字节的块
+		assert.Equal(t, int32(7), atomic.LoadInt32(&largeRequests))
+	})
+
+	t.Run("Block1", func(t *testing.T) {
+		client.BlockSize = 32
+		defer func() {
+			client.BlockSize = DefaultBlockSize
+		}()
+		req := newRequest(Confirmable, "/upload")
+		req.Payload = bytes.Repeat([]byte("abcdefg"), 10)
+		resp, err := client.Do(req, time.Second)
+		assert.Nil(t, err)
+		assert.Equal(t, Changed, resp.Code)
+		//首个块32字节，服务端要求16字节的块后从偏移32继续发送
+		assert.Equal(t, req.Payload, resp.Payload)
+		block, ok := resp.Block(Block1)
+		assert.True(t, ok)
+		assert.Equal(t, Block{Num: 4, SZX: 0}, block)
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		assert.Nil(t, client.Close())
+		_, err := client.Do(newRequest(NonConfirmable, "/none"), time.Second)
+		assert.NotNil(t, err)
+	})
+}
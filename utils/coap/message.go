@@ -0,0 +1,515 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package coap provides a minimal CoAP (RFC 7252) message codec and UDP client with
+// Observe (RFC 7641) and block-wise transfer (RFC 7959) support, shared by the coap endpoint
+// and the coapClient component. DTLS is not supported.
+//
+// Package coap 提供精简的 CoAP（RFC 7252）消息编解码和 UDP 客户端，支持 Observe（RFC 7641）
+// 和分块传输（RFC 7959），由 coap 端点和 coapClient 组件共享。不支持 DTLS。
+package coap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rulego/rulego/api/types"
+)
+
+// Type 消息类型
+type Type uint8
+
+const (
+	// Confirmable 需要确认的消息
+	Confirmable Type = 0
+	// NonConfirmable 不需要确认的消息
+	NonConfirmable Type = 1
+	// Acknowledgement 确认消息
+	Acknowledgement Type = 2
+	// Reset 复位消息，表示无法处理收到的消息
+	Reset Type = 3
+)
+
+func (t Type) String() string {
+	switch t {
+	case Confirmable:
+		return "CON"
+	case NonConfirmable:
+		return "NON"
+	case Acknowledgement:
+		return "ACK"
+	case Reset:
+		return "RST"
+	default:
+		return fmt.Sprintf("Type(%d)", uint8(t))
+	}
+}
+
+// Code 请求方法或者响应码，高3位为类别，低5位为详情，例如 2.05 = 2<<5|5
+type Code uint8
+
+const (
+	Empty  Code = 0
+	GET    Code = 1
+	POST   Code = 2
+	PUT    Code = 3
+	DELETE Code = 4
+
+	Created             Code = 2<<5 | 1
+	Deleted             Code = 2<<5 | 2
+	Valid               Code = 2<<5 | 3
+	Changed             Code = 2<<5 | 4
+	Content             Code = 2<<5 | 5
+	Continue            Code = 2<<5 | 31
+	BadRequest          Code = 4<<5 | 0
+	Unauthorized        Code = 4<<5 | 1
+	BadOption           Code = 4<<5 | 2
+	Forbidden           Code = 4<<5 | 3
+	NotFound            Code = 4<<5 | 4
+	MethodNotAllowed    Code = 4<<5 | 5
+	RequestIncomplete   Code = 4<<5 | 8
+	RequestTooLarge     Code = 4<<5 | 13
+	InternalServerError Code = 5<<5 | 0
+	ServiceUnavailable  Code = 5<<5 | 3
+	GatewayTimeout      Code = 5<<5 | 4
+)
+
+const (
+	codeClassShift            = 5
+	codeDetailMask       Code = 0x1f
+	maxCodeDetail             = 31
+	requestCodeClass          = 0
+	successResponseClass      = 2
+	clientErrorClass          = 4
+	serverErrorClass          = 5
+)
+
+var methodNames = map[Code]string{GET: "GET", POST: "POST", PUT: "PUT", DELETE: "DELETE"}
+
+// ParseMethod 解析请求方法名，不区分大小写
+func ParseMethod(method string) (Code, error) {
+	for code, name := range methodNames {
+		if strings.EqualFold(name, method) {
+			return code, nil
+		}
+	}
+	return Empty, fmt.Errorf("unsupported coap method: %s", method)
+}
+
+// Class 码的类别：0 请求，2 成功，4 客户端错误，5 服务端错误
+func (c Code) Class() int {
+	return int(c >> codeClassShift)
+}
+
+// Detail 码的详情
+func (c Code) Detail() int {
+	return int(c & codeDetailMask)
+}
+
+// IsRequest 是否是请求方法
+func (c Code) IsRequest() bool {
+	return c != Empty && c.Class() == requestCodeClass
+}
+
+// IsSuccess 是否是成功响应
+func (c Code) IsSuccess() bool {
+	return c.Class() == successResponseClass
+}
+
+// String 请求方法返回方法名，响应码返回 c.dd 格式，例如 2.05
+func (c Code) String() string {
+	if name, ok := methodNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d.%02d", c.Class(), c.Detail())
+}
+
+// CodeFromHTTP 把HTTP状态码转换为响应码，例如 404 -> 4.04。200 没有对应的响应码，返回 Content，
+// 无法转换的状态码返回 InternalServerError
+func CodeFromHTTP(statusCode int) Code {
+	if statusCode == 200 {
+		return Content
+	}
+	class, detail := statusCode/100, statusCode%100
+	if (class != successResponseClass && class != clientErrorClass && class != serverErrorClass) || detail > maxCodeDetail {
+		return InternalServerError
+	}
+	return Code(class<<codeClassShift | detail)
+}
+
+// OptionID 选项编号
+type OptionID uint16
+
+const (
+	IfMatch       OptionID = 1
+	URIHost       OptionID = 3
+	ETag          OptionID = 4
+	IfNoneMatch   OptionID = 5
+	Observe       OptionID = 6
+	URIPort       OptionID = 7
+	LocationPath  OptionID = 8
+	URIPath       OptionID = 11
+	ContentFormat OptionID = 12
+	MaxAge        OptionID = 14
+	URIQuery      OptionID = 15
+	Accept        OptionID = 17
+	LocationQuery OptionID = 20
+	Block2        OptionID = 23
+	Block1        OptionID = 27
+	Size2         OptionID = 28
+	ProxyURI      OptionID = 35
+	ProxyScheme   OptionID = 39
+	Size1         OptionID = 60
+)
+
+// MediaType 内容格式（Content-Format）编号
+type MediaType uint16
+
+const (
+	TextPlain     MediaType = 0
+	AppLinkFormat MediaType = 40
+	AppXML        MediaType = 41
+	AppOctets     MediaType = 42
+	AppJSON       MediaType = 50
+	AppCBOR       MediaType = 60
+)
+
+var mediaTypeNames = map[MediaType]string{
+	TextPlain:     "text/plain",
+	AppLinkFormat: "application/link-format",
+	AppXML:        "application/xml",
+	AppOctets:     "application/octet-stream",
+	AppJSON:       "application/json",
+	AppCBOR:       "application/cbor",
+}
+
+// String 返回MIME类型，未知格式返回编号
+func (m MediaType) String() string {
+	if name, ok := mediaTypeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d", uint16(m))
+}
+
+// ParseMediaType 解析MIME类型或者编号，忽略参数，例如 "application/json; charset=utf-8"
+func ParseMediaType(v string) (MediaType, bool) {
+	v = strings.TrimSpace(strings.SplitN(v, ";", 2)[0])
+	for m, name := range mediaTypeNames {
+		if strings.EqualFold(name, v) {
+			return m, true
+		}
+	}
+	var n uint16
+	if _, err := fmt.Sscanf(v, "%d", &n); err == nil && fmt.Sprintf("%d", n) == v {
+		return MediaType(n), true
+	}
+	return 0, false
+}
+
+// MediaTypeOf 消息数据类型对应的内容格式
+func MediaTypeOf(dataType types.DataType) MediaType {
+	switch dataType {
+	case types.JSON:
+		return AppJSON
+	case types.BINARY:
+		return AppOctets
+	default:
+		return TextPlain
+	}
+}
+
+// DataTypeOf 内容格式对应的消息数据类型，其他格式作为二进制处理
+func DataTypeOf(m MediaType) types.DataType {
+	switch m {
+	case AppJSON:
+		return types.JSON
+	case TextPlain, AppLinkFormat, AppXML:
+		return types.TEXT
+	default:
+		return types.BINARY
+	}
+}
+
+// Option 消息选项
+type Option struct {
+	ID    OptionID
+	Value []byte
+}
+
+// Message CoAP消息
+type Message struct {
+	Type      Type
+	Code      Code
+	MessageID uint16
+	Token     []byte
+	Options   []Option
+	Payload   []byte
+}
+
+const (
+	version       = 1
+	payloadMarker = 0xff
+	maxTokenLen   = 8
+	// 选项增量和长度的扩展编码
+	extendByte     = 13
+	extendWord     = 14
+	extendReserved = 15
+	extendByteBase = 13
+	extendWordBase = 269
+)
+
+// ErrInvalidMessage 无法解析的消息
+var ErrInvalidMessage = errors.New("invalid coap message")
+
+// Marshal 编码消息，选项按编号排序
+func (m *Message) Marshal() ([]byte, error) {
+	if len(m.Token) > maxTokenLen {
+		return nil, fmt.Errorf("%w: token length %d", ErrInvalidMessage, len(m.Token))
+	}
+	buf := make([]byte, 4, 4+len(m.Token)+len(m.Payload)+16)
+	buf[0] = version<<6 | byte(m.Type)<<4 | byte(len(m.Token))
+	buf[1] = byte(m.Code)
+	binary.BigEndian.PutUint16(buf[2:], m.MessageID)
+	buf = append(buf, m.Token...)
+
+	options := make([]Option, len(m.Options))
+	copy(options, m.Options)
+	sort.SliceStable(options, func(i, j int) bool {
+		return options[i].ID < options[j].ID
+	})
+	var last OptionID
+	for _, opt := range options {
+		delta, deltaExt := encodeOptionNumber(int(opt.ID - last))
+		length, lengthExt := encodeOptionNumber(len(opt.Value))
+		buf = append(buf, byte(delta<<4|length))
+		buf = append(buf, deltaExt...)
+		buf = append(buf, lengthExt...)
+		buf = append(buf, opt.Value...)
+		last = opt.ID
+	}
+	if len(m.Payload) > 0 {
+		buf = append(buf, payloadMarker)
+		buf = append(buf, m.Payload...)
+	}
+	return buf, nil
+}
+
+// encodeOptionNumber 编码选项增量或者长度，返回4位值和扩展字节
+func encodeOptionNumber(n int) (int, []byte) {
+	switch {
+	case n < extendByteBase:
+		return n, nil
+	case n < extendWordBase:
+		return extendByte, []byte{byte(n - extendByteBase)}
+	default:
+		ext := make([]byte, 2)
+		binary.BigEndian.PutUint16(ext, uint16(n-extendWordBase))
+		return extendWord, ext
+	}
+}
+
+// decodeOptionNumber 解码选项增量或者长度，返回值和扩展字节数
+func decodeOptionNumber(n int, data []byte) (int, int, error) {
+	switch n {
+	case extendByte:
+		if len(data) < 1 {
+			return 0, 0, ErrInvalidMessage
+		}
+		return int(data[0]) + extendByteBase, 1, nil
+	case extendWord:
+		if len(data) < 2 {
+			return 0, 0, ErrInvalidMessage
+		}
+		return int(binary.BigEndian.Uint16(data)) + extendWordBase, 2, nil
+	case extendReserved:
+		return 0, 0, ErrInvalidMessage
+	default:
+		return n, 0, nil
+	}
+}
+
+// Unmarshal 解码消息
+func Unmarshal(data []byte) (*Message, error) {
+	if len(data) < 4 || data[0]>>6 != version {
+		return nil, ErrInvalidMessage
+	}
+	tokenLen := int(data[0] & 0x0f)
+	if tokenLen > maxTokenLen || len(data) < 4+tokenLen {
+		return nil, ErrInvalidMessage
+	}
+	m := &Message{
+		Type:      Type(data[0] >> 4 & 0x03),
+		Code:      Code(data[1]),
+		MessageID: binary.BigEndian.Uint16(data[2:]),
+	}
+	if tokenLen > 0 {
+		m.Token = append([]byte(nil), data[4:4+tokenLen]...)
+	}
+	data = data[4+tokenLen:]
+	var last int
+	for len(data) > 0 {
+		if data[0] == payloadMarker {
+			if len(data) == 1 {
+				return nil, ErrInvalidMessage
+			}
+			m.Payload = append([]byte(nil), data[1:]...)
+			break
+		}
+		delta, n, err := decodeOptionNumber(int(data[0]>>4), data[1:])
+		if err != nil {
+			return nil, err
+		}
+		length, n2, err := decodeOptionNumber(int(data[0]&0x0f), data[1+n:])
+		if err != nil {
+			return nil, err
+		}
+		data = data[1+n+n2:]
+		if len(data) < length {
+			return nil, ErrInvalidMessage
+		}
+		last += delta
+		m.Options = append(m.Options, Option{ID: OptionID(last), Value: append([]byte(nil), data[:length]...)})
+		data = data[length:]
+	}
+	return m, nil
+}
+
+// Option 返回第一个指定编号的选项值
+func (m *Message) Option(id OptionID) ([]byte, bool) {
+	for _, opt := range m.Options {
+		if opt.ID == id {
+			return opt.Value, true
+		}
+	}
+	return nil, false
+}
+
+// OptionStrings 返回所有指定编号的选项值
+func (m *Message) OptionStrings(id OptionID) []string {
+	var values []string
+	for _, opt := range m.Options {
+		if opt.ID == id {
+			values = append(values, string(opt.Value))
+		}
+	}
+	return values
+}
+
+// OptionUint 返回无符号整数选项值
+func (m *Message) OptionUint(id OptionID) (uint32, bool) {
+	v, ok := m.Option(id)
+	if !ok {
+		return 0, false
+	}
+	var n uint32
+	for _, b := range v {
+		n = n<<8 | uint32(b)
+	}
+	return n, true
+}
+
+// AddOption 添加选项
+func (m *Message) AddOption(id OptionID, value []byte) {
+	m.Options = append(m.Options, Option{ID: id, Value: value})
+}
+
+// SetOption 替换所有指定编号的选项
+func (m *Message) SetOption(id OptionID, value []byte) {
+	m.RemoveOption(id)
+	m.AddOption(id, value)
+}
+
+// SetOptionUint 以最短字节编码设置无符号整数选项，0编码为空值
+func (m *Message) SetOptionUint(id OptionID, n uint32) {
+	var value []byte
+	for ; n > 0; n >>= 8 {
+		value = append([]byte{byte(n)}, value...)
+	}
+	m.SetOption(id, value)
+}
+
+// RemoveOption 删除所有指定编号的选项
+func (m *Message) RemoveOption(id OptionID) {
+	options := m.Options[:0]
+	for _, opt := range m.Options {
+		if opt.ID != id {
+			options = append(options, opt)
+		}
+	}
+	m.Options = options
+}
+
+// Path 返回请求路径，例如 /sensors/1
+func (m *Message) Path() string {
+	return "/" + strings.Join(m.OptionStrings(URIPath), "/")
+}
+
+// SetPath 设置请求路径，路径中的 ?key=value 设置为查询参数
+func (m *Message) SetPath(path string) {
+	m.RemoveOption(URIPath)
+	m.RemoveOption(URIQuery)
+	path, query, _ := strings.Cut(path, "?")
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment != "" {
+			m.AddOption(URIPath, []byte(segment))
+		}
+	}
+	for _, item := range strings.Split(query, "&") {
+		if item != "" {
+			m.AddOption(URIQuery, []byte(item))
+		}
+	}
+}
+
+// Queries 返回查询参数
+func (m *Message) Queries() map[string]string {
+	queries := make(map[string]string)
+	for _, item := range m.OptionStrings(URIQuery) {
+		key, value, _ := strings.Cut(item, "=")
+		queries[key] = value
+	}
+	return queries
+}
+
+// ContentFormat 返回内容格式
+func (m *Message) ContentFormat() (MediaType, bool) {
+	v, ok := m.OptionUint(ContentFormat)
+	return MediaType(v), ok
+}
+
+// SetContentFormat 设置内容格式
+func (m *Message) SetContentFormat(mediaType MediaType) {
+	m.SetOptionUint(ContentFormat, uint32(mediaType))
+}
+
+// DataType 根据内容格式返回消息数据类型，没有内容格式时负荷为JSON对象或数组则为JSON，否则为文本
+func (m *Message) DataType() types.DataType {
+	if cf, ok := m.ContentFormat(); ok {
+		return DataTypeOf(cf)
+	}
+	if len(m.Payload) > 0 && (m.Payload[0] == '{' || m.Payload[0] == '[') {
+		return types.JSON
+	}
+	return types.TEXT
+}
+
+// Observe 返回 Observe 选项值
+func (m *Message) Observe() (uint32, bool) {
+	return m.OptionUint(Observe)
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coap
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestMessage(t *testing.T) {
+	t.Run("Marshal", func(t *testing.T) {
+		m := &Message{Type: Confirmable, Code: GET, MessageID: 0x1234, Token: []byte{0xa, 0xb}}
+		m.SetPath("/temp")
+		data, err := m.Marshal()
+		assert.Nil(t, err)
+		//RFC 7252 示例：版本1，CON，token长度2，GET，消息ID，token，Uri-Path "temp"
+		assert.Equal(t, []byte{0x42, 0x01, 0x12, 0x34, 0xa, 0xb, 0xb4, 't', 'e', 'm', 'p'}, data)
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		m := &Message{Type: NonConfirmable, Code: POST, MessageID: 7, Token: NewToken(), Payload: []byte("{\"a\":1}")}
+		m.SetPath("/devices/d1/telemetry?qos=1&tenant=t1")
+		m.SetContentFormat(AppJSON)
+		//扩展编码的选项增量和长度
+		m.AddOption(Size1, []byte{0x01, 0x00})
+		m.AddOption(ProxyURI, bytes.Repeat([]byte("x"), 300))
+		data, err := m.Marshal()
+		assert.Nil(t, err)
+
+		decoded, err := Unmarshal(data)
+		assert.Nil(t, err)
+		assert.Equal(t, m.Type, decoded.Type)
+		assert.Equal(t, m.Code, decoded.Code)
+		assert.Equal(t, m.MessageID, decoded.MessageID)
+		assert.Equal(t, m.Token, decoded.Token)
+		assert.Equal(t, m.Payload, decoded.Payload)
+		assert.Equal(t, "/devices/d1/telemetry", decoded.Path())
+		assert.Equal(t, map[string]string{"qos": "1", "tenant": "t1"}, decoded.Queries())
+		cf, ok := decoded.ContentFormat()
+		assert.True(t, ok)
+		assert.Equal(t, AppJSON, cf)
+		assert.Equal(t, types.JSON, decoded.DataType())
+		size, _ := decoded.OptionUint(Size1)
+		assert.Equal(t, uint32(256), size)
+		proxy, _ := decoded.Option(ProxyURI)
+		assert.Equal(t, 300, len(proxy))
+	})
+
+	t.Run("OptionUint", func(t *testing.T) {
+		m := &Message{}
+		m.SetOptionUint(Observe, 0)
+		v, ok := m.Option(Observe)
+		assert.True(t, ok)
+		assert.Equal(t, 0, len(v))
+		m.SetOptionUint(Observe, 0x10203)
+		n, _ := m.Observe()
+		assert.Equal(t, uint32(0x10203), n)
+		assert.Equal(t, 1, len(m.Options))
+		m.RemoveOption(Observe)
+		_, ok = m.Observe()
+		assert.False(t, ok)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := Unmarshal([]byte{0x40, 0x01})
+		assert.Equal(t, ErrInvalidMessage, err)
+		//版本错误
+		_, err = Unmarshal([]byte{0x80, 0x01, 0x00, 0x01})
+		assert.Equal(t, ErrInvalidMessage, err)
+		//token长度超过8
+		_, err = Unmarshal([]byte{0x49, 0x01, 0x00, 0x01})
+		assert.Equal(t, ErrInvalidMessage, err)
+		//负荷标记后没有负荷
+		_, err = Unmarshal([]byte{0x40, 0x01, 0x00, 0x01, 0xff})
+		assert.Equal(t, ErrInvalidMessage, err)
+		//选项长度超出数据
+		_, err = Unmarshal([]byte{0x40, 0x01, 0x00, 0x01, 0xb4, 't'})
+		assert.Equal(t, ErrInvalidMessage, err)
+		_, err = (&Message{Token: make([]byte, 9)}).Marshal()
+		assert.NotNil(t, err)
+	})
+
+	t.Run("DataType", func(t *testing.T) {
+		assert.Equal(t, types.TEXT, (&Message{Payload: []byte("23.5")}).DataType())
+		assert.Equal(t, types.JSON, (&Message{Payload: []byte("[1]")}).DataType())
+		m := &Message{Payload: []byte{0x01}}
+		m.SetContentFormat(AppCBOR)
+		assert.Equal(t, types.BINARY, m.DataType())
+		assert.Equal(t, AppJSON, MediaTypeOf(types.JSON))
+		assert.Equal(t, AppOctets, MediaTypeOf(types.BINARY))
+		assert.Equal(t, TextPlain, MediaTypeOf(types.TEXT))
+	})
+}
+
+func TestCode(t *testing.T) {
+	assert.Equal(t, "2.05", Content.String())
+	assert.Equal(t, "4.04", NotFound.String())
+	assert.Equal(t, "GET", GET.String())
+	assert.True(t, PUT.IsRequest())
+	assert.False(t, Content.IsRequest())
+	assert.False(t, Empty.IsRequest())
+	assert.True(t, Created.IsSuccess())
+	assert.False(t, BadRequest.IsSuccess())
+
+	assert.Equal(t, Content, CodeFromHTTP(200))
+	assert.Equal(t, Created, CodeFromHTTP(201))
+	assert.Equal(t, Changed, CodeFromHTTP(204))
+	assert.Equal(t, BadRequest, CodeFromHTTP(400))
+	assert.Equal(t, NotFound, CodeFromHTTP(404))
+	assert.Equal(t, GatewayTimeout, CodeFromHTTP(504))
+	assert.Equal(t, InternalServerError, CodeFromHTTP(302))
+	assert.Equal(t, InternalServerError, CodeFromHTTP(451))
+
+	method, err := ParseMethod("put")
+	assert.Nil(t, err)
+	assert.Equal(t, PUT, method)
+	_, err = ParseMethod("PATCH")
+	assert.True(t, strings.Contains(err.Error(), "PATCH"))
+
+	cf, ok := ParseMediaType("application/json; charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, AppJSON, cf)
+	cf, ok = ParseMediaType("11542")
+	assert.True(t, ok)
+	assert.Equal(t, "11542", cf.String())
+	_, ok = ParseMediaType("image/png")
+	assert.False(t, ok)
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coap
+
+import (
+	"sync"
+
+	"github.com/rulego/rulego/api/types"
+)
+
+// Notifier 向资源路径的观察者推送通知，由 coap 端点实现
+type Notifier interface {
+	// Notify 向路径的所有观察者发送通知，返回通知的观察者数量
+	Notify(path string, dataType types.DataType, data []byte) int
+}
+
+// notifiers 端点服务地址到通知者的映射
+var notifiers sync.Map
+
+// RegisterNotifier 以服务地址注册通知者，coap 端点启动时调用，
+// coapNotify 组件通过服务地址找到端点
+func RegisterNotifier(server string, n Notifier) {
+	notifiers.Store(server, n)
+}
+
+// UnregisterNotifier 取消注册通知者，服务地址已被其他通知者注册时不处理
+func UnregisterNotifier(server string, n Notifier) {
+	if v, ok := notifiers.Load(server); ok && v == n {
+		notifiers.Delete(server)
+	}
+}
+
+// GetNotifier 获取服务地址对应的通知者
+func GetNotifier(server string) (Notifier, bool) {
+	v, ok := notifiers.Load(server)
+	if !ok {
+		return nil, false
+	}
+	return v.(Notifier), true
+}
@@ -0,0 +1,339 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package connection provides registries of live client connections of the websocket and
+// net endpoints. Each endpoint has its own registry, looked up by its server address.
+// Connections are registered by client ID and tags once the router From processors accepted
+// them, so rule chains can push to a specific client, a tag group or all clients of an endpoint
+// with the endpointSend component.
+//
+// Package connection 提供 websocket 和 net 端点的在线客户端连接注册表，每个端点使用自己的注册表，
+// 通过端点服务地址查找。连接通过路由 From 处理器后按客户端ID和标签注册，
+// 规则链可以通过 endpointSend 组件向端点的指定客户端、标签分组或者所有客户端推送消息。
+package connection
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/utils/el"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// KeyClientId 连接注册的客户端ID写入的元数据键
+const KeyClientId = "clientId"
+
+// ErrClientNotFound 目标客户端不在线
+var ErrClientNotFound = errors.New("client not found")
+
+// registries 端点服务地址到注册表的映射
+var registries sync.Map
+
+// EndpointRegistry 返回服务地址对应端点的注册表，不存在时创建。
+// websocket 和 net 端点默认使用各自服务地址的注册表，不同端点的客户端ID互不冲突
+func EndpointRegistry(server string) *Registry {
+	if v, ok := registries.Load(server); ok {
+		return v.(*Registry)
+	}
+	v, _ := registries.LoadOrStore(server, NewRegistry())
+	return v.(*Registry)
+}
+
+// Conn 可推送的客户端连接，实现需要保证并发写安全
+type Conn interface {
+	// Send 发送数据，websocket 连接根据数据类型选择文本帧或者二进制帧
+	Send(dataType types.DataType, data []byte) error
+}
+
+// client 注册的客户端
+type client struct {
+	id   string
+	tags []string
+	conn Conn
+}
+
+// Registry 客户端连接注册表
+type Registry struct {
+	clients map[string]*client
+	conns   map[Conn]*client
+	tags    map[string]map[*client]struct{}
+	lock    sync.RWMutex
+}
+
+// NewRegistry 创建注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		clients: make(map[string]*client),
+		conns:   make(map[Conn]*client),
+		tags:    make(map[string]map[*client]struct{}),
+	}
+}
+
+// Register 以客户端ID和标签注册连接，已注册的连接更新ID和标签。
+// 其他连接已使用该ID时（例如设备重连），由新连接替代
+func (r *Registry) Register(conn Conn, id string, tags ...string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if c, ok := r.conns[conn]; ok {
+		if c.id == id && equalTags(c.tags, tags) {
+			return
+		}
+		r.remove(c)
+	}
+	if old, ok := r.clients[id]; ok {
+		r.remove(old)
+	}
+	c := &client{id: id, tags: tags, conn: conn}
+	r.clients[id] = c
+	r.conns[conn] = c
+	for _, tag := range tags {
+		group, ok := r.tags[tag]
+		if !ok {
+			group = make(map[*client]struct{})
+			r.tags[tag] = group
+		}
+		group[c] = struct{}{}
+	}
+}
+
+// Unregister 取消注册连接，连接断开时调用
+func (r *Registry) Unregister(conn Conn) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if c, ok := r.conns[conn]; ok {
+		r.remove(c)
+	}
+}
+
+// remove 删除客户端，调用方需持有锁
+func (r *Registry) remove(c *client) {
+	delete(r.conns, c.conn)
+	if r.clients[c.id] == c {
+		delete(r.clients, c.id)
+	}
+	for _, tag := range c.tags {
+		if group, ok := r.tags[tag]; ok {
+			delete(group, c)
+			if len(group) == 0 {
+				delete(r.tags, tag)
+			}
+		}
+	}
+}
+
+// Get 获取客户端连接
+func (r *Registry) Get(id string) (Conn, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if c, ok := r.clients[id]; ok {
+		return c.conn, true
+	}
+	return nil, false
+}
+
+// Clients 返回在线客户端ID，tag 不为空则只返回该标签分组的客户端
+func (r *Registry) Clients(tag string) []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	var ids []string
+	if tag == "" {
+		for id := range r.clients {
+			ids = append(ids, id)
+		}
+	} else {
+		for c := range r.tags[tag] {
+			ids = append(ids, c.id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Send 向指定客户端发送数据，客户端不在线返回 ErrClientNotFound
+func (r *Registry) Send(id string, dataType types.DataType, data []byte) error {
+	conn, ok := r.Get(id)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrClientNotFound, id)
+	}
+	return conn.Send(dataType, data)
+}
+
+// SendToTag 向标签分组的所有客户端发送数据，返回发送成功的客户端数量。
+// 分组没有在线客户端返回 ErrClientNotFound，全部发送失败返回最后一个错误
+func (r *Registry) SendToTag(tag string, dataType types.DataType, data []byte) (int, error) {
+	r.lock.RLock()
+	conns := make([]Conn, 0, len(r.tags[tag]))
+	for c := range r.tags[tag] {
+		conns = append(conns, c.conn)
+	}
+	r.lock.RUnlock()
+	if len(conns) == 0 {
+		return 0, fmt.Errorf("%w: tag %s", ErrClientNotFound, tag)
+	}
+	return sendAll(conns, dataType, data)
+}
+
+// Broadcast 向所有客户端发送数据，返回发送成功的客户端数量。
+// 没有在线客户端返回 ErrClientNotFound，全部发送失败返回最后一个错误
+func (r *Registry) Broadcast(dataType types.DataType, data []byte) (int, error) {
+	r.lock.RLock()
+	conns := make([]Conn, 0, len(r.clients))
+	for _, c := range r.clients {
+		conns = append(conns, c.conn)
+	}
+	r.lock.RUnlock()
+	if len(conns) == 0 {
+		return 0, ErrClientNotFound
+	}
+	return sendAll(conns, dataType, data)
+}
+
+// sendAll 在锁外逐个发送，避免慢连接阻塞注册表
+func sendAll(conns []Conn, dataType types.DataType, data []byte) (int, error) {
+	var count int
+	var lastErr error
+	for _, conn := range conns {
+		if err := conn.Send(dataType, data); err != nil {
+			lastErr = err
+		} else {
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, lastErr
+	}
+	return count, nil
+}
+
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Identity 路由的客户端ID和标签模板，从 From 配置的 clientId 和 clientTags 解析
+type Identity struct {
+	id   *el.MixedTemplate
+	tags []*el.MixedTemplate
+}
+
+// NewIdentity 从 From 配置解析客户端ID和标签模板，没有配置返回nil
+func NewIdentity(config types.Configuration) (*Identity, error) {
+	idValue := strings.TrimSpace(str.ToString(config[endpoint.FromConfigKeyClientId]))
+	tagsValue := config[endpoint.FromConfigKeyClientTags]
+	if idValue == "" && tagsValue == nil {
+		return nil, nil
+	}
+	identity := &Identity{}
+	if idValue != "" {
+		tmpl, err := el.NewMixedTemplate(idValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s configuration: %w", endpoint.FromConfigKeyClientId, err)
+		}
+		identity.id = tmpl
+	}
+	var tags []string
+	switch v := tagsValue.(type) {
+	case nil:
+	case string:
+		tags = strings.Split(v, ",")
+	default:
+		if err := maps.Map2Struct(v, &tags); err != nil {
+			return nil, fmt.Errorf("invalid %s configuration: %w", endpoint.FromConfigKeyClientTags, err)
+		}
+	}
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag == "" {
+			continue
+		}
+		tmpl, err := el.NewMixedTemplate(tag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s configuration: %w", endpoint.FromConfigKeyClientTags, err)
+		}
+		identity.tags = append(identity.tags, tmpl)
+	}
+	return identity, nil
+}
+
+// OnAccepted 包装路由，路由的 From 处理器全部通过后调用 onAccept，然后再执行 To。
+// 端点在 onAccept 中注册连接，未通过认证等处理器的连接不会注册，也不能替代其他连接的客户端ID
+func OnAccepted(router endpoint.Router, onAccept func(exchange *endpoint.Exchange)) endpoint.Router {
+	return &acceptedRouter{Router: router, onAccept: onAccept}
+}
+
+// acceptedRouter 在 From 处理器通过后回调的路由
+type acceptedRouter struct {
+	endpoint.Router
+	onAccept func(exchange *endpoint.Exchange)
+}
+
+func (r *acceptedRouter) GetFrom() endpoint.From {
+	from := r.Router.GetFrom()
+	if from == nil {
+		return nil
+	}
+	return &acceptedFrom{From: from, router: r.Router, onAccept: r.onAccept}
+}
+
+// acceptedFrom 在处理器通过后回调的 From
+type acceptedFrom struct {
+	endpoint.From
+	// router 原始路由，处理器通过它读取 From 配置
+	router   endpoint.Router
+	onAccept func(exchange *endpoint.Exchange)
+}
+
+func (f *acceptedFrom) ExecuteProcess(_ endpoint.Router, exchange *endpoint.Exchange) bool {
+	if !f.From.ExecuteProcess(f.router, exchange) {
+		return false
+	}
+	f.onAccept(exchange)
+	return true
+}
+
+// Resolve 使用元数据计算客户端ID和标签，ID为空表示使用默认ID，结果为空的标签被忽略
+func (i *Identity) Resolve(metadata map[string]string) (string, []string) {
+	if i == nil {
+		return "", nil
+	}
+	env := make(map[string]any, len(metadata))
+	for k, v := range metadata {
+		env[k] = v
+	}
+	var id string
+	if i.id != nil {
+		id = i.id.ExecuteAsString(env)
+	}
+	var tags []string
+	for _, tmpl := range i.tags {
+		if tag := tmpl.ExecuteAsString(env); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return id, tags
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package connection
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/test/assert"
+)
+
+// testConn 记录发送数据的测试连接
+type testConn struct {
+	data []string
+	err  error
+	lock sync.Mutex
+}
+
+func (c *testConn) Send(dataType types.DataType, data []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.data = append(c.data, string(data))
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	t.Run("Send", func(t *testing.T) {
+		registry := NewRegistry()
+		c1, c2 := &testConn{}, &testConn{}
+		registry.Register(c1, "d1", "tenant/t1")
+		registry.Register(c2, "d2", "tenant/t1", "tenant/t2")
+		assert.Equal(t, []string{"d1", "d2"}, registry.Clients(""))
+		assert.Equal(t, []string{"d1", "d2"}, registry.Clients("tenant/t1"))
+		assert.Equal(t, []string{"d2"}, registry.Clients("tenant/t2"))
+
+		assert.Nil(t, registry.Send("d1", types.TEXT, []byte("a")))
+		count, err := registry.SendToTag("tenant/t2", types.TEXT, []byte("b"))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+		count, err = registry.Broadcast(types.TEXT, []byte("c"))
+		assert.Nil(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, []string{"a", "c"}, c1.data)
+		assert.Equal(t, []string{"b", "c"}, c2.data)
+
+		err = registry.Send("d3", types.TEXT, []byte("a"))
+		assert.True(t, errors.Is(err, ErrClientNotFound))
+		_, err = registry.SendToTag("tenant/t3", types.TEXT, []byte("a"))
+		assert.True(t, errors.Is(err, ErrClientNotFound))
+	})
+
+	t.Run("Reregister", func(t *testing.T) {
+		registry := NewRegistry()
+		c1, c2 := &testConn{}, &testConn{}
+		registry.Register(c1, "127.0.0.1:5000")
+		//连接更新ID和标签
+		registry.Register(c1, "d1", "g1")
+		_, ok := registry.Get("127.0.0.1:5000")
+		assert.False(t, ok)
+		assert.Equal(t, []string{"d1"}, registry.Clients("g1"))
+
+		//设备重连，新连接替代旧连接
+		registry.Register(c2, "d1")
+		conn, ok := registry.Get("d1")
+		assert.True(t, ok)
+		assert.True(t, conn == Conn(c2))
+		assert.Equal(t, 0, len(registry.Clients("g1")))
+
+		//旧连接断开不影响新连接
+		registry.Unregister(c1)
+		_, ok = registry.Get("d1")
+		assert.True(t, ok)
+		registry.Unregister(c2)
+		_, ok = registry.Get("d1")
+		assert.False(t, ok)
+		_, err := registry.Broadcast(types.TEXT, []byte("a"))
+		assert.True(t, errors.Is(err, ErrClientNotFound))
+	})
+
+	t.Run("SendError", func(t *testing.T) {
+		registry := NewRegistry()
+		c1, c2 := &testConn{err: errors.New("broken pipe")}, &testConn{}
+		registry.Register(c1, "d1", "g1")
+		registry.Register(c2, "d2", "g1")
+		count, err := registry.SendToTag("g1", types.TEXT, []byte("a"))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+		registry.Unregister(c2)
+		_, err = registry.SendToTag("g1", types.TEXT, []byte("a"))
+		assert.Equal(t, "broken pipe", err.Error())
+	})
+
+	t.Run("EndpointRegistry", func(t *testing.T) {
+		//每个端点使用自己的注册表，客户端ID互不冲突
+		ws, tcp := EndpointRegistry(":16334"), EndpointRegistry(":16335")
+		assert.True(t, ws == EndpointRegistry(":16334"))
+		assert.True(t, ws != tcp)
+		c1, c2 := &testConn{}, &testConn{}
+		ws.Register(c1, "d1")
+		tcp.Register(c2, "d1")
+		count, err := ws.Broadcast(types.TEXT, []byte("a"))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, []string{"a"}, c1.data)
+		assert.Equal(t, 0, len(c2.data))
+	})
+}
+
+// testRouter 只实现 GetFrom 的测试路由
+type testRouter struct {
+	endpoint.Router
+	from endpoint.From
+}
+
+func (r *testRouter) GetFrom() endpoint.From {
+	return r.from
+}
+
+// testFrom 带配置和单个处理器的测试 From
+type testFrom struct {
+	endpoint.From
+	config  types.Configuration
+	process endpoint.Process
+}
+
+func (f *testFrom) GetConfiguration() types.Configuration {
+	return f.config
+}
+
+func (f *testFrom) ExecuteProcess(router endpoint.Router, exchange *endpoint.Exchange) bool {
+	return f.process(router, exchange)
+}
+
+func TestOnAccepted(t *testing.T) {
+	var accepted int
+	var configured bool
+	allow := true
+	router := &testRouter{}
+	router.from = &testFrom{config: types.Configuration{"token": "secret"}, process: func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		//处理器通过原始路由读取 From 配置
+		from, ok := router.GetFrom().(interface{ GetConfiguration() types.Configuration })
+		configured = ok && from.GetConfiguration()["token"] == "secret"
+		return allow
+	}}
+	wrapped := OnAccepted(router, func(exchange *endpoint.Exchange) {
+		accepted++
+	})
+
+	assert.True(t, wrapped.GetFrom().ExecuteProcess(wrapped, &endpoint.Exchange{}))
+	assert.True(t, configured)
+	assert.Equal(t, 1, accepted)
+
+	//处理器拒绝时不回调
+	allow = false
+	assert.False(t, wrapped.GetFrom().ExecuteProcess(wrapped, &endpoint.Exchange{}))
+	assert.Equal(t, 1, accepted)
+}
+
+func TestIdentity(t *testing.T) {
+	identity, err := NewIdentity(types.Configuration{})
+	assert.Nil(t, err)
+	assert.Nil(t, identity)
+	id, tags := identity.Resolve(map[string]string{"deviceId": "d1"})
+	assert.Equal(t, "", id)
+	assert.Equal(t, 0, len(tags))
+
+	identity, err = NewIdentity(types.Configuration{
+		endpoint.FromConfigKeyClientId:   "${deviceId}",
+		endpoint.FromConfigKeyClientTags: []interface{}{"tenant/${tenant}", "${group}"},
+	})
+	assert.Nil(t, err)
+	id, tags = identity.Resolve(map[string]string{"deviceId": "d1", "tenant": "t1"})
+	assert.Equal(t, "d1", id)
+	assert.Equal(t, []string{"tenant/t1"}, tags)
+
+	//变量不存在使用默认ID
+	id, _ = identity.Resolve(map[string]string{})
+	assert.Equal(t, "", id)
+
+	identity, err = NewIdentity(types.Configuration{
+		endpoint.FromConfigKeyClientTags: "all, tenant/${tenant}",
+	})
+	assert.Nil(t, err)
+	_, tags = identity.Resolve(map[string]string{"tenant": "t1"})
+	assert.Equal(t, []string{"all", "tenant/t1"}, tags)
+}
@@ -55,6 +55,19 @@ func Unmarshal(b []byte, m interface{}) error {
 	return json.Unmarshal(b, m)
 }
 
+// Number is the type numbers are decoded into by UnmarshalUseNumber.
+type Number = json.Number
+
+// RawMessage is a raw encoded JSON value.
+type RawMessage = json.RawMessage
+
+// UnmarshalUseNumber unmarshals json data, decoding numbers into Number instead of float64.
+func UnmarshalUseNumber(b []byte, m interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	return decoder.Decode(m)
+}
+
 // Format json格式化
 func Format(jsonStr []byte) ([]byte, error) {
 	var buf bytes.Buffer
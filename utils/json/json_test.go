@@ -112,6 +112,20 @@ func TestUnMarshal(t *testing.T) {
 	assert.Equal(t, user1.Username, user2.Username)
 }
 
+func TestUnmarshalUseNumber(t *testing.T) {
+	var m map[string]interface{}
+	err := UnmarshalUseNumber([]byte(`{"a":9007199254740993,"b":1.5,"c":"x"}`), &m)
+	assert.Nil(t, err)
+	a, ok := m["a"].(Number)
+	assert.True(t, ok)
+	i, _ := a.Int64()
+	assert.Equal(t, int64(9007199254740993), i)
+	assert.Equal(t, Number("1.5"), m["b"])
+	assert.Equal(t, "x", m["c"])
+
+	assert.NotNil(t, UnmarshalUseNumber([]byte(`{invalid}`), &m))
+}
+
 func TestFormat(t *testing.T) {
 	var user = User{
 		Username: "test",
@@ -0,0 +1,281 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// headerSize MBAP 报文头长度：事务ID、协议ID、长度、单元ID
+	headerSize = 7
+	// maxPduSize 最大PDU长度
+	maxPduSize = 253
+)
+
+// ErrClientClosed 客户端已关闭
+var ErrClientClosed = errors.New("modbus client closed")
+
+// Client Modbus TCP 客户端，同一时间只有一个请求在执行。
+// 连接出错后关闭，下一个请求时重新连接
+type Client struct {
+	address       string
+	timeout       time.Duration
+	conn          net.Conn
+	transactionId uint16
+	closed        bool
+	mu            sync.Mutex
+}
+
+// Dial 连接 Modbus TCP 服务端，timeout 为连接和每个请求的超时时间
+func Dial(address string, timeout time.Duration) (*Client, error) {
+	c := &Client{address: address, timeout: timeout}
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	return c, nil
+}
+
+// Close 关闭客户端
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.conn != nil {
+		err := c.conn.Close()
+		c.conn = nil
+		return err
+	}
+	return nil
+}
+
+// ReadCoils 读线圈
+func (c *Client) ReadCoils(unitId byte, address, quantity uint16) ([]bool, error) {
+	return c.readBits(unitId, ReadCoils, address, quantity)
+}
+
+// ReadDiscreteInputs 读离散输入
+func (c *Client) ReadDiscreteInputs(unitId byte, address, quantity uint16) ([]bool, error) {
+	return c.readBits(unitId, ReadDiscreteInputs, address, quantity)
+}
+
+// ReadHoldingRegisters 读保持寄存器
+func (c *Client) ReadHoldingRegisters(unitId byte, address, quantity uint16) ([]uint16, error) {
+	return c.readRegisters(unitId, ReadHoldingRegisters, address, quantity)
+}
+
+// ReadInputRegisters 读输入寄存器
+func (c *Client) ReadInputRegisters(unitId byte, address, quantity uint16) ([]uint16, error) {
+	return c.readRegisters(unitId, ReadInputRegisters, address, quantity)
+}
+
+// WriteSingleCoil 写单个线圈
+func (c *Client) WriteSingleCoil(unitId byte, address uint16, value bool) error {
+	var v uint16
+	if value {
+		v = 0xFF00
+	}
+	data, err := c.send(unitId, WriteSingleCoil, uint16s(address, v))
+	if err != nil {
+		return err
+	}
+	if len(data) != 4 {
+		return ErrInvalidResponse
+	}
+	return nil
+}
+
+// WriteSingleRegister 写单个保持寄存器
+func (c *Client) WriteSingleRegister(unitId byte, address uint16, value uint16) error {
+	data, err := c.send(unitId, WriteSingleRegister, uint16s(address, value))
+	if err != nil {
+		return err
+	}
+	if len(data) != 4 {
+		return ErrInvalidResponse
+	}
+	return nil
+}
+
+// WriteMultipleCoils 写多个线圈
+func (c *Client) WriteMultipleCoils(unitId byte, address uint16, values []bool) error {
+	if len(values) == 0 || len(values) > maxWriteBits {
+		return fmt.Errorf("invalid coil quantity: %d", len(values))
+	}
+	bits := packBits(values)
+	req := append(uint16s(address, uint16(len(values))), byte(len(bits)))
+	return c.writeMultiple(unitId, WriteMultipleCoils, append(req, bits...))
+}
+
+// WriteMultipleRegisters 写多个保持寄存器
+func (c *Client) WriteMultipleRegisters(unitId byte, address uint16, values []uint16) error {
+	if len(values) == 0 || len(values) > maxWriteRegisters {
+		return fmt.Errorf("invalid register quantity: %d", len(values))
+	}
+	req := append(uint16s(address, uint16(len(values))), byte(len(values)*2))
+	return c.writeMultiple(unitId, WriteMultipleRegisters, append(req, uint16s(values...)...))
+}
+
+func (c *Client) writeMultiple(unitId byte, function Function, req []byte) error {
+	data, err := c.send(unitId, function, req)
+	if err != nil {
+		return err
+	}
+	if len(data) != 4 {
+		return ErrInvalidResponse
+	}
+	return nil
+}
+
+func (c *Client) readBits(unitId byte, function Function, address, quantity uint16) ([]bool, error) {
+	if quantity == 0 || quantity > maxReadBits {
+		return nil, fmt.Errorf("invalid bit quantity: %d", quantity)
+	}
+	data, err := c.send(unitId, function, uint16s(address, quantity))
+	if err != nil {
+		return nil, err
+	}
+	byteCount := (int(quantity) + 7) / 8
+	if len(data) != byteCount+1 || int(data[0]) != byteCount {
+		return nil, ErrInvalidResponse
+	}
+	return unpackBits(data[1:], int(quantity)), nil
+}
+
+func (c *Client) readRegisters(unitId byte, function Function, address, quantity uint16) ([]uint16, error) {
+	if quantity == 0 || quantity > maxReadRegisters {
+		return nil, fmt.Errorf("invalid register quantity: %d", quantity)
+	}
+	data, err := c.send(unitId, function, uint16s(address, quantity))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != int(quantity)*2+1 || int(data[0]) != int(quantity)*2 {
+		return nil, ErrInvalidResponse
+	}
+	regs := make([]uint16, quantity)
+	for i := range regs {
+		regs[i] = binary.BigEndian.Uint16(data[1+i*2:])
+	}
+	return regs, nil
+}
+
+// send 发送请求并返回响应中功能码之后的数据，异常响应返回 *Exception
+func (c *Client) send(unitId byte, function Function, data []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, ErrClientClosed
+	}
+	if c.conn == nil {
+		conn, err := net.DialTimeout("tcp", c.address, c.timeout)
+		if err != nil {
+			return nil, err
+		}
+		c.conn = conn
+	}
+	c.transactionId++
+	frame := make([]byte, headerSize+1, headerSize+1+len(data))
+	binary.BigEndian.PutUint16(frame[0:], c.transactionId)
+	binary.BigEndian.PutUint16(frame[4:], uint16(len(data)+2))
+	frame[6] = unitId
+	frame[7] = byte(function)
+	frame = append(frame, data...)
+
+	resp, err := c.roundTrip(frame)
+	if err != nil {
+		//连接出错或者超时后响应可能错位，关闭连接
+		_ = c.conn.Close()
+		c.conn = nil
+		return nil, err
+	}
+	if Function(resp[0]) == function|exceptionFlag {
+		if len(resp) != 2 {
+			return nil, ErrInvalidResponse
+		}
+		return nil, &Exception{Function: function, Code: ExceptionCode(resp[1])}
+	}
+	if Function(resp[0]) != function {
+		return nil, ErrInvalidResponse
+	}
+	return resp[1:], nil
+}
+
+// roundTrip 发送报文并读取相同事务ID的响应PDU
+func (c *Client) roundTrip(frame []byte) ([]byte, error) {
+	if c.timeout > 0 {
+		_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		return nil, err
+	}
+	header := make([]byte, headerSize)
+	for {
+		if _, err := io.ReadFull(c.conn, header); err != nil {
+			return nil, err
+		}
+		length := int(binary.BigEndian.Uint16(header[4:]))
+		if length < 2 || length > maxPduSize+1 {
+			return nil, ErrInvalidResponse
+		}
+		pdu := make([]byte, length-1)
+		if _, err := io.ReadFull(c.conn, pdu); err != nil {
+			return nil, err
+		}
+		//忽略事务ID不匹配的响应
+		if binary.BigEndian.Uint16(header[0:]) == binary.BigEndian.Uint16(frame[0:]) {
+			return pdu, nil
+		}
+	}
+}
+
+// uint16s 把值按大端编码
+func uint16s(values ...uint16) []byte {
+	data := make([]byte, len(values)*2)
+	for i, v := range values {
+		binary.BigEndian.PutUint16(data[i*2:], v)
+	}
+	return data
+}
+
+// packBits 把位打包成字节，低位在前
+func packBits(values []bool) []byte {
+	data := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			data[i/8] |= 1 << (i % 8)
+		}
+	}
+	return data
+}
+
+// unpackBits 从字节解包指定数量的位
+func unpackBits(data []byte, quantity int) []bool {
+	values := make([]bool, quantity)
+	for i := range values {
+		values[i] = data[i/8]&(1<<(i%8)) != 0
+	}
+	return values
+}
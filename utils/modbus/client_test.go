@@ -0,0 +1,114 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/test/modbustest"
+)
+
+func TestClient(t *testing.T) {
+	server := modbustest.NewServer()
+	assert.Nil(t, server.Start("127.0.0.1:0"))
+	defer server.Close()
+	server.SetCoils(10, true, false, true)
+	server.SetDiscreteInputs(0, false, true)
+	server.SetHoldingRegisters(100, 1, 2, 3)
+	server.SetInputRegisters(200, 0x4148, 0)
+
+	client, err := Dial(server.Addr().String(), time.Second)
+	assert.Nil(t, err)
+	defer client.Close()
+
+	t.Run("Read", func(t *testing.T) {
+		coils, err := client.ReadCoils(1, 10, 3)
+		assert.Nil(t, err)
+		assert.Equal(t, []bool{true, false, true}, coils)
+		inputs, err := client.ReadDiscreteInputs(1, 0, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, []bool{false, true}, inputs)
+		regs, err := client.ReadHoldingRegisters(1, 100, 3)
+		assert.Nil(t, err)
+		assert.Equal(t, []uint16{1, 2, 3}, regs)
+		regs, err = client.ReadInputRegisters(1, 200, 2)
+		assert.Nil(t, err)
+		assert.Equal(t, []uint16{0x4148, 0}, regs)
+	})
+
+	t.Run("Write", func(t *testing.T) {
+		assert.Nil(t, client.WriteSingleCoil(1, 20, true))
+		assert.Nil(t, client.WriteMultipleCoils(1, 21, []bool{true, false, true, true, false, false, false, false, true}))
+		assert.Equal(t, []bool{true, true, false, true, true, false, false, false, false, true}, server.Coils(20, 10))
+		assert.Nil(t, client.WriteSingleRegister(1, 300, 7))
+		assert.Nil(t, client.WriteMultipleRegisters(1, 301, []uint16{8, 9}))
+		assert.Equal(t, []uint16{7, 8, 9}, server.HoldingRegisters(300, 3))
+	})
+
+	t.Run("Exception", func(t *testing.T) {
+		_, err := client.ReadHoldingRegisters(1, 65535, 2)
+		assert.Equal(t, &Exception{Function: ReadHoldingRegisters, Code: IllegalDataAddress}, err)
+		_, err = client.ReadHoldingRegisters(1, 0, 200)
+		assert.Equal(t, "invalid register quantity: 200", err.Error())
+		//异常响应后连接仍然可用
+		regs, err := client.ReadHoldingRegisters(1, 100, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, []uint16{1}, regs)
+	})
+
+	t.Run("Reconnect", func(t *testing.T) {
+		//服务端关闭连接后，下一个请求重新连接
+		server.CloseConns()
+		_, err := client.ReadHoldingRegisters(1, 100, 1)
+		assert.NotNil(t, err)
+		regs, err := client.ReadHoldingRegisters(1, 100, 1)
+		assert.Nil(t, err)
+		assert.Equal(t, []uint16{1}, regs)
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		assert.Nil(t, client.Close())
+		_, err := client.ReadCoils(1, 0, 1)
+		assert.Equal(t, ErrClientClosed, err)
+	})
+}
+
+func TestClientTimeout(t *testing.T) {
+	//只接受连接不响应的服务端
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	client, err := Dial(listener.Addr().String(), time.Millisecond*200)
+	assert.Nil(t, err)
+	defer client.Close()
+	_, err = client.ReadCoils(1, 0, 1)
+	netErr, ok := err.(net.Error)
+	assert.True(t, ok)
+	assert.True(t, netErr.Timeout())
+}
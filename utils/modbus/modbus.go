@@ -0,0 +1,319 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package modbus provides a Modbus TCP client and register data codecs.
+// It is used by the modbusClient component and the modbus polling endpoint.
+//
+// Package modbus 提供 Modbus TCP 客户端和寄存器数据编解码。
+// 供 modbusClient 组件和 modbus 轮询端点使用。
+package modbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/rulego/rulego/utils/cast"
+)
+
+// Function 功能码
+type Function byte
+
+const (
+	ReadCoils              Function = 1
+	ReadDiscreteInputs     Function = 2
+	ReadHoldingRegisters   Function = 3
+	ReadInputRegisters     Function = 4
+	WriteSingleCoil        Function = 5
+	WriteSingleRegister    Function = 6
+	WriteMultipleCoils     Function = 15
+	WriteMultipleRegisters Function = 16
+)
+
+// exceptionFlag 异常响应的功能码最高位为1
+const exceptionFlag = 0x80
+
+const (
+	// maxReadBits 一次最多读取的线圈或离散输入数量
+	maxReadBits = 2000
+	// maxReadRegisters 一次最多读取的寄存器数量
+	maxReadRegisters = 125
+	// maxWriteBits 一次最多写入的线圈数量
+	maxWriteBits = 1968
+	// maxWriteRegisters 一次最多写入的寄存器数量
+	maxWriteRegisters = 123
+)
+
+var functionNames = map[Function]string{
+	ReadCoils:              "readCoils",
+	ReadDiscreteInputs:     "readDiscreteInputs",
+	ReadHoldingRegisters:   "readHoldingRegisters",
+	ReadInputRegisters:     "readInputRegisters",
+	WriteSingleCoil:        "writeSingleCoil",
+	WriteSingleRegister:    "writeSingleRegister",
+	WriteMultipleCoils:     "writeMultipleCoils",
+	WriteMultipleRegisters: "writeMultipleRegisters",
+}
+
+func (f Function) String() string {
+	if name, ok := functionNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("function(%d)", byte(f))
+}
+
+// IsRead 是否读功能码
+func (f Function) IsRead() bool {
+	return f >= ReadCoils && f <= ReadInputRegisters
+}
+
+// IsBit 是否操作线圈或者离散输入
+func (f Function) IsBit() bool {
+	return f == ReadCoils || f == ReadDiscreteInputs || f == WriteSingleCoil || f == WriteMultipleCoils
+}
+
+// ParseFunction 按名称解析功能码，例如 readHoldingRegisters，不区分大小写
+func ParseFunction(name string) (Function, error) {
+	for f, n := range functionNames {
+		if strings.EqualFold(n, name) {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported modbus function: %s", name)
+}
+
+// ExceptionCode 异常码
+type ExceptionCode byte
+
+const (
+	IllegalFunction     ExceptionCode = 1
+	IllegalDataAddress  ExceptionCode = 2
+	IllegalDataValue    ExceptionCode = 3
+	ServerDeviceFailure ExceptionCode = 4
+	Acknowledge         ExceptionCode = 5
+	ServerDeviceBusy    ExceptionCode = 6
+	GatewayPathFailed   ExceptionCode = 10
+	GatewayTargetFailed ExceptionCode = 11
+)
+
+var exceptionNames = map[ExceptionCode]string{
+	IllegalFunction:     "illegal function",
+	IllegalDataAddress:  "illegal data address",
+	IllegalDataValue:    "illegal data value",
+	ServerDeviceFailure: "server device failure",
+	Acknowledge:         "acknowledge",
+	ServerDeviceBusy:    "server device busy",
+	GatewayPathFailed:   "gateway path unavailable",
+	GatewayTargetFailed: "gateway target device failed to respond",
+}
+
+// Exception 服务端返回的异常响应
+type Exception struct {
+	Function Function
+	Code     ExceptionCode
+}
+
+func (e *Exception) Error() string {
+	name, ok := exceptionNames[e.Code]
+	if !ok {
+		name = "unknown exception"
+	}
+	return fmt.Sprintf("modbus exception %d (%s) for %s", e.Code, name, e.Function)
+}
+
+// ErrInvalidResponse 响应格式错误
+var ErrInvalidResponse = errors.New("invalid modbus response")
+
+// 寄存器数据类型
+const (
+	Int16   = "int16"
+	Uint16  = "uint16"
+	Int32   = "int32"
+	Uint32  = "uint32"
+	Float32 = "float32"
+	Int64   = "int64"
+	Uint64  = "uint64"
+	Float64 = "float64"
+)
+
+// 字节序和字序
+const (
+	// BigEndian 高位在前，Modbus 默认
+	BigEndian = "big"
+	// LittleEndian 低位在前
+	LittleEndian = "little"
+)
+
+// Codec 寄存器数据编解码器。ByteOrder 是每个寄存器内两个字节的顺序，
+// WordOrder 是多寄存器数据类型中寄存器的顺序，例如 float32 的 CDAB 顺序为 ByteOrder=big、WordOrder=little
+type Codec struct {
+	// DataType 数据类型，默认 uint16
+	DataType string
+	// ByteOrder 字节序：big 或 little，默认 big
+	ByteOrder string
+	// WordOrder 字序：big 或 little，默认 big
+	WordOrder string
+}
+
+// NewCodec 创建并校验编解码器，空值使用默认值
+func NewCodec(dataType, byteOrder, wordOrder string) (Codec, error) {
+	c := Codec{
+		DataType:  strings.ToLower(strings.TrimSpace(dataType)),
+		ByteOrder: strings.ToLower(strings.TrimSpace(byteOrder)),
+		WordOrder: strings.ToLower(strings.TrimSpace(wordOrder)),
+	}
+	if c.DataType == "" {
+		c.DataType = Uint16
+	}
+	if c.ByteOrder == "" {
+		c.ByteOrder = BigEndian
+	}
+	if c.WordOrder == "" {
+		c.WordOrder = BigEndian
+	}
+	if c.Registers() == 0 {
+		return c, fmt.Errorf("unsupported data type: %s", dataType)
+	}
+	if c.ByteOrder != BigEndian && c.ByteOrder != LittleEndian {
+		return c, fmt.Errorf("unsupported byte order: %s", byteOrder)
+	}
+	if c.WordOrder != BigEndian && c.WordOrder != LittleEndian {
+		return c, fmt.Errorf("unsupported word order: %s", wordOrder)
+	}
+	return c, nil
+}
+
+// Registers 每个值占用的寄存器数量，不支持的数据类型返回0
+func (c Codec) Registers() int {
+	switch c.DataType {
+	case Int16, Uint16:
+		return 1
+	case Int32, Uint32, Float32:
+		return 2
+	case Int64, Uint64, Float64:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// toBytes 按字序和字节序把寄存器转换成大端字节
+func (c Codec) toBytes(regs []uint16) []byte {
+	data := make([]byte, len(regs)*2)
+	for i, reg := range regs {
+		j := i
+		if c.WordOrder == LittleEndian {
+			j = len(regs) - 1 - i
+		}
+		if c.ByteOrder == LittleEndian {
+			reg = reg<<8 | reg>>8
+		}
+		binary.BigEndian.PutUint16(data[j*2:], reg)
+	}
+	return data
+}
+
+// fromBytes 把大端字节按字序和字节序转换成寄存器
+func (c Codec) fromBytes(data []byte) []uint16 {
+	regs := make([]uint16, len(data)/2)
+	for i := range regs {
+		j := i
+		if c.WordOrder == LittleEndian {
+			j = len(regs) - 1 - i
+		}
+		reg := binary.BigEndian.Uint16(data[j*2:])
+		if c.ByteOrder == LittleEndian {
+			reg = reg<<8 | reg>>8
+		}
+		regs[i] = reg
+	}
+	return regs
+}
+
+// Decode 把寄存器解码成值，寄存器数量必须是 Registers() 的整数倍
+func (c Codec) Decode(regs []uint16) ([]interface{}, error) {
+	n := c.Registers()
+	if n == 0 {
+		return nil, fmt.Errorf("unsupported data type: %s", c.DataType)
+	}
+	if len(regs)%n != 0 {
+		return nil, fmt.Errorf("%d registers can not be decoded as %s", len(regs), c.DataType)
+	}
+	values := make([]interface{}, 0, len(regs)/n)
+	for i := 0; i < len(regs); i += n {
+		data := c.toBytes(regs[i : i+n])
+		switch c.DataType {
+		case Int16:
+			values = append(values, int16(binary.BigEndian.Uint16(data)))
+		case Uint16:
+			values = append(values, binary.BigEndian.Uint16(data))
+		case Int32:
+			values = append(values, int32(binary.BigEndian.Uint32(data)))
+		case Uint32:
+			values = append(values, binary.BigEndian.Uint32(data))
+		case Float32:
+			values = append(values, math.Float32frombits(binary.BigEndian.Uint32(data)))
+		case Int64:
+			values = append(values, int64(binary.BigEndian.Uint64(data)))
+		case Uint64:
+			values = append(values, binary.BigEndian.Uint64(data))
+		case Float64:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(data)))
+		}
+	}
+	return values, nil
+}
+
+// Encode 把值编码成寄存器，值可以是数字或者数字字符串
+func (c Codec) Encode(values ...interface{}) ([]uint16, error) {
+	n := c.Registers()
+	if n == 0 {
+		return nil, fmt.Errorf("unsupported data type: %s", c.DataType)
+	}
+	regs := make([]uint16, 0, len(values)*n)
+	for _, value := range values {
+		data := make([]byte, n*2)
+		switch c.DataType {
+		case Float32, Float64:
+			v, err := cast.ToFloat64E(value)
+			if err != nil {
+				return nil, err
+			}
+			if c.DataType == Float32 {
+				binary.BigEndian.PutUint32(data, math.Float32bits(float32(v)))
+			} else {
+				binary.BigEndian.PutUint64(data, math.Float64bits(v))
+			}
+		default:
+			v, err := cast.ToInt64E(value)
+			if err != nil {
+				return nil, err
+			}
+			switch n {
+			case 1:
+				binary.BigEndian.PutUint16(data, uint16(v))
+			case 2:
+				binary.BigEndian.PutUint32(data, uint32(v))
+			default:
+				binary.BigEndian.PutUint64(data, uint64(v))
+			}
+		}
+		regs = append(regs, c.fromBytes(data)...)
+	}
+	return regs, nil
+}
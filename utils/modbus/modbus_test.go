@@ -0,0 +1,100 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modbus
+
+import (
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestFunction(t *testing.T) {
+	f, err := ParseFunction("ReadHoldingRegisters")
+	assert.Nil(t, err)
+	assert.Equal(t, ReadHoldingRegisters, f)
+	assert.Equal(t, "readHoldingRegisters", f.String())
+	assert.True(t, f.IsRead())
+	assert.False(t, f.IsBit())
+	assert.True(t, WriteMultipleCoils.IsBit())
+	assert.False(t, WriteMultipleCoils.IsRead())
+	_, err = ParseFunction("readFifo")
+	assert.Equal(t, "unsupported modbus function: readFifo", err.Error())
+	assert.Equal(t, "function(99)", Function(99).String())
+
+	err = &Exception{Function: ReadCoils, Code: IllegalDataAddress}
+	assert.Equal(t, "modbus exception 2 (illegal data address) for readCoils", err.Error())
+}
+
+func TestCodec(t *testing.T) {
+	_, err := NewCodec("int128", "", "")
+	assert.Equal(t, "unsupported data type: int128", err.Error())
+	_, err = NewCodec("", "middle", "")
+	assert.Equal(t, "unsupported byte order: middle", err.Error())
+	_, err = NewCodec("", "", "middle")
+	assert.Equal(t, "unsupported word order: middle", err.Error())
+
+	c, err := NewCodec("", "", "")
+	assert.Nil(t, err)
+	assert.Equal(t, Uint16, c.DataType)
+	assert.Equal(t, 1, c.Registers())
+
+	tests := []struct {
+		dataType  string
+		byteOrder string
+		wordOrder string
+		value     interface{}
+		regs      []uint16
+	}{
+		{Int16, BigEndian, BigEndian, int16(-2), []uint16{0xFFFE}},
+		{Uint16, LittleEndian, BigEndian, uint16(0x1234), []uint16{0x3412}},
+		{Int32, BigEndian, BigEndian, int32(-100000), []uint16{0xFFFE, 0x7960}},
+		{Uint32, BigEndian, LittleEndian, uint32(0x12345678), []uint16{0x5678, 0x1234}},
+		//ABCD
+		{Float32, BigEndian, BigEndian, float32(12.5), []uint16{0x4148, 0x0000}},
+		//CDAB
+		{Float32, BigEndian, LittleEndian, float32(12.5), []uint16{0x0000, 0x4148}},
+		//BADC
+		{Float32, LittleEndian, BigEndian, float32(12.5), []uint16{0x4841, 0x0000}},
+		//DCBA
+		{Float32, LittleEndian, LittleEndian, float32(12.5), []uint16{0x0000, 0x4841}},
+		{Int64, BigEndian, BigEndian, int64(-1), []uint16{0xFFFF, 0xFFFF, 0xFFFF, 0xFFFF}},
+		{Uint64, BigEndian, LittleEndian, uint64(0x0001000200030004), []uint16{4, 3, 2, 1}},
+		{Float64, BigEndian, BigEndian, float64(1), []uint16{0x3FF0, 0, 0, 0}},
+	}
+	for _, item := range tests {
+		c, err := NewCodec(item.dataType, item.byteOrder, item.wordOrder)
+		assert.Nil(t, err)
+		regs, err := c.Encode(item.value)
+		assert.Nil(t, err)
+		assert.Equal(t, item.regs, regs)
+		values, err := c.Decode(item.regs)
+		assert.Nil(t, err)
+		assert.Equal(t, []interface{}{item.value}, values)
+	}
+
+	c, _ = NewCodec(Float32, "", "")
+	values, err := c.Decode([]uint16{0x4148, 0, 0xC148, 0})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{float32(12.5), float32(-12.5)}, values)
+	_, err = c.Decode([]uint16{0x4148})
+	assert.Equal(t, "1 registers can not be decoded as float32", err.Error())
+	regs, err := c.Encode("1.5", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []uint16{0x3FC0, 0, 0x4000, 0}, regs)
+	_, err = c.Encode("abc")
+	assert.NotNil(t, err)
+}
@@ -26,11 +26,13 @@
 // - Handler: Struct for defining subscription handlers.
 //
 // The package supports features such as:
-// - TLS/SSL connections
-// - Authentication with username and password
-// - Automatic reconnection
-// - QoS levels for publishing and subscribing
-// - Custom message handlers for subscriptions
+//   - TLS/SSL connections
+//   - Authentication with username and password
+//   - Automatic reconnection
+//   - QoS levels for publishing and subscribing
+//   - Custom message handlers for subscriptions
+//   - MQTT 5 (ProtocolVersion=5, built on github.com/eclipse/paho.golang): user properties,
+//     request/response, shared subscriptions and reason codes
 //
 // This package is crucial for components that require MQTT communication,
 // such as the MqttNode in the external package.
@@ -59,6 +61,7 @@ type Handler struct {
 	//订阅Qos
 	Qos byte
 	//接收订阅数据 处理
+	//MQTT 5 模式下 c 为 nil，data 为 *Message，可以通过 Properties() 获取消息属性
 	Handle func(c paho.Client, data paho.Message)
 }
 
@@ -79,6 +82,8 @@ type Config struct {
 	CAFile      string
 	CertFile    string
 	CertKeyFile string
+	//ProtocolVersion 协议版本，3：MQTT 3.1，4：MQTT 3.1.1，5：MQTT 5，默认4
+	ProtocolVersion uint
 }
 
 // Client mqtt客户端
@@ -86,6 +91,8 @@ type Client struct {
 	sync.RWMutex
 	wg     sync.WaitGroup
 	client paho.Client
+	//v5 MQTT 5 客户端，ProtocolVersion 为5时使用
+	v5 *v5Client
 	//订阅主题和处理器映射
 	msgHandlerMap map[string]Handler
 	// 连接状态标识 (0=未连接, 1=已连接)
@@ -102,16 +109,51 @@ func NewClient(ctx context.Context, conf Config) (*Client, error) {
 		isConnected:   0, // 初始化为未连接状态
 	}
 
+	if conf.MaxReconnectInterval <= 0 {
+		conf.MaxReconnectInterval = time.Second * 60
+	}
+	switch conf.ProtocolVersion {
+	case 0, 3, 4, ProtocolVersion5:
+	default:
+		return nil, fmt.Errorf("unsupported mqtt protocol version: %d", conf.ProtocolVersion)
+	}
+	tlsconfig, err := newTLSConfig(conf.CAFile, conf.CertFile, conf.CertKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading mqtt certificate files,ca_cert=%s,tls_cert=%s,tls_key=%s", conf.CAFile, conf.CertFile, conf.CertKeyFile)
+	}
+	clientID := conf.ClientID
+	if clientID == "" {
+		//随机clientId
+		clientID = "rulego/" + string2.RandomStr(8)
+	}
+	if conf.ProtocolVersion == ProtocolVersion5 {
+		if _, _, err := parseServer(conf.Server); err != nil {
+			return nil, err
+		}
+		b.v5 = newV5Client(conf, tlsconfig, clientID)
+		b.v5.onConnect = func() {
+			b.onConnected(nil)
+		}
+		b.v5.onConnectionLost = func(err error) {
+			b.onConnectionLost(nil, err)
+		}
+		b.v5.onMessage = b.onMessage
+		if err := connectWithRetry(ctx, conf.MaxReconnectInterval, b.v5.connect); err != nil {
+			_ = b.v5.close()
+			return nil, err
+		}
+		atomic.StoreInt32(&b.isConnected, 1)
+		return &b, nil
+	}
+
 	opts := paho.NewClientOptions()
 	opts.AddBroker(conf.Server)
 	opts.SetUsername(conf.Username)
 	opts.SetPassword(conf.Password)
 	opts.SetCleanSession(conf.CleanSession)
-	if conf.ClientID == "" {
-		//随机clientId
-		opts.SetClientID("rulego/" + string2.RandomStr(8))
-	} else {
-		opts.SetClientID(conf.ClientID)
+	opts.SetClientID(clientID)
+	if conf.ProtocolVersion != 0 {
+		opts.SetProtocolVersion(conf.ProtocolVersion)
 	}
 
 	// 设置回调函数
@@ -121,52 +163,60 @@ func NewClient(ctx context.Context, conf Config) (*Client, error) {
 
 	// 配置自动重连
 	opts.SetAutoReconnect(true)
-	if conf.MaxReconnectInterval <= 0 {
-		conf.MaxReconnectInterval = time.Second * 60
-	}
 	opts.SetMaxReconnectInterval(conf.MaxReconnectInterval)
 
-	tlsconfig, err := newTLSConfig(conf.CAFile, conf.CertFile, conf.CertKeyFile)
-	if err != nil {
-		return nil, fmt.Errorf("error loading mqtt certificate files,ca_cert=%s,tls_cert=%s,tls_key=%s", conf.CAFile, conf.CertFile, conf.CertKeyFile)
-	}
 	//tls
 	if tlsconfig != nil {
 		opts.SetTLSConfig(tlsconfig)
 	}
 	b.client = paho.NewClient(opts)
 
-	// 初始连接重试逻辑，使用指数退避策略
+	err = connectWithRetry(ctx, conf.MaxReconnectInterval, func(ctx context.Context) error {
+		token := b.client.Connect()
+		token.Wait()
+		return token.Error()
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 连接成功，设置连接状态
+	atomic.StoreInt32(&b.isConnected, 1)
+	return &b, nil
+}
+
+// connectWithRetry 初始连接重试逻辑，使用指数退避策略
+// 服务端通过原因码拒绝连接时不再重试
+func connectWithRetry(ctx context.Context, maxReconnectInterval time.Duration, connect func(ctx context.Context) error) error {
 	maxRetries := 5
 	retryInterval := time.Second * 2
 
 	for i := 0; i < maxRetries; i++ {
-		if token := b.client.Connect(); token.Wait() && token.Error() != nil {
-			select {
-			case <-ctx.Done():
-				// context被取消或超时，返回错误
-				return nil, ctx.Err()
-			case <-time.After(retryInterval):
-				// 指数退避：每次重试间隔增加50%
-				retryInterval = time.Duration(float64(retryInterval) * 1.5)
-				if retryInterval > conf.MaxReconnectInterval {
-					retryInterval = conf.MaxReconnectInterval
-				}
+		err := connect(ctx)
+		if err == nil {
+			return nil
+		}
+		var reasonCodeErr *ReasonCodeError
+		if errors.As(err, &reasonCodeErr) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			// context被取消或超时，返回错误
+			return ctx.Err()
+		case <-time.After(retryInterval):
+			// 指数退避：每次重试间隔增加50%
+			retryInterval = time.Duration(float64(retryInterval) * 1.5)
+			if retryInterval > maxReconnectInterval {
+				retryInterval = maxReconnectInterval
 			}
-		} else {
-			// 连接成功，设置连接状态
-			atomic.StoreInt32(&b.isConnected, 1)
-			return &b, nil
 		}
 	}
 
 	// 达到最大重试次数，返回最后一次连接错误
-	if token := b.client.Connect(); token.Wait() && token.Error() != nil {
-		return nil, fmt.Errorf("failed to connect after %d retries: %v", maxRetries, token.Error())
+	if err := connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect after %d retries: %v", maxRetries, err)
 	}
-
-	atomic.StoreInt32(&b.isConnected, 1)
-	return &b, nil
+	return nil
 }
 
 // RegisterHandler 注册订阅数据处理器
@@ -187,6 +237,14 @@ func (b *Client) UnregisterHandler(topic string) error {
 		return nil // Already unregistered, no error
 	}
 
+	if b.v5 != nil {
+		if err := b.v5.unsubscribe(topic); err != nil && err != ErrNotConnected {
+			return err
+		}
+		delete(b.msgHandlerMap, topic)
+		return nil
+	}
+
 	if token := b.client.Unsubscribe(topic); token.Wait() && token.Error() != nil {
 		return token.Error()
 	} else {
@@ -211,6 +269,14 @@ func (b *Client) Close() error {
 	}
 	b.RUnlock()
 
+	if b.v5 != nil {
+		for _, v := range handlers {
+			_ = b.v5.unsubscribe(v.Topic)
+		}
+		atomic.StoreInt32(&b.isConnected, 0)
+		return b.v5.close()
+	}
+
 	// Unsubscribe from all topics without holding locks
 	for _, v := range handlers {
 		b.client.Unsubscribe(v.Topic)
@@ -226,12 +292,23 @@ func (b *Client) IsConnected() bool {
 
 // Publish 发布数据
 func (b *Client) Publish(topic string, qos byte, data []byte) error {
+	return b.PublishWithProperties(topic, qos, false, data, nil)
+}
+
+// PublishWithProperties 发布数据并携带 MQTT 5 消息属性
+// MQTT 3.1/3.1.1 模式下忽略消息属性
+// MQTT 5 模式下服务端返回的失败原因码以 *ReasonCodeError 返回
+func (b *Client) PublishWithProperties(topic string, qos byte, retained bool, data []byte, props *Properties) error {
 	// 检查连接状态
 	if !b.IsConnected() {
-		return errors.New("MQTT client is not connected")
+		return ErrNotConnected
 	}
 
-	token := b.client.Publish(topic, qos, false, data)
+	if b.v5 != nil {
+		return b.v5.publish(topic, qos, retained, data, props)
+	}
+
+	token := b.client.Publish(topic, qos, retained, data)
 	// 使用5秒超时等待发布完成
 	if !token.WaitTimeout(5 * time.Second) {
 		return errors.New("publish timeout after 5 seconds")
@@ -267,6 +344,20 @@ func (b *Client) subscribe() {
 
 func (b *Client) subscribeHandler(handler Handler) {
 	topic := handler.Topic
+	if b.v5 != nil {
+		//未连接时由重连后的重新订阅处理
+		for {
+			err := b.v5.subscribe(topic, handler.Qos)
+			if err == nil || err == ErrNotConnected {
+				return
+			}
+			select {
+			case <-b.v5.done:
+				return
+			case <-time.After(2 * time.Second):
+			}
+		}
+	}
 	for {
 		if token := b.client.Subscribe(topic, handler.Qos, handler.Handle).(*paho.SubscribeToken); token.Wait() && (token.Error() != nil || is128Err(token, topic)) { //128 ACK错误
 			time.Sleep(2 * time.Second)
@@ -276,6 +367,21 @@ func (b *Client) subscribeHandler(handler Handler) {
 	}
 }
 
+// onMessage MQTT 5 消息回调，分发到所有匹配的处理器
+func (b *Client) onMessage(msg *Message) {
+	b.RLock()
+	var handlers []Handler
+	for _, handler := range b.msgHandlerMap {
+		if handler.Handle != nil && matchTopic(handler.Topic, msg.Topic()) {
+			handlers = append(handlers, handler)
+		}
+	}
+	b.RUnlock()
+	for _, handler := range handlers {
+		handler.Handle(nil, msg)
+	}
+}
+
 // 判断是否是acl 128错误
 func is128Err(token *paho.SubscribeToken, topic string) bool {
 	result, ok := token.Result()[topic]
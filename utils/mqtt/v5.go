@@ -0,0 +1,697 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eclipse/paho.golang/packets"
+	paho5 "github.com/eclipse/paho.golang/paho"
+)
+
+// ProtocolVersion5 MQTT 5 协议版本
+const ProtocolVersion5 uint = 5
+
+const (
+	// defaultKeepAlive 默认心跳间隔
+	defaultKeepAlive = 30 * time.Second
+	// requestTimeout 等待确认报文的超时时间
+	requestTimeout = 5 * time.Second
+	// reconnectTimeout 重连时建立连接的超时时间
+	reconnectTimeout = 10 * time.Second
+	// persistentSessionExpiry 不清理会话时的会话过期时间，单位秒
+	persistentSessionExpiry uint32 = 0xFFFFFFFF
+	// sharePrefix 共享订阅前缀
+	sharePrefix = "$share/"
+)
+
+// ErrNotConnected 客户端未连接
+var ErrNotConnected = errors.New("MQTT client is not connected")
+
+// ErrClientClosed 客户端已关闭
+var ErrClientClosed = errors.New("MQTT client is closed")
+
+// ReasonCode MQTT 5 原因码
+type ReasonCode byte
+
+const (
+	Success                           ReasonCode = 0x00
+	GrantedQoS1                       ReasonCode = 0x01
+	GrantedQoS2                       ReasonCode = 0x02
+	NoMatchingSubscribers             ReasonCode = 0x10
+	NoSubscriptionExisted             ReasonCode = 0x11
+	UnspecifiedError                  ReasonCode = 0x80
+	MalformedPacket                   ReasonCode = 0x81
+	ProtocolError                     ReasonCode = 0x82
+	ImplementationSpecificError       ReasonCode = 0x83
+	UnsupportedProtocolVersion        ReasonCode = 0x84
+	ClientIdentifierNotValid          ReasonCode = 0x85
+	BadUserNameOrPassword             ReasonCode = 0x86
+	NotAuthorized                     ReasonCode = 0x87
+	ServerUnavailable                 ReasonCode = 0x88
+	ServerBusy                        ReasonCode = 0x89
+	Banned                            ReasonCode = 0x8A
+	ServerShuttingDown                ReasonCode = 0x8B
+	KeepAliveTimeout                  ReasonCode = 0x8D
+	SessionTakenOver                  ReasonCode = 0x8E
+	TopicFilterInvalid                ReasonCode = 0x8F
+	TopicNameInvalid                  ReasonCode = 0x90
+	PacketIdentifierInUse             ReasonCode = 0x91
+	PacketIdentifierNotFound          ReasonCode = 0x92
+	ReceiveMaximumExceeded            ReasonCode = 0x93
+	TopicAliasInvalid                 ReasonCode = 0x94
+	PacketTooLarge                    ReasonCode = 0x95
+	QuotaExceeded                     ReasonCode = 0x97
+	PayloadFormatInvalid              ReasonCode = 0x99
+	RetainNotSupported                ReasonCode = 0x9A
+	QoSNotSupported                   ReasonCode = 0x9B
+	SharedSubscriptionsNotSupported   ReasonCode = 0x9E
+	WildcardSubscriptionsNotSupported ReasonCode = 0xA2
+)
+
+var reasonCodeNames = map[ReasonCode]string{
+	Success:                           "success",
+	GrantedQoS1:                       "granted qos 1",
+	GrantedQoS2:                       "granted qos 2",
+	NoMatchingSubscribers:             "no matching subscribers",
+	NoSubscriptionExisted:             "no subscription existed",
+	UnspecifiedError:                  "unspecified error",
+	MalformedPacket:                   "malformed packet",
+	ProtocolError:                     "protocol error",
+	ImplementationSpecificError:       "implementation specific error",
+	UnsupportedProtocolVersion:        "unsupported protocol version",
+	ClientIdentifierNotValid:          "client identifier not valid",
+	BadUserNameOrPassword:             "bad user name or password",
+	NotAuthorized:                     "not authorized",
+	ServerUnavailable:                 "server unavailable",
+	ServerBusy:                        "server busy",
+	Banned:                            "banned",
+	ServerShuttingDown:                "server shutting down",
+	KeepAliveTimeout:                  "keep alive timeout",
+	SessionTakenOver:                  "session taken over",
+	TopicFilterInvalid:                "topic filter invalid",
+	TopicNameInvalid:                  "topic name invalid",
+	PacketIdentifierInUse:             "packet identifier in use",
+	PacketIdentifierNotFound:          "packet identifier not found",
+	ReceiveMaximumExceeded:            "receive maximum exceeded",
+	TopicAliasInvalid:                 "topic alias invalid",
+	PacketTooLarge:                    "packet too large",
+	QuotaExceeded:                     "quota exceeded",
+	PayloadFormatInvalid:              "payload format invalid",
+	RetainNotSupported:                "retain not supported",
+	QoSNotSupported:                   "qos not supported",
+	SharedSubscriptionsNotSupported:   "shared subscriptions not supported",
+	WildcardSubscriptionsNotSupported: "wildcard subscriptions not supported",
+}
+
+func (c ReasonCode) String() string {
+	if name, ok := reasonCodeNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("reason code 0x%02X", byte(c))
+}
+
+// IsError 原因码大于等于0x80表示失败
+func (c ReasonCode) IsError() bool {
+	return c >= UnspecifiedError
+}
+
+// ReasonCodeError 服务端返回的失败原因码，例如连接被拒绝、没有发布或者订阅权限
+type ReasonCodeError struct {
+	// Packet 返回原因码的报文，例如 connack、puback、suback
+	Packet string
+	// Code 原因码
+	Code ReasonCode
+	// Reason 服务端返回的原因字符串
+	Reason string
+}
+
+func (e *ReasonCodeError) Error() string {
+	msg := fmt.Sprintf("mqtt %s reason code 0x%02X (%s)", e.Packet, byte(e.Code), e.Code)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// UserProperty MQTT 5 用户属性
+type UserProperty struct {
+	Key   string
+	Value string
+}
+
+// Properties MQTT 5 消息属性
+type Properties struct {
+	// PayloadFormat 负荷格式，0：未指定的字节，1：UTF-8 字符串
+	PayloadFormat byte
+	// MessageExpiry 消息过期时间，单位秒，0表示不过期
+	MessageExpiry uint32
+	// ContentType 内容类型，例如 application/json
+	ContentType string
+	// ResponseTopic 请求/响应模式中接收响应的主题
+	ResponseTopic string
+	// CorrelationData 请求/响应模式中用于关联请求和响应的数据
+	CorrelationData []byte
+	// User 用户属性，同一个键可以出现多次
+	User []UserProperty
+}
+
+// Message MQTT 5 消息，实现 paho.Message 接口。Duplicate 总是返回false，QoS2 的重复投递由协议层处理
+type Message struct {
+	topic      string
+	payload    []byte
+	qos        byte
+	retained   bool
+	duplicate  bool
+	messageID  uint16
+	properties Properties
+}
+
+func (m *Message) Duplicate() bool {
+	return m.duplicate
+}
+
+func (m *Message) Qos() byte {
+	return m.qos
+}
+
+func (m *Message) Retained() bool {
+	return m.retained
+}
+
+func (m *Message) Topic() string {
+	return m.topic
+}
+
+func (m *Message) MessageID() uint16 {
+	return m.messageID
+}
+
+func (m *Message) Payload() []byte {
+	return m.payload
+}
+
+// Ack 确认报文由客户端在处理器返回后自动发送
+func (m *Message) Ack() {
+}
+
+// Properties 消息属性
+func (m *Message) Properties() *Properties {
+	return &m.properties
+}
+
+// splitShare 拆分共享订阅 $share/{group}/{filter}，非共享订阅 group 为空
+func splitShare(filter string) (group string, topicFilter string) {
+	if !strings.HasPrefix(filter, sharePrefix) {
+		return "", filter
+	}
+	rest := filter[len(sharePrefix):]
+	if i := strings.IndexByte(rest, '/'); i > 0 {
+		return rest[:i], rest[i+1:]
+	}
+	return "", filter
+}
+
+// matchTopic 判断主题是否匹配订阅的主题过滤器，支持 + 和 # 通配符以及共享订阅
+func matchTopic(filter, topic string) bool {
+	_, filter = splitShare(filter)
+	//通配符不匹配以$开头的主题
+	if strings.HasPrefix(topic, "$") && (strings.HasPrefix(filter, "+") || strings.HasPrefix(filter, "#")) {
+		return false
+	}
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return i == len(filterLevels)-1
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
+
+// v5Client MQTT 5 客户端连接，协议处理（编解码、确认、心跳、主题别名）使用 github.com/eclipse/paho.golang，
+// 这里负责建立网络连接、断线重连以及与 Client 的属性和原因码转换
+type v5Client struct {
+	conf      Config
+	tlsConfig *tls.Config
+	clientID  string
+	// onConnect 连接成功回调，用于重连后重新订阅
+	onConnect func()
+	// onConnectionLost 连接断开回调
+	onConnectionLost func(err error)
+	// onMessage 收到消息回调
+	onMessage func(msg *Message)
+
+	//queue 按接收顺序等待处理的消息，由 dispatch 协程逐条处理并在处理完成后确认
+	queue        []delivery
+	queueLock    sync.Mutex
+	queueReady   chan struct{}
+	dispatchOnce sync.Once
+
+	mu     sync.Mutex
+	client *paho5.Client
+	maxQoS byte
+	closed bool
+	done   chan struct{}
+}
+
+func newV5Client(conf Config, tlsConfig *tls.Config, clientID string) *v5Client {
+	return &v5Client{
+		conf:       conf,
+		tlsConfig:  tlsConfig,
+		clientID:   clientID,
+		queueReady: make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// delivery 等待处理的消息和接收该消息的连接
+type delivery struct {
+	client  *paho5.Client
+	publish *paho5.Publish
+	msg     *Message
+}
+
+// parseServer 解析服务端地址，支持 tcp://、mqtt://、ssl://、tls://、mqtts:// 前缀，没有前缀时使用 tcp
+func parseServer(server string) (useTLS bool, address string, err error) {
+	scheme, address := "tcp", server
+	if i := strings.Index(address, "://"); i >= 0 {
+		scheme, address = strings.ToLower(address[:i]), address[i+3:]
+	}
+	switch scheme {
+	case "tcp", "mqtt":
+		return false, address, nil
+	case "ssl", "tls", "mqtts", "tcps":
+		return true, address, nil
+	default:
+		return false, "", fmt.Errorf("unsupported mqtt 5 server scheme: %s", scheme)
+	}
+}
+
+// dial 建立网络连接，配置了证书或者使用 TLS 前缀时使用 TLS 连接
+func (c *v5Client) dial(ctx context.Context) (net.Conn, error) {
+	useTLS, address, err := parseServer(c.conf.Server)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{}
+	if !useTLS && c.tlsConfig == nil {
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+	config := c.tlsConfig
+	if config == nil {
+		config = &tls.Config{}
+	}
+	return (&tls.Dialer{NetDialer: dialer, Config: config}).DialContext(ctx, "tcp", address)
+}
+
+// connect 建立连接并完成 CONNECT/CONNACK 握手，服务端拒绝连接时返回 *ReasonCodeError
+func (c *v5Client) connect(ctx context.Context) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	var client *paho5.Client
+	client = paho5.NewClient(paho5.ClientConfig{
+		//tls.Conn 并发写不安全
+		Conn: packets.NewThreadSafeConn(conn),
+		Router: paho5.NewSingleHandlerRouter(func(p *paho5.Publish) {
+			c.handlePublish(client, p)
+		}),
+		PacketTimeout: requestTimeout,
+		PingHandler:   newPinger(),
+		//消息处理完成后再发送确认报文
+		EnableManualAcknowledgment: true,
+		OnClientError: func(err error) {
+			c.connectionLost(client, err)
+		},
+		OnServerDisconnect: func(d *paho5.Disconnect) {
+			err := &ReasonCodeError{Packet: "disconnect", Code: ReasonCode(d.ReasonCode)}
+			if d.Properties != nil {
+				err.Reason = d.Properties.ReasonString
+			}
+			c.connectionLost(client, err)
+		},
+	})
+	cp := &paho5.Connect{
+		ClientID:     c.clientID,
+		KeepAlive:    uint16(defaultKeepAlive / time.Second),
+		CleanStart:   c.conf.CleanSession,
+		Username:     c.conf.Username,
+		UsernameFlag: c.conf.Username != "",
+		Password:     []byte(c.conf.Password),
+		PasswordFlag: c.conf.Password != "",
+	}
+	if !c.conf.CleanSession {
+		expiry := persistentSessionExpiry
+		cp.Properties = &paho5.ConnectProperties{SessionExpiryInterval: &expiry}
+	}
+	ack, err := client.Connect(ctx, cp)
+	if err != nil {
+		if ack != nil && ReasonCode(ack.ReasonCode).IsError() {
+			rcErr := &ReasonCodeError{Packet: "connack", Code: ReasonCode(ack.ReasonCode)}
+			if ack.Properties != nil {
+				rcErr.Reason = ack.Properties.ReasonString
+			}
+			return rcErr
+		}
+		return err
+	}
+	maxQoS := byte(2)
+	if ack.Properties != nil && ack.Properties.MaximumQoS != nil {
+		maxQoS = *ack.Properties.MaximumQoS
+	}
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		_ = client.Disconnect(&paho5.Disconnect{})
+		return ErrClientClosed
+	}
+	if ack.Properties != nil && ack.Properties.AssignedClientID != "" {
+		c.clientID = ack.Properties.AssignedClientID
+	}
+	c.client = client
+	c.maxQoS = maxQoS
+	c.mu.Unlock()
+	return nil
+}
+
+// handlePublish 服务端发布的消息。消息加入队列后立即返回，避免阻塞 paho 的读取协程，
+// 由 dispatch 协程按接收顺序处理，保证同一主题的消息顺序，处理完成后再确认
+func (c *v5Client) handlePublish(client *paho5.Client, p *paho5.Publish) {
+	if c.onMessage == nil {
+		_ = client.Ack(p)
+		return
+	}
+	msg := &Message{
+		topic:     p.Topic,
+		payload:   p.Payload,
+		qos:       p.QoS,
+		retained:  p.Retain,
+		messageID: p.PacketID,
+	}
+	if p.Properties != nil {
+		msg.properties = fromPublishProperties(p.Properties)
+	}
+	c.dispatchOnce.Do(func() {
+		go c.dispatch()
+	})
+	c.queueLock.Lock()
+	c.queue = append(c.queue, delivery{client: client, publish: p, msg: msg})
+	c.queueLock.Unlock()
+	select {
+	case c.queueReady <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch 按接收顺序逐条处理队列中的消息，直到客户端关闭
+func (c *v5Client) dispatch() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-c.queueReady:
+		}
+		for {
+			c.queueLock.Lock()
+			if len(c.queue) == 0 {
+				c.queueLock.Unlock()
+				break
+			}
+			d := c.queue[0]
+			c.queue[0] = delivery{}
+			c.queue = c.queue[1:]
+			c.queueLock.Unlock()
+			c.onMessage(d.msg)
+			//连接已断开时确认失败，服务端会重新投递 QoS1 和 QoS2 消息
+			_ = d.client.Ack(d.publish)
+		}
+	}
+}
+
+// connectionLost 连接断开，开始重连
+func (c *v5Client) connectionLost(client *paho5.Client, err error) {
+	c.mu.Lock()
+	if c.client != client {
+		c.mu.Unlock()
+		return
+	}
+	c.client = nil
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return
+	}
+	if c.onConnectionLost != nil {
+		c.onConnectionLost(err)
+	}
+	go c.reconnect()
+}
+
+// reconnect 指数退避重连，直到连接成功或者客户端关闭
+func (c *v5Client) reconnect() {
+	interval := time.Second
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-time.After(interval):
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), reconnectTimeout)
+		err := c.connect(ctx)
+		cancel()
+		if err == nil {
+			if c.onConnect != nil {
+				c.onConnect()
+			}
+			return
+		}
+		interval *= 2
+		if interval > c.conf.MaxReconnectInterval {
+			interval = c.conf.MaxReconnectInterval
+		}
+	}
+}
+
+// current 当前连接，未连接返回nil
+func (c *v5Client) current() (*paho5.Client, byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.client, c.maxQoS
+}
+
+// publish 发布消息，QoS1和QoS2等待服务端确认，QoS 超过服务端支持的最大值时降级
+func (c *v5Client) publish(topic string, qos byte, retained bool, payload []byte, props *Properties) error {
+	client, maxQoS := c.current()
+	if client == nil {
+		return ErrNotConnected
+	}
+	if qos > maxQoS {
+		qos = maxQoS
+	}
+	resp, err := client.Publish(context.Background(), &paho5.Publish{
+		Topic:      topic,
+		QoS:        qos,
+		Retain:     retained,
+		Payload:    payload,
+		Properties: toPublishProperties(props),
+	})
+	//QoS2 的 PUBREC 失败原因码不返回错误，统一转换为 *ReasonCodeError
+	if resp != nil && ReasonCode(resp.ReasonCode).IsError() {
+		packet := "puback"
+		if qos == 2 {
+			packet = "pubrec"
+		}
+		rcErr := &ReasonCodeError{Packet: packet, Code: ReasonCode(resp.ReasonCode)}
+		if resp.Properties != nil {
+			rcErr.Reason = resp.Properties.ReasonString
+		}
+		return rcErr
+	}
+	return err
+}
+
+// subscribe 订阅主题
+func (c *v5Client) subscribe(filter string, qos byte) error {
+	client, _ := c.current()
+	if client == nil {
+		return ErrNotConnected
+	}
+	ack, err := client.Subscribe(context.Background(), &paho5.Subscribe{
+		Subscriptions: []paho5.SubscribeOptions{{Topic: filter, QoS: qos}},
+	})
+	if ack == nil {
+		return err
+	}
+	if len(ack.Reasons) != 1 {
+		return &ReasonCodeError{Packet: "suback", Code: ProtocolError}
+	}
+	if code := ReasonCode(ack.Reasons[0]); code.IsError() {
+		rcErr := &ReasonCodeError{Packet: "suback", Code: code}
+		if ack.Properties != nil {
+			rcErr.Reason = ack.Properties.ReasonString
+		}
+		return rcErr
+	}
+	return nil
+}
+
+// unsubscribe 取消订阅
+func (c *v5Client) unsubscribe(filter string) error {
+	client, _ := c.current()
+	if client == nil {
+		return ErrNotConnected
+	}
+	ack, err := client.Unsubscribe(context.Background(), &paho5.Unsubscribe{Topics: []string{filter}})
+	if ack == nil {
+		return err
+	}
+	if len(ack.Reasons) == 1 && ReasonCode(ack.Reasons[0]).IsError() {
+		rcErr := &ReasonCodeError{Packet: "unsuback", Code: ReasonCode(ack.Reasons[0])}
+		if ack.Properties != nil {
+			rcErr.Reason = ack.Properties.ReasonString
+		}
+		return rcErr
+	}
+	return nil
+}
+
+// close 发送 DISCONNECT 并关闭连接，停止重连
+func (c *v5Client) close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	client := c.client
+	c.client = nil
+	c.mu.Unlock()
+	if client == nil {
+		return nil
+	}
+	return client.Disconnect(&paho5.Disconnect{})
+}
+
+// pinger 心跳处理。paho 默认的 PingHandler 在 Start 中创建停止通道，连接后立即关闭时 Stop 会被忽略，
+// Disconnect 需要等待一个检查周期才返回，这里在创建时初始化停止通道
+type pinger struct {
+	stop     chan struct{}
+	once     sync.Once
+	awaiting atomic.Bool
+}
+
+func newPinger() *pinger {
+	return &pinger{stop: make(chan struct{})}
+}
+
+// Start 每个心跳周期发送 PINGREQ，上一个 PINGREQ 没有响应时关闭连接，由 paho 报告连接断开
+func (p *pinger) Start(conn net.Conn, keepAlive time.Duration) {
+	if keepAlive <= 0 {
+		return
+	}
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			if p.awaiting.Load() {
+				_ = conn.Close()
+				return
+			}
+			p.awaiting.Store(true)
+			if _, err := packets.NewControlPacket(packets.PINGREQ).WriteTo(conn); err != nil {
+				_ = conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (p *pinger) Stop() {
+	p.once.Do(func() {
+		close(p.stop)
+	})
+}
+
+func (p *pinger) PingResp() {
+	p.awaiting.Store(false)
+}
+
+func (p *pinger) SetDebug(paho5.Logger) {
+}
+
+// toPublishProperties 转换为 paho 的消息属性
+func toPublishProperties(props *Properties) *paho5.PublishProperties {
+	if props == nil {
+		return nil
+	}
+	p := &paho5.PublishProperties{
+		ContentType:     props.ContentType,
+		ResponseTopic:   props.ResponseTopic,
+		CorrelationData: props.CorrelationData,
+	}
+	if props.PayloadFormat != 0 {
+		payloadFormat := props.PayloadFormat
+		p.PayloadFormat = &payloadFormat
+	}
+	if props.MessageExpiry != 0 {
+		messageExpiry := props.MessageExpiry
+		p.MessageExpiry = &messageExpiry
+	}
+	for _, item := range props.User {
+		p.User.Add(item.Key, item.Value)
+	}
+	return p
+}
+
+// fromPublishProperties 转换 paho 的消息属性
+func fromPublishProperties(p *paho5.PublishProperties) Properties {
+	props := Properties{
+		ContentType:     p.ContentType,
+		ResponseTopic:   p.ResponseTopic,
+		CorrelationData: p.CorrelationData,
+	}
+	if p.PayloadFormat != nil {
+		props.PayloadFormat = *p.PayloadFormat
+	}
+	if p.MessageExpiry != nil {
+		props.MessageExpiry = *p.MessageExpiry
+	}
+	for _, item := range p.User {
+		props.User = append(props.User, UserProperty{Key: item.Key, Value: item.Value})
+	}
+	return props
+}
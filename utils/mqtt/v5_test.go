@@ -0,0 +1,235 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mqtt
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/test/mqtttest"
+)
+
+func newV5TestClient(t *testing.T, broker *mqtttest.Broker, clientID string) *Client {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+	defer cancel()
+	client, err := NewClient(ctx, Config{
+		Server:               broker.Addr().String(),
+		ClientID:             clientID,
+		CleanSession:         true,
+		MaxReconnectInterval: time.Second,
+		ProtocolVersion:      ProtocolVersion5,
+	})
+	assert.Nil(t, err)
+	return client
+}
+
+// receive 订阅主题，收到的消息写入通道
+func receive(client *Client, topic string, qos byte) chan *Message {
+	msgs := make(chan *Message, 10)
+	client.RegisterHandler(Handler{Topic: topic, Qos: qos, Handle: func(c paho.Client, data paho.Message) {
+		msgs <- data.(*Message)
+	}})
+	return msgs
+}
+
+func waitMessage(t *testing.T, msgs chan *Message) *Message {
+	select {
+	case msg := <-msgs:
+		return msg
+	case <-time.After(time.Second * 3):
+		t.Fatal("receive message timeout")
+		return nil
+	}
+}
+
+func TestClientV5(t *testing.T) {
+	broker := mqtttest.NewBroker()
+	broker.Deny("denied/topic")
+	assert.Nil(t, broker.Start("127.0.0.1:0"))
+	defer broker.Close()
+
+	publisher := newV5TestClient(t, broker, "publisher")
+	defer publisher.Close()
+	subscriber := newV5TestClient(t, broker, "")
+	defer subscriber.Close()
+	assert.True(t, subscriber.IsConnected())
+
+	t.Run("Properties", func(t *testing.T) {
+		msgs := receive(subscriber, "device/+/req", 2)
+		props := &Properties{
+			PayloadFormat:   1,
+			MessageExpiry:   30,
+			ContentType:     "application/json",
+			ResponseTopic:   "device/1/resp",
+			CorrelationData: []byte("c1"),
+			User:            []UserProperty{{Key: "deviceId", Value: "1"}},
+		}
+		for qos := byte(0); qos <= 2; qos++ {
+			assert.Nil(t, publisher.PublishWithProperties("device/1/req", qos, false, []byte(`{"temperature":20}`), props))
+			msg := waitMessage(t, msgs)
+			assert.Equal(t, "device/1/req", msg.Topic())
+			assert.Equal(t, `{"temperature":20}`, string(msg.Payload()))
+			assert.Equal(t, qos, msg.Qos())
+			assert.Equal(t, props, msg.Properties())
+		}
+		assert.Nil(t, subscriber.UnregisterHandler("device/+/req"))
+		//没有订阅者不是错误
+		assert.Nil(t, publisher.Publish("device/1/req", 1, []byte("a")))
+		select {
+		case <-msgs:
+			t.Fatal("unsubscribed topic received message")
+		case <-time.After(time.Millisecond * 100):
+		}
+	})
+
+	t.Run("Order", func(t *testing.T) {
+		msgs := make(chan string, 10)
+		subscriber.RegisterHandler(Handler{Topic: "order/+", Qos: 1, Handle: func(c paho.Client, data paho.Message) {
+			//第一条消息处理较慢，后续消息仍然按接收顺序处理
+			if string(data.Payload()) == "0" {
+				time.Sleep(time.Millisecond * 100)
+			}
+			msgs <- string(data.Payload())
+		}})
+		for i := 0; i < 5; i++ {
+			assert.Nil(t, publisher.Publish("order/1", 1, []byte(strconv.Itoa(i))))
+		}
+		for i := 0; i < 5; i++ {
+			select {
+			case payload := <-msgs:
+				assert.Equal(t, strconv.Itoa(i), payload)
+			case <-time.After(time.Second * 3):
+				t.Fatal("receive message timeout")
+			}
+		}
+		assert.Nil(t, subscriber.UnregisterHandler("order/+"))
+	})
+
+	t.Run("SharedSubscription", func(t *testing.T) {
+		other := newV5TestClient(t, broker, "other")
+		defer other.Close()
+		msgs1 := receive(subscriber, "$share/g1/job/+", 1)
+		msgs2 := receive(other, "$share/g1/job/+", 1)
+		for i := 0; i < 4; i++ {
+			assert.Nil(t, publisher.Publish("job/run", 1, []byte("job")))
+		}
+		time.Sleep(time.Millisecond * 200)
+		//同一个组的消息在订阅者之间分配
+		assert.Equal(t, 2, len(msgs1))
+		assert.Equal(t, 2, len(msgs2))
+		assert.Equal(t, "job/run", waitMessage(t, msgs1).Topic())
+	})
+
+	t.Run("ReasonCode", func(t *testing.T) {
+		err := publisher.Publish("denied/topic", 1, []byte("a"))
+		assert.Equal(t, &ReasonCodeError{Packet: "puback", Code: NotAuthorized}, err)
+		err = publisher.Publish("denied/topic", 2, []byte("a"))
+		assert.Equal(t, &ReasonCodeError{Packet: "pubrec", Code: NotAuthorized}, err)
+		assert.Equal(t, &ReasonCodeError{Packet: "suback", Code: NotAuthorized}, publisher.v5.subscribe("denied/topic", 0))
+		//QoS0没有确认报文
+		assert.Nil(t, publisher.Publish("denied/topic", 0, []byte("a")))
+	})
+
+	t.Run("Reconnect", func(t *testing.T) {
+		msgs := receive(subscriber, "reconnect/+", 1)
+		broker.CloseConns()
+		time.Sleep(time.Millisecond * 100)
+		assert.False(t, subscriber.IsConnected())
+		err := subscriber.Publish("reconnect/1", 1, []byte("a"))
+		assert.Equal(t, ErrNotConnected, err)
+		//重连后重新订阅
+		deadline := time.Now().Add(time.Second * 3)
+		for !(subscriber.IsConnected() && publisher.IsConnected()) && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond * 50)
+		}
+		assert.True(t, subscriber.IsConnected())
+		time.Sleep(time.Millisecond * 100)
+		assert.Nil(t, publisher.Publish("reconnect/1", 1, []byte("a")))
+		assert.Equal(t, "a", string(waitMessage(t, msgs).Payload()))
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		client := newV5TestClient(t, broker, "closed")
+		assert.Nil(t, client.Close())
+		assert.False(t, client.IsConnected())
+		assert.Equal(t, ErrNotConnected, client.Publish("a", 0, []byte("a")))
+		assert.Nil(t, client.Close())
+	})
+}
+
+func TestNewClientV5Error(t *testing.T) {
+	broker := mqtttest.NewBroker()
+	broker.SetCredentials("user", "pass")
+	assert.Nil(t, broker.Start("127.0.0.1:0"))
+	defer broker.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*4)
+	defer cancel()
+	//认证失败不重试
+	start := time.Now()
+	_, err := NewClient(ctx, Config{Server: broker.Addr().String(), Username: "user", Password: "wrong", ProtocolVersion: ProtocolVersion5})
+	assert.Equal(t, &ReasonCodeError{Packet: "connack", Code: BadUserNameOrPassword}, err)
+	assert.True(t, time.Since(start) < time.Second)
+
+	client, err := NewClient(ctx, Config{Server: "tcp://" + broker.Addr().String(), Username: "user", Password: "pass", ProtocolVersion: ProtocolVersion5})
+	assert.Nil(t, err)
+	assert.Nil(t, client.Close())
+
+	_, err = NewClient(ctx, Config{Server: "ws://" + broker.Addr().String(), ProtocolVersion: ProtocolVersion5})
+	assert.Equal(t, "unsupported mqtt 5 server scheme: ws", err.Error())
+	_, err = NewClient(ctx, Config{Server: broker.Addr().String(), ProtocolVersion: 6})
+	assert.Equal(t, "unsupported mqtt protocol version: 6", err.Error())
+}
+
+func TestMatchTopic(t *testing.T) {
+	for _, item := range []struct {
+		filter string
+		topic  string
+		match  bool
+	}{
+		{"a/b", "a/b", true},
+		{"a/b", "a/c", false},
+		{"a/+", "a/b", true},
+		{"a/+", "a/b/c", false},
+		{"a/#", "a", true},
+		{"a/#", "a/b/c", true},
+		{"#", "a/b", true},
+		{"+/+", "/a", true},
+		{"#", "$SYS/broker", false},
+		{"$SYS/#", "$SYS/broker", true},
+		{"$share/g1/a/+", "a/b", true},
+		{"$share/g1/a/+", "b/b", false},
+		{"$share/g1", "$share/g1", true},
+	} {
+		assert.Equal(t, item.match, matchTopic(item.filter, item.topic), item.filter+" "+item.topic)
+	}
+	group, filter := splitShare("$share/g1/a/#")
+	assert.Equal(t, "g1", group)
+	assert.Equal(t, "a/#", filter)
+}
+
+func TestReasonCode(t *testing.T) {
+	assert.Equal(t, "not authorized", NotAuthorized.String())
+	assert.Equal(t, "reason code 0xFE", ReasonCode(0xFE).String())
+	assert.False(t, NoMatchingSubscribers.IsError())
+	assert.True(t, NotAuthorized.IsError())
+	assert.Equal(t, "mqtt puback reason code 0x87 (not authorized): denied", (&ReasonCodeError{Packet: "puback", Code: NotAuthorized, Reason: "denied"}).Error())
+}
@@ -0,0 +1,143 @@
+//go:build with_grpc
+
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package protofile loads protobuf descriptors at runtime from .proto files or
+// descriptor sets, so gRPC components and endpoints can work without generated code.
+//
+// Package protofile 在运行时从.proto文件或描述集加载protobuf描述，
+// 使gRPC组件和端点无需生成代码即可工作。只在使用 with_grpc 编译标签时编译。
+package protofile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ErrNoSource is returned by Load when neither a .proto file nor a descriptor set is given.
+var ErrNoSource = errors.New("protoFile or descriptorSet must be set")
+
+// Load loads descriptors from a descriptor set if descriptorSet is not empty, otherwise compiles protoFile.
+// Load 如果 descriptorSet 不为空则从描述集加载，否则编译 protoFile。
+func Load(protoFile string, importPaths []string, descriptorSet string) (*protoregistry.Files, error) {
+	if descriptorSet != "" {
+		return LoadDescriptorSet(descriptorSet)
+	} else if protoFile != "" {
+		return Compile(protoFile, importPaths)
+	}
+	return nil, ErrNoSource
+}
+
+// Compile compiles a .proto file and its imports. Imports are searched in importPaths,
+// the current directory is used if it is empty. Well-known types such as google/protobuf/timestamp.proto are built in.
+// Compile 编译.proto文件及其依赖。在 importPaths 中查找依赖，为空则使用当前目录。内置 google/protobuf/timestamp.proto 等标准类型。
+func Compile(protoFile string, importPaths []string) (*protoregistry.Files, error) {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: importPaths,
+		}),
+	}
+	compiled, err := compiler.Compile(context.Background(), protoFile)
+	if err != nil {
+		return nil, err
+	}
+	files := new(protoregistry.Files)
+	for _, file := range compiled {
+		if err := registerFile(files, file); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// LoadDescriptorSet loads a binary FileDescriptorSet, for example generated by
+// protoc --descriptor_set_out --include_imports.
+// LoadDescriptorSet 加载二进制 FileDescriptorSet，例如通过 protoc --descriptor_set_out --include_imports 生成。
+func LoadDescriptorSet(path string) (*protoregistry.Files, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	return protodesc.NewFiles(&set)
+}
+
+// FindService finds a service by its fully qualified name, for example helloworld.Greeter.
+// FindService 通过服务全名查找服务，例如 helloworld.Greeter。
+func FindService(files *protoregistry.Files, service string) (protoreflect.ServiceDescriptor, error) {
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found: %w", service, err)
+	}
+	result, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+	return result, nil
+}
+
+// FindMethod finds a method of a service.
+// FindMethod 查找服务的方法。
+func FindMethod(files *protoregistry.Files, service, method string) (protoreflect.MethodDescriptor, error) {
+	serviceDescriptor, err := FindService(files, service)
+	if err != nil {
+		return nil, err
+	}
+	result := serviceDescriptor.Methods().ByName(protoreflect.Name(method))
+	if result == nil {
+		return nil, fmt.Errorf("method %s not found in service %s", method, service)
+	}
+	return result, nil
+}
+
+// FullMethodName returns the gRPC full method name, for example /helloworld.Greeter/SayHello.
+// FullMethodName 返回gRPC方法全路径，例如 /helloworld.Greeter/SayHello。
+func FullMethodName(method protoreflect.MethodDescriptor) string {
+	return fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+}
+
+// IsStreaming returns whether the method is a client or server streaming method.
+// IsStreaming 返回是否为客户端或服务端流方法。
+func IsStreaming(method protoreflect.MethodDescriptor) bool {
+	return method.IsStreamingClient() || method.IsStreamingServer()
+}
+
+// registerFile 注册文件及其依赖
+func registerFile(files *protoregistry.Files, file protoreflect.FileDescriptor) error {
+	if _, err := files.FindFileByPath(file.Path()); err == nil {
+		return nil
+	}
+	imports := file.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if err := registerFile(files, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	return files.RegisterFile(file)
+}
@@ -0,0 +1,78 @@
+//go:build with_grpc
+
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protofile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const testProtoDir = "../../testdata/proto"
+
+func TestLoad(t *testing.T) {
+	_, err := Load("", nil, "")
+	assert.Equal(t, ErrNoSource, err)
+
+	files, err := Load("greeter.proto", []string{testProtoDir}, "")
+	assert.Nil(t, err)
+	method, err := FindMethod(files, "helloworld.Greeter", "SayHello")
+	assert.Nil(t, err)
+	assert.Equal(t, "/helloworld.Greeter/SayHello", FullMethodName(method))
+	assert.False(t, IsStreaming(method))
+
+	method, err = FindMethod(files, "helloworld.Greeter", "SayHelloStream")
+	assert.Nil(t, err)
+	assert.True(t, IsStreaming(method))
+
+	_, err = FindMethod(files, "helloworld.Greeter", "NotFound")
+	assert.Equal(t, "method NotFound not found in service helloworld.Greeter", err.Error())
+	_, err = FindService(files, "helloworld.HelloRequest")
+	assert.Equal(t, "helloworld.HelloRequest is not a service", err.Error())
+	_, err = FindService(files, "helloworld.NotFound")
+	assert.NotNil(t, err)
+
+	_, err = Load("notFound.proto", []string{testProtoDir}, "")
+	assert.NotNil(t, err)
+
+	// 描述集
+	set := &descriptorpb.FileDescriptorSet{}
+	files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(file))
+		return true
+	})
+	data, err := proto.Marshal(set)
+	assert.Nil(t, err)
+	path := filepath.Join(t.TempDir(), "greeter.pb")
+	assert.Nil(t, os.WriteFile(path, data, 0644))
+
+	files, err = Load("", nil, path)
+	assert.Nil(t, err)
+	_, err = FindMethod(files, "helloworld.Greeter", "SayHello")
+	assert.Nil(t, err)
+
+	_, err = Load("", nil, filepath.Join(t.TempDir(), "notFound.pb"))
+	assert.NotNil(t, err)
+}
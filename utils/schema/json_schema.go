@@ -18,6 +18,11 @@ package schema
 
 import (
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/rulego/rulego/utils/str"
 )
 
 // JSONSchema 定义了 JSON Schema 的结构
@@ -39,13 +44,15 @@ func (s JSONSchema) CheckFieldIsRequired(fieldName string) bool {
 
 // FieldSchema 定义了单个字段的 Schema
 type FieldSchema struct {
-	Type        string                 `json:"type"`        //类型
-	Title       string                 `json:"title"`       //标题
-	Description string                 `json:"description"` //描述
-	Default     interface{}            `json:"default"`     //默认值
-	Properties  map[string]FieldSchema `json:"properties"`  // 嵌套字段
-	Required    []string               `json:"required"`    // 嵌套字段的必填列表
-	Component   map[string]interface{} `json:"component"`   //前端表单组件配置
+	Type        string                 `json:"type"`            //类型
+	Title       string                 `json:"title"`           //标题
+	Description string                 `json:"description"`     //描述
+	Default     interface{}            `json:"default"`         //默认值
+	Properties  map[string]FieldSchema `json:"properties"`      // 嵌套字段
+	Required    []string               `json:"required"`        // 嵌套字段的必填列表
+	Component   map[string]interface{} `json:"component"`       //前端表单组件配置
+	Items       *FieldSchema           `json:"items,omitempty"` // 数组元素的Schema
+	Enum        []interface{}          `json:"enum,omitempty"`  // 允许的值
 }
 
 // Data 定义了 JSON 数据的结构
@@ -102,3 +109,127 @@ func validateFieldType(value interface{}, fieldType string) error {
 	}
 	return nil
 }
+
+// ValidationError 校验错误
+type ValidationError struct {
+	// Field 字段路径，例如：device.tags[0]，根节点为空
+	Field string `json:"field"`
+	// Message 错误信息
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// Validate 使用 Schema 校验JSON解析后的数据，支持嵌套对象、数组元素和枚举，返回所有校验错误
+func (s FieldSchema) Validate(value interface{}) []ValidationError {
+	return s.validate("", value, nil)
+}
+
+func (s FieldSchema) validate(field string, value interface{}, errs []ValidationError) []ValidationError {
+	if s.Type != "" && !matchType(value, s.Type) {
+		return append(errs, ValidationError{Field: field, Message: fmt.Sprintf("expected %s, got %s", s.Type, jsonType(value))})
+	}
+	if len(s.Enum) > 0 {
+		var found bool
+		for _, item := range s.Enum {
+			if str.ToString(item) == str.ToString(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf("must be one of %s", str.ToString(s.Enum))})
+		}
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, ValidationError{Field: joinField(field, name), Message: "is required"})
+			}
+		}
+		//按字段名排序，保证错误顺序稳定
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if item, ok := v[name]; ok {
+				errs = s.Properties[name].validate(joinField(field, name), item, errs)
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				errs = s.Items.validate(field+"["+strconv.Itoa(i)+"]", item, errs)
+			}
+		}
+	}
+	return errs
+}
+
+// joinField 拼接字段路径
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// matchType 值是否符合JSON Schema类型
+func matchType(value interface{}, fieldType string) bool {
+	switch fieldType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		v, ok := value.(float64)
+		return ok && v == math.Trunc(v)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "null":
+		return value == nil
+	default:
+		//不支持的类型不做校验
+		return true
+	}
+}
+
+// jsonType 返回值的JSON类型
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
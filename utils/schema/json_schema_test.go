@@ -221,3 +221,68 @@ func TestDataStruct(t *testing.T) {
 		t.Errorf("Expected key1 to be 'value1', got %v", data.Properties["key1"])
 	}
 }
+
+func TestFieldSchemaValidate(t *testing.T) {
+	schema := FieldSchema{
+		Type: "object",
+		Properties: map[string]FieldSchema{
+			"name":   {Type: "string"},
+			"age":    {Type: "integer"},
+			"weight": {Type: "number"},
+			"status": {Type: "string", Enum: []interface{}{"online", "offline"}},
+			"tags":   {Type: "array", Items: &FieldSchema{Type: "string"}},
+			"address": {
+				Type: "object",
+				Properties: map[string]FieldSchema{
+					"street": {Type: "string"},
+				},
+				Required: []string{"street"},
+			},
+		},
+		Required: []string{"name"},
+	}
+	tests := []struct {
+		name string
+		data interface{}
+		want []string
+	}{
+		{
+			name: "Valid data",
+			data: map[string]interface{}{"name": "John", "age": float64(30), "weight": 61.5, "status": "online", "tags": []interface{}{"a"}},
+		},
+		{
+			name: "Not an object",
+			data: []interface{}{},
+			want: []string{"expected object, got array"},
+		},
+		{
+			name: "Nested errors",
+			data: map[string]interface{}{
+				"age":     30.5,
+				"status":  "unknown",
+				"tags":    []interface{}{"a", float64(1)},
+				"address": map[string]interface{}{},
+			},
+			want: []string{
+				"name: is required",
+				"address.street: is required",
+				"age: expected integer, got number",
+				`status: must be one of ["online","offline"]`,
+				"tags[1]: expected string, got integer",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := schema.Validate(tt.data)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Validate() errors = %v, want %v", errs, tt.want)
+			}
+			for i, err := range errs {
+				if err.Error() != tt.want[i] {
+					t.Errorf("Validate() error[%d] = %v, want %v", i, err.Error(), tt.want[i])
+				}
+			}
+		})
+	}
+}
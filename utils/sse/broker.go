@@ -0,0 +1,288 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package sse provides an in-process Server-Sent Events broker. The sse endpoint subscribes
+// clients to channels and the ssePublish component publishes rule chain output to them.
+// Each channel keeps a bounded buffer of recent events, so a client can resume with Last-Event-ID.
+// Channels without subscribers are deleted after an idle timeout.
+//
+// Package sse 提供进程内的 Server-Sent Events 代理。sse 端点把客户端订阅到通道，
+// ssePublish 组件把规则链输出发布给客户端。每个通道保存有限长度的最近事件，客户端可以通过 Last-Event-ID 断点续传。
+// 没有订阅者的通道空闲超时后删除。
+package sse
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBufferSize 每个通道默认保存的最近事件数量
+	DefaultBufferSize = 100
+	// DefaultClientBufferSize 每个客户端默认的待发送事件队列长度，队列满的客户端会被断开，由客户端重连续传
+	DefaultClientBufferSize = 64
+	// DefaultIdleTimeout 没有订阅者的通道默认保留时间，超时后删除通道和缓存事件
+	DefaultIdleTimeout = 5 * time.Minute
+)
+
+// DefaultBroker 默认代理，sse 端点和 ssePublish 组件默认使用该代理
+var DefaultBroker = NewBroker(DefaultBufferSize)
+
+// Event SSE事件
+type Event struct {
+	// ID 事件ID，为空则发布时使用通道内自增序号
+	ID string `json:"id"`
+	// Event 事件类型，为空则客户端触发 message 事件
+	Event string `json:"event"`
+	// Data 事件数据，多行数据按行发送
+	Data string `json:"data"`
+	// Metadata 事件元数据，不发送给客户端，用于按客户端过滤
+	Metadata map[string]string `json:"metadata"`
+}
+
+// Encode 按 text/event-stream 格式编码事件
+func (e Event) Encode() []byte {
+	var buf bytes.Buffer
+	if e.ID != "" {
+		buf.WriteString("id: ")
+		buf.WriteString(singleLine(e.ID))
+		buf.WriteByte('\n')
+	}
+	if e.Event != "" {
+		buf.WriteString("event: ")
+		buf.WriteString(singleLine(e.Event))
+		buf.WriteByte('\n')
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(e.Data, "\r\n", "\n"), "\n") {
+		buf.WriteString("data: ")
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// singleLine 去掉换行，id和event字段不能换行
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// Match 事件元数据是否满足过滤条件，过滤条件的每个键值都要相等
+func (e Event) Match(filter map[string]string) bool {
+	for k, v := range filter {
+		if e.Metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Subscriber 通道订阅者
+type Subscriber struct {
+	channel string
+	filter  map[string]string
+	events  chan Event
+	once    sync.Once
+}
+
+// Channel 订阅的通道
+func (s *Subscriber) Channel() string {
+	return s.channel
+}
+
+// Events 事件队列，订阅取消或者客户端处理太慢被断开时关闭
+func (s *Subscriber) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscriber) close() {
+	s.once.Do(func() {
+		close(s.events)
+	})
+}
+
+// channel 通道
+type channel struct {
+	// seq 自增事件序号
+	seq uint64
+	// buffer 最近事件，长度不超过 Broker.bufferSize
+	buffer []Event
+	// subscribers 订阅者
+	subscribers map[*Subscriber]struct{}
+	// idleSince 最后一个订阅者取消或者没有订阅者时最后发布的时间
+	idleSince time.Time
+}
+
+// Broker SSE代理，管理通道、订阅者和最近事件
+type Broker struct {
+	bufferSize       int
+	clientBufferSize int
+	idleTimeout      time.Duration
+	channels         map[string]*channel
+	// lastSweep 最后一次清理空闲通道的时间
+	lastSweep time.Time
+	lock      sync.Mutex
+}
+
+// NewBroker 创建代理，bufferSize 为每个通道保存的最近事件数量
+func NewBroker(bufferSize int) *Broker {
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+	return &Broker{
+		bufferSize:       bufferSize,
+		clientBufferSize: DefaultClientBufferSize,
+		idleTimeout:      DefaultIdleTimeout,
+		channels:         make(map[string]*channel),
+		lastSweep:        time.Now(),
+	}
+}
+
+// SetIdleTimeout 设置没有订阅者的通道保留时间，通道在这段时间内保留缓存事件供客户端重连续传
+func (b *Broker) SetIdleTimeout(idleTimeout time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.idleTimeout = idleTimeout
+}
+
+// sweep 删除空闲超时的通道，每个超时周期最多执行一次，调用方需持有锁
+func (b *Broker) sweep(now time.Time) {
+	if now.Sub(b.lastSweep) < b.idleTimeout {
+		return
+	}
+	b.lastSweep = now
+	for id, ch := range b.channels {
+		if len(ch.subscribers) == 0 && now.Sub(ch.idleSince) >= b.idleTimeout {
+			delete(b.channels, id)
+		}
+	}
+}
+
+// getChannel 获取通道，不存在则创建，调用方需持有锁
+func (b *Broker) getChannel(id string) *channel {
+	ch, ok := b.channels[id]
+	if !ok {
+		ch = &channel{subscribers: make(map[*Subscriber]struct{})}
+		b.channels[id] = ch
+	}
+	return ch
+}
+
+// Publish 发布事件到通道，返回分配了ID的事件和接收的订阅者数量。
+// 处理太慢、队列已满的订阅者会被断开，客户端可以使用 Last-Event-ID 重连续传
+func (b *Broker) Publish(channelId string, event Event) (Event, int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	now := time.Now()
+	b.sweep(now)
+	ch := b.getChannel(channelId)
+	ch.seq++
+	if event.ID == "" {
+		event.ID = strconv.FormatUint(ch.seq, 10)
+	}
+	if b.bufferSize > 0 {
+		if len(ch.buffer) >= b.bufferSize {
+			ch.buffer = append(ch.buffer[:0], ch.buffer[len(ch.buffer)-b.bufferSize+1:]...)
+		}
+		ch.buffer = append(ch.buffer, event)
+	}
+	var count int
+	for sub := range ch.subscribers {
+		if !event.Match(sub.filter) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+			count++
+		default:
+			delete(ch.subscribers, sub)
+			sub.close()
+		}
+	}
+	if len(ch.subscribers) == 0 {
+		ch.idleSince = now
+	}
+	return event, count
+}
+
+// Subscribe 订阅通道，只接收元数据满足 filter 的事件。
+// lastEventId 不为空时先补发缓存中该事件之后的事件，缓存中找不到该事件则补发所有缓存事件
+func (b *Broker) Subscribe(channelId string, lastEventId string, filter map[string]string) *Subscriber {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.sweep(time.Now())
+	ch := b.getChannel(channelId)
+	var replay []Event
+	if lastEventId != "" {
+		replay = ch.buffer
+		for i := len(ch.buffer) - 1; i >= 0; i-- {
+			if ch.buffer[i].ID == lastEventId {
+				replay = ch.buffer[i+1:]
+				break
+			}
+		}
+	}
+	size := b.clientBufferSize
+	if len(replay) > size {
+		size = len(replay)
+	}
+	sub := &Subscriber{channel: channelId, filter: filter, events: make(chan Event, size)}
+	for _, event := range replay {
+		if event.Match(filter) {
+			sub.events <- event
+		}
+	}
+	ch.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe 取消订阅并关闭事件队列
+func (b *Broker) Unsubscribe(sub *Subscriber) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if ch, ok := b.channels[sub.channel]; ok {
+		delete(ch.subscribers, sub)
+		if len(ch.subscribers) == 0 {
+			ch.idleSince = time.Now()
+		}
+	}
+	sub.close()
+}
+
+// Subscribers 返回通道订阅者数量
+func (b *Broker) Subscribers(channelId string) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if ch, ok := b.channels[channelId]; ok {
+		return len(ch.subscribers)
+	}
+	return 0
+}
+
+// Remove 删除通道和缓存事件，并断开所有订阅者
+func (b *Broker) Remove(channelId string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if ch, ok := b.channels[channelId]; ok {
+		for sub := range ch.subscribers {
+			sub.close()
+		}
+		delete(b.channels, channelId)
+	}
+}
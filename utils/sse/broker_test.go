@@ -0,0 +1,156 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sse
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/test/assert"
+)
+
+// receive 读取队列中已有的事件
+func receive(sub *Subscriber) []Event {
+	var events []Event
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	assert.Equal(t, "data: hello\n\n", string(Event{Data: "hello"}.Encode()))
+	assert.Equal(t, "id: 1\nevent: alarm\ndata: a\ndata: b\n\n", string(Event{ID: "1", Event: "alarm", Data: "a\r\nb"}.Encode()))
+	assert.Equal(t, "id: 12\ndata: \n\n", string(Event{ID: "1\n2"}.Encode()))
+}
+
+func TestBroker(t *testing.T) {
+	t.Run("Publish", func(t *testing.T) {
+		broker := NewBroker(10)
+		sub := broker.Subscribe("ch1", "", nil)
+		assert.Equal(t, 1, broker.Subscribers("ch1"))
+		event, count := broker.Publish("ch1", Event{Data: "a"})
+		assert.Equal(t, "1", event.ID)
+		assert.Equal(t, 1, count)
+		event, _ = broker.Publish("ch1", Event{ID: "custom", Data: "b"})
+		assert.Equal(t, "custom", event.ID)
+		_, count = broker.Publish("ch2", Event{Data: "c"})
+		assert.Equal(t, 0, count)
+
+		events := receive(sub)
+		assert.Equal(t, 2, len(events))
+		assert.Equal(t, "a", events[0].Data)
+		assert.Equal(t, "b", events[1].Data)
+
+		broker.Unsubscribe(sub)
+		assert.Equal(t, 0, broker.Subscribers("ch1"))
+		_, ok := <-sub.Events()
+		assert.False(t, ok)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		broker := NewBroker(10)
+		sub := broker.Subscribe("ch1", "", map[string]string{"deviceId": "d1"})
+		_, count := broker.Publish("ch1", Event{Data: "a", Metadata: map[string]string{"deviceId": "d2"}})
+		assert.Equal(t, 0, count)
+		_, count = broker.Publish("ch1", Event{Data: "b", Metadata: map[string]string{"deviceId": "d1"}})
+		assert.Equal(t, 1, count)
+		events := receive(sub)
+		assert.Equal(t, 1, len(events))
+		assert.Equal(t, "b", events[0].Data)
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		broker := NewBroker(3)
+		for i := 1; i <= 5; i++ {
+			broker.Publish("ch1", Event{Data: strconv.Itoa(i), Metadata: map[string]string{"odd": strconv.FormatBool(i%2 == 1)}})
+		}
+		//只保存最近3个事件
+		events := receive(broker.Subscribe("ch1", "3", nil))
+		assert.Equal(t, 2, len(events))
+		assert.Equal(t, "4", events[0].ID)
+		assert.Equal(t, "5", events[1].ID)
+
+		//找不到事件补发所有缓存事件
+		events = receive(broker.Subscribe("ch1", "1", nil))
+		assert.Equal(t, 3, len(events))
+		assert.Equal(t, "3", events[0].ID)
+
+		//补发的事件也按元数据过滤
+		events = receive(broker.Subscribe("ch1", "1", map[string]string{"odd": "true"}))
+		assert.Equal(t, 2, len(events))
+		assert.Equal(t, "5", events[1].ID)
+
+		//不指定lastEventId不补发
+		assert.Equal(t, 0, len(receive(broker.Subscribe("ch1", "", nil))))
+	})
+
+	t.Run("SlowSubscriber", func(t *testing.T) {
+		broker := NewBroker(0)
+		broker.clientBufferSize = 2
+		sub := broker.Subscribe("ch1", "", nil)
+		for i := 0; i < 3; i++ {
+			broker.Publish("ch1", Event{Data: strconv.Itoa(i)})
+		}
+		assert.Equal(t, 0, broker.Subscribers("ch1"))
+		assert.Equal(t, 2, len(receive(sub)))
+		//断开后重复取消订阅
+		broker.Unsubscribe(sub)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		broker := NewBroker(10)
+		sub := broker.Subscribe("ch1", "", nil)
+		broker.Publish("ch1", Event{Data: "a"})
+		broker.Remove("ch1")
+		assert.Equal(t, 0, broker.Subscribers("ch1"))
+		assert.Equal(t, 1, len(receive(sub)))
+		assert.Equal(t, 0, len(receive(broker.Subscribe("ch1", "1", nil))))
+	})
+
+	t.Run("IdleChannel", func(t *testing.T) {
+		broker := NewBroker(10)
+		broker.SetIdleTimeout(time.Millisecond * 50)
+		sub := broker.Subscribe("ch1", "", nil)
+		//没有订阅者的通道
+		for i := 0; i < 10; i++ {
+			broker.Publish("device/"+strconv.Itoa(i), Event{Data: "a"})
+		}
+		assert.Equal(t, 11, len(broker.channels))
+		time.Sleep(time.Millisecond * 60)
+		broker.Publish("ch1", Event{Data: "a"})
+		assert.Equal(t, 1, len(broker.channels))
+
+		//取消订阅后在超时时间内保留缓存事件，客户端可以重连续传
+		broker.Unsubscribe(sub)
+		resumed := broker.Subscribe("ch1", "0", nil)
+		assert.Equal(t, 1, len(receive(resumed)))
+		broker.Unsubscribe(resumed)
+		time.Sleep(time.Millisecond * 60)
+		broker.Publish("ch2", Event{Data: "a"})
+		_, ok := broker.channels["ch1"]
+		assert.False(t, ok)
+	})
+}
@@ -224,8 +224,10 @@ func CheckHasVar(str string) bool {
 }
 
 // ConvertDollarPlaceholder 转postgres风格占位符
+// postgres 驱动把 ? 转换成 $1,$2... ；mysql、sqlite 等驱动原生支持 ? 占位符，保持不变
 func ConvertDollarPlaceholder(sql, dbType string) string {
-	if dbType == "postgres" {
+	switch dbType {
+	case "postgres", "pgx":
 		n := 1
 		for strings.Contains(sql, "?") {
 			sql = strings.Replace(sql, "?", fmt.Sprintf("$%d", n), 1)
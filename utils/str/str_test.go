@@ -209,7 +209,10 @@ func TestCheckHasVar(t *testing.T) {
 func TestConvertDollarPlaceholder(t *testing.T) {
 	sql := "select * from user where name=? and age=?"
 	assert.Equal(t, "select * from user where name=$1 and age=$2", ConvertDollarPlaceholder(sql, "postgres"))
+	assert.Equal(t, "select * from user where name=$1 and age=$2", ConvertDollarPlaceholder(sql, "pgx"))
 	assert.Equal(t, sql, ConvertDollarPlaceholder(sql, "mysql"))
+	assert.Equal(t, sql, ConvertDollarPlaceholder(sql, "sqlite"))
+	assert.Equal(t, sql, ConvertDollarPlaceholder(sql, "sqlite3"))
 }
 
 func TestToLowerFirst(t *testing.T) {